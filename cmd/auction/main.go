@@ -2,23 +2,54 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fullcycle-auction_go/configuration/config"
 	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
 	"fullcycle-auction_go/internal/infra/api/web/controller/auction_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/auth_controller"
 	"fullcycle-auction_go/internal/infra/api/web/controller/bid_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/category_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/events_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/health_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/live_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/rating_controller"
+	"fullcycle-auction_go/internal/infra/api/web/controller/report_controller"
 	"fullcycle-auction_go/internal/infra/api/web/controller/user_controller"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+	"fullcycle-auction_go/internal/infra/api/web/response"
 	"fullcycle-auction_go/internal/infra/database/auction"
-	"fullcycle-auction_go/internal/infra/database/bid"
-	"fullcycle-auction_go/internal/infra/database/user"
+	"fullcycle-auction_go/internal/infra/messaging"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/infra/tracing"
 	"fullcycle-auction_go/internal/usecase/auction_usecase"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
+	"fullcycle-auction_go/internal/usecase/category_usecase"
+	"fullcycle-auction_go/internal/usecase/rating_usecase"
+	"fullcycle-auction_go/internal/usecase/report_usecase"
 	"fullcycle-auction_go/internal/usecase/user_usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
+// outbidEventsBufferSize bounds the outbid events waiting for the live hub
+const outbidEventsBufferSize = 256
+
+// migrate runs the data migrations and exits instead of serving
+var migrate = flag.Bool("migrate", false, "run the data migrations and exit")
+
 func main() {
+	flag.Parse()
 	ctx := context.Background()
 
 	if err := godotenv.Load("cmd/auction/.env"); err != nil {
@@ -26,41 +57,335 @@ func main() {
 		return
 	}
 
-	databaseConnection, err := mongodb.NewMongoDBConnection(ctx)
+	// The configuration is read once; changing it takes a restart
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n" + err.Error())
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	databaseConnection, err := mongodb.Connect(ctx, cfg.Mongo)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	if *migrate {
+		if err := runMigrations(ctx, cfg, databaseConnection); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+
+	tokenService, err := auth.NewTokenService(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL, clock.New())
 	if err != nil {
 		log.Fatal(err.Error())
 		return
 	}
 
-	router := gin.Default()
+	// Panics are recovered after RequestID, so they are logged with the request id and
+	// answered with the structured error body instead of gin's plain text 500. The access
+	// log sits between them to log the request id and the status of recovered panics.
+	// CORS answers preflights before any route is matched, and sets its headers before
+	// the handlers run so errors carry them too. Compression comes last, so the body of a
+	// recovered panic isn't held by it
+	router := gin.New()
+	router.Use(
+		tracing.Middleware(),
+		middleware.RequestID(),
+		middleware.AccessLog(cfg.Server.AccessLogSlowThreshold, cfg.Server.AccessLogExcludedPaths),
+		middleware.Recovery(),
+		middleware.CORS(cfg.Server.CORS),
+		middleware.Gzip(cfg.Server.GzipMinSize))
 
-	userController, bidController, auctionsController := initDependencies(databaseConnection)
+	// The WebSocket and SSE streams end once shutdown starts, instead of holding Shutdown
+	// until its deadline; the other requests in flight are left to finish
+	streamsCtx, cancelStreams := context.WithCancel(ctx)
+	defer cancelStreams()
 
-	router.GET("/auction", auctionsController.FindAuctions)
-	router.GET("/auction/:auctionId", auctionsController.FindAuctionById)
-	router.POST("/auction", auctionsController.CreateAuction)
-	router.GET("/auction/winner/:auctionId", auctionsController.FindWinningBidByAuctionId)
-	router.POST("/bid", bidController.CreateBid)
-	router.GET("/bid/:auctionId", bidController.FindBidByAuctionId)
-	router.GET("/user/:userId", userController.FindUserById)
+	userController, authController, categoryController, bidController, auctionsController, liveController, eventsController, reportController, healthController, ratingController, shutdownDependencies :=
+		initDependencies(ctx, streamsCtx, cfg, databaseConnection, tokenService)
+
+	// Writes act on behalf of the authenticated user; reads stay public, except for the
+	// soft-deleted auctions only admins may include
+	authenticated := middleware.Authenticate(tokenService)
+	admin := middleware.RequireAdmin(cfg.Auth.AdminUserIds)
+	includeDeleted := middleware.IncludeDeleted(tokenService, cfg.Auth.AdminUserIds)
+
+	// Bids and auction creation are limited separately, so a client creating auctions
+	// doesn't use up its bids
+	bidRateLimit := middleware.RateLimit(middleware.NewRateLimiter(clock.New(), cfg.Server.RateLimit))
+	auctionRateLimit := middleware.RateLimit(middleware.NewRateLimiter(clock.New(), cfg.Server.RateLimit))
+
+	// Requests answer with 504 once their timeout passes; batches get a longer one, and
+	// the WebSocket, SSE, metrics and CSV export routes none, as they stay open on purpose
+	timeout := middleware.Timeout(cfg.Server.RequestTimeout)
+	batchTimeout := middleware.Timeout(cfg.Server.BatchRequestTimeout)
+
+	// Probes and metrics are read by the infrastructure, not by API clients, so they stay
+	// at the root without the envelope
+	router.GET("/healthz", timeout, healthController.Healthz)
+	router.GET("/readyz", timeout, healthController.Readyz)
+	router.GET("/metrics", metrics.Handler())
+
+	// The API lives under /api/v1, answering with the response envelope. With
+	// API_LEGACY_ROUTES its routes are also registered at the root, with the bare bodies
+	// of the clients written before the envelope, until they move to /api/v1
+	registerAPI := func(api gin.IRoutes) {
+		api.GET("/auction", timeout, includeDeleted, auctionsController.FindAuctions)
+		api.GET("/auction/:auctionId", timeout, includeDeleted, auctionsController.FindAuctionById)
+		api.GET("/auction/export", authenticated, admin, auctionsController.ExportAuctions)
+		api.GET("/auction/facets", timeout, includeDeleted, auctionsController.GetAuctionFacets)
+		api.GET("/auction/category/:slug", timeout, includeDeleted, auctionsController.FindAuctionsByCategorySlug)
+		api.POST("/auction", timeout, authenticated, auctionRateLimit, auctionsController.CreateAuction)
+		api.POST("/auction/batch", batchTimeout, authenticated, auctionRateLimit, auctionsController.CreateAuctions)
+		api.GET("/auction/winner/:auctionId", timeout, includeDeleted, auctionsController.FindWinningBidByAuctionId)
+		api.GET("/auction/:auctionId/summary", timeout, includeDeleted, auctionsController.GetAuctionSummary)
+		api.GET("/auction/:auctionId/winner", timeout, includeDeleted, auctionsController.FindWinnerByAuctionId)
+		api.PATCH("/auction/:auctionId", timeout, authenticated, auctionsController.UpdateAuction)
+		api.PUT("/auction/:auctionId/images", timeout, authenticated, auctionsController.ReplaceAuctionImages)
+		api.PATCH("/auction/:auctionId/cancel", timeout, authenticated, auctionsController.CancelAuction)
+		api.POST("/auction/:auctionId/close", timeout, authenticated, admin, auctionsController.CloseAuction)
+		api.GET("/auction/:auctionId/audit", timeout, authenticated, admin, auctionsController.FindAuditByAuctionId)
+		api.DELETE("/auction/:auctionId", timeout, authenticated, admin, auctionsController.DeleteAuction)
+		api.POST("/auction/:auctionId/watch", timeout, authenticated, auctionsController.WatchAuction)
+		api.DELETE("/auction/:auctionId/watch", timeout, authenticated, auctionsController.UnwatchAuction)
+		api.POST("/auction/:auctionId/buy-now", timeout, authenticated, bidRateLimit, bidController.BuyNow)
+		api.POST("/auction/:auctionId/rating", timeout, authenticated, ratingController.CreateRating)
+		api.POST("/bid", timeout, authenticated, bidRateLimit, bidController.CreateBid)
+		api.POST("/bid/proxy", timeout, authenticated, bidRateLimit, bidController.CreateProxyBid)
+		api.GET("/bid/self-bids", timeout, authenticated, admin, bidController.CountSelfBids)
+		api.GET("/bid/export", authenticated, admin, bidController.ExportBids)
+		api.GET("/bid/:auctionId", timeout, bidController.FindBidByAuctionId)
+		api.GET("/bid/user/:userId", timeout, bidController.FindBidsByUserId)
+		api.GET("/reports/top-bidders", timeout, reportController.TopBidders)
+		api.GET("/reports/top-sellers", timeout, reportController.TopSellers)
+		api.POST("/user", timeout, userController.CreateUser)
+		api.GET("/user/:userId", timeout, userController.FindUserById)
+		api.PATCH("/user/:userId", timeout, authenticated, userController.UpdateUser)
+		api.DELETE("/user/:userId", timeout, authenticated, userController.DeleteUser)
+		api.POST("/user/:userId/deposit", timeout, authenticated, userController.Deposit)
+		api.GET("/user/:userId/auctions", timeout, includeDeleted, auctionsController.FindAuctionsBySellerId)
+		api.GET("/user/:userId/stats", timeout, userController.GetUserStats)
+		api.GET("/user/:userId/watchlist", timeout, authenticated, auctionsController.FindWatchlist)
+		api.GET("/user/:userId/ratings", timeout, ratingController.FindRatingsBySellerId)
+		api.POST("/auth/login", timeout, authController.Login)
+		api.GET("/category", timeout, categoryController.FindAllCategories)
+		api.POST("/category", timeout, authenticated, admin, categoryController.CreateCategory)
+		api.PATCH("/category/:categoryId", timeout, authenticated, admin, categoryController.RenameCategory)
+		api.GET("/admin/close-failures", timeout, authenticated, admin, auctionsController.FindCloseFailures)
+		api.POST("/admin/close-failures/:auctionId/retry", timeout, authenticated, admin, auctionsController.RetryClose)
+		api.GET("/admin/stats", timeout, authenticated, admin, reportController.DashboardStats)
+		api.GET("/admin/bid-queue", timeout, authenticated, admin, bidController.BidQueue)
+		api.GET("/ws/auction/:auctionId", liveController.SubscribeAuction)
+		api.GET("/events/auctions", eventsController.StreamAuctionEvents)
+	}
+	registerAPI(router.Group("/api/v1", response.Envelope()))
+	if cfg.Server.LegacyRoutes {
+		registerAPI(router)
+	}
+
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
+	server.RegisterOnShutdown(cancelStreams)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-signalCtx.Done()
+
+	log.Println("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Stop taking requests first, then let the background writers finish, and only then
+	// release the database connection they depend on
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Error shutting down HTTP server: " + err.Error())
+	}
+
+	shutdownDependencies(shutdownCtx)
+
+	if err := databaseConnection.Client().Disconnect(shutdownCtx); err != nil {
+		log.Println("Error disconnecting from MongoDB: " + err.Error())
+	}
 
-	router.Run(":8080")
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Println("Error flushing traces: " + err.Error())
+	}
 }
 
-func initDependencies(database *mongo.Database) (
+func initDependencies(
+	ctx, streamsCtx context.Context, cfg *config.Config, database *mongo.Database, tokens *auth.TokenService) (
 	userController *user_controller.UserController,
+	authController *auth_controller.AuthController,
+	categoryController *category_controller.CategoryController,
 	bidController *bid_controller.BidController,
-	auctionController *auction_controller.AuctionController) {
+	auctionController *auction_controller.AuctionController,
+	liveController *live_controller.LiveController,
+	eventsController *events_controller.EventsController,
+	reportController *report_controller.ReportController,
+	healthController *health_controller.HealthController,
+	ratingController *rating_controller.RatingController,
+	shutdown func(ctx context.Context)) {
+
+	// The auction timing logic, and the times the entities stamp, run on the accelerated
+	// clock in development; tokens and rate limits keep the wall clock
+	clk := clock.NewAccelerated(clock.New(), cfg.TimeAcceleration)
+	if cfg.TimeAcceleration != 1 {
+		log.Printf("Time runs %gx faster than the wall clock (TIME_ACCELERATION_FACTOR)", cfg.TimeAcceleration)
+	}
+	repos := newRepositories(ctx, cfg, database, clk)
+
+	if cfg.Auction.BackfillCategories {
+		backfillAuctionCategories(ctx, repos.auctionStore, repos.category)
+	}
 
-	auctionRepository := auction.NewAuctionRepository(database)
-	bidRepository := bid.NewBidRepository(database, auctionRepository)
-	userRepository := user.NewUserRepository(database)
+	hub := live_controller.NewHub(clk)
+	outbidNotifier := bid_usecase.NewChannelNotifier(outbidEventsBufferSize)
+	go hub.ConsumeOutbid(outbidNotifier.Events())
 
-	userController = user_controller.NewUserController(
-		user_usecase.NewUserUseCase(userRepository))
-	auctionController = auction_controller.NewAuctionController(
-		auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository))
-	bidController = bid_controller.NewBidController(bid_usecase.NewBidUseCase(bidRepository))
+	eventBus := events.NewBus(cfg.Server.EventsBufferSize)
+	auctionEvents := auction_usecase.AuctionPublisherFunc(func(auction auction_usecase.AuctionOutputDTO) {
+		eventBus.Publish(events.AuctionCreated, auction.Id, auction)
+	})
+	// Events also go to RabbitMQ when RABBITMQ_URL is set, through a queue that never
+	// holds up the requests publishing them
+	eventPublisher, shutdownEventPublisher := messaging.NewEventPublisher(cfg.Messaging)
+	go publishWatchedAuctionClosed(outbidNotifier.WatchedAuctionClosedEvents(), eventPublisher)
+	bidEvents := bid_usecase.BidPublisherFunc(func(bid bid_usecase.BidOutputDTO) {
+		eventBus.Publish(events.BidPlaced, bid.AuctionId, bid)
+		eventPublisher.Publish(events.BidPlaced, bid.AuctionId, bid)
+	})
+
+	// The closer is created first so the use case can close auctions on demand through it;
+	// its listeners are added below, before it starts
+	auctionCloser := auction.NewCloser(ctx, repos.auctionStore, clk, cfg.Auction.Closer)
+
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		repos.auction, repos.bid, repos.user, repos.category, repos.watchlist, auctionEvents, auctionCloser,
+		eventPublisher, clk, auction_usecase.Config{
+			DuplicateWindow: cfg.Auction.DuplicateWindow,
+			DurationBounds:  cfg.Auction.DurationBounds,
+		})
+
+	userUseCase := user_usecase.NewUserUseCase(repos.user, repos.bid)
+	userController = user_controller.NewUserController(userUseCase)
+	authController = auth_controller.NewAuthController(userUseCase, tokens)
+	categoryController = category_controller.NewCategoryController(
+		category_usecase.NewCategoryUseCase(repos.category, repos.auction, cfg.Auction.DurationBounds))
+	auctionController = auction_controller.NewAuctionController(auctionUseCase)
+	bidUseCase := bid_usecase.NewBidUseCase(
+		repos.bid, repos.auction, repos.user, bid_usecase.BidPublishers{hub, bidEvents}, outbidNotifier,
+		auctionCloser, clk, cfg.Bid)
+	// The bids accepted right before an auction ends may still be queued when it closes
+	repos.auctionStore.FlushBids = bidUseCase.Flush
+	metrics.WatchBidQueue(bidUseCase.QueueStats)
+	bidController = bid_controller.NewBidController(bidUseCase)
+	liveController = live_controller.NewLiveController(hub, auctionUseCase, streamsCtx)
+	go liveController.SyncCountdowns(ctx, cfg.Server.LiveTimeSyncInterval)
+	eventsController = events_controller.NewEventsController(eventBus, streamsCtx)
+	reportController = report_controller.NewReportController(
+		report_usecase.NewReportUseCase(repos.auction, repos.bid, clk, cfg.ReportCacheTTL, closeLagQuantile))
+	ratingController = rating_controller.NewRatingController(
+		rating_usecase.NewRatingUseCase(repos.rating, repos.auction, repos.user, clk))
+
+	auctionCloser.AddListener(liveController)
+	auctionCloser.AddListener(eventBus)
+	auctionCloser.AddListener(auction_usecase.NewWatchersNotifier(repos.watchlist, outbidNotifier))
+	if _, noop := eventPublisher.(events.NoopPublisher); !noop {
+		auctionCloser.AddListener(messaging.NewClosedAuctionPublisher(eventPublisher))
+	}
+	auctionCloser.Start(ctx)
+	go repos.auctionStore.RunArchival(ctx, cfg.Auction.ArchiveAfter, cfg.Auction.ArchiveInterval)
+
+	closeLagMonitor := auction.NewCloseLagMonitor(
+		repos.auctionStore, clk, cfg.Auction.CloseLagAlarm, cfg.Auction.CloseLagCheckInterval)
+	go closeLagMonitor.Run(ctx)
+
+	mongoPinger := health_controller.PingerFunc(func(ctx context.Context) error {
+		return mongodb.HealthCheck(ctx, database)
+	})
+	healthController = health_controller.NewHealthController(mongoPinger, auctionCloser, closeLagMonitor, clk)
+
+	shutdown = func(ctx context.Context) {
+		if err := bidUseCase.Shutdown(ctx); err != nil {
+			log.Println("Error flushing pending bids: " + err.Error())
+		}
+
+		if err := auctionCloser.Shutdown(ctx); err != nil {
+			log.Println("Error stopping auction closer: " + err.Error())
+		}
+
+		if err := shutdownEventPublisher(ctx); err != nil {
+			log.Println("Error flushing pending events: " + err.Error())
+		}
+	}
 
 	return
 }
+
+// runMigrations backfills the end time of the auctions created before it existed. It
+// can run with the service taking traffic, and again once done.
+func runMigrations(ctx context.Context, cfg *config.Config, database *mongo.Database) error {
+	txRunner := mongodb.NewTxRunner(ctx, database.Client())
+	auctionRepository := auction.NewAuctionRepository(
+		database, clock.New(), txRunner, cfg.Auction.Duration, cfg.DBOperationTimeout)
+
+	backfilled, err := auctionRepository.BackfillEndTimes(ctx, cfg.Auction.Duration)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Backfilled the end time of %d auctions", backfilled)
+	return nil
+}
+
+// backfillAuctionCategories renames the categories of existing auctions to the managed
+// category they match, see AuctionRepository.NormalizeCategories. Failures are logged and
+// the app starts anyway, since the backfill can simply run again on the next start.
+func backfillAuctionCategories(
+	ctx context.Context,
+	auctionRepository *auction.AuctionRepository,
+	categoryRepository category_entity.CategoryRepositoryInterface) {
+	categories, err := categoryRepository.FindAllCategories(ctx)
+	if err != nil {
+		return
+	}
+
+	normalized, err := auctionRepository.NormalizeCategories(ctx, categories)
+	if err != nil {
+		return
+	}
+
+	log.Printf("Normalized the category of %d auctions", normalized)
+}
+
+// closeLagQuantile reads the close lag recorded by the closer of this instance for the
+// admin dashboard.
+func closeLagQuantile(q float64) (float64, bool) {
+	return metrics.Quantile(metrics.AuctionCloseLag, q)
+}
+
+// publishWatchedAuctionClosed sends the watched auction closes to the message broker,
+// where a consumer such as an e-mail sender can tell each watcher.
+func publishWatchedAuctionClosed(
+	closed <-chan bid_usecase.WatchedAuctionClosedEvent, publisher events.EventPublisher) {
+	for event := range closed {
+		publisher.Publish(events.WatchedAuctionClosed, event.Auction.AuctionId, event)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"fullcycle-auction_go/configuration/config"
+	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/entity/rating_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/entity/watchlist_entity"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/bid"
+	"fullcycle-auction_go/internal/infra/database/category"
+	"fullcycle-auction_go/internal/infra/database/rating"
+	"fullcycle-auction_go/internal/infra/database/user"
+	"fullcycle-auction_go/internal/infra/database/watchlist"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// repositories are the stores the use cases are built on, as the interfaces the use
+// cases take, so an implementation can be swapped or decorated here without touching
+// them. The Mongo auction repository is kept too, for the closer and the maintenance
+// jobs that need more than the use cases do, and always see it undecorated.
+type repositories struct {
+	auctionStore *auction.AuctionRepository
+
+	auction   auction_entity.AuctionRepositoryInterface
+	bid       bid_entity.BidEntityRepository
+	user      user_entity.UserRepositoryInterface
+	category  category_entity.CategoryRepositoryInterface
+	watchlist watchlist_entity.WatchlistRepositoryInterface
+	rating    rating_entity.RatingRepositoryInterface
+}
+
+// newRepositories builds the Mongo repositories and creates their indexes. Index
+// failures are logged by the repositories; the app still serves without them.
+func newRepositories(
+	ctx context.Context, cfg *config.Config, database *mongo.Database, clk clock.Clock) *repositories {
+	txRunner := mongodb.NewTxRunner(ctx, database.Client())
+	auctionStore := newAuctionStore(ctx, cfg, database, clk, txRunner)
+
+	return &repositories{
+		auctionStore: auctionStore,
+		auction:      decorateAuctionRepository(auctionStore, auctionRepositoryDecorators()...),
+		bid:          newBidRepository(ctx, cfg, database, auctionStore, txRunner),
+		user:         newUserRepository(ctx, cfg, database),
+		category:     newCategoryRepository(ctx, cfg, database),
+		watchlist:    newWatchlistRepository(ctx, cfg, database),
+		rating:       newRatingRepository(ctx, cfg, database, txRunner),
+	}
+}
+
+// newAuctionStore, like newBidRepository, turns the timestamps stored in Unix seconds
+// into dates first, as the queries by timestamp only match dates.
+func newAuctionStore(
+	ctx context.Context, cfg *config.Config, database *mongo.Database, clk clock.Clock,
+	txRunner mongodb.TxRunner) *auction.AuctionRepository {
+	auctionRepository := auction.NewAuctionRepository(
+		database, clk, txRunner, cfg.Auction.Duration, cfg.DBOperationTimeout)
+	auctionRepository.CloseRetryInterval = cfg.Auction.CloseRetryInterval
+	auctionRepository.FacetsCacheTTL = cfg.Auction.FacetsCacheTTL
+	if backfilled, err := auctionRepository.BackfillTimestamps(ctx); err == nil && backfilled > 0 {
+		log.Printf("Backfilled the timestamps of %d auctions", backfilled)
+	}
+	auctionRepository.EnsureIndexes(ctx)
+
+	return auctionRepository
+}
+
+// newBidRepository writes the bids along with the counters of their auction, so it takes
+// the undecorated auction repository. Like newAuctionStore it converts the legacy
+// timestamps first, and the legacy float amounts into cents, which the winning bid is
+// sorted on.
+func newBidRepository(
+	ctx context.Context, cfg *config.Config, database *mongo.Database,
+	auctionStore *auction.AuctionRepository, txRunner mongodb.TxRunner) bid_entity.BidEntityRepository {
+	bidRepository := bid.NewBidRepository(database, auctionStore, txRunner, cfg.DBOperationTimeout)
+	bidRepository.ReserveBalances = cfg.Bid.BalanceMode == bid_usecase.BalanceReserve
+	if backfilled, err := bidRepository.BackfillTimestamps(ctx); err == nil && backfilled > 0 {
+		log.Printf("Backfilled the timestamps of %d bids", backfilled)
+	}
+	if backfilled, err := bidRepository.BackfillAmountCents(ctx); err == nil && backfilled > 0 {
+		log.Printf("Backfilled the amounts in cents of %d bids", backfilled)
+	}
+	bidRepository.EnsureIndexes(ctx)
+
+	return bidRepository
+}
+
+func newUserRepository(
+	ctx context.Context, cfg *config.Config, database *mongo.Database) user_entity.UserRepositoryInterface {
+	userRepository := user.NewUserRepository(database, cfg.DBOperationTimeout)
+	userRepository.EnsureIndexes(ctx)
+
+	return userRepository
+}
+
+// newCategoryRepository gives the categories created before slugs one before the slug
+// index is created, as it would fail on the duplicates missing it.
+func newCategoryRepository(
+	ctx context.Context, cfg *config.Config, database *mongo.Database) category_entity.CategoryRepositoryInterface {
+	categoryRepository := category.NewCategoryRepository(database, cfg.DBOperationTimeout)
+	if backfilled, err := categoryRepository.BackfillSlugs(ctx); err == nil && backfilled > 0 {
+		log.Printf("Backfilled the slug of %d categories", backfilled)
+	}
+	categoryRepository.EnsureIndexes(ctx)
+
+	return categoryRepository
+}
+
+func newWatchlistRepository(
+	ctx context.Context, cfg *config.Config, database *mongo.Database) watchlist_entity.WatchlistRepositoryInterface {
+	watchlistRepository := watchlist.NewWatchlistRepository(database, cfg.DBOperationTimeout)
+	watchlistRepository.EnsureIndexes(ctx)
+
+	return watchlistRepository
+}
+
+// newRatingRepository keeps the average rating on the users, along with the ratings, in
+// transactions.
+func newRatingRepository(
+	ctx context.Context, cfg *config.Config, database *mongo.Database,
+	txRunner mongodb.TxRunner) rating_entity.RatingRepositoryInterface {
+	ratingRepository := rating.NewRatingRepository(database, txRunner, cfg.DBOperationTimeout)
+	ratingRepository.EnsureIndexes(ctx)
+
+	return ratingRepository
+}
+
+// auctionRepositoryDecorator wraps an auction repository in another one adding to it,
+// e.g. metrics, tracing or a cache in front of the reads.
+type auctionRepositoryDecorator func(
+	inner auction_entity.AuctionRepositoryInterface) auction_entity.AuctionRepositoryInterface
+
+// auctionRepositoryDecorators are the layers the use cases see the auction repository
+// through, innermost first.
+func auctionRepositoryDecorators() []auctionRepositoryDecorator {
+	return []auctionRepositoryDecorator{
+		func(inner auction_entity.AuctionRepositoryInterface) auction_entity.AuctionRepositoryInterface {
+			return metrics.NewAuctionRepository(inner)
+		},
+	}
+}
+
+// decorateAuctionRepository wraps repository in each decorator in turn, so the first one
+// sits right around it and the last one is called first.
+func decorateAuctionRepository(
+	repository auction_entity.AuctionRepositoryInterface,
+	decorators ...auctionRepositoryDecorator) auction_entity.AuctionRepositoryInterface {
+	for _, decorate := range decorators {
+		repository = decorate(repository)
+	}
+
+	return repository
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the histogram rows; slower requests go in a
+// last, open row.
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// histogramWidth is how many characters the longest bar takes
+const histogramWidth = 40
+
+// latencyHistogram keeps every sample, so the percentiles are exact. It is safe for
+// concurrent use.
+type latencyHistogram struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+func (h *latencyHistogram) record(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples = append(h.samples, latency)
+}
+
+// print writes the percentiles and one bar per bucket.
+func (h *latencyHistogram) print(out io.Writer) {
+	h.mu.Lock()
+	samples := append([]time.Duration(nil), h.samples...)
+	h.mu.Unlock()
+
+	if len(samples) == 0 {
+		fmt.Fprintln(out, "  no samples")
+		return
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	percentile := func(p float64) time.Duration {
+		return samples[int(p*float64(len(samples)-1))]
+	}
+	fmt.Fprintf(out, "  p50 %s  p90 %s  p99 %s  max %s\n",
+		percentile(0.5).Round(time.Microsecond), percentile(0.9).Round(time.Microsecond),
+		percentile(0.99).Round(time.Microsecond), samples[len(samples)-1].Round(time.Microsecond))
+
+	counts := make([]int, len(latencyBuckets)+1)
+	for _, sample := range samples {
+		bucket := sort.Search(len(latencyBuckets), func(i int) bool { return sample <= latencyBuckets[i] })
+		counts[bucket]++
+	}
+
+	largest := 0
+	for _, count := range counts {
+		if count > largest {
+			largest = count
+		}
+	}
+
+	for i, count := range counts {
+		label := "> " + latencyBuckets[len(latencyBuckets)-1].String()
+		if i < len(latencyBuckets) {
+			label = "<= " + latencyBuckets[i].String()
+		}
+
+		bar := strings.Repeat("#", count*histogramWidth/largest)
+		fmt.Fprintf(out, "  %-10s %8d %s\n", label, count, bar)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+	"fullcycle-auction_go/internal/usecase/user_usecase"
+)
+
+// httpRequestTimeout is how long a request may take before the bid counts as unanswered
+const httpRequestTimeout = 30 * time.Second
+
+// apiPrefix is where the server serves the version of the API the driver speaks
+const apiPrefix = "/api/v1"
+
+// httpDriver goes through the API of a running server, logging each user in once it is
+// created and sending its token along with its requests.
+type httpDriver struct {
+	baseURL string
+	client  *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// newHTTPDriver keeps a connection per bidder open, so the bids don't wait on new ones.
+func newHTTPDriver(baseURL string, bidders int) *httpDriver {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = bidders
+
+	return &httpDriver{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Transport: transport, Timeout: httpRequestTimeout},
+		tokens:  make(map[string]string),
+	}
+}
+
+func (d *httpDriver) createUser(ctx context.Context, name, email string) (string, error) {
+	var user user_usecase.UserOutputDTO
+	userInput := user_usecase.UserInputDTO{Name: name, Email: email, Password: userPassword}
+	if err := d.do(ctx, http.MethodPost, "/user", "", userInput, &user); err != nil {
+		return "", err
+	}
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	credentials := map[string]string{"user_id": user.Id, "password": userPassword}
+	if err := d.do(ctx, http.MethodPost, "/auth/login", "", credentials, &login); err != nil {
+		return "", err
+	}
+
+	d.mu.Lock()
+	d.tokens[user.Id] = login.AccessToken
+	d.mu.Unlock()
+
+	return user.Id, nil
+}
+
+func (d *httpDriver) deposit(ctx context.Context, userId string, amount int64) error {
+	return d.do(ctx, http.MethodPost, "/user/"+userId+"/deposit", userId,
+		user_usecase.DepositInputDTO{Amount: money.Amount(amount)}, nil)
+}
+
+func (d *httpDriver) createAuctions(
+	ctx context.Context, sellerId string, auctions []auction_usecase.AuctionInputDTO) ([]string, error) {
+	var batch struct {
+		Results []struct {
+			Id    string            `json:"id"`
+			Error *rest_err.RestErr `json:"error"`
+		} `json:"results"`
+	}
+	if err := d.do(ctx, http.MethodPost, "/auction/batch", sellerId, auctions, &batch); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(batch.Results))
+	for i, result := range batch.Results {
+		if result.Error != nil {
+			return nil, fmt.Errorf("auction %d: %w", i+1, result.Error)
+		}
+		ids[i] = result.Id
+	}
+
+	return ids, nil
+}
+
+func (d *httpDriver) placeBid(ctx context.Context, userId, auctionId string, amount int64) (bidResult, error) {
+	var bid bid_usecase.CreateBidOutputDTO
+	err := d.do(ctx, http.MethodPost, "/bid", userId,
+		bid_usecase.BidInputDTO{AuctionId: auctionId, Amount: money.Amount(amount)}, &bid)
+	if restErr, ok := err.(*rest_err.RestErr); ok {
+		return bidResult{outcome: restErr.Err}, nil
+	}
+	if err != nil {
+		return bidResult{}, err
+	}
+
+	if bid.Duplicate {
+		return bidResult{outcome: outcomeDuplicate}, nil
+	}
+	return bidResult{outcome: outcomeAccepted, highest: bid.CurrentHighestAmount.Cents()}, nil
+}
+
+func (d *httpDriver) findWinningBid(
+	ctx context.Context, auctionId string) (*auction_usecase.WinningInfoOutputDTO, error) {
+	var info auction_usecase.WinningInfoOutputDTO
+	if err := d.do(ctx, http.MethodGet, "/auction/winner/"+auctionId, "", nil, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// do sends body as JSON, on behalf of userId unless it is empty, and decodes the answer
+// into out from the data of the envelope. Answers with an error status are returned as the
+// *rest_err.RestErr they carry.
+func (d *httpDriver) do(ctx context.Context, method, path, userId string, body, out interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, d.baseURL+apiPrefix+path, &payload)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	if userId != "" {
+		d.mu.Lock()
+		token := d.tokens[userId]
+		d.mu.Unlock()
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	answer, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer answer.Body.Close()
+
+	if answer.StatusCode >= http.StatusBadRequest {
+		var envelope response.Body
+		if err := json.NewDecoder(answer.Body).Decode(&envelope); err != nil ||
+			envelope.Error == nil || envelope.Error.Err == "" {
+			envelope.Error = &rest_err.RestErr{
+				Err: strings.ReplaceAll(strings.ToLower(http.StatusText(answer.StatusCode)), " ", "_"),
+			}
+		}
+		envelope.Error.Code = answer.StatusCode
+		return envelope.Error
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(answer.Body).Decode(&response.Body{Data: out})
+}
@@ -0,0 +1,110 @@
+// Command loadtest drives bidding traffic against the auction service and then checks
+// that nothing was lost on the way: it creates auctions, lets concurrent bidders place
+// bids on them until they end, waits for the closer and verifies every auction against
+// the bids that were accepted. It talks to a running server over HTTP, or with -mode
+// usecase builds the use cases itself on the database configured in cmd/auction/.env.
+//
+//	go run ./cmd/loadtest -auctions 20 -bidders 100 -duration 1m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"fullcycle-auction_go/internal/money"
+)
+
+// amountFlag reads a decimal amount such as 10.50 into cents.
+type amountFlag int64
+
+func (a *amountFlag) String() string {
+	return money.Format(int64(*a))
+}
+
+func (a *amountFlag) Set(value string) error {
+	cents, err := money.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	*a = amountFlag(cents)
+	return nil
+}
+
+type options struct {
+	mode    string
+	baseURL string
+
+	auctions int
+	bidders  int
+	duration time.Duration
+	category string
+
+	thinkTime     time.Duration
+	startingPrice amountFlag
+	minStep       amountFlag
+	stepMean      amountFlag
+	stepDist      string
+	deposit       amountFlag
+
+	closeTimeout time.Duration
+}
+
+func main() {
+	opts := options{startingPrice: 1000, minStep: 100, stepMean: 500, deposit: 100000000}
+	flag.StringVar(&opts.mode, "mode", "http", "how to reach the service: http or usecase")
+	flag.StringVar(&opts.baseURL, "url", "http://localhost:8080", "address of the server, in http mode")
+	flag.IntVar(&opts.auctions, "auctions", 10, "auctions to create")
+	flag.IntVar(&opts.bidders, "bidders", 50, "concurrent bidders")
+	flag.DurationVar(&opts.duration, "duration", 30*time.Second, "how long the auctions, and the bidding, last")
+	flag.StringVar(&opts.category, "category", "Electronics", "existing category of the auctions")
+	flag.DurationVar(&opts.thinkTime, "think-time", 200*time.Millisecond, "average pause of a bidder between bids")
+	flag.Var(&opts.startingPrice, "starting-price", "starting price of the auctions")
+	flag.Var(&opts.minStep, "min-step", "least a bid raises the price by, BID_MIN_INCREMENT on the server")
+	flag.Var(&opts.stepMean, "step-mean", "average raise on top of -min-step")
+	flag.StringVar(&opts.stepDist, "step-dist", "uniform", "distribution of the raises: uniform or exponential")
+	flag.Var(&opts.deposit, "deposit", "balance deposited to each bidder")
+	flag.DurationVar(&opts.closeTimeout, "close-timeout", 2*time.Minute, "how long to wait for the auctions to close")
+	flag.Parse()
+
+	if opts.auctions < 1 || opts.bidders < 1 || opts.duration < time.Second {
+		log.Fatal("-auctions and -bidders must be at least 1 and -duration at least 1s")
+	}
+
+	steps, err := newStepper(opts.stepDist, int64(opts.minStep), int64(opts.stepMean))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	ctx := context.Background()
+
+	var d driver
+	shutdown := func(ctx context.Context) {}
+	switch opts.mode {
+	case "http":
+		d = newHTTPDriver(opts.baseURL, opts.bidders)
+	case "usecase":
+		d, shutdown, err = newUseCaseDriver(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	default:
+		log.Fatalf("Unknown -mode %q, use http or usecase", opts.mode)
+	}
+
+	violations, err := run(ctx, d, opts, steps, os.Stdout)
+	shutdown(ctx)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if violations > 0 {
+		fmt.Fprintf(os.Stdout, "\nFAILED: %d invariant violations\n", violations)
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stdout, "\nOK: every invariant holds")
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+)
+
+// Bid outcomes besides the error codes bids are rejected with
+const (
+	outcomeAccepted  = "accepted"
+	outcomeDuplicate = "duplicate"
+	outcomeNoAnswer  = "no_answer"
+)
+
+// setupWorkers bounds the requests creating the bidders at once
+const setupWorkers = 16
+
+// userPassword is the password of every user the load test creates
+const userPassword = "loadtest-password"
+
+// closePollInterval is how often the auctions still open are looked up again
+const closePollInterval = time.Second
+
+// driver is how the load test reaches the service, see httpDriver and useCaseDriver.
+type driver interface {
+	// createUser registers a user the driver can then act on behalf of
+	createUser(ctx context.Context, name, email string) (string, error)
+
+	deposit(ctx context.Context, userId string, amount int64) error
+
+	// createAuctions returns the ids of the auctions in the order they were given
+	createAuctions(
+		ctx context.Context, sellerId string, auctions []auction_usecase.AuctionInputDTO) ([]string, error)
+
+	// placeBid fails only for bids that got no answer; rejected bids are a result too
+	placeBid(ctx context.Context, userId, auctionId string, amount int64) (bidResult, error)
+
+	findWinningBid(ctx context.Context, auctionId string) (*auction_usecase.WinningInfoOutputDTO, error)
+}
+
+// bidResult is how a bid went: outcomeAccepted, outcomeDuplicate or the error code it
+// was rejected with. Highest is the price of the auction right after an accepted bid.
+type bidResult struct {
+	outcome string
+	highest int64
+}
+
+// auctionTracker keeps what the bidders know of each auction: the price the next bid
+// has to raise, and the bids accepted so far, which the closed auction must match.
+type auctionTracker struct {
+	mu       sync.Mutex
+	price    map[string]int64
+	accepted map[string]int64
+	highest  map[string]int64
+}
+
+func newAuctionTracker(ids []string, startingPrice int64) *auctionTracker {
+	tracker := &auctionTracker{
+		price:    make(map[string]int64, len(ids)),
+		accepted: make(map[string]int64, len(ids)),
+		highest:  make(map[string]int64, len(ids)),
+	}
+	for _, id := range ids {
+		tracker.price[id] = startingPrice
+	}
+
+	return tracker
+}
+
+func (t *auctionTracker) nextAmount(id string, step int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.price[id] + step
+}
+
+func (t *auctionTracker) record(id string, amount int64, result bidResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if result.outcome != outcomeAccepted {
+		return
+	}
+
+	t.accepted[id]++
+	if amount > t.highest[id] {
+		t.highest[id] = amount
+	}
+	if result.highest > t.price[id] {
+		t.price[id] = result.highest
+	}
+}
+
+// stepper draws how much each bid raises the price by: at least min, plus an extra of
+// the given distribution averaging mean.
+type stepper struct {
+	distribution string
+	min, mean    int64
+}
+
+func newStepper(distribution string, min, mean int64) (stepper, error) {
+	if distribution != "uniform" && distribution != "exponential" {
+		return stepper{}, fmt.Errorf("unknown -step-dist %q, use uniform or exponential", distribution)
+	}
+	if min < 1 || mean < 0 {
+		return stepper{}, fmt.Errorf("-min-step must be positive and -step-mean not negative")
+	}
+
+	return stepper{distribution: distribution, min: min, mean: mean}, nil
+}
+
+func (s stepper) next(r *rand.Rand) int64 {
+	extra := r.ExpFloat64() * float64(s.mean)
+	if s.distribution == "uniform" {
+		extra = r.Float64() * 2 * float64(s.mean)
+	}
+
+	return s.min + int64(extra)
+}
+
+// run creates the users and auctions, bids until the auctions end, waits for them to
+// close and checks them, reporting to out. It returns how many invariants were broken.
+func run(ctx context.Context, d driver, opts options, steps stepper, out io.Writer) (int, error) {
+	runId := time.Now().Format("20060102150405")
+
+	sellerId, err := d.createUser(ctx, "Load Test Seller", fmt.Sprintf("loadtest-%s-seller@example.com", runId))
+	if err != nil {
+		return 0, fmt.Errorf("creating the seller: %w", err)
+	}
+
+	bidderIds, err := createBidders(ctx, d, runId, opts.bidders, int64(opts.deposit))
+	if err != nil {
+		return 0, err
+	}
+
+	auctionIds, err := createAuctions(ctx, d, sellerId, opts)
+	if err != nil {
+		return 0, err
+	}
+	fmt.Fprintf(out, "Created %d auctions and %d bidders, bidding for %s\n", len(auctionIds), len(bidderIds), opts.duration)
+
+	tracker := newAuctionTracker(auctionIds, int64(opts.startingPrice))
+	outcomes, latencies, elapsed := placeBids(ctx, d, bidderIds, auctionIds, tracker, steps, opts)
+	printBids(out, outcomes, latencies, elapsed)
+
+	fmt.Fprintf(out, "\nWaiting up to %s for the auctions to close\n", opts.closeTimeout)
+	closed, waited := waitForClose(ctx, d, auctionIds, opts.closeTimeout)
+	fmt.Fprintf(out, "%d of %d auctions closed after %s\n", len(closed), len(auctionIds), waited.Round(time.Millisecond))
+
+	return verify(out, auctionIds, closed, tracker), nil
+}
+
+func createBidders(ctx context.Context, d driver, runId string, count int, deposit int64) ([]string, error) {
+	ids := make([]string, count)
+	errs := make([]error, count)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < setupWorkers && w < count; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				ids[i], errs[i] = d.createUser(
+					ctx, fmt.Sprintf("Load Test Bidder %d", i+1), fmt.Sprintf("loadtest-%s-bidder-%d@example.com", runId, i+1))
+				if errs[i] == nil && deposit > 0 {
+					errs[i] = d.deposit(ctx, ids[i], deposit)
+				}
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("creating bidder %d: %w", i+1, err)
+		}
+	}
+
+	return ids, nil
+}
+
+func createAuctions(ctx context.Context, d driver, sellerId string, opts options) ([]string, error) {
+	inputs := make([]auction_usecase.AuctionInputDTO, opts.auctions)
+	for i := range inputs {
+		inputs[i] = auction_usecase.AuctionInputDTO{
+			ProductName:     fmt.Sprintf("Load Test Product %d", i+1),
+			Category:        opts.category,
+			Description:     "Auction created by the load test",
+			Condition:       auction_usecase.ProductCondition(auction_entity.New),
+			DurationSeconds: int64(opts.duration / time.Second),
+			StartingPrice:   money.Amount(opts.startingPrice),
+		}
+	}
+
+	ids := make([]string, 0, len(inputs))
+	for start := 0; start < len(inputs); start += auction_usecase.MaxAuctionBatchSize {
+		end := start + auction_usecase.MaxAuctionBatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+
+		batch, err := d.createAuctions(ctx, sellerId, inputs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("creating the auctions: %w", err)
+		}
+		ids = append(ids, batch...)
+	}
+
+	return ids, nil
+}
+
+// placeBids runs the bidders until the auctions end. Each bidder picks a random auction,
+// raises the price it last saw by a step and pauses for a random think time, averaging
+// opts.thinkTime, before the next bid.
+func placeBids(
+	ctx context.Context,
+	d driver,
+	bidderIds, auctionIds []string,
+	tracker *auctionTracker,
+	steps stepper,
+	opts options) (map[string]int, *latencyHistogram, time.Duration) {
+	var mu sync.Mutex
+	outcomes := make(map[string]int)
+	latencies := &latencyHistogram{}
+
+	started := time.Now()
+	deadline := started.Add(opts.duration)
+
+	var wg sync.WaitGroup
+	for i, bidderId := range bidderIds {
+		wg.Add(1)
+		go func(bidderId string, seed int64) {
+			defer wg.Done()
+
+			r := rand.New(rand.NewSource(seed))
+			for time.Now().Before(deadline) {
+				auctionId := auctionIds[r.Intn(len(auctionIds))]
+				amount := tracker.nextAmount(auctionId, steps.next(r))
+
+				sent := time.Now()
+				result, err := d.placeBid(ctx, bidderId, auctionId, amount)
+				latencies.record(time.Since(sent))
+				if err != nil {
+					result.outcome = outcomeNoAnswer
+				}
+				tracker.record(auctionId, amount, result)
+
+				mu.Lock()
+				outcomes[result.outcome]++
+				mu.Unlock()
+
+				if opts.thinkTime > 0 {
+					time.Sleep(time.Duration(r.Int63n(int64(2 * opts.thinkTime))))
+				}
+			}
+		}(bidderId, started.UnixNano()+int64(i))
+	}
+	wg.Wait()
+
+	return outcomes, latencies, time.Since(started)
+}
+
+func printBids(out io.Writer, outcomes map[string]int, latencies *latencyHistogram, elapsed time.Duration) {
+	total := 0
+	names := make([]string, 0, len(outcomes))
+	for name, count := range outcomes {
+		names = append(names, name)
+		total += count
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(out, "\n%d bids in %s (%.1f/s)\n", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-24s %8d\n", name, outcomes[name])
+	}
+
+	fmt.Fprintln(out, "\nBid latency")
+	latencies.print(out)
+}
+
+// waitForClose looks the auctions up until all of them are Completed or timeout passes,
+// returning the closed ones by id and how long it waited.
+func waitForClose(
+	ctx context.Context,
+	d driver,
+	auctionIds []string,
+	timeout time.Duration) (map[string]*auction_usecase.WinningInfoOutputDTO, time.Duration) {
+	closed := make(map[string]*auction_usecase.WinningInfoOutputDTO, len(auctionIds))
+
+	started := time.Now()
+	for {
+		for _, id := range auctionIds {
+			if closed[id] != nil {
+				continue
+			}
+
+			info, err := d.findWinningBid(ctx, id)
+			if err == nil && info.Auction.Status == auction_usecase.AuctionStatus(auction_entity.Completed) {
+				closed[id] = info
+			}
+		}
+
+		if len(closed) == len(auctionIds) || time.Since(started) >= timeout {
+			return closed, time.Since(started)
+		}
+		time.Sleep(closePollInterval)
+	}
+}
+
+// verify checks every auction closed, with as many stored bids as were accepted and the
+// highest accepted bid as the winner. It prints each violation and returns their count.
+func verify(
+	out io.Writer,
+	auctionIds []string,
+	closed map[string]*auction_usecase.WinningInfoOutputDTO,
+	tracker *auctionTracker) int {
+	fmt.Fprintln(out, "\nInvariants")
+
+	violations := 0
+	report := func(format string, args ...interface{}) {
+		violations++
+		fmt.Fprintf(out, "  "+format+"\n", args...)
+	}
+
+	for _, id := range auctionIds {
+		info := closed[id]
+		if info == nil {
+			report("%s: not Completed", id)
+			continue
+		}
+
+		accepted, highest := tracker.accepted[id], tracker.highest[id]
+		if info.Auction.BidCount != accepted {
+			report("%s: %d bids stored, %d accepted", id, info.Auction.BidCount, accepted)
+		}
+
+		switch {
+		case accepted == 0 && info.Bid != nil:
+			report("%s: won by a bid of %s without any accepted bid", id, info.Bid.Amount)
+		case accepted > 0 && info.Bid == nil:
+			report("%s: no winning bid, the highest accepted bid was %s", id, money.Amount(highest))
+		case accepted > 0 && info.Bid.Amount.Cents() != highest:
+			report("%s: won by a bid of %s, the highest accepted bid was %s", id, info.Bid.Amount, money.Amount(highest))
+		}
+	}
+
+	if violations == 0 {
+		fmt.Fprintf(out, "  %d auctions checked, all Completed with the accepted bids and winners\n", len(auctionIds))
+	}
+
+	return violations
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fullcycle-auction_go/configuration/config"
+	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/bid"
+	"fullcycle-auction_go/internal/infra/database/category"
+	"fullcycle-auction_go/internal/infra/database/user"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+	"fullcycle-auction_go/internal/usecase/user_usecase"
+
+	"github.com/joho/godotenv"
+)
+
+// useCaseDriver calls the use cases directly, wired to the database like the server
+// does, so the load skips HTTP, authentication and rate limiting. It runs its own
+// auction closer.
+type useCaseDriver struct {
+	userUseCase    user_usecase.UserUseCaseInterface
+	auctionUseCase auction_usecase.AuctionUseCaseInterface
+	bidUseCase     bid_usecase.BidUseCaseInterface
+}
+
+// newUseCaseDriver reads the server configuration from cmd/auction/.env. The returned
+// shutdown writes the bids still queued and stops the closer.
+func newUseCaseDriver(ctx context.Context) (*useCaseDriver, func(ctx context.Context), error) {
+	if err := godotenv.Load("cmd/auction/.env"); err != nil {
+		return nil, nil, errors.New("error trying to load env variables")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	database, err := mongodb.Connect(ctx, cfg.Mongo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clk := clock.New()
+	txRunner := mongodb.NewTxRunner(ctx, database.Client())
+	auctionRepository := auction.NewAuctionRepository(
+		database, clk, txRunner, cfg.Auction.Duration, cfg.DBOperationTimeout)
+	auctionRepository.CloseRetryInterval = cfg.Auction.CloseRetryInterval
+	bidRepository := bid.NewBidRepository(database, auctionRepository, txRunner, cfg.DBOperationTimeout)
+	bidRepository.ReserveBalances = cfg.Bid.BalanceMode == bid_usecase.BalanceReserve
+	userRepository := user.NewUserRepository(database, cfg.DBOperationTimeout)
+	categoryRepository := category.NewCategoryRepository(database, cfg.DBOperationTimeout)
+
+	auctionCloser := auction.NewCloser(ctx, auctionRepository, clk, cfg.Auction.Closer)
+	auctionCloser.Start(ctx)
+
+	bidUseCase := bid_usecase.NewBidUseCase(
+		bidRepository, auctionRepository, userRepository, nil, nil, nil, clk, cfg.Bid)
+
+	d := &useCaseDriver{
+		userUseCase: user_usecase.NewUserUseCase(userRepository, bidRepository),
+		auctionUseCase: auction_usecase.NewAuctionUseCase(
+			auctionRepository, bidRepository, userRepository, categoryRepository, nil, nil, auctionCloser, nil, clk,
+			auction_usecase.Config{DurationBounds: cfg.Auction.DurationBounds}),
+		bidUseCase: bidUseCase,
+	}
+
+	shutdown := func(ctx context.Context) {
+		bidUseCase.Shutdown(ctx)
+		auctionCloser.Shutdown(ctx)
+		database.Client().Disconnect(ctx)
+	}
+
+	return d, shutdown, nil
+}
+
+func (d *useCaseDriver) createUser(ctx context.Context, name, email string) (string, error) {
+	userOutput, err := d.userUseCase.CreateUser(
+		ctx, user_usecase.UserInputDTO{Name: name, Email: email, Password: userPassword})
+	if err != nil {
+		return "", err
+	}
+
+	return userOutput.Id, nil
+}
+
+func (d *useCaseDriver) deposit(ctx context.Context, userId string, amount int64) error {
+	if _, err := d.userUseCase.Deposit(ctx, userId, user_usecase.DepositInputDTO{Amount: money.Amount(amount)}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *useCaseDriver) createAuctions(
+	ctx context.Context, sellerId string, auctions []auction_usecase.AuctionInputDTO) ([]string, error) {
+	results, err := d.auctionUseCase.CreateAuctions(ctx, sellerId, auctions)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			return nil, fmt.Errorf("auction %d: %w", i+1, result.Err)
+		}
+		ids[i] = result.Id
+	}
+
+	return ids, nil
+}
+
+func (d *useCaseDriver) placeBid(ctx context.Context, userId, auctionId string, amount int64) (bidResult, error) {
+	bidOutput, err := d.bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: userId, AuctionId: auctionId, Amount: money.Amount(amount),
+	})
+	if err != nil {
+		return bidResult{outcome: string(err.Err)}, nil
+	}
+
+	if bidOutput.Duplicate {
+		return bidResult{outcome: outcomeDuplicate}, nil
+	}
+	return bidResult{outcome: outcomeAccepted, highest: bidOutput.CurrentHighestAmount.Cents()}, nil
+}
+
+func (d *useCaseDriver) findWinningBid(
+	ctx context.Context, auctionId string) (*auction_usecase.WinningInfoOutputDTO, error) {
+	info, err := d.auctionUseCase.FindWinningBidByAuctionId(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
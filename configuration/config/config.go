@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/messaging"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+)
+
+// Environments of ENV; any other name counts as production for the checks of Load.
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// Config gathers the settings of the service. Load reads it once at startup and main
+// hands each part to the constructors, so changing a variable of a running service has
+// no effect until it restarts.
+//
+// Only the OTEL_* variables are left out, as the OpenTelemetry SDK reads them itself.
+type Config struct {
+	// Environment is where the service runs, read from ENV, production by default
+	Environment string
+
+	// TimeAcceleration makes the auction clock run that many times faster than the wall
+	// clock, see clock.NewAccelerated. Only development may set it to anything but 1
+	TimeAcceleration float64
+
+	Mongo mongodb.Config
+
+	// DBOperationTimeout bounds each repository operation
+	DBOperationTimeout time.Duration
+
+	Auth    AuthConfig
+	Server  ServerConfig
+	Auction AuctionConfig
+	Bid     bid_usecase.Config
+
+	// Messaging is the RabbitMQ the events are published to, disabled without a URL
+	Messaging messaging.Config
+
+	// ReportCacheTTL is how long the reports are cached, zero disables the cache
+	ReportCacheTTL time.Duration
+}
+
+type AuthConfig struct {
+	// JWTSecret signs the tokens, which last TokenTTL
+	JWTSecret string
+	TokenTTL  time.Duration
+
+	// AdminUserIds are the users allowed on the admin routes
+	AdminUserIds []string
+}
+
+type ServerConfig struct {
+	// RequestTimeout bounds each request and BatchRequestTimeout the batch auction import;
+	// zero disables them
+	RequestTimeout      time.Duration
+	BatchRequestTimeout time.Duration
+
+	// ShutdownTimeout is the deadline of the in-flight requests and pending writes
+	// after SIGTERM
+	ShutdownTimeout time.Duration
+
+	// Requests slower than AccessLogSlowThreshold are logged as warnings; the routes of
+	// AccessLogExcludedPaths aren't logged at all
+	AccessLogSlowThreshold time.Duration
+	AccessLogExcludedPaths []string
+
+	// JSON responses of GzipMinSize bytes or more are compressed, zero disables it
+	GzipMinSize int
+
+	// LiveTimeSyncInterval is how often the live subscribers of an auction get its end
+	// time, zero disables it
+	LiveTimeSyncInterval time.Duration
+
+	// RateLimit is the rate each user, or IP, may bid and create auctions at
+	RateLimit middleware.RateLimitConfig
+
+	// EventsBufferSize is how many recent events the SSE stream keeps for the clients
+	// resuming with Last-Event-ID
+	EventsBufferSize int
+
+	// CORS lets browsers of other origins, such as the frontend's, call the API
+	CORS middleware.CORSConfig
+
+	// LegacyRoutes also serves the API at the root, without the response envelope of
+	// /api/v1, for the clients that didn't move yet
+	LegacyRoutes bool
+}
+
+type AuctionConfig struct {
+	// Duration is how long the auctions created without an end time or a category
+	// default last
+	Duration time.Duration
+
+	// DurationBounds limit the duration of new auctions and how far in the past their
+	// start time may be
+	DurationBounds auction_entity.DurationBounds
+
+	// DuplicateWindow is how long after creating an auction its seller is refused an
+	// identical one, see auction_usecase.Config; zero disables the check
+	DuplicateWindow time.Duration
+
+	Closer auction.CloserConfig
+
+	// CloseRetryInterval is how long the closer waits before trying again to close an
+	// auction that failed to close
+	CloseRetryInterval time.Duration
+
+	// Completed and cancelled auctions that ended more than ArchiveAfter ago are moved to
+	// the archive every ArchiveInterval, see AuctionRepository.ArchiveCompleted; a zero
+	// ArchiveAfter disables it
+	ArchiveAfter    time.Duration
+	ArchiveInterval time.Duration
+
+	// Every CloseLagCheckInterval the Active auctions more than CloseLagAlarm past their end
+	// time are reported and /readyz fails, see auction.CloseLagMonitor; zero disables it
+	CloseLagAlarm         time.Duration
+	CloseLagCheckInterval time.Duration
+
+	// BackfillCategories renames the categories of existing auctions on startup, see
+	// AuctionRepository.NormalizeCategories
+	BackfillCategories bool
+
+	// FacetsCacheTTL is how long GET /auction/facets answers from its cache, zero
+	// disables the cache
+	FacetsCacheTTL time.Duration
+}
+
+// Load reads the configuration from the environment. Unset variables take their
+// default, and every malformed one is reported in the returned error.
+func Load() (*Config, error) {
+	env := &envReader{}
+
+	config := &Config{
+		Environment:      env.string("ENV", Production),
+		TimeAcceleration: env.positiveFloat("TIME_ACCELERATION_FACTOR", 1),
+		Mongo: mongodb.Config{
+			URL:               env.required(mongodb.MONGODB_URL),
+			Database:          env.required(mongodb.MONGODB_DB),
+			MaxPoolSize:       uint64(env.int(mongodb.MONGODB_MAX_POOL_SIZE, 0, 0)),
+			ConnectTimeout:    env.duration(mongodb.MONGODB_CONNECT_TIMEOUT, 10*time.Second, time.Nanosecond),
+			ConnectAttempts:   env.int(mongodb.MONGODB_CONNECT_ATTEMPTS, 10, 1),
+			ConnectBackoff:    env.duration(mongodb.MONGODB_CONNECT_BACKOFF, time.Second, time.Nanosecond),
+			ConnectMaxBackoff: env.duration(mongodb.MONGODB_CONNECT_MAX_BACKOFF, 15*time.Second, time.Nanosecond),
+		},
+		DBOperationTimeout: env.milliseconds("DB_OPERATION_TIMEOUT_MS", 5*time.Second, time.Millisecond),
+		Auth: AuthConfig{
+			JWTSecret:    env.required("JWT_SECRET"),
+			TokenTTL:     env.duration("JWT_TTL", auth.DefaultTokenTTL, time.Nanosecond),
+			AdminUserIds: env.uuidList("ADMIN_USER_IDS"),
+		},
+		Server: ServerConfig{
+			RequestTimeout:         env.duration("REQUEST_TIMEOUT", 10*time.Second, 0),
+			BatchRequestTimeout:    env.duration("BATCH_REQUEST_TIMEOUT", 30*time.Second, 0),
+			ShutdownTimeout:        env.duration("SHUTDOWN_TIMEOUT", 30*time.Second, time.Nanosecond),
+			AccessLogSlowThreshold: env.duration("ACCESS_LOG_SLOW_THRESHOLD", time.Second, 0),
+			AccessLogExcludedPaths: env.list("ACCESS_LOG_EXCLUDE_PATHS", []string{"/healthz", "/readyz"}),
+			GzipMinSize:            env.int("GZIP_MIN_SIZE_BYTES", 1024, 0),
+			LiveTimeSyncInterval:   env.duration("LIVE_TIME_SYNC_INTERVAL", 10*time.Second, 0),
+			LegacyRoutes:           env.bool("API_LEGACY_ROUTES", true),
+			RateLimit: middleware.RateLimitConfig{
+				PerSecond: env.nonNegativeFloat("RATE_LIMIT_PER_SECOND", middleware.DefaultRateLimitPerSecond),
+				Burst:     env.int("RATE_LIMIT_BURST", middleware.DefaultRateLimitBurst, 1),
+			},
+			EventsBufferSize: env.int("EVENTS_BUFFER_SIZE", events.DefaultBufferSize, 1),
+			CORS: middleware.CORSConfig{
+				AllowedOrigins: env.list("CORS_ALLOWED_ORIGINS", nil),
+				AllowedMethods: env.list("CORS_ALLOWED_METHODS",
+					[]string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+				AllowedHeaders: env.list("CORS_ALLOWED_HEADERS",
+					[]string{"Authorization", "Content-Type", "If-None-Match", "Last-Event-ID", middleware.RequestIDHeader}),
+				MaxAge: env.duration("CORS_MAX_AGE", 10*time.Minute, 0),
+			},
+		},
+		Auction: AuctionConfig{
+			Duration: env.seconds("AUCTION_DURATION_SECONDS", 600*time.Second, time.Second),
+			DurationBounds: auction_entity.DurationBounds{
+				Min:           env.duration("AUCTION_MIN_DURATION", auction_entity.MinDuration, time.Second),
+				Max:           env.duration("AUCTION_MAX_DURATION", auction_entity.MaxDuration, time.Second),
+				StartTimeSkew: env.duration("AUCTION_START_TIME_SKEW", auction_entity.StartTimeSkew, time.Second),
+			},
+			DuplicateWindow: env.seconds("AUCTION_DUPLICATE_WINDOW_SECONDS", 0, 0),
+			Closer: auction.CloserConfig{
+				Mode: env.oneOf("AUCTION_CLOSE_MODE", auction.CloseModeSweep,
+					auction.CloseModeSweep, auction.CloseModeChangeStream),
+				Interval: env.duration("AUCTION_CLOSE_INTERVAL", 5*time.Second, time.Nanosecond),
+				LeaseTTL: env.duration("AUCTION_CLOSER_LEASE_TTL", 15*time.Second, 0),
+			},
+			CloseRetryInterval:    env.duration("AUCTION_CLOSE_RETRY_INTERVAL", 5*time.Minute, time.Nanosecond),
+			ArchiveAfter:          env.duration("AUCTION_ARCHIVE_AFTER", 0, 0),
+			ArchiveInterval:       env.duration("AUCTION_ARCHIVE_INTERVAL", time.Hour, time.Nanosecond),
+			CloseLagAlarm:         env.seconds("AUCTION_CLOSE_LAG_ALARM_SECONDS", time.Minute, 0),
+			CloseLagCheckInterval: env.duration("AUCTION_CLOSE_LAG_CHECK_INTERVAL", 30*time.Second, 0),
+			BackfillCategories:    env.bool("BACKFILL_AUCTION_CATEGORIES", false),
+			FacetsCacheTTL:        env.duration("AUCTION_FACETS_CACHE_TTL", 5*time.Second, 0),
+		},
+		ReportCacheTTL: env.seconds("REPORT_CACHE_TTL_SECONDS", time.Minute, 0),
+	}
+
+	bid := bid_usecase.DefaultConfig()
+	config.Bid = bid_usecase.Config{
+		MaxBatchSize:      env.int("MAX_BATCH_SIZE", bid.MaxBatchSize, 1),
+		QueueCapacity:     env.int("BID_QUEUE_CAPACITY", bid.QueueCapacity, 1),
+		MinIncrement:      env.amount("BID_MIN_INCREMENT", bid.MinIncrement),
+		SnipeWindow:       env.seconds("AUCTION_SNIPE_WINDOW_SECONDS", bid.SnipeWindow, 0),
+		SnipeExtension:    env.seconds("AUCTION_SNIPE_EXTENSION_SECONDS", bid.SnipeExtension, 0),
+		SnipeMaxExtension: env.seconds("AUCTION_SNIPE_MAX_EXTENSION_SECONDS", bid.SnipeMaxExtension, 0),
+		BalanceMode: env.oneOf("BID_BALANCE_MODE", bid.BalanceMode,
+			bid_usecase.BalanceVerify, bid_usecase.BalanceReserve, bid_usecase.BalanceOff),
+		MaxBidsPerUser: env.int("MAX_OPEN_BIDS_PER_USER_PER_AUCTION", bid.MaxBidsPerUser, 0),
+	}
+
+	messagingDefaults := messaging.DefaultConfig()
+	config.Messaging = messaging.Config{
+		URL:              env.string("RABBITMQ_URL", ""),
+		Exchange:         env.string("RABBITMQ_EXCHANGE", messagingDefaults.Exchange),
+		RoutingKeyPrefix: env.string("RABBITMQ_ROUTING_KEY_PREFIX", messagingDefaults.RoutingKeyPrefix),
+		QueueSize:        env.int("RABBITMQ_QUEUE_SIZE", messagingDefaults.QueueSize, 1),
+		PublishAttempts:  env.int("RABBITMQ_PUBLISH_ATTEMPTS", messagingDefaults.PublishAttempts, 1),
+	}
+
+	if bounds := config.Auction.DurationBounds; bounds.Min > bounds.Max {
+		env.errs = append(env.errs, fmt.Errorf("AUCTION_MIN_DURATION: %v is longer than AUCTION_MAX_DURATION %v",
+			bounds.Min, bounds.Max))
+	}
+
+	// An accelerated clock closes auctions early, so a production service refuses to start
+	// with one rather than ignoring the variable
+	if config.TimeAcceleration != 1 && config.Environment != Development {
+		env.errs = append(env.errs, fmt.Errorf("TIME_ACCELERATION_FACTOR: %v is only allowed with ENV=%s, not %s",
+			config.TimeAcceleration, Development, config.Environment))
+	}
+
+	if err := env.err(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fullcycle-auction_go/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// envReader reads the variables of Load, collecting an error for each malformed one so
+// they are all reported at once. An empty variable counts as unset.
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) fail(name, value, expected string) {
+	r.errs = append(r.errs, fmt.Errorf("%s: %q is not %s", name, value, expected))
+}
+
+func (r *envReader) err() error {
+	return errors.Join(r.errs...)
+}
+
+func (r *envReader) required(name string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		r.errs = append(r.errs, fmt.Errorf("%s: is required", name))
+	}
+
+	return value
+}
+
+// string reads a free form value.
+func (r *envReader) string(name, defaultValue string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+// int reads a whole number of at least min.
+func (r *envReader) int(name string, defaultValue, min int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < min {
+		r.fail(name, value, fmt.Sprintf("a whole number of at least %d", min))
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// positiveFloat reads a number greater than 0, such as 1.5.
+func (r *envReader) positiveFloat(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(parsed > 0) || math.IsInf(parsed, 0) {
+		r.fail(name, value, "a number greater than 0")
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// nonNegativeFloat reads a number of at least 0, such as 0.5.
+func (r *envReader) nonNegativeFloat(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(parsed >= 0) || math.IsInf(parsed, 0) {
+		r.fail(name, value, "a number of at least 0")
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// duration reads a Go duration such as 1m30s of at least min.
+func (r *envReader) duration(name string, defaultValue, min time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < min {
+		r.fail(name, value, "a duration such as 30s"+atLeast(min))
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// seconds reads a whole number of seconds of at least min.
+func (r *envReader) seconds(name string, defaultValue, min time.Duration) time.Duration {
+	return r.wholeUnits(name, defaultValue, min, time.Second, "seconds")
+}
+
+// milliseconds reads a whole number of milliseconds of at least min.
+func (r *envReader) milliseconds(name string, defaultValue, min time.Duration) time.Duration {
+	return r.wholeUnits(name, defaultValue, min, time.Millisecond, "milliseconds")
+}
+
+func (r *envReader) wholeUnits(
+	name string, defaultValue, min, unit time.Duration, unitName string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || time.Duration(parsed)*unit < min {
+		r.fail(name, value, "a whole number of "+unitName+atLeast(min))
+		return defaultValue
+	}
+
+	return time.Duration(parsed) * unit
+}
+
+// amount reads a non negative decimal amount such as 1.50, in cents.
+func (r *envReader) amount(name string, defaultValue int64) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := money.Parse(value)
+	if err != nil || parsed < 0 {
+		r.fail(name, value, "a non negative amount with at most two decimals")
+		return defaultValue
+	}
+
+	return parsed
+}
+
+func (r *envReader) bool(name string, defaultValue bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		r.fail(name, value, "true or false")
+		return defaultValue
+	}
+
+	return parsed
+}
+
+func (r *envReader) oneOf(name, defaultValue string, allowed ...string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	for _, candidate := range allowed {
+		if value == candidate {
+			return value
+		}
+	}
+
+	r.fail(name, value, "one of "+strings.Join(allowed, ", "))
+	return defaultValue
+}
+
+// list reads a comma separated list, skipping blank items. Unlike the other variables,
+// an empty list is kept as is, so it can clear the default.
+func (r *envReader) list(name string, defaultValue []string) []string {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// uuidList reads a comma separated list of UUIDs like list, reporting every item that
+// isn't one.
+func (r *envReader) uuidList(name string) []string {
+	items := r.list(name, nil)
+	for _, item := range items {
+		if err := uuid.Validate(item); err != nil {
+			r.fail(name, item, "a UUID")
+		}
+	}
+
+	return items
+}
+
+// atLeast describes the lower bound of a duration; the bounds used are either zero or
+// the smallest positive value of their unit.
+func atLeast(min time.Duration) string {
+	if min <= 0 {
+		return ", 0 or more"
+	}
+
+	return ", greater than 0"
+}
@@ -2,29 +2,116 @@ package mongodb
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.uber.org/zap"
 )
 
+// Names of the environment variables holding the Config, read by the config package.
 const (
-	MONGODB_URL = "MONGODB_URL"
-	MONGODB_DB  = "MONGODB_DB"
+	MONGODB_URL                 = "MONGODB_URL"
+	MONGODB_DB                  = "MONGODB_DB"
+	MONGODB_MAX_POOL_SIZE       = "MONGODB_MAX_POOL_SIZE"
+	MONGODB_CONNECT_TIMEOUT     = "MONGODB_CONNECT_TIMEOUT"
+	MONGODB_CONNECT_ATTEMPTS    = "MONGODB_CONNECT_ATTEMPTS"
+	MONGODB_CONNECT_BACKOFF     = "MONGODB_CONNECT_BACKOFF"
+	MONGODB_CONNECT_MAX_BACKOFF = "MONGODB_CONNECT_MAX_BACKOFF"
 )
 
-func NewMongoDBConnection(ctx context.Context) (*mongo.Database, error) {
-	mongoURL := os.Getenv(MONGODB_URL)
-	mongoDatabase := os.Getenv(MONGODB_DB)
+// Config holds the connection settings. A zero MaxPoolSize keeps the driver default.
+type Config struct {
+	URL         string
+	Database    string
+	MaxPoolSize uint64
+
+	// ConnectTimeout bounds each connection attempt, including the server selection
+	// done by the initial ping
+	ConnectTimeout time.Duration
+
+	// Startup retry policy: up to ConnectAttempts tries, waiting ConnectBackoff after the
+	// first failure and doubling it after each one, up to ConnectMaxBackoff
+	ConnectAttempts   int
+	ConnectBackoff    time.Duration
+	ConnectMaxBackoff time.Duration
+}
+
+// Connect connects and pings MongoDB, retrying with backoff while it isn't reachable, so
+// the service can start before the database is up. It gives up after ConnectAttempts
+// tries or when ctx is done, whichever comes first.
+func Connect(ctx context.Context, config Config) (*mongo.Database, error) {
+	attempts := config.ConnectAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	// Every command gets a span, child of the repository span issuing it
+	clientOptions := options.Client().ApplyURI(config.URL).SetMonitor(otelmongo.NewMonitor())
+	if config.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(config.MaxPoolSize)
+	}
+	if config.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(config.ConnectTimeout)
+		clientOptions.SetServerSelectionTimeout(config.ConnectTimeout)
+	}
+
+	backoff := config.ConnectBackoff
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		client, err := connectOnce(ctx, clientOptions, config.ConnectTimeout)
+		if err == nil {
+			return client.Database(config.Database), nil
+		}
+		lastErr = err
+
+		if attempt == attempts {
+			break
+		}
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+		logger.Info("MongoDB not reachable yet, retrying",
+			zap.Int("attempt", attempt), zap.Duration("backoff", backoff), zap.String("error", err.Error()))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("connecting to MongoDB: %w (last error: %v)", ctx.Err(), lastErr)
+		}
+
+		backoff *= 2
+		if config.ConnectMaxBackoff > 0 && backoff > config.ConnectMaxBackoff {
+			backoff = config.ConnectMaxBackoff
+		}
+	}
+
+	return nil, fmt.Errorf("connecting to MongoDB: giving up after %d attempts: %w", attempts, lastErr)
+}
+
+func connectOnce(ctx context.Context, clientOptions *options.ClientOptions, timeout time.Duration) (*mongo.Client, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
 		return nil, err
 	}
 
-	return client.Database(mongoDatabase), nil
+	return client, nil
+}
+
+// HealthCheck reports whether the database behind the connection answers a ping.
+func HealthCheck(ctx context.Context, database *mongo.Database) error {
+	return database.Client().Ping(ctx, nil)
 }
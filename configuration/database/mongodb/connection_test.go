@@ -0,0 +1,74 @@
+package mongodb_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/database/mongodb"
+)
+
+// closedPortURL returns a MongoDB URL pointing at a local port nothing listens on.
+func closedPortURL(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve a port: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	return "mongodb://" + address + "/?directConnection=true"
+}
+
+func TestConnectGivesUpAfterAttempts(t *testing.T) {
+	config := mongodb.Config{
+		URL:             closedPortURL(t),
+		Database:        "test",
+		ConnectTimeout:  100 * time.Millisecond,
+		ConnectAttempts: 3,
+		ConnectBackoff:  10 * time.Millisecond,
+	}
+
+	start := time.Now()
+	database, err := mongodb.Connect(context.Background(), config)
+	elapsed := time.Since(start)
+
+	if err == nil || database != nil {
+		t.Fatal("Expected the connection to a closed port to fail")
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("Expected the error to report 3 attempts, got %v", err)
+	}
+
+	// Three attempts bounded by the connect timeout, plus the backoffs in between
+	if elapsed > 3*time.Second {
+		t.Errorf("Expected the attempts to respect the connect timeout, took %v", elapsed)
+	}
+}
+
+func TestConnectRespectsContextDeadline(t *testing.T) {
+	config := mongodb.Config{
+		URL:             closedPortURL(t),
+		Database:        "test",
+		ConnectTimeout:  100 * time.Millisecond,
+		ConnectAttempts: 1000,
+		ConnectBackoff:  50 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := mongodb.Connect(ctx, config)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to stop the retries, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Expected to give up around the 500ms deadline, took %v", elapsed)
+	}
+}
@@ -0,0 +1,69 @@
+package mongodb
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TxRunner runs a unit of work that must commit as a whole. Every operation of fn has to
+// use the ctx it is given, which carries the transaction when there is one.
+type TxRunner interface {
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// NewTxRunner returns a runner backed by multi-document transactions when the server
+// supports them, i.e. it is a replica set member or a mongos. On a standalone mongod,
+// as used in local development, it logs a warning and returns a SequentialTxRunner.
+func NewTxRunner(ctx context.Context, client *mongo.Client) TxRunner {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		logger.Error("Error trying to detect transaction support, running without transactions", err)
+		return SequentialTxRunner{}
+	}
+
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		logger.Warn("MongoDB is a standalone server, multi-document writes will run without transactions")
+		return SequentialTxRunner{}
+	}
+
+	return NewSessionTxRunner(client)
+}
+
+// SessionTxRunner runs each unit of work in a session transaction. The driver retries fn
+// on transient errors, so fn must be safe to run more than once.
+type SessionTxRunner struct {
+	client *mongo.Client
+}
+
+func NewSessionTxRunner(client *mongo.Client) *SessionTxRunner {
+	return &SessionTxRunner{client: client}
+}
+
+func (r *SessionTxRunner) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+// SequentialTxRunner runs fn as is, so its writes are applied one by one and those made
+// before a failure are kept.
+type SequentialTxRunner struct{}
+
+func (SequentialTxRunner) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying l, so everything downstream logs through it.
+func WithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// WithFields returns a copy of ctx whose logger adds fields to every entry, on top of
+// the ones already carried by ctx.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(fields...))
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or the global logger
+// when there is none.
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+			return l
+		}
+	}
+
+	return log
+}
+
+func InfoContext(ctx context.Context, message string, tags ...zap.Field) {
+	l := FromContext(ctx)
+	l.Info(message, tags...)
+	l.Sync()
+}
+
+func WarnContext(ctx context.Context, message string, tags ...zap.Field) {
+	l := FromContext(ctx)
+	l.Warn(message, tags...)
+	l.Sync()
+}
+
+// ErrorContext logs the error and records it on the span in ctx, if any, so the failed
+// operation shows up in its trace too.
+func ErrorContext(ctx context.Context, message string, err error, tags ...zap.Field) {
+	tags = append(tags, zap.NamedError("error", err))
+
+	l := FromContext(ctx)
+	l.Error(message, tags...)
+	l.Sync()
+
+	if span := trace.SpanFromContext(ctx); err != nil && span.IsRecording() {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, message)
+	}
+}
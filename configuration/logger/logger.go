@@ -31,6 +31,11 @@ func Info(message string, tags ...zap.Field) {
 	log.Sync()
 }
 
+func Warn(message string, tags ...zap.Field) {
+	log.Warn(message, tags...)
+	log.Sync()
+}
+
 func Error(message string, err error, tags ...zap.Field) {
 	tags = append(tags, zap.NamedError("error", err))
 	log.Error(message, tags...)
@@ -1,18 +1,28 @@
 package rest_err
 
 import (
+	"context"
+	"errors"
 	"fullcycle-auction_go/internal/internal_error"
 	"net/http"
+	"time"
 )
 
 type RestErr struct {
-	Message string   `json:"message"`
-	Err     string   `json:"err"`
-	Code    int      `json:"code"`
-	Causes  []Causes `json:"causes"`
+	Message string       `json:"message"`
+	Err     string       `json:"err"`
+	Code    int          `json:"code"`
+	Causes  []FieldError `json:"causes"`
+
+	// ExistingAuctionId is only sent with duplicate_auction errors
+	ExistingAuctionId string `json:"existing_auction_id,omitempty"`
+
+	// RetryAfter is sent in the Retry-After header, for the errors worth retrying later
+	RetryAfter time.Duration `json:"-"`
 }
 
-type Causes struct {
+// FieldError is one invalid request field and why it was rejected.
+type FieldError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
 }
@@ -21,18 +31,91 @@ func (r *RestErr) Error() string {
 	return r.Message
 }
 
+// StatusCode maps an internal error code to its HTTP status. Unknown codes are
+// treated as internal errors.
+func StatusCode(code internal_error.ErrorCode) int {
+	switch code {
+	case internal_error.BadRequest, internal_error.CurrencyMismatch:
+		return http.StatusBadRequest
+	case internal_error.NotFound, internal_error.NoBids, internal_error.CategoryNotFound,
+		internal_error.AuctionNotFound, internal_error.UserNotFound:
+		return http.StatusNotFound
+	case internal_error.Conflict, internal_error.NotStarted, internal_error.DuplicateAuction,
+		internal_error.BuyNowUnavailable:
+		return http.StatusConflict
+	case internal_error.Forbidden, internal_error.SelfBidForbidden:
+		return http.StatusForbidden
+	case internal_error.Unauthorized:
+		return http.StatusUnauthorized
+	case internal_error.BidLimitExceeded:
+		return http.StatusTooManyRequests
+	case internal_error.ServiceBusy:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func ConvertError(internalError *internal_error.InternalError) *RestErr {
-	switch internalError.Err {
-	case "bad_request":
-		return NewBadRequestError(internalError.Error())
-	case "not_found":
-		return NewNotFoundError(internalError.Error())
+	// A database operation or request that ran out of time is reported as a timeout
+	// whatever its code, so clients can tell it apart from a failure worth reporting
+	if errors.Is(internalError, context.DeadlineExceeded) {
+		return NewGatewayTimeoutError("The request timed out")
+	}
+
+	// Nobody reads the answer to a request its client gave up on, but the access log and
+	// the metrics still tell it apart from a failure
+	if errors.Is(internalError, context.Canceled) {
+		return NewClientClosedRequestError("The client closed the request")
+	}
+
+	switch StatusCode(internalError.Err) {
+	case http.StatusBadRequest:
+		causes := make([]FieldError, 0, len(internalError.Causes))
+		for _, cause := range internalError.Causes {
+			causes = append(causes, FieldError{Field: cause.Field, Message: cause.Message})
+		}
+		restErr := NewBadRequestError(internalError.Error(), causes...)
+		if internalError.Err != internal_error.BadRequest {
+			restErr.Err = string(internalError.Err)
+		}
+		return restErr
+	case http.StatusNotFound:
+		restErr := NewNotFoundError(internalError.Error())
+		if internalError.Err != internal_error.NotFound {
+			restErr.Err = string(internalError.Err)
+		}
+		return restErr
+	case http.StatusConflict:
+		restErr := NewConflictError(internalError.Error())
+		if internalError.Err != internal_error.Conflict {
+			restErr.Err = string(internalError.Err)
+		}
+		restErr.ExistingAuctionId = internalError.ExistingAuctionId
+		return restErr
+	case http.StatusForbidden:
+		restErr := NewForbiddenError(internalError.Error())
+		if internalError.Err == internal_error.SelfBidForbidden {
+			restErr.Err = string(internal_error.SelfBidForbidden)
+		}
+		return restErr
+	case http.StatusUnauthorized:
+		return NewUnauthorizedError(internalError.Error())
+	case http.StatusTooManyRequests:
+		restErr := NewTooManyRequestsError(internalError.Error())
+		restErr.Err = string(internalError.Err)
+		return restErr
+	case http.StatusServiceUnavailable:
+		restErr := NewServiceUnavailableError(internalError.Error())
+		restErr.Err = string(internalError.Err)
+		restErr.RetryAfter = serviceBusyRetryAfter
+		return restErr
 	default:
 		return NewInternalServerError(internalError.Error())
 	}
 }
 
-func NewBadRequestError(message string, causes ...Causes) *RestErr {
+func NewBadRequestError(message string, causes ...FieldError) *RestErr {
 	return &RestErr{
 		Message: message,
 		Err:     "bad_request",
@@ -58,3 +141,74 @@ func NewNotFoundError(message string) *RestErr {
 		Causes:  nil,
 	}
 }
+
+func NewUnauthorizedError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "unauthorized",
+		Code:    http.StatusUnauthorized,
+		Causes:  nil,
+	}
+}
+
+func NewForbiddenError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "forbidden",
+		Code:    http.StatusForbidden,
+		Causes:  nil,
+	}
+}
+
+func NewTooManyRequestsError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "too_many_requests",
+		Code:    http.StatusTooManyRequests,
+		Causes:  nil,
+	}
+}
+
+func NewConflictError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "conflict",
+		Code:    http.StatusConflict,
+		Causes:  nil,
+	}
+}
+
+// serviceBusyRetryAfter is how long clients are told to wait before retrying a request
+// refused to shed load, long enough for a batch of bids to be written.
+const serviceBusyRetryAfter = time.Second
+
+func NewServiceUnavailableError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "service_unavailable",
+		Code:    http.StatusServiceUnavailable,
+		Causes:  nil,
+	}
+}
+
+// StatusClientClosedRequest is the non-standard status nginx logs for requests whose
+// client disconnected before the answer.
+const StatusClientClosedRequest = 499
+
+func NewClientClosedRequestError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "client_closed_request",
+		Code:    StatusClientClosedRequest,
+		Causes:  nil,
+	}
+}
+
+func NewGatewayTimeoutError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "gateway_timeout",
+		Code:    http.StatusGatewayTimeout,
+		Causes:  nil,
+	}
+}
@@ -0,0 +1,55 @@
+package clock
+
+import "time"
+
+// accelerated is a Clock running factor times faster than base from its creation on:
+// Now moves factor seconds per second of base, and timers wait a factor of their
+// duration on base, so an auction of 600 seconds closes after 10 seconds of base at a
+// factor of 60. The ticks of its timers carry the time of base.
+type accelerated struct {
+	base   Clock
+	origin time.Time
+	factor float64
+}
+
+// NewAccelerated returns a Clock running factor times faster than base, or base itself
+// for a factor of 1. It starts at the time of base, so the times stamped right after
+// startup match it and only drift ahead from then on. The factor must be positive.
+func NewAccelerated(base Clock, factor float64) Clock {
+	if factor == 1 {
+		return base
+	}
+
+	return &accelerated{base: base, origin: base.Now(), factor: factor}
+}
+
+func (a *accelerated) Now() time.Time {
+	return a.origin.Add(a.scale(a.base.Now().Sub(a.origin)))
+}
+
+func (a *accelerated) NewTimer(d time.Duration) Timer {
+	return &acceleratedTimer{Timer: a.base.NewTimer(a.wait(d)), clock: a}
+}
+
+func (a *accelerated) After(d time.Duration) <-chan time.Time {
+	return a.base.After(a.wait(d))
+}
+
+// scale turns a duration of base into one of the accelerated clock.
+func (a *accelerated) scale(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * a.factor)
+}
+
+// wait turns a duration of the accelerated clock into one of base.
+func (a *accelerated) wait(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / a.factor)
+}
+
+type acceleratedTimer struct {
+	Timer
+	clock *accelerated
+}
+
+func (t *acceleratedTimer) Reset(d time.Duration) bool {
+	return t.Timer.Reset(t.clock.wait(d))
+}
@@ -0,0 +1,110 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock for tests that only moves when told to. Timers created from it fire
+// once Advance or Set takes the time past their deadline.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	timer := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	timer.Reset(d)
+
+	f.mu.Lock()
+	f.timers = append(f.timers, timer)
+	f.mu.Unlock()
+
+	return timer
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).Chan()
+}
+
+// Advance moves the clock forward by d and fires the timers that are due.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	f.fireDue()
+}
+
+// Set moves the clock to now and fires the timers that are due.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+	f.fireDue()
+}
+
+// fireDue must be called with f.mu held.
+func (f *Fake) fireDue() {
+	for _, timer := range f.timers {
+		if timer.active && !timer.deadline.After(f.now) {
+			timer.fire(f.now)
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) Chan() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = true
+	t.deadline = t.clock.now.Add(d)
+	if d <= 0 {
+		t.fire(t.clock.now)
+	}
+	return wasActive
+}
+
+// fire must be called with the clock's lock held. Like time.Timer, a tick nobody
+// received yet is dropped rather than blocking the clock.
+func (t *fakeTimer) fire(now time.Time) {
+	t.active = false
+	select {
+	case t.c <- now:
+	default:
+	}
+}
@@ -0,0 +1,73 @@
+package auction_entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actors of the status changes made by the application itself. Changes made on behalf of
+// a user are recorded against the user id.
+const (
+	// ActorSystem is recorded when no actor was set on the context
+	ActorSystem = "system"
+
+	// ActorSweeper is the closer starting and closing auctions on their own schedule
+	ActorSweeper = "sweeper"
+)
+
+// Reasons recorded along with each status change.
+const (
+	AuditReasonStarted   = "start_time_reached"
+	AuditReasonExpired   = "end_time_reached"
+	AuditReasonClosedNow = "closed_early"
+	AuditReasonCancelled = "cancelled"
+	AuditReasonBoughtNow = "bought_now"
+
+	// AuditReasonStatusChanged covers the changes without a more specific reason
+	AuditReasonStatusChanged = "status_changed"
+)
+
+// AuctionAudit records one status change of an auction: who made it, when and why.
+type AuctionAudit struct {
+	Id        string
+	AuctionId string
+	From      AuctionStatus
+	To        AuctionStatus
+	Actor     string
+	Reason    string
+	Timestamp time.Time
+}
+
+// NewAuctionAudit records the change of auctionId from one status to another at
+// timestamp, made by the actor set on ctx.
+func NewAuctionAudit(
+	ctx context.Context, auctionId string, from, to AuctionStatus, reason string, timestamp time.Time) AuctionAudit {
+	return AuctionAudit{
+		Id:        uuid.New().String(),
+		AuctionId: auctionId,
+		From:      from,
+		To:        to,
+		Actor:     AuditActor(ctx),
+		Reason:    reason,
+		Timestamp: timestamp,
+	}
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a copy of ctx whose status changes are recorded against actor,
+// a user id or one of the Actor constants.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActor returns the actor set on ctx, ActorSystem when there is none.
+func AuditActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return ActorSystem
+}
@@ -2,77 +2,805 @@ package auction_entity
 
 import (
 	"context"
+	"fmt"
+	"fullcycle-auction_go/internal/clock"
 	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
 	"github.com/google/uuid"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// CreateAuction builds a new Active auction sold by sellerId, starting at the time of clk
+// and lasting duration, which must be within bounds. A zero duration leaves EndTime zero,
+// so the default duration is applied when the auction is persisted.
 func CreateAuction(
-	productName, category, description string,
-	condition ProductCondition) (*Auction, *internal_error.InternalError) {
+	sellerId, productName, category, description string,
+	condition ProductCondition,
+	duration time.Duration,
+	bounds DurationBounds,
+	clk clock.Clock) (*Auction, *internal_error.InternalError) {
 	auction := &Auction{
 		Id:          uuid.New().String(),
+		SellerId:    sellerId,
 		ProductName: productName,
 		Category:    category,
 		Description: description,
 		Condition:   condition,
+		Currency:    money.DefaultCurrency,
+		AuctionType: English,
 		Status:      Active,
-		Timestamp:   time.Now(),
+		Timestamp:   clk.Now(),
 	}
+	auction.StartTime = auction.Timestamp
 
-	if err := auction.Validate(); err != nil {
-		return nil, err
+	causes := auction.validateFields()
+	if duration != 0 {
+		if cause := bounds.validate("duration_seconds", duration); cause != nil {
+			causes = append(causes, *cause)
+		} else {
+			auction.EndTime = auction.Timestamp.Add(duration)
+		}
+	}
+
+	if len(causes) > 0 {
+		return nil, internal_error.NewValidationError("Invalid auction fields", causes...)
 	}
 
 	return auction, nil
 }
 
+// ScheduleStart makes the auction go live at startTime rather than right away. A start
+// time after the creation leaves the auction Scheduled until then, with its duration
+// counted from the start; start times not after the creation keep it Active.
+//
+// A start time further in the past than the clock skew allowed by bounds is rejected
+// under start_time, as is one leaving the end time not after the start.
+func (au *Auction) ScheduleStart(startTime time.Time, bounds DurationBounds) *internal_error.InternalError {
+	if startTime.IsZero() {
+		return nil
+	}
+
+	if skew := bounds.withDefaults().StartTimeSkew; startTime.Before(au.Timestamp.Add(-skew)) {
+		return internal_error.NewValidationError("Invalid auction fields", internal_error.FieldError{
+			Field:   "start_time",
+			Message: fmt.Sprintf("start_time can't be more than %d seconds in the past", int64(skew/time.Second)),
+		})
+	}
+
+	if startTime.After(au.Timestamp) {
+		if !au.EndTime.IsZero() {
+			au.EndTime = startTime.Add(au.EndTime.Sub(au.StartTime))
+		}
+		au.StartTime = startTime
+		au.Status = Scheduled
+	}
+
+	if !au.EndTime.IsZero() && !au.EndTime.After(au.StartTime) {
+		return internal_error.NewValidationError("Invalid auction fields", internal_error.FieldError{
+			Field:   "end_time",
+			Message: "end_time must be after start_time",
+		})
+	}
+
+	return nil
+}
+
+// ValidateNotEnded rejects an Active or Scheduled auction whose end time is not after
+// now. The repositories check it before storing an auction, so none is stored already
+// over, whatever computed its end time.
+func (au *Auction) ValidateNotEnded(now time.Time) *internal_error.InternalError {
+	if (au.Status != Active && au.Status != Scheduled) || au.EndTime.After(now) {
+		return nil
+	}
+
+	return internal_error.NewValidationError("Invalid auction fields", internal_error.FieldError{
+		Field:   "end_time",
+		Message: "end_time is already in the past",
+	})
+}
+
+// ValidateAcceptsBids rejects a bid placed at now on an auction that isn't taking bids:
+// ErrAuctionNotStarted while it is Scheduled, and ErrAuctionNotActive once it is over,
+// whether closed, cancelled or past its end time. A deleted auction isn't found.
+func (au *Auction) ValidateAcceptsBids(now time.Time) *internal_error.InternalError {
+	if au.DeletedAt != nil {
+		return internal_error.NewAuctionNotFoundError(au.Id)
+	}
+	if au.Status == Scheduled {
+		return internal_error.ErrAuctionNotStarted
+	}
+	if au.Status != Active || !now.Before(au.EndTime) {
+		return internal_error.ErrAuctionNotActive
+	}
+
+	return nil
+}
+
+// Default bounds of an auction duration, whether sent with the auction or taken from the
+// default of its category, and how far in the past its start time may be, allowing for
+// the clock of the client running behind
+const (
+	MinDuration   = 30 * time.Second
+	MaxDuration   = 30 * 24 * time.Hour
+	StartTimeSkew = time.Minute
+)
+
+// DurationBounds are the shortest and longest an auction may last and how far in the past
+// its start time may be, as configured. A zero field takes the default above.
+type DurationBounds struct {
+	Min           time.Duration
+	Max           time.Duration
+	StartTimeSkew time.Duration
+}
+
+func (b DurationBounds) withDefaults() DurationBounds {
+	if b.Min <= 0 {
+		b.Min = MinDuration
+	}
+	if b.Max <= 0 {
+		b.Max = MaxDuration
+	}
+	if b.StartTimeSkew <= 0 {
+		b.StartTimeSkew = StartTimeSkew
+	}
+
+	return b
+}
+
+// ValidateSeconds checks a duration in seconds against the bounds, reporting a failure
+// under field. It returns nil for a valid duration. Seconds are checked before any
+// conversion, so huge values can't overflow.
+func (b DurationBounds) ValidateSeconds(field string, seconds int64) *internal_error.FieldError {
+	b = b.withDefaults()
+	if minSeconds := int64(b.Min / time.Second); seconds < minSeconds {
+		return &internal_error.FieldError{Field: field,
+			Message: fmt.Sprintf("%s must be at least %d seconds", field, minSeconds)}
+	}
+	if maxSeconds := int64(b.Max / time.Second); seconds > maxSeconds {
+		return &internal_error.FieldError{Field: field,
+			Message: fmt.Sprintf("%s must be at most %d seconds", field, maxSeconds)}
+	}
+
+	return nil
+}
+
+// validate checks a duration against the bounds like ValidateSeconds, to the nanosecond.
+func (b DurationBounds) validate(field string, duration time.Duration) *internal_error.FieldError {
+	if b = b.withDefaults(); duration < b.Min || duration > b.Max {
+		return b.ValidateSeconds(field, int64(duration/time.Second))
+	}
+
+	return nil
+}
+
+// Validate checks every auction field and reports all the failing ones at once, named
+// as in the API.
 func (au *Auction) Validate() *internal_error.InternalError {
-	if len(au.ProductName) <= 1 ||
-		len(au.Category) <= 2 ||
-		len(au.Description) <= 10 && (au.Condition != New &&
-			au.Condition != Refurbished &&
-			au.Condition != Used) {
-		return internal_error.NewBadRequestError("invalid auction object")
+	if causes := au.validateFields(); len(causes) > 0 {
+		return internal_error.NewValidationError("Invalid auction fields", causes...)
+	}
+
+	return nil
+}
+
+func (au *Auction) validateFields() []internal_error.FieldError {
+	var causes []internal_error.FieldError
+	invalid := func(field, message string) {
+		causes = append(causes, internal_error.FieldError{Field: field, Message: message})
+	}
+
+	if err := uuid.Validate(au.SellerId); err != nil {
+		invalid("seller_id", "seller_id must be a valid UUID")
+	}
+
+	if len(strings.TrimSpace(au.ProductName)) < 2 {
+		invalid("product_name", "product_name must be at least 2 characters in length")
+	}
+
+	if len(strings.TrimSpace(au.Category)) < 3 {
+		invalid("category", "category must be at least 3 characters in length")
+	}
+
+	if description := strings.TrimSpace(au.Description); len(description) < 10 || len(au.Description) > 200 {
+		invalid("description", "description must be between 10 and 200 characters in length")
+	}
+
+	if !au.Condition.Valid() {
+		invalid("condition", "condition must be one of new, used or refurbished")
+	}
+
+	if !money.IsSupportedCurrency(au.Currency) {
+		invalid("currency", "currency must be one of "+strings.Join(money.SupportedCurrencies, ", "))
+	}
+
+	if !au.AuctionType.Valid() {
+		invalid("auction_type", "auction_type must be one of "+strings.Join(AuctionTypes, ", "))
+	}
+
+	if au.StartingPrice < 0 {
+		invalid("starting_price", "starting_price can't be negative")
+	}
+
+	if au.ReservePrice < 0 {
+		invalid("reserve_price", "reserve_price can't be negative")
+	} else if au.ReservePrice > 0 && au.ReservePrice < au.StartingPrice {
+		invalid("reserve_price", "reserve_price can't be lower than the starting price")
+	}
+
+	switch {
+	case au.BuyNowPrice < 0:
+		invalid("buy_now_price", "buy_now_price can't be negative")
+	case au.BuyNowPrice == 0:
+	case au.AuctionType == Vickrey:
+		invalid("buy_now_price", "buy_now_price can't be set on vickrey auctions")
+	case au.BuyNowPrice < au.StartingPrice || au.BuyNowPrice < au.ReservePrice:
+		invalid("buy_now_price", "buy_now_price can't be lower than the starting or the reserve price")
+	}
+
+	causes = append(causes, validateImages(au.Images)...)
+
+	return causes
+}
+
+// ValidateImages checks a set of images the way CreateAuction does, reporting every
+// invalid one at once.
+func ValidateImages(images []AuctionImage) *internal_error.InternalError {
+	if causes := validateImages(images); len(causes) > 0 {
+		return internal_error.NewValidationError("Invalid auction images", causes...)
+	}
+
+	return nil
+}
+
+func validateImages(images []AuctionImage) []internal_error.FieldError {
+	var causes []internal_error.FieldError
+	if len(images) > MaxAuctionImages {
+		causes = append(causes, internal_error.FieldError{
+			Field: "images", Message: fmt.Sprintf("an auction can have at most %d images", MaxAuctionImages)})
+	}
+
+	for i, image := range images {
+		if !isImageURL(image.URL) {
+			causes = append(causes, internal_error.FieldError{
+				Field:   fmt.Sprintf("images[%d].url", i),
+				Message: "url must be an absolute http or https URL"})
+		}
+
+		if len(image.AltText) > maxImageAltTextLength {
+			causes = append(causes, internal_error.FieldError{
+				Field:   fmt.Sprintf("images[%d].alt", i),
+				Message: fmt.Sprintf("alt must be at most %d characters in length", maxImageAltTextLength)})
+		}
+	}
+
+	return causes
+}
+
+func isImageURL(rawURL string) bool {
+	parsed, err := url.ParseRequestURI(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// SetImages replaces the images of the auction, numbering them in the order given.
+func (au *Auction) SetImages(images []AuctionImage) *internal_error.InternalError {
+	au.Images = NumberImages(images)
+
+	return au.Validate()
+}
+
+// NumberImages returns a copy of images with their Order set to their position. An
+// empty set stays empty rather than nil, so it is stored as such.
+func NumberImages(images []AuctionImage) []AuctionImage {
+	numbered := make([]AuctionImage, len(images))
+	for i, image := range images {
+		image.Order = i + 1
+		numbered[i] = image
+	}
+
+	return numbered
+}
+
+// SetPrices sets the starting price, the minimum for the first bid, and the optional
+// reserve price, below which the auction closes without a sale, both in cents. A zero
+// reserve means there is none.
+func (au *Auction) SetPrices(startingPrice, reservePrice int64) *internal_error.InternalError {
+	au.StartingPrice = startingPrice
+	au.ReservePrice = reservePrice
+
+	return au.Validate()
+}
+
+// SetBuyNowPrice sets the price, in cents, at which a bid buys the auction outright and
+// ends it right away, see BuyNowAvailable. A zero price means there is none.
+func (au *Auction) SetBuyNowPrice(buyNowPrice int64) *internal_error.InternalError {
+	au.BuyNowPrice = buyNowPrice
+
+	return au.Validate()
+}
+
+// BuyNowAvailable reports whether the auction can still be bought at its buy-now price:
+// it has one, it is Active and no bid reached it yet.
+func (au *Auction) BuyNowAvailable() bool {
+	return au.BuyNowPrice > 0 && au.Status == Active && au.CurrentHighestAmount < au.BuyNowPrice
+}
+
+// SetCurrency prices the auction in currency, an ISO 4217 code among
+// money.SupportedCurrencies matched regardless of case. An empty currency keeps
+// money.DefaultCurrency.
+func (au *Auction) SetCurrency(currency string) *internal_error.InternalError {
+	if currency = strings.ToUpper(strings.TrimSpace(currency)); currency != "" {
+		au.Currency = currency
+	}
+
+	return au.Validate()
+}
+
+// SetAuctionType sets how the auction is won and paid for, one of AuctionTypes matched
+// regardless of case. An empty type keeps English.
+func (au *Auction) SetAuctionType(auctionType string) *internal_error.InternalError {
+	if auctionType = strings.ToLower(strings.TrimSpace(auctionType)); auctionType != "" {
+		au.AuctionType = AuctionType(auctionType)
+	}
+
+	return au.Validate()
+}
+
+// BidAmountsHidden reports whether the amounts of the bids on the auction must be kept
+// from everyone: the bids of a Vickrey auction are sealed until it closes.
+func (au *Auction) BidAmountsHidden() bool {
+	return au.AuctionType == Vickrey && au.Status != Completed
+}
+
+// SettlementFor is what the winner pays given the winning bid amount and runnerUpAmount,
+// the highest amount bid by any other bidder, zero when nobody else bid. English
+// auctions are paid the winning amount. Vickrey auctions are paid the runner-up amount,
+// or the starting price when there is no runner-up, raised to the reserve price and
+// never more than the winning amount.
+func (au *Auction) SettlementFor(winningAmount, runnerUpAmount int64) int64 {
+	if au.AuctionType != Vickrey {
+		return winningAmount
+	}
+
+	settlement := runnerUpAmount
+	if settlement < au.StartingPrice {
+		settlement = au.StartingPrice
+	}
+	if settlement < au.ReservePrice {
+		settlement = au.ReservePrice
+	}
+	if settlement > winningAmount {
+		settlement = winningAmount
+	}
+
+	return settlement
+}
+
+// OutcomeFor decides how the auction ends given its winning bid amount, if it got any bid.
+func (au *Auction) OutcomeFor(winningAmount int64, hasBids bool) AuctionOutcome {
+	switch {
+	case !hasBids:
+		return NoBids
+	case au.ReservePrice > 0 && winningAmount < au.ReservePrice:
+		return ReserveNotMet
+	default:
+		return Sold
+	}
+}
+
+// IsOwnedBy reports whether sellerId is the seller of the auction. Auctions without a
+// seller are owned by nobody.
+func (au *Auction) IsOwnedBy(sellerId string) bool {
+	return au.SellerId != "" && au.SellerId == sellerId
+}
+
+// AuctionUpdate carries the fields a seller can still fix while the auction has no
+// bids. Nil fields are left untouched.
+type AuctionUpdate struct {
+	ProductName *string
+	Category    *string
+	Description *string
+}
+
+func (u AuctionUpdate) IsEmpty() bool {
+	return u.ProductName == nil && u.Category == nil && u.Description == nil
+}
+
+// Validate applies the same limits CreateAuction does to the fields being changed.
+func (u AuctionUpdate) Validate() *internal_error.InternalError {
+	if u.IsEmpty() {
+		return internal_error.NewBadRequestError("at least one auction field must be updated")
+	}
+
+	if u.ProductName != nil && len(strings.TrimSpace(*u.ProductName)) <= 1 {
+		return internal_error.NewBadRequestError("invalid product name")
+	}
+
+	if u.Category != nil && len(strings.TrimSpace(*u.Category)) <= 2 {
+		return internal_error.NewBadRequestError("invalid category")
+	}
+
+	if u.Description != nil &&
+		(len(strings.TrimSpace(*u.Description)) < 10 || len(*u.Description) > 200) {
+		return internal_error.NewBadRequestError("invalid description")
 	}
 
 	return nil
 }
 
 type Auction struct {
-	Id          string
+	Id string
+
+	// SellerId is the user who created the auction and may edit or cancel it. Auctions
+	// created before sellers were recorded have none.
+	SellerId    string
 	ProductName string
 	Category    string
 	Description string
 	Condition   ProductCondition
 	Status      AuctionStatus
 	Timestamp   time.Time
+	EndTime     time.Time
+
+	// StartTime is when the auction goes live and starts counting down to EndTime. It is
+	// the creation time, unless the auction was scheduled to start later.
+	StartTime time.Time
+
+	// Currency is the ISO 4217 code the prices and every bid of the auction are in, one
+	// of money.SupportedCurrencies. Auctions created before it existed are in
+	// money.DefaultCurrency.
+	Currency string
+
+	// AuctionType decides how the auction is won and paid for. Auctions created before it
+	// existed are English.
+	AuctionType AuctionType
+
+	// Prices are in cents of Currency, see the money package
+	StartingPrice int64
+	ReservePrice  int64
+	Outcome       AuctionOutcome
+
+	// BuyNowPrice is what a bid must reach to buy the auction outright, in cents of
+	// Currency, zero when the seller set none
+	BuyNowPrice int64
+
+	// DeletedAt is set when an admin hides the auction. Soft-deleted auctions keep their
+	// bids but are left out of every find and accept no bids.
+	DeletedAt *time.Time
+
+	// ClosedAt and WinningBid are recorded when the auction is closed. WinningBid stays
+	// nil when the auction closed without bids, and for auctions closed before the
+	// snapshot was recorded.
+	ClosedAt   *time.Time
+	WinningBid *WinningBid
+
+	// BidCount and CurrentHighestAmount are kept up to date by every accepted bid, so
+	// listings don't have to look the bids up. CurrentHighestAmount is zero until the
+	// first bid.
+	BidCount             int64
+	CurrentHighestAmount int64
+
+	// Revision goes up with every write to the auction, bids included, so a copy of the
+	// same revision is still current. It is zero until the first write after it existed.
+	Revision int64
+
+	// Version only goes up with the changes made by the seller: updates, new images and
+	// cancelling. Those writes only apply at the version they were read at, so two of them
+	// racing don't silently overwrite each other. Bids leave it alone; their counters are
+	// commutative and would make every seller change fail while the auction is busy.
+	Version int64
+
+	// Images are kept in the order they were submitted in, see SetImages
+	Images []AuctionImage
+}
+
+const (
+	// MaxAuctionImages is how many images an auction can have
+	MaxAuctionImages = 10
+
+	maxImageAltTextLength = 200
+)
+
+// AuctionImage points to a picture of the product hosted elsewhere; only its metadata is
+// stored.
+type AuctionImage struct {
+	URL string
+
+	// Order is the position of the image in the auction, starting at 1
+	Order   int
+	AltText string
+}
+
+// WinningBid is the winning bid as it was when the auction closed, so the winner of a
+// Completed auction doesn't change if its bids or its bidder are changed afterwards.
+type WinningBid struct {
+	BidId  string
+	UserId string
+
+	// Amount is in cents of Currency
+	Amount    int64
+	Currency  string
+	Timestamp time.Time
+
+	// SettlementAmount is what the winner pays, in cents of Currency, see
+	// Auction.SettlementFor. It is the Amount for English auctions and for snapshots
+	// recorded before it existed.
+	SettlementAmount int64
+}
+
+type includeDeletedKey struct{}
+
+// WithDeleted returns a copy of ctx under which the repository finds also return
+// soft-deleted auctions.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether ctx was returned by WithDeleted.
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
+
+// AuctionType is how an auction is won and paid for, stored and sent by name.
+type AuctionType string
+
+const (
+	// English auctions are won by the highest bid, which every bid must beat and which
+	// the winner pays. Bids are public.
+	English AuctionType = "english"
+
+	// Vickrey auctions are sealed second-price auctions: bids don't have to beat each
+	// other and their amounts stay hidden until the auction closes, when the highest bid
+	// wins and pays the amount of the runner-up, see Auction.SettlementFor.
+	Vickrey AuctionType = "vickrey"
+)
+
+// AuctionTypes are the names of the auction types the API accepts.
+var AuctionTypes = []string{string(English), string(Vickrey)}
+
+func (t AuctionType) Valid() bool {
+	return t == English || t == Vickrey
 }
 
 type ProductCondition int
 type AuctionStatus int
 
+// AuctionOutcome records how a Completed auction ended. It is decided when the auction
+// closes, so Scheduled, Active and Cancelled auctions have none.
+type AuctionOutcome int
+
 const (
 	Active AuctionStatus = iota
 	Completed
+	Cancelled
+
+	// Scheduled auctions don't accept bids until their start time, when the closer makes
+	// them Active
+	Scheduled
+)
+
+const (
+	NoOutcome AuctionOutcome = iota
+	Sold
+	NoBids
+	ReserveNotMet
+
+	// BoughtNow auctions were closed before their end time by a bid reaching their
+	// buy-now price
+	BoughtNow
 )
 
+// SaleOutcomes are the outcomes of the auctions that ended with a sale to their winner.
+var SaleOutcomes = []AuctionOutcome{Sold, BoughtNow}
+
+// IsSale reports whether the auction was sold to its winner, see SaleOutcomes.
+func (o AuctionOutcome) IsSale() bool {
+	return o == Sold || o == BoughtNow
+}
+
 const (
 	New ProductCondition = iota + 1
 	Used
 	Refurbished
 )
 
+// The names of the conditions and statuses are how the API sends them; they are stored
+// by number.
+var (
+	productConditionNames = map[ProductCondition]string{New: "new", Used: "used", Refurbished: "refurbished"}
+
+	auctionStatusNames = map[AuctionStatus]string{
+		Active: "active", Completed: "completed", Cancelled: "cancelled", Scheduled: "scheduled",
+	}
+)
+
+func (c ProductCondition) Valid() bool {
+	_, ok := productConditionNames[c]
+	return ok
+}
+
+// String returns the name of the condition, or its number when it is unknown.
+func (c ProductCondition) String() string {
+	if name, ok := productConditionNames[c]; ok {
+		return name
+	}
+
+	return fmt.Sprint(int(c))
+}
+
+// ParseProductCondition finds the condition named name, ignoring case.
+func ParseProductCondition(name string) (ProductCondition, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for condition, candidate := range productConditionNames {
+		if candidate == name {
+			return condition, true
+		}
+	}
+
+	return 0, false
+}
+
+// String returns the name of the status, or its number when it is unknown.
+func (s AuctionStatus) String() string {
+	if name, ok := auctionStatusNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprint(int(s))
+}
+
+// ParseAuctionStatus finds the status named name, ignoring case.
+func ParseAuctionStatus(name string) (AuctionStatus, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for status, candidate := range auctionStatusNames {
+		if candidate == name {
+			return status, true
+		}
+	}
+
+	return 0, false
+}
+
+// AuctionFilter narrows FindAuctions. Empty fields don't filter: no statuses matches
+// every status and a zero time leaves that side of the creation range open.
+type AuctionFilter struct {
+	SellerId      string
+	Statuses      []AuctionStatus
+	Category      string
+	ProductName   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// Sort orders the results, newest first by default. Ties are broken by id so
+	// pagination stays stable.
+	Sort AuctionSort
+}
+
+// AuctionSort is the order FindAuctions returns auctions in.
+type AuctionSort int
+
+const (
+	SortNewest AuctionSort = iota
+	SortOldest
+
+	// SortEndingSoon orders by end_time, the auction closing first coming first
+	SortEndingSoon
+
+	// SortMostBids orders by bid count, newest first among equal counts
+	SortMostBids
+)
+
 type AuctionRepositoryInterface interface {
 	CreateAuction(
 		ctx context.Context,
 		auctionEntity *Auction) *internal_error.InternalError
 
+	// CreateAuctions stores each auction independently, so one failing doesn't keep the
+	// others from being stored. The returned errors line up with auctionEntities and are
+	// nil for the auctions stored.
+	CreateAuctions(
+		ctx context.Context,
+		auctionEntities []*Auction) []*internal_error.InternalError
+
 	FindAuctions(
 		ctx context.Context,
-		status AuctionStatus,
-		category, productName string) ([]Auction, *internal_error.InternalError)
+		filter AuctionFilter,
+		page, pageSize int) ([]Auction, int64, *internal_error.InternalError)
 
 	FindAuctionById(
 		ctx context.Context, id string) (*Auction, *internal_error.InternalError)
+
+	// StreamAuctions calls fn with every auction matching filter, in the filter's order,
+	// reading them one at a time so an export never holds them all in memory. It stops
+	// at the first error returned by fn.
+	StreamAuctions(
+		ctx context.Context,
+		filter AuctionFilter,
+		fn func(auction Auction) error) *internal_error.InternalError
+
+	// UpdateAuctionStatus moves the auction at version from one status to another and
+	// records the change in its audit log, against the actor set on ctx. It returns
+	// ErrAuctionModified when the auction is no longer at version.
+	UpdateAuctionStatus(
+		ctx context.Context, id string, version int64, from, to AuctionStatus) *internal_error.InternalError
+
+	// UpdateAuction applies the update only while the auction is Active, has no bids and
+	// is still at version, checked atomically with the write, and returns the updated
+	// auction.
+	UpdateAuction(
+		ctx context.Context, id string, version int64, update AuctionUpdate) (*Auction, *internal_error.InternalError)
+
+	ExtendAuctionEndTime(
+		ctx context.Context,
+		id string,
+		window, extension, maxExtension time.Duration) (time.Time, bool, *internal_error.InternalError)
+
+	// SoftDeleteAuction marks the auction as deleted, returning not found when it doesn't
+	// exist or is already deleted.
+	SoftDeleteAuction(ctx context.Context, id string) *internal_error.InternalError
+
+	// ReplaceAuctionImages sets the images of the auction while it is Active, returning
+	// ErrAuctionNotActive otherwise, and returns the updated auction. Like UpdateAuction it
+	// returns ErrAuctionModified when the auction is no longer at version.
+	ReplaceAuctionImages(
+		ctx context.Context, id string, version int64, images []AuctionImage) (*Auction, *internal_error.InternalError)
+
+	// FindAuditByAuctionId returns the status changes of the auction, oldest first.
+	FindAuditByAuctionId(ctx context.Context, auctionId string) ([]AuctionAudit, *internal_error.InternalError)
+
+	// AggregateTopSellers ranks the sellers by the amount their auctions sold for, among
+	// the auctions closed between from and to, returning at most limit of them. Amounts
+	// aren't converted, so a seller gets one entry per currency they sold in.
+	AggregateTopSellers(
+		ctx context.Context, from, to time.Time, limit int) ([]TopSeller, *internal_error.InternalError)
+
+	// GetFacets counts the auctions matching filter by category and by condition, in one
+	// pass. The order and pagination of filter don't apply.
+	GetFacets(ctx context.Context, filter AuctionFilter) (*AuctionFacets, *internal_error.InternalError)
+
+	// RenameCategory moves every auction of the category named from to the name to,
+	// returning how many auctions changed.
+	RenameCategory(ctx context.Context, from, to string) (int64, *internal_error.InternalError)
+
+	// CountAuctionsByStatus counts the auctions that weren't deleted, by status.
+	CountAuctionsByStatus(ctx context.Context) (map[AuctionStatus]int64, *internal_error.InternalError)
+
+	// FindDuplicateAuction returns the id of an Active auction, not deleted, of the same
+	// seller, product name and category as auctionEntity and created since since, or an
+	// empty id when there is none.
+	FindDuplicateAuction(
+		ctx context.Context, auctionEntity *Auction, since time.Time) (string, *internal_error.InternalError)
+}
+
+// AuctionFacets counts the auctions matching a filter by category and by condition,
+// each sorted by count, most common first, and then by value.
+type AuctionFacets struct {
+	Categories []CategoryCount
+	Conditions []ConditionCount
+}
+
+type CategoryCount struct {
+	Category string
+	Count    int64
+}
+
+type ConditionCount struct {
+	Condition ProductCondition
+	Count     int64
+}
+
+// TopSeller sums up the auctions a seller sold in a period in one currency, with the
+// total in cents of Currency. SellerName is empty when the seller's user no longer exists.
+type TopSeller struct {
+	SellerId     string
+	SellerName   string
+	Currency     string
+	AuctionsSold int64
+	TotalSold    int64
 }
@@ -0,0 +1,16 @@
+package auction_entity
+
+import "time"
+
+// CloseFailure records an expired auction the closer failed to close even after
+// retrying, e.g. because its document can't be updated. The closer leaves it alone
+// until NextRetryAt, so a close that keeps failing doesn't run on every sweep, and the
+// record is dropped once the auction closes.
+type CloseFailure struct {
+	AuctionId     string
+	Error         string
+	Attempts      int64
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+	NextRetryAt   time.Time
+}
@@ -0,0 +1,9 @@
+package auction_entity
+
+// ClosedAuction is an auction read back right after the closer closed it, with its
+// winner snapshot and the name of the winning user. WinnerName is empty when the
+// auction had no winner or the user no longer exists.
+type ClosedAuction struct {
+	Auction
+	WinnerName string
+}
@@ -2,26 +2,50 @@ package bid_entity
 
 import (
 	"context"
+	"fullcycle-auction_go/internal/clock"
 	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
 	"github.com/google/uuid"
 	"time"
 )
 
+// Bid amounts are in cents of Currency, see the money package.
 type Bid struct {
 	Id        string
 	UserId    string
 	AuctionId string
-	Amount    float64
+	Amount    int64
+	Currency  string
 	Timestamp time.Time
+
+	// Proxy marks a bid placed automatically on behalf of a maximum bid
+	Proxy bool
+
+	// Reserved is set on a bid whose amount was held from the bidder's balance when it
+	// took the lead, with BID_BALANCE_MODE=reserve. The hold is released when the bid is
+	// outbid or its auction is cancelled.
+	Reserved bool
+
+	// CounterBid is the proxy bid answering this one, if any. Repositories store it right
+	// after the bid and in the same transaction, so the bid is never seen leading alone.
+	CounterBid *Bid
 }
 
-func CreateBid(userId, auctionId string, amount float64) (*Bid, *internal_error.InternalError) {
+// CreateBid builds a bid of amount cents, stamped with the time of clk. An empty currency
+// means money.DefaultCurrency.
+func CreateBid(
+	userId, auctionId string, amount int64, currency string, clk clock.Clock) (*Bid, *internal_error.InternalError) {
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
 	bid := &Bid{
 		Id:        uuid.New().String(),
 		UserId:    userId,
 		AuctionId: auctionId,
 		Amount:    amount,
-		Timestamp: time.Now(),
+		Currency:  currency,
+		Timestamp: clk.Now(),
 	}
 
 	if err := bid.Validate(); err != nil {
@@ -38,19 +62,157 @@ func (b *Bid) Validate() *internal_error.InternalError {
 		return internal_error.NewBadRequestError("AuctionId is not a valid id")
 	} else if b.Amount <= 0 {
 		return internal_error.NewBadRequestError("Amount is not a valid value")
+	} else if err := money.ValidateCurrency(b.Currency); err != nil {
+		return internal_error.NewBadRequestError("Currency is not a valid ISO 4217 code")
 	}
 
 	return nil
 }
 
+// BidStats aggregates the bids of an auction, with amounts in cents and the average
+// rounded to the nearest cent. An auction without bids has all zeros.
+type BidStats struct {
+	BidCount      int64
+	HighestBid    int64
+	LowestBid     int64
+	AverageBid    int64
+	UniqueBidders int64
+}
+
+// SelfBidCount sums up the bids a seller placed on their own auctions.
+type SelfBidCount struct {
+	UserId       string
+	AuctionCount int64
+	BidCount     int64
+}
+
+// TopBidder sums up the bids a user placed in a period in one currency, with the total
+// in cents of Currency. UserName is empty when the user no longer exists.
+type TopBidder struct {
+	UserId      string
+	UserName    string
+	Currency    string
+	BidCount    int64
+	TotalAmount int64
+}
+
+// BidActivity counts the bids placed in the last hour and in the last day.
+type BidActivity struct {
+	LastHour int64
+	LastDay  int64
+}
+
+// UserBidStats sums up the participation of a user: the auctions they bid on and the
+// bids they placed, and the auctions they won with the total of their winning bids, in
+// cents. A user without bids has all zeros.
+type UserBidStats struct {
+	AuctionsBidOn int64
+	BidCount      int64
+	AuctionsWon   int64
+	TotalSpent    int64
+}
+
+// BidListFilter orders and pages FindBidByAuctionId. A zero Limit returns every bid
+// from Offset on.
+type BidListFilter struct {
+	// Ascending lists the oldest bid first; bids are listed newest first by default
+	Ascending bool
+	Limit     int
+	Offset    int
+
+	// After starts the listing right after the bid it points to, in place of Offset
+	After *BidCursor
+}
+
+// BidCursor points to a bid of a listing by its timestamp and id, which order the bids
+// sharing a timestamp.
+type BidCursor struct {
+	Timestamp time.Time
+	Id        string
+}
+
+// BidExportFilter picks the bids StreamBids reads. Empty fields don't filter; From and To
+// bound the timestamp, both included.
+type BidExportFilter struct {
+	AuctionId string
+	From      time.Time
+	To        time.Time
+}
+
 type BidEntityRepository interface {
+	// CreateBid writes a batch of accepted bids, each on its own, and returns the result
+	// of each write in the order of bidEntities: nil for a bid stored, or for the retry
+	// of a bid already stored, and why the bid was rejected otherwise, e.g.
+	// internal_error.ErrAuctionNotActive when its auction closed first.
 	CreateBid(
 		ctx context.Context,
-		bidEntities []Bid) *internal_error.InternalError
+		bidEntities []Bid) []*internal_error.InternalError
 
 	FindBidByAuctionId(
-		ctx context.Context, auctionId string) ([]Bid, *internal_error.InternalError)
+		ctx context.Context, auctionId string, filter BidListFilter) ([]Bid, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context, auctionId string) (*Bid, *internal_error.InternalError)
+
+	FindBidsByUserId(
+		ctx context.Context, userId string, onlyActiveAuctions bool) ([]Bid, *internal_error.InternalError)
+
+	// BuyNow stores the bid and closes its auction in the same write, with the bid as the
+	// winner and auction_entity.BoughtNow as the outcome. The bid amount must be the
+	// buy-now price of the auction, which must still be available: the auction is Active,
+	// didn't end and no bid reached the price. Otherwise nothing is written and it returns
+	// internal_error.ErrBuyNowUnavailable, so of concurrent buyers only the first gets it.
+	BuyNow(ctx context.Context, bidEntity *Bid) *internal_error.InternalError
+
+	GetAuctionBidStats(
+		ctx context.Context, auctionId string) (*BidStats, *internal_error.InternalError)
+
+	// FindBidByUserAndAmount returns the bid of userId on auctionId for exactly amount
+	// cents, the one a retried bid duplicates, or a not found error when there is none.
+	FindBidByUserAndAmount(
+		ctx context.Context, auctionId, userId string, amount int64) (*Bid, *internal_error.InternalError)
+
+	// CountUserBids returns how many bids userId placed on auctionId, proxy bids
+	// included. The MongoDB repository keeps a counter per user and auction, so it stays
+	// cheap however many bids there are.
+	CountUserBids(
+		ctx context.Context, auctionId, userId string) (int64, *internal_error.InternalError)
+
+	// CountBidsByUserOnOwnAuctions lists the sellers who bid on their own auctions, most
+	// bids first. Auctions without a seller are left out.
+	CountBidsByUserOnOwnAuctions(ctx context.Context) ([]SelfBidCount, *internal_error.InternalError)
+
+	// AggregateUserStats sums up the bids of userId. An auction counts as won when it was
+	// sold and the bid of its winner snapshot is one of the user's.
+	AggregateUserStats(ctx context.Context, userId string) (*UserBidStats, *internal_error.InternalError)
+
+	// AggregateTopBidders ranks the users by the number of bids they placed between from
+	// and to, then by their total amount, returning at most limit of them. Amounts aren't
+	// converted, so a user gets one entry per currency they bid in.
+	AggregateTopBidders(
+		ctx context.Context, from, to time.Time, limit int) ([]TopBidder, *internal_error.InternalError)
+
+	// SaveMaxBid stores the maximum bid of its user on its auction, replacing the
+	// previous one.
+	SaveMaxBid(ctx context.Context, maxBid *MaxBid) *internal_error.InternalError
+
+	// FindMaxBid returns the maximum bid of userId on auctionId, or a not found error
+	// when the user has none.
+	FindMaxBid(
+		ctx context.Context, auctionId, userId string) (*MaxBid, *internal_error.InternalError)
+
+	// StreamBids calls fn with every bid matching filter, oldest first, reading them a
+	// batch at a time so an export never holds them all in memory. It stops at the first
+	// error returned by fn, and once ctx is done.
+	StreamBids(
+		ctx context.Context, filter BidExportFilter, fn func(bid Bid) error) *internal_error.InternalError
+
+	// CountRecentBids counts the bids placed in the hour and in the day before now.
+	CountRecentBids(ctx context.Context, now time.Time) (*BidActivity, *internal_error.InternalError)
+
+	// AverageTimeToFirstBid averages the time from the start of each auction created since
+	// since to its first bid, leaving out the auctions without bids. It returns false when
+	// none of them has any.
+	AverageTimeToFirstBid(
+		ctx context.Context, since time.Time) (time.Duration, bool, *internal_error.InternalError)
 }
@@ -0,0 +1,102 @@
+package bid_entity
+
+import (
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"github.com/google/uuid"
+	"time"
+)
+
+// MaxBid is the most a user is willing to pay for an auction. While the user is
+// outbid, proxy bids are placed on their behalf up to Amount, in cents of Currency.
+type MaxBid struct {
+	AuctionId string
+	UserId    string
+	Amount    int64
+	Currency  string
+	Timestamp time.Time
+}
+
+// CreateMaxBid builds the maximum bid of userId on auctionId, stamped with the time of
+// clk. An empty currency means money.DefaultCurrency.
+func CreateMaxBid(
+	userId, auctionId string, amount int64, currency string,
+	clk clock.Clock) (*MaxBid, *internal_error.InternalError) {
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	if err := uuid.Validate(userId); err != nil {
+		return nil, internal_error.NewBadRequestError("invalid user id")
+	}
+	if err := uuid.Validate(auctionId); err != nil {
+		return nil, internal_error.NewBadRequestError("invalid auction id")
+	}
+	if amount <= 0 {
+		return nil, internal_error.NewBadRequestError("maximum bid amount must be greater than zero")
+	}
+
+	return &MaxBid{
+		AuctionId: auctionId,
+		UserId:    userId,
+		Amount:    amount,
+		Currency:  currency,
+		Timestamp: clk.Now(),
+	}, nil
+}
+
+// ResolveProxyBids returns the bids to place, in order, when bid meets the current
+// leading bid. bidMax is the most the bidder pays: the bid amount itself for a plain
+// bid, the bidder's maximum for a proxy one. leadingMax is the leader's maximum, or the
+// leading amount when the leader has none.
+//
+// The higher maximum wins, and equal maximums go to the leader, who got there first.
+// The loser bids up to their maximum and the winner answers with increment on top of
+// it, capped at the winner's own maximum, so two proxies always settle at the second
+// maximum plus increment. The returned bids are each strictly above the one before; a
+// loser's bid that couldn't be, because the maximums are equal, is left out.
+func ResolveProxyBids(bid Bid, bidMax int64, leading Bid, leadingMax int64, increment int64) []Bid {
+	if increment < 1 {
+		increment = 1
+	}
+
+	if leadingMax <= bid.Amount {
+		return []Bid{bid}
+	}
+
+	if bidMax > leadingMax {
+		bid.Amount = minAmount(leadingMax+increment, bidMax)
+		bid.Proxy = true
+		return []Bid{proxyBid(leading.UserId, bid, leadingMax), bid}
+	}
+
+	leaderAmount := minAmount(bidMax+increment, leadingMax)
+	if bidMax >= leaderAmount {
+		return []Bid{proxyBid(leading.UserId, bid, leaderAmount)}
+	}
+
+	bid.Amount = bidMax
+	return []Bid{bid, proxyBid(leading.UserId, bid, leaderAmount)}
+}
+
+// proxyBid is the bid placed on behalf of userId while bidding against bid.
+func proxyBid(userId string, bid Bid, amount int64) Bid {
+	return Bid{
+		Id:        uuid.New().String(),
+		UserId:    userId,
+		AuctionId: bid.AuctionId,
+		Amount:    amount,
+		Currency:  bid.Currency,
+		Timestamp: bid.Timestamp,
+		Proxy:     true,
+	}
+}
+
+func minAmount(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
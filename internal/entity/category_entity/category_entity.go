@@ -0,0 +1,238 @@
+package category_entity
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"github.com/google/uuid"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSuggestionDistance is how many edits apart a name can be from a category and still
+// be suggested for it, enough for "eletronics" to suggest "Electronics"
+const maxSuggestionDistance = 2
+
+type Category struct {
+	Id   string
+	Name string
+
+	// Slug is the URL form of the name, e.g. "home-garden" for "Home & Garden"
+	Slug string
+
+	// PreviousSlugs are the slugs of the names the category had before, so links made
+	// with them still lead to it
+	PreviousSlugs []string
+
+	// DefaultDurationSeconds is how long the auctions of the category last when they
+	// don't set a duration themselves; zero leaves it to AUCTION_DURATION_SECONDS
+	DefaultDurationSeconds int64
+}
+
+// CreateCategory builds a new category. Inner whitespace is collapsed, so the name is
+// stored the way auctions will show it. An optional default duration, in seconds, within
+// bounds applies to the auctions created in it.
+func CreateCategory(
+	name string, bounds auction_entity.DurationBounds,
+	defaultDurationSeconds ...int64) (*Category, *internal_error.InternalError) {
+	category := &Category{
+		Id:   uuid.New().String(),
+		Name: strings.Join(strings.Fields(name), " "),
+	}
+	category.Slug = Slugify(category.Name)
+	if len(defaultDurationSeconds) > 0 {
+		category.DefaultDurationSeconds = defaultDurationSeconds[0]
+	}
+
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+
+	if category.DefaultDurationSeconds != 0 {
+		if cause := bounds.ValidateSeconds("default_duration_seconds", category.DefaultDurationSeconds); cause != nil {
+			return nil, internal_error.NewValidationError("Invalid category fields", *cause)
+		}
+	}
+
+	return category, nil
+}
+
+// DefaultDuration is DefaultDurationSeconds as a duration, zero when there is none.
+func (c *Category) DefaultDuration() time.Duration {
+	return time.Duration(c.DefaultDurationSeconds) * time.Second
+}
+
+func (c *Category) Validate() *internal_error.InternalError {
+	if len(c.Name) <= 2 || len(c.Name) > 50 {
+		return internal_error.NewBadRequestError("Category name must have between 3 and 50 characters")
+	}
+
+	if c.Slug == "" {
+		return internal_error.NewBadRequestError("Category name must have at least one letter or digit")
+	}
+
+	return nil
+}
+
+// Rename gives the category a new name and the slug that goes with it. The slug it had is
+// kept in PreviousSlugs, so links made with it keep working.
+func (c *Category) Rename(name string) *internal_error.InternalError {
+	renamed := *c
+	renamed.Name = strings.Join(strings.Fields(name), " ")
+	renamed.Slug = Slugify(renamed.Name)
+	if err := renamed.Validate(); err != nil {
+		return err
+	}
+
+	previousSlugs := make([]string, 0, len(c.PreviousSlugs)+1)
+	for _, slug := range append(c.PreviousSlugs, c.Slug) {
+		if slug != "" && slug != renamed.Slug && !containsString(previousSlugs, slug) {
+			previousSlugs = append(previousSlugs, slug)
+		}
+	}
+	renamed.PreviousSlugs = previousSlugs
+
+	*c = renamed
+	return nil
+}
+
+// HasSlug reports whether slug is the category's slug or one it had before.
+func (c *Category) HasSlug(slug string) bool {
+	return c.Slug == slug || containsString(c.PreviousSlugs, slug)
+}
+
+// slugReplacer folds the accented letters of Portuguese and the other Latin languages
+// category names are written in.
+var slugReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ý", "y", "ÿ", "y",
+)
+
+// Slugify is the URL form of a category name: lower case ASCII letters and digits, with
+// accents dropped and every other run of characters turned into a single dash, so
+// "Eletrônicos & Games" becomes "eletronicos-games".
+func Slugify(name string) string {
+	folded := slugReplacer.Replace(strings.ToLower(name))
+
+	var slug strings.Builder
+	dash := false
+	for _, r := range folded {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	return slug.String()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Key is the case and whitespace insensitive form of a category name. Two names with the
+// same key are the same category.
+func Key(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Match finds the category named name, ignoring case and whitespace. When there is none,
+// it returns the names of the categories close enough to be likely typos, nearest first.
+func Match(categories []Category, name string) (*Category, []string) {
+	key := Key(name)
+
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var suggestions []suggestion
+
+	for i := range categories {
+		categoryKey := Key(categories[i].Name)
+		if categoryKey == key {
+			return &categories[i], nil
+		}
+
+		if distance := editDistance(categoryKey, key); distance <= maxSuggestionDistance {
+			suggestions = append(suggestions, suggestion{categories[i].Name, distance})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	names := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		names = append(names, s.name)
+	}
+
+	return nil, names
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minOf(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minOf(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
+
+type CategoryRepositoryInterface interface {
+	// CreateCategory returns a conflict error when a category with the same Key exists.
+	CreateCategory(
+		ctx context.Context, categoryEntity *Category) *internal_error.InternalError
+
+	// FindAllCategories returns every category ordered by name.
+	FindAllCategories(ctx context.Context) ([]Category, *internal_error.InternalError)
+
+	FindCategoryById(ctx context.Context, id string) (*Category, *internal_error.InternalError)
+
+	// FindCategoryBySlug returns the category whose slug is slug or, when none is, the one
+	// that had it before. It returns internal_error.ErrCategoryNotFound otherwise.
+	FindCategoryBySlug(ctx context.Context, slug string) (*Category, *internal_error.InternalError)
+
+	// UpdateCategory stores the name and slugs of an existing category, returning a
+	// conflict error when another category has the same Key or Slug.
+	UpdateCategory(ctx context.Context, categoryEntity *Category) *internal_error.InternalError
+}
@@ -0,0 +1,90 @@
+package rating_entity
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MinScore = 1
+	MaxScore = 5
+
+	// MaxCommentLength is in characters
+	MaxCommentLength = 500
+)
+
+// Rating is the score the winner of a Completed auction gave its seller. An auction is
+// rated at most once.
+type Rating struct {
+	Id        string
+	AuctionId string
+	SellerId  string
+	RaterId   string
+	Score     int
+	Comment   string
+	Timestamp time.Time
+}
+
+// CreateRating builds the rating of the seller of the auction by the rater, stamped with
+// the time of clk, listing every invalid field.
+func CreateRating(
+	auctionId, sellerId, raterId string,
+	score int,
+	comment string,
+	clk clock.Clock) (*Rating, *internal_error.InternalError) {
+	rating := &Rating{
+		Id:        uuid.New().String(),
+		AuctionId: auctionId,
+		SellerId:  sellerId,
+		RaterId:   raterId,
+		Score:     score,
+		Comment:   strings.TrimSpace(comment),
+		Timestamp: clk.Now(),
+	}
+
+	if err := rating.Validate(); err != nil {
+		return nil, err
+	}
+
+	return rating, nil
+}
+
+// Validate checks the score and the comment, reporting both when both fail.
+func (r *Rating) Validate() *internal_error.InternalError {
+	var causes []internal_error.FieldError
+	invalid := func(field, message string) {
+		causes = append(causes, internal_error.FieldError{Field: field, Message: message})
+	}
+
+	if r.Score < MinScore || r.Score > MaxScore {
+		invalid("score", "score must be between 1 and 5")
+	}
+
+	if utf8.RuneCountInString(r.Comment) > MaxCommentLength {
+		invalid("comment", "comment must be at most 500 characters in length")
+	}
+
+	if len(causes) > 0 {
+		return internal_error.NewValidationError("Invalid rating fields", causes...)
+	}
+
+	return nil
+}
+
+type RatingRepositoryInterface interface {
+	// CreateRating stores the rating and adds its score to the rating counters of the
+	// seller, failing with ErrAuctionAlreadyRated when the auction already has one.
+	CreateRating(ctx context.Context, rating *Rating) *internal_error.InternalError
+
+	// FindRatingsBySellerId returns a page of the ratings of the seller, the most recent
+	// first, along with how many ratings the seller has.
+	FindRatingsBySellerId(
+		ctx context.Context, sellerId string, page, pageSize int) ([]Rating, int64, *internal_error.InternalError)
+}
@@ -2,15 +2,172 @@ package user_entity
 
 import (
 	"context"
+	"fmt"
 	"fullcycle-auction_go/internal/internal_error"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// MinPasswordLength and MaxPasswordLength bound the passwords, in bytes. bcrypt doesn't
+// hash past 72 bytes, so longer passwords are refused rather than cut.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
 )
 
 type User struct {
-	Id   string
-	Name string
+	Id    string
+	Name  string
+	Email string
+
+	// Balance is what the user still has available to bid, in cents. Amounts held by the
+	// bids leading an auction are already taken out of it.
+	Balance int64
+
+	// RatingCount and RatingSum add up the ratings the user got as a seller, so the
+	// average is read without going through the ratings
+	RatingCount int64
+	RatingSum   int64
+
+	// DeletedAt is set once the user deleted their account. The user is kept so past
+	// auctions and bids still resolve who made them.
+	DeletedAt *time.Time
+
+	// PasswordHash is the bcrypt hash of the password the user logs in with. Users
+	// created before passwords existed have none, and can't log in.
+	PasswordHash []byte
+}
+
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+// AverageRating is the average score of the ratings of the user as a seller, false while
+// the user has none.
+func (u *User) AverageRating() (float64, bool) {
+	if u.RatingCount == 0 {
+		return 0, false
+	}
+
+	return float64(u.RatingSum) / float64(u.RatingCount), true
+}
+
+// CreateUser builds a new user logging in with password, which is only kept hashed. The
+// email is trimmed and lower-cased so the unique email index treats differently cased
+// addresses as the same user.
+func CreateUser(name, email, password string) (*User, *internal_error.InternalError) {
+	user := &User{
+		Id:    uuid.New().String(),
+		Name:  strings.TrimSpace(name),
+		Email: strings.ToLower(strings.TrimSpace(email)),
+	}
+
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return nil, internal_error.NewBadRequestError(
+			fmt.Sprintf("Password must have between %d and %d bytes", MinPasswordLength, MaxPasswordLength))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, internal_error.NewInternalServerError("Error trying to hash the password").Wrap(err)
+	}
+	user.PasswordHash = hash
+
+	return user, nil
+}
+
+// CheckPassword reports whether password is the one the user logs in with. It is false
+// for users without a password.
+func (u *User) CheckPassword(password string) bool {
+	return len(u.PasswordHash) > 0 && bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(password)) == nil
+}
+
+func (u *User) Validate() *internal_error.InternalError {
+	if u.Name == "" {
+		return internal_error.NewBadRequestError("Name is required")
+	}
+
+	address, err := mail.ParseAddress(u.Email)
+	if err != nil || address.Address != u.Email {
+		return internal_error.NewBadRequestError("Email is not a valid address")
+	}
+
+	return nil
+}
+
+// UserUpdate carries the profile fields a user can change. Nil fields are left untouched.
+type UserUpdate struct {
+	Name  *string
+	Email *string
+}
+
+func (u UserUpdate) IsEmpty() bool {
+	return u.Name == nil && u.Email == nil
+}
+
+// Normalize trims the fields and lower-cases the email the same way CreateUser does.
+func (u UserUpdate) Normalize() UserUpdate {
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		u.Name = &name
+	}
+	if u.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*u.Email))
+		u.Email = &email
+	}
+
+	return u
+}
+
+// Validate applies the same rules CreateUser does to the fields being changed.
+func (u UserUpdate) Validate() *internal_error.InternalError {
+	if u.IsEmpty() {
+		return internal_error.NewBadRequestError("at least one user field must be updated")
+	}
+
+	if u.Name != nil && *u.Name == "" {
+		return internal_error.NewBadRequestError("Name is required")
+	}
+
+	if u.Email != nil {
+		address, err := mail.ParseAddress(*u.Email)
+		if err != nil || address.Address != *u.Email {
+			return internal_error.NewBadRequestError("Email is not a valid address")
+		}
+	}
+
+	return nil
 }
 
 type UserRepositoryInterface interface {
+	CreateUser(
+		ctx context.Context, userEntity *User) *internal_error.InternalError
+
+	// FindUserById also returns soft-deleted users, with DeletedAt set.
 	FindUserById(
 		ctx context.Context, userId string) (*User, *internal_error.InternalError)
+
+	// UpdateUser changes the profile of a user that isn't deleted, returning a conflict
+	// error when the new email belongs to another user.
+	UpdateUser(
+		ctx context.Context, userId string, update UserUpdate) (*User, *internal_error.InternalError)
+
+	// SoftDeleteUser marks the user as deleted, returning not found when it doesn't exist
+	// or is already deleted.
+	SoftDeleteUser(ctx context.Context, userId string) *internal_error.InternalError
+
+	// DebitBalance takes amount cents from the balance, failing with a bad request error
+	// instead of letting it go negative.
+	DebitBalance(
+		ctx context.Context, userId string, amount int64) *internal_error.InternalError
+
+	CreditBalance(
+		ctx context.Context, userId string, amount int64) *internal_error.InternalError
 }
@@ -0,0 +1,46 @@
+package watchlist_entity
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// Watch is a user following an auction without bidding on it. A user watches an auction
+// at most once.
+type Watch struct {
+	UserId    string
+	AuctionId string
+
+	// Timestamp is when the user started watching the auction
+	Timestamp time.Time
+}
+
+func CreateWatch(userId, auctionId string, clk clock.Clock) *Watch {
+	return &Watch{
+		UserId:    userId,
+		AuctionId: auctionId,
+		Timestamp: clk.Now(),
+	}
+}
+
+type WatchlistRepositoryInterface interface {
+	// Add stores the watch unless the user already watches the auction, in which case the
+	// watch stored first, with its timestamp, is kept.
+	Add(ctx context.Context, watch *Watch) *internal_error.InternalError
+
+	// Remove deletes the watch of the user on the auction, doing nothing when there is none.
+	Remove(ctx context.Context, userId, auctionId string) *internal_error.InternalError
+
+	// FindByUserId returns a page of the watches of the user, the most recent first, along
+	// with how many watches the user has.
+	FindByUserId(
+		ctx context.Context, userId string, page, pageSize int) ([]Watch, int64, *internal_error.InternalError)
+
+	CountByAuctionId(ctx context.Context, auctionId string) (int64, *internal_error.InternalError)
+
+	// FindUserIdsByAuctionId returns the users watching the auction.
+	FindUserIdsByAuctionId(ctx context.Context, auctionId string) ([]string, *internal_error.InternalError)
+}
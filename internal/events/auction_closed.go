@@ -0,0 +1,89 @@
+package events
+
+import (
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/money"
+)
+
+// AuctionClosedEvent is the payload of auction_closed, sent as is to every consumer:
+// the SSE stream, the live WebSocket and the message broker. It is built once the close
+// wrote the winner snapshot, so consumers don't need to read the auction back. The
+// field names are part of the API; add fields, never rename them.
+type AuctionClosedEvent struct {
+	AuctionId     string                        `json:"auction_id"`
+	SellerId      string                        `json:"seller_id"`
+	ProductName   string                        `json:"product_name"`
+	Category      string                        `json:"category"`
+	Currency      string                        `json:"currency"`
+	AuctionType   string                        `json:"auction_type"`
+	StartingPrice money.Amount                  `json:"starting_price"`
+	EndTime       *time.Time                    `json:"end_time"`
+	ClosedAt      *time.Time                    `json:"closed_at"`
+	Outcome       auction_entity.AuctionOutcome `json:"outcome"`
+	TotalBids     int64                         `json:"total_bids"`
+
+	// WinningBid is the highest bid, null when the auction closed without bids. Winner
+	// is its bidder, set only when the auction was sold, not when the highest bid fell
+	// short of the reserve price
+	WinningBid *ClosedWinningBid `json:"winning_bid"`
+	Winner     *ClosedWinner     `json:"winner"`
+}
+
+// ClosedWinningBid is the winning bid along with SettlementAmount, what its bidder pays:
+// the amount of the bid, except in Vickrey auctions.
+type ClosedWinningBid struct {
+	BidId            string       `json:"bid_id"`
+	UserId           string       `json:"user_id"`
+	Amount           money.Amount `json:"amount"`
+	SettlementAmount money.Amount `json:"settlement_amount"`
+	Currency         string       `json:"currency"`
+	Timestamp        time.Time    `json:"timestamp"`
+}
+
+// ClosedWinner is what the event tells about the winning user. The event reaches public
+// streams, so it leaves the e-mail out; Name is empty when the user no longer exists.
+type ClosedWinner struct {
+	UserId string `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+// NewAuctionClosedEvent builds the event of an auction read back after its close.
+func NewAuctionClosedEvent(closed auction_entity.ClosedAuction) AuctionClosedEvent {
+	event := AuctionClosedEvent{
+		AuctionId:     closed.Id,
+		SellerId:      closed.SellerId,
+		ProductName:   closed.ProductName,
+		Category:      closed.Category,
+		Currency:      closed.Currency,
+		AuctionType:   string(closed.AuctionType),
+		StartingPrice: money.Amount(closed.StartingPrice),
+		Outcome:       closed.Outcome,
+		TotalBids:     closed.BidCount,
+	}
+	if !closed.EndTime.IsZero() {
+		endTime := closed.EndTime.UTC()
+		event.EndTime = &endTime
+	}
+	if closed.ClosedAt != nil {
+		closedAt := closed.ClosedAt.UTC()
+		event.ClosedAt = &closedAt
+	}
+
+	if winningBid := closed.WinningBid; winningBid != nil {
+		event.WinningBid = &ClosedWinningBid{
+			BidId:            winningBid.BidId,
+			UserId:           winningBid.UserId,
+			Amount:           money.Amount(winningBid.Amount),
+			SettlementAmount: money.Amount(winningBid.SettlementAmount),
+			Currency:         winningBid.Currency,
+			Timestamp:        winningBid.Timestamp.UTC(),
+		}
+		if closed.Outcome.IsSale() {
+			event.Winner = &ClosedWinner{UserId: winningBid.UserId, Name: closed.WinnerName}
+		}
+	}
+
+	return event
+}
@@ -0,0 +1,93 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/events"
+)
+
+func TestAuctionClosedEventPayload(t *testing.T) {
+	endTime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	closedAt := endTime.Add(2 * time.Second)
+	auction := auction_entity.Auction{
+		Id:            "auction-1",
+		SellerId:      "seller-1",
+		ProductName:   "Camera",
+		Category:      "electronics",
+		Currency:      "USD",
+		AuctionType:   auction_entity.Vickrey,
+		Status:        auction_entity.Completed,
+		EndTime:       endTime,
+		StartingPrice: 1000,
+		ClosedAt:      &closedAt,
+	}
+
+	sold := auction
+	sold.Outcome = auction_entity.Sold
+	sold.BidCount = 3
+	sold.WinningBid = &auction_entity.WinningBid{
+		BidId: "bid-1", UserId: "user-1", Amount: 2550, Currency: "USD", Timestamp: endTime.Add(-time.Minute),
+		SettlementAmount: 1800,
+	}
+
+	noBids := auction
+	noBids.Outcome = auction_entity.NoBids
+
+	testCases := []struct {
+		name   string
+		closed auction_entity.ClosedAuction
+		want   string
+	}{
+		{
+			name:   "winner",
+			closed: auction_entity.ClosedAuction{Auction: sold, WinnerName: "Ana"},
+			want: `{"auction_id":"auction-1","seller_id":"seller-1","product_name":"Camera",` +
+				`"category":"electronics","currency":"USD","auction_type":"vickrey","starting_price":10.00,` +
+				`"end_time":"2026-03-01T12:00:00Z","closed_at":"2026-03-01T12:00:02Z","outcome":1,"total_bids":3,` +
+				`"winning_bid":{"bid_id":"bid-1","user_id":"user-1","amount":25.50,"settlement_amount":18.00,"currency":"USD",` +
+				`"timestamp":"2026-03-01T11:59:00Z"},"winner":{"user_id":"user-1","name":"Ana"}}`,
+		},
+		{
+			name:   "no bids",
+			closed: auction_entity.ClosedAuction{Auction: noBids},
+			want: `{"auction_id":"auction-1","seller_id":"seller-1","product_name":"Camera",` +
+				`"category":"electronics","currency":"USD","auction_type":"vickrey","starting_price":10.00,` +
+				`"end_time":"2026-03-01T12:00:00Z","closed_at":"2026-03-01T12:00:02Z","outcome":2,"total_bids":0,` +
+				`"winning_bid":null,"winner":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := json.Marshal(events.NewAuctionClosedEvent(tc.closed))
+			if err != nil {
+				t.Fatalf("Failed to encode event: %v", err)
+			}
+
+			if string(payload) != tc.want {
+				t.Errorf("Expected payload\n%s\ngot\n%s", tc.want, payload)
+			}
+		})
+	}
+}
+
+func TestAuctionClosedEventLeavesOutTheBidderBelowReserve(t *testing.T) {
+	event := events.NewAuctionClosedEvent(auction_entity.ClosedAuction{
+		Auction: auction_entity.Auction{
+			Id:         "auction-1",
+			Outcome:    auction_entity.ReserveNotMet,
+			WinningBid: &auction_entity.WinningBid{BidId: "bid-1", UserId: "user-1", Amount: 500},
+		},
+		WinnerName: "Ana",
+	})
+
+	if event.WinningBid == nil || event.WinningBid.BidId != "bid-1" {
+		t.Errorf("Expected the highest bid, got %+v", event.WinningBid)
+	}
+	if event.Winner != nil {
+		t.Errorf("Expected no winner below the reserve price, got %+v", event.Winner)
+	}
+}
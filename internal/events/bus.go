@@ -0,0 +1,156 @@
+// Package events is the in-process bus the auction lifecycle events go through on their
+// way to streaming clients. It keeps the most recent events so a client that reconnects
+// can resume where it stopped.
+package events
+
+import (
+	"encoding/json"
+	"sync"
+
+	"fullcycle-auction_go/configuration/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	AuctionCreated   = "auction_created"
+	AuctionClosed    = "auction_closed"
+	AuctionCancelled = "auction_cancelled"
+	BidPlaced        = "bid_placed"
+
+	// WatchedAuctionClosed tells a single watcher an auction closed, so it only goes to
+	// the message broker, never to the public streams
+	WatchedAuctionClosed = "watched_auction_closed"
+
+	// DefaultBufferSize is how many events a bus keeps unless configured otherwise
+	DefaultBufferSize = 256
+
+	subscriberBufferSize = 64
+)
+
+// Event is one published event. Ids increase with every event published by the bus, so
+// a client can ask for everything after the last id it saw.
+type Event struct {
+	Id        int64
+	Type      string
+	AuctionId string
+	Data      json.RawMessage
+}
+
+// Subscription receives the events published after it was created. Events is closed
+// when the subscriber falls too far behind or unsubscribes.
+type Subscription struct {
+	Events <-chan Event
+
+	events    chan Event
+	auctionId string
+}
+
+func (s *Subscription) matches(event Event) bool {
+	return s.auctionId == "" || s.auctionId == event.AuctionId
+}
+
+// Bus fans events out to its subscribers. Publishing never blocks: a subscriber whose
+// buffer is full is dropped, like a slow live WebSocket client, and can resume with
+// Subscribe from the last event it got.
+type Bus struct {
+	mutex       sync.Mutex
+	nextId      int64
+	buffer      []Event
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBus returns a bus keeping the last bufferSize events for resuming subscribers,
+// DefaultBufferSize when it is zero.
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Bus{
+		nextId:      1,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event about the auction, with data encoded as JSON, to every
+// matching subscriber and keeps it for the ones that resume later.
+func (b *Bus) Publish(eventType, auctionId string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("Error trying to encode event", err, zap.String("event", eventType))
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	event := Event{Id: b.nextId, Type: eventType, AuctionId: auctionId, Data: payload}
+	b.nextId++
+
+	b.buffer = append(b.buffer, event)
+	if len(b.buffer) > b.bufferSize {
+		b.buffer = b.buffer[len(b.buffer)-b.bufferSize:]
+	}
+
+	for sub := range b.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// AuctionsClosed publishes an auction_closed event for every auction closed by a sweep,
+// so the bus can be registered as a listener of the auction closer.
+func (b *Bus) AuctionsClosed(closed []AuctionClosedEvent) {
+	for _, event := range closed {
+		b.Publish(AuctionClosed, event.AuctionId, event)
+	}
+}
+
+// Subscribe returns a subscription to the events of auctionId, or of every auction when
+// it is empty, together with the buffered events published after lastEventId. A zero
+// lastEventId replays nothing. Events older than the buffer are lost to the subscriber.
+func (b *Bus) Subscribe(auctionId string, lastEventId int64) ([]Event, *Subscription) {
+	events := make(chan Event, subscriberBufferSize)
+	sub := &Subscription{Events: events, events: events, auctionId: auctionId}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var replay []Event
+	if lastEventId > 0 {
+		for _, event := range b.buffer {
+			if event.Id > lastEventId && sub.matches(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	b.subscribers[sub] = struct{}{}
+	return replay, sub
+}
+
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.removeLocked(sub)
+}
+
+func (b *Bus) removeLocked(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
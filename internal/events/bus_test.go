@@ -0,0 +1,68 @@
+package events_test
+
+import (
+	"testing"
+
+	"fullcycle-auction_go/internal/events"
+)
+
+func TestSubscribeFiltersAndResumes(t *testing.T) {
+	bus := events.NewBus(3)
+
+	for _, auctionId := range []string{"a", "b", "a", "a"} {
+		bus.Publish(events.BidPlaced, auctionId, map[string]string{"auction_id": auctionId})
+	}
+
+	// Only the last 3 events are buffered, ids 2 to 4
+	replay, sub := bus.Subscribe("", 1)
+	defer bus.Unsubscribe(sub)
+	if len(replay) != 3 || replay[0].Id != 2 || replay[2].Id != 4 {
+		t.Fatalf("Expected events 2 to 4 to be replayed, got %+v", replay)
+	}
+
+	replay, filtered := bus.Subscribe("a", 2)
+	defer bus.Unsubscribe(filtered)
+	if len(replay) != 2 || replay[0].Id != 3 || replay[1].Id != 4 {
+		t.Fatalf("Expected events 3 and 4 of auction a, got %+v", replay)
+	}
+
+	if replay, fresh := bus.Subscribe("", 0); len(replay) != 0 {
+		t.Errorf("Expected no replay without a last event id, got %+v", replay)
+	} else {
+		bus.Unsubscribe(fresh)
+	}
+
+	bus.AuctionsClosed([]events.AuctionClosedEvent{{AuctionId: "b"}})
+	event := <-sub.Events
+	if event.Type != events.AuctionClosed || event.AuctionId != "b" || event.Id != 5 {
+		t.Errorf("Expected auction_closed for b, got %+v", event)
+	}
+
+	select {
+	case event := <-filtered.Events:
+		t.Errorf("Expected the filtered subscription to skip auction b, got %+v", event)
+	default:
+	}
+}
+
+func TestPublishDropsSlowSubscribers(t *testing.T) {
+	bus := events.NewBus(0)
+	_, sub := bus.Subscribe("", 0)
+
+	// Nobody reads, so the subscriber is dropped once its buffer fills instead of
+	// blocking the publisher
+	for i := 0; i < 1000; i++ {
+		bus.Publish(events.BidPlaced, "a", i)
+	}
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	if received == 0 || received >= 1000 {
+		t.Errorf("Expected the buffered events and then a closed channel, got %d events", received)
+	}
+
+	// Unsubscribing a dropped subscription is a no-op
+	bus.Unsubscribe(sub)
+}
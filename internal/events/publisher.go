@@ -0,0 +1,14 @@
+package events
+
+// EventPublisher sends the auction lifecycle events to the services outside the process
+// that consume them, such as email and analytics. Delivery is best effort: Publish
+// returns right away and never fails the operation that triggered the event. The Bus
+// is an EventPublisher too.
+type EventPublisher interface {
+	Publish(eventType, auctionId string, data interface{})
+}
+
+// NoopPublisher drops every event. It is used when no message broker is configured.
+type NoopPublisher struct{}
+
+func (NoopPublisher) Publish(eventType, auctionId string, data interface{}) {}
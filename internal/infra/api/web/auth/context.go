@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type userIdKey struct{}
+
+// WithUserId returns a copy of ctx carrying the authenticated user id.
+func WithUserId(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userIdKey{}, userId)
+}
+
+// UserId returns the authenticated user id stored in ctx, if any.
+func UserId(ctx context.Context) (string, bool) {
+	userId, ok := ctx.Value(userIdKey{}).(string)
+	return userId, ok && userId != ""
+}
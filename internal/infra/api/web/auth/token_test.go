@@ -0,0 +1,55 @@
+package auth_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+)
+
+func TestTokenService(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	tokens, err := auth.NewTokenService("test-secret", time.Hour, clk)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+
+	token, expiresAt, err := tokens.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if !expiresAt.Equal(clk.Now().Add(time.Hour)) {
+		t.Errorf("Expected the token to expire in an hour, got %v", expiresAt)
+	}
+
+	userId, err := tokens.Verify(token)
+	if err != nil || userId != "user-1" {
+		t.Fatalf("Expected the token to identify user-1, got %q, %v", userId, err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := tokens.Verify(tampered); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Expected a tampered token to be rejected, got %v", err)
+	}
+
+	otherTokens, err := auth.NewTokenService("other-secret", time.Hour, clk)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	if _, err := otherTokens.Verify(token); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Expected a token signed with another secret to be rejected, got %v", err)
+	}
+
+	clk.Advance(time.Hour)
+	if _, err := tokens.Verify(token); !errors.Is(err, auth.ErrExpiredToken) {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+
+	if _, err := auth.NewTokenService("", time.Hour, clk); !errors.Is(err, auth.ErrMissingSecret) {
+		t.Errorf("Expected a missing secret to be refused, got %v", err)
+	}
+}
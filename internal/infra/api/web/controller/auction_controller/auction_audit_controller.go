@@ -0,0 +1,35 @@
+package auction_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FindAuditByAuctionId answers GET /auction/:auctionId/audit with the status changes of
+// the auction, oldest first. It is meant for admins.
+func (u *AuctionController) FindAuditByAuctionId(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	auditOutputs, err := u.auctionUseCase.FindAuditByAuctionId(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, auditOutputs)
+}
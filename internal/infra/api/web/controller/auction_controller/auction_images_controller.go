@@ -0,0 +1,55 @@
+package auction_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReplaceAuctionImages replaces the images only when the authenticated user is the seller.
+func (u *AuctionController) ReplaceAuctionImages(c *gin.Context) {
+	sellerId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	var imagesInputDTO auction_usecase.AuctionImagesInputDTO
+	if err := c.ShouldBindJSON(&imagesInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	imagesInputDTO.SellerId = sellerId
+	auctionData, err := u.auctionUseCase.ReplaceAuctionImages(c.Request.Context(), auctionId, imagesInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, auctionData)
+}
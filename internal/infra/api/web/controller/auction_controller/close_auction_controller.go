@@ -0,0 +1,43 @@
+package auction_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CloseAuction answers POST /auction/:auctionId/close, ending an Active auction right
+// away. It is meant for admins; auctions no longer Active answer 409.
+func (u *AuctionController) CloseAuction(c *gin.Context) {
+	adminId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	if err := u.auctionUseCase.CloseAuctionNow(c.Request.Context(), auctionId, adminId); err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
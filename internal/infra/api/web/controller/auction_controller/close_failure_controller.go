@@ -0,0 +1,57 @@
+package auction_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FindCloseFailures answers GET /admin/close-failures with the expired auctions that
+// failed to close, the longest failing first. It is meant for admins.
+func (u *AuctionController) FindCloseFailures(c *gin.Context) {
+	failureOutputs, err := u.auctionUseCase.FindCloseFailures(c.Request.Context())
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, failureOutputs)
+}
+
+// RetryClose answers POST /admin/close-failures/:auctionId/retry, closing an auction
+// that failed to close without waiting for its next retry. It is meant for admins;
+// auctions that no longer need closing answer 409.
+func (u *AuctionController) RetryClose(c *gin.Context) {
+	adminId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	if err := u.auctionUseCase.RetryClose(c.Request.Context(), auctionId, adminId); err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,48 @@
+package auction_controller
+
+import (
+	"fmt"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The ETags of the auction responses follow the revisions of the auctions in them. They
+// are weak: the remaining_seconds of a copy lags behind, while every stored field is
+// still current.
+
+func auctionETag(auction *auction_usecase.AuctionOutputDTO) string {
+	return fmt.Sprintf(`W/"%s-%d"`, auction.Id, auction.Revision)
+}
+
+// listingETag also covers the total, which changes with auctions off the page.
+func listingETag(auctions []auction_usecase.AuctionOutputDTO, total int64) string {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d", total)
+	for _, auction := range auctions {
+		fmt.Fprintf(hash, ";%s:%d", auction.Id, auction.Revision)
+	}
+
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// notModified tags the response with etag and answers 304 when If-None-Match shows the
+// client already has it, reporting whether it did. Clients are asked to revalidate
+// their copy on every use.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,105 @@
+package auction_controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+// auctionBatchItemOutput is the result of the auction at Index in the request array.
+type auctionBatchItemOutput struct {
+	Index int               `json:"index"`
+	Id    string            `json:"id,omitempty"`
+	Error *rest_err.RestErr `json:"error,omitempty"`
+}
+
+type auctionBatchOutput struct {
+	Created int                      `json:"created"`
+	Failed  int                      `json:"failed"`
+	Results []auctionBatchItemOutput `json:"results"`
+}
+
+// CreateAuctions lists a batch of auctions sold by the authenticated user. Each item is
+// validated and created on its own: the response has one result per item and answers
+// 201 when all of them were created, or 207 when any failed.
+func (u *AuctionController) CreateAuctions(c *gin.Context) {
+	sellerId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	var items []json.RawMessage
+	if err := c.ShouldBindJSON(&items); err != nil {
+		restErr := rest_err.NewBadRequestError("The body must be a JSON array of auctions")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	if len(items) == 0 || len(items) > auction_usecase.MaxAuctionBatchSize {
+		restErr := rest_err.NewBadRequestError(
+			fmt.Sprintf("A batch takes from 1 to %d auctions", auction_usecase.MaxAuctionBatchSize))
+
+		response.Error(c, restErr)
+		return
+	}
+
+	results := make([]auctionBatchItemOutput, len(items))
+	auctionInputs := make([]auction_usecase.AuctionInputDTO, 0, len(items))
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].Index = i
+
+		var auctionInputDTO auction_usecase.AuctionInputDTO
+		if err := binding.JSON.BindBody(item, &auctionInputDTO); err != nil {
+			results[i].Error = validation.ValidateErr(err)
+			continue
+		}
+
+		auctionInputs = append(auctionInputs, auctionInputDTO)
+		indexes = append(indexes, i)
+	}
+
+	batchResults, err := u.auctionUseCase.CreateAuctions(c.Request.Context(), sellerId, auctionInputs)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	for j, batchResult := range batchResults {
+		if batchResult.Err != nil {
+			results[indexes[j]].Error = rest_err.ConvertError(batchResult.Err)
+			continue
+		}
+
+		results[indexes[j]].Id = batchResult.Id
+	}
+
+	output := auctionBatchOutput{Results: results}
+	for _, result := range results {
+		if result.Error != nil {
+			output.Failed++
+		} else {
+			output.Created++
+		}
+	}
+
+	status := http.StatusCreated
+	if output.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	response.JSON(c, status, output)
+}
@@ -0,0 +1,120 @@
+package auction_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/controller/auction_controller"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// auctionUseCaseStub creates every auction it gets, except the ones of the Vehicles
+// category.
+type auctionUseCaseStub struct {
+	auction_usecase.AuctionUseCaseInterface
+
+	received int
+}
+
+func (s *auctionUseCaseStub) CreateAuctions(
+	ctx context.Context,
+	sellerId string,
+	auctionInputs []auction_usecase.AuctionInputDTO) ([]auction_usecase.AuctionBatchResult, *internal_error.InternalError) {
+	s.received += len(auctionInputs)
+
+	results := make([]auction_usecase.AuctionBatchResult, len(auctionInputs))
+	for i, auctionInput := range auctionInputs {
+		if auctionInput.Category == "Vehicles" {
+			results[i].Err = internal_error.NewBadRequestError("Category Vehicles does not exist")
+			continue
+		}
+		results[i].Id = uuid.New().String()
+	}
+
+	return results, nil
+}
+
+func postBatch(t *testing.T, stub *auctionUseCaseStub, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auction/batch", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithUserId(c.Request.Context(), uuid.New().String()))
+		c.Next()
+	}, auction_controller.NewAuctionController(stub).CreateAuctions)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/auction/batch", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestCreateAuctionsReportsEachItem(t *testing.T) {
+	item := func(category string) string {
+		return `{"product_name":"Test Product","category":"` + category +
+			`","description":"Test auction description","condition":1}`
+	}
+	body := "[" + item("Electronics") + `,{"product_name":"Test Product"},` + item("Vehicles") + "," + item("Books") + "]"
+
+	stub := &auctionUseCaseStub{}
+	recorder := postBatch(t, stub, body)
+	if recorder.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected status 207, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var output struct {
+		Created int `json:"created"`
+		Failed  int `json:"failed"`
+		Results []struct {
+			Index int       `json:"index"`
+			Id    string    `json:"id"`
+			Error *struct{} `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+
+	if output.Created != 2 || output.Failed != 2 || len(output.Results) != 4 {
+		t.Fatalf("Expected 2 created and 2 failed out of 4, got %+v", output)
+	}
+	for i, expectCreated := range []bool{true, false, false, true} {
+		result := output.Results[i]
+		if result.Index != i || expectCreated != (result.Id != "" && result.Error == nil) {
+			t.Errorf("Unexpected result for item %d: %+v", i, result)
+		}
+	}
+	if stub.received != 3 {
+		t.Errorf("Expected the invalid item to never reach the usecase, got %d items", stub.received)
+	}
+}
+
+func TestCreateAuctionsRejectsInvalidBatch(t *testing.T) {
+	tooMany := "[" + strings.Repeat("{},", auction_usecase.MaxAuctionBatchSize) + "{}]"
+
+	for name, body := range map[string]string{
+		"Not an array": `{"product_name":"Test Product"}`,
+		"Empty array":  `[]`,
+		"Too many":     tooMany,
+	} {
+		t.Run(name, func(t *testing.T) {
+			stub := &auctionUseCaseStub{}
+			recorder := postBatch(t, stub, body)
+			if recorder.Code != http.StatusBadRequest || stub.received != 0 {
+				t.Errorf("Expected 400 without reaching the usecase, got %d", recorder.Code)
+			}
+		})
+	}
+}
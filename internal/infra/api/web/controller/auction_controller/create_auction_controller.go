@@ -1,8 +1,9 @@
 package auction_controller
 
 import (
-	"context"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
 	"fullcycle-auction_go/internal/infra/api/web/validation"
 	"fullcycle-auction_go/internal/usecase/auction_usecase"
 	"github.com/gin-gonic/gin"
@@ -19,21 +20,31 @@ func NewAuctionController(auctionUseCase auction_usecase.AuctionUseCaseInterface
 	}
 }
 
+// CreateAuction lists a new auction sold by the authenticated user.
 func (u *AuctionController) CreateAuction(c *gin.Context) {
+	sellerId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
 	var auctionInputDTO auction_usecase.AuctionInputDTO
 
 	if err := c.ShouldBindJSON(&auctionInputDTO); err != nil {
 		restErr := validation.ValidateErr(err)
 
-		c.JSON(restErr.Code, restErr)
+		response.Error(c, restErr)
 		return
 	}
 
-	err := u.auctionUseCase.CreateAuction(context.Background(), auctionInputDTO)
+	auctionInputDTO.SellerId = sellerId
+	err := u.auctionUseCase.CreateAuction(c.Request.Context(), auctionInputDTO)
 	if err != nil {
 		restErr := rest_err.ConvertError(err)
 
-		c.JSON(restErr.Code, restErr)
+		response.Error(c, restErr)
 		return
 	}
 
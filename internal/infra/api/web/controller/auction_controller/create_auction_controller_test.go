@@ -0,0 +1,122 @@
+package auction_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/controller/auction_controller"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateAuctionReportsEveryInvalidField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+
+	categoryRepo := memory.NewCategoryRepository()
+	electronics, _ := category_entity.CreateCategory("Electronics", auction_entity.DurationBounds{})
+	categoryRepo.CreateCategory(context.Background(), electronics)
+
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		memory.NewAuctionRepository(nil), nil, userRepo, categoryRepo, nil, nil, nil, nil, nil,
+		auction_usecase.Config{})
+
+	router := gin.New()
+	router.POST("/auction", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithUserId(c.Request.Context(), seller.Id))
+		c.Next()
+	}, auction_controller.NewAuctionController(auctionUseCase).CreateAuction)
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		invalidFields  []string
+	}{
+		{
+			name:           "Valid auction",
+			body:           `{"product_name":"Test Product","category":"Electronics","description":"Test auction description","condition":3}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Condition by name in any case",
+			body:           `{"product_name":"Test Product","category":"Electronics","description":"Test auction description","condition":"Refurbished"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Unknown condition name",
+			body:           `{"product_name":"Test Product","category":"Electronics","description":"Test auction description","condition":"broken"}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"condition"},
+		},
+		{
+			name:           "One character product name",
+			body:           `{"product_name":"A","category":"Electronics","description":"Test auction description","condition":1}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"product_name"},
+		},
+		{
+			name:           "Short description and invalid condition",
+			body:           `{"product_name":"Test Product","category":"Electronics","description":"Short","condition":4}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"description", "condition"},
+		},
+		{
+			name:           "Unknown condition zero",
+			body:           `{"product_name":"Test Product","category":"Electronics","description":"Test auction description","condition":0}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"condition"},
+		},
+		{
+			name:           "Unknown category",
+			body:           `{"product_name":"Test Product","category":"Eletronics","description":"Test auction description","condition":1}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"category"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/auction", strings.NewReader(tc.body))
+			request.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedStatus != http.StatusBadRequest {
+				return
+			}
+
+			var restErr rest_err.RestErr
+			if err := json.Unmarshal(recorder.Body.Bytes(), &restErr); err != nil {
+				t.Fatalf("Failed to decode error body: %v", err)
+			}
+
+			fields := make(map[string]string)
+			for _, cause := range restErr.Causes {
+				fields[cause.Field] = cause.Message
+			}
+			for _, field := range tc.invalidFields {
+				if fields[field] == "" {
+					t.Errorf("Expected %s among the invalid fields, got %+v", field, restErr.Causes)
+				}
+			}
+		})
+	}
+}
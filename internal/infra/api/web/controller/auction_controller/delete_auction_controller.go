@@ -0,0 +1,32 @@
+package auction_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (u *AuctionController) DeleteAuction(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	if err := u.auctionUseCase.DeleteAuction(c.Request.Context(), auctionId); err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,116 @@
+package auction_controller
+
+import (
+	"encoding/csv"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushRows is how many rows are written between two flushes of the response, so
+// the client receives a long export as it is produced
+const exportFlushRows = 1000
+
+var exportColumns = []string{
+	"auction_id", "product_name", "category", "created_at", "closed_at", "winning_amount", "winner_id", "currency",
+}
+
+// ExportAuctions answers GET /auction/export with a CSV of the auctions matching the
+// listing filters, Completed ones oldest first unless asked otherwise. Rows are written
+// as the auctions are read, so the export never sits in memory as a whole. Once the
+// first row is out the status can't change anymore: an export failing halfway is logged
+// and cut short.
+func (u *AuctionController) ExportAuctions(c *gin.Context) {
+	filter, _, _, errRest := parseListingQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []auction_usecase.AuctionStatus{auction_usecase.AuctionStatus(auction_entity.Completed)}
+	}
+	if filter.Sort == "" {
+		filter.Sort = "oldest"
+	}
+
+	writer := csv.NewWriter(c.Writer)
+	rows := 0
+	start := func() error {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="auctions.csv"`)
+		c.Status(http.StatusOK)
+		return writer.Write(exportColumns)
+	}
+
+	errInternal := u.auctionUseCase.ExportAuctions(c.Request.Context(), filter,
+		func(result auction_usecase.AuctionResultOutputDTO) error {
+			if rows == 0 {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+
+			if err := writer.Write(exportRecord(result)); err != nil {
+				return err
+			}
+
+			if rows++; rows%exportFlushRows == 0 {
+				writer.Flush()
+				c.Writer.Flush()
+				return writer.Error()
+			}
+			return nil
+		})
+	if errInternal != nil {
+		if rows == 0 {
+			errRest := rest_err.ConvertError(errInternal)
+			response.Error(c, errRest)
+			return
+		}
+
+		logger.ErrorContext(c.Request.Context(), "Error trying to export auctions", errInternal)
+		return
+	}
+
+	if rows == 0 {
+		if err := start(); err != nil {
+			logger.ErrorContext(c.Request.Context(), "Error trying to export auctions", err)
+			return
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.ErrorContext(c.Request.Context(), "Error trying to export auctions", err)
+	}
+}
+
+// exportRecord is the CSV row of result. encoding/csv quotes the fields holding commas,
+// quotes or line breaks.
+func exportRecord(result auction_usecase.AuctionResultOutputDTO) []string {
+	record := []string{
+		result.AuctionId,
+		result.ProductName,
+		result.Category,
+		result.CreatedAt.UTC().Format(time.RFC3339),
+		"",
+		"",
+		result.WinnerId,
+		result.Currency,
+	}
+
+	if result.ClosedAt != nil {
+		record[4] = result.ClosedAt.UTC().Format(time.RFC3339)
+	}
+	if result.WinningAmount != nil {
+		record[5] = result.WinningAmount.String()
+	}
+
+	return record
+}
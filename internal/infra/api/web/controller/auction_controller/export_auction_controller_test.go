@@ -0,0 +1,102 @@
+package auction_controller_test
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/api/web/controller/auction_controller"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestExportAuctionsStreamsEscapedCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+
+	newAuction := func(productName string, duration time.Duration) *auction_entity.Auction {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), productName, "Electronics", "Auction used by the export test", auction_entity.New, duration,
+			auction_entity.DurationBounds{}, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+		return auctionEntity
+	}
+
+	sold := newAuction(`Lamp, "vintage"`, time.Minute)
+	unsold := newAuction("Old radio", time.Minute)
+	open := newAuction("Still open", time.Hour)
+
+	winner := uuid.New().String()
+	bid, _ := bid_entity.CreateBid(winner, sold.Id, 12345, money.DefaultCurrency, clk)
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, bid); err != nil {
+		t.Fatalf("Failed to create bid: %v", err.Error())
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, nil, nil, nil, nil, nil, nil, clk, auction_usecase.Config{})
+	router := gin.New()
+	router.GET("/auction/export", auction_controller.NewAuctionController(auctionUseCase).ExportAuctions)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/auction/export", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("Expected a text/csv response, got %q", contentType)
+	}
+	if disposition := recorder.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment") {
+		t.Errorf("Expected an attachment, got %q", disposition)
+	}
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse the CSV: %v", err)
+	}
+
+	// Only the Completed auctions are exported by default, below the header row
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", records)
+	}
+
+	rows := make(map[string][]string)
+	for _, record := range records[1:] {
+		rows[record[0]] = record
+	}
+
+	if row := rows[sold.Id]; row == nil || row[1] != `Lamp, "vintage"` || row[5] != "123.45" || row[6] != winner || row[4] == "" {
+		t.Errorf("Unexpected row for the sold auction: %v", row)
+	}
+	if row := rows[unsold.Id]; row == nil || row[5] != "" || row[6] != "" {
+		t.Errorf("Unexpected row for the auction without bids: %v", row)
+	}
+	if _, ok := rows[open.Id]; ok {
+		t.Error("Expected the open auction to be left out")
+	}
+}
@@ -1,82 +1,360 @@
 package auction_controller
 
 import (
-	"context"
+	"fmt"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/api/web/response"
 	"fullcycle-auction_go/internal/usecase/auction_usecase"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 func (u *AuctionController) FindAuctions(c *gin.Context) {
-	status := c.Query("status")
-	category := c.Query("category")
-	productName := c.Query("productName")
+	filter, page, pageSize, errRest := parseListingQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
 
-	statusNumber, err := strconv.Atoi(status)
-	if err != nil {
-		statusNumber = 0
+	auctions, total, errInternal := u.auctionUseCase.FindAuctions(c.Request.Context(), filter, page, pageSize)
+	if errInternal != nil {
+		errRest := rest_err.ConvertError(errInternal)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	if notModified(c, listingETag(auctions, total)) {
+		return
+	}
+
+	response.Page(c, http.StatusOK, auctions, response.PageMeta(page, pageSize, total))
+}
+
+// GetAuctionFacets answers GET /auction/facets with how many auctions match the listing
+// filters, e.g. status=active and q, by category and by condition. Pagination and sort
+// are ignored.
+func (u *AuctionController) GetAuctionFacets(c *gin.Context) {
+	filter, _, _, errRest := parseListingQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	facets, errInternal := u.auctionUseCase.GetAuctionFacets(c.Request.Context(), filter)
+	if errInternal != nil {
+		errRest := rest_err.ConvertError(errInternal)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, facets)
+}
+
+// FindAuctionsBySellerId answers GET /user/:userId/auctions with the user's auctions,
+// accepting the same filters and pagination as GET /auction.
+func (u *AuctionController) FindAuctionsBySellerId(c *gin.Context) {
+	userId := c.Param("userId")
+
+	if err := uuid.Validate(userId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "userId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	filter, page, pageSize, errRest := parseListingQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	auctions, total, errInternal := u.auctionUseCase.FindAuctionsBySellerId(
+		c.Request.Context(), userId, filter, page, pageSize)
+	if errInternal != nil {
+		errRest := rest_err.ConvertError(errInternal)
+		response.Error(c, errRest)
+		return
 	}
 
-	auctions, errInternal := u.auctionUseCase.FindAuctions(
-		context.Background(),
-		auction_usecase.AuctionStatus(statusNumber),
-		category,
-		productName)
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	response.Page(c, http.StatusOK, auctions, response.PageMeta(page, pageSize, total))
+}
+
+// FindAuctionsByCategorySlug answers GET /auction/category/:slug with the auctions of the
+// category, accepting the same filters, sort and pagination as GET /auction. The
+// category query param is ignored; an unknown slug is a 404 with err category_not_found.
+func (u *AuctionController) FindAuctionsByCategorySlug(c *gin.Context) {
+	filter, page, pageSize, errRest := parseListingQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	auctions, total, errInternal := u.auctionUseCase.FindAuctionsByCategorySlug(
+		c.Request.Context(), c.Param("slug"), filter, page, pageSize)
 	if errInternal != nil {
 		errRest := rest_err.ConvertError(errInternal)
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, auctions)
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	if notModified(c, listingETag(auctions, total)) {
+		return
+	}
+
+	response.Page(c, http.StatusOK, auctions, response.PageMeta(page, pageSize, total))
+}
+
+// parseListingQuery reads the filters and pagination shared by the auction listings.
+func parseListingQuery(c *gin.Context) (auction_usecase.AuctionFilterInputDTO, int, int, *rest_err.RestErr) {
+	var filter auction_usecase.AuctionFilterInputDTO
+
+	statuses, errRest := parseStatusesQuery(c)
+	if errRest != nil {
+		return filter, 0, 0, errRest
+	}
+
+	createdAfter, errRest := parseTimeQuery(c, "from", false)
+	if errRest != nil {
+		return filter, 0, 0, errRest
+	}
+
+	createdBefore, errRest := parseTimeQuery(c, "to", true)
+	if errRest != nil {
+		return filter, 0, 0, errRest
+	}
+
+	productName, errRest := parseSearchQuery(c)
+	if errRest != nil {
+		return filter, 0, 0, errRest
+	}
+
+	page, errRest := parsePositiveQuery(c, "page")
+	if errRest != nil {
+		return filter, 0, 0, errRest
+	}
+
+	pageSize, errRest := parsePositiveQuery(c, "page_size")
+	if errRest != nil {
+		return filter, 0, 0, errRest
+	}
+
+	filter = auction_usecase.AuctionFilterInputDTO{
+		Statuses:      statuses,
+		Category:      c.Query("category"),
+		ProductName:   productName,
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		Sort:          c.Query("sort"),
+	}
+
+	return filter, page, pageSize, nil
 }
 
 func (u *AuctionController) FindAuctionById(c *gin.Context) {
 	auctionId := c.Param("auctionId")
 
 	if err := uuid.Validate(auctionId); err != nil {
-		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
 			Field:   "auctionId",
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	auctionData, errInternal := u.auctionUseCase.FindAuctionById(context.Background(), auctionId)
+	auctionData, errInternal := u.auctionUseCase.FindAuctionById(c.Request.Context(), auctionId)
 	if errInternal != nil {
 		errRest := rest_err.ConvertError(errInternal)
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
+		return
+	}
+
+	if notModified(c, auctionETag(auctionData)) {
 		return
 	}
 
-	c.JSON(http.StatusOK, auctionData)
+	response.JSON(c, http.StatusOK, auctionData)
 }
 
 func (u *AuctionController) FindWinningBidByAuctionId(c *gin.Context) {
 	auctionId := c.Param("auctionId")
 
 	if err := uuid.Validate(auctionId); err != nil {
-		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	auctionData, errInternal := u.auctionUseCase.FindWinningBidByAuctionId(c.Request.Context(), auctionId)
+	if errInternal != nil {
+		errRest := rest_err.ConvertError(errInternal)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, auctionData)
+}
+
+// FindWinnerByAuctionId answers GET /auction/:auctionId/winner with the winning bid
+// and its bidder. Auctions that are still active answer 409 and those closed without
+// bids answer 404 with the no_bids code.
+func (u *AuctionController) FindWinnerByAuctionId(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	winner, errInternal := u.auctionUseCase.FindWinnerByAuctionId(c.Request.Context(), auctionId)
+	if errInternal != nil {
+		errRest := rest_err.ConvertError(errInternal)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, winner)
+}
+
+const maxSearchQueryLength = 100
+
+func (u *AuctionController) GetAuctionSummary(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
 			Field:   "auctionId",
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	auctionData, errInternal := u.auctionUseCase.FindWinningBidByAuctionId(context.Background(), auctionId)
+	summary, errInternal := u.auctionUseCase.GetAuctionSummary(c.Request.Context(), auctionId)
 	if errInternal != nil {
 		errRest := rest_err.ConvertError(errInternal)
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, auctionData)
+	response.JSON(c, http.StatusOK, summary)
+}
+
+// parseSearchQuery reads the product name search, q, falling back to the older
+// productName param. Results match it case-insensitively anywhere in the product name.
+func parseSearchQuery(c *gin.Context) (string, *rest_err.RestErr) {
+	value, ok := c.GetQuery("q")
+	if !ok {
+		value = c.Query("productName")
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) > maxSearchQueryLength {
+		return "", rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "q",
+			Message: fmt.Sprintf("Must be at most %d characters long", maxSearchQueryLength),
+		})
+	}
+
+	return value, nil
+}
+
+// parseStatusesQuery reads the comma-separated status query param, by number or by
+// name, e.g. status=1,2 or status=scheduled.
+func parseStatusesQuery(c *gin.Context) ([]auction_usecase.AuctionStatus, *rest_err.RestErr) {
+	value := c.Query("status")
+	if value == "" {
+		return nil, nil
+	}
+
+	var statuses []auction_usecase.AuctionStatus
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if status, ok := auction_entity.ParseAuctionStatus(part); ok {
+			statuses = append(statuses, auction_usecase.AuctionStatus(status))
+			continue
+		}
+
+		number, err := strconv.Atoi(part)
+		if err != nil || number < int(auction_entity.Active) || number > int(auction_entity.Scheduled) {
+			return nil, rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+				Field:   "status",
+				Message: "Must be a comma-separated list of auction statuses",
+			})
+		}
+
+		statuses = append(statuses, auction_usecase.AuctionStatus(number))
+	}
+
+	return statuses, nil
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp or YYYY-MM-DD date query param,
+// returning the zero time when it is absent. A plain date used as an upper bound covers
+// the whole day.
+func parseTimeQuery(c *gin.Context, name string, endOfDay bool) (time.Time, *rest_err.RestErr) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, rest_err.NewBadRequestError(
+			fmt.Sprintf("Invalid date format for %s", name), rest_err.FieldError{
+				Field:   name,
+				Message: "Must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			})
+	}
+
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Second)
+	}
+
+	return parsed, nil
+}
+
+// parsePositiveQuery reads an optional numeric query param, returning 0 when it is absent.
+func parsePositiveQuery(c *gin.Context, name string) (int, *rest_err.RestErr) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   name,
+			Message: "Must be a positive integer",
+		})
+	}
+
+	return number, nil
 }
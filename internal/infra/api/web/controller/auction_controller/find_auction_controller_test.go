@@ -0,0 +1,132 @@
+package auction_controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/api/web/controller/auction_controller"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestFindAuctionsAnswerNotModifiedUntilABid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, 0,
+		auction_entity.DurationBounds{}, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	auctionRepo.CreateAuction(ctx, auctionEntity)
+
+	controller := auction_controller.NewAuctionController(
+		auction_usecase.NewAuctionUseCase(
+			auctionRepo, bidRepo, nil, nil, nil, nil, nil, nil, nil, auction_usecase.Config{}))
+	router := gin.New()
+	router.GET("/auction", controller.FindAuctions)
+	router.GET("/auction/:auctionId", controller.FindAuctionById)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for i, path := range []string{"/auction", "/auction/" + auctionEntity.Id} {
+		first := get(path, "")
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" {
+			t.Fatalf("Expected %s to answer 200 with an ETag, got %d and %q", path, first.Code, etag)
+		}
+
+		if unchanged := get(path, etag); unchanged.Code != http.StatusNotModified || unchanged.Body.Len() != 0 {
+			t.Errorf("Expected %s to answer 304 while unchanged, got %d", path, unchanged.Code)
+		}
+
+		bid, _ := bid_entity.CreateBid(
+			uuid.New().String(), auctionEntity.Id, int64(i+1)*1000, money.DefaultCurrency, clock.New())
+		if err := bidRepo.CreateBidIfAuctionActive(ctx, bid); err != nil {
+			t.Fatalf("Failed to create bid: %v", err.Error())
+		}
+
+		changed := get(path, etag)
+		if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+			t.Errorf("Expected %s to answer 200 with a new ETag after a bid, got %d and %q",
+				path, changed.Code, changed.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestGetAuctionFacetsCountsMatchingAuctions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+
+	for _, auction := range []struct {
+		name, category string
+		condition      auction_entity.ProductCondition
+		completed      bool
+	}{
+		{"iPhone 13", "Electronics", auction_entity.Used, false},
+		{"iPhone 14", "Electronics", auction_entity.New, false},
+		{"iPhone Case", "Accessories", auction_entity.Used, false},
+		{"Old iPhone", "Electronics", auction_entity.Used, true},
+		{"Go Book", "Books", auction_entity.New, false},
+	} {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), auction.name, auction.category, "Test auction description", auction.condition, 0,
+			auction_entity.DurationBounds{}, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if auction.completed {
+			auctionEntity.Status = auction_entity.Completed
+		}
+		auctionRepo.CreateAuction(ctx, auctionEntity)
+	}
+
+	controller := auction_controller.NewAuctionController(
+		auction_usecase.NewAuctionUseCase(
+			auctionRepo, bidRepo, nil, nil, nil, nil, nil, nil, nil, auction_usecase.Config{}))
+	router := gin.New()
+	router.GET("/auction/facets", controller.GetAuctionFacets)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/auction/facets?status=active&q=iphone", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	expected := `{"categories":[{"category":"Electronics","count":2},{"category":"Accessories","count":1}],` +
+		`"conditions":[{"condition":"used","count":2},{"condition":"new","count":1}]}`
+	if body := recorder.Body.String(); body != expected {
+		t.Errorf("Expected %s, got %s", expected, body)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/auction/facets?from=yesterday", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid filter to answer 400, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package auction_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WatchAuction answers POST /auction/:auctionId/watch by adding the auction to the
+// watchlist of the authenticated user. Watching it again is a no-op.
+func (u *AuctionController) WatchAuction(c *gin.Context) {
+	userId, auctionId, ok := watchParams(c)
+	if !ok {
+		return
+	}
+
+	if err := u.auctionUseCase.WatchAuction(c.Request.Context(), userId, auctionId); err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnwatchAuction answers DELETE /auction/:auctionId/watch by removing the auction from
+// the watchlist of the authenticated user, if it is there.
+func (u *AuctionController) UnwatchAuction(c *gin.Context) {
+	userId, auctionId, ok := watchParams(c)
+	if !ok {
+		return
+	}
+
+	if err := u.auctionUseCase.UnwatchAuction(c.Request.Context(), userId, auctionId); err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// FindWatchlist answers GET /user/:userId/watchlist with the auctions the user watches,
+// the most recently watched first. Only the user can see the watchlist.
+func (u *AuctionController) FindWatchlist(c *gin.Context) {
+	authenticatedId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	userId := c.Param("userId")
+
+	if err := uuid.Validate(userId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "userId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	if userId != authenticatedId {
+		restErr := rest_err.NewForbiddenError("Users can only see their own watchlist")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	page, errRest := parsePositiveQuery(c, "page")
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	pageSize, errRest := parsePositiveQuery(c, "page_size")
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	auctions, total, errInternal := u.auctionUseCase.FindWatchlist(c.Request.Context(), userId, page, pageSize)
+	if errInternal != nil {
+		errRest := rest_err.ConvertError(errInternal)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	response.Page(c, http.StatusOK, auctions, response.PageMeta(page, pageSize, total))
+}
+
+// watchParams reads the authenticated user and the auction of a watch request, writing
+// the error response when either is missing or invalid.
+func watchParams(c *gin.Context) (string, string, bool) {
+	userId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return "", "", false
+	}
+
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return "", "", false
+	}
+
+	return userId, auctionId, true
+}
@@ -0,0 +1,74 @@
+package auth_controller
+
+import (
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/user_usecase"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthController struct {
+	userUseCase user_usecase.UserUseCaseInterface
+	tokens      *auth.TokenService
+}
+
+func NewAuthController(
+	userUseCase user_usecase.UserUseCaseInterface, tokens *auth.TokenService) *AuthController {
+	return &AuthController{
+		userUseCase: userUseCase,
+		tokens:      tokens,
+	}
+}
+
+type loginRequest struct {
+	UserId   string `json:"user_id" binding:"required,uuid"`
+	Password string `json:"password" binding:"required"`
+}
+
+type loginResponse struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at" time_format:"2006-01-02 15:04:05"`
+}
+
+// Login issues a bearer token for a user sending their password. A wrong password, and
+// unknown and deleted users, all get the same 401.
+func (u *AuthController) Login(c *gin.Context) {
+	var request loginRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	user, authErr := u.userUseCase.Authenticate(c.Request.Context(), request.UserId, request.Password)
+	if authErr != nil {
+		restErr := rest_err.ConvertError(authErr)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	token, expiresAt, err := u.tokens.Issue(user.Id)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Error trying to issue token", err)
+		restErr := rest_err.NewInternalServerError("Error trying to issue token")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, loginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt.UTC(),
+	})
+}
@@ -0,0 +1,13 @@
+package bid_controller
+
+import (
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// BidQueue answers GET /admin/bid-queue with the depth, capacity and drop count of the
+// queue of bids waiting to be written on this instance.
+func (u *BidController) BidQueue(c *gin.Context) {
+	response.JSON(c, http.StatusOK, u.bidUseCase.QueueStats())
+}
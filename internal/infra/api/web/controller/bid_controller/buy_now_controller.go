@@ -0,0 +1,44 @@
+package bid_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// BuyNow answers POST /auction/:auctionId/buy-now with the bid buying the auction at its
+// buy-now price, and a 409 when the auction doesn't offer it or someone bought it first.
+func (u *BidController) BuyNow(c *gin.Context) {
+	userId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		restErr := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, restErr)
+		return
+	}
+
+	bidOutput, err := u.bidUseCase.BuyNow(c.Request.Context(), userId, auctionId)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusCreated, bidOutput)
+}
@@ -1,9 +1,11 @@
 package bid_controller
 
 import (
-	"context"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
 	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/money"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -19,23 +21,58 @@ func NewBidController(bidUseCase bid_usecase.BidUseCaseInterface) *BidController
 	}
 }
 
+// createBidRequest is the POST /bid body. It is validated on binding, so malformed bids
+// are answered with a 400 listing every invalid field before reaching the usecase.
+// The amount is parsed from its decimal text straight into cents, never through a float,
+// so more than two decimal places are rejected rather than rounded. The bidder is the
+// authenticated user.
+type createBidRequest struct {
+	AuctionId string        `json:"auction_id" binding:"required,uuid"`
+	Amount    money.Decimal `json:"amount" binding:"required,amount"`
+	Currency  string        `json:"currency" binding:"omitempty,iso4217"`
+}
+
+// CreateBid answers POST /bid with the written bid and the state of its auction, or a
+// 409 when the auction isn't active, also when it closed before the write.
 func (u *BidController) CreateBid(c *gin.Context) {
-	var bidInputDTO bid_usecase.BidInputDTO
+	userId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
 
-	if err := c.ShouldBindJSON(&bidInputDTO); err != nil {
+	var request createBidRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
 		restErr := validation.ValidateErr(err)
 
-		c.JSON(restErr.Code, restErr)
+		response.Error(c, restErr)
 		return
 	}
 
-	err := u.bidUseCase.CreateBid(context.Background(), bidInputDTO)
+	// The binding already checked the amount parses
+	amount, _ := request.Amount.Cents()
+
+	bidOutput, err := u.bidUseCase.CreateBid(c.Request.Context(), bid_usecase.BidInputDTO{
+		UserId:    userId,
+		AuctionId: request.AuctionId,
+		Amount:    money.Amount(amount),
+		Currency:  request.Currency,
+	})
 	if err != nil {
 		restErr := rest_err.ConvertError(err)
 
-		c.JSON(restErr.Code, restErr)
+		response.Error(c, restErr)
+		return
+	}
+
+	// A retried bid creates nothing, so it is answered with the first one and a 200
+	if bidOutput.Duplicate {
+		response.JSON(c, http.StatusOK, bidOutput)
 		return
 	}
 
-	c.Status(http.StatusCreated)
+	response.JSON(c, http.StatusCreated, bidOutput)
 }
@@ -0,0 +1,334 @@
+package bid_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/controller/bid_controller"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// bidUseCaseStub counts the bids that made it past the controller, keeping the amount of
+// the last one.
+type bidUseCaseStub struct {
+	bid_usecase.BidUseCaseInterface
+
+	created int
+	amount  money.Amount
+}
+
+func (s *bidUseCaseStub) CreateBid(
+	ctx context.Context, bidInputDTO bid_usecase.BidInputDTO) (*bid_usecase.CreateBidOutputDTO, *internal_error.InternalError) {
+	s.created++
+	s.amount = bidInputDTO.Amount
+	return &bid_usecase.CreateBidOutputDTO{
+		BidOutputDTO: bid_usecase.BidOutputDTO{UserId: bidInputDTO.UserId, AuctionId: bidInputDTO.AuctionId},
+	}, nil
+}
+
+// authenticateAs stands in for the auth middleware, which the controller relies on for
+// the bidder.
+func authenticateAs(userId string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithUserId(c.Request.Context(), userId))
+		c.Next()
+	}
+}
+
+func TestCreateBidRequiresAuthenticatedUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stub := &bidUseCaseStub{}
+	router := gin.New()
+	router.POST("/bid", bid_controller.NewBidController(stub).CreateBid)
+
+	recorder := httptest.NewRecorder()
+	body := `{"auction_id":"` + uuid.New().String() + `","amount":10}`
+	request := httptest.NewRequest(http.MethodPost, "/bid", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusUnauthorized || stub.created != 0 {
+		t.Errorf("Expected 401 without reaching the usecase, got %d and %d bids", recorder.Code, stub.created)
+	}
+}
+
+func TestCreateBidParsesTheAmountIntoExactCents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for amount, cents := range map[string]money.Amount{
+		`19.99`:   1999,
+		`0.3`:     30,
+		`1000.1`:  100010,
+		`"19.99"`: 1999,
+		`4.35`:    435,
+	} {
+		stub := &bidUseCaseStub{}
+		router := gin.New()
+		router.POST("/bid", authenticateAs(uuid.New().String()), bid_controller.NewBidController(stub).CreateBid)
+
+		recorder := httptest.NewRecorder()
+		body := `{"auction_id":"` + uuid.New().String() + `","amount":` + amount + `}`
+		request := httptest.NewRequest(http.MethodPost, "/bid", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusCreated || stub.amount != cents {
+			t.Errorf("Expected %s to be a bid of %d cents, got %d cents and status %d",
+				amount, cents, stub.amount, recorder.Code)
+		}
+	}
+}
+
+func TestCreateBidValidatesRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userId := uuid.New().String()
+	auctionId := uuid.New().String()
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		invalidFields  []string
+	}{
+		{
+			name:           "Valid bid",
+			body:           `{"auction_id":"` + auctionId + `","amount":10.5}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Zero amount",
+			body:           `{"auction_id":"` + auctionId + `","amount":0}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"amount"},
+		},
+		{
+			name:           "Negative amount",
+			body:           `{"auction_id":"` + auctionId + `","amount":-1}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"amount"},
+		},
+		{
+			name:           "Fraction of a cent",
+			body:           `{"auction_id":"` + auctionId + `","amount":10.555}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"amount"},
+		},
+		{
+			name:           "Amount as text",
+			body:           `{"auction_id":"` + auctionId + `","amount":"NaN"}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"amount"},
+		},
+		{
+			name:           "Unknown currency",
+			body:           `{"auction_id":"` + auctionId + `","amount":10,"currency":"XYZ"}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"currency"},
+		},
+		{
+			name:           "Missing auction id",
+			body:           `{"amount":10}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"auction_id"},
+		},
+		{
+			name:           "Every field invalid",
+			body:           `{"auction_id":"","amount":0}`,
+			expectedStatus: http.StatusBadRequest,
+			invalidFields:  []string{"auction_id", "amount"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &bidUseCaseStub{}
+			router := gin.New()
+			router.POST("/bid", authenticateAs(userId), bid_controller.NewBidController(stub).CreateBid)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/bid", strings.NewReader(tc.body))
+			request.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+
+			if tc.expectedStatus != http.StatusBadRequest {
+				return
+			}
+
+			if stub.created != 0 {
+				t.Errorf("Expected the invalid bid to never reach the usecase")
+			}
+
+			var restErr rest_err.RestErr
+			if err := json.Unmarshal(recorder.Body.Bytes(), &restErr); err != nil {
+				t.Fatalf("Failed to decode error body: %v", err)
+			}
+
+			fields := make(map[string]bool)
+			for _, cause := range restErr.Causes {
+				fields[cause.Field] = true
+			}
+			for _, field := range tc.invalidFields {
+				if !fields[field] {
+					t.Errorf("Expected %s among the invalid fields, got %+v", field, restErr.Causes)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateBidAnswersUnknownAuctionOrUserWithNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := bid_usecase.DefaultConfig()
+	config.BalanceMode = bid_usecase.BalanceOff
+
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	userRepo := memory.NewUserRepository()
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, userRepo, nil, nil, nil, nil, config)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		bidUseCase.Shutdown(ctx)
+	})
+
+	bidder, err := user_entity.CreateUser("Bidder", "bidder@example.com", "secret-password")
+	if err != nil {
+		t.Fatalf("Failed to create user entity: %v", err.Error())
+	}
+	if err := userRepo.CreateUser(context.Background(), bidder); err != nil {
+		t.Fatalf("Failed to create user: %v", err.Error())
+	}
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if err := auctionRepo.CreateAuction(context.Background(), auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	unknownAuctionId, unknownUserId := uuid.New().String(), uuid.New().String()
+
+	testCases := []struct {
+		name           string
+		userId         string
+		auctionId      string
+		expectedStatus int
+		expectedErr    string
+		expectedId     string
+	}{
+		{
+			name:           "Unknown auction",
+			userId:         bidder.Id,
+			auctionId:      unknownAuctionId,
+			expectedStatus: http.StatusNotFound,
+			expectedErr:    string(internal_error.AuctionNotFound),
+			expectedId:     unknownAuctionId,
+		},
+		{
+			name:           "Unknown user",
+			userId:         unknownUserId,
+			auctionId:      auctionEntity.Id,
+			expectedStatus: http.StatusNotFound,
+			expectedErr:    string(internal_error.UserNotFound),
+			expectedId:     unknownUserId,
+		},
+		// Last, since the bid is written right away and a second one of the same amount
+		// would fall under the minimum increment
+		{
+			name:           "Valid bid",
+			userId:         bidder.Id,
+			auctionId:      auctionEntity.Id,
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/bid", authenticateAs(tc.userId), bid_controller.NewBidController(bidUseCase).CreateBid)
+
+			recorder := httptest.NewRecorder()
+			body := `{"auction_id":"` + tc.auctionId + `","amount":10}`
+			request := httptest.NewRequest(http.MethodPost, "/bid", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedStatus != http.StatusNotFound {
+				return
+			}
+
+			var restErr rest_err.RestErr
+			if err := json.Unmarshal(recorder.Body.Bytes(), &restErr); err != nil {
+				t.Fatalf("Failed to decode error body: %v", err)
+			}
+			if restErr.Err != tc.expectedErr || !strings.Contains(restErr.Message, tc.expectedId) {
+				t.Errorf("Expected %s naming %s, got %+v", tc.expectedErr, tc.expectedId, restErr)
+			}
+		})
+	}
+}
+
+// busyBidUseCaseStub refuses every bid as the full bid queue does.
+type busyBidUseCaseStub struct {
+	bid_usecase.BidUseCaseInterface
+}
+
+func (s *busyBidUseCaseStub) CreateBid(
+	ctx context.Context, bidInputDTO bid_usecase.BidInputDTO) (*bid_usecase.CreateBidOutputDTO, *internal_error.InternalError) {
+	return nil, internal_error.ErrServiceBusy
+}
+
+func TestCreateBidAnswersFullQueueWith503AndRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/bid", authenticateAs(uuid.New().String()),
+		bid_controller.NewBidController(&busyBidUseCaseStub{}).CreateBid)
+
+	recorder := httptest.NewRecorder()
+	body := `{"auction_id":"` + uuid.New().String() + `","amount":10}`
+	request := httptest.NewRequest(http.MethodPost, "/bid", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After of 1 second, got %q", recorder.Header().Get("Retry-After"))
+	}
+
+	var restErr rest_err.RestErr
+	if err := json.Unmarshal(recorder.Body.Bytes(), &restErr); err != nil || restErr.Err != "service_busy" {
+		t.Errorf("Expected a service_busy error, got %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,51 @@
+package bid_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/money"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// createProxyBidRequest is the POST /bid/proxy body. The maximum is what the bidder is
+// willing to pay at most; counter-bids are placed on their behalf up to it. Like the
+// amount of createBidRequest, it is parsed from its decimal text.
+type createProxyBidRequest struct {
+	AuctionId string        `json:"auction_id" binding:"required,uuid"`
+	MaxAmount money.Decimal `json:"max_amount" binding:"required,amount"`
+}
+
+func (u *BidController) CreateProxyBid(c *gin.Context) {
+	userId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	var request createProxyBidRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	// The binding already checked the maximum parses
+	maxAmount, _ := request.MaxAmount.Cents()
+
+	err := u.bidUseCase.CreateProxyBid(c.Request.Context(), userId, request.AuctionId, maxAmount)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
@@ -0,0 +1,177 @@
+package bid_controller
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportFlushRows is how many bids are written between two flushes of the response, so
+// the client receives a long export as it is produced
+const exportFlushRows = 1000
+
+// bidExportSummary is the last line of a complete export. An export cut short, by an
+// error or by the client going away, ends without it.
+type bidExportSummary struct {
+	Summary struct {
+		Total int64 `json:"total"`
+	} `json:"summary"`
+}
+
+// ExportBids answers GET /bid/export with every bid, oldest first, as newline-delimited
+// JSON, optionally only those of auction_id and placed between from and to. Bids are
+// written as they are read and the stream is gzipped for the clients accepting it. Once
+// the first line is out the status can't change anymore: an export failing halfway is
+// logged and cut short before its summary line.
+func (u *BidController) ExportBids(c *gin.Context) {
+	input, errRest := parseExportQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	var (
+		out     io.Writer = c.Writer
+		gz      *gzip.Writer
+		encoder *json.Encoder
+		rows    int64
+	)
+	start := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="bids.ndjson"`)
+		c.Header("Vary", "Accept-Encoding")
+		if middleware.AcceptsGzip(c.Request) {
+			c.Header("Content-Encoding", "gzip")
+			gz = gzip.NewWriter(c.Writer)
+			out = gz
+		}
+		c.Status(http.StatusOK)
+		encoder = json.NewEncoder(out)
+	}
+	flush := func() error {
+		if gz != nil {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	ctx := c.Request.Context()
+	errInternal := u.bidUseCase.ExportBids(ctx, input, func(bid bid_usecase.BidOutputDTO) error {
+		if encoder == nil {
+			start()
+		}
+
+		if err := encoder.Encode(bid); err != nil {
+			return err
+		}
+
+		if rows++; rows%exportFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if errInternal != nil {
+		if encoder == nil {
+			errRest := rest_err.ConvertError(errInternal)
+			response.Error(c, errRest)
+			return
+		}
+
+		if errors.Is(errInternal, context.Canceled) {
+			logger.InfoContext(ctx, fmt.Sprintf("Bid export cancelled by the client after %d bids", rows))
+		} else {
+			logger.ErrorContext(ctx, "Error trying to export bids", errInternal)
+		}
+		if gz != nil {
+			gz.Close()
+		}
+		return
+	}
+
+	if encoder == nil {
+		start()
+	}
+
+	var summary bidExportSummary
+	summary.Summary.Total = rows
+	if err := encoder.Encode(summary); err != nil {
+		logger.ErrorContext(ctx, "Error trying to export bids", err)
+		return
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			logger.ErrorContext(ctx, "Error trying to export bids", err)
+		}
+	}
+}
+
+// parseExportQuery reads the optional auction_id, from and to filters of the export.
+func parseExportQuery(c *gin.Context) (bid_usecase.BidExportInputDTO, *rest_err.RestErr) {
+	auctionId := c.Query("auction_id")
+	if auctionId != "" {
+		if err := uuid.Validate(auctionId); err != nil {
+			return bid_usecase.BidExportInputDTO{}, rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+				Field:   "auction_id",
+				Message: "Invalid UUID value",
+			})
+		}
+	}
+
+	from, errRest := parseTimeQuery(c, "from", false)
+	if errRest != nil {
+		return bid_usecase.BidExportInputDTO{}, errRest
+	}
+
+	to, errRest := parseTimeQuery(c, "to", true)
+	if errRest != nil {
+		return bid_usecase.BidExportInputDTO{}, errRest
+	}
+
+	return bid_usecase.BidExportInputDTO{AuctionId: auctionId, From: from, To: to}, nil
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp or YYYY-MM-DD date query param,
+// returning the zero time when it is absent. A plain date used as an upper bound covers
+// the whole day.
+func parseTimeQuery(c *gin.Context, name string, endOfDay bool) (time.Time, *rest_err.RestErr) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, rest_err.NewBadRequestError(
+			fmt.Sprintf("Invalid date format for %s", name), rest_err.FieldError{
+				Field:   name,
+				Message: "Must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			})
+	}
+
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Second)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,194 @@
+package bid_controller_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fullcycle-auction_go/internal/infra/api/web/controller/bid_controller"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportBidUseCaseStub streams its bids to the controller, failing after failAfter of
+// them when it is set.
+type exportBidUseCaseStub struct {
+	bid_usecase.BidUseCaseInterface
+
+	bids      []bid_usecase.BidOutputDTO
+	failAfter int
+	input     bid_usecase.BidExportInputDTO
+}
+
+func (s *exportBidUseCaseStub) ExportBids(
+	ctx context.Context,
+	input bid_usecase.BidExportInputDTO,
+	fn func(bid bid_usecase.BidOutputDTO) error) *internal_error.InternalError {
+	s.input = input
+	if input.From.After(input.To) && !input.To.IsZero() {
+		return internal_error.NewBadRequestError("from must not be after to")
+	}
+
+	for i, bid := range s.bids {
+		if s.failAfter > 0 && i == s.failAfter {
+			return internal_error.NewInternalServerError("Error reading bids")
+		}
+		if err := fn(bid); err != nil {
+			return internal_error.NewInternalServerError("Error streaming bids").Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func serveExport(stub *exportBidUseCaseStub, target string, gzipped bool) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/bid/export", bid_controller.NewBidController(stub).ExportBids)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	if gzipped {
+		request.Header.Set("Accept-Encoding", "gzip")
+	}
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func readLines(t *testing.T, body io.Reader) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Expected a JSON object per line, got %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func exportBids(n int) []bid_usecase.BidOutputDTO {
+	bids := make([]bid_usecase.BidOutputDTO, n)
+	for i := range bids {
+		bids[i] = bid_usecase.BidOutputDTO{Id: uuid.New().String(), Amount: money.Amount(money.FromFloat(float64(i + 1)))}
+	}
+
+	return bids
+}
+
+func TestExportBidsStreamsOneLinePerBidAndASummary(t *testing.T) {
+	stub := &exportBidUseCaseStub{bids: exportBids(3)}
+	auctionId := uuid.New().String()
+
+	recorder := serveExport(stub, "/bid/export?auction_id="+auctionId+"&from=2024-01-01&to=2024-01-31", false)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Expected an NDJSON response, got %q", contentType)
+	}
+	if stub.input.AuctionId != auctionId || stub.input.To.Format("15:04:05") != "23:59:59" {
+		t.Errorf("Expected the filters to reach the usecase, got %+v", stub.input)
+	}
+
+	lines := readLines(t, recorder.Body)
+	if len(lines) != 4 {
+		t.Fatalf("Expected 3 bids and a summary, got %d lines", len(lines))
+	}
+	for i, bid := range stub.bids {
+		if lines[i]["id"] != bid.Id {
+			t.Errorf("Expected bid %s on line %d, got %v", bid.Id, i, lines[i])
+		}
+	}
+
+	summary, ok := lines[3]["summary"].(map[string]interface{})
+	if !ok || summary["total"] != float64(3) {
+		t.Errorf("Expected a summary with 3 bids, got %v", lines[3])
+	}
+}
+
+func TestExportBidsGzipsForClientsAcceptingIt(t *testing.T) {
+	stub := &exportBidUseCaseStub{bids: exportBids(1500)}
+
+	recorder := serveExport(stub, "/bid/export", true)
+	if recorder.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a gzipped response, got headers %v", recorder.Header())
+	}
+
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatalf("Failed to read the gzipped response: %v", err)
+	}
+	lines := readLines(t, reader)
+	if len(lines) != 1501 || lines[1500]["summary"] == nil {
+		t.Errorf("Expected 1500 bids and a summary, got %d lines", len(lines))
+	}
+}
+
+func TestExportBidsWithoutBidsStillWritesTheSummary(t *testing.T) {
+	recorder := serveExport(&exportBidUseCaseStub{}, "/bid/export", false)
+
+	lines := readLines(t, recorder.Body)
+	if recorder.Code != http.StatusOK || len(lines) != 1 || lines[0]["summary"] == nil {
+		t.Errorf("Expected only the summary line, got %d: %v", recorder.Code, lines)
+	}
+}
+
+func TestExportBidsCutShortLeavesOutTheSummary(t *testing.T) {
+	stub := &exportBidUseCaseStub{bids: exportBids(5), failAfter: 2}
+
+	recorder := serveExport(stub, "/bid/export", false)
+
+	lines := readLines(t, recorder.Body)
+	if len(lines) != 2 {
+		t.Fatalf("Expected the 2 bids sent before the failure, got %d lines", len(lines))
+	}
+	for _, line := range lines {
+		if line["summary"] != nil {
+			t.Errorf("Expected no summary on an export cut short, got %v", line)
+		}
+	}
+}
+
+func TestExportBidsValidatesQuery(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{name: "Invalid auction id", query: "auction_id=not-a-uuid", field: "auction_id"},
+		{name: "Invalid from", query: "from=yesterday", field: "from"},
+		{name: "From after to", query: "from=2024-02-01&to=2024-01-01"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveExport(&exportBidUseCaseStub{}, "/bid/export?"+tc.query, false)
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("Expected 400, got %d", recorder.Code)
+			}
+			if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "application/json") {
+				t.Errorf("Expected a JSON error, got %q", recorder.Header().Get("Content-Type"))
+			}
+			if tc.field != "" && !strings.Contains(recorder.Body.String(), `"`+tc.field+`"`) {
+				t.Errorf("Expected an error on %s, got %s", tc.field, recorder.Body.String())
+			}
+		})
+	}
+}
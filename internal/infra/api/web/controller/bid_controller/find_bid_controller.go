@@ -1,32 +1,118 @@
 package bid_controller
 
 import (
-	"context"
+	"fmt"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
+	"strconv"
 )
 
 func (u *BidController) FindBidByAuctionId(c *gin.Context) {
 	auctionId := c.Param("auctionId")
 
 	if err := uuid.Validate(auctionId); err != nil {
-		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
 			Field:   "auctionId",
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	bidOutputList, err := u.bidUseCase.FindBidByAuctionId(context.Background(), auctionId)
+	limit, errRest := parseIntQuery(c, "limit", 1)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	// The legacy offset is still accepted, but the cursor wins when both are given
+	cursor := c.Query("cursor")
+	offset := 0
+	if cursor == "" {
+		offset, errRest = parseIntQuery(c, "offset", 0)
+		if errRest != nil {
+			response.Error(c, errRest)
+			return
+		}
+	}
+
+	bidOutputList, err := u.bidUseCase.FindBidByAuctionId(c.Request.Context(), auctionId, bid_usecase.BidListInputDTO{
+		Order:  c.Query("order"),
+		Limit:  limit,
+		Offset: offset,
+		Cursor: cursor,
+	})
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, bidOutputList)
+	// The cursor moves to the meta of the envelope, the rest of the list stays in data
+	meta := &response.Meta{Limit: limit, Offset: offset, NextCursor: bidOutputList.NextCursor}
+	if response.Enveloped(c) {
+		page := *bidOutputList
+		page.NextCursor = ""
+		bidOutputList = &page
+	}
+
+	response.Page(c, http.StatusOK, bidOutputList, meta)
+}
+
+func (u *BidController) FindBidsByUserId(c *gin.Context) {
+	userId := c.Param("userId")
+
+	if err := uuid.Validate(userId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "userId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != "active" {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "status",
+			Message: "Only the active status filter is supported",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	bidOutputList, err := u.bidUseCase.FindBidsByUserId(c.Request.Context(), userId, status == "active")
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, bidOutputList)
+}
+
+// parseIntQuery reads an optional integer query param of at least min, returning 0 when
+// it is absent.
+func parseIntQuery(c *gin.Context, name string, min int) (int, *rest_err.RestErr) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min {
+		return 0, rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   name,
+			Message: fmt.Sprintf("Must be an integer of at least %d", min),
+		})
+	}
+
+	return number, nil
 }
@@ -0,0 +1,20 @@
+package bid_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// CountSelfBids lists the sellers who bid on their own auctions, for admins.
+func (u *BidController) CountSelfBids(c *gin.Context) {
+	counts, err := u.bidUseCase.CountSelfBids(c.Request.Context())
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, counts)
+}
@@ -0,0 +1,87 @@
+package category_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/category_usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type CategoryController struct {
+	categoryUseCase category_usecase.CategoryUseCaseInterface
+}
+
+func NewCategoryController(categoryUseCase category_usecase.CategoryUseCaseInterface) *CategoryController {
+	return &CategoryController{
+		categoryUseCase: categoryUseCase,
+	}
+}
+
+func (u *CategoryController) CreateCategory(c *gin.Context) {
+	var categoryInputDTO category_usecase.CategoryInputDTO
+
+	if err := c.ShouldBindJSON(&categoryInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	categoryData, err := u.categoryUseCase.CreateCategory(c.Request.Context(), categoryInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusCreated, categoryData)
+}
+
+func (u *CategoryController) FindAllCategories(c *gin.Context) {
+	categories, err := u.categoryUseCase.FindAllCategories(c.Request.Context())
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, categories)
+}
+
+// RenameCategory answers PATCH /category/:categoryId with the renamed category.
+func (u *CategoryController) RenameCategory(c *gin.Context) {
+	categoryId := c.Param("categoryId")
+
+	if err := uuid.Validate(categoryId); err != nil {
+		restErr := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "categoryId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, restErr)
+		return
+	}
+
+	var renameInputDTO category_usecase.CategoryRenameInputDTO
+	if err := c.ShouldBindJSON(&renameInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	categoryData, err := u.categoryUseCase.RenameCategory(c.Request.Context(), categoryId, renameInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, categoryData)
+}
@@ -0,0 +1,114 @@
+package events_controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// heartbeatInterval is how often an idle stream gets a comment line, so proxies don't
+// close it for inactivity
+const heartbeatInterval = 15 * time.Second
+
+type EventsController struct {
+	bus     *events.Bus
+	streams context.Context
+}
+
+// NewEventsController returns a controller whose streams end once streams is done, so
+// shutdown can close them without cancelling the other requests.
+func NewEventsController(bus *events.Bus, streams context.Context) *EventsController {
+	return &EventsController{
+		bus:     bus,
+		streams: streams,
+	}
+}
+
+// StreamAuctionEvents streams the auction lifecycle events as Server-Sent Events, for
+// clients that can't use the WebSocket. ?auction_id= limits the stream to one auction,
+// and a Last-Event-ID header replays the buffered events the client missed. The stream
+// ends when the client disconnects, falls too far behind or the server shuts down.
+func (e *EventsController) StreamAuctionEvents(c *gin.Context) {
+	auctionId := c.Query("auction_id")
+	if auctionId != "" {
+		if err := uuid.Validate(auctionId); err != nil {
+			errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+				Field:   "auction_id",
+				Message: "Invalid UUID value",
+			})
+
+			response.Error(c, errRest)
+			return
+		}
+	}
+
+	var lastEventId int64
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+				Field:   "Last-Event-ID",
+				Message: "Invalid event id",
+			})
+
+			response.Error(c, errRest)
+			return
+		}
+		lastEventId = id
+	}
+
+	replay, sub := e.bus.Subscribe(auctionId, lastEventId)
+	defer e.bus.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range replay {
+		if !writeEvent(c, event) {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-e.streams.Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if !writeEvent(c, event) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(c *gin.Context, event events.Event) bool {
+	_, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, event.Data)
+	return err == nil
+}
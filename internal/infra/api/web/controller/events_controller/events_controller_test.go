@@ -0,0 +1,155 @@
+package events_controller_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/api/web/controller/events_controller"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestStreamAuctionEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bus := events.NewBus(0)
+	router := gin.New()
+	router.GET("/events/auctions", events_controller.NewEventsController(bus, context.Background()).StreamAuctionEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	auctionId := uuid.New().String()
+	otherAuctionId := uuid.New().String()
+	bus.Publish(events.AuctionCreated, auctionId, map[string]string{"id": auctionId})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events/auctions?auction_id="+auctionId, nil)
+	request.Header.Set("Last-Event-ID", "0")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Failed to open the stream: %v", err)
+	}
+	defer response.Body.Close()
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", contentType)
+	}
+
+	bus.Publish(events.BidPlaced, otherAuctionId, map[string]string{"auction_id": otherAuctionId})
+	bus.Publish(events.BidPlaced, auctionId, map[string]string{"auction_id": auctionId})
+
+	reader := bufio.NewReader(response.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read the stream: %v", err)
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if lines[0] != "id: 3" || lines[1] != "event: bid_placed" || !strings.Contains(lines[2], auctionId) {
+		t.Errorf("Expected only the bid on the followed auction, got %q", lines)
+	}
+
+	// A client that saw up to event 2 gets event 3 replayed first
+	resumed, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events/auctions", nil)
+	resumed.Header.Set("Last-Event-ID", "2")
+	resumedResponse, err := http.DefaultClient.Do(resumed)
+	if err != nil {
+		t.Fatalf("Failed to open the stream: %v", err)
+	}
+	defer resumedResponse.Body.Close()
+
+	line, _ := bufio.NewReader(resumedResponse.Body).ReadString('\n')
+	if strings.TrimSpace(line) != "id: 3" {
+		t.Errorf("Expected the missed event 3 to be replayed, got %q", line)
+	}
+}
+
+func TestStreamAuctionEventsEndsOnDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bus := events.NewBus(0)
+	router := gin.New()
+	router.GET("/events/auctions", events_controller.NewEventsController(bus, context.Background()).StreamAuctionEvents)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := httptest.NewRequest(http.MethodGet, "/events/auctions", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(recorder, request)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the handler to return once the client disconnected")
+	}
+
+	// The subscription is gone, so publishing doesn't wait on anyone
+	bus.Publish(events.BidPlaced, uuid.New().String(), nil)
+}
+
+func TestStreamAuctionEventsEndsOnShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	streams, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+
+	router := gin.New()
+	router.GET("/events/auctions", events_controller.NewEventsController(events.NewBus(0), streams).StreamAuctionEvents)
+
+	// The request context stays open, as it does for the requests in flight at shutdown
+	request := httptest.NewRequest(http.MethodGet, "/events/auctions", nil)
+	recorder := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(recorder, request)
+		close(done)
+	}()
+
+	shutdown()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the handler to return once the server shut down")
+	}
+}
+
+func TestStreamAuctionEventsValidatesQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/events/auctions", events_controller.NewEventsController(events.NewBus(0), context.Background()).StreamAuctionEvents)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events/auctions?auction_id=not-a-uuid", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid auction id, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/events/auctions", nil)
+	request.Header.Set("Last-Event-ID", "abc")
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid Last-Event-ID, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package health_controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MongoDBDependency       = "mongodb"
+	AuctionCloserDependency = "auction_closer"
+
+	pingTimeout = 2 * time.Second
+)
+
+// Pinger checks that a dependency answers.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingerFunc adapts a plain function to Pinger.
+type PingerFunc func(ctx context.Context) error
+
+func (f PingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+// SweeperStatus reports when the background auction closer last swept and how often it
+// is supposed to. auction.AuctionCloser implements it.
+type SweeperStatus interface {
+	LastRun() time.Time
+	Interval() time.Duration
+}
+
+// CloseLagStatus reports how far past its end time the oldest auction the closer has yet
+// to close is, and the lag past which the closer is degraded. auction.CloseLagMonitor
+// implements it.
+type CloseLagStatus interface {
+	MaxLag() time.Duration
+	Alarm() time.Duration
+}
+
+type ReadinessOutputDTO struct {
+	Status            string     `json:"status"`
+	FailingDependency string     `json:"failing_dependency,omitempty"`
+	Error             string     `json:"error,omitempty"`
+	SweeperLastRun    *time.Time `json:"sweeper_last_run,omitempty"`
+
+	// MaxCloseLagSeconds is left out when the close lag isn't monitored
+	MaxCloseLagSeconds *float64 `json:"max_close_lag_seconds,omitempty"`
+}
+
+type HealthController struct {
+	mongoPinger Pinger
+	sweeper     SweeperStatus
+	closeLag    CloseLagStatus
+	clock       clock.Clock
+}
+
+// NewHealthController checks the close lag too when closeLag is not nil and has an alarm.
+func NewHealthController(
+	mongoPinger Pinger, sweeper SweeperStatus, closeLag CloseLagStatus, clk clock.Clock) *HealthController {
+	if closeLag != nil && closeLag.Alarm() <= 0 {
+		closeLag = nil
+	}
+
+	return &HealthController{
+		mongoPinger: mongoPinger,
+		sweeper:     sweeper,
+		closeLag:    closeLag,
+		clock:       clk,
+	}
+}
+
+// Healthz only tells the process is up and serving requests.
+func (h *HealthController) Healthz(c *gin.Context) {
+	response.JSON(c, http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the service can do its job: MongoDB must answer a ping, the
+// auction closer must have swept within twice its interval and, when the close lag is
+// monitored, no Active auction may be past its end time by more than the alarm.
+// Otherwise it responds 503 naming the failing dependency.
+func (h *HealthController) Readyz(c *gin.Context) {
+	output := ReadinessOutputDTO{Status: "ready"}
+
+	lastRun := h.sweeper.LastRun().UTC()
+	if !lastRun.IsZero() {
+		output.SweeperLastRun = &lastRun
+	}
+
+	var maxLag time.Duration
+	if h.closeLag != nil {
+		maxLag = h.closeLag.MaxLag()
+		maxLagSeconds := maxLag.Seconds()
+		output.MaxCloseLagSeconds = &maxLagSeconds
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), pingTimeout)
+	defer cancel()
+
+	if err := h.mongoPinger.Ping(ctx); err != nil {
+		output.Status = "unavailable"
+		output.FailingDependency = MongoDBDependency
+		output.Error = err.Error()
+		response.JSON(c, http.StatusServiceUnavailable, output)
+		return
+	}
+
+	maxAge := 2 * h.sweeper.Interval()
+	if lastRun.IsZero() || h.clock.Now().Sub(lastRun) > maxAge {
+		output.Status = "unavailable"
+		output.FailingDependency = AuctionCloserDependency
+		output.Error = fmt.Sprintf("auction closer has not run in the last %s", maxAge)
+		response.JSON(c, http.StatusServiceUnavailable, output)
+		return
+	}
+
+	if h.closeLag != nil && maxLag > h.closeLag.Alarm() {
+		output.Status = "unavailable"
+		output.FailingDependency = AuctionCloserDependency
+		output.Error = fmt.Sprintf("auction closer is %s behind, over the %s alarm", maxLag, h.closeLag.Alarm())
+		response.JSON(c, http.StatusServiceUnavailable, output)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, output)
+}
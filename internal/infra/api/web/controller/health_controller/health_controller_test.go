@@ -0,0 +1,172 @@
+package health_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/api/web/controller/health_controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sweeperStub struct {
+	lastRun  time.Time
+	interval time.Duration
+}
+
+func (s sweeperStub) LastRun() time.Time {
+	return s.lastRun
+}
+
+func (s sweeperStub) Interval() time.Duration {
+	return s.interval
+}
+
+type closeLagStub struct {
+	maxLag time.Duration
+	alarm  time.Duration
+}
+
+func (s closeLagStub) MaxLag() time.Duration {
+	return s.maxLag
+}
+
+func (s closeLagStub) Alarm() time.Duration {
+	return s.alarm
+}
+
+func newRouter(
+	pinger health_controller.Pinger,
+	sweeper health_controller.SweeperStatus,
+	closeLag health_controller.CloseLagStatus,
+	clk clock.Clock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	controller := health_controller.NewHealthController(pinger, sweeper, closeLag, clk)
+	router := gin.New()
+	router.GET("/healthz", controller.Healthz)
+	router.GET("/readyz", controller.Readyz)
+
+	return router
+}
+
+func TestHealthz(t *testing.T) {
+	pinger := health_controller.PingerFunc(func(ctx context.Context) error {
+		return errors.New("unreachable")
+	})
+	router := newRouter(pinger, sweeperStub{}, nil, clock.New())
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	interval := 5 * time.Second
+
+	healthyPinger := health_controller.PingerFunc(func(ctx context.Context) error {
+		return nil
+	})
+	failingPinger := health_controller.PingerFunc(func(ctx context.Context) error {
+		return errors.New("server selection timeout")
+	})
+
+	testCases := []struct {
+		name               string
+		pinger             health_controller.Pinger
+		sweeper            sweeperStub
+		closeLag           health_controller.CloseLagStatus
+		expectedStatus     int
+		expectedDependency string
+	}{
+		{
+			name:           "Ready",
+			pinger:         healthyPinger,
+			sweeper:        sweeperStub{lastRun: now.Add(-interval), interval: interval},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:               "MongoDB unreachable",
+			pinger:             failingPinger,
+			sweeper:            sweeperStub{lastRun: now, interval: interval},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedDependency: health_controller.MongoDBDependency,
+		},
+		{
+			name:               "Sweeper never ran",
+			pinger:             healthyPinger,
+			sweeper:            sweeperStub{interval: interval},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedDependency: health_controller.AuctionCloserDependency,
+		},
+		{
+			name:               "Sweeper stale",
+			pinger:             healthyPinger,
+			sweeper:            sweeperStub{lastRun: now.Add(-2*interval - time.Second), interval: interval},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedDependency: health_controller.AuctionCloserDependency,
+		},
+		{
+			name:           "Close lag under the alarm",
+			pinger:         healthyPinger,
+			sweeper:        sweeperStub{lastRun: now, interval: interval},
+			closeLag:       closeLagStub{maxLag: 30 * time.Second, alarm: time.Minute},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:               "Close lag over the alarm",
+			pinger:             healthyPinger,
+			sweeper:            sweeperStub{lastRun: now, interval: interval},
+			closeLag:           closeLagStub{maxLag: 90 * time.Second, alarm: time.Minute},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedDependency: health_controller.AuctionCloserDependency,
+		},
+		{
+			name:           "Close lag alarm disabled",
+			pinger:         healthyPinger,
+			sweeper:        sweeperStub{lastRun: now, interval: interval},
+			closeLag:       closeLagStub{maxLag: 90 * time.Second},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRouter(tc.pinger, tc.sweeper, tc.closeLag, clock.NewFake(now))
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+
+			var output health_controller.ReadinessOutputDTO
+			if err := json.Unmarshal(recorder.Body.Bytes(), &output); err != nil {
+				t.Fatalf("unexpected body %q: %v", recorder.Body.String(), err)
+			}
+
+			if output.FailingDependency != tc.expectedDependency {
+				t.Errorf("expected failing dependency %q, got %q", tc.expectedDependency, output.FailingDependency)
+			}
+
+			monitored := tc.closeLag != nil && tc.closeLag.Alarm() > 0
+			if monitored && (output.MaxCloseLagSeconds == nil || *output.MaxCloseLagSeconds != tc.closeLag.MaxLag().Seconds()) {
+				t.Errorf("expected a max close lag of %v, got %v", tc.closeLag.MaxLag(), output.MaxCloseLagSeconds)
+			}
+			if !monitored && output.MaxCloseLagSeconds != nil {
+				t.Errorf("expected no max close lag, got %v", *output.MaxCloseLagSeconds)
+			}
+		})
+	}
+}
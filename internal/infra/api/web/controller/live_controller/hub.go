@@ -0,0 +1,169 @@
+package live_controller
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+)
+
+const (
+	BidPlacedMessage     = "bid_placed"
+	AuctionClosedMessage = "auction_closed"
+	OutbidMessage        = "outbid"
+	TimeSyncMessage      = "time_sync"
+
+	subscriberBufferSize = 16
+)
+
+type Message struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// TimeSync lets the clients correct their countdown: EndsAt is the end time stored for
+// the auction, extensions included, and ServerTime the clock it is measured against.
+type TimeSync struct {
+	ServerTime time.Time `json:"server_time"`
+	EndsAt     time.Time `json:"ends_at"`
+}
+
+type subscriber struct {
+	send chan []byte
+}
+
+// Hub keeps the live subscribers of each auction and fans messages out to them.
+// Every subscriber owns a buffered channel drained by its connection writer, so a
+// slow client never blocks the publisher: it is dropped instead.
+type Hub struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[*subscriber]struct{}
+
+	// clock is the ServerTime of the time syncs, the one the auctions end by
+	clock clock.Clock
+}
+
+// NewHub returns a hub sending clk as the server time of the time syncs, so the clients
+// count down on the clock the auctions are closed by.
+func NewHub(clk clock.Clock) *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[*subscriber]struct{}),
+		clock:       clk,
+	}
+}
+
+func (h *Hub) subscribe(auctionId string) *subscriber {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	sub := &subscriber{send: make(chan []byte, subscriberBufferSize)}
+	if h.subscribers[auctionId] == nil {
+		h.subscribers[auctionId] = make(map[*subscriber]struct{})
+	}
+	h.subscribers[auctionId][sub] = struct{}{}
+
+	return sub
+}
+
+func (h *Hub) unsubscribe(auctionId string, sub *subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.removeLocked(auctionId, sub)
+}
+
+func (h *Hub) removeLocked(auctionId string, sub *subscriber) {
+	subs, ok := h.subscribers[auctionId]
+	if !ok {
+		return
+	}
+
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.send)
+
+	if len(subs) == 0 {
+		delete(h.subscribers, auctionId)
+	}
+}
+
+// HasSubscribers reports whether anyone is following the auction.
+func (h *Hub) HasSubscribers(auctionId string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return len(h.subscribers[auctionId]) > 0
+}
+
+// auctionIds lists the auctions someone is following.
+func (h *Hub) auctionIds() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ids := make([]string, 0, len(h.subscribers))
+	for auctionId := range h.subscribers {
+		ids = append(ids, auctionId)
+	}
+
+	return ids
+}
+
+// Broadcast sends the message to every subscriber of the auction.
+func (h *Hub) Broadcast(auctionId string, message Message) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		logger.Error("Error trying to encode live message", err)
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for sub := range h.subscribers[auctionId] {
+		select {
+		case sub.send <- payload:
+		default:
+			h.removeLocked(auctionId, sub)
+		}
+	}
+}
+
+// CloseAuction sends the final message to the subscribers of the auction and
+// disconnects all of them.
+func (h *Hub) CloseAuction(auctionId string, message Message) {
+	h.Broadcast(auctionId, message)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for sub := range h.subscribers[auctionId] {
+		h.removeLocked(auctionId, sub)
+	}
+}
+
+func (h *Hub) PublishBid(bid bid_usecase.BidOutputDTO) {
+	h.Broadcast(bid.AuctionId, Message{Type: BidPlacedMessage, Data: bid})
+}
+
+// ConsumeOutbid broadcasts every outbid event to the subscribers of its auction, so the
+// previous leader learns about it live. It returns when events is closed.
+func (h *Hub) ConsumeOutbid(events <-chan bid_usecase.OutbidEvent) {
+	for event := range events {
+		h.Broadcast(event.NewBid.AuctionId, Message{Type: OutbidMessage, Data: event})
+	}
+}
+
+// PublishExtension resyncs the countdown of the subscribers right away when a last
+// second bid extends the auction, rather than at the next periodic sync.
+func (h *Hub) PublishExtension(auctionId string, endTime time.Time) {
+	h.Broadcast(auctionId, Message{
+		Type: TimeSyncMessage,
+		Data: TimeSync{ServerTime: h.clock.Now().UTC(), EndsAt: endTime.UTC()},
+	})
+}
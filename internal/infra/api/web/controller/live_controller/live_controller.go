@@ -0,0 +1,171 @@
+package live_controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// CloseAuctionNotFound is sent as the close code when the auction does not exist.
+	CloseAuctionNotFound = 4404
+
+	writeTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type LiveController struct {
+	hub            *Hub
+	auctionUseCase auction_usecase.AuctionUseCaseInterface
+	streams        context.Context
+}
+
+// NewLiveController returns a controller whose connections are closed once streams is
+// done, so shutdown can end them without cancelling the other requests.
+func NewLiveController(
+	hub *Hub, auctionUseCase auction_usecase.AuctionUseCaseInterface, streams context.Context) *LiveController {
+	return &LiveController{
+		hub:            hub,
+		auctionUseCase: auctionUseCase,
+		streams:        streams,
+	}
+}
+
+func (l *LiveController) SubscribeAuction(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Error trying to upgrade live connection", err,
+			zap.String("auction_id", auctionId))
+		return
+	}
+
+	if _, err := l.auctionUseCase.FindAuctionById(c.Request.Context(), auctionId); err != nil {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(CloseAuctionNotFound, "auction not found"),
+			time.Now().Add(writeTimeout))
+		conn.Close()
+		return
+	}
+
+	sub := l.hub.subscribe(auctionId)
+
+	go writeMessages(l.streams, conn, sub)
+
+	// Clients are not expected to send anything; reading only detects the disconnect
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	l.hub.unsubscribe(auctionId, sub)
+}
+
+func writeMessages(streams context.Context, conn *websocket.Conn, sub *subscriber) {
+	defer conn.Close()
+
+	// Closing the connection also ends the read loop, which unsubscribes
+	for {
+		select {
+		case payload, ok := <-sub.send:
+			if !ok {
+				writeClose(conn, websocket.CloseNormalClosure)
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-streams.Done():
+			writeClose(conn, websocket.CloseGoingAway)
+			return
+		}
+	}
+}
+
+func writeClose(conn *websocket.Conn, code int) {
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, ""),
+		time.Now().Add(writeTimeout))
+}
+
+// AuctionsClosed sends the final auction_closed message, with the outcome and the
+// winning bid, to the subscribers of every closed auction.
+func (l *LiveController) AuctionsClosed(closed []events.AuctionClosedEvent) {
+	for _, event := range closed {
+		if !l.hub.HasSubscribers(event.AuctionId) {
+			continue
+		}
+
+		l.hub.CloseAuction(event.AuctionId, Message{Type: AuctionClosedMessage, Data: event})
+	}
+}
+
+// SyncCountdowns sends a time_sync message to the subscribers of each followed auction
+// every interval, until ctx is done. The end time is read again from the repository
+// each time, so extensions made by other instances reach the clients too. Closed and
+// cancelled auctions get no more syncs. A zero interval disables it.
+func (l *LiveController) SyncCountdowns(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, auctionId := range l.hub.auctionIds() {
+				l.syncCountdown(ctx, auctionId)
+			}
+		}
+	}
+}
+
+func (l *LiveController) syncCountdown(ctx context.Context, auctionId string) {
+	ctx = logger.WithFields(ctx, zap.String("auction_id", auctionId))
+	auction, err := l.auctionUseCase.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return
+	}
+
+	switch auction_entity.AuctionStatus(auction.Status) {
+	case auction_entity.Completed, auction_entity.Cancelled:
+		return
+	}
+
+	l.hub.Broadcast(auctionId, Message{
+		Type: TimeSyncMessage,
+		Data: TimeSync{ServerTime: l.hub.clock.Now().UTC(), EndsAt: auction.EndTime.UTC()},
+	})
+}
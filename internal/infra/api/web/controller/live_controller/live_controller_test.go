@@ -0,0 +1,395 @@
+package live_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/api/web/controller/live_controller"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// auctionUseCaseStub only knows the auction ids it was created with. They all end at
+// endTime, and are active unless statuses says otherwise.
+type auctionUseCaseStub struct {
+	auctionIds map[string]bool
+	statuses   map[string]auction_usecase.AuctionStatus
+	endTime    time.Time
+}
+
+func (s *auctionUseCaseStub) CreateAuction(
+	ctx context.Context, auctionInput auction_usecase.AuctionInputDTO) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) CreateAuctions(
+	ctx context.Context,
+	sellerId string,
+	auctionInputs []auction_usecase.AuctionInputDTO) ([]auction_usecase.AuctionBatchResult, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) FindAuctionById(
+	ctx context.Context, id string) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	if !s.auctionIds[id] {
+		return nil, internal_error.NewNotFoundError("Auction not found")
+	}
+
+	return &auction_usecase.AuctionOutputDTO{Id: id, Status: s.statuses[id], EndTime: s.endTime}, nil
+}
+
+func (s *auctionUseCaseStub) FindAuctions(
+	ctx context.Context,
+	filter auction_usecase.AuctionFilterInputDTO,
+	page, pageSize int) ([]auction_usecase.AuctionOutputDTO, int64, *internal_error.InternalError) {
+	return nil, 0, nil
+}
+
+func (s *auctionUseCaseStub) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*auction_usecase.WinningInfoOutputDTO, *internal_error.InternalError) {
+	return &auction_usecase.WinningInfoOutputDTO{
+		Auction: auction_usecase.AuctionOutputDTO{Id: auctionId},
+	}, nil
+}
+
+func (s *auctionUseCaseStub) FindWinnerByAuctionId(
+	ctx context.Context, auctionId string) (*auction_usecase.WinnerOutputDTO, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) FindAuctionsBySellerId(
+	ctx context.Context,
+	sellerId string,
+	filter auction_usecase.AuctionFilterInputDTO,
+	page, pageSize int) ([]auction_usecase.AuctionOutputDTO, int64, *internal_error.InternalError) {
+	return nil, 0, nil
+}
+
+func (s *auctionUseCaseStub) FindAuctionsByCategorySlug(
+	ctx context.Context,
+	slug string,
+	filter auction_usecase.AuctionFilterInputDTO,
+	page, pageSize int) ([]auction_usecase.AuctionOutputDTO, int64, *internal_error.InternalError) {
+	return nil, 0, nil
+}
+
+func (s *auctionUseCaseStub) CancelAuction(
+	ctx context.Context, id, sellerId string) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) CloseAuctionNow(ctx context.Context, id, adminId string) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) FindCloseFailures(
+	ctx context.Context) ([]auction_usecase.CloseFailureOutputDTO, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) RetryClose(ctx context.Context, id, adminId string) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) FindAuditByAuctionId(
+	ctx context.Context, auctionId string) ([]auction_usecase.AuctionAuditOutputDTO, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) ReplaceAuctionImages(
+	ctx context.Context,
+	id string,
+	imagesInput auction_usecase.AuctionImagesInputDTO) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) DeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) GetAuctionSummary(
+	ctx context.Context, auctionId string) (*auction_usecase.AuctionSummaryOutputDTO, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) GetAuctionFacets(
+	ctx context.Context,
+	filter auction_usecase.AuctionFilterInputDTO) (*auction_usecase.AuctionFacetsOutputDTO, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) ExportAuctions(
+	ctx context.Context,
+	filter auction_usecase.AuctionFilterInputDTO,
+	fn func(result auction_usecase.AuctionResultOutputDTO) error) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) UpdateAuction(
+	ctx context.Context,
+	id string,
+	updateInput auction_usecase.AuctionUpdateInputDTO) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionUseCaseStub) WatchAuction(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) UnwatchAuction(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionUseCaseStub) FindWatchlist(
+	ctx context.Context,
+	userId string,
+	page, pageSize int) ([]auction_usecase.WatchedAuctionOutputDTO, int64, *internal_error.InternalError) {
+	return nil, 0, nil
+}
+
+func setupLiveServer(t *testing.T, auctionIds ...string) (*live_controller.Hub, *live_controller.LiveController, string) {
+	known := make(map[string]bool)
+	for _, id := range auctionIds {
+		known[id] = true
+	}
+
+	return serveLive(t, &auctionUseCaseStub{auctionIds: known})
+}
+
+func serveLive(t *testing.T, stub *auctionUseCaseStub) (*live_controller.Hub, *live_controller.LiveController, string) {
+	gin.SetMode(gin.TestMode)
+
+	hub := live_controller.NewHub(clock.New())
+	controller := live_controller.NewLiveController(hub, stub, context.Background())
+
+	router := gin.New()
+	router.GET("/ws/auction/:auctionId", controller.SubscribeAuction)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return hub, controller, "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/auction/"
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) live_controller.Message {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var message live_controller.Message
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("Failed to read live message: %v", err)
+	}
+
+	return message
+}
+
+func TestLiveBidUpdatesAndAuctionClosed(t *testing.T) {
+	auctionId := uuid.New().String()
+	hub, controller, baseURL := setupLiveServer(t, auctionId)
+
+	conn, _, err := websocket.DefaultDialer.Dial(baseURL+auctionId, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	waitFor(t, func() bool { return hub.HasSubscribers(auctionId) })
+
+	hub.PublishBid(bid_usecase.BidOutputDTO{Id: uuid.New().String(), AuctionId: auctionId, Amount: 150})
+
+	message := readMessage(t, conn)
+	if message.Type != live_controller.BidPlacedMessage {
+		t.Errorf("Expected a %s message, got %s", live_controller.BidPlacedMessage, message.Type)
+	}
+
+	data, _ := json.Marshal(message.Data)
+	var bid bid_usecase.BidOutputDTO
+	json.Unmarshal(data, &bid)
+	if bid.Amount != 150 {
+		t.Errorf("Expected the published bid amount 150, got %s", bid.Amount)
+	}
+
+	controller.AuctionsClosed([]events.AuctionClosedEvent{{AuctionId: auctionId, Outcome: auction_entity.NoBids}})
+
+	message = readMessage(t, conn)
+	if message.Type != live_controller.AuctionClosedMessage {
+		t.Errorf("Expected a %s message, got %s", live_controller.AuctionClosedMessage, message.Type)
+	}
+
+	data, _ = json.Marshal(message.Data)
+	var closed events.AuctionClosedEvent
+	json.Unmarshal(data, &closed)
+	if closed.AuctionId != auctionId || closed.Outcome != auction_entity.NoBids {
+		t.Errorf("Expected the auction_closed event of the auction, got %+v", closed)
+	}
+
+	if hub.HasSubscribers(auctionId) {
+		t.Error("Expected the subscribers to be removed once the auction is closed")
+	}
+}
+
+func TestLiveSubscriberRemovedOnDisconnect(t *testing.T) {
+	auctionId := uuid.New().String()
+	hub, _, baseURL := setupLiveServer(t, auctionId)
+
+	conn, _, err := websocket.DefaultDialer.Dial(baseURL+auctionId, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	waitFor(t, func() bool { return hub.HasSubscribers(auctionId) })
+
+	conn.Close()
+
+	waitFor(t, func() bool { return !hub.HasSubscribers(auctionId) })
+}
+
+func TestLiveConnectionsClosedOnShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	auctionId := uuid.New().String()
+	streams, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+
+	hub := live_controller.NewHub(clock.New())
+	controller := live_controller.NewLiveController(
+		hub, &auctionUseCaseStub{auctionIds: map[string]bool{auctionId: true}}, streams)
+	router := gin.New()
+	router.GET("/ws/auction/:auctionId", controller.SubscribeAuction)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(server.URL, "http")+"/ws/auction/"+auctionId, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	waitFor(t, func() bool { return hub.HasSubscribers(auctionId) })
+
+	shutdown()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected close code %d, got %v", websocket.CloseGoingAway, err)
+	}
+
+	waitFor(t, func() bool { return !hub.HasSubscribers(auctionId) })
+}
+
+func TestLiveUnknownAuctionRejected(t *testing.T) {
+	_, _, baseURL := setupLiveServer(t)
+
+	conn, _, err := websocket.DefaultDialer.Dial(baseURL+uuid.New().String(), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, live_controller.CloseAuctionNotFound) {
+		t.Errorf("Expected close code %d, got %v", live_controller.CloseAuctionNotFound, err)
+	}
+}
+
+func readTimeSync(t *testing.T, conn *websocket.Conn) live_controller.TimeSync {
+	message := readMessage(t, conn)
+	if message.Type != live_controller.TimeSyncMessage {
+		t.Fatalf("Expected a %s message, got %s", live_controller.TimeSyncMessage, message.Type)
+	}
+
+	data, _ := json.Marshal(message.Data)
+	var sync live_controller.TimeSync
+	json.Unmarshal(data, &sync)
+
+	return sync
+}
+
+func TestLiveTimeSyncStopsForClosedAuctions(t *testing.T) {
+	activeId, cancelledId := uuid.New().String(), uuid.New().String()
+	endTime := time.Now().Add(time.Minute).Truncate(time.Second)
+	hub, controller, baseURL := serveLive(t, &auctionUseCaseStub{
+		auctionIds: map[string]bool{activeId: true, cancelledId: true},
+		statuses:   map[string]auction_usecase.AuctionStatus{cancelledId: auction_usecase.AuctionStatus(auction_entity.Cancelled)},
+		endTime:    endTime,
+	})
+
+	active, _, err := websocket.DefaultDialer.Dial(baseURL+activeId, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer active.Close()
+
+	cancelled, _, err := websocket.DefaultDialer.Dial(baseURL+cancelledId, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cancelled.Close()
+
+	waitFor(t, func() bool { return hub.HasSubscribers(activeId) && hub.HasSubscribers(cancelledId) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.SyncCountdowns(ctx, 20*time.Millisecond)
+
+	sync := readTimeSync(t, active)
+	if !sync.EndsAt.Equal(endTime) {
+		t.Errorf("Expected the auction to end at %v, got %v", endTime, sync.EndsAt)
+	}
+	if time.Since(sync.ServerTime) > time.Second {
+		t.Errorf("Expected the current server time, got %v", sync.ServerTime)
+	}
+
+	cancelled.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	var message live_controller.Message
+	if err := cancelled.ReadJSON(&message); err == nil {
+		t.Errorf("Expected no message for the cancelled auction, got %s", message.Type)
+	}
+}
+
+func TestLiveExtensionResyncsCountdown(t *testing.T) {
+	auctionId := uuid.New().String()
+	hub, _, baseURL := setupLiveServer(t, auctionId)
+
+	conn, _, err := websocket.DefaultDialer.Dial(baseURL+auctionId, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	waitFor(t, func() bool { return hub.HasSubscribers(auctionId) })
+
+	endTime := time.Now().Add(30 * time.Second).Truncate(time.Second)
+	hub.PublishExtension(auctionId, endTime)
+
+	if sync := readTimeSync(t, conn); !sync.EndsAt.Equal(endTime) {
+		t.Errorf("Expected the extended end time %v, got %v", endTime, sync.EndsAt)
+	}
+}
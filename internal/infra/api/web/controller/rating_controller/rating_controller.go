@@ -0,0 +1,123 @@
+package rating_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/rating_usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RatingController struct {
+	ratingUseCase rating_usecase.RatingUseCaseInterface
+}
+
+func NewRatingController(ratingUseCase rating_usecase.RatingUseCaseInterface) *RatingController {
+	return &RatingController{
+		ratingUseCase: ratingUseCase,
+	}
+}
+
+// CreateRating answers POST /auction/:auctionId/rating with the rating the authenticated
+// user gave the seller, a 403 when the user didn't win the auction and a 409 when it
+// wasn't sold or was already rated.
+func (u *RatingController) CreateRating(c *gin.Context) {
+	userId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return
+	}
+
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		restErr := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, restErr)
+		return
+	}
+
+	var ratingInputDTO rating_usecase.RatingInputDTO
+	if err := c.ShouldBindJSON(&ratingInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	ratingData, err := u.ratingUseCase.CreateRating(c.Request.Context(), userId, auctionId, ratingInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusCreated, ratingData)
+}
+
+// FindRatingsBySellerId answers GET /user/:userId/ratings with a page of the ratings of
+// the seller, the most recent first, and the average of all of them.
+func (u *RatingController) FindRatingsBySellerId(c *gin.Context) {
+	userId := c.Param("userId")
+
+	if err := uuid.Validate(userId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   "userId",
+			Message: "Invalid UUID value",
+		})
+
+		response.Error(c, errRest)
+		return
+	}
+
+	page, errRest := parsePositiveQuery(c, "page")
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	pageSize, errRest := parsePositiveQuery(c, "page_size")
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	ratings, total, errInternal := u.ratingUseCase.FindRatingsBySellerId(c.Request.Context(), userId, page, pageSize)
+	if errInternal != nil {
+		errRest := rest_err.ConvertError(errInternal)
+		response.Error(c, errRest)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	response.Page(c, http.StatusOK, ratings, response.PageMeta(page, pageSize, total))
+}
+
+// parsePositiveQuery reads an optional numeric query param, returning 0 when it is absent.
+func parsePositiveQuery(c *gin.Context, name string) (int, *rest_err.RestErr) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+			Field:   name,
+			Message: "Must be a positive integer",
+		})
+	}
+
+	return number, nil
+}
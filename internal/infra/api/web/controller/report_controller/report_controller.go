@@ -0,0 +1,124 @@
+package report_controller
+
+import (
+	"fmt"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/usecase/report_usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	reportUseCase report_usecase.ReportUseCaseInterface
+}
+
+func NewReportController(reportUseCase report_usecase.ReportUseCaseInterface) *ReportController {
+	return &ReportController{
+		reportUseCase: reportUseCase,
+	}
+}
+
+// TopBidders answers GET /reports/top-bidders. A period without bids answers an empty
+// array.
+func (rc *ReportController) TopBidders(c *gin.Context) {
+	reportInput, errRest := parseReportQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	topBidders, err := rc.reportUseCase.TopBidders(c.Request.Context(), reportInput)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, topBidders)
+}
+
+// TopSellers answers GET /reports/top-sellers. A period without sales answers an empty
+// array.
+func (rc *ReportController) TopSellers(c *gin.Context) {
+	reportInput, errRest := parseReportQuery(c)
+	if errRest != nil {
+		response.Error(c, errRest)
+		return
+	}
+
+	topSellers, err := rc.reportUseCase.TopSellers(c.Request.Context(), reportInput)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		response.Error(c, errRest)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, topSellers)
+}
+
+// DashboardStats answers GET /admin/stats. It always answers 200: the figures that
+// couldn't be read in time are null.
+func (rc *ReportController) DashboardStats(c *gin.Context) {
+	response.JSON(c, http.StatusOK, rc.reportUseCase.DashboardStats(c.Request.Context()))
+}
+
+// parseReportQuery reads the from, to and limit params shared by the reports. Dates
+// follow the auction listing: an RFC 3339 timestamp or a YYYY-MM-DD date, which for to
+// covers the whole day.
+func parseReportQuery(c *gin.Context) (report_usecase.ReportInputDTO, *rest_err.RestErr) {
+	var reportInput report_usecase.ReportInputDTO
+
+	from, errRest := parseTimeQuery(c, "from", false)
+	if errRest != nil {
+		return reportInput, errRest
+	}
+
+	to, errRest := parseTimeQuery(c, "to", true)
+	if errRest != nil {
+		return reportInput, errRest
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return reportInput, rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
+				Field:   "limit",
+				Message: "Must be a positive integer",
+			})
+		}
+		limit = number
+	}
+
+	return report_usecase.ReportInputDTO{From: from, To: to, Limit: limit}, nil
+}
+
+func parseTimeQuery(c *gin.Context, name string, endOfDay bool) (time.Time, *rest_err.RestErr) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, rest_err.NewBadRequestError(
+			fmt.Sprintf("Invalid date format for %s", name), rest_err.FieldError{
+				Field:   name,
+				Message: "Must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			})
+	}
+
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Second)
+	}
+
+	return parsed, nil
+}
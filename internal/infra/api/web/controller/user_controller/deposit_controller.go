@@ -0,0 +1,38 @@
+package user_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/user_usecase"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Deposit tops up the wallet of the authenticated user, who can only deposit into their
+// own wallet.
+func (u *UserController) Deposit(c *gin.Context) {
+	userId, ok := u.requireOwner(c, "Users can only deposit into their own wallet")
+	if !ok {
+		return
+	}
+
+	var depositInputDTO user_usecase.DepositInputDTO
+
+	if err := c.ShouldBindJSON(&depositInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	balance, err := u.userUseCase.Deposit(c.Request.Context(), userId, depositInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, balance)
+}
@@ -1,8 +1,8 @@
 package user_controller
 
 import (
-	"context"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
 	"fullcycle-auction_go/internal/usecase/user_usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,21 +23,21 @@ func (u *UserController) FindUserById(c *gin.Context) {
 	userId := c.Param("userId")
 
 	if err := uuid.Validate(userId); err != nil {
-		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.FieldError{
 			Field:   "userId",
 			Message: "Invalid UUID value",
 		})
 
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	userData, err := u.userUseCase.FindUserById(context.Background(), userId)
+	userData, err := u.userUseCase.FindUserById(c.Request.Context(), userId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
-		c.JSON(errRest.Code, errRest)
+		response.Error(c, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, userData)
+	response.JSON(c, http.StatusOK, userData)
 }
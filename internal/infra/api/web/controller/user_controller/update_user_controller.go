@@ -0,0 +1,77 @@
+package user_controller
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/api/web/validation"
+	"fullcycle-auction_go/internal/usecase/user_usecase"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// UpdateUser changes the profile of the authenticated user, who can only update their
+// own profile.
+func (u *UserController) UpdateUser(c *gin.Context) {
+	userId, ok := u.requireOwner(c, "Users can only update their own profile")
+	if !ok {
+		return
+	}
+
+	var updateInputDTO user_usecase.UserUpdateInputDTO
+
+	if err := c.ShouldBindJSON(&updateInputDTO); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	userData, err := u.userUseCase.UpdateUser(c.Request.Context(), userId, updateInputDTO)
+	if err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	response.JSON(c, http.StatusOK, userData)
+}
+
+// DeleteUser soft deletes the account of the authenticated user.
+func (u *UserController) DeleteUser(c *gin.Context) {
+	userId, ok := u.requireOwner(c, "Users can only delete their own account")
+	if !ok {
+		return
+	}
+
+	if err := u.userUseCase.DeleteUser(c.Request.Context(), userId); err != nil {
+		restErr := rest_err.ConvertError(err)
+
+		response.Error(c, restErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// requireOwner returns the authenticated user when it is the one in the userId path
+// parameter, writing the 401 or 403 response otherwise.
+func (u *UserController) requireOwner(c *gin.Context, forbiddenMessage string) (string, bool) {
+	userId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		restErr := rest_err.NewUnauthorizedError("Authentication required")
+
+		response.Error(c, restErr)
+		return "", false
+	}
+
+	if c.Param("userId") != userId {
+		restErr := rest_err.NewForbiddenError(forbiddenMessage)
+
+		response.Error(c, restErr)
+		return "", false
+	}
+
+	return userId, true
+}
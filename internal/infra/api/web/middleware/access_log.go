@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// unmatchedPath stands for the path of requests no route matched, so scans of random
+// URLs don't add a path per request to the logs.
+const unmatchedPath = "unmatched"
+
+// AccessLog writes one entry per request once it was answered, with the method, the
+// route template, the status, the latency and the authenticated user, if any. It logs
+// through the request logger, so it must run after RequestID to carry the request id,
+// and before Recovery to see the 500 of a recovered panic. Requests taking
+// slowThreshold or more are logged as warnings, 0 never does. Routes in excludedPaths,
+// such as the health checks, aren't logged.
+func AccessLog(slowThreshold time.Duration, excludedPaths []string) gin.HandlerFunc {
+	excluded := make(map[string]struct{}, len(excludedPaths))
+	for _, path := range excludedPaths {
+		excluded[path] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		path := c.FullPath()
+		if _, ok := excluded[path]; ok {
+			return
+		}
+		if path == "" {
+			path = unmatchedPath
+		}
+
+		latency := time.Since(start)
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.Int("status", c.Writer.Status()),
+			zap.Float64("latency_ms", float64(latency.Microseconds())/1000),
+		}
+		if userId, ok := auth.UserId(c.Request.Context()); ok {
+			fields = append(fields, zap.String("user_id", userId))
+		}
+
+		l := logger.FromContext(c.Request.Context())
+		if slowThreshold > 0 && latency >= slowThreshold {
+			l.Warn("Slow request", fields...)
+		} else {
+			l.Info("Request served", fields...)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLogWritesOneEntryPerRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logger.WithLogger(c.Request.Context(), zap.New(core)))
+		c.Next()
+	})
+	router.Use(middleware.RequestID(), middleware.AccessLog(50*time.Millisecond, []string{"/healthz"}))
+	router.GET("/healthz", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/auction/:auctionId", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithUserId(c.Request.Context(), "user-1"))
+		c.Status(http.StatusNotFound)
+	})
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(60 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/healthz", "/auction/123", "/auction/456", "/slow", "/missing"} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set(middleware.RequestIDHeader, "req"+path)
+		router.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	if n := logs.Len(); n != 4 {
+		t.Fatalf("Expected 4 entries with the health check left out, got %d: %+v", n, logs.All())
+	}
+
+	auctionEntries := logs.FilterField(zap.String("path", "/auction/:auctionId")).All()
+	if len(auctionEntries) != 2 {
+		t.Fatalf("Expected both auction requests logged under the route template, got %d", len(auctionEntries))
+	}
+	fields := auctionEntries[0].ContextMap()
+	if fields["method"] != "GET" || fields["status"] != int64(http.StatusNotFound) ||
+		fields["user_id"] != "user-1" || fields["request_id"] != "req/auction/123" {
+		t.Errorf("Unexpected access log fields %v", fields)
+	}
+	if _, ok := fields["latency_ms"]; !ok {
+		t.Errorf("Expected latency_ms in %v", fields)
+	}
+
+	slow := logs.FilterField(zap.String("path", "/slow")).All()
+	if len(slow) != 1 || slow[0].Level != zapcore.WarnLevel {
+		t.Fatalf("Expected the slow request logged as a warning, got %+v", slow)
+	}
+	if _, ok := slow[0].ContextMap()["user_id"]; ok {
+		t.Error("Expected no user_id for an anonymous request")
+	}
+
+	unmatched := logs.FilterField(zap.String("path", "unmatched")).All()
+	if len(unmatched) != 1 || unmatched[0].ContextMap()["status"] != int64(http.StatusNotFound) {
+		t.Errorf("Expected the unmatched request logged without its raw path, got %+v", unmatched)
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strings"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Authenticate requires an "Authorization: Bearer <token>" header with a valid token and
+// stores the user id it identifies in the request context, see auth.UserId. Requests
+// without one are answered with 401.
+func Authenticate(tokens *auth.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if restErr := authenticate(c, tokens); restErr != nil {
+			response.AbortWithError(c, restErr)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticate verifies the bearer token of the request and stores its user id in the
+// request context.
+func authenticate(c *gin.Context, tokens *auth.TokenService) *rest_err.RestErr {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return rest_err.NewUnauthorizedError("Missing bearer token")
+	}
+
+	userId, err := tokens.Verify(token)
+	if err != nil {
+		return rest_err.NewUnauthorizedError("Invalid or expired token")
+	}
+
+	ctx := auth.WithUserId(c.Request.Context(), userId)
+	ctx = logger.WithFields(ctx, zap.String("user_id", userId))
+	c.Request = c.Request.WithContext(ctx)
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens, err := auth.NewTokenService("test-secret", 0, clock.NewFake(time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	token, _, err := tokens.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/protected", middleware.Authenticate(tokens), func(c *gin.Context) {
+		userId, _ := auth.UserId(c.Request.Context())
+		c.String(http.StatusOK, userId)
+	})
+
+	testCases := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "Valid token", authorization: "Bearer " + token, expectedStatus: http.StatusOK},
+		{name: "Missing header", authorization: "", expectedStatus: http.StatusUnauthorized},
+		{name: "Other scheme", authorization: "Basic " + token, expectedStatus: http.StatusUnauthorized},
+		{name: "Invalid token", authorization: "Bearer not-a-token", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/protected", nil)
+			if tc.authorization != "" {
+				request.Header.Set("Authorization", tc.authorization)
+			}
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+
+			if tc.expectedStatus == http.StatusOK {
+				if recorder.Body.String() != "user-1" {
+					t.Errorf("Expected the handler to see user-1, got %q", recorder.Body.String())
+				}
+				return
+			}
+
+			var restErr rest_err.RestErr
+			if err := json.Unmarshal(recorder.Body.Bytes(), &restErr); err != nil {
+				t.Fatalf("Failed to decode error body: %v", err)
+			}
+			if restErr.Err != "unauthorized" {
+				t.Errorf("Expected an unauthorized error, got %+v", restErr)
+			}
+		})
+	}
+}
+
+func TestIncludeDeleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens, err := auth.NewTokenService("test-secret", 0, clock.NewFake(time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	adminToken, _, _ := tokens.Issue("admin-2")
+	userToken, _, _ := tokens.Issue("user-1")
+
+	router := gin.New()
+	router.GET("/auction", middleware.IncludeDeleted(tokens, []string{"admin-1", "admin-2"}), func(c *gin.Context) {
+		if auction_entity.IncludesDeleted(c.Request.Context()) {
+			c.String(http.StatusOK, "with deleted")
+			return
+		}
+		c.String(http.StatusOK, "without deleted")
+	})
+
+	testCases := []struct {
+		name           string
+		target         string
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "Public listing", target: "/auction", expectedStatus: http.StatusOK, expectedBody: "without deleted"},
+		{name: "Anonymous include", target: "/auction?include_deleted=true", expectedStatus: http.StatusUnauthorized},
+		{name: "User include", target: "/auction?include_deleted=true", token: userToken, expectedStatus: http.StatusForbidden},
+		{name: "Admin include", target: "/auction?include_deleted=true", token: adminToken,
+			expectedStatus: http.StatusOK, expectedBody: "with deleted"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedBody != "" && recorder.Body.String() != tc.expectedBody {
+				t.Errorf("Expected %q, got %q", tc.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveWithCORS(allowedOrigins []string, request *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.CORS(middleware.CORSConfig{
+		AllowedOrigins: allowedOrigins,
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	}))
+	router.POST("/bid", func(c *gin.Context) {
+		c.Header("X-Total-Count", "1")
+		c.Status(http.StatusCreated)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func preflight(origin string) *http.Request {
+	request := httptest.NewRequest(http.MethodOptions, "/bid", nil)
+	request.Header.Set("Origin", origin)
+	request.Header.Set("Access-Control-Request-Method", "POST")
+	request.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	return request
+}
+
+func simpleRequest(origin string) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/bid", nil)
+	request.Header.Set("Origin", origin)
+	return request
+}
+
+func TestCORSAnswersPreflightOfAllowedOrigin(t *testing.T) {
+	recorder := serveWithCORS([]string{"https://app.example.com"}, preflight("https://app.example.com"))
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", recorder.Code)
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST",
+		"Access-Control-Allow-Headers": "Authorization, Content-Type",
+		"Access-Control-Max-Age":       "600",
+		"Vary":                         "Origin",
+	}
+	for name, value := range expected {
+		if got := recorder.Header().Get(name); got != value {
+			t.Errorf("expected %s %q, got %q", name, value, got)
+		}
+	}
+	if credentials := recorder.Header().Get("Access-Control-Allow-Credentials"); credentials != "" {
+		t.Errorf("expected no credentials allowed, got %q", credentials)
+	}
+}
+
+func TestCORSExposesHeadersOnSimpleRequest(t *testing.T) {
+	recorder := serveWithCORS([]string{"https://app.example.com"}, simpleRequest("https://app.example.com"))
+
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("expected the handler to answer 201, got %d", recorder.Code)
+	}
+	if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "https://app.example.com" {
+		t.Fatalf("expected the origin allowed, got %q", origin)
+	}
+
+	exposed := recorder.Header().Get("Access-Control-Expose-Headers")
+	if exposed != "X-Request-ID, X-Total-Count, ETag, Retry-After" {
+		t.Fatalf("expected the request id and pagination headers exposed, got %q", exposed)
+	}
+	if methods := recorder.Header().Get("Access-Control-Allow-Methods"); methods != "" {
+		t.Fatalf("expected the preflight headers only on preflights, got %q", methods)
+	}
+}
+
+func TestCORSWildcardAllowsAnyOrigin(t *testing.T) {
+	recorder := serveWithCORS([]string{"*"}, preflight("https://other.example.com"))
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", recorder.Code)
+	}
+	if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Fatalf("expected any origin allowed, got %q", origin)
+	}
+	if vary := recorder.Header().Get("Vary"); vary != "" {
+		t.Fatalf("expected no Vary for a wildcard, got %q", vary)
+	}
+}
+
+func TestCORSIgnoresDisallowedOrigin(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *http.Request
+		expected int
+	}{
+		{name: "preflight", request: preflight("https://evil.example.com"), expected: http.StatusNoContent},
+		{name: "simple request", request: simpleRequest("https://evil.example.com"), expected: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveWithCORS([]string{"https://app.example.com"}, tt.request)
+
+			if recorder.Code != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, recorder.Code)
+			}
+			for name := range recorder.Header() {
+				if strings.HasPrefix(name, "Access-Control-") {
+					t.Fatalf("expected no CORS headers, got %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSDisabledWithoutOrigins(t *testing.T) {
+	recorder := serveWithCORS(nil, preflight("https://app.example.com"))
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected the preflight left to the router, got %d", recorder.Code)
+	}
+	if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Fatalf("expected no CORS headers, got %q", origin)
+	}
+}
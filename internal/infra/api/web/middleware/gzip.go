@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// Gzip compresses the JSON responses of minSize bytes or more for the clients accepting
+// gzip. A JSON response is held until the handler returns to know its size; other
+// responses, such as the CSV export, and those the handler flushes as it goes, such as
+// the event streams, are sent as they are written. A minSize of 0 disables it.
+func Gzip(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if minSize <= 0 || !AcceptsGzip(c.Request) {
+			c.Next()
+			return
+		}
+
+		// A panicking handler skips finish, so its partial body is dropped and Recovery
+		// answers through the original writer
+		writer := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+		writer.finish()
+	}
+}
+
+// AcceptsGzip reads Accept-Encoding, honoring a gzip;q=0 refusal. Handlers compressing
+// their own streams, which this middleware passes through, check it too.
+func AcceptsGzip(request *http.Request) bool {
+	for _, header := range request.Header.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(encoding, ";")
+			if strings.TrimSpace(name) != "gzip" {
+				continue
+			}
+
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				weight, err := strconv.ParseFloat(q, 64)
+				return err == nil && weight > 0
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+type gzipMode int
+
+const (
+	gzipUndecided gzipMode = iota
+	gzipBuffering
+	gzipPassthrough
+)
+
+// gzipWriter decides on the first write whether to hold the response: JSON bodies are
+// buffered for finish, everything else goes straight through.
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	mode    gzipMode
+	buffer  bytes.Buffer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.mode == gzipUndecided {
+		w.mode = gzipPassthrough
+		if w.compressible() {
+			w.mode = gzipBuffering
+		}
+	}
+
+	if w.mode == gzipPassthrough {
+		return w.ResponseWriter.Write(data)
+	}
+
+	return w.buffer.Write(data)
+}
+
+func (w *gzipWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+func (w *gzipWriter) compressible() bool {
+	header := w.Header()
+	return header.Get("Content-Encoding") == "" &&
+		strings.HasPrefix(header.Get("Content-Type"), "application/json")
+}
+
+// Written and Size account for the buffered body, so the middlewares before this one,
+// such as Timeout, see the response as answered.
+func (w *gzipWriter) Written() bool {
+	return w.mode == gzipBuffering || w.ResponseWriter.Written()
+}
+
+func (w *gzipWriter) Size() int {
+	if w.mode == gzipBuffering {
+		return w.buffer.Len()
+	}
+
+	return w.ResponseWriter.Size()
+}
+
+// Flush sends what was buffered as is and stops buffering, since a handler flushing
+// wants the client to see the body right away.
+func (w *gzipWriter) Flush() {
+	w.release(false)
+	w.ResponseWriter.Flush()
+}
+
+// finish sends the buffered body, compressed if it reached minSize.
+func (w *gzipWriter) finish() {
+	w.release(w.buffer.Len() >= w.minSize)
+}
+
+func (w *gzipWriter) release(compress bool) {
+	if w.mode != gzipBuffering {
+		w.mode = gzipPassthrough
+		return
+	}
+	w.mode = gzipPassthrough
+
+	// Caches must keep the compressed and plain bodies apart
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	if !compress {
+		w.ResponseWriter.Write(w.buffer.Bytes())
+		return
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+
+	gz := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(gz)
+
+	gz.Reset(w.ResponseWriter)
+	gz.Write(w.buffer.Bytes())
+	gz.Close()
+}
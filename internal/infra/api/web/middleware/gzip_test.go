@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveWithGzip(acceptEncoding string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/auction", middleware.Gzip(1024), handler)
+
+	request := httptest.NewRequest(http.MethodGet, "/auction", nil)
+	if acceptEncoding != "" {
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func jsonOfSize(size int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"description": strings.Repeat("a", size)})
+	}
+}
+
+func TestGzipCompressesLargeJSON(t *testing.T) {
+	recorder := serveWithGzip("br, gzip", jsonOfSize(4096))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+	if encoding := recorder.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("expected a gzip body, got encoding %q", encoding)
+	}
+
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatalf("expected a valid gzip body: %v", err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("expected a valid gzip body: %v", err)
+	}
+	if !strings.HasPrefix(string(body), `{"description":"aaa`) || len(body) < 4096 {
+		t.Fatalf("expected the JSON body once decompressed, got %d bytes", len(body))
+	}
+}
+
+func TestGzipLeavesOtherResponsesAsIs(t *testing.T) {
+	testCases := []struct {
+		name           string
+		acceptEncoding string
+		handler        gin.HandlerFunc
+	}{
+		{name: "Small JSON", acceptEncoding: "gzip", handler: jsonOfSize(10)},
+		{name: "Client without gzip", handler: jsonOfSize(4096)},
+		{name: "Client refusing gzip", acceptEncoding: "gzip;q=0", handler: jsonOfSize(4096)},
+		{name: "CSV", acceptEncoding: "gzip", handler: func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/csv", []byte(strings.Repeat("a,b\n", 1024)))
+		}},
+		{name: "Flushed JSON", acceptEncoding: "gzip", handler: func(c *gin.Context) {
+			c.Header("Content-Type", "application/json")
+			c.Writer.WriteString(`"` + strings.Repeat("a", 2048))
+			c.Writer.Flush()
+			c.Writer.WriteString(strings.Repeat("a", 2048) + `"`)
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithGzip(tc.acceptEncoding, tc.handler)
+
+			if encoding := recorder.Header().Get("Content-Encoding"); encoding != "" {
+				t.Fatalf("expected a plain body, got encoding %q", encoding)
+			}
+			if recorder.Body.Len() == 0 {
+				t.Fatal("expected the body to be sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Default rate of the limiters, see RateLimitConfig
+	DefaultRateLimitPerSecond = 5.0
+	DefaultRateLimitBurst     = 10
+
+	// rateLimitSweepInterval is how often Allow looks for idle keys to evict
+	rateLimitSweepInterval = time.Minute
+)
+
+// RateLimitConfig is the rate of a RateLimiter: up to Burst requests at once, refilled at
+// PerSecond requests per second. A PerSecond of 0 disables the limiter.
+type RateLimitConfig struct {
+	PerSecond float64
+	Burst     int
+}
+
+// RateLimiter is a token bucket per key: each key may spend up to burst requests at once,
+// refilled at perSecond requests per second. It is safe for concurrent use.
+type RateLimiter struct {
+	perSecond float64
+	burst     float64
+	clock     clock.Clock
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter builds a limiter with the rate of config; a burst of 0 means
+// DefaultRateLimitBurst. A nil clk uses the real clock.
+func NewRateLimiter(clk clock.Clock, config RateLimitConfig) *RateLimiter {
+	if clk == nil {
+		clk = clock.New()
+	}
+	if config.Burst <= 0 {
+		config.Burst = DefaultRateLimitBurst
+	}
+
+	return &RateLimiter{
+		perSecond: config.PerSecond,
+		burst:     float64(config.Burst),
+		clock:     clk,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: clk.Now(),
+	}
+}
+
+// Allow spends a token of key. When the bucket is empty it returns false along with how
+// long until the next token is available.
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if rl.perSecond <= 0 {
+		return true, 0
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	rl.evictIdle(now)
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(rl.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*rl.perSecond)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / rl.perSecond * float64(time.Second))
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// Len returns how many keys the limiter is tracking.
+func (rl *RateLimiter) Len() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return len(rl.buckets)
+}
+
+// evictIdle drops the buckets that refilled completely. They are exactly what a new
+// bucket would be, so evicting them changes no decision while bounding memory to the
+// keys active within the last refill period. The caller must hold the lock.
+func (rl *RateLimiter) evictIdle(now time.Time) {
+	if now.Sub(rl.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	rl.lastSweep = now
+
+	for key, bucket := range rl.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*rl.perSecond >= rl.burst {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects requests beyond the limiter's rate with 429 and a Retry-After header,
+// in seconds. Requests are limited per authenticated user, so it must run after
+// Authenticate, falling back to the client IP for anonymous requests.
+func RateLimit(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userId, ok := auth.UserId(c.Request.Context()); ok {
+			key = "user:" + userId
+		}
+
+		if allowed, retryAfter := limiter.Allow(key); !allowed {
+			restErr := rest_err.NewTooManyRequestsError("Too many requests, try again later")
+
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			response.AbortWithError(c, restErr)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,140 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitedRouter(limiter *middleware.RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/bid", func(c *gin.Context) {
+		if userId := c.GetHeader("X-Test-User"); userId != "" {
+			c.Request = c.Request.WithContext(auth.WithUserId(c.Request.Context(), userId))
+		}
+		c.Next()
+	}, middleware.RateLimit(limiter), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	return router
+}
+
+func postBid(router *gin.Engine, userId, remoteAddr string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/bid", nil)
+	request.RemoteAddr = remoteAddr
+	if userId != "" {
+		request.Header.Set("X-Test-User", userId)
+	}
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestRateLimitUnderConcurrentRequests(t *testing.T) {
+	// The fake clock doesn't move, so no token is refilled while the requests run
+	router := newRateLimitedRouter(middleware.NewRateLimiter(
+		clock.NewFake(time.Now()), middleware.RateLimitConfig{PerSecond: 1, Burst: 20}))
+
+	var allowed, limited atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				recorder := postBid(router, "user-1", "10.0.0.1:1234")
+				switch recorder.Code {
+				case http.StatusCreated:
+					allowed.Add(1)
+				case http.StatusTooManyRequests:
+					if recorder.Header().Get("Retry-After") != "1" {
+						t.Errorf("Expected Retry-After 1, got %q", recorder.Header().Get("Retry-After"))
+					}
+					limited.Add(1)
+				default:
+					t.Errorf("Unexpected status %d", recorder.Code)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 20 || limited.Load() != 480 {
+		t.Errorf("Expected exactly the burst of 20 requests allowed, got %d allowed and %d limited",
+			allowed.Load(), limited.Load())
+	}
+}
+
+func TestRateLimitKeysByUserThenIP(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	router := newRateLimitedRouter(middleware.NewRateLimiter(clk, middleware.RateLimitConfig{PerSecond: 0.5, Burst: 1}))
+
+	// Two users behind the same IP have their own buckets, and so do anonymous clients
+	// on different IPs
+	for _, request := range []struct{ userId, remoteAddr string }{
+		{"user-1", "10.0.0.1:1234"},
+		{"user-2", "10.0.0.1:1234"},
+		{"", "10.0.0.1:1234"},
+		{"", "10.0.0.2:1234"},
+	} {
+		if recorder := postBid(router, request.userId, request.remoteAddr); recorder.Code != http.StatusCreated {
+			t.Errorf("Expected the first request of %+v to pass, got %d", request, recorder.Code)
+		}
+	}
+
+	recorder := postBid(router, "user-1", "10.0.0.3:1234")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected user-1 to be limited from any IP, got %d", recorder.Code)
+	}
+	if retryAfter, _ := strconv.Atoi(recorder.Header().Get("Retry-After")); retryAfter != 2 {
+		t.Errorf("Expected Retry-After 2 at half a request per second, got %q", recorder.Header().Get("Retry-After"))
+	}
+
+	clk.Advance(2 * time.Second)
+	if recorder := postBid(router, "user-1", "10.0.0.1:1234"); recorder.Code != http.StatusCreated {
+		t.Errorf("Expected a refilled token after 2 seconds, got %d", recorder.Code)
+	}
+}
+
+func TestRateLimiterEvictsIdleKeys(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	limiter := middleware.NewRateLimiter(clk, middleware.RateLimitConfig{PerSecond: 10, Burst: 5})
+
+	for i := 0; i < 100; i++ {
+		limiter.Allow("idle-" + strconv.Itoa(i))
+	}
+	if limiter.Len() != 100 {
+		t.Fatalf("Expected 100 tracked keys, got %d", limiter.Len())
+	}
+
+	clk.Advance(2 * time.Minute)
+	limiter.Allow("active")
+
+	if limiter.Len() != 1 {
+		t.Errorf("Expected the idle keys to be evicted, got %d tracked keys", limiter.Len())
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := middleware.NewRateLimiter(clock.NewFake(time.Now()), middleware.RateLimitConfig{PerSecond: 0, Burst: 1})
+	for i := 0; i < 100; i++ {
+		if allowed, _ := limiter.Allow("user-1"); !allowed {
+			t.Fatalf("Expected a zero rate to disable the limiter, got request %d limited", i)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+	"fullcycle-auction_go/internal/infra/metrics"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Recovery turns a panic in the handlers after it into a 500 with the structured error
+// body. The panic is logged with its stack trace through the request logger, so it must
+// run after RequestID to carry the request id, and counted in http_handler_panics_total.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			err, ok := recovered.(error)
+			if !ok {
+				err = fmt.Errorf("%v", recovered)
+			}
+
+			logger.ErrorContext(c.Request.Context(), "Recovered from a panic serving the request", err,
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.FullPath()),
+				zap.String("stack", string(debug.Stack())))
+			metrics.HandlerPanics.Inc()
+
+			// A handler that panicked halfway through its response can't be answered anymore
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			restErr := rest_err.NewInternalServerError("Internal server error")
+			response.AbortWithError(c, restErr)
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoveryAnswersPanicsWithStructuredError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Recovery())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("something went wrong")
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+		t.Errorf("Expected a JSON body, got %q", contentType)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON body, got %q: %v", recorder.Body.String(), err)
+	}
+	for _, field := range []string{"message", "err", "code", "causes"} {
+		if _, ok := body[field]; !ok {
+			t.Errorf("Expected the %q field in %v", field, body)
+		}
+	}
+
+	var restErr rest_err.RestErr
+	json.Unmarshal(recorder.Body.Bytes(), &restErr)
+	if restErr.Err != "internal_server" || restErr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected an internal_server error, got %+v", restErr)
+	}
+	if recorder.Header().Get(middleware.RequestIDHeader) == "" {
+		t.Error("Expected the request id to be kept on the response")
+	}
+
+	// The server keeps serving after the panic
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 after the panic, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"fullcycle-auction_go/configuration/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength keeps clients from stuffing arbitrary payloads into every log entry
+	maxRequestIDLength = 128
+)
+
+// RequestID propagates the X-Request-ID sent by the client, or generates one, echoes it
+// back on the response and stores a logger tagged with it in the request context, so
+// logger.FromContext ties every entry written while serving the request to it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIDHeader)
+		if requestId == "" || len(requestId) > maxRequestIDLength {
+			requestId = uuid.New().String()
+		}
+
+		c.Header(RequestIDHeader, requestId)
+
+		ctx := logger.WithFields(c.Request.Context(), zap.String("request_id", requestId))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
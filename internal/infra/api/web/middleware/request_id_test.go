@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newRouter logs one entry per request through the contextual logger, captured by logs.
+func newRouter() (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logger.WithLogger(c.Request.Context(), zap.New(core)))
+		c.Next()
+	})
+	router.Use(middleware.RequestID())
+	router.GET("/", func(c *gin.Context) {
+		logger.InfoContext(c.Request.Context(), "handled")
+		c.Status(http.StatusOK)
+	})
+
+	return router, logs
+}
+
+func TestRequestIDIsPropagated(t *testing.T) {
+	router, logs := newRouter()
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(middleware.RequestIDHeader, "abc-123")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if got := recorder.Header().Get(middleware.RequestIDHeader); got != "abc-123" {
+		t.Fatalf("expected the request id to be echoed back, got %q", got)
+	}
+
+	entries := logs.FilterField(zap.String("request_id", "abc-123")).All()
+	if len(entries) != 1 {
+		t.Fatalf("expected one log entry tagged with the request id, got %d", len(entries))
+	}
+}
+
+func TestRequestIDIsGenerated(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+	}{
+		{name: "Missing header"},
+		{name: "Oversized header", header: strings.Repeat("x", 200)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router, logs := newRouter()
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				request.Header.Set(middleware.RequestIDHeader, tc.header)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			requestId := recorder.Header().Get(middleware.RequestIDHeader)
+			if err := uuid.Validate(requestId); err != nil {
+				t.Fatalf("expected a generated UUID request id, got %q", requestId)
+			}
+
+			if len(logs.FilterField(zap.String("request_id", requestId)).All()) != 1 {
+				t.Fatalf("expected the log entry to carry the generated request id")
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/api/web/auth"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin only lets through the users of adminUserIds. It must run after
+// Authenticate; everyone else gets 403.
+func RequireAdmin(adminUserIds []string) gin.HandlerFunc {
+	admins := adminSet(adminUserIds)
+
+	return func(c *gin.Context) {
+		if restErr := requireAdmin(c, admins); restErr != nil {
+			response.AbortWithError(c, restErr)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IncludeDeleted serves soft-deleted auctions too when the request asks for them with
+// ?include_deleted=true, which is reserved for admins: the request must then carry an
+// admin's bearer token, one of adminUserIds. Other requests pass through untouched,
+// authenticated or not.
+func IncludeDeleted(tokens *auth.TokenService, adminUserIds []string) gin.HandlerFunc {
+	admins := adminSet(adminUserIds)
+
+	return func(c *gin.Context) {
+		if c.Query("include_deleted") != "true" {
+			c.Next()
+			return
+		}
+
+		if restErr := authenticate(c, tokens); restErr != nil {
+			response.AbortWithError(c, restErr)
+			return
+		}
+
+		if restErr := requireAdmin(c, admins); restErr != nil {
+			response.AbortWithError(c, restErr)
+			return
+		}
+
+		c.Request = c.Request.WithContext(auction_entity.WithDeleted(c.Request.Context()))
+		c.Next()
+	}
+}
+
+func requireAdmin(c *gin.Context, admins map[string]bool) *rest_err.RestErr {
+	userId, ok := auth.UserId(c.Request.Context())
+	if !ok {
+		return rest_err.NewUnauthorizedError("Authentication required")
+	}
+
+	if !admins[userId] {
+		return rest_err.NewForbiddenError("Only administrators can do this")
+	}
+
+	return nil
+}
+
+func adminSet(adminUserIds []string) map[string]bool {
+	admins := make(map[string]bool, len(adminUserIds))
+	for _, userId := range adminUserIds {
+		admins[userId] = true
+	}
+
+	return admins
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout bounds the handlers after it by timeout: their request context is done once it
+// passes, which aborts the database operations in flight, and a handler that didn't
+// answer by then gets a 504 with the structured error body. Handlers that answered with
+// an error caused by the deadline are reported as 504 by rest_err.ConvertError. A
+// timeout of 0 disables it.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			restErr := rest_err.NewGatewayTimeoutError("The request timed out")
+			response.AbortWithError(c, restErr)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/middleware"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveWithTimeout(timeout time.Duration, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/auction", middleware.Timeout(timeout), handler)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/auction", nil))
+
+	return recorder
+}
+
+func decodeRestErr(t *testing.T, recorder *httptest.ResponseRecorder) rest_err.RestErr {
+	t.Helper()
+
+	var restErr rest_err.RestErr
+	if err := json.Unmarshal(recorder.Body.Bytes(), &restErr); err != nil {
+		t.Fatalf("expected a structured error body, got %q: %v", recorder.Body.String(), err)
+	}
+	return restErr
+}
+
+func TestTimeoutAnswers504WhenHandlerDoesNotAnswer(t *testing.T) {
+	recorder := serveWithTimeout(20*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	if recorder.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", recorder.Code)
+	}
+	if restErr := decodeRestErr(t, recorder); restErr.Err != "gateway_timeout" || restErr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected a gateway_timeout error, got %+v", restErr)
+	}
+}
+
+func TestTimeoutReportsDeadlineErrorsAs504(t *testing.T) {
+	recorder := serveWithTimeout(20*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+
+		internalError := internal_error.NewInternalServerError("Error trying to find auctions").
+			Wrap(c.Request.Context().Err())
+		restErr := rest_err.ConvertError(internalError)
+		c.JSON(restErr.Code, restErr)
+	})
+
+	if recorder.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", recorder.Code)
+	}
+	if restErr := decodeRestErr(t, recorder); restErr.Err != "gateway_timeout" {
+		t.Fatalf("expected a gateway_timeout error, got %+v", restErr)
+	}
+}
+
+func TestTimeoutLetsFastRequestsThrough(t *testing.T) {
+	recorder := serveWithTimeout(time.Second, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,99 @@
+package response
+
+import (
+	"math"
+	"strconv"
+
+	"fullcycle-auction_go/configuration/rest_err"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Body is the envelope of the /api/v1 responses: Data on success, Error on failure, in
+// the rest_err structure, and Meta along with the paginated listings. Every field is
+// always sent, null when it doesn't apply.
+type Body struct {
+	Data  interface{}       `json:"data"`
+	Error *rest_err.RestErr `json:"error"`
+	Meta  *Meta             `json:"meta"`
+}
+
+// Meta is the pagination of a listing. Page listings set Page, PageSize, when requested,
+// and Total; cursor listings set Limit, when requested, and NextCursor, empty on the
+// last page.
+type Meta struct {
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PageMeta is the Meta of a page listing. A page of zero is the first one.
+func PageMeta(page, pageSize int, total int64) *Meta {
+	if page < 1 {
+		page = 1
+	}
+
+	return &Meta{Page: page, PageSize: pageSize, Total: &total}
+}
+
+const envelopeKey = "response.envelope"
+
+// Envelope makes the helpers of this package answer the routes after it with a Body.
+// Without it they write the bare data and errors of the routes at the root, which
+// clients written before /api/v1 still read.
+func Envelope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(envelopeKey, true)
+		c.Next()
+	}
+}
+
+// Enveloped reports whether the route answers with a Body, see Envelope.
+func Enveloped(c *gin.Context) bool {
+	return c.GetBool(envelopeKey)
+}
+
+// JSON answers with data and status.
+func JSON(c *gin.Context, status int, data interface{}) {
+	if !Enveloped(c) {
+		c.JSON(status, data)
+		return
+	}
+
+	c.JSON(status, Body{Data: data})
+}
+
+// Page answers with a page of a listing, meta carrying its pagination.
+func Page(c *gin.Context, status int, data interface{}, meta *Meta) {
+	if !Enveloped(c) {
+		c.JSON(status, data)
+		return
+	}
+
+	c.JSON(status, Body{Data: data, Meta: meta})
+}
+
+// Error answers with restErr and its status code, along with a Retry-After header, in
+// seconds, when restErr has a RetryAfter.
+func Error(c *gin.Context, restErr *rest_err.RestErr) {
+	if restErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(restErr.RetryAfter.Seconds()))))
+	}
+
+	if !Enveloped(c) {
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+
+	c.JSON(restErr.Code, Body{Error: restErr})
+}
+
+// AbortWithError answers like Error and keeps the handlers after the caller from running,
+// for the middlewares.
+func AbortWithError(c *gin.Context, restErr *rest_err.RestErr) {
+	c.Abort()
+	Error(c, restErr)
+}
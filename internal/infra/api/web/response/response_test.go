@@ -0,0 +1,149 @@
+package response_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/infra/api/web/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type item struct {
+	Id string `json:"id"`
+}
+
+// serve answers path with handler, both under /api/v1, with the envelope, and at the root
+func serve(t *testing.T, path string, handler gin.HandlerFunc) (enveloped, bare *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Group("/api/v1", response.Envelope()).GET(path, handler)
+	router.GET(path, handler)
+
+	enveloped = httptest.NewRecorder()
+	router.ServeHTTP(enveloped, httptest.NewRequest(http.MethodGet, "/api/v1"+path, nil))
+
+	bare = httptest.NewRecorder()
+	router.ServeHTTP(bare, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return enveloped, bare
+}
+
+func decodeBody(t *testing.T, recorder *httptest.ResponseRecorder) map[string]json.RawMessage {
+	t.Helper()
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON body, got %q: %v", recorder.Body.String(), err)
+	}
+	return body
+}
+
+func TestJSONWrapsDataOnlyUnderTheEnvelope(t *testing.T) {
+	enveloped, bare := serve(t, "/auction", func(c *gin.Context) {
+		response.JSON(c, http.StatusOK, item{Id: "123"})
+	})
+
+	if enveloped.Code != http.StatusOK || bare.Code != http.StatusOK {
+		t.Fatalf("Expected 200 on both routes, got %d and %d", enveloped.Code, bare.Code)
+	}
+
+	body := decodeBody(t, enveloped)
+	if string(body["data"]) != `{"id":"123"}` {
+		t.Errorf("Expected the item in data, got %s", body["data"])
+	}
+	for _, field := range []string{"error", "meta"} {
+		if raw, ok := body[field]; !ok || string(raw) != "null" {
+			t.Errorf("Expected %s to be sent as null, got %s", field, raw)
+		}
+	}
+
+	if bare.Body.String() != `{"id":"123"}` {
+		t.Errorf("Expected the bare item at the root, got %s", bare.Body.String())
+	}
+}
+
+func TestErrorKeepsTheRestErrInsideError(t *testing.T) {
+	enveloped, bare := serve(t, "/auction", func(c *gin.Context) {
+		response.Error(c, rest_err.NewBadRequestError("Invalid fields",
+			rest_err.FieldError{Field: "product_name", Message: "is required"}))
+	})
+
+	if enveloped.Code != http.StatusBadRequest || bare.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 on both routes, got %d and %d", enveloped.Code, bare.Code)
+	}
+
+	var body response.Body
+	if err := json.Unmarshal(enveloped.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected an envelope, got %q: %v", enveloped.Body.String(), err)
+	}
+	if body.Data != nil || body.Meta != nil {
+		t.Errorf("Expected no data nor meta along with the error, got %+v", body)
+	}
+	if body.Error == nil || body.Error.Err != "bad_request" || body.Error.Code != http.StatusBadRequest ||
+		len(body.Error.Causes) != 1 || body.Error.Causes[0].Field != "product_name" {
+		t.Errorf("Expected the rest_err with its causes in error, got %+v", body.Error)
+	}
+
+	var restErr rest_err.RestErr
+	if err := json.Unmarshal(bare.Body.Bytes(), &restErr); err != nil || restErr.Err != "bad_request" {
+		t.Errorf("Expected the bare rest_err at the root, got %s", bare.Body.String())
+	}
+}
+
+func TestPageSendsThePaginationInMeta(t *testing.T) {
+	enveloped, bare := serve(t, "/auction", func(c *gin.Context) {
+		response.Page(c, http.StatusOK, []item{{Id: "1"}, {Id: "2"}}, response.PageMeta(0, 2, 7))
+	})
+
+	body := decodeBody(t, enveloped)
+	var meta response.Meta
+	if err := json.Unmarshal(body["meta"], &meta); err != nil {
+		t.Fatalf("Expected the meta, got %s: %v", body["meta"], err)
+	}
+	if meta.Page != 1 || meta.PageSize != 2 || meta.Total == nil || *meta.Total != 7 {
+		t.Errorf("Expected page 1 of size 2 out of 7, got %+v", meta)
+	}
+	if string(body["data"]) != `[{"id":"1"},{"id":"2"}]` {
+		t.Errorf("Expected the page in data, got %s", body["data"])
+	}
+
+	if bare.Body.String() != `[{"id":"1"},{"id":"2"}]` {
+		t.Errorf("Expected the bare page at the root, got %s", bare.Body.String())
+	}
+}
+
+func TestAbortWithErrorStopsTheChain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reached := false
+	router := gin.New()
+	router.Group("/api/v1", response.Envelope()).GET("/bid",
+		func(c *gin.Context) {
+			response.AbortWithError(c, rest_err.NewUnauthorizedError("Missing token"))
+		},
+		func(c *gin.Context) {
+			reached = true
+		})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/bid", nil))
+
+	if reached {
+		t.Error("Expected the handler after the abort not to run")
+	}
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", recorder.Code)
+	}
+
+	var body response.Body
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body.Error == nil ||
+		body.Error.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the error in the envelope, got %s", recorder.Body.String())
+	}
+}
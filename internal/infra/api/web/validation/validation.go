@@ -3,12 +3,17 @@ package validation
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/money"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	validator_en "github.com/go-playground/validator/v10/translations/en"
+	"reflect"
+	"strings"
 )
 
 var (
@@ -22,7 +27,57 @@ func init() {
 		enTransl := ut.New(en, en)
 		transl, _ = enTransl.GetTranslator("en")
 		validator_en.RegisterDefaultTranslations(value, transl)
+
+		// Report fields by their JSON name, which is what clients sent
+		value.RegisterTagNameFunc(jsonFieldName)
+
+		registerProductCondition(value)
+		registerAmount(value)
+	}
+}
+
+// registerAmount adds the amount tag, for the money.Decimal amounts of the requests:
+// positive, with at most two decimal places.
+func registerAmount(value *validator.Validate) {
+	value.RegisterValidation("amount", func(field validator.FieldLevel) bool {
+		cents, err := money.Parse(field.Field().String())
+		return err == nil && cents > 0
+	})
+
+	value.RegisterTranslation("amount", transl,
+		func(translator ut.Translator) error {
+			return translator.Add("amount", "{0} must be a positive amount with at most two decimal places", true)
+		},
+		func(translator ut.Translator, fieldError validator.FieldError) string {
+			message, _ := translator.T("amount", fieldError.Field())
+			return message
+		})
+}
+
+// registerProductCondition adds the product_condition tag, for the conditions clients
+// send by name.
+func registerProductCondition(value *validator.Validate) {
+	value.RegisterValidation("product_condition", func(field validator.FieldLevel) bool {
+		return auction_entity.ProductCondition(field.Field().Int()).Valid()
+	})
+
+	value.RegisterTranslation("product_condition", transl,
+		func(translator ut.Translator) error {
+			return translator.Add("product_condition", "{0} must be one of new, used or refurbished", true)
+		},
+		func(translator ut.Translator, fieldError validator.FieldError) string {
+			message, _ := translator.T("product_condition", fieldError.Field())
+			return message
+		})
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "" || name == "-" {
+		return field.Name
 	}
+
+	return name
 }
 
 func ValidateErr(validation_err error) *rest_err.RestErr {
@@ -30,12 +85,15 @@ func ValidateErr(validation_err error) *rest_err.RestErr {
 	var jsonValidation validator.ValidationErrors
 
 	if errors.As(validation_err, &jsonErr) {
-		return rest_err.NewNotFoundError("Invalid type error")
+		return rest_err.NewBadRequestError("Invalid field values", rest_err.FieldError{
+			Field:   jsonErr.Field,
+			Message: fmt.Sprintf("%s must be a %s", jsonErr.Field, jsonErr.Type.String()),
+		})
 	} else if errors.As(validation_err, &jsonValidation) {
-		errorCauses := []rest_err.Causes{}
+		errorCauses := []rest_err.FieldError{}
 
 		for _, e := range validation_err.(validator.ValidationErrors) {
-			errorCauses = append(errorCauses, rest_err.Causes{
+			errorCauses = append(errorCauses, rest_err.FieldError{
 				Field:   e.Field(),
 				Message: e.Translate(transl),
 			})
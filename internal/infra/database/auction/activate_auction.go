@@ -0,0 +1,92 @@
+package auction
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/database/retry"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ActivateScheduledAuctions makes every Scheduled auction whose start_time already
+// passed Active and returns the ids of the auctions it activated.
+func (ar *AuctionRepository) ActivateScheduledAuctions(ctx context.Context) ([]string, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.ActivateScheduledAuctions")
+	defer span.End()
+
+	return ar.activateScheduled(ctx, bson.M{})
+}
+
+// ActivateAuctionsById activates the auctions among auctionIds that are still Scheduled
+// and whose start_time already passed, like ActivateScheduledAuctions.
+func (ar *AuctionRepository) ActivateAuctionsById(
+	ctx context.Context, auctionIds []string) ([]string, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.ActivateAuctionsById",
+		attribute.Int("auction_count", len(auctionIds)))
+	defer span.End()
+
+	return ar.activateScheduled(ctx, bson.M{"_id": bson.M{"$in": auctionIds}})
+}
+
+// activateScheduled activates the due Scheduled auctions matching filter. The status
+// filter of the update keeps it harmless for auctions activated or cancelled meanwhile.
+func (ar *AuctionRepository) activateScheduled(
+	ctx context.Context, filter bson.M) ([]string, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter["status"] = auction_entity.Scheduled
+	filter["start_time"] = bson.M{"$lte": ar.Clock.Now()}
+
+	var dueAuctions []AuctionEntityMongo
+	err := retry.Do(ctx, retry.DefaultPolicy(), "find_due_scheduled_auctions", func(ctx context.Context) error {
+		cursor, err := ar.Collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return err
+		}
+
+		return cursor.All(ctx, &dueAuctions)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find scheduled auctions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find scheduled auctions").Wrap(err)
+	}
+
+	if len(dueAuctions) == 0 {
+		return nil, nil
+	}
+
+	auctionIds := make([]string, 0, len(dueAuctions))
+	for _, due := range dueAuctions {
+		auctionIds = append(auctionIds, due.Id)
+	}
+
+	err = retry.Do(ctx, retry.DefaultPolicy(), "activate_scheduled_auctions", func(ctx context.Context) error {
+		_, err := ar.Collection.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": auctionIds}, "status": auction_entity.Scheduled},
+			bson.M{"$set": bson.M{"status": auction_entity.Active}, "$inc": revisionIncrement})
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to activate scheduled auctions", err, zap.Strings("auction_ids", auctionIds))
+		return nil, internal_error.NewInternalServerError("Error trying to activate scheduled auctions").Wrap(err)
+	}
+
+	activatedAt := ar.Clock.Now()
+	auditEntries := make([]auction_entity.AuctionAudit, 0, len(auctionIds))
+	for _, auctionId := range auctionIds {
+		auditEntries = append(auditEntries, auction_entity.NewAuctionAudit(
+			ctx, auctionId, auction_entity.Scheduled, auction_entity.Active, auction_entity.AuditReasonStarted, activatedAt))
+	}
+	ar.recordStatusChanges(ctx, auditEntries)
+
+	return auctionIds, nil
+}
@@ -0,0 +1,132 @@
+package auction
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// archiveBatchSize is how many auctions ArchiveCompleted moves at once.
+const archiveBatchSize = 500
+
+// ArchiveCompleted moves the Completed and Cancelled auctions that ended more than
+// olderThan ago to the archive collection, keeping their ids, so the listings only scan
+// the auctions still of interest. FindAuctionById falls back to the archive, so links to
+// archived auctions keep working; their bids stay where they are.
+//
+// Each batch is copied to the archive before it is removed, and an auction is only
+// removed if it didn't change since it was copied, so an interrupted run loses nothing
+// and the next one picks up where it stopped. It returns how many auctions it moved.
+func (ar *AuctionRepository) ArchiveCompleted(
+	ctx context.Context, olderThan time.Duration) (int64, *internal_error.InternalError) {
+	cutoff := ar.Clock.Now().Add(-olderThan)
+
+	var archived int64
+	for {
+		moved, found, err := ar.archiveBatch(ctx, cutoff)
+		if err != nil {
+			return archived, err
+		}
+		archived += moved
+
+		// Auctions changed while being copied are left behind and skipped by the next
+		// batches, so a batch moving nothing ends the run rather than looping on them
+		if found < archiveBatchSize || moved == 0 {
+			break
+		}
+	}
+
+	if archived > 0 {
+		logger.InfoContext(ctx, "Auctions archived", zap.Int64("count", archived))
+	}
+
+	return archived, nil
+}
+
+// archiveBatch moves the next batch of auctions that ended before cutoff. It returns
+// how many it moved and how many it found.
+func (ar *AuctionRepository) archiveBatch(
+	ctx context.Context, cutoff time.Time) (int64, int, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{
+		"status":   bson.M{"$in": bson.A{auction_entity.Completed, auction_entity.Cancelled}},
+		"end_time": bson.M{"$lt": cutoff},
+	}
+
+	// Documents are moved as they are stored, fields this version doesn't know included
+	var auctions []bson.M
+	cursor, err := ar.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}).SetLimit(archiveBatchSize))
+	if err == nil {
+		err = cursor.All(ctx, &auctions)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auctions to archive", err)
+		return 0, 0, internal_error.NewInternalServerError("Error trying to archive auctions").Wrap(err)
+	}
+
+	if len(auctions) == 0 {
+		return 0, 0, nil
+	}
+
+	copies := make([]mongo.WriteModel, 0, len(auctions))
+	removals := make([]mongo.WriteModel, 0, len(auctions))
+	for _, auction := range auctions {
+		copies = append(copies, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": auction["_id"]}).
+			SetReplacement(auction).
+			SetUpsert(true))
+
+		// Every update bumps the revision, so a matching one means the copy is current
+		unchanged := bson.M{"_id": auction["_id"], "revision": bson.M{"$exists": false}}
+		if revision, ok := auction["revision"]; ok {
+			unchanged["revision"] = revision
+		}
+		removals = append(removals, mongo.NewDeleteOneModel().SetFilter(unchanged))
+	}
+
+	if _, err := ar.ArchiveCollection.BulkWrite(ctx, copies, options.BulkWrite().SetOrdered(false)); err != nil {
+		logger.ErrorContext(ctx, "Error trying to copy auctions to the archive", err)
+		return 0, 0, internal_error.NewInternalServerError("Error trying to archive auctions").Wrap(err)
+	}
+
+	result, err := ar.Collection.BulkWrite(ctx, removals, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to remove archived auctions", err)
+		return 0, 0, internal_error.NewInternalServerError("Error trying to archive auctions").Wrap(err)
+	}
+
+	return result.DeletedCount, len(auctions), nil
+}
+
+// RunArchival archives the auctions that ended more than olderThan ago every interval,
+// until ctx is done. A failed run is logged by ArchiveCompleted and tried again on the
+// next interval. A zero olderThan or interval disables it.
+func (ar *AuctionRepository) RunArchival(ctx context.Context, olderThan, interval time.Duration) {
+	if olderThan <= 0 || interval <= 0 {
+		return
+	}
+
+	timer := ar.Clock.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.Chan():
+			ar.ArchiveCompleted(ctx, olderThan)
+			timer.Reset(interval)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+type AuctionAuditMongo struct {
+	Id        string                       `bson:"_id"`
+	AuctionId string                       `bson:"auction_id"`
+	From      auction_entity.AuctionStatus `bson:"from_status"`
+	To        auction_entity.AuctionStatus `bson:"to_status"`
+	Actor     string                       `bson:"actor"`
+	Reason    string                       `bson:"reason"`
+	Timestamp time.Time                    `bson:"timestamp"`
+}
+
+// recordStatusChanges appends entries to the audit log. Inside a transaction a failure is
+// returned, so the status change rolls back along with it; otherwise the status change
+// already happened and a failure is only logged.
+func (ar *AuctionRepository) recordStatusChanges(ctx context.Context, entries []auction_entity.AuctionAudit) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(entries))
+	auctionIds := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		documents = append(documents, AuctionAuditMongo{
+			Id:        entry.Id,
+			AuctionId: entry.AuctionId,
+			From:      entry.From,
+			To:        entry.To,
+			Actor:     entry.Actor,
+			Reason:    entry.Reason,
+			Timestamp: entry.Timestamp,
+		})
+		auctionIds = append(auctionIds, entry.AuctionId)
+	}
+
+	_, err := ar.AuditCollection.InsertMany(ctx, documents)
+	if err == nil {
+		return nil
+	}
+
+	if mongo.SessionFromContext(ctx) != nil {
+		return err
+	}
+
+	logger.ErrorContext(ctx, "Error trying to record auction status changes", err, zap.Strings("auction_ids", auctionIds))
+	return nil
+}
+
+func (ar *AuctionRepository) FindAuditByAuctionId(
+	ctx context.Context, auctionId string) ([]auction_entity.AuctionAudit, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.FindAuditByAuctionId", attribute.String("auction_id", auctionId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := ar.AuditCollection.Find(ctx, bson.M{"auction_id": auctionId}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find the auction audit log", err, zap.String("auction_id", auctionId))
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find the audit log of auction %s", auctionId)).Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var entriesMongo []AuctionAuditMongo
+	if err := cursor.All(ctx, &entriesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to find the auction audit log", err, zap.String("auction_id", auctionId))
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find the audit log of auction %s", auctionId)).Wrap(err)
+	}
+
+	entries := make([]auction_entity.AuctionAudit, 0, len(entriesMongo))
+	for _, entry := range entriesMongo {
+		entries = append(entries, auction_entity.AuctionAudit{
+			Id:        entry.Id,
+			AuctionId: entry.AuctionId,
+			From:      entry.From,
+			To:        entry.To,
+			Actor:     entry.Actor,
+			Reason:    entry.Reason,
+			Timestamp: entry.Timestamp,
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,322 @@
+package auction
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/database/lock"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+// AuctionCloseListener is notified with the auction_closed events of the auctions
+// closed by each sweep.
+type AuctionCloseListener interface {
+	AuctionsClosed(closed []events.AuctionClosedEvent)
+}
+
+// ExpiredAuctionsCloser activates the Scheduled auctions whose start time came and
+// closes every expired auction, each in one go, returning their ids. CloseAuctionNow
+// closes a single Active auction before its end time the same way, and RetryClose one
+// that failed to close. FindClosedAuctions reads the closed auctions back for their
+// events. AuctionRepository implements it against MongoDB.
+type ExpiredAuctionsCloser interface {
+	ActivateScheduledAuctions(ctx context.Context) ([]string, *internal_error.InternalError)
+	CloseExpiredAuctions(ctx context.Context) ([]string, *internal_error.InternalError)
+	CloseAuctionNow(ctx context.Context, id string) *internal_error.InternalError
+	FindCloseFailures(ctx context.Context) ([]auction_entity.CloseFailure, *internal_error.InternalError)
+	RetryClose(ctx context.Context, id string) *internal_error.InternalError
+	FindClosedAuctions(
+		ctx context.Context, auctionIds []string) ([]auction_entity.ClosedAuction, *internal_error.InternalError)
+}
+
+// Closer is the background worker closing expired auctions, either the sweeping
+// AuctionCloser or the ChangeStreamCloser, possibly run by a LeaderCloser.
+// CloseAuctionNow closes an auction on demand and RetryClose one that failed to close,
+// both notifying the listeners like the automatic closes do. NotifyClosed notifies them
+// of auctions closed elsewhere, as those bought at their buy-now price.
+type Closer interface {
+	AddListener(listener AuctionCloseListener)
+	CloseAuctionNow(ctx context.Context, id string) *internal_error.InternalError
+	NotifyClosed(ctx context.Context, auctionIds []string)
+	FindCloseFailures(ctx context.Context) ([]auction_entity.CloseFailure, *internal_error.InternalError)
+	RetryClose(ctx context.Context, id string) *internal_error.InternalError
+	Start(ctx context.Context)
+	Stop()
+	Shutdown(ctx context.Context) error
+	LastRun() time.Time
+	Interval() time.Duration
+}
+
+// CloserConfig selects the closer NewCloser returns and how often it runs.
+type CloserConfig struct {
+	// Mode is one of the AUCTION_CLOSE_MODE values, CloseModeSweep when empty
+	Mode string
+
+	// Interval is the time between two sweeps, or the longest time between two checks
+	// of the ChangeStreamCloser. Zero means 5 seconds.
+	Interval time.Duration
+
+	// LeaseTTL is how long the closer lease lasts once taken or renewed, see
+	// LeaderCloser. Zero runs the closer on every instance.
+	LeaseTTL time.Duration
+}
+
+// NewCloser returns the closer selected by config.Mode: the ChangeStreamCloser for
+// CloseModeChangeStream and the AuctionCloser otherwise. When the deployment doesn't
+// support change streams, as a standalone server, it falls back to the AuctionCloser.
+// With a config.LeaseTTL the closer is wrapped in a LeaderCloser, so only one instance
+// runs it at a time.
+func NewCloser(
+	ctx context.Context, auctionRepository *AuctionRepository, clk clock.Clock, config CloserConfig) Closer {
+	var closer Closer = NewAuctionCloser(auctionRepository, clk, config.Interval, auctionRepository.operationTimeout)
+	if config.Mode == CloseModeChangeStream {
+		if auctionRepository.SupportsChangeStreams(ctx) {
+			closer = NewChangeStreamCloser(auctionRepository, clk, config.Interval)
+		} else {
+			logger.Warn("Change streams are not supported by the database, closing auctions by sweeping instead")
+		}
+	}
+
+	if config.LeaseTTL <= 0 {
+		return closer
+	}
+
+	leases := lock.NewLeaseRepository(auctionRepository.Collection.Database(), clk, auctionRepository.operationTimeout)
+	leases.EnsureIndexes(ctx)
+
+	return NewLeaderCloser(closer, leases, clk, config.LeaseTTL)
+}
+
+// AuctionCloser is the single background worker responsible for closing expired
+// auctions. It replaces the goroutine-per-auction approach: every tick it closes all
+// Active auctions whose end_time has passed with one UpdateMany. The same sweep first
+// activates the Scheduled auctions whose start_time has passed.
+type AuctionCloser struct {
+	auctionRepository ExpiredAuctionsCloser
+	clock             clock.Clock
+	interval          time.Duration
+	operationTimeout  time.Duration
+	listeners         []AuctionCloseListener
+
+	lastRunMutex sync.RWMutex
+	lastRun      time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAuctionCloser sweeps every interval, 5 seconds when it is zero, bounding each sweep
+// by operationTimeout like a repository operation.
+func NewAuctionCloser(
+	auctionRepository ExpiredAuctionsCloser, clk clock.Clock, interval, operationTimeout time.Duration) *AuctionCloser {
+	return &AuctionCloser{
+		auctionRepository: auctionRepository,
+		clock:             clk,
+		interval:          closeIntervalOrDefault(interval),
+		operationTimeout:  operationTimeout,
+	}
+}
+
+// AddListener registers a listener for closed auctions. It must be called before Start.
+func (ac *AuctionCloser) AddListener(listener AuctionCloseListener) {
+	ac.listeners = append(ac.listeners, listener)
+}
+
+// Start runs a first sweep right away, which also takes care of auctions that expired
+// while the service was down, and then keeps sweeping on every interval until ctx is
+// cancelled or Stop is called.
+func (ac *AuctionCloser) Start(ctx context.Context) {
+	ctx, ac.cancel = context.WithCancel(ctx)
+	ac.done = make(chan struct{})
+
+	go func() {
+		defer close(ac.done)
+
+		timer := ac.clock.NewTimer(ac.interval)
+		defer timer.Stop()
+
+		// Sweeps get their own context so stopping the worker never aborts a close
+		// update halfway through
+		ac.sweep(context.Background())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.Chan():
+				ac.sweep(context.Background())
+				timer.Reset(ac.interval)
+			}
+		}
+	}()
+}
+
+// Stop cancels the worker and waits for the sweep in progress, if any, to finish.
+func (ac *AuctionCloser) Stop() {
+	if ac.cancel == nil {
+		return
+	}
+
+	ac.cancel()
+	<-ac.done
+}
+
+// Shutdown stops the worker, waits for the sweep in progress and then runs a last sweep,
+// so auctions that expired while the process was going down are persisted as closed
+// before the database connection is released. It returns ctx.Err() if ctx is done first.
+func (ac *AuctionCloser) Shutdown(ctx context.Context) error {
+	if ac.cancel != nil {
+		ac.cancel()
+
+		select {
+		case <-ac.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ac.sweep(ctx)
+	return ctx.Err()
+}
+
+func (ac *AuctionCloser) sweep(ctx context.Context) {
+	// Each sweep is a trace of its own, linked by the repository to the requests that
+	// created the auctions it closes
+	ctx, span := tracing.Start(ctx, "AuctionCloser.sweep")
+	defer span.End()
+
+	sweepCtx, cancel := dbtimeout.WithTimeout(
+		auction_entity.WithAuditActor(ctx, auction_entity.ActorSweeper), ac.operationTimeout)
+	defer cancel()
+
+	// A failed activation is retried by the next sweep and doesn't hold up the closes
+	if activatedIds, err := ac.auctionRepository.ActivateScheduledAuctions(sweepCtx); err == nil && len(activatedIds) > 0 {
+		logger.Info("Scheduled auctions started",
+			zap.Int("count", len(activatedIds)), zap.Strings("auction_ids", activatedIds))
+	}
+
+	closedIds, err := ac.auctionRepository.CloseExpiredAuctions(sweepCtx)
+	if err != nil {
+		return
+	}
+
+	ac.lastRunMutex.Lock()
+	ac.lastRun = ac.clock.Now()
+	ac.lastRunMutex.Unlock()
+
+	if len(closedIds) == 0 {
+		return
+	}
+
+	logger.Info("Expired auctions auto-closed",
+		zap.Int("count", len(closedIds)), zap.Strings("auction_ids", closedIds))
+
+	notifyClosed(ctx, ac.auctionRepository, ac.listeners, closedIds)
+}
+
+// CloseAuctionNow closes the Active auction id before its end time. The next sweeps skip
+// it, as it is no longer Active.
+func (ac *AuctionCloser) CloseAuctionNow(ctx context.Context, id string) *internal_error.InternalError {
+	if err := ac.auctionRepository.CloseAuctionNow(ctx, id); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Auction closed manually", zap.String("auction_id", id))
+	notifyClosed(ctx, ac.auctionRepository, ac.listeners, []string{id})
+
+	return nil
+}
+
+// NotifyClosed hands the listeners the events of auctions closed outside the closer,
+// as the ones bought at their buy-now price.
+func (ac *AuctionCloser) NotifyClosed(ctx context.Context, auctionIds []string) {
+	notifyClosed(ctx, ac.auctionRepository, ac.listeners, auctionIds)
+}
+
+// FindCloseFailures lists the auctions that failed to close, see
+// AuctionRepository.FindCloseFailures.
+func (ac *AuctionCloser) FindCloseFailures(
+	ctx context.Context) ([]auction_entity.CloseFailure, *internal_error.InternalError) {
+	return ac.auctionRepository.FindCloseFailures(ctx)
+}
+
+// RetryClose closes an auction that failed to close without waiting for the sweep to
+// retry it.
+func (ac *AuctionCloser) RetryClose(ctx context.Context, id string) *internal_error.InternalError {
+	if err := ac.auctionRepository.RetryClose(ctx, id); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Auction closed on retry", zap.String("auction_id", id))
+	notifyClosed(ctx, ac.auctionRepository, ac.listeners, []string{id})
+
+	return nil
+}
+
+// LastRun returns when the last successful sweep finished, or the zero time if none did yet.
+func (ac *AuctionCloser) LastRun() time.Time {
+	ac.lastRunMutex.RLock()
+	defer ac.lastRunMutex.RUnlock()
+
+	return ac.lastRun
+}
+
+// Interval returns the time between two sweeps.
+func (ac *AuctionCloser) Interval() time.Duration {
+	return ac.interval
+}
+
+// Values of AUCTION_CLOSE_MODE.
+const (
+	CloseModeSweep        = "sweep"
+	CloseModeChangeStream = "changestream"
+)
+
+func closeIntervalOrDefault(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 5 * time.Second
+	}
+
+	return interval
+}
+
+// closedAuctionsFinder reads back the auctions just closed, see
+// AuctionRepository.FindClosedAuctions.
+type closedAuctionsFinder interface {
+	FindClosedAuctions(
+		ctx context.Context, auctionIds []string) ([]auction_entity.ClosedAuction, *internal_error.InternalError)
+}
+
+// notifyClosed hands the listeners the auction_closed events of the auctions just
+// closed. They are read back after the close wrote the winner snapshots, so every
+// listener gets the final outcome. Should the read fail, the events carry only the
+// auction ids, so the listeners still learn about the closes.
+func notifyClosed(
+	ctx context.Context, finder closedAuctionsFinder, listeners []AuctionCloseListener, closedIds []string) {
+	if len(closedIds) == 0 || len(listeners) == 0 {
+		return
+	}
+
+	closedEvents := make([]events.AuctionClosedEvent, 0, len(closedIds))
+	if closedAuctions, err := finder.FindClosedAuctions(ctx, closedIds); err == nil {
+		for _, closed := range closedAuctions {
+			closedEvents = append(closedEvents, events.NewAuctionClosedEvent(closed))
+		}
+	} else {
+		for _, auctionId := range closedIds {
+			closedEvents = append(closedEvents, events.AuctionClosedEvent{AuctionId: auctionId})
+		}
+	}
+
+	for _, listener := range listeners {
+		listener.AuctionsClosed(closedEvents)
+	}
+}
@@ -0,0 +1,144 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetFacets counts the auctions matching auctionFilter by category and by condition
+// with a single $facet aggregation. As the counts are asked for on every listing, each
+// result is kept for FacetsCacheTTL, so they may lag behind the auctions by that long.
+func (ar *AuctionRepository) GetFacets(
+	ctx context.Context,
+	auctionFilter auction_entity.AuctionFilter) (*auction_entity.AuctionFacets, *internal_error.InternalError) {
+	key := facetsCacheKey(ctx, auctionFilter)
+	if facets, ok := ar.facetsCache.get(ar.Clock.Now(), key); ok {
+		return facets, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "AuctionRepository.GetFacets")
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	countBy := func(field string) bson.A {
+		return bson.A{
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: auctionFilterQuery(ctx, auctionFilter)}},
+		{{Key: "$facet", Value: bson.M{
+			"categories": countBy("category"),
+			"conditions": countBy("condition"),
+		}}},
+	}
+
+	cursor, err := ar.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate auction facets", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate auction facets").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	// $facet always answers a single document
+	var results []struct {
+		Categories []struct {
+			Category string `bson:"_id"`
+			Count    int64  `bson:"count"`
+		} `bson:"categories"`
+		Conditions []struct {
+			Condition auction_entity.ProductCondition `bson:"_id"`
+			Count     int64                           `bson:"count"`
+		} `bson:"conditions"`
+	}
+	if err := cursor.All(ctx, &results); err != nil || len(results) != 1 {
+		if err == nil {
+			err = fmt.Errorf("expected one $facet document, got %d", len(results))
+		}
+		logger.ErrorContext(ctx, "Error trying to aggregate auction facets", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate auction facets").Wrap(err)
+	}
+
+	facets := &auction_entity.AuctionFacets{
+		Categories: make([]auction_entity.CategoryCount, 0, len(results[0].Categories)),
+		Conditions: make([]auction_entity.ConditionCount, 0, len(results[0].Conditions)),
+	}
+	for _, category := range results[0].Categories {
+		facets.Categories = append(facets.Categories,
+			auction_entity.CategoryCount{Category: category.Category, Count: category.Count})
+	}
+	for _, condition := range results[0].Conditions {
+		facets.Conditions = append(facets.Conditions,
+			auction_entity.ConditionCount{Condition: condition.Condition, Count: condition.Count})
+	}
+
+	ar.facetsCache.set(ar.Clock.Now(), ar.FacetsCacheTTL, key, facets)
+	return facets, nil
+}
+
+// facetsCacheKey tells apart the filters GetFacets counts for, including whether ctx
+// lets deleted auctions in. The order of the filter doesn't change the counts.
+func facetsCacheKey(ctx context.Context, auctionFilter auction_entity.AuctionFilter) string {
+	return fmt.Sprintf("%s|%v|%s|%s|%d|%d|%t",
+		auctionFilter.SellerId, auctionFilter.Statuses, auctionFilter.Category, auctionFilter.ProductName,
+		auctionFilter.CreatedAfter.Unix(), auctionFilter.CreatedBefore.Unix(), auction_entity.IncludesDeleted(ctx))
+}
+
+type facetsCacheEntry struct {
+	facets    *auction_entity.AuctionFacets
+	expiresAt time.Time
+}
+
+// facetsCache keeps each facets result until it expires. Expired entries are dropped
+// whenever a new one is stored, so it only grows with the distinct filters asked for
+// within the ttl. Its zero value is ready to use.
+type facetsCache struct {
+	mu      sync.Mutex
+	entries map[string]facetsCacheEntry
+}
+
+func (fc *facetsCache) get(now time.Time, key string) (*auction_entity.AuctionFacets, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	entry, ok := fc.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.facets, true
+}
+
+func (fc *facetsCache) set(now time.Time, ttl time.Duration, key string, facets *auction_entity.AuctionFacets) {
+	if ttl <= 0 {
+		return
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.entries == nil {
+		fc.entries = make(map[string]facetsCacheEntry)
+	}
+	for existingKey, entry := range fc.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(fc.entries, existingKey)
+		}
+	}
+
+	fc.entries[key] = facetsCacheEntry{facets: facets, expiresAt: now.Add(ttl)}
+}
@@ -0,0 +1,80 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ReplaceAuctionImages overwrites the images of the auction with a single update guarded
+// by its status, end time and version, so an auction closing or changed by another
+// request in the meantime is left untouched.
+func (ar *AuctionRepository) ReplaceAuctionImages(
+	ctx context.Context,
+	id string,
+	version int64,
+	images []auction_entity.AuctionImage) (*auction_entity.Auction, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.ReplaceAuctionImages", attribute.String("auction_id", id))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":        id,
+		"status":     auction_entity.Active,
+		"end_time":   bson.M{"$gt": ar.Clock.Now()},
+		"deleted_at": nil,
+		"version":    versionFilter(version),
+	}
+	update := bson.M{"$set": bson.M{"images": toAuctionImagesMongo(images)}, "$inc": versionIncrement}
+
+	var updated AuctionEntityMongo
+	err := ar.Collection.FindOneAndUpdate(
+		ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.ErrorContext(ctx, "Error trying to update auction images", err, zap.String("auction_id", id))
+		return nil, internal_error.NewInternalServerError("Error trying to update auction images").Wrap(err)
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, err := ar.Collection.CountDocuments(ctx, bson.M{"_id": id, "deleted_at": nil})
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to find auction by id", err, zap.String("auction_id", id))
+			return nil, internal_error.NewInternalServerError("Error trying to update auction images").Wrap(err)
+		}
+
+		if count == 0 {
+			return nil, internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+		}
+
+		// Still active without the version filter, so it failed on the version
+		delete(filter, "version")
+		count, err = ar.Collection.CountDocuments(ctx, filter)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to find auction by id", err, zap.String("auction_id", id))
+			return nil, internal_error.NewInternalServerError("Error trying to update auction images").Wrap(err)
+		}
+
+		if count > 0 {
+			return nil, internal_error.ErrAuctionModified
+		}
+
+		return nil, internal_error.ErrAuctionNotActive
+	}
+
+	auctionEntity := toAuctionEntity(updated)
+	return &auctionEntity, nil
+}
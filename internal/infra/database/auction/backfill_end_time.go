@@ -0,0 +1,121 @@
+package auction
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// endTimeBackfillBatchSize is how many auctions BackfillEndTimes updates at once.
+const endTimeBackfillBatchSize = 500
+
+// withoutEndTime matches the Active auctions created before end_time existed. Matching
+// null also matches the documents lacking the field.
+func withoutEndTime() bson.M {
+	return bson.M{"status": auction_entity.Active, "end_time": bson.M{"$in": bson.A{nil, 0}}}
+}
+
+// BackfillEndTimes is a one-time migration for the Active auctions created before
+// end_time existed: each gets the end time it would have had, its start plus duration,
+// defaultDuration of the repository when duration is zero. Auctions whose end time is
+// already past are closed right away, like the closer would.
+//
+// Auctions are updated in batches, each only if it still lacks an end_time and is still
+// Active, so it is safe to run with the service taking traffic and to run again. It
+// returns how many auctions got an end time.
+func (ar *AuctionRepository) BackfillEndTimes(
+	ctx context.Context, duration time.Duration) (int64, *internal_error.InternalError) {
+	if duration <= 0 {
+		duration = ar.defaultDuration
+	}
+
+	var backfilled, closed int64
+	for {
+		updated, expiredIds, found, err := ar.backfillEndTimeBatch(ctx, duration)
+		if err != nil {
+			return backfilled, err
+		}
+		backfilled += updated
+
+		if len(expiredIds) > 0 {
+			closedIds, err := ar.CloseAuctionsById(ctx, expiredIds)
+			if err != nil {
+				return backfilled, err
+			}
+			closed += int64(len(closedIds))
+		}
+
+		if found > 0 {
+			logger.InfoContext(ctx, "Backfilled auction end times",
+				zap.Int64("backfilled", backfilled), zap.Int64("closed", closed))
+		}
+
+		// Updated auctions no longer match, so the next batch starts with the rest
+		if found < endTimeBackfillBatchSize {
+			return backfilled, nil
+		}
+	}
+}
+
+// backfillEndTimeBatch sets the end time of the next batch of auctions without one. It
+// returns how many it updated, the ids of those already expired and how many it found.
+func (ar *AuctionRepository) backfillEndTimeBatch(
+	ctx context.Context, duration time.Duration) (int64, []string, int, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "timestamp": 1, "start_time": 1}).
+		SetSort(bson.M{"_id": 1}).
+		SetLimit(endTimeBackfillBatchSize)
+
+	var auctions []AuctionEntityMongo
+	cursor, err := ar.Collection.Find(ctx, withoutEndTime(), opts)
+	if err == nil {
+		err = cursor.All(ctx, &auctions)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auctions without end time", err)
+		return 0, nil, 0, internal_error.NewInternalServerError("Error trying to backfill auction end times").Wrap(err)
+	}
+
+	if len(auctions) == 0 {
+		return 0, nil, 0, nil
+	}
+
+	now := ar.Clock.Now()
+	models := make([]mongo.WriteModel, 0, len(auctions))
+	var expiredIds []string
+	for _, auction := range auctions {
+		// Auctions created before scheduling existed started at their timestamp
+		endTime := dbtime.From(auction.startTime().Add(duration))
+
+		filter := withoutEndTime()
+		filter["_id"] = auction.Id
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{"$set": bson.M{"end_time": endTime}, "$inc": revisionIncrement}))
+
+		if !endTime.UTC().After(now) {
+			expiredIds = append(expiredIds, auction.Id)
+		}
+	}
+
+	result, err := ar.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to backfill auction end times", err)
+		return 0, nil, 0, internal_error.NewInternalServerError("Error trying to backfill auction end times").Wrap(err)
+	}
+
+	return result.ModifiedCount, expiredIds, len(auctions), nil
+}
@@ -0,0 +1,33 @@
+package auction
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// BackfillTimestamps converts the times of the auctions stored in Unix seconds, before
+// they had milliseconds, into dates: timestamp, winning_bid_timestamp, start_time,
+// end_time and last_bid_at. It has to run before the auctions are filtered by any of
+// them, which only matches dates, e.g. by the closer looking for the expired ones. It
+// returns how many values it converted and is safe to run again, also after it was cut
+// short.
+func (ar *AuctionRepository) BackfillTimestamps(ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	var backfilled int64
+	for _, field := range []string{"timestamp", "winning_bid_timestamp", "start_time", "end_time", "last_bid_at"} {
+		updated, err := dbtime.BackfillDates(ctx, ar.Collection, field)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to backfill auction timestamps", err)
+			return backfilled, internal_error.NewInternalServerError("Error trying to backfill auction timestamps").Wrap(err)
+		}
+		backfilled += updated
+	}
+
+	return backfilled, nil
+}
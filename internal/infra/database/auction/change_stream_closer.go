@@ -0,0 +1,600 @@
+package auction
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	// closeWatcherCollection keeps the resume token of the ChangeStreamCloser, in the
+	// document with id closeWatcherId
+	closeWatcherCollection = "auction_close_watcher"
+	closeWatcherId         = "auctions"
+
+	// changeStreamHistoryLost is the server error returned when resuming from a token
+	// that fell out of the oplog
+	changeStreamHistoryLost = 286
+)
+
+// ChangeStreamCloser closes auctions right at their end_time without every instance
+// sweeping the collection. It watches the auctions change stream for created auctions
+// and changes to end_time or status, keeps the end times of the Active ones in a
+// min-heap and closes each auction once its end time is reached. Scheduled auctions are
+// kept in the heap by their start_time and activated once it is reached.
+//
+// The resume token of the last event handled is persisted, so after a restart the
+// stream picks up where it stopped. The heap itself is rebuilt from the Active auctions
+// every time the stream is opened. Closes go through the same filtered update as the
+// sweep, so an auction closed twice, by another instance or a sweep, is left untouched
+// the second time.
+type ChangeStreamCloser struct {
+	auctionRepository *AuctionRepository
+	tokens            *mongo.Collection
+	clock             clock.Clock
+	interval          time.Duration
+	listeners         []AuctionCloseListener
+
+	lastRunMutex sync.RWMutex
+	lastRun      time.Time
+
+	// lastRetry is when retryFailedCloses last looked for due retries, only ever read
+	// and written by the watcher
+	lastRetry time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewChangeStreamCloser checks for due auctions at least every interval, 5 seconds when
+// it is zero.
+func NewChangeStreamCloser(
+	auctionRepository *AuctionRepository, clk clock.Clock, interval time.Duration) *ChangeStreamCloser {
+	return &ChangeStreamCloser{
+		auctionRepository: auctionRepository,
+		tokens:            auctionRepository.Collection.Database().Collection(closeWatcherCollection),
+		clock:             clk,
+		interval:          closeIntervalOrDefault(interval),
+	}
+}
+
+// SupportsChangeStreams reports whether the auctions collection can be watched. Change
+// streams need a replica set or a sharded cluster, so it is false on a standalone server.
+func (ar *AuctionRepository) SupportsChangeStreams(ctx context.Context) bool {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	stream, err := ar.Collection.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to open the auctions change stream", err)
+		return false
+	}
+	stream.Close(ctx)
+
+	return true
+}
+
+// AddListener registers a listener for closed auctions. It must be called before Start.
+func (cc *ChangeStreamCloser) AddListener(listener AuctionCloseListener) {
+	cc.listeners = append(cc.listeners, listener)
+}
+
+// Start watches the change stream in the background until ctx is cancelled or Stop is
+// called. When the stream fails it is opened again after the close interval.
+func (cc *ChangeStreamCloser) Start(ctx context.Context) {
+	ctx, cc.cancel = context.WithCancel(ctx)
+	cc.done = make(chan struct{})
+
+	go func() {
+		defer close(cc.done)
+
+		for {
+			err := cc.watch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("Auction change stream stopped, reopening it", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-cc.clock.After(cc.interval):
+			}
+		}
+	}()
+}
+
+// Stop cancels the watcher and waits for it to return.
+func (cc *ChangeStreamCloser) Stop() {
+	if cc.cancel == nil {
+		return
+	}
+
+	cc.cancel()
+	<-cc.done
+}
+
+// Shutdown stops the watcher and then closes whatever expired in the meantime with a
+// last sweep, like AuctionCloser.Shutdown. It returns ctx.Err() if ctx is done first.
+func (cc *ChangeStreamCloser) Shutdown(ctx context.Context) error {
+	if cc.cancel != nil {
+		cc.cancel()
+
+		select {
+		case <-cc.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	closedIds, err := cc.auctionRepository.CloseExpiredAuctions(
+		auction_entity.WithAuditActor(ctx, auction_entity.ActorSweeper))
+	if err == nil {
+		cc.notify(ctx, closedIds)
+	}
+
+	return ctx.Err()
+}
+
+// LastRun returns when the watcher last checked for due auctions successfully, which
+// it does at least every Interval.
+func (cc *ChangeStreamCloser) LastRun() time.Time {
+	cc.lastRunMutex.RLock()
+	defer cc.lastRunMutex.RUnlock()
+
+	return cc.lastRun
+}
+
+// Interval returns the longest time between two checks for due auctions.
+func (cc *ChangeStreamCloser) Interval() time.Duration {
+	return cc.interval
+}
+
+// auctionChangeEvent is the part of a change stream event the closer reads.
+// FullDocument is nil for deletes and for auctions deleted before the update was looked up.
+type auctionChangeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		Id string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument *AuctionEntityMongo `bson:"fullDocument"`
+}
+
+// watch runs one change stream until it fails or ctx is done.
+func (cc *ChangeStreamCloser) watch(ctx context.Context) error {
+	stream, err := cc.openStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Events are read in their own goroutine, which hands over the resume token along
+	// with each event since the stream isn't safe for concurrent use
+	type streamEvent struct {
+		auctionChangeEvent
+		resumeToken bson.Raw
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan streamEvent)
+	streamErr := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for stream.Next(ctx) {
+			event := streamEvent{resumeToken: stream.ResumeToken()}
+			if err := stream.Decode(&event.auctionChangeEvent); err != nil {
+				streamErr <- err
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		streamErr <- stream.Err()
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+		stream.Close(context.Background())
+	}()
+
+	// Seeded once the stream is open, so an auction created in between is seen by
+	// either the query or the stream
+	deadlines, internalErr := cc.findActiveDeadlines(ctx)
+	if internalErr != nil {
+		return internalErr
+	}
+
+	timer := cc.clock.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-streamErr:
+			if err == nil {
+				err = errors.New("change stream closed")
+			}
+			return err
+		case event := <-events:
+			deadlines.apply(event.auctionChangeEvent)
+			cc.saveResumeToken(ctx, event.resumeToken)
+		case <-timer.Chan():
+			cc.closeDue(deadlines)
+		}
+
+		timer.Stop()
+		timer.Reset(cc.nextCheck(deadlines))
+	}
+}
+
+// openStream watches the auctions collection from the persisted resume token, or from
+// now on if there is none or it is too old to resume from.
+func (cc *ChangeStreamCloser) openStream(ctx context.Context) (*mongo.ChangeStream, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"$or": bson.A{
+		bson.M{"operationType": bson.M{"$in": bson.A{"insert", "replace", "delete"}}},
+		bson.M{"operationType": "update", "$or": bson.A{
+			bson.M{"updateDescription.updatedFields.end_time": bson.M{"$exists": true}},
+			bson.M{"updateDescription.updatedFields.status": bson.M{"$exists": true}},
+		}},
+	}}}}}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	if token := cc.loadResumeToken(ctx); token != nil {
+		stream, err := cc.auctionRepository.Collection.Watch(ctx, pipeline, opts.SetResumeAfter(token))
+		var serverErr mongo.ServerError
+		if err == nil || !errors.As(err, &serverErr) || !serverErr.HasErrorCode(changeStreamHistoryLost) {
+			return stream, err
+		}
+
+		// Nothing is lost by starting over: the heap is seeded from the Active auctions
+		logger.Info("Auction change stream resume token expired, watching from now on")
+		opts.SetResumeAfter(nil)
+	}
+
+	return cc.auctionRepository.Collection.Watch(ctx, pipeline, opts)
+}
+
+func (cc *ChangeStreamCloser) loadResumeToken(ctx context.Context) bson.Raw {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cc.auctionRepository.operationTimeout)
+	defer cancel()
+
+	var stored struct {
+		Token bson.Raw `bson:"token"`
+	}
+	err := cc.tokens.FindOne(ctx, bson.M{"_id": closeWatcherId}).Decode(&stored)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			logger.Error("Error trying to load the auction change stream resume token", err)
+		}
+		return nil
+	}
+
+	return stored.Token
+}
+
+// saveResumeToken persists the token of the last event handled. A failed save is only
+// logged: the stream would resume from an earlier event, handled twice harmlessly.
+func (cc *ChangeStreamCloser) saveResumeToken(ctx context.Context, token bson.Raw) {
+	if token == nil {
+		return
+	}
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cc.auctionRepository.operationTimeout)
+	defer cancel()
+
+	_, err := cc.tokens.UpdateOne(ctx,
+		bson.M{"_id": closeWatcherId},
+		bson.M{"$set": bson.M{"token": token, "updated_at": cc.clock.Now()}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		logger.Error("Error trying to save the auction change stream resume token", err)
+	}
+}
+
+func (cc *ChangeStreamCloser) findActiveDeadlines(ctx context.Context) (*auctionDeadlines, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cc.auctionRepository.operationTimeout)
+	defer cancel()
+
+	cursor, err := cc.auctionRepository.Collection.Find(ctx,
+		bson.M{"status": bson.M{"$in": bson.A{auction_entity.Active, auction_entity.Scheduled}}},
+		options.Find().SetProjection(bson.M{"_id": 1, "status": 1, "timestamp": 1, "start_time": 1, "end_time": 1}))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find active auctions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find active auctions").Wrap(err)
+	}
+
+	var activeAuctions []AuctionEntityMongo
+	if err := cursor.All(ctx, &activeAuctions); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode active auctions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find active auctions").Wrap(err)
+	}
+
+	deadlines := newAuctionDeadlines()
+	for _, activeAuction := range activeAuctions {
+		if deadline, ok := watchedDeadline(activeAuction); ok {
+			deadlines.set(activeAuction.Id, deadline)
+		}
+	}
+
+	return deadlines, nil
+}
+
+// closeDue activates and closes the auctions whose deadline was reached: the Scheduled
+// ones become Active, to be put back with their end time by the change event that
+// follows, and the Active ones are closed. When either fails they are put back, to be
+// retried on the next check; an auction whose close failed even after retrying is
+// dropped instead, and closed again by retryFailedCloses once its retry is due.
+func (cc *ChangeStreamCloser) closeDue(deadlines *auctionDeadlines) {
+	cc.retryFailedCloses()
+
+	due := deadlines.popDue(cc.clock.Now().UnixMilli())
+	if len(due) > 0 {
+		// Each close is a trace of its own, like the sweeps
+		ctx, span := tracing.Start(
+			auction_entity.WithAuditActor(context.Background(), auction_entity.ActorSweeper), "ChangeStreamCloser.closeDue")
+		defer span.End()
+
+		ctx, cancel := dbtimeout.WithTimeout(ctx, cc.auctionRepository.operationTimeout)
+		defer cancel()
+
+		activatedIds, err := cc.auctionRepository.ActivateAuctionsById(ctx, auctionIds(due))
+		if err != nil {
+			for _, deadline := range due {
+				deadlines.set(deadline.auctionId, deadline.endTime)
+			}
+			return
+		}
+		if len(activatedIds) > 0 {
+			logger.Info("Scheduled auctions started",
+				zap.Int("count", len(activatedIds)), zap.Strings("auction_ids", activatedIds))
+		}
+
+		closedIds, err := cc.auctionRepository.CloseAuctionsById(ctx, auctionIds(due))
+		if err != nil {
+			for _, deadline := range due {
+				deadlines.set(deadline.auctionId, deadline.endTime)
+			}
+			return
+		}
+
+		if len(closedIds) > 0 {
+			logger.Info("Expired auctions auto-closed",
+				zap.Int("count", len(closedIds)), zap.Strings("auction_ids", closedIds))
+			cc.notify(ctx, closedIds)
+		}
+	}
+
+	cc.lastRunMutex.Lock()
+	cc.lastRun = cc.clock.Now()
+	cc.lastRunMutex.Unlock()
+}
+
+// retryFailedCloses closes the auctions that failed to close once their retry is due.
+// They left the heap when their close failed, so nothing else would close them. It
+// looks for them at most once per interval, however often the deadlines come.
+func (cc *ChangeStreamCloser) retryFailedCloses() {
+	now := cc.clock.Now()
+	if now.Sub(cc.lastRetry) < cc.interval {
+		return
+	}
+	cc.lastRetry = now
+
+	ctx, cancel := dbtimeout.WithTimeout(
+		auction_entity.WithAuditActor(context.Background(), auction_entity.ActorSweeper),
+		cc.auctionRepository.operationTimeout)
+	defer cancel()
+
+	closedIds, err := cc.auctionRepository.RetryDueCloseFailures(ctx)
+	if err != nil || len(closedIds) == 0 {
+		return
+	}
+
+	logger.Info("Auctions closed on retry", zap.Int("count", len(closedIds)), zap.Strings("auction_ids", closedIds))
+	cc.notify(ctx, closedIds)
+}
+
+// FindCloseFailures lists the auctions that failed to close, see
+// AuctionRepository.FindCloseFailures.
+func (cc *ChangeStreamCloser) FindCloseFailures(
+	ctx context.Context) ([]auction_entity.CloseFailure, *internal_error.InternalError) {
+	return cc.auctionRepository.FindCloseFailures(ctx)
+}
+
+// RetryClose closes an auction that failed to close without waiting for its retry.
+func (cc *ChangeStreamCloser) RetryClose(ctx context.Context, id string) *internal_error.InternalError {
+	if err := cc.auctionRepository.RetryClose(ctx, id); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Auction closed on retry", zap.String("auction_id", id))
+	cc.notify(ctx, []string{id})
+
+	return nil
+}
+
+// CloseAuctionNow closes the Active auction id before its end time. Its deadline is
+// dropped by the change events of the close, and CloseAuctionsById skips it anyway should
+// the deadline come first.
+func (cc *ChangeStreamCloser) CloseAuctionNow(ctx context.Context, id string) *internal_error.InternalError {
+	if err := cc.auctionRepository.CloseAuctionNow(ctx, id); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Auction closed manually", zap.String("auction_id", id))
+	cc.notify(ctx, []string{id})
+
+	return nil
+}
+
+// NotifyClosed notifies the listeners of auctions the closer didn't close, see
+// AuctionCloser.NotifyClosed.
+func (cc *ChangeStreamCloser) NotifyClosed(ctx context.Context, auctionIds []string) {
+	cc.notify(ctx, auctionIds)
+}
+
+func (cc *ChangeStreamCloser) notify(ctx context.Context, closedIds []string) {
+	notifyClosed(ctx, cc.auctionRepository, cc.listeners, closedIds)
+}
+
+// nextCheck is the time until the next end time, never longer than the interval.
+func (cc *ChangeStreamCloser) nextCheck(deadlines *auctionDeadlines) time.Duration {
+	endTime, ok := deadlines.next()
+	if !ok {
+		return cc.interval
+	}
+
+	wait := time.UnixMilli(endTime).Sub(cc.clock.Now())
+	if wait < 0 {
+		return 0
+	}
+	if wait > cc.interval {
+		return cc.interval
+	}
+
+	return wait
+}
+
+// auctionDeadline is the deadline of an auction in Unix milliseconds, the precision
+// end_time is stored with.
+type auctionDeadline struct {
+	auctionId string
+	endTime   int64
+}
+
+func auctionIds(deadlines []auctionDeadline) []string {
+	ids := make([]string, len(deadlines))
+	for i, deadline := range deadlines {
+		ids[i] = deadline.auctionId
+	}
+
+	return ids
+}
+
+// auctionDeadlines is a min-heap of the end times of the watched auctions. Changing or
+// removing an end time leaves the old heap entry behind; entries that don't match
+// endTimes anymore are dropped when they reach the top.
+type auctionDeadlines struct {
+	heap     deadlineHeap
+	endTimes map[string]int64
+}
+
+func newAuctionDeadlines() *auctionDeadlines {
+	return &auctionDeadlines{endTimes: make(map[string]int64)}
+}
+
+// apply updates the end times with a change stream event.
+func (d *auctionDeadlines) apply(event auctionChangeEvent) {
+	if event.FullDocument == nil {
+		d.remove(event.DocumentKey.Id)
+		return
+	}
+
+	deadline, ok := watchedDeadline(*event.FullDocument)
+	if !ok {
+		d.remove(event.DocumentKey.Id)
+		return
+	}
+
+	d.set(event.FullDocument.Id, deadline)
+}
+
+// watchedDeadline is when the closer has to act on the auction: the start time of a
+// Scheduled auction and the end time of an Active one. Other auctions aren't watched.
+func watchedDeadline(auctionEntityMongo AuctionEntityMongo) (int64, bool) {
+	switch auctionEntityMongo.Status {
+	case auction_entity.Active:
+		return auctionEntityMongo.EndTime.UTC().UnixMilli(), true
+	case auction_entity.Scheduled:
+		return auctionEntityMongo.startTime().UnixMilli(), true
+	default:
+		return 0, false
+	}
+}
+
+func (d *auctionDeadlines) set(auctionId string, endTime int64) {
+	if current, ok := d.endTimes[auctionId]; ok && current == endTime {
+		return
+	}
+
+	d.endTimes[auctionId] = endTime
+	heap.Push(&d.heap, auctionDeadline{auctionId: auctionId, endTime: endTime})
+}
+
+func (d *auctionDeadlines) remove(auctionId string) {
+	delete(d.endTimes, auctionId)
+}
+
+// next returns the earliest end time, if any auction is watched.
+func (d *auctionDeadlines) next() (int64, bool) {
+	d.dropStale()
+	if len(d.heap) == 0 {
+		return 0, false
+	}
+
+	return d.heap[0].endTime, true
+}
+
+// popDue removes and returns the auctions whose end time is at or before now.
+func (d *auctionDeadlines) popDue(now int64) []auctionDeadline {
+	var due []auctionDeadline
+	for {
+		d.dropStale()
+		if len(d.heap) == 0 || d.heap[0].endTime > now {
+			return due
+		}
+
+		deadline := heap.Pop(&d.heap).(auctionDeadline)
+		delete(d.endTimes, deadline.auctionId)
+		due = append(due, deadline)
+	}
+}
+
+func (d *auctionDeadlines) dropStale() {
+	for len(d.heap) > 0 {
+		top := d.heap[0]
+		if endTime, ok := d.endTimes[top.auctionId]; ok && endTime == top.endTime {
+			return
+		}
+		heap.Pop(&d.heap)
+	}
+}
+
+// deadlineHeap implements heap.Interface, earliest end time first.
+type deadlineHeap []auctionDeadline
+
+func (h deadlineHeap) Len() int           { return len(h) }
+func (h deadlineHeap) Less(i, j int) bool { return h[i].endTime < h[j].endTime }
+func (h deadlineHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *deadlineHeap) Push(x interface{}) {
+	*h = append(*h, x.(auctionDeadline))
+}
+
+func (h *deadlineHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,473 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/database/retry"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// bidsCollection is read to decide the outcome of the auctions being closed. It is the
+// collection the bid repository writes to.
+const bidsCollection = "bids"
+
+// BidAmountCentsExpr is the aggregation expression reading a bid amount in cents. Bids
+// stored before amounts moved to cents only have a float amount until
+// bid.BackfillAmountCents runs, converted on the fly, so the aggregations over bid
+// amounts go through it. Sorts use amount_cents directly, to be served by its index.
+var BidAmountCentsExpr = bson.M{"$ifNull": bson.A{
+	"$amount_cents",
+	bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$amount", 100}}, 0}}},
+}}
+
+// winningBidMongo is the winning bid of an auction as read by findWinningBids. It only
+// has the bid fields the winner snapshot keeps, along with the highest amount bid by
+// another bidder, which Vickrey auctions settle at.
+type winningBidMongo struct {
+	AuctionId string      `bson:"_id"`
+	BidId     string      `bson:"bid_id"`
+	UserId    string      `bson:"user_id"`
+	Amount    int64       `bson:"amount"`
+	Currency  string      `bson:"currency"`
+	Timestamp dbtime.Time `bson:"timestamp"`
+
+	RunnerUpAmount int64 `bson:"runner_up_amount"`
+}
+
+// CloseExpiredAuctions marks every Active auction whose end_time already passed as
+// Completed, along with its outcome and a snapshot of its winning bid, and returns the
+// ids of the auctions it closed. The auctions whose close failed recently are left for
+// their next retry, see recordCloseFailures.
+func (ar *AuctionRepository) CloseExpiredAuctions(ctx context.Context) ([]string, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.CloseExpiredAuctions")
+	defer span.End()
+
+	filter := bson.M{}
+	if err := ar.excludeBackingOff(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	return ar.closeExpired(ctx, filter)
+}
+
+// CloseAuctionsById closes the auctions among auctionIds that are still Active and whose
+// end_time already passed, like CloseExpiredAuctions, and returns the ids it closed.
+// Auctions extended past now in the meantime are left open, and so are those whose
+// close failed recently.
+func (ar *AuctionRepository) CloseAuctionsById(
+	ctx context.Context, auctionIds []string) ([]string, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.CloseAuctionsById",
+		attribute.Int("auction_count", len(auctionIds)))
+	defer span.End()
+
+	filter := bson.M{"_id": bson.M{"$in": auctionIds}}
+	if err := ar.excludeBackingOff(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	return ar.closeExpired(ctx, filter)
+}
+
+// CloseAuctionNow ends the Active auction id right away. Its end_time is first moved to
+// now, which stops it from taking bids, and it is then closed by the same routine as the
+// expired auctions. Should that fail, the auction is already expired and the closer
+// picks it up on its next run. It returns a not found error for an unknown auction and a
+// conflict error for one that is not Active.
+func (ar *AuctionRepository) CloseAuctionNow(ctx context.Context, id string) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.CloseAuctionNow", attribute.String("auction_id", id))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": auction_entity.Active, "deleted_at": nil}
+	update := bson.M{"$set": bson.M{"end_time": dbtime.From(ar.Clock.Now())}, "$inc": revisionIncrement}
+	opts := options.FindOneAndUpdate().SetProjection(closeProjection).SetReturnDocument(options.After)
+
+	var closing AuctionEntityMongo
+	err := ar.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&closing)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.ErrorContext(ctx, "Error trying to close auction", err, zap.String("auction_id", id))
+		return internal_error.NewInternalServerError("Error trying to close auction").Wrap(err)
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, err := ar.Collection.CountDocuments(ctx, bson.M{"_id": id, "deleted_at": nil})
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to find auction by id", err, zap.String("auction_id", id))
+			return internal_error.NewInternalServerError("Error trying to close auction").Wrap(err)
+		}
+
+		if count == 0 {
+			return internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+		}
+
+		return internal_error.NewConflictError("Only active auctions can be closed")
+	}
+
+	_, errInternal := ar.closeAuctions(ctx, []AuctionEntityMongo{closing}, auction_entity.AuditReasonClosedNow)
+	return errInternal
+}
+
+// CloseBoughtNow closes the auction bidEntity buys at its buy-now price, with the bid as
+// its winner and BoughtNow as its outcome, and records the close in the audit log. It is
+// the conditional update of the other closes, matching only an auction still Active and
+// before its end time, whose buy-now price is the bid amount and wasn't reached by any
+// bid yet; it returns mongo.ErrNoDocuments when none matches. The update also counts the
+// bid, so it must run in the transaction inserting it, see bid.BidRepository.BuyNow.
+func (ar *AuctionRepository) CloseBoughtNow(
+	ctx context.Context, bidEntity *bid_entity.Bid, closedAt time.Time) error {
+	filter := bson.M{
+		"_id":                    bidEntity.AuctionId,
+		"status":                 auction_entity.Active,
+		"end_time":               bson.M{"$gt": closedAt},
+		"deleted_at":             nil,
+		"buy_now_price_cents":    bidEntity.Amount,
+		"current_highest_amount": bson.M{"$not": bson.M{"$gte": bidEntity.Amount}},
+	}
+
+	// The auction ends now, so it closes without any lag
+	set := closeUpdate(AuctionEntityMongo{EndTime: dbtime.From(closedAt)}, &winningBidMongo{
+		AuctionId: bidEntity.AuctionId,
+		BidId:     bidEntity.Id,
+		UserId:    bidEntity.UserId,
+		Amount:    bidEntity.Amount,
+		Currency:  bidEntity.Currency,
+		Timestamp: dbtime.From(bidEntity.Timestamp),
+	}, closedAt)
+	set["outcome"] = auction_entity.BoughtNow
+	set["end_time"] = dbtime.From(closedAt)
+	set["closed_lag_ms"] = int64(0)
+	set["last_bid_at"] = dbtime.From(closedAt)
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, bson.M{
+		"$set": set,
+		"$inc": bson.M{"bid_count": 1, "revision": 1},
+		"$max": bson.M{"current_highest_amount": bidEntity.Amount},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return ar.recordStatusChanges(ctx, []auction_entity.AuctionAudit{auction_entity.NewAuctionAudit(
+		ctx, bidEntity.AuctionId, auction_entity.Active, auction_entity.Completed,
+		auction_entity.AuditReasonBoughtNow, closedAt)})
+}
+
+// closeProjection reads the fields closeAuctions needs from the auctions it closes.
+var closeProjection = bson.M{
+	"_id": 1, "end_time": 1, "auction_type": 1, "trace_parent": 1,
+	"starting_price": 1, "starting_price_cents": 1, "reserve_price": 1, "reserve_price_cents": 1,
+}
+
+// closeExpired closes the expired Active auctions matching filter.
+func (ar *AuctionRepository) closeExpired(
+	ctx context.Context, filter bson.M) ([]string, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter["status"] = auction_entity.Active
+	filter["end_time"] = bson.M{"$lte": ar.Clock.Now()}
+
+	var expiredAuctions []AuctionEntityMongo
+	err := retry.Do(ctx, retry.DefaultPolicy(), "find_expired_auctions", func(ctx context.Context) error {
+		cursor, err := ar.Collection.Find(ctx, filter, options.Find().SetProjection(closeProjection))
+		if err != nil {
+			return err
+		}
+
+		return cursor.All(ctx, &expiredAuctions)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find expired auctions", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find expired auctions").Wrap(err)
+	}
+
+	if len(expiredAuctions) == 0 {
+		return nil, nil
+	}
+
+	return ar.closeAuctions(ctx, expiredAuctions, auction_entity.AuditReasonExpired)
+}
+
+// closeAuctions closes the expired auctions found by closeExpired or CloseAuctionNow,
+// recording reason in their audit log. Each auction is closed by its own conditional
+// update, and only those the update changed are audited and returned, so an auction
+// closed in the meantime by another instance is neither audited nor announced twice.
+// Its span links to the traces of the requests that created them.
+func (ar *AuctionRepository) closeAuctions(
+	ctx context.Context,
+	expiredAuctions []AuctionEntityMongo,
+	reason string) ([]string, *internal_error.InternalError) {
+	auctionIds := make([]string, 0, len(expiredAuctions))
+	var links []trace.Link
+	for _, expired := range expiredAuctions {
+		auctionIds = append(auctionIds, expired.Id)
+
+		if link, ok := tracing.LinkTo(expired.TraceParent); ok {
+			links = append(links, link)
+		}
+	}
+
+	ctx, span := tracing.StartLinked(ctx, "AuctionRepository.closeAuctions", links,
+		attribute.Int("auction_count", len(auctionIds)))
+	defer span.End()
+
+	// Bids are only accepted before end_time, so once the queued ones are written the
+	// winning bids can't change anymore. A failed flush leaves its bids queued, and the
+	// auctions are closed without them rather than left open
+	if ar.FlushBids != nil {
+		if err := ar.FlushBids(ctx); err != nil {
+			logger.ErrorContext(ctx, "Error trying to write the queued bids before closing auctions", err,
+				zap.Strings("auction_ids", auctionIds))
+		}
+	}
+
+	winningBids, err := ar.findWinningBids(ctx, auctionIds)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find the winning bids of expired auctions", err,
+			zap.Strings("auction_ids", auctionIds))
+		ar.recordCloseFailures(ctx, failedCloses(err, expiredAuctions))
+		return nil, internal_error.NewInternalServerError("Error trying to close expired auctions").Wrap(err)
+	}
+
+	// closeId marks the auctions this call closes, so a retried update still matches an
+	// auction its first attempt closed, while one closed in the meantime by another
+	// instance or request is left to the closer that closed it
+	closeId := uuid.NewString()
+	closedAt := ar.Clock.Now()
+	failures := make(map[string]error)
+	closed := make([]AuctionEntityMongo, 0, len(expiredAuctions))
+	for _, expired := range expiredAuctions {
+		set := closeUpdate(expired, winningBids[expired.Id], closedAt)
+		set["close_id"] = closeId
+		filter := bson.M{"_id": expired.Id, "$or": bson.A{
+			bson.M{"status": auction_entity.Active},
+			bson.M{"close_id": closeId},
+		}}
+
+		var result *mongo.UpdateResult
+		err := retry.Do(ctx, retry.DefaultPolicy(), "close_expired_auction", func(ctx context.Context) error {
+			var err error
+			result, err = ar.Collection.UpdateOne(ctx, filter, bson.M{"$set": set, "$inc": revisionIncrement})
+			return err
+		})
+		switch {
+		case err != nil:
+			failures[expired.Id] = err
+		case result.MatchedCount > 0:
+			closed = append(closed, expired)
+		}
+	}
+
+	if len(failures) > 0 {
+		// The auctions whose own update failed are left for a later retry, while the
+		// others were closed all the same
+		failedIds := make([]string, 0, len(failures))
+		var err error
+		for auctionId, failure := range failures {
+			failedIds = append(failedIds, auctionId)
+			err = failure
+		}
+		logger.ErrorContext(ctx, "Error trying to close expired auctions", err, zap.Strings("auction_ids", failedIds))
+
+		ar.recordCloseFailures(ctx, failures)
+		if len(failures) == len(expiredAuctions) {
+			return nil, internal_error.NewInternalServerError("Error trying to close expired auctions").Wrap(err)
+		}
+	}
+
+	auctionIds = auctionIds[:0]
+	for _, expired := range closed {
+		auctionIds = append(auctionIds, expired.Id)
+	}
+	expiredAuctions = closed
+	ar.clearCloseFailures(ctx, auctionIds)
+
+	// The closes are separate updates rather than a transaction, so the audit log is
+	// written on a best-effort basis
+	auditEntries := make([]auction_entity.AuctionAudit, 0, len(expiredAuctions))
+	for _, expired := range expiredAuctions {
+		metrics.AuctionCloseLag.Observe(closeLag(expired, closedAt).Seconds())
+		auditEntries = append(auditEntries, auction_entity.NewAuctionAudit(
+			ctx, expired.Id, auction_entity.Active, auction_entity.Completed, reason, closedAt))
+	}
+	ar.recordStatusChanges(ctx, auditEntries)
+	metrics.AuctionsClosed.Add(float64(len(auctionIds)))
+
+	return auctionIds, nil
+}
+
+// closeUpdate is the $set closing the expired auction: its status, its outcome, how late
+// it closed and the winner snapshot, with the amount the winner pays. The winner fields
+// are set to null when the auction got no bids.
+func closeUpdate(expired AuctionEntityMongo, winningBid *winningBidMongo, closedAt time.Time) bson.M {
+	auctionEntity := toAuctionEntity(expired)
+	update := bson.M{
+		"status":                auction_entity.Completed,
+		"closed_at":             closedAt,
+		"closed_lag_ms":         closeLag(expired, closedAt).Milliseconds(),
+		"winning_bid_id":        nil,
+		"winning_user_id":       nil,
+		"winning_amount":        nil,
+		"winning_currency":      nil,
+		"winning_bid_timestamp": nil,
+		"settlement_amount":     nil,
+	}
+
+	if winningBid == nil {
+		update["outcome"] = auctionEntity.OutcomeFor(0, false)
+		return update
+	}
+
+	update["outcome"] = auctionEntity.OutcomeFor(winningBid.Amount, true)
+	update["winning_bid_id"] = winningBid.BidId
+	update["winning_user_id"] = winningBid.UserId
+	update["winning_amount"] = winningBid.Amount
+	update["winning_currency"] = winningBid.Currency
+	update["winning_bid_timestamp"] = winningBid.Timestamp
+	update["settlement_amount"] = auctionEntity.SettlementFor(winningBid.Amount, winningBid.RunnerUpAmount)
+
+	return update
+}
+
+// closeLag is the time between the end of the expired auction and closedAt.
+func closeLag(expired AuctionEntityMongo, closedAt time.Time) time.Duration {
+	return closedAt.Sub(expired.EndTime.UTC())
+}
+
+// findWinningBids returns the winning bid of each auction that got any bid, in a single
+// aggregation over the bids collection. It ranks bids the way the bid repository does:
+// the highest amount and, among equal amounts, the earliest bid. The best bid of each
+// bidder is kept first, so the runner-up amount is the best bid of another bidder.
+func (ar *AuctionRepository) findWinningBids(
+	ctx context.Context, auctionIds []string) (map[string]*winningBidMongo, error) {
+	// The bids are sorted on amount_cents so the {auction_id, amount_cents, timestamp}
+	// index serves the sort, see bid.BackfillAmountCents for the legacy float amounts
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"auction_id": bson.M{"$in": auctionIds}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "amount_cents", Value: -1}, {Key: "timestamp", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"auction_id": "$auction_id", "user_id": "$user_id"},
+			"bid_id":    bson.M{"$first": "$_id"},
+			"amount":    bson.M{"$first": "$amount_cents"},
+			"currency":  bson.M{"$first": "$currency"},
+			"timestamp": bson.M{"$first": "$timestamp"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "amount", Value: -1}, {Key: "timestamp", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$_id.auction_id",
+			"bid_id":    bson.M{"$first": "$bid_id"},
+			"user_id":   bson.M{"$first": "$_id.user_id"},
+			"amount":    bson.M{"$first": "$amount"},
+			"currency":  bson.M{"$first": "$currency"},
+			"timestamp": bson.M{"$first": "$timestamp"},
+			"amounts":   bson.M{"$push": "$amount"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"runner_up_amount": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$amounts", 1}}, 0}},
+		}}},
+		{{Key: "$project", Value: bson.M{"amounts": 0}}},
+	}
+
+	var results []winningBidMongo
+	err := retry.Do(ctx, retry.DefaultPolicy(), "find_winning_bids", func(ctx context.Context) error {
+		cursor, err := ar.Collection.Database().Collection(bidsCollection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	winningBids := make(map[string]*winningBidMongo, len(results))
+	for i := range results {
+		winningBids[results[i].AuctionId] = &results[i]
+	}
+
+	return winningBids, nil
+}
+
+// FindClosedAuctions reads back the auctions with the given ids, in that order, joining
+// the name of their winning user. The closers call it once the close wrote the winner
+// snapshots, to build the auction_closed events. Unknown ids are skipped.
+func (ar *AuctionRepository) FindClosedAuctions(
+	ctx context.Context, auctionIds []string) ([]auction_entity.ClosedAuction, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.FindClosedAuctions",
+		attribute.Int("auction_count", len(auctionIds)))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$in": auctionIds}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         usersCollection,
+			"localField":   "winning_user_id",
+			"foreignField": "_id",
+			"as":           "winner",
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"winner_name": bson.M{"$arrayElemAt": bson.A{"$winner.name", 0}},
+		}}},
+		{{Key: "$project", Value: bson.M{"winner": 0}}},
+	}
+
+	cursor, err := ar.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find closed auctions", err, zap.Strings("auction_ids", auctionIds))
+		return nil, internal_error.NewInternalServerError("Error trying to find closed auctions").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		AuctionEntityMongo `bson:",inline"`
+		WinnerName         string `bson:"winner_name"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode closed auctions", err, zap.Strings("auction_ids", auctionIds))
+		return nil, internal_error.NewInternalServerError("Error trying to find closed auctions").Wrap(err)
+	}
+
+	byId := make(map[string]auction_entity.ClosedAuction, len(results))
+	for _, result := range results {
+		byId[result.Id] = auction_entity.ClosedAuction{
+			Auction:    toAuctionEntity(result.AuctionEntityMongo),
+			WinnerName: result.WinnerName,
+		}
+	}
+
+	closedAuctions := make([]auction_entity.ClosedAuction, 0, len(results))
+	for _, auctionId := range auctionIds {
+		if closed, ok := byId[auctionId]; ok {
+			closedAuctions = append(closedAuctions, closed)
+		}
+	}
+
+	return closedAuctions, nil
+}
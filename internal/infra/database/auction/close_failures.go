@@ -0,0 +1,227 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// defaultCloseRetryInterval is used when the repository has no CloseRetryInterval.
+const defaultCloseRetryInterval = 5 * time.Minute
+
+type CloseFailureMongo struct {
+	AuctionId     string    `bson:"_id"`
+	Error         string    `bson:"error"`
+	Attempts      int64     `bson:"attempts"`
+	FirstFailedAt time.Time `bson:"first_failed_at"`
+	LastFailedAt  time.Time `bson:"last_failed_at"`
+	NextRetryAt   time.Time `bson:"next_retry_at"`
+}
+
+func (ar *AuctionRepository) closeRetryInterval() time.Duration {
+	if ar.CloseRetryInterval <= 0 {
+		return defaultCloseRetryInterval
+	}
+
+	return ar.CloseRetryInterval
+}
+
+// failedCloses records err against every auction of expiredAuctions, as a close that
+// failed before updating any of them leaves them all open.
+func failedCloses(err error, expiredAuctions []AuctionEntityMongo) map[string]error {
+	failures := make(map[string]error, len(expiredAuctions))
+	for _, expired := range expiredAuctions {
+		failures[expired.Id] = err
+	}
+
+	return failures
+}
+
+// recordCloseFailures records the auctions that failed to close, counting the attempts
+// and pushing their next retry out by the retry interval. A close interrupted by its
+// context, e.g. at shutdown, isn't a failure of the auction and isn't recorded. Like
+// the audit log, the records are written on a best-effort basis.
+func (ar *AuctionRepository) recordCloseFailures(ctx context.Context, failures map[string]error) {
+	if len(failures) == 0 || ctx.Err() != nil {
+		return
+	}
+
+	now := ar.Clock.Now()
+	auctionIds := make([]string, 0, len(failures))
+	models := make([]mongo.WriteModel, 0, len(failures))
+	for auctionId, err := range failures {
+		auctionIds = append(auctionIds, auctionId)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": auctionId}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"error":          err.Error(),
+					"last_failed_at": now,
+					"next_retry_at":  now.Add(ar.closeRetryInterval()),
+				},
+				"$setOnInsert": bson.M{"first_failed_at": now},
+				"$inc":         bson.M{"attempts": 1},
+			}).
+			SetUpsert(true))
+	}
+
+	metrics.AuctionCloseFailures.Add(float64(len(failures)))
+	logger.WarnContext(ctx, "Auctions left open after failing to close, retrying them later",
+		zap.Strings("auction_ids", auctionIds), zap.Duration("retry_in", ar.closeRetryInterval()))
+
+	_, err := ar.CloseFailureCollection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to record auction close failures", err,
+			zap.Strings("auction_ids", auctionIds))
+	}
+}
+
+// clearCloseFailures drops the records of the auctions just closed, if any.
+func (ar *AuctionRepository) clearCloseFailures(ctx context.Context, auctionIds []string) {
+	_, err := ar.CloseFailureCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": auctionIds}})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to clear auction close failures", err,
+			zap.Strings("auction_ids", auctionIds))
+	}
+}
+
+// excludeBackingOff leaves out of filter the auctions whose last close failed less than
+// the retry interval ago, so a close that keeps failing isn't tried on every sweep.
+func (ar *AuctionRepository) excludeBackingOff(ctx context.Context, filter bson.M) *internal_error.InternalError {
+	backingOff, err := ar.findCloseFailureIds(ctx, bson.M{"next_retry_at": bson.M{"$gt": ar.Clock.Now()}})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auction close failures", err)
+		return internal_error.NewInternalServerError("Error trying to find expired auctions").Wrap(err)
+	}
+
+	if len(backingOff) == 0 {
+		return nil
+	}
+
+	idFilter, _ := filter["_id"].(bson.M)
+	if idFilter == nil {
+		idFilter = bson.M{}
+	}
+	idFilter["$nin"] = backingOff
+	filter["_id"] = idFilter
+
+	return nil
+}
+
+func (ar *AuctionRepository) findCloseFailureIds(ctx context.Context, filter bson.M) ([]string, error) {
+	cursor, err := ar.CloseFailureCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []CloseFailureMongo
+	if err := cursor.All(ctx, &failures); err != nil {
+		return nil, err
+	}
+
+	auctionIds := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		auctionIds = append(auctionIds, failure.AuctionId)
+	}
+
+	return auctionIds, nil
+}
+
+// RetryDueCloseFailures closes the auctions whose last close failed at least the retry
+// interval ago, and returns the ids it closed. CloseExpiredAuctions already picks them
+// up again; it is meant for the ChangeStreamCloser, which only closes the auctions it
+// sees reaching their end time.
+func (ar *AuctionRepository) RetryDueCloseFailures(ctx context.Context) ([]string, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	dueIds, err := ar.findCloseFailureIds(ctx, bson.M{"next_retry_at": bson.M{"$lte": ar.Clock.Now()}})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auction close failures", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction close failures").Wrap(err)
+	}
+
+	if len(dueIds) == 0 {
+		return nil, nil
+	}
+
+	return ar.closeExpired(ctx, bson.M{"_id": bson.M{"$in": dueIds}})
+}
+
+// FindCloseFailures lists the auctions that failed to close, the longest failing first.
+func (ar *AuctionRepository) FindCloseFailures(
+	ctx context.Context) ([]auction_entity.CloseFailure, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	cursor, err := ar.CloseFailureCollection.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "first_failed_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auction close failures", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction close failures").Wrap(err)
+	}
+
+	var failuresMongo []CloseFailureMongo
+	if err := cursor.All(ctx, &failuresMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode auction close failures", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction close failures").Wrap(err)
+	}
+
+	failures := make([]auction_entity.CloseFailure, 0, len(failuresMongo))
+	for _, failure := range failuresMongo {
+		failures = append(failures, auction_entity.CloseFailure{
+			AuctionId:     failure.AuctionId,
+			Error:         failure.Error,
+			Attempts:      failure.Attempts,
+			FirstFailedAt: failure.FirstFailedAt,
+			LastFailedAt:  failure.LastFailedAt,
+			NextRetryAt:   failure.NextRetryAt,
+		})
+	}
+
+	return failures, nil
+}
+
+// RetryClose runs the close of an auction that failed to close right away, without
+// waiting for its next retry. It returns a not found error when no failure is recorded
+// for the auction, and a conflict error, dropping the record, when the auction no
+// longer needs closing, e.g. because it was cancelled or closed manually since. A close
+// failing again is recorded as one more attempt.
+func (ar *AuctionRepository) RetryClose(ctx context.Context, auctionId string) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	err := ar.CloseFailureCollection.FindOne(ctx, bson.M{"_id": auctionId}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("No close failure recorded for the auction with this id = %s", auctionId))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auction close failure", err, zap.String("auction_id", auctionId))
+		return internal_error.NewInternalServerError("Error trying to retry auction close").Wrap(err)
+	}
+
+	closedIds, errInternal := ar.closeExpired(ctx, bson.M{"_id": auctionId})
+	if errInternal != nil {
+		return errInternal
+	}
+
+	if len(closedIds) == 0 {
+		ar.clearCloseFailures(ctx, []string{auctionId})
+		return internal_error.NewConflictError("The auction no longer needs closing")
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// OverdueAuctionsFinder looks up the Active auctions whose end time passed without the
+// closer closing them. *AuctionRepository and memory.AuctionRepository implement it.
+type OverdueAuctionsFinder interface {
+	// FindOverdueAuctions returns the end time of the Active auction that ends first, the
+	// zero time when there is none, and how many Active auctions ended before endedBefore.
+	FindOverdueAuctions(
+		ctx context.Context, endedBefore time.Time) (time.Time, int64, *internal_error.InternalError)
+}
+
+// FindOverdueAuctions reads the Active auctions through the {status, end_time} index the
+// closer sweeps by.
+func (ar *AuctionRepository) FindOverdueAuctions(
+	ctx context.Context, endedBefore time.Time) (time.Time, int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "end_time", Value: 1}}).
+		SetProjection(bson.M{"end_time": 1})
+
+	var oldest struct {
+		EndTime dbtime.Time `bson:"end_time"`
+	}
+	err := ar.Collection.FindOne(ctx, bson.M{"status": auction_entity.Active}, opts).Decode(&oldest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, 0, nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find overdue auctions", err)
+		return time.Time{}, 0, internal_error.NewInternalServerError("Error trying to find overdue auctions").Wrap(err)
+	}
+
+	overdue, err := ar.Collection.CountDocuments(ctx, bson.M{
+		"status":   auction_entity.Active,
+		"end_time": bson.M{"$lt": endedBefore},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count overdue auctions", err)
+		return time.Time{}, 0, internal_error.NewInternalServerError("Error trying to count overdue auctions").Wrap(err)
+	}
+
+	return oldest.EndTime.UTC(), overdue, nil
+}
+
+// CloseLagMonitor checks every interval how far behind the closer is: how long ago the
+// Active auction that ended first ended. An auction still Active more than alarm past
+// its end time is logged at Error level and counted in auction_close_overdue_total.
+type CloseLagMonitor struct {
+	finder   OverdueAuctionsFinder
+	clock    clock.Clock
+	alarm    time.Duration
+	interval time.Duration
+
+	mutex  sync.RWMutex
+	maxLag time.Duration
+}
+
+func NewCloseLagMonitor(
+	finder OverdueAuctionsFinder, clk clock.Clock, alarm, interval time.Duration) *CloseLagMonitor {
+	return &CloseLagMonitor{
+		finder:   finder,
+		clock:    clk,
+		alarm:    alarm,
+		interval: interval,
+	}
+}
+
+// Run checks the lag right away and then every interval, until ctx is done. A zero
+// alarm or interval disables it.
+func (m *CloseLagMonitor) Run(ctx context.Context) {
+	if m.alarm <= 0 || m.interval <= 0 {
+		return
+	}
+
+	timer := m.clock.NewTimer(m.interval)
+	defer timer.Stop()
+
+	m.Check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.Chan():
+			m.Check(ctx)
+			timer.Reset(m.interval)
+		}
+	}
+}
+
+// Check measures the lag once. A failed lookup is logged by the finder and keeps the lag
+// of the previous check.
+func (m *CloseLagMonitor) Check(ctx context.Context) {
+	now := m.clock.Now()
+
+	oldestEndTime, overdue, err := m.finder.FindOverdueAuctions(ctx, now.Add(-m.alarm))
+	if err != nil {
+		return
+	}
+
+	var maxLag time.Duration
+	if !oldestEndTime.IsZero() && now.After(oldestEndTime) {
+		maxLag = now.Sub(oldestEndTime)
+	}
+
+	m.mutex.Lock()
+	m.maxLag = maxLag
+	m.mutex.Unlock()
+
+	if overdue > 0 {
+		metrics.AuctionCloseOverdue.Add(float64(overdue))
+		logger.Error("Active auctions are overdue to close", nil,
+			zap.Int64("count", overdue),
+			zap.Duration("max_lag", maxLag),
+			zap.Duration("alarm", m.alarm))
+	}
+}
+
+// MaxLag is how far past its end time the Active auction that ended first was at the
+// last check, zero when every auction that ended was closed.
+func (m *CloseLagMonitor) MaxLag() time.Duration {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.maxLag
+}
+
+// Alarm is the lag past which the closer is considered degraded.
+func (m *CloseLagMonitor) Alarm() time.Duration {
+	return m.alarm
+}
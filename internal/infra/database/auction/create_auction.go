@@ -2,122 +2,459 @@ package auction
 
 import (
 	"context"
-	"os"
-	"strconv"
+	"errors"
 	"time"
 
+	"fullcycle-auction_go/configuration/database/mongodb"
 	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
 	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/infra/tracing"
 	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-const (
-	Active = iota
-	Finished
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 )
 
 type AuctionEntityMongo struct {
 	Id          string                          `bson:"_id"`
+	SellerId    string                          `bson:"seller_id,omitempty"`
 	ProductName string                          `bson:"product_name"`
 	Category    string                          `bson:"category"`
 	Description string                          `bson:"description"`
 	Condition   auction_entity.ProductCondition `bson:"condition"`
 	Status      auction_entity.AuctionStatus    `bson:"status"`
-	Timestamp   int64                           `bson:"timestamp"`
+	EndTime     dbtime.Time                     `bson:"end_time"`
+
+	// Timestamp is when the auction was created, to the millisecond. Auctions created
+	// before it was stored as a date have it in Unix seconds until BackfillTimestamps
+	Timestamp dbtime.Time `bson:"timestamp"`
+
+	// StartTime is when a Scheduled auction goes live. Auctions created before scheduling
+	// existed don't have it and started at their timestamp. Like the end time, it was
+	// stored in Unix seconds until BackfillTimestamps
+	StartTime dbtime.Time `bson:"start_time,omitempty"`
+
+	// ExtensionSeconds is how much anti-sniping extensions have added to end_time so far
+	ExtensionSeconds int64 `bson:"extension_seconds,omitempty"`
+
+	// Currency is missing from the auctions created before it existed, which are in
+	// money.DefaultCurrency
+	Currency string `bson:"currency,omitempty"`
+
+	// AuctionType is missing from the auctions created before it existed, which are English
+	AuctionType auction_entity.AuctionType `bson:"auction_type,omitempty"`
+
+	// Prices are stored in cents. Auctions created before prices moved to cents only
+	// have the legacy float prices, converted when read
+	StartingPriceCents  *int64                        `bson:"starting_price_cents,omitempty"`
+	ReservePriceCents   *int64                        `bson:"reserve_price_cents,omitempty"`
+	LegacyStartingPrice float64                       `bson:"starting_price,omitempty"`
+	LegacyReservePrice  float64                       `bson:"reserve_price,omitempty"`
+	Outcome             auction_entity.AuctionOutcome `bson:"outcome,omitempty"`
+
+	// BuyNowPriceCents is missing from the auctions without a buy-now price
+	BuyNowPriceCents int64 `bson:"buy_now_price_cents,omitempty"`
+
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
+
+	// The winner snapshot is written along with the Completed status. The winner fields
+	// are null when the auction closed without bids
+	ClosedAt            *time.Time   `bson:"closed_at,omitempty"`
+	WinningBidId        *string      `bson:"winning_bid_id,omitempty"`
+	WinningUserId       *string      `bson:"winning_user_id,omitempty"`
+	WinningAmount       *int64       `bson:"winning_amount,omitempty"`
+	WinningCurrency     *string      `bson:"winning_currency,omitempty"`
+	WinningBidTimestamp *dbtime.Time `bson:"winning_bid_timestamp,omitempty"`
+
+	// SettlementAmount is what the winner pays, missing from the snapshots recorded before
+	// it existed, which were paid the winning amount
+	SettlementAmount *int64 `bson:"settlement_amount,omitempty"`
+
+	// ClosedLagMs is how long after its end time the auction was closed, in milliseconds
+	ClosedLagMs *int64 `bson:"closed_lag_ms,omitempty"`
+
+	// TraceParent is the W3C traceparent of the request that created the auction, so the
+	// span closing it can link back to that trace
+	TraceParent string `bson:"trace_parent,omitempty"`
+
+	// BidCount and CurrentHighestAmount are updated in the transaction inserting each
+	// bid. Auctions that got their bids before the fields existed don't have them
+	BidCount             int64  `bson:"bid_count,omitempty"`
+	CurrentHighestAmount *int64 `bson:"current_highest_amount,omitempty"`
+
+	// Revision is incremented by every update of the auction, see revisionIncrement
+	Revision int64 `bson:"revision,omitempty"`
+
+	// Version is incremented by the seller's changes only, see versionIncrement. Auctions
+	// stored before it existed don't have it and are at version 0, see versionFilter
+	Version int64 `bson:"version,omitempty"`
+
+	// Images are stored in the order they were submitted in. Auctions created before
+	// images existed don't have them
+	Images []AuctionImageMongo `bson:"images"`
+}
+
+// revisionIncrement is the $inc every update of an auction carries, so its revision
+// changes along with it.
+var revisionIncrement = bson.M{"revision": 1}
+
+// versionIncrement replaces revisionIncrement in the seller's changes, which also move
+// the version. Bids only ever use revisionIncrement: their $inc and $max on the counters
+// commute, so they don't need to conflict with anything.
+var versionIncrement = bson.M{"revision": 1, "version": 1}
+
+// versionFilter matches the auctions at version, counting a missing version as 0.
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return version
+}
+
+type AuctionImageMongo struct {
+	URL     string `bson:"url"`
+	Order   int    `bson:"order"`
+	AltText string `bson:"alt,omitempty"`
+}
+
+func toAuctionImagesMongo(images []auction_entity.AuctionImage) []AuctionImageMongo {
+	imagesMongo := make([]AuctionImageMongo, len(images))
+	for i, image := range images {
+		imagesMongo[i] = AuctionImageMongo{URL: image.URL, Order: image.Order, AltText: image.AltText}
+	}
+
+	return imagesMongo
+}
+
+func (a AuctionEntityMongo) images() []auction_entity.AuctionImage {
+	images := make([]auction_entity.AuctionImage, len(a.Images))
+	for i, image := range a.Images {
+		images[i] = auction_entity.AuctionImage{URL: image.URL, Order: image.Order, AltText: image.AltText}
+	}
+
+	return images
+}
+
+// winningBid returns the winner snapshot, or nil when there is none.
+func (a AuctionEntityMongo) winningBid() *auction_entity.WinningBid {
+	if a.WinningBidId == nil || a.WinningUserId == nil || a.WinningAmount == nil {
+		return nil
+	}
+
+	winningBid := &auction_entity.WinningBid{
+		BidId:    *a.WinningBidId,
+		UserId:   *a.WinningUserId,
+		Amount:   *a.WinningAmount,
+		Currency: money.DefaultCurrency,
+
+		SettlementAmount: *a.WinningAmount,
+	}
+	if a.WinningCurrency != nil && *a.WinningCurrency != "" {
+		winningBid.Currency = *a.WinningCurrency
+	}
+	if a.WinningBidTimestamp != nil {
+		winningBid.Timestamp = a.WinningBidTimestamp.UTC()
+	}
+	if a.SettlementAmount != nil {
+		winningBid.SettlementAmount = *a.SettlementAmount
+	}
+
+	return winningBid
+}
+
+func (a AuctionEntityMongo) currency() string {
+	if a.Currency == "" {
+		return money.DefaultCurrency
+	}
+
+	return a.Currency
+}
+
+func (a AuctionEntityMongo) auctionType() auction_entity.AuctionType {
+	if a.AuctionType == "" {
+		return auction_entity.English
+	}
+
+	return a.AuctionType
+}
+
+func (a AuctionEntityMongo) startingPrice() int64 {
+	if a.StartingPriceCents != nil {
+		return *a.StartingPriceCents
+	}
+
+	return money.FromFloat(a.LegacyStartingPrice)
+}
+
+func (a AuctionEntityMongo) reservePrice() int64 {
+	if a.ReservePriceCents != nil {
+		return *a.ReservePriceCents
+	}
+
+	return money.FromFloat(a.LegacyReservePrice)
+}
+
+func (a AuctionEntityMongo) startTime() time.Time {
+	if a.StartTime.IsZero() {
+		return a.Timestamp.UTC()
+	}
+
+	return a.StartTime.UTC()
+}
+
+func (a AuctionEntityMongo) currentHighestAmount() int64 {
+	if a.CurrentHighestAmount == nil {
+		return 0
+	}
+
+	return *a.CurrentHighestAmount
 }
 
 type AuctionRepository struct {
 	Collection *mongo.Collection
+
+	// Clock decides which auctions have ended. The bid repository reads it too, so
+	// both agree on when an auction stops accepting bids.
+	Clock clock.Clock
+
+	// TxRunner runs the writes that span several documents, see UpdateAuction
+	TxRunner mongodb.TxRunner
+
+	// AuditCollection holds the status changes of the auctions, see recordStatusChanges
+	AuditCollection *mongo.Collection
+
+	// CloseFailureCollection holds the auctions that failed to close, see
+	// recordCloseFailures
+	CloseFailureCollection *mongo.Collection
+
+	// ArchiveCollection holds the auctions moved away by ArchiveCompleted
+	ArchiveCollection *mongo.Collection
+
+	// CloseRetryInterval is how long the closer leaves an auction that failed to close
+	// before trying again, 5 minutes when it is zero
+	CloseRetryInterval time.Duration
+
+	// FlushBids, when set, writes the bids still queued by this instance before any
+	// auction is closed, so the bids accepted right before its end time decide its
+	// winner, see bid_usecase.BidUseCase.Flush
+	FlushBids func(ctx context.Context) error
+
+	// FacetsCacheTTL is how long GetFacets keeps each result, zero disables the cache
+	FacetsCacheTTL time.Duration
+	facetsCache    facetsCache
+
+	// defaultDuration is how long the auctions created without an end time last
+	defaultDuration time.Duration
+
+	// operationTimeout bounds each operation, see dbtimeout.WithTimeout
+	operationTimeout time.Duration
 }
 
-func NewAuctionRepository(database *mongo.Database) *AuctionRepository {
+// defaultAuctionDuration is used when NewAuctionRepository is given no duration.
+const defaultAuctionDuration = 600 * time.Second
+
+// NewAuctionRepository stores auctions in database, bounding each operation by
+// operationTimeout. Auctions created without an end time last defaultDuration, 10 minutes
+// when it is zero.
+func NewAuctionRepository(
+	database *mongo.Database, clk clock.Clock, txRunner mongodb.TxRunner,
+	defaultDuration, operationTimeout time.Duration) *AuctionRepository {
+	if defaultDuration <= 0 {
+		defaultDuration = defaultAuctionDuration
+	}
+
 	return &AuctionRepository{
-		Collection: database.Collection("auctions"),
+		Collection:             database.Collection("auctions"),
+		Clock:                  clk,
+		TxRunner:               txRunner,
+		AuditCollection:        database.Collection("auction_audit"),
+		CloseFailureCollection: database.Collection("close_failures"),
+		ArchiveCollection:      database.Collection("auctions_archive"),
+		defaultDuration:        defaultDuration,
+		operationTimeout:       operationTimeout,
 	}
 }
 
-func getAuctionDuration() time.Duration {
-	v := os.Getenv("AUCTION_DURATION_SECONDS")
-	if v == "" {
-		return time.Duration(600) * time.Second
+// EnsureIndexes creates the indexes backing the auction filters, the closer sweep, the
+// admin stats and the audit log lookup. CreateMany is a no-op for indexes that already exist, so it is safe to
+// call on every startup.
+func (ar *AuctionRepository) EnsureIndexes(ctx context.Context) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	_, err := ar.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "timestamp", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "end_time", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "start_time", Value: 1}}},
+		{Keys: bson.D{{Key: "category", Value: 1}}},
+		{Keys: bson.D{{Key: "seller_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: 1}}},
+		{Keys: bson.D{
+			{Key: "seller_id", Value: 1}, {Key: "product_name", Value: 1},
+			{Key: "category", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create auction indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create auction indexes").Wrap(err)
 	}
-	secs, err := strconv.Atoi(v)
-	if err != nil || secs <= 0 {
-		return time.Duration(600) * time.Second
+
+	_, err = ar.AuditCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "auction_id", Value: 1}, {Key: "timestamp", Value: 1}},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create auction audit indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create auction indexes").Wrap(err)
 	}
-	return time.Duration(secs) * time.Second
+
+	return nil
 }
 
 func (ar *AuctionRepository) CreateAuction(
 	ctx context.Context,
 	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.CreateAuction", attribute.String("auction_id", auctionEntity.Id))
+	defer span.End()
 
-	if auctionEntity.Timestamp.IsZero() {
-		auctionEntity.Timestamp = time.Now()
-	}
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
 
-	auctionEntityMongo := &AuctionEntityMongo{
-		Id:          auctionEntity.Id,
-		ProductName: auctionEntity.ProductName,
-		Category:    auctionEntity.Category,
-		Description: auctionEntity.Description,
-		Condition:   auctionEntity.Condition,
-		Status:      auctionEntity.Status,
-		Timestamp:   auctionEntity.Timestamp.Unix(),
+	auctionMongo := ar.newAuctionEntityMongo(ctx, auctionEntity)
+	if err := auctionEntity.ValidateNotEnded(ar.Clock.Now()); err != nil {
+		return err
 	}
 
-	_, err := ar.Collection.InsertOne(ctx, auctionEntityMongo)
+	_, err := ar.Collection.InsertOne(ctx, auctionMongo)
 	if err != nil {
-		logger.Error("Error trying to insert auction", err)
-		return internal_error.NewInternalServerError("Error trying to insert auction")
+		logger.ErrorContext(ctx, "Error trying to insert auction", err, zap.String("auction_id", auctionEntity.Id))
+		return internal_error.NewInternalServerError("Error trying to insert auction").Wrap(err)
 	}
 
-	duration := getAuctionDuration()
-	createdAt := time.Unix(auctionEntityMongo.Timestamp, 0)
-	elapsed := time.Since(createdAt)
-	var remaining time.Duration
-	if elapsed >= duration {
-		remaining = 0
-	} else {
-		remaining = duration - elapsed
-	}
+	metrics.AuctionsCreated.Inc()
+	return nil
+}
 
-	go func(auctionID string, wait time.Duration) {
-		if wait > 0 {
-			timer := time.NewTimer(wait)
-			<-timer.C
-		}
+// CreateAuctions inserts the auctions with a single unordered InsertMany, so MongoDB
+// keeps inserting past the documents it rejects. Auctions already ended are left out of
+// it, like CreateAuction refuses them.
+func (ar *AuctionRepository) CreateAuctions(
+	ctx context.Context,
+	auctionEntities []*auction_entity.Auction) []*internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.CreateAuctions", attribute.Int("auction_count", len(auctionEntities)))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
 
-		updateCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	errs := make([]*internal_error.InternalError, len(auctionEntities))
+	if len(auctionEntities) == 0 {
+		return errs
+	}
 
-		filter := bson.M{"_id": auctionID, "status": Active}
-		update := bson.M{
-			"$set": bson.M{
-				"status": auction_entity.AuctionStatus(Finished),
-			},
+	// indexes maps the position of each document back to its auction
+	now := ar.Clock.Now()
+	documents := make([]interface{}, 0, len(auctionEntities))
+	indexes := make([]int, 0, len(auctionEntities))
+	for i, auctionEntity := range auctionEntities {
+		auctionMongo := ar.newAuctionEntityMongo(ctx, auctionEntity)
+		if errs[i] = auctionEntity.ValidateNotEnded(now); errs[i] != nil {
+			continue
 		}
 
-		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		documents = append(documents, auctionMongo)
+		indexes = append(indexes, i)
+	}
+	if len(documents) == 0 {
+		return errs
+	}
+
+	_, err := ar.Collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
 
-		var updated AuctionEntityMongo
-		err := ar.Collection.FindOneAndUpdate(updateCtx, filter, update, opts).Decode(&updated)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				logger.Info("Auction already closed or not found, skipping auto-close")
-				return
+	var bulkErr mongo.BulkWriteException
+	switch {
+	case err == nil:
+	case errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil:
+		for _, writeErr := range bulkErr.WriteErrors {
+			i := indexes[writeErr.Index]
+			if mongo.IsDuplicateKeyError(writeErr) {
+				errs[i] = internal_error.NewConflictError("Auction already exists")
+				continue
 			}
 
-			logger.Error("Error auto-closing auction", err)
-			return
+			logger.ErrorContext(ctx, "Error trying to insert auction", writeErr, zap.String("auction_id", auctionEntities[i].Id))
+			errs[i] = internal_error.NewInternalServerError("Error trying to insert auction").Wrap(writeErr)
 		}
+	default:
+		// Which auctions made it in is unknown, so every one of them is reported as failed
+		logger.ErrorContext(ctx, "Error trying to insert auctions", err, zap.Int("auctions", len(documents)))
+		for _, i := range indexes {
+			errs[i] = internal_error.NewInternalServerError("Error trying to insert auction").Wrap(err)
+		}
+		return errs
+	}
+
+	for _, i := range indexes {
+		if errs[i] == nil {
+			metrics.AuctionsCreated.Inc()
+		}
+	}
 
-		logger.Info("Auction auto-closed")
-	}(auctionEntityMongo.Id, remaining)
+	return errs
+}
 
-	return nil
+// newAuctionEntityMongo fills in the creation defaults of auctionEntity and returns the
+// document storing it, along with the trace of the request creating it.
+func (ar *AuctionRepository) newAuctionEntityMongo(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *AuctionEntityMongo {
+	if auctionEntity.Timestamp.IsZero() {
+		auctionEntity.Timestamp = ar.Clock.Now()
+	}
+	timestamp := dbtime.From(auctionEntity.Timestamp)
+	auctionEntity.Timestamp = timestamp.UTC()
+
+	if auctionEntity.StartTime.IsZero() {
+		auctionEntity.StartTime = auctionEntity.Timestamp
+	}
+	startTime := dbtime.From(auctionEntity.StartTime)
+	auctionEntity.StartTime = startTime.UTC()
+
+	// Auctions without an explicit duration get the default one, counted from their start,
+	// resolved once here and persisted as end_time so the close path never depends on the
+	// env again
+	if auctionEntity.EndTime.IsZero() {
+		auctionEntity.EndTime = auctionEntity.StartTime.Add(ar.defaultDuration)
+	}
+	endTime := dbtime.From(auctionEntity.EndTime)
+	auctionEntity.EndTime = endTime.UTC()
+
+	return &AuctionEntityMongo{
+		Id:          auctionEntity.Id,
+		SellerId:    auctionEntity.SellerId,
+		ProductName: auctionEntity.ProductName,
+		Category:    auctionEntity.Category,
+		Description: auctionEntity.Description,
+		Condition:   auctionEntity.Condition,
+		Status:      auctionEntity.Status,
+		Timestamp:   timestamp,
+		EndTime:     endTime,
+		StartTime:   startTime,
+
+		Currency:           auctionEntity.Currency,
+		AuctionType:        auctionEntity.AuctionType,
+		StartingPriceCents: &auctionEntity.StartingPrice,
+		ReservePriceCents:  &auctionEntity.ReservePrice,
+		BuyNowPriceCents:   auctionEntity.BuyNowPrice,
+
+		Images: toAuctionImagesMongo(auctionEntity.Images),
+
+		TraceParent: tracing.TraceParent(ctx),
+	}
 }
@@ -2,16 +2,29 @@ package auction_test
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
+	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/internal/clock"
 	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
 	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/internal_error"
 
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 const (
@@ -19,7 +32,19 @@ const (
 	testCollectionName = "auctions"
 )
 
+// mongoSkipReason caches the first connection failure so the remaining tests
+// skip right away instead of waiting for the server selection timeout again.
+var mongoSkipReason string
+
+// testBounds lowers the shortest auction duration to a second, so the closer tests don't
+// wait the 30 seconds allowed by default for their auctions to end.
+var testBounds = auction_entity.DurationBounds{Min: time.Second}
+
 func setupTestDB(t *testing.T) (*mongo.Database, func()) {
+	if mongoSkipReason != "" {
+		t.Skip(mongoSkipReason)
+	}
+
 	mongoURL := os.Getenv("MONGODB_URL")
 	if mongoURL == "" {
 		mongoURL = "mongodb://localhost:27017"
@@ -30,11 +55,13 @@ func setupTestDB(t *testing.T) (*mongo.Database, func()) {
 
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
 	if err != nil {
-		t.Skipf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		t.Skip(mongoSkipReason)
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
-		t.Skipf("Skipping test: MongoDB ping failed: %v", err)
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB ping failed: %v", err)
+		t.Skip(mongoSkipReason)
 	}
 
 	database := client.Database(testDBName)
@@ -49,22 +76,57 @@ func setupTestDB(t *testing.T) (*mongo.Database, func()) {
 	return database, cleanup
 }
 
-func TestAuctionAutoClose(t *testing.T) {
-	// Set a very short auction duration for testing (3 seconds)
-	os.Setenv("AUCTION_DURATION_SECONDS", "3")
-	defer os.Unsetenv("AUCTION_DURATION_SECONDS")
+// startAuctionCloser runs the background sweeper for the duration of the test with a
+// short interval, so auctions are closed shortly after their end_time.
+// newAuctionRepository runs transactions when the test server supports them, like main
+// does.
+func newAuctionRepository(
+	database *mongo.Database, clk clock.Clock, duration time.Duration) *auction.AuctionRepository {
+	return auction.NewAuctionRepository(
+		database, clk, mongodb.NewTxRunner(context.Background(), database.Client()), duration, 0)
+}
+
+func startAuctionCloser(t *testing.T, repo *auction.AuctionRepository) {
+	closer := auction.NewAuctionCloser(repo, repo.Clock, 500*time.Millisecond, 0)
+	closer.Start(context.Background())
+	t.Cleanup(closer.Stop)
+}
 
+// waitForStatus polls the auction until it reaches status. Sweeps triggered by a fake
+// clock still run on the closer goroutine, so the test can't assume they are done.
+func waitForStatus(t *testing.T, repo *auction.AuctionRepository, id string, status auction_entity.AuctionStatus) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		found, internalErr := repo.FindAuctionById(context.Background(), id)
+		if internalErr != nil {
+			t.Fatalf("Failed to find auction: %v", internalErr.Error())
+		}
+		if found.Status == status {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected auction %s to reach status %d, got %d", id, status, found.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAuctionAutoClose(t *testing.T) {
 	database, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := auction.NewAuctionRepository(database)
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 3*time.Second)
+	startAuctionCloser(t, repo)
 
 	// Create a new auction
 	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(),
 		"Test Product",
 		"Electronics",
 		"This is a test product description for testing",
-		auction_entity.New,
+		auction_entity.New, 0,
+		testBounds, clk,
 	)
 	if err != nil {
 		t.Fatalf("Failed to create auction entity: %v", err)
@@ -88,82 +150,88 @@ func TestAuctionAutoClose(t *testing.T) {
 
 	t.Logf("Auction created with ID: %s, Status: %d", createdAuction.Id, createdAuction.Status)
 
-	// Wait for the auction to auto-close (duration + buffer)
-	t.Log("Waiting for auction to auto-close...")
-	time.Sleep(5 * time.Second)
+	// Move past the end time (duration + buffer); the closer's timer fires right away
+	clk.Advance(5 * time.Second)
 
-	// Verify auction was auto-closed
-	closedAuction, internalErr := repo.FindAuctionById(ctx, auctionEntity.Id)
-	if internalErr != nil {
-		t.Fatalf("Failed to find auction after auto-close: %v", internalErr.Error())
+	waitForStatus(t, repo, auctionEntity.Id, auction_entity.Completed)
+	t.Logf("Auction successfully auto-closed")
+}
+
+func TestAuctionDurationPersistedPerAuction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	overridden, err := auction_entity.CreateAuction(
+		uuid.New().String(),
+		"Short Product",
+		"Electronics",
+		"Auction with its own two second duration",
+		auction_entity.New,
+		2*time.Second,
+		testBounds,
+		clock.New(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err)
 	}
 
-	if closedAuction.Status != auction_entity.Completed {
-		t.Errorf("Expected auction status to be Completed (1), got %d", closedAuction.Status)
-	} else {
-		t.Logf("Auction successfully auto-closed. Status: %d (Completed)", closedAuction.Status)
+	defaulted, err := auction_entity.CreateAuction(
+		uuid.New().String(),
+		"Default Product",
+		"Electronics",
+		"Auction using the default duration",
+		auction_entity.New, 0,
+		testBounds,
+		clock.New(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err)
 	}
-}
 
-func TestAuctionDurationFromEnv(t *testing.T) {
-	testCases := []struct {
-		name            string
-		envValue        string
-		expectedMinSecs int
-		expectedMaxSecs int
-	}{
-		{
-			name:            "Custom duration 10 seconds",
-			envValue:        "10",
-			expectedMinSecs: 10,
-			expectedMaxSecs: 10,
-		},
-		{
-			name:            "Empty env uses default 600 seconds",
-			envValue:        "",
-			expectedMinSecs: 600,
-			expectedMaxSecs: 600,
-		},
-		{
-			name:            "Invalid env uses default 600 seconds",
-			envValue:        "invalid",
-			expectedMinSecs: 600,
-			expectedMaxSecs: 600,
-		},
-		{
-			name:            "Negative value uses default 600 seconds",
-			envValue:        "-5",
-			expectedMinSecs: 600,
-			expectedMaxSecs: 600,
-		},
+	for _, auctionEntity := range []*auction_entity.Auction{overridden, defaulted} {
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction in repository: %v", internalErr.Error())
+		}
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			if tc.envValue == "" {
-				os.Unsetenv("AUCTION_DURATION_SECONDS")
-			} else {
-				os.Setenv("AUCTION_DURATION_SECONDS", tc.envValue)
-			}
+	found, internalErr := repo.FindAuctionById(ctx, defaulted.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if got := found.EndTime.Sub(found.Timestamp); got != 600*time.Second {
+		t.Errorf("Expected the default duration of 600s to be persisted, got %v", got)
+	}
 
-			// We can't directly test the private function, but we can verify
-			// the behavior through the repository creation and auction flow
-			t.Logf("Test case: %s - AUCTION_DURATION_SECONDS=%s", tc.name, tc.envValue)
-		})
+	startAuctionCloser(t, repo)
+	time.Sleep(4 * time.Second)
+
+	closedAuction, internalErr := repo.FindAuctionById(ctx, overridden.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if closedAuction.Status != auction_entity.Completed {
+		t.Errorf("Expected the overridden auction to be Completed (1), got %d", closedAuction.Status)
 	}
 
-	os.Unsetenv("AUCTION_DURATION_SECONDS")
+	activeAuction, internalErr := repo.FindAuctionById(ctx, defaulted.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if activeAuction.Status != auction_entity.Active {
+		t.Errorf("Expected the default auction to still be Active (0), got %d", activeAuction.Status)
+	}
 }
 
 func TestMultipleAuctionsAutoClose(t *testing.T) {
-	// Set a short auction duration for testing (2 seconds)
-	os.Setenv("AUCTION_DURATION_SECONDS", "2")
-	defer os.Unsetenv("AUCTION_DURATION_SECONDS")
-
 	database, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := auction.NewAuctionRepository(database)
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 2*time.Second)
+	startAuctionCloser(t, repo)
 	ctx := context.Background()
 
 	// Create multiple auctions
@@ -172,10 +240,12 @@ func TestMultipleAuctionsAutoClose(t *testing.T) {
 
 	for i := 0; i < numAuctions; i++ {
 		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(),
 			"Test Product",
 			"Electronics",
 			"This is a test product description",
-			auction_entity.New,
+			auction_entity.New, 0,
+			testBounds, clk,
 		)
 		if err != nil {
 			t.Fatalf("Failed to create auction entity %d: %v", i, err)
@@ -189,41 +259,30 @@ func TestMultipleAuctionsAutoClose(t *testing.T) {
 		t.Logf("Created auction %d with ID: %s", i, auctionEntity.Id)
 	}
 
-	// Wait for all auctions to auto-close
-	t.Log("Waiting for all auctions to auto-close...")
-	time.Sleep(4 * time.Second)
+	clk.Advance(4 * time.Second)
 
 	// Verify all auctions were auto-closed
-	for i, id := range auctionIDs {
-		closedAuction, internalErr := repo.FindAuctionById(ctx, id)
-		if internalErr != nil {
-			t.Fatalf("Failed to find auction %d after auto-close: %v", i, internalErr.Error())
-		}
-
-		if closedAuction.Status != auction_entity.Completed {
-			t.Errorf("Auction %d: Expected status to be Completed (1), got %d", i, closedAuction.Status)
-		} else {
-			t.Logf("Auction %d successfully auto-closed. Status: %d", i, closedAuction.Status)
-		}
+	for _, id := range auctionIDs {
+		waitForStatus(t, repo, id, auction_entity.Completed)
 	}
 }
 
 func TestAuctionNotClosedBeforeDuration(t *testing.T) {
-	// Set auction duration for 10 seconds
-	os.Setenv("AUCTION_DURATION_SECONDS", "10")
-	defer os.Unsetenv("AUCTION_DURATION_SECONDS")
-
 	database, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := auction.NewAuctionRepository(database)
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 10*time.Second)
+	startAuctionCloser(t, repo)
 
 	// Create a new auction
 	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(),
 		"Test Product",
 		"Electronics",
 		"This is a test product description for testing",
-		auction_entity.New,
+		auction_entity.New, 0,
+		testBounds, clk,
 	)
 	if err != nil {
 		t.Fatalf("Failed to create auction entity: %v", err)
@@ -234,8 +293,11 @@ func TestAuctionNotClosedBeforeDuration(t *testing.T) {
 		t.Fatalf("Failed to create auction in repository: %v", internalErr.Error())
 	}
 
-	// Wait only 2 seconds (less than the 10 second duration)
-	time.Sleep(2 * time.Second)
+	// Move only 8 seconds ahead (less than the 10 second duration), sweeping on the way
+	for i := 0; i < 4; i++ {
+		clk.Advance(2 * time.Second)
+	}
+	time.Sleep(200 * time.Millisecond)
 
 	// Verify auction is still active
 	activeAuction, internalErr := repo.FindAuctionById(ctx, auctionEntity.Id)
@@ -251,14 +313,11 @@ func TestAuctionNotClosedBeforeDuration(t *testing.T) {
 }
 
 func TestConcurrentAuctionCreationAndClose(t *testing.T) {
-	// Set a very short auction duration for testing (1 second)
-	os.Setenv("AUCTION_DURATION_SECONDS", "1")
-	defer os.Unsetenv("AUCTION_DURATION_SECONDS")
-
 	database, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := auction.NewAuctionRepository(database)
+	repo := newAuctionRepository(database, clock.New(), time.Second)
+	startAuctionCloser(t, repo)
 	ctx := context.Background()
 
 	// Create auctions concurrently
@@ -268,10 +327,13 @@ func TestConcurrentAuctionCreationAndClose(t *testing.T) {
 	for i := 0; i < numAuctions; i++ {
 		go func(idx int) {
 			auctionEntity, err := auction_entity.CreateAuction(
+				uuid.New().String(),
 				"Concurrent Product",
 				"TestCategory",
 				"Testing concurrent auction creation",
-				auction_entity.Used,
+				auction_entity.Used, 0,
+				testBounds,
+				clock.New(),
 			)
 			if err != nil {
 				t.Errorf("Failed to create auction entity %d: %v", idx, err)
@@ -323,24 +385,1887 @@ func TestConcurrentAuctionCreationAndClose(t *testing.T) {
 	}
 }
 
-// Test to verify that the auction collection is properly set up
-func TestAuctionRepositorySetup(t *testing.T) {
+func TestAuctionCloserResumesAfterRestart(t *testing.T) {
 	database, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := auction.NewAuctionRepository(database)
+	ctx := context.Background()
+	now := time.Now()
 
-	// Verify the repository is properly initialized
-	if repo == nil {
-		t.Fatal("Repository should not be nil")
+	// Simulate auctions left behind by a previous process: one already past its
+	// end_time and one still in flight.
+	expiredAuction := auction.AuctionEntityMongo{
+		Id:          uuid.New().String(),
+		ProductName: "Expired Product",
+		Category:    "Electronics",
+		Description: "Auction that expired while the service was down",
+		Condition:   auction_entity.New,
+		Status:      auction_entity.Active,
+		Timestamp:   dbtime.From(now.Add(-10 * time.Minute)),
+		EndTime:     dbtime.From(now.Add(-time.Minute)),
+	}
+	inFlightAuction := auction.AuctionEntityMongo{
+		Id:          uuid.New().String(),
+		ProductName: "In Flight Product",
+		Category:    "Electronics",
+		Description: "Auction still running when the service restarted",
+		Condition:   auction_entity.New,
+		Status:      auction_entity.Active,
+		Timestamp:   dbtime.From(now),
+		EndTime:     dbtime.From(now.Add(3 * time.Second)),
 	}
 
-	// Verify we can perform operations on the collection
+	if _, err := database.Collection(testCollectionName).InsertMany(
+		ctx, []interface{}{expiredAuction, inFlightAuction}); err != nil {
+		t.Fatalf("Failed to insert auctions: %v", err)
+	}
+
+	// A new repository and closer against the same collection play the restarted process
+	repo := newAuctionRepository(database, clock.New(), 0)
+	startAuctionCloser(t, repo)
+	time.Sleep(time.Second)
+
+	closedAuction, internalErr := repo.FindAuctionById(ctx, expiredAuction.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find expired auction: %v", internalErr.Error())
+	}
+	if closedAuction.Status != auction_entity.Completed {
+		t.Errorf("Expected expired auction to be Completed (1), got %d", closedAuction.Status)
+	}
+
+	activeAuction, internalErr := repo.FindAuctionById(ctx, inFlightAuction.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find in flight auction: %v", internalErr.Error())
+	}
+	if activeAuction.Status != auction_entity.Active {
+		t.Errorf("Expected in flight auction to still be Active (0), got %d", activeAuction.Status)
+	}
+
+	t.Log("Waiting for the closer to pick up the in flight auction...")
+	time.Sleep(4 * time.Second)
+
+	activeAuction, internalErr = repo.FindAuctionById(ctx, inFlightAuction.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find in flight auction after resume: %v", internalErr.Error())
+	}
+	if activeAuction.Status != auction_entity.Completed {
+		t.Errorf("Expected in flight auction to be Completed (1), got %d", activeAuction.Status)
+	}
+}
+
+func TestAuctionCloserClosesThousandsInOneSweep(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), time.Second)
 	ctx := context.Background()
-	count, err := repo.Collection.CountDocuments(ctx, bson.M{})
+
+	goroutinesBefore := runtime.NumGoroutine()
+
+	numAuctions := 2000
+	for i := 0; i < numAuctions; i++ {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(),
+			"Load Product",
+			"Electronics",
+			"Auction created by the sweeper load test",
+			auction_entity.New, 0,
+			testBounds,
+			clock.New(),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity %d: %v", i, err)
+		}
+
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction %d in repository: %v", i, internalErr.Error())
+		}
+	}
+
+	// CreateAuction must not leave anything running behind per auction
+	if growth := runtime.NumGoroutine() - goroutinesBefore; growth > 5 {
+		t.Errorf("Expected no goroutine growth after creating %d auctions, got %d new goroutines",
+			numAuctions, growth)
+	}
+
+	// Wait for every auction to expire, then a single sweep must close all of them
+	time.Sleep(2 * time.Second)
+
+	closer := auction.NewAuctionCloser(repo, repo.Clock, 0, 0)
+	closer.Start(ctx)
+	time.Sleep(time.Second)
+	closer.Stop()
+
+	activeCount, err := repo.Collection.CountDocuments(ctx, bson.M{"status": auction_entity.Active})
 	if err != nil {
-		t.Fatalf("Failed to count documents: %v", err)
+		t.Fatalf("Failed to count active auctions: %v", err)
 	}
 
-	t.Logf("Initial auction count in test database: %d", count)
+	if activeCount != 0 {
+		t.Errorf("Expected all %d auctions to be closed in one sweep, %d still active", numAuctions, activeCount)
+	}
+}
+
+func TestAuctionStatusRoundTrip(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), time.Second)
+	ctx := context.Background()
+
+	for _, status := range []auction_entity.AuctionStatus{auction_entity.Active, auction_entity.Completed} {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(),
+			"Round Trip Product",
+			"Electronics",
+			"Checking the persisted status mapping",
+			auction_entity.New, 0,
+			testBounds,
+			clock.New(),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err)
+		}
+		auctionEntity.Status = status
+
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction in repository: %v", internalErr.Error())
+		}
+
+		found, internalErr := repo.FindAuctionById(ctx, auctionEntity.Id)
+		if internalErr != nil {
+			t.Fatalf("Failed to find auction: %v", internalErr.Error())
+		}
+
+		if found.Status != status {
+			t.Errorf("Expected status %d to round-trip, got %d", status, found.Status)
+		}
+	}
+
+	// The status written by the close path must be the entity's Completed constant
+	time.Sleep(2 * time.Second)
+	if _, internalErr := repo.CloseExpiredAuctions(ctx); internalErr != nil {
+		t.Fatalf("Failed to close expired auctions: %v", internalErr.Error())
+	}
+
+	var raw bson.M
+	if err := repo.Collection.FindOne(ctx, bson.M{"status": bson.M{"$ne": auction_entity.Completed}}).Decode(&raw); err != mongo.ErrNoDocuments {
+		t.Errorf("Expected every expired auction to be stored with status %d, found %v", auction_entity.Completed, raw)
+	}
+}
+
+func TestFindAuctionsPagination(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	numAuctions := 5
+	createdIds := make([]string, numAuctions)
+	for i := 0; i < numAuctions; i++ {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(),
+			"Paginated Product",
+			"Electronics",
+			"Auction used by the pagination test",
+			auction_entity.New, 0,
+			testBounds,
+			clock.New(),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity %d: %v", i, err)
+		}
+		auctionEntity.Timestamp = time.Now().Add(time.Duration(i) * time.Minute)
+
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction %d in repository: %v", i, internalErr.Error())
+		}
+		createdIds[i] = auctionEntity.Id
+	}
+
+	activeFilter := auction_entity.AuctionFilter{Statuses: []auction_entity.AuctionStatus{auction_entity.Active}}
+
+	firstPage, total, internalErr := repo.FindAuctions(ctx, activeFilter, 1, 2)
+	if internalErr != nil {
+		t.Fatalf("Failed to find first page: %v", internalErr.Error())
+	}
+
+	if total != int64(numAuctions) {
+		t.Errorf("Expected total count %d, got %d", numAuctions, total)
+	}
+	if len(firstPage) != 2 {
+		t.Fatalf("Expected 2 auctions on the first page, got %d", len(firstPage))
+	}
+	if firstPage[0].Id != createdIds[4] || firstPage[1].Id != createdIds[3] {
+		t.Errorf("Expected the newest auctions first, got %s and %s", firstPage[0].Id, firstPage[1].Id)
+	}
+
+	lastPage, _, internalErr := repo.FindAuctions(ctx, activeFilter, 3, 2)
+	if internalErr != nil {
+		t.Fatalf("Failed to find last page: %v", internalErr.Error())
+	}
+
+	if len(lastPage) != 1 || lastPage[0].Id != createdIds[0] {
+		t.Errorf("Expected only the oldest auction on the last page, got %v", lastPage)
+	}
+}
+
+func TestFindAuctionsSorts(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	// Oldest first, the newest ending first, and bid counts in neither order
+	now := time.Now()
+	bidCounts := []int{1, 3, 0}
+	ids := make([]string, len(bidCounts))
+	for i, bidCount := range bidCounts {
+		auctionEntity, _ := auction_entity.CreateAuction(
+			uuid.New().String(), "Sorted Product", "Electronics", "Auction used by the sort test", auction_entity.New, 0,
+			testBounds, clock.New())
+		auctionEntity.Timestamp = now.Add(time.Duration(i-3) * time.Hour)
+		auctionEntity.EndTime = auctionEntity.Timestamp.Add(time.Duration(10-3*i) * time.Hour)
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+		ids[i] = auctionEntity.Id
+
+		for j := 0; j < bidCount; j++ {
+			_, err := database.Collection("bids").InsertOne(ctx, bson.M{
+				"_id": uuid.New().String(), "user_id": uuid.New().String(), "auction_id": auctionEntity.Id,
+				"amount_cents": int64(100 * (j + 1)), "timestamp": now.Unix(),
+			})
+			if err != nil {
+				t.Fatalf("Failed to insert bid: %v", err)
+			}
+		}
+		// Inserted directly, the bids don't update the counter the bid repository keeps
+		if _, err := repo.Collection.UpdateOne(ctx,
+			bson.M{"_id": auctionEntity.Id}, bson.M{"$set": bson.M{"bid_count": bidCount}}); err != nil {
+			t.Fatalf("Failed to set bid count: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		sort     auction_entity.AuctionSort
+		expected []string
+	}{
+		{sort: auction_entity.SortNewest, expected: []string{ids[2], ids[1], ids[0]}},
+		{sort: auction_entity.SortOldest, expected: []string{ids[0], ids[1], ids[2]}},
+		{sort: auction_entity.SortEndingSoon, expected: []string{ids[2], ids[1], ids[0]}},
+		{sort: auction_entity.SortMostBids, expected: []string{ids[1], ids[0], ids[2]}},
+	}
+
+	for _, tc := range testCases {
+		auctions, total, internalErr := repo.FindAuctions(ctx, auction_entity.AuctionFilter{Sort: tc.sort}, 1, 10)
+		if internalErr != nil {
+			t.Fatalf("Failed to find auctions: %v", internalErr.Error())
+		}
+		if total != 3 || len(auctions) != 3 {
+			t.Fatalf("Expected the 3 auctions, got %d of %d", len(auctions), total)
+		}
+		for i := range auctions {
+			if auctions[i].Id != tc.expected[i] {
+				t.Errorf("Sort %d: expected auction %s at position %d, got %s", tc.sort, tc.expected[i], i, auctions[i].Id)
+			}
+		}
+	}
+
+	// Pages of the most bids order don't overlap
+	secondPage, _, internalErr := repo.FindAuctions(ctx, auction_entity.AuctionFilter{Sort: auction_entity.SortMostBids}, 2, 2)
+	if internalErr != nil || len(secondPage) != 1 || secondPage[0].Id != ids[2] {
+		t.Errorf("Expected only the auction without bids on the second page, got %v", secondPage)
+	}
+}
+
+func TestFindAuctionsByStatusesAndDateRange(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+	now := time.Now()
+
+	seeds := []struct {
+		category string
+		status   auction_entity.AuctionStatus
+		age      time.Duration
+	}{
+		{"Electronics", auction_entity.Completed, 2 * 24 * time.Hour},
+		{"Electronics", auction_entity.Cancelled, 3 * 24 * time.Hour},
+		{"Electronics", auction_entity.Active, 24 * time.Hour},
+		{"Electronics", auction_entity.Completed, 10 * 24 * time.Hour},
+		{"Books", auction_entity.Completed, 24 * time.Hour},
+	}
+
+	for i, seed := range seeds {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Filtered Product", seed.category, "Auction used by the filter test", auction_entity.New, 0,
+			testBounds, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity %d: %v", i, err.Error())
+		}
+		auctionEntity.Status = seed.status
+		auctionEntity.Timestamp = now.Add(-seed.age)
+		if seed.status == auction_entity.Active {
+			auctionEntity.EndTime = now.Add(time.Hour)
+		}
+
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction %d: %v", i, internalErr.Error())
+		}
+	}
+
+	// Completed or cancelled Electronics auctions from the last 7 days
+	auctions, total, internalErr := repo.FindAuctions(ctx, auction_entity.AuctionFilter{
+		Statuses:     []auction_entity.AuctionStatus{auction_entity.Completed, auction_entity.Cancelled},
+		Category:     "Electronics",
+		CreatedAfter: now.Add(-7 * 24 * time.Hour),
+	}, 1, 20)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auctions: %v", internalErr.Error())
+	}
+
+	if total != 2 || len(auctions) != 2 {
+		t.Fatalf("Expected 2 auctions, got total %d and %d results", total, len(auctions))
+	}
+	for _, found := range auctions {
+		if found.Status == auction_entity.Active || found.Category != "Electronics" {
+			t.Errorf("Unexpected auction in results: %+v", found)
+		}
+	}
+
+	// The upper bound alone only keeps the older auctions
+	_, total, internalErr = repo.FindAuctions(ctx, auction_entity.AuctionFilter{
+		CreatedBefore: now.Add(-5 * 24 * time.Hour),
+	}, 1, 20)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auctions: %v", internalErr.Error())
+	}
+	if total != 1 {
+		t.Errorf("Expected 1 auction created before 5 days ago, got %d", total)
+	}
+}
+
+func TestFindAuctionsBySellerId(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	sellerId := uuid.New().String()
+	for _, seller := range []string{sellerId, sellerId, uuid.New().String()} {
+		auctionEntity, err := auction_entity.CreateAuction(
+			seller, "Seller Product", "Electronics", "Auction used by the seller test", auction_entity.New, 0,
+			testBounds, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+	}
+
+	auctions, total, internalErr := repo.FindAuctions(ctx, auction_entity.AuctionFilter{SellerId: sellerId}, 1, 20)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auctions: %v", internalErr.Error())
+	}
+	if total != 2 || len(auctions) != 2 || auctions[0].SellerId != sellerId {
+		t.Errorf("Expected the seller's 2 auctions, got total %d: %+v", total, auctions)
+	}
+}
+
+func TestSoftDeleteAuction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Deleted Product", "Electronics", "Auction used by the soft delete test", auction_entity.New, 0,
+		testBounds, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction: %v", internalErr.Error())
+	}
+
+	if internalErr := repo.SoftDeleteAuction(ctx, auctionEntity.Id); internalErr != nil {
+		t.Fatalf("Failed to delete auction: %v", internalErr.Error())
+	}
+	if internalErr := repo.SoftDeleteAuction(ctx, auctionEntity.Id); internalErr == nil || internalErr.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found deleting the auction twice, got %v", internalErr)
+	}
+
+	if _, internalErr := repo.FindAuctionById(ctx, auctionEntity.Id); internalErr == nil || internalErr.Err != internal_error.NotFound {
+		t.Errorf("Expected the deleted auction to be not_found, got %v", internalErr)
+	}
+	if _, total, _ := repo.FindAuctions(ctx, auction_entity.AuctionFilter{}, 1, 20); total != 0 {
+		t.Errorf("Expected the deleted auction to be left out of listings, got %d", total)
+	}
+
+	adminCtx := auction_entity.WithDeleted(ctx)
+	found, internalErr := repo.FindAuctionById(adminCtx, auctionEntity.Id)
+	if internalErr != nil || found.DeletedAt == nil {
+		t.Errorf("Expected the deleted auction with deleted_at when including deleted ones, got %+v, %v", found, internalErr)
+	}
+	if _, total, _ := repo.FindAuctions(adminCtx, auction_entity.AuctionFilter{}, 1, 20); total != 1 {
+		t.Errorf("Expected the deleted auction to be listed when including deleted ones, got %d", total)
+	}
+}
+
+func TestNormalizeCategories(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	categories := []string{"electronics", " ELECTRONICS", "Home  appliances", "eletronics", "Books"}
+	ids := make(map[string]string)
+	for _, category := range categories {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Category Product", category, "Auction used by the category backfill", auction_entity.New, 0,
+			testBounds, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+		ids[category] = auctionEntity.Id
+	}
+
+	managed := []category_entity.Category{{Name: "Electronics"}, {Name: "Home Appliances"}, {Name: "Books"}}
+	normalized, internalErr := repo.NormalizeCategories(ctx, managed)
+	if internalErr != nil {
+		t.Fatalf("Failed to normalize categories: %v", internalErr.Error())
+	}
+	if normalized != 3 {
+		t.Errorf("Expected 3 auctions to be normalized, got %d", normalized)
+	}
+
+	expected := map[string]string{
+		"electronics":      "Electronics",
+		" ELECTRONICS":     "Electronics",
+		"Home  appliances": "Home Appliances",
+		"eletronics":       "eletronics",
+		"Books":            "Books",
+	}
+	for original, want := range expected {
+		found, internalErr := repo.FindAuctionById(ctx, ids[original])
+		if internalErr != nil {
+			t.Fatalf("Failed to find auction: %v", internalErr.Error())
+		}
+		if found.Category != want {
+			t.Errorf("Expected %q to become %q, got %q", original, want, found.Category)
+		}
+	}
+
+	if normalized, _ := repo.NormalizeCategories(ctx, managed); normalized != 0 {
+		t.Errorf("Expected a second run to change nothing, got %d", normalized)
+	}
+}
+
+func TestFindAuctionsByProductNameQuery(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	for _, productName := range []string{"iPhone 13", "Used IPHONE case", "C++ book", "50% off blender", "Cbook"} {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), productName, "Electronics", "Auction used by the search test", auction_entity.New, 0,
+			testBounds, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+	}
+
+	testCases := []struct {
+		query    string
+		expected int64
+	}{
+		{"iphone", 2},
+		{"IPHONE 13", 1},
+		{"C++ book", 1},
+		{"c++", 1},
+		{"50% off", 1},
+		{".*", 0},
+		{"phone|book", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			auctions, total, internalErr := repo.FindAuctions(
+				ctx, auction_entity.AuctionFilter{ProductName: tc.query}, 1, 20)
+			if internalErr != nil {
+				t.Fatalf("Failed to find auctions: %v", internalErr.Error())
+			}
+
+			if total != tc.expected || int64(len(auctions)) != tc.expected {
+				t.Errorf("Expected %d auctions for %q, got total %d and %d results",
+					tc.expected, tc.query, total, len(auctions))
+			}
+		})
+	}
+}
+
+func TestGetFacetsCountsAndCaches(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 0)
+	repo.FacetsCacheTTL = 5 * time.Second
+	ctx := context.Background()
+
+	create := func(productName, category string, condition auction_entity.ProductCondition) {
+		t.Helper()
+
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), productName, category, "Auction used by the facets test", condition, 0,
+			testBounds, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+	}
+	create("iPhone 13", "Electronics", auction_entity.Used)
+	create("iPhone 14", "Electronics", auction_entity.New)
+	create("iPhone Case", "Accessories", auction_entity.Used)
+	create("Go Book", "Books", auction_entity.New)
+
+	filter := auction_entity.AuctionFilter{
+		Statuses: []auction_entity.AuctionStatus{auction_entity.Active}, ProductName: "iphone"}
+	facets, internalErr := repo.GetFacets(ctx, filter)
+	if internalErr != nil {
+		t.Fatalf("Failed to get facets: %v", internalErr.Error())
+	}
+
+	expected := &auction_entity.AuctionFacets{
+		Categories: []auction_entity.CategoryCount{{Category: "Electronics", Count: 2}, {Category: "Accessories", Count: 1}},
+		Conditions: []auction_entity.ConditionCount{
+			{Condition: auction_entity.Used, Count: 2}, {Condition: auction_entity.New, Count: 1}},
+	}
+	if fmt.Sprint(facets) != fmt.Sprint(expected) {
+		t.Errorf("Expected facets %v, got %v", expected, facets)
+	}
+
+	// Within the ttl the same filter answers from the cache, other filters don't
+	create("iPhone 15", "Electronics", auction_entity.New)
+	if cached, _ := repo.GetFacets(ctx, filter); fmt.Sprint(cached) != fmt.Sprint(expected) {
+		t.Errorf("Expected the cached facets %v, got %v", expected, cached)
+	}
+	all, _ := repo.GetFacets(ctx, auction_entity.AuctionFilter{})
+	if len(all.Categories) != 3 || all.Categories[0].Count != 3 {
+		t.Errorf("Expected 3 categories with 3 Electronics auctions without a filter, got %v", all)
+	}
+
+	clk.Advance(5 * time.Second)
+	refreshed, _ := repo.GetFacets(ctx, filter)
+	if refreshed.Categories[0].Count != 3 {
+		t.Errorf("Expected 3 Electronics auctions once the cache expired, got %v", refreshed)
+	}
+}
+
+func TestExtendAuctionEndTime(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	closing, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Sniped Product", "Electronics", "Auction about to close", auction_entity.New, 5*time.Second,
+		testBounds, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	far, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Quiet Product", "Electronics", "Auction far from closing", auction_entity.New, time.Hour,
+		testBounds, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	for _, auctionEntity := range []*auction_entity.Auction{closing, far} {
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+	}
+
+	// A wide window keeps the auction inside it after each extension, so only the cap
+	// stops them: 30s, then the remaining 15s, then nothing
+	window, extension, maxExtension := time.Hour-time.Minute, 30*time.Second, 45*time.Second
+	originalEnd := closing.EndTime.Unix()
+
+	for i, expected := range []int64{30, 45, 45} {
+		endTime, extended, internalErr := repo.ExtendAuctionEndTime(ctx, closing.Id, window, extension, maxExtension)
+		if internalErr != nil {
+			t.Fatalf("Failed to extend auction: %v", internalErr.Error())
+		}
+
+		if extended != (i < 2) {
+			t.Errorf("Extension %d: expected extended=%v, got %v", i, i < 2, extended)
+		}
+
+		stored, internalErr := repo.FindAuctionById(ctx, closing.Id)
+		if internalErr != nil {
+			t.Fatalf("Failed to find auction: %v", internalErr.Error())
+		}
+		if stored.EndTime.Unix() != originalEnd+expected {
+			t.Errorf("Extension %d: expected end_time %d, got %d", i, originalEnd+expected, stored.EndTime.Unix())
+		}
+		if extended && !endTime.Equal(stored.EndTime) {
+			t.Errorf("Extension %d: expected the returned end time to match the stored one", i)
+		}
+	}
+
+	// Outside the window nothing changes
+	_, extended, internalErr := repo.ExtendAuctionEndTime(ctx, far.Id, 10*time.Second, extension, maxExtension)
+	if internalErr != nil {
+		t.Fatalf("Failed to extend auction: %v", internalErr.Error())
+	}
+	if extended {
+		t.Error("Expected an auction outside the window not to be extended")
+	}
+
+	// Closed auctions are never extended
+	if internalErr := repo.UpdateAuctionStatus(ctx, closing.Id, 0, auction_entity.Active, auction_entity.Cancelled); internalErr != nil {
+		t.Fatalf("Failed to cancel auction: %v", internalErr.Error())
+	}
+	_, extended, internalErr = repo.ExtendAuctionEndTime(ctx, closing.Id, window, extension, time.Hour)
+	if internalErr != nil {
+		t.Fatalf("Failed to extend auction: %v", internalErr.Error())
+	}
+	if extended {
+		t.Error("Expected a cancelled auction not to be extended")
+	}
+}
+
+func TestCloseExpiredAuctionsDecidesOutcome(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 0)
+	ctx := context.Background()
+
+	newAuction := func(reservePrice int64, bidAmounts ...int64) string {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Auction used by the outcome test", auction_entity.New, time.Minute,
+			testBounds, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if err := auctionEntity.SetPrices(1000, reservePrice); err != nil {
+			t.Fatalf("Failed to set prices: %v", err.Error())
+		}
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+
+		for _, amount := range bidAmounts {
+			_, err := database.Collection("bids").InsertOne(ctx, bson.M{
+				"_id": uuid.New().String(), "user_id": uuid.New().String(),
+				"auction_id": auctionEntity.Id, "amount_cents": amount, "timestamp": time.Now().Unix(),
+			})
+			if err != nil {
+				t.Fatalf("Failed to insert bid: %v", err)
+			}
+		}
+
+		return auctionEntity.Id
+	}
+
+	expected := map[string]auction_entity.AuctionOutcome{
+		newAuction(10000, 5000, 10000): auction_entity.Sold,
+		newAuction(10000, 5000, 9950):  auction_entity.ReserveNotMet,
+		newAuction(0, 1000):            auction_entity.Sold,
+		newAuction(10000):              auction_entity.NoBids,
+	}
+
+	clk.Advance(2 * time.Minute)
+	closedIds, internalErr := repo.CloseExpiredAuctions(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+	if len(closedIds) != len(expected) {
+		t.Errorf("Expected %d auctions closed, got %d", len(expected), len(closedIds))
+	}
+
+	for id, outcome := range expected {
+		found, internalErr := repo.FindAuctionById(ctx, id)
+		if internalErr != nil {
+			t.Fatalf("Failed to find auction: %v", internalErr.Error())
+		}
+		if found.Status != auction_entity.Completed || found.Outcome != outcome {
+			t.Errorf("Auction %s: expected Completed with outcome %d, got %d with outcome %d",
+				id, outcome, found.Status, found.Outcome)
+		}
+		if found.ClosedAt == nil || (found.WinningBid == nil) != (outcome == auction_entity.NoBids) {
+			t.Errorf("Auction %s: unexpected winner snapshot %+v closed at %v", id, found.WinningBid, found.ClosedAt)
+		}
+	}
+}
+
+func TestCloseFailuresAreRetriedLater(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, time.Minute)
+	repo.CloseRetryInterval = 10 * time.Minute
+	ctx := context.Background()
+
+	var auctionIds []string
+	for i := 0; i < 2; i++ {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Auction used by the close failure test", auction_entity.New, 0,
+			testBounds, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+		auctionIds = append(auctionIds, auctionEntity.Id)
+	}
+	goodId, badId := auctionIds[0], auctionIds[1]
+
+	// A validator rejecting the close of one auction stands for a document that can't
+	// be updated
+	setValidator := func(validator bson.M) {
+		err := database.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: testCollectionName}, {Key: "validator", Value: validator},
+		}).Err()
+		if err != nil {
+			t.Fatalf("Failed to set the auctions validator: %v", err)
+		}
+	}
+	setValidator(bson.M{"$or": bson.A{
+		bson.M{"_id": bson.M{"$ne": badId}},
+		bson.M{"status": bson.M{"$ne": auction_entity.Completed}},
+	}})
+
+	clk.Advance(2 * time.Minute)
+	closedIds, internalErr := repo.CloseExpiredAuctions(ctx)
+	if internalErr != nil {
+		t.Fatalf("Expected the other auctions to close despite the failure, got %v", internalErr.Error())
+	}
+	if len(closedIds) != 1 || closedIds[0] != goodId {
+		t.Errorf("Expected only %s to close, got %v", goodId, closedIds)
+	}
+
+	failures, internalErr := repo.FindCloseFailures(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to find close failures: %v", internalErr.Error())
+	}
+	if len(failures) != 1 || failures[0].AuctionId != badId || failures[0].Attempts != 1 || failures[0].Error == "" {
+		t.Fatalf("Expected one recorded failure for %s, got %+v", badId, failures)
+	}
+
+	// Fixed, the auction still waits for its retry instead of closing on the next sweep
+	setValidator(bson.M{})
+	if closedIds, _ := repo.CloseExpiredAuctions(ctx); len(closedIds) != 0 {
+		t.Errorf("Expected the failed auction to wait for its retry, got %v closed", closedIds)
+	}
+
+	if internalErr := repo.RetryClose(ctx, badId); internalErr != nil {
+		t.Fatalf("Failed to retry the close: %v", internalErr.Error())
+	}
+	found, internalErr := repo.FindAuctionById(ctx, badId)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if found.Status != auction_entity.Completed {
+		t.Errorf("Expected the retried auction to be Completed, got %d", found.Status)
+	}
+
+	if failures, _ := repo.FindCloseFailures(ctx); len(failures) != 0 {
+		t.Errorf("Expected the failure to be cleared once closed, got %+v", failures)
+	}
+	if internalErr := repo.RetryClose(ctx, badId); internalErr == nil || internalErr.Err != internal_error.NotFound {
+		t.Errorf("Expected a not found error without a recorded failure, got %v", internalErr)
+	}
+}
+
+func TestCloseExpiredAuctionsSkipsAuctionsClosedMeanwhile(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, time.Minute)
+	ctx := context.Background()
+
+	var auctionIds []string
+	for i := 0; i < 2; i++ {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Auction used by the concurrent close test", auction_entity.New, 0,
+			testBounds, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+		auctionIds = append(auctionIds, auctionEntity.Id)
+	}
+	ownId, otherId := auctionIds[0], auctionIds[1]
+
+	// Another instance closes one of the auctions between the lookup and the update
+	repo.FlushBids = func(ctx context.Context) error {
+		_, err := repo.Collection.UpdateOne(ctx,
+			bson.M{"_id": otherId}, bson.M{"$set": bson.M{"status": auction_entity.Completed}})
+		return err
+	}
+
+	clk.Advance(2 * time.Minute)
+	closedIds, internalErr := repo.CloseExpiredAuctions(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+	if len(closedIds) != 1 || closedIds[0] != ownId {
+		t.Errorf("Expected only %s to be reported closed, got %v", ownId, closedIds)
+	}
+
+	for id, expected := range map[string]int{ownId: 1, otherId: 0} {
+		audit, internalErr := repo.FindAuditByAuctionId(ctx, id)
+		if internalErr != nil {
+			t.Fatalf("Failed to find audit: %v", internalErr.Error())
+		}
+		if closes := countCloses(audit); closes != expected {
+			t.Errorf("Expected %d close audited for %s, got %d", expected, id, closes)
+		}
+	}
+}
+
+// countCloses counts the entries of audit closing the auction.
+func countCloses(audit []auction_entity.AuctionAudit) int {
+	closes := 0
+	for _, entry := range audit {
+		if entry.To == auction_entity.Completed {
+			closes++
+		}
+	}
+
+	return closes
+}
+
+func TestCloseExpiredAuctionsRecordsWinnerSnapshot(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 0)
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Auction used by the snapshot test", auction_entity.New, time.Minute,
+		testBounds, clk)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction: %v", internalErr.Error())
+	}
+
+	// Two bids of the same amount: the earliest one wins
+	winnerId, laterId := uuid.New().String(), uuid.New().String()
+	bidTime := time.Now().Unix()
+	for i, bidId := range []string{laterId, winnerId} {
+		_, err := database.Collection("bids").InsertOne(ctx, bson.M{
+			"_id": bidId, "user_id": bidId, "auction_id": auctionEntity.Id,
+			"amount_cents": int64(2500), "currency": "USD", "timestamp": bidTime - int64(i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert bid: %v", err)
+		}
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, internalErr := repo.CloseExpiredAuctions(ctx); internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+
+	// Bids changed after the close don't change the recorded winner
+	if _, err := database.Collection("bids").DeleteMany(ctx, bson.M{"auction_id": auctionEntity.Id}); err != nil {
+		t.Fatalf("Failed to delete bids: %v", err)
+	}
+
+	found, internalErr := repo.FindAuctionById(ctx, auctionEntity.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if found.ClosedAt == nil || !found.ClosedAt.Equal(clk.Now().Truncate(time.Millisecond)) {
+		t.Errorf("Expected closed_at %v, got %v", clk.Now(), found.ClosedAt)
+	}
+	expected := auction_entity.WinningBid{
+		BidId: winnerId, UserId: winnerId, Amount: 2500, Currency: "USD", Timestamp: time.Unix(bidTime-1, 0).UTC(),
+	}
+	if found.WinningBid == nil || *found.WinningBid != expected {
+		t.Errorf("Expected winner snapshot %+v, got %+v", expected, found.WinningBid)
+	}
+}
+
+func TestFindClosedAuctionsJoinsWinnerName(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 0)
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Auction used by the closed event test", auction_entity.New, time.Minute,
+		testBounds, clk)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction: %v", internalErr.Error())
+	}
+
+	winnerId := uuid.New().String()
+	if _, err := database.Collection("users").InsertOne(ctx, bson.M{"_id": winnerId, "name": "Ana"}); err != nil {
+		t.Fatalf("Failed to insert user: %v", err)
+	}
+	_, insertErr := database.Collection("bids").InsertOne(ctx, bson.M{
+		"_id": uuid.New().String(), "user_id": winnerId, "auction_id": auctionEntity.Id,
+		"amount_cents": int64(2500), "currency": "USD", "timestamp": time.Now().Unix(),
+	})
+	if insertErr != nil {
+		t.Fatalf("Failed to insert bid: %v", insertErr)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, internalErr := repo.CloseExpiredAuctions(ctx); internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+
+	closedAuctions, internalErr := repo.FindClosedAuctions(ctx, []string{uuid.New().String(), auctionEntity.Id})
+	if internalErr != nil {
+		t.Fatalf("Failed to find closed auctions: %v", internalErr.Error())
+	}
+	if len(closedAuctions) != 1 || closedAuctions[0].Id != auctionEntity.Id {
+		t.Fatalf("Expected only the closed auction, got %+v", closedAuctions)
+	}
+
+	closed := closedAuctions[0]
+	if closed.Outcome != auction_entity.Sold || closed.WinningBid == nil || closed.WinningBid.UserId != winnerId {
+		t.Errorf("Expected the winner snapshot of user %s, got %+v", winnerId, closed.WinningBid)
+	}
+	if closed.WinnerName != "Ana" {
+		t.Errorf("Expected the winner name Ana, got %q", closed.WinnerName)
+	}
+}
+
+func TestCloseExpiredAuctionsLinksToCreatingTrace(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 0)
+
+	// The auction is created inside a request span, as the gin middleware does
+	requestCtx, requestSpan := provider.Tracer("test").Start(context.Background(), "POST /auction")
+	auctionEntity, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Auction used by the tracing test", auction_entity.New, time.Minute,
+		testBounds, clk)
+	if internalErr := repo.CreateAuction(requestCtx, auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction: %v", internalErr.Error())
+	}
+	requestSpan.End()
+
+	clk.Advance(2 * time.Minute)
+	if _, internalErr := repo.CloseExpiredAuctions(context.Background()); internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+
+	var createSpan, closeSpan *tracetest.SpanStub
+	spans := exporter.GetSpans()
+	for i := range spans {
+		switch spans[i].Name {
+		case "AuctionRepository.CreateAuction":
+			createSpan = &spans[i]
+		case "AuctionRepository.closeAuctions":
+			closeSpan = &spans[i]
+		}
+	}
+	if createSpan == nil || closeSpan == nil {
+		t.Fatalf("Expected the create and close spans to be recorded, got %d spans", len(spans))
+	}
+
+	if createSpan.Parent.SpanID() != requestSpan.SpanContext().SpanID() {
+		t.Error("Expected the create span to be a child of the request span")
+	}
+	if closeSpan.SpanContext.TraceID() == requestSpan.SpanContext().TraceID() {
+		t.Error("Expected the close to run in a trace of its own")
+	}
+
+	linked := false
+	for _, link := range closeSpan.Links {
+		linked = linked || link.SpanContext.SpanID() == createSpan.SpanContext.SpanID()
+	}
+	if !linked {
+		t.Errorf("Expected the close span to link to the creating trace, got %d links", len(closeSpan.Links))
+	}
+}
+
+func TestSellerChangesCompareVersions(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), time.Minute)
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(),
+		"Versioned Product",
+		"Electronics",
+		"Auction changed by two requests at once",
+		auction_entity.New, 0,
+		testBounds,
+		clock.New(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err)
+	}
+	if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction in repository: %v", internalErr.Error())
+	}
+
+	images := []auction_entity.AuctionImage{{URL: "https://example.com/front.jpg", Order: 1}}
+	updated, internalErr := repo.ReplaceAuctionImages(ctx, auctionEntity.Id, 0, images)
+	if internalErr != nil {
+		t.Fatalf("Expected the images to be replaced at version 0, got %v", internalErr.Error())
+	}
+	if updated.Version != 1 {
+		t.Errorf("Expected the version to go up to 1, got %d", updated.Version)
+	}
+
+	if _, internalErr := repo.ReplaceAuctionImages(ctx, auctionEntity.Id, 0, images); internalErr != internal_error.ErrAuctionModified {
+		t.Errorf("Expected ErrAuctionModified replacing images at a stale version, got %v", internalErr)
+	}
+	if internalErr := repo.UpdateAuctionStatus(
+		ctx, auctionEntity.Id, 0, auction_entity.Active, auction_entity.Cancelled); internalErr != internal_error.ErrAuctionModified {
+		t.Errorf("Expected ErrAuctionModified cancelling at a stale version, got %v", internalErr)
+	}
+	if internalErr := repo.UpdateAuctionStatus(
+		ctx, auctionEntity.Id, 1, auction_entity.Active, auction_entity.Cancelled); internalErr != nil {
+		t.Errorf("Expected the cancel at the current version to succeed, got %v", internalErr.Error())
+	}
+
+	// Auctions stored before versions existed are at version 0
+	legacyId := uuid.New().String()
+	_, insertErr := repo.Collection.InsertOne(ctx, bson.M{
+		"_id": legacyId, "product_name": "Legacy Product", "category": "Electronics",
+		"description": "Auction stored without a version", "condition": auction_entity.Used,
+		"status": auction_entity.Active, "timestamp": time.Now().Unix(), "end_time": time.Now().Add(time.Hour),
+	})
+	if insertErr != nil {
+		t.Fatalf("Failed to insert legacy auction: %v", insertErr)
+	}
+	if legacy, internalErr := repo.ReplaceAuctionImages(ctx, legacyId, 0, images); internalErr != nil || legacy.Version != 1 {
+		t.Errorf("Expected the legacy auction to move from version 0 to 1, got %+v, %v", legacy, internalErr)
+	}
+}
+
+func TestBackfillEndTimes(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	// Auctions written before end_time existed, one still running and one long over
+	now := time.Now()
+	recentId, expiredId := uuid.New().String(), uuid.New().String()
+	for id, timestamp := range map[string]time.Time{recentId: now, expiredId: now.Add(-time.Hour)} {
+		_, err := repo.Collection.InsertOne(ctx, bson.M{
+			"_id": id, "product_name": "Legacy Product", "category": "Electronics",
+			"description": "Auction stored without an end time", "condition": auction_entity.Used,
+			"status": auction_entity.Active, "timestamp": timestamp.Unix(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert legacy auction: %v", err)
+		}
+	}
+
+	backfilled, internalErr := repo.BackfillEndTimes(ctx, 10*time.Minute)
+	if internalErr != nil {
+		t.Fatalf("Failed to backfill end times: %v", internalErr.Error())
+	}
+	if backfilled != 2 {
+		t.Errorf("Expected 2 auctions to be backfilled, got %d", backfilled)
+	}
+
+	recent, internalErr := repo.FindAuctionById(ctx, recentId)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if recent.Status != auction_entity.Active || recent.EndTime.Unix() != now.Add(10*time.Minute).Unix() {
+		t.Errorf("Expected an Active auction ending in 10 minutes, got %v ending at %v", recent.Status, recent.EndTime)
+	}
+
+	expired, internalErr := repo.FindAuctionById(ctx, expiredId)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if expired.Status != auction_entity.Completed {
+		t.Errorf("Expected the expired auction to be closed, got %v", expired.Status)
+	}
+
+	if backfilled, _ := repo.BackfillEndTimes(ctx, 10*time.Minute); backfilled != 0 {
+		t.Errorf("Expected a second run to change nothing, got %d", backfilled)
+	}
+}
+
+func TestBackfillTimestampsConvertsAuctionTimes(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	// Auctions written while their times were Unix seconds: one that ended a minute ago
+	// and one from before end_time existed, which stored a zero
+	now := time.Now().Truncate(time.Second)
+	expiredId, withoutEndId := uuid.New().String(), uuid.New().String()
+	for id, endTime := range map[string]int64{expiredId: now.Add(-time.Minute).Unix(), withoutEndId: 0} {
+		_, err := repo.Collection.InsertOne(ctx, bson.M{
+			"_id": id, "product_name": "Legacy Product", "category": "Electronics",
+			"description": "Auction stored with times in seconds", "condition": auction_entity.Used,
+			"status": auction_entity.Active, "timestamp": now.Add(-time.Hour).Unix(),
+			"start_time": now.Add(-time.Hour).Unix(), "end_time": endTime, "last_bid_at": now.Add(-2 * time.Minute).Unix(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert legacy auction: %v", err)
+		}
+	}
+
+	if _, internalErr := repo.BackfillTimestamps(ctx); internalErr != nil {
+		t.Fatalf("Failed to backfill timestamps: %v", internalErr.Error())
+	}
+
+	var stored bson.M
+	if err := repo.Collection.FindOne(ctx, bson.M{"_id": expiredId}).Decode(&stored); err != nil {
+		t.Fatalf("Failed to read the legacy auction: %v", err)
+	}
+	for _, field := range []string{"timestamp", "start_time", "end_time", "last_bid_at"} {
+		if _, ok := stored[field].(primitive.DateTime); !ok {
+			t.Errorf("Expected %s to be stored as a date, got %T", field, stored[field])
+		}
+	}
+	if err := repo.Collection.FindOne(ctx, bson.M{"_id": withoutEndId}).Decode(&stored); err != nil {
+		t.Fatalf("Failed to read the legacy auction: %v", err)
+	}
+	if stored["end_time"] != nil {
+		t.Errorf("Expected a zero end_time to become null, got %T %v", stored["end_time"], stored["end_time"])
+	}
+
+	// The closer only matches dates, so it finds the expired auction once converted
+	closedIds, internalErr := repo.CloseExpiredAuctions(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+	if len(closedIds) != 1 || closedIds[0] != expiredId {
+		t.Errorf("Expected only the expired auction to close, got %v", closedIds)
+	}
+
+	found, internalErr := repo.FindAuctionById(ctx, expiredId)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if !found.EndTime.Equal(now.Add(-time.Minute)) || !found.StartTime.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected the auction to run from %v to %v, got %v to %v",
+			now.Add(-time.Hour), now.Add(-time.Minute), found.StartTime, found.EndTime)
+	}
+
+	if backfilled, _ := repo.BackfillTimestamps(ctx); backfilled != 0 {
+		t.Errorf("Expected a second backfill to change nothing, got %d", backfilled)
+	}
+}
+
+func TestLegacyFloatPricesReadAsCents(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	// An auction written before prices moved to cents
+	auctionId := uuid.New().String()
+	_, err := repo.Collection.InsertOne(ctx, bson.M{
+		"_id": auctionId, "product_name": "Legacy Product", "category": "Electronics",
+		"description": "Auction stored with float prices", "condition": auction_entity.Used,
+		"status": auction_entity.Active, "timestamp": time.Now().Unix(), "end_time": time.Now().Add(time.Hour),
+		"starting_price": 10.1, "reserve_price": 99.99,
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert legacy auction: %v", err)
+	}
+
+	found, internalErr := repo.FindAuctionById(ctx, auctionId)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if found.StartingPrice != 1010 || found.ReservePrice != 9999 {
+		t.Errorf("Expected prices of 1010 and 9999 cents, got %d and %d", found.StartingPrice, found.ReservePrice)
+	}
+}
+
+func TestCancelAuction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), time.Second)
+	startAuctionCloser(t, repo)
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(),
+		"Cancelled Product",
+		"Electronics",
+		"Auction cancelled by the seller",
+		auction_entity.New, 0,
+		testBounds,
+		clock.New(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err)
+	}
+
+	if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction in repository: %v", internalErr.Error())
+	}
+
+	if internalErr := repo.UpdateAuctionStatus(
+		ctx, auctionEntity.Id, 0, auction_entity.Active, auction_entity.Cancelled); internalErr != nil {
+		t.Fatalf("Failed to cancel auction: %v", internalErr.Error())
+	}
+
+	internalErr := repo.UpdateAuctionStatus(ctx, auctionEntity.Id, 1, auction_entity.Active, auction_entity.Cancelled)
+	if internalErr == nil || internalErr.Err != "conflict" {
+		t.Errorf("Expected a conflict when cancelling twice, got %v", internalErr)
+	}
+
+	internalErr = repo.UpdateAuctionStatus(ctx, uuid.New().String(), 0, auction_entity.Active, auction_entity.Cancelled)
+	if internalErr == nil || internalErr.Err != "not_found" {
+		t.Errorf("Expected not_found for an unknown auction, got %v", internalErr)
+	}
+
+	// The sweeper must not turn the cancelled auction into a Completed one
+	time.Sleep(3 * time.Second)
+
+	cancelledAuction, internalErr := repo.FindAuctionById(ctx, auctionEntity.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if cancelledAuction.Status != auction_entity.Cancelled {
+		t.Errorf("Expected auction status to stay Cancelled (2), got %d", cancelledAuction.Status)
+	}
+}
+
+// Test to verify that the auction collection is properly set up
+func TestAuctionCloserShutdownPersistsExpiredAuction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	// A long interval leaves the expiry to be caught by the shutdown sweep alone
+	closer := auction.NewAuctionCloser(repo, repo.Clock, time.Hour, 0)
+	closer.Start(ctx)
+
+	expiring, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Shutdown Product", "Electronics", "Created just before shutdown", auction_entity.New, time.Second,
+		testBounds, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	running, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Running Product", "Electronics", "Still running at shutdown", auction_entity.New, time.Hour,
+		testBounds, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+
+	for _, auctionEntity := range []*auction_entity.Auction{expiring, running} {
+		if err := repo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := closer.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	expired, findErr := repo.FindAuctionById(ctx, expiring.Id)
+	if findErr != nil {
+		t.Fatalf("Failed to find auction: %v", findErr.Error())
+	}
+	if expired.Status != auction_entity.Completed {
+		t.Errorf("Expected the expired auction to be Completed after shutdown, got %v", expired.Status)
+	}
+
+	stillRunning, findErr := repo.FindAuctionById(ctx, running.Id)
+	if findErr != nil {
+		t.Fatalf("Failed to find auction: %v", findErr.Error())
+	}
+	if stillRunning.Status != auction_entity.Active {
+		t.Errorf("Expected the running auction to stay Active after shutdown, got %v", stillRunning.Status)
+	}
+}
+
+func TestChangeStreamCloserClosesAuctionsAtEndTime(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Change streams need a replica set member
+	var hello bson.M
+	if err := database.Client().Database("admin").RunCommand(ctx, bson.M{"hello": 1}).Decode(&hello); err != nil || hello["setName"] == nil {
+		t.Skip("Skipping test: MongoDB is not running as a replica set")
+	}
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+
+	// Left behind already expired, before the closer started
+	expired, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Expired Product", "Electronics", "Expired before the watcher started", auction_entity.New, time.Second,
+		testBounds, clock.New())
+	if err := repo.CreateAuction(ctx, expired); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+	time.Sleep(1500 * time.Millisecond)
+
+	// A long interval leaves the closes to the end times seen on the stream
+	closer := auction.NewChangeStreamCloser(repo, repo.Clock, time.Hour)
+	closer.Start(ctx)
+	defer closer.Stop()
+	time.Sleep(500 * time.Millisecond)
+
+	created, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Watched Product", "Electronics", "Created while the watcher runs", auction_entity.New, 2*time.Second,
+		testBounds, clock.New())
+	extended, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Extended Product", "Electronics", "Extended while the watcher runs", auction_entity.New, 2*time.Second,
+		testBounds, clock.New())
+	for _, auctionEntity := range []*auction_entity.Auction{created, extended} {
+		if err := repo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+	}
+	if _, err := repo.Collection.UpdateOne(ctx, bson.M{"_id": extended.Id},
+		bson.M{"$set": bson.M{"end_time": time.Now().Add(time.Hour)}}); err != nil {
+		t.Fatalf("Failed to extend auction: %v", err)
+	}
+
+	time.Sleep(3500 * time.Millisecond)
+
+	expectedStatuses := map[string]auction_entity.AuctionStatus{
+		expired.Id:  auction_entity.Completed,
+		created.Id:  auction_entity.Completed,
+		extended.Id: auction_entity.Active,
+	}
+	for id, expected := range expectedStatuses {
+		found, findErr := repo.FindAuctionById(ctx, id)
+		if findErr != nil {
+			t.Fatalf("Failed to find auction: %v", findErr.Error())
+		}
+		if found.Status != expected {
+			t.Errorf("Expected auction %s to be %v, got %v", id, expected, found.Status)
+		}
+	}
+
+	// The token of the last event is kept for the next start
+	count, err := database.Collection("auction_close_watcher").CountDocuments(ctx, bson.M{"token": bson.M{"$exists": true}})
+	if err != nil || count != 1 {
+		t.Errorf("Expected the resume token to be saved, got %d documents (%v)", count, err)
+	}
+}
+
+// sharedLease is a single lease shared by the closers of a test, standing in for the
+// locks collection. Each closer asks for it through its own owner.
+type sharedLease struct {
+	mu        sync.Mutex
+	clock     *clock.Fake
+	owner     string
+	expiresAt time.Time
+	failing   map[string]bool
+}
+
+type leaseOwner struct {
+	lease *sharedLease
+	name  string
+}
+
+func (lo leaseOwner) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, *internal_error.InternalError) {
+	lo.lease.mu.Lock()
+	defer lo.lease.mu.Unlock()
+
+	if lo.lease.failing[lo.name] {
+		return false, internal_error.NewInternalServerError("database unavailable")
+	}
+
+	now := lo.lease.clock.Now()
+	if lo.lease.owner != lo.name && now.Before(lo.lease.expiresAt) {
+		return false, nil
+	}
+	lo.lease.owner, lo.lease.expiresAt = lo.name, now.Add(ttl)
+
+	return true, nil
+}
+
+func (lo leaseOwner) Release(ctx context.Context, name string) *internal_error.InternalError {
+	lo.lease.mu.Lock()
+	defer lo.lease.mu.Unlock()
+
+	if lo.lease.owner == lo.name {
+		lo.lease.owner, lo.lease.expiresAt = "", time.Time{}
+	}
+
+	return nil
+}
+
+// countingCloser counts how often it is started and stopped, and whether it runs.
+type countingCloser struct {
+	auction.Closer
+
+	mu        sync.Mutex
+	running   bool
+	starts    int
+	shutdowns int
+}
+
+func (cc *countingCloser) Start(ctx context.Context) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.running = true
+	cc.starts++
+}
+
+func (cc *countingCloser) Stop() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.running = false
+}
+
+func (cc *countingCloser) Shutdown(ctx context.Context) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.running = false
+	cc.shutdowns++
+	return nil
+}
+
+func (cc *countingCloser) LastRun() time.Time      { return time.Time{} }
+func (cc *countingCloser) Interval() time.Duration { return time.Second }
+
+func (cc *countingCloser) isRunning() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.running
+}
+
+func TestLeaderCloserRunsOnOneInstance(t *testing.T) {
+	ctx := context.Background()
+	ttl := 15 * time.Second
+
+	clk := clock.NewFake(time.Now())
+	lease := &sharedLease{clock: clk, failing: make(map[string]bool)}
+	firstCloser, secondCloser := &countingCloser{}, &countingCloser{}
+	first := auction.NewLeaderCloser(firstCloser, leaseOwner{lease, "first"}, clk, ttl)
+	second := auction.NewLeaderCloser(secondCloser, leaseOwner{lease, "second"}, clk, ttl)
+
+	// waitFor lets the heartbeats, which run in the background, catch up with the clock
+	waitFor := func(step string, condition func() bool) {
+		t.Helper()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: timed out", step)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	runs := func(firstRuns, secondRuns bool) func() bool {
+		return func() bool {
+			return firstCloser.isRunning() == firstRuns && secondCloser.isRunning() == secondRuns &&
+				first.Leading() == firstRuns && second.Leading() == secondRuns
+		}
+	}
+
+	first.Start(ctx)
+	waitFor("first takes the lease", runs(true, false))
+	second.Start(ctx)
+	defer second.Stop()
+
+	// The renewals every third of the ttl keep second out
+	for i := 0; i < 6; i++ {
+		clk.Advance(ttl / 3)
+		time.Sleep(10 * time.Millisecond)
+		waitFor("first keeps the lease", runs(true, false))
+	}
+
+	// Failed renewals keep first running until its lease expires, when it steps down
+	// and second takes over
+	lease.mu.Lock()
+	lease.failing["first"] = true
+	lease.mu.Unlock()
+	clk.Advance(ttl / 3)
+	time.Sleep(10 * time.Millisecond)
+	waitFor("first rides out a failed renewal", runs(true, false))
+
+	for i := 0; i < 3; i++ {
+		clk.Advance(ttl / 3)
+		time.Sleep(10 * time.Millisecond)
+	}
+	lease.mu.Lock()
+	lease.failing["first"] = false
+	lease.mu.Unlock()
+	clk.Advance(ttl / 3)
+	waitFor("second takes the expired lease", runs(false, true))
+
+	// Shutting the leader down releases the lease, leaving the last sweep to it alone
+	if err := first.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+	if firstCloser.shutdowns != 0 {
+		t.Errorf("Expected the closer of a follower not to be shut down, got %d shutdowns", firstCloser.shutdowns)
+	}
+
+	third := auction.NewLeaderCloser(&countingCloser{}, leaseOwner{lease, "third"}, clk, ttl)
+	if err := second.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+	if secondCloser.shutdowns != 1 {
+		t.Errorf("Expected the closer of the leader to be shut down once, got %d", secondCloser.shutdowns)
+	}
+	third.Start(ctx)
+	defer third.Stop()
+	waitFor("third takes the released lease", third.Leading)
+
+	if firstCloser.starts != 1 || secondCloser.starts != 1 {
+		t.Errorf("Expected each closer to be started once, got %d and %d", firstCloser.starts, secondCloser.starts)
+	}
+}
+
+// hangingLeaser answers nothing until the context of the call is done, like a database
+// that stopped responding.
+type hangingLeaser struct {
+	called chan struct{}
+}
+
+func (hl hangingLeaser) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, *internal_error.InternalError) {
+	select {
+	case hl.called <- struct{}{}:
+	default:
+	}
+	<-ctx.Done()
+	return false, internal_error.NewInternalServerError(ctx.Err().Error())
+}
+
+func (hl hangingLeaser) Release(ctx context.Context, name string) *internal_error.InternalError {
+	return nil
+}
+
+func TestLeaderCloserStopsDuringAHangingRenewal(t *testing.T) {
+	leaser := hangingLeaser{called: make(chan struct{}, 1)}
+	leader := auction.NewLeaderCloser(&countingCloser{}, leaser, clock.NewFake(time.Now()), 15*time.Second)
+	leader.Start(context.Background())
+	<-leaser.called
+
+	stopped := make(chan struct{})
+	go func() {
+		leader.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Stop to cancel the pending lease renewal")
+	}
+}
+
+func TestAuctionRepositoryEnsureIndexes(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	// Running it twice proves it is idempotent
+	for i := 0; i < 2; i++ {
+		if internalErr := repo.EnsureIndexes(ctx); internalErr != nil {
+			t.Fatalf("Failed to create auction indexes: %v", internalErr.Error())
+		}
+	}
+
+	cursor, err := repo.Collection.Indexes().List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+
+	var indexes []struct {
+		Key bson.D `bson:"key"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatalf("Failed to decode indexes: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, index := range indexes {
+		name := ""
+		for _, key := range index.Key {
+			name += fmt.Sprintf("%s_%v,", key.Key, key.Value)
+		}
+		found[name] = true
+	}
+
+	for _, expected := range []string{"status_1,timestamp_1,", "status_1,end_time_1,", "category_1,"} {
+		if !found[expected] {
+			t.Errorf("Expected index %q to exist, got %v", expected, found)
+		}
+	}
+}
+
+func TestAuctionRepositorySetup(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+
+	// Verify the repository is properly initialized
+	if repo == nil {
+		t.Fatal("Repository should not be nil")
+	}
+
+	// Verify we can perform operations on the collection
+	ctx := context.Background()
+	count, err := repo.Collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("Failed to count documents: %v", err)
+	}
+
+	t.Logf("Initial auction count in test database: %d", count)
+}
+
+func TestCreateAuctionsKeepsInsertingPastFailures(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := newAuctionRepository(database, clock.New(), 0)
+	ctx := context.Background()
+
+	newAuction := func() *auction_entity.Auction {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Auction used by the batch test", auction_entity.New, 0,
+			testBounds, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		return auctionEntity
+	}
+
+	existing := newAuction()
+	if internalErr := repo.CreateAuction(ctx, existing); internalErr != nil {
+		t.Fatalf("Failed to create auction: %v", internalErr.Error())
+	}
+
+	// The ended auction never reaches InsertMany, so the duplicate after it is reported
+	// at its own position rather than at the one of its document
+	ended := newAuction()
+	ended.EndTime = time.Now().Add(-time.Minute)
+	duplicate := *existing
+	auctions := []*auction_entity.Auction{newAuction(), ended, &duplicate, newAuction()}
+	errs := repo.CreateAuctions(ctx, auctions)
+
+	if len(errs) != len(auctions) {
+		t.Fatalf("Expected one error slot per auction, got %d", len(errs))
+	}
+	if errs[1] == nil || errs[1].Err != internal_error.BadRequest || !errs[1].HasCause("end_time") {
+		t.Errorf("Expected the ended auction to be rejected under end_time, got %v", errs[1])
+	}
+	if errs[2] == nil || errs[2].Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict for the duplicate auction, got %v", errs[2])
+	}
+	for _, i := range []int{0, 3} {
+		if errs[i] != nil {
+			t.Fatalf("Expected auction %d to be created, got %v", i, errs[i].Error())
+		}
+		found, internalErr := repo.FindAuctionById(ctx, auctions[i].Id)
+		if internalErr != nil {
+			t.Fatalf("Failed to find auction %d: %v", i, internalErr.Error())
+		}
+		if found.EndTime.IsZero() {
+			t.Errorf("Expected auction %d to get an end_time", i)
+		}
+	}
+}
+
+func TestActivateScheduledAuctions(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 0)
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Auction used by the schedule test", auction_entity.New, time.Minute,
+		testBounds, clk)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	auctionEntity.ScheduleStart(auctionEntity.Timestamp.Add(time.Hour), testBounds)
+	if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction: %v", internalErr.Error())
+	}
+
+	// Neither the start nor the end time passed, so the auction is left alone
+	if activatedIds, internalErr := repo.ActivateScheduledAuctions(ctx); internalErr != nil || len(activatedIds) != 0 {
+		t.Fatalf("Expected no auction activated before its start time, got %v (%v)", activatedIds, internalErr)
+	}
+
+	clk.Advance(time.Hour + time.Second)
+	activatedIds, internalErr := repo.ActivateScheduledAuctions(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to activate auctions: %v", internalErr.Error())
+	}
+	if len(activatedIds) != 1 || activatedIds[0] != auctionEntity.Id {
+		t.Fatalf("Expected the auction to be activated, got %v", activatedIds)
+	}
+
+	// The countdown started at the start time, so the auction doesn't close yet
+	if closedIds, internalErr := repo.CloseExpiredAuctions(ctx); internalErr != nil || len(closedIds) != 0 {
+		t.Fatalf("Expected no auction closed before its end time, got %v (%v)", closedIds, internalErr)
+	}
+
+	found, internalErr := repo.FindAuctionById(ctx, auctionEntity.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if found.Status != auction_entity.Active || !found.StartTime.Equal(auctionEntity.StartTime) {
+		t.Errorf("Expected an Active auction started at %v, got status %v started at %v",
+			auctionEntity.StartTime, found.Status, found.StartTime)
+	}
+}
+
+func TestArchiveCompletedMovesOldAuctions(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	clk := clock.NewFake(time.Now())
+	repo := newAuctionRepository(database, clk, 0)
+	ctx := context.Background()
+
+	create := func() *auction_entity.Auction {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Auction used by the archive test", auction_entity.New, time.Minute,
+			testBounds, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if internalErr := repo.CreateAuction(ctx, auctionEntity); internalErr != nil {
+			t.Fatalf("Failed to create auction: %v", internalErr.Error())
+		}
+		return auctionEntity
+	}
+
+	completed, cancelled, active := create(), create(), create()
+	if _, err := repo.Collection.UpdateOne(ctx, bson.M{"_id": cancelled.Id},
+		bson.M{"$set": bson.M{"status": auction_entity.Cancelled}}); err != nil {
+		t.Fatalf("Failed to cancel auction: %v", err)
+	}
+	if _, err := repo.Collection.UpdateOne(ctx, bson.M{"_id": active.Id},
+		bson.M{"$set": bson.M{"end_time": clk.Now().Add(48 * time.Hour)}}); err != nil {
+		t.Fatalf("Failed to extend auction: %v", err)
+	}
+	_, insertErr := database.Collection("bids").InsertOne(ctx, bson.M{
+		"_id": uuid.New().String(), "user_id": uuid.New().String(), "auction_id": completed.Id,
+		"amount_cents": int64(2500), "currency": "USD", "timestamp": clk.Now().Unix(),
+	})
+	if insertErr != nil {
+		t.Fatalf("Failed to insert bid: %v", insertErr)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, internalErr := repo.CloseExpiredAuctions(ctx); internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+	recent := create()
+	clk.Advance(2 * time.Minute)
+	if _, internalErr := repo.CloseExpiredAuctions(ctx); internalErr != nil {
+		t.Fatalf("Failed to close auctions: %v", internalErr.Error())
+	}
+
+	// Only the auctions that ended more than 2 minutes ago are old enough
+	archived, internalErr := repo.ArchiveCompleted(ctx, 2*time.Minute)
+	if internalErr != nil {
+		t.Fatalf("Failed to archive auctions: %v", internalErr.Error())
+	}
+	if archived != 2 {
+		t.Errorf("Expected the completed and the cancelled auction archived, got %d", archived)
+	}
+
+	for _, id := range []string{completed.Id, cancelled.Id} {
+		if count, _ := repo.Collection.CountDocuments(ctx, bson.M{"_id": id}); count != 0 {
+			t.Errorf("Expected auction %s to leave the auctions collection", id)
+		}
+		if count, _ := repo.ArchiveCollection.CountDocuments(ctx, bson.M{"_id": id}); count != 1 {
+			t.Errorf("Expected auction %s in the archive", id)
+		}
+	}
+	for _, id := range []string{active.Id, recent.Id} {
+		if count, _ := repo.Collection.CountDocuments(ctx, bson.M{"_id": id}); count != 1 {
+			t.Errorf("Expected auction %s to stay in the auctions collection", id)
+		}
+	}
+
+	// Links to archived auctions keep working, and their bids stay where they are
+	found, internalErr := repo.FindAuctionById(ctx, completed.Id)
+	if internalErr != nil {
+		t.Fatalf("Expected the archived auction to be found, got %v", internalErr.Error())
+	}
+	if found.Status != auction_entity.Completed || found.WinningBid == nil || found.WinningBid.Amount != 2500 {
+		t.Errorf("Expected the archived auction with its winner snapshot, got %+v", found)
+	}
+	if count, _ := database.Collection("bids").CountDocuments(ctx, bson.M{"auction_id": completed.Id}); count != 1 {
+		t.Errorf("Expected the bids of the archived auction kept, got %d", count)
+	}
+
+	if archived, _ := repo.ArchiveCompleted(ctx, 2*time.Minute); archived != 0 {
+		t.Errorf("Expected a second run to archive nothing, got %d", archived)
+	}
 }
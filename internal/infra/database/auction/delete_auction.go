@@ -0,0 +1,37 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// SoftDeleteAuction sets deleted_at on the auction instead of removing it, so its bids
+// keep pointing at an existing document.
+func (ar *AuctionRepository) SoftDeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "deleted_at": nil}
+	update := bson.M{"$set": bson.M{"deleted_at": ar.Clock.Now().UTC()}, "$inc": revisionIncrement}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete auction", err, zap.String("auction_id", id))
+		return internal_error.NewInternalServerError("Error trying to delete auction").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	logger.InfoContext(ctx, "Auction deleted", zap.String("auction_id", id))
+	return nil
+}
@@ -0,0 +1,58 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// FindDuplicateAuction looks the auction up by the {seller_id, product_name, category,
+// status} index, returning the most recent match.
+func (ar *AuctionRepository) FindDuplicateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	since time.Time) (string, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.FindDuplicateAuction")
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{
+		"seller_id":    auctionEntity.SellerId,
+		"product_name": auctionEntity.ProductName,
+		"category":     auctionEntity.Category,
+		"status":       auction_entity.Active,
+		"deleted_at":   nil,
+		"timestamp":    bson.M{"$gte": since},
+	}
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetProjection(bson.M{"_id": 1})
+
+	var duplicate struct {
+		Id string `bson:"_id"`
+	}
+	if err := ar.Collection.FindOne(ctx, filter, opts).Decode(&duplicate); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find duplicate auction", err,
+			zap.String("seller_id", auctionEntity.SellerId))
+		return "", internal_error.NewInternalServerError("Error trying to find duplicate auction").Wrap(err)
+	}
+
+	return duplicate.Id, nil
+}
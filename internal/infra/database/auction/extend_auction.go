@@ -0,0 +1,74 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ExtendAuctionEndTime pushes the end_time of an Active auction out by extension when
+// less than window is left, never extending it by more than maxExtension in total. The
+// check and the update happen in a single FindOneAndUpdate, so concurrent bids can't
+// extend the auction past the cap or revive one that already ended. It returns the new
+// end time and whether the auction was extended.
+func (ar *AuctionRepository) ExtendAuctionEndTime(
+	ctx context.Context,
+	id string,
+	window, extension, maxExtension time.Duration) (time.Time, bool, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	now := ar.Clock.Now()
+	maxSeconds := int64(maxExtension / time.Second)
+
+	filter := bson.M{
+		"_id":    id,
+		"status": auction_entity.Active,
+		"end_time": bson.M{
+			"$gt":  now,
+			"$lte": now.Add(window),
+		},
+		"$or": bson.A{
+			bson.M{"extension_seconds": bson.M{"$exists": false}},
+			bson.M{"extension_seconds": bson.M{"$lt": maxSeconds}},
+		},
+	}
+
+	extensionSoFar := bson.M{"$ifNull": bson.A{"$extension_seconds", 0}}
+	step := bson.M{"$min": bson.A{
+		int64(extension / time.Second),
+		bson.M{"$subtract": bson.A{maxSeconds, extensionSoFar}},
+	}}
+	// Adding a number to a date adds milliseconds
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"end_time":          bson.M{"$add": bson.A{"$end_time", bson.M{"$multiply": bson.A{step, 1000}}}},
+			"extension_seconds": bson.M{"$add": bson.A{extensionSoFar, step}},
+			"revision":          bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$revision", 0}}, 1}},
+		}}},
+	}
+
+	var auctionEntityMongo AuctionEntityMongo
+	err := ar.Collection.FindOneAndUpdate(
+		ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&auctionEntityMongo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return time.Time{}, false, nil
+		}
+
+		logger.ErrorContext(ctx, "Error trying to extend auction", err, zap.String("auction_id", id))
+		return time.Time{}, false, internal_error.NewInternalServerError("Error trying to extend auction").Wrap(err)
+	}
+
+	return auctionEntityMongo.EndTime.UTC(), true, nil
+}
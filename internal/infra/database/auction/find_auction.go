@@ -2,80 +2,191 @@ package auction
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
 	"fullcycle-auction_go/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"time"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"regexp"
 )
 
+// FindAuctionById looks the auction up among the current auctions and then, when it
+// isn't there, in the archive, see ArchiveCompleted.
 func (ar *AuctionRepository) FindAuctionById(
 	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
 	filter := bson.M{"_id": id}
+	excludeDeleted(ctx, filter)
 
 	var auctionEntityMongo AuctionEntityMongo
-	if err := ar.Collection.FindOne(ctx, filter).Decode(&auctionEntityMongo); err != nil {
-		logger.Error(fmt.Sprintf("Error trying to find auction by id = %s", id), err)
-		return nil, internal_error.NewInternalServerError("Error trying to find auction by id")
+	err := ar.Collection.FindOne(ctx, filter).Decode(&auctionEntityMongo)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = ar.ArchiveCollection.FindOne(ctx, filter).Decode(&auctionEntityMongo)
 	}
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Auction not found with this id = %s", id)).Wrap(err)
+		}
 
-	return &auction_entity.Auction{
-		Id:          auctionEntityMongo.Id,
-		ProductName: auctionEntityMongo.ProductName,
-		Category:    auctionEntityMongo.Category,
-		Description: auctionEntityMongo.Description,
-		Condition:   auctionEntityMongo.Condition,
-		Status:      auctionEntityMongo.Status,
-		Timestamp:   time.Unix(auctionEntityMongo.Timestamp, 0),
-	}, nil
+		logger.ErrorContext(ctx, "Error trying to find auction by id", err, zap.String("auction_id", id))
+		return nil, internal_error.NewInternalServerError("Error trying to find auction by id").Wrap(err)
+	}
+
+	auctionEntity := toAuctionEntity(auctionEntityMongo)
+	return &auctionEntity, nil
 }
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
 func (repo *AuctionRepository) FindAuctions(
 	ctx context.Context,
-	status auction_entity.AuctionStatus,
-	category string,
-	productName string) ([]auction_entity.Auction, *internal_error.InternalError) {
-	filter := bson.M{}
+	auctionFilter auction_entity.AuctionFilter,
+	page, pageSize int) ([]auction_entity.Auction, int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, repo.operationTimeout)
+	defer cancel()
 
-	if status != 0 {
-		filter["status"] = status
+	if page < 1 {
+		page = 1
 	}
-
-	if category != "" {
-		filter["category"] = category
+	if pageSize < 1 {
+		pageSize = defaultPageSize
 	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	filter := auctionFilterQuery(ctx, auctionFilter)
 
-	if productName != "" {
-		filter["productName"] = primitive.Regex{Pattern: productName, Options: "i"}
+	total, err := repo.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error counting auctions", err)
+		return nil, 0, internal_error.NewInternalServerError("Error counting auctions").Wrap(err)
 	}
 
-	cursor, err := repo.Collection.Find(ctx, filter)
+	skip, limit := int64((page-1)*pageSize), int64(pageSize)
+
+	opts := options.Find().SetSort(auctionSortSpec(auctionFilter.Sort)).SetSkip(skip).SetLimit(limit)
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
 	if err != nil {
-		logger.Error("Error finding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error finding auctions")
+		logger.ErrorContext(ctx, "Error finding auctions", err)
+		return nil, 0, internal_error.NewInternalServerError("Error finding auctions").Wrap(err)
 	}
 	defer cursor.Close(ctx)
 
 	var auctionsMongo []AuctionEntityMongo
 	if err := cursor.All(ctx, &auctionsMongo); err != nil {
-		logger.Error("Error decoding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error decoding auctions")
+		logger.ErrorContext(ctx, "Error decoding auctions", err)
+		return nil, 0, internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
 	}
 
 	var auctionsEntity []auction_entity.Auction
 	for _, auction := range auctionsMongo {
-		auctionsEntity = append(auctionsEntity, auction_entity.Auction{
-			Id:          auction.Id,
-			ProductName: auction.ProductName,
-			Category:    auction.Category,
-			Status:      auction.Status,
-			Description: auction.Description,
-			Condition:   auction.Condition,
-			Timestamp:   time.Unix(auction.Timestamp, 0),
-		})
-	}
-
-	return auctionsEntity, nil
+		auctionsEntity = append(auctionsEntity, toAuctionEntity(auction))
+	}
+
+	return auctionsEntity, total, nil
+}
+
+// auctionFilterQuery is the MongoDB filter matching auctionFilter.
+func auctionFilterQuery(ctx context.Context, auctionFilter auction_entity.AuctionFilter) bson.M {
+	filter := bson.M{}
+	excludeDeleted(ctx, filter)
+
+	if auctionFilter.SellerId != "" {
+		filter["seller_id"] = auctionFilter.SellerId
+	}
+
+	if len(auctionFilter.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": auctionFilter.Statuses}
+	}
+
+	if auctionFilter.Category != "" {
+		filter["category"] = auctionFilter.Category
+	}
+
+	// Case-insensitive partial match on the product name. The user input is escaped so
+	// it is always matched literally: "C++" or ".*" are text, not patterns.
+	if auctionFilter.ProductName != "" {
+		filter["product_name"] = primitive.Regex{Pattern: regexp.QuoteMeta(auctionFilter.ProductName), Options: "i"}
+	}
+
+	timestampRange := bson.M{}
+	if !auctionFilter.CreatedAfter.IsZero() {
+		timestampRange["$gte"] = auctionFilter.CreatedAfter
+	}
+	if !auctionFilter.CreatedBefore.IsZero() {
+		timestampRange["$lte"] = auctionFilter.CreatedBefore
+	}
+	if len(timestampRange) > 0 {
+		filter["timestamp"] = timestampRange
+	}
+
+	return filter
+}
+
+// auctionSortSpec is the sort document of the order. The id breaks ties so pages don't
+// overlap.
+func auctionSortSpec(auctionSort auction_entity.AuctionSort) bson.D {
+	switch auctionSort {
+	case auction_entity.SortOldest:
+		return bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}
+	case auction_entity.SortEndingSoon:
+		return bson.D{{Key: "end_time", Value: 1}, {Key: "_id", Value: 1}}
+	case auction_entity.SortMostBids:
+		return bson.D{{Key: "bid_count", Value: -1}, {Key: "timestamp", Value: -1}, {Key: "_id", Value: 1}}
+	default:
+		return bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: 1}}
+	}
+}
+
+// excludeDeleted leaves soft-deleted auctions out of filter, unless ctx asks for them
+// with auction_entity.WithDeleted.
+func excludeDeleted(ctx context.Context, filter bson.M) {
+	if !auction_entity.IncludesDeleted(ctx) {
+		filter["deleted_at"] = nil
+	}
+}
+
+func toAuctionEntity(auctionEntityMongo AuctionEntityMongo) auction_entity.Auction {
+	return auction_entity.Auction{
+		Id:          auctionEntityMongo.Id,
+		SellerId:    auctionEntityMongo.SellerId,
+		ProductName: auctionEntityMongo.ProductName,
+		Category:    auctionEntityMongo.Category,
+		Description: auctionEntityMongo.Description,
+		Condition:   auctionEntityMongo.Condition,
+		Status:      auctionEntityMongo.Status,
+		Timestamp:   auctionEntityMongo.Timestamp.UTC(),
+		EndTime:     auctionEntityMongo.EndTime.UTC(),
+		StartTime:   auctionEntityMongo.startTime(),
+
+		Currency:      auctionEntityMongo.currency(),
+		AuctionType:   auctionEntityMongo.auctionType(),
+		StartingPrice: auctionEntityMongo.startingPrice(),
+		ReservePrice:  auctionEntityMongo.reservePrice(),
+		BuyNowPrice:   auctionEntityMongo.BuyNowPriceCents,
+		Outcome:       auctionEntityMongo.Outcome,
+		DeletedAt:     auctionEntityMongo.DeletedAt,
+		ClosedAt:      auctionEntityMongo.ClosedAt,
+		WinningBid:    auctionEntityMongo.winningBid(),
+
+		BidCount:             auctionEntityMongo.BidCount,
+		CurrentHighestAmount: auctionEntityMongo.currentHighestAmount(),
+		Revision:             auctionEntityMongo.Revision,
+		Version:              auctionEntityMongo.Version,
+
+		Images: auctionEntityMongo.images(),
+	}
 }
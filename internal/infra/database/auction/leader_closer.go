@@ -0,0 +1,216 @@
+package auction
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+// closerLeaseName is the lease the instances compete for to run the closer
+const closerLeaseName = "auction_closer"
+
+// Leaser hands out named leases shared by all instances, see lock.LeaseRepository.
+// Acquire also renews a lease the caller already holds.
+type Leaser interface {
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, *internal_error.InternalError)
+	Release(ctx context.Context, name string) *internal_error.InternalError
+}
+
+// LeaderCloser runs its Closer only on the instance holding the closer lease, so with
+// several replicas a single one closes the auctions, whichever the mode. Every instance
+// tries to take the lease every third of its ttl; the one holding it renews it and
+// starts the closer, and stops it again as soon as a renewal is refused.
+//
+// Losing the lease in the middle of a sweep is safe: each auction is closed by its own
+// update, conditional on the auction still being Active, and a closer only audits and
+// announces the auctions its update changed. An auction swept by the old and the new
+// leader at once is then closed, and announced, by one of them. On demand closes and
+// retries go straight to the Closer on any instance, for the same reason.
+type LeaderCloser struct {
+	Closer
+
+	leaser Leaser
+	clock  clock.Clock
+	ttl    time.Duration
+
+	mutex        sync.RWMutex
+	leading      bool
+	leadingSince time.Time
+	lastCheck    time.Time
+
+	// renewedAt is when the lease was last renewed, only ever read and written by the
+	// heartbeat
+	renewedAt time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewLeaderCloser runs closer while holding the lease, which lasts ttl once taken or
+// renewed.
+func NewLeaderCloser(closer Closer, leaser Leaser, clk clock.Clock, ttl time.Duration) *LeaderCloser {
+	return &LeaderCloser{
+		Closer: closer,
+		leaser: leaser,
+		clock:  clk,
+		ttl:    ttl,
+	}
+}
+
+// Start tries to take the lease right away and then keeps taking or renewing it every
+// third of the ttl in the background, until ctx is cancelled or Stop is called.
+func (lc *LeaderCloser) Start(ctx context.Context) {
+	ctx, lc.cancel = context.WithCancel(ctx)
+	lc.done = make(chan struct{})
+
+	go func() {
+		defer close(lc.done)
+
+		timer := lc.clock.NewTimer(lc.heartbeat())
+		defer timer.Stop()
+
+		lc.checkLease(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.Chan():
+				lc.checkLease(ctx)
+				timer.Reset(lc.heartbeat())
+			}
+		}
+	}()
+}
+
+// checkLease takes or renews the lease, starting the closer when it was just taken and
+// stopping it when it was lost. A renewal that fails on the database keeps the closer
+// running until the lease it renewed last expires, as no other instance can take it
+// before then. The renewal runs on ctx, so stopping doesn't wait for a slow database.
+func (lc *LeaderCloser) checkLease(ctx context.Context) {
+	now := lc.clock.Now()
+	acquired, err := lc.leaser.Acquire(ctx, closerLeaseName, lc.ttl)
+	if err == nil {
+		lc.mutex.Lock()
+		lc.lastCheck = now
+		lc.mutex.Unlock()
+	}
+
+	switch {
+	case acquired:
+		lc.renewedAt = now
+		if !lc.isLeading() {
+			logger.Info("Closer lease acquired, starting the auction closer", zap.Duration("ttl", lc.ttl))
+			lc.Closer.Start(ctx)
+			lc.setLeading(true, now)
+		}
+	case lc.isLeading() && (err == nil || !now.Before(lc.renewedAt.Add(lc.ttl))):
+		logger.Warn("Closer lease lost, stopping the auction closer")
+		lc.setLeading(false, now)
+		lc.Closer.Stop()
+	}
+}
+
+// Stop stops taking the lease and, on the leader, stops the closer and releases the
+// lease so another instance takes over without waiting for it to expire.
+func (lc *LeaderCloser) Stop() {
+	if lc.cancel == nil {
+		return
+	}
+
+	lc.cancel()
+	<-lc.done
+
+	if lc.isLeading() {
+		lc.Closer.Stop()
+		lc.release(context.Background())
+	}
+}
+
+// Shutdown stops taking the lease and, on the leader, shuts the closer down, running its
+// last sweep, before releasing the lease. The other instances leave the last sweep to
+// the leader. It returns ctx.Err() if ctx is done first.
+func (lc *LeaderCloser) Shutdown(ctx context.Context) error {
+	if lc.cancel != nil {
+		lc.cancel()
+
+		select {
+		case <-lc.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if !lc.isLeading() {
+		return ctx.Err()
+	}
+
+	err := lc.Closer.Shutdown(ctx)
+	lc.release(ctx)
+
+	return err
+}
+
+func (lc *LeaderCloser) release(ctx context.Context) {
+	lc.setLeading(false, lc.clock.Now())
+	if err := lc.leaser.Release(ctx, closerLeaseName); err == nil {
+		logger.Info("Closer lease released")
+	}
+}
+
+// Leading reports whether this instance holds the lease and runs the closer.
+func (lc *LeaderCloser) Leading() bool {
+	return lc.isLeading()
+}
+
+// LastRun returns, on the leader, when the closer last ran, counting from when the lease
+// was taken so a new leader isn't reported stale before its first run. On the other
+// instances it returns when the lease was last checked.
+func (lc *LeaderCloser) LastRun() time.Time {
+	lc.mutex.RLock()
+	leading, leadingSince, lastCheck := lc.leading, lc.leadingSince, lc.lastCheck
+	lc.mutex.RUnlock()
+
+	if !leading {
+		return lastCheck
+	}
+
+	if lastRun := lc.Closer.LastRun(); lastRun.After(leadingSince) {
+		return lastRun
+	}
+	return leadingSince
+}
+
+// Interval returns the longest of the closer interval and the time between two lease
+// checks.
+func (lc *LeaderCloser) Interval() time.Duration {
+	if interval := lc.Closer.Interval(); interval > lc.heartbeat() {
+		return interval
+	}
+
+	return lc.heartbeat()
+}
+
+func (lc *LeaderCloser) heartbeat() time.Duration {
+	return lc.ttl / 3
+}
+
+func (lc *LeaderCloser) isLeading() bool {
+	lc.mutex.RLock()
+	defer lc.mutex.RUnlock()
+
+	return lc.leading
+}
+
+func (lc *LeaderCloser) setLeading(leading bool, now time.Time) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.leading = leading
+	lc.leadingSince = now
+}
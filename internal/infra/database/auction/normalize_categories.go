@@ -0,0 +1,71 @@
+package auction
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// NormalizeCategories is a one-time backfill for auctions created while the category was
+// free text: every auction whose category matches one of categories ignoring case and
+// whitespace is renamed to that category's name. Auctions matching none are left as is.
+// It returns how many auctions changed and is safe to run again.
+func (ar *AuctionRepository) NormalizeCategories(
+	ctx context.Context, categories []category_entity.Category) (int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	var normalized int64
+
+	for _, category := range categories {
+		words := strings.Fields(category.Name)
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		pattern := `^\s*` + strings.Join(words, `\s+`) + `\s*$`
+
+		filter := bson.M{"$and": bson.A{
+			bson.M{"category": primitive.Regex{Pattern: pattern, Options: "i"}},
+			bson.M{"category": bson.M{"$ne": category.Name}},
+		}}
+
+		result, err := ar.Collection.UpdateMany(ctx, filter,
+			bson.M{"$set": bson.M{"category": category.Name}, "$inc": revisionIncrement})
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to normalize auction categories", err,
+				zap.String("category", category.Name))
+			return normalized, internal_error.NewInternalServerError("Error trying to normalize auction categories").Wrap(err)
+		}
+
+		normalized += result.ModifiedCount
+	}
+
+	return normalized, nil
+}
+
+// RenameCategory follows a category rename, so the auctions already in the category keep
+// being listed under it. Deleted and closed auctions are renamed too.
+func (ar *AuctionRepository) RenameCategory(
+	ctx context.Context, from, to string) (int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	result, err := ar.Collection.UpdateMany(ctx, bson.M{"category": from},
+		bson.M{"$set": bson.M{"category": to}, "$inc": revisionIncrement})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to rename auction categories", err,
+			zap.String("from", from), zap.String("to", to))
+		return 0, internal_error.NewInternalServerError("Error trying to rename auction categories").Wrap(err)
+	}
+
+	return result.ModifiedCount, nil
+}
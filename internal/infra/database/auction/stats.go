@@ -0,0 +1,57 @@
+package auction
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CountAuctionsByStatus groups the auctions by status in one pass. Matching on every
+// status rather than none lets the {status, timestamp} index serve the scan.
+func (ar *AuctionRepository) CountAuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.CountAuctionsByStatus")
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status": bson.M{"$in": bson.A{
+				auction_entity.Active, auction_entity.Completed, auction_entity.Cancelled, auction_entity.Scheduled}},
+			"deleted_at": nil,
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := ar.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count auctions by status", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count auctions by status").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Status auction_entity.AuctionStatus `bson:"_id"`
+		Count  int64                        `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode auction counts", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count auctions by status").Wrap(err)
+	}
+
+	counts := make(map[auction_entity.AuctionStatus]int64, len(results))
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,54 @@
+package auction
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// streamBatchSize is how many auctions each round trip of the export cursor fetches
+const streamBatchSize = 500
+
+// StreamAuctions reads the auctions matching auctionFilter from a cursor, decoding and
+// handing them to fn one at a time. The cursor lives as long as the export does, so it
+// is bounded by ctx rather than DB_OPERATION_TIMEOUT_MS: a client that goes away cancels
+// it.
+func (ar *AuctionRepository) StreamAuctions(
+	ctx context.Context,
+	auctionFilter auction_entity.AuctionFilter,
+	fn func(auction auction_entity.Auction) error) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.StreamAuctions")
+	defer span.End()
+
+	opts := options.Find().SetSort(auctionSortSpec(auctionFilter.Sort)).SetBatchSize(streamBatchSize)
+	cursor, err := ar.Collection.Find(ctx, auctionFilterQuery(ctx, auctionFilter), opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error finding auctions", err)
+		return internal_error.NewInternalServerError("Error finding auctions").Wrap(err)
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(ctx) {
+		var auctionEntityMongo AuctionEntityMongo
+		if err := cursor.Decode(&auctionEntityMongo); err != nil {
+			logger.ErrorContext(ctx, "Error decoding auctions", err)
+			return internal_error.NewInternalServerError("Error decoding auctions").Wrap(err)
+		}
+
+		if err := fn(toAuctionEntity(auctionEntityMongo)); err != nil {
+			return internal_error.NewInternalServerError("Error streaming auctions").Wrap(err)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error reading auctions", err)
+		return internal_error.NewInternalServerError("Error reading auctions").Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package auction
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const usersCollection = "users"
+
+// topSellersOrder ranks the sellers by total sold, then auctions sold, then id and
+// currency to break the ties the same way every time.
+var topSellersOrder = bson.D{
+	{Key: "total_sold", Value: -1}, {Key: "auctions_sold", Value: -1},
+	{Key: "_id.seller_id", Value: 1}, {Key: "_id.currency", Value: 1},
+}
+
+// AggregateTopSellers groups the auctions sold between from and to by seller and
+// currency, summing their winner snapshots, and joins the names of only the sellers that
+// made the cut. Deleted auctions and those without a seller are left out.
+func (ar *AuctionRepository) AggregateTopSellers(
+	ctx context.Context, from, to time.Time, limit int) ([]auction_entity.TopSeller, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "AuctionRepository.AggregateTopSellers", attribute.Int("limit", limit))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":     auction_entity.Completed,
+			"outcome":    bson.M{"$in": auction_entity.SaleOutcomes},
+			"closed_at":  bson.M{"$gte": from, "$lte": to},
+			"seller_id":  bson.M{"$nin": bson.A{nil, ""}},
+			"deleted_at": nil,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"seller_id": "$seller_id",
+				"currency":  bson.M{"$ifNull": bson.A{"$winning_currency", money.DefaultCurrency}},
+			},
+			"auctions_sold": bson.M{"$sum": 1},
+			"total_sold":    bson.M{"$sum": bson.M{"$ifNull": bson.A{"$settlement_amount", "$winning_amount"}}},
+		}}},
+		{{Key: "$sort", Value: topSellersOrder}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         usersCollection,
+			"localField":   "_id.seller_id",
+			"foreignField": "_id",
+			"as":           "seller",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"auctions_sold": 1,
+			"total_sold":    1,
+			"seller_name":   bson.M{"$arrayElemAt": bson.A{"$seller.name", 0}},
+		}}},
+		// $lookup doesn't keep the order, so the ranking is sorted again
+		{{Key: "$sort", Value: topSellersOrder}},
+	}
+
+	cursor, err := ar.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate top sellers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate top sellers").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Id struct {
+			SellerId string `bson:"seller_id"`
+			Currency string `bson:"currency"`
+		} `bson:"_id"`
+		SellerName   string `bson:"seller_name"`
+		AuctionsSold int64  `bson:"auctions_sold"`
+		TotalSold    int64  `bson:"total_sold"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate top sellers", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate top sellers").Wrap(err)
+	}
+
+	topSellers := make([]auction_entity.TopSeller, 0, len(results))
+	for _, result := range results {
+		topSellers = append(topSellers, auction_entity.TopSeller{
+			SellerId:     result.Id.SellerId,
+			SellerName:   result.SellerName,
+			Currency:     result.Id.Currency,
+			AuctionsSold: result.AuctionsSold,
+			TotalSold:    result.TotalSold,
+		})
+	}
+
+	return topSellers, nil
+}
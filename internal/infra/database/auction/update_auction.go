@@ -0,0 +1,152 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// UpdateAuctionStatus moves the auction from one status to another. The filter on the
+// current status and version makes the transition a compare-and-swap, so e.g. an auction
+// closed by the sweeper can no longer be cancelled and vice versa. The audit entry is
+// written in the same transaction as the status.
+func (ar *AuctionRepository) UpdateAuctionStatus(
+	ctx context.Context,
+	id string,
+	version int64,
+	from, to auction_entity.AuctionStatus) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": from, "version": versionFilter(version)}
+	update := bson.M{"$set": bson.M{"status": to}, "$inc": versionIncrement}
+
+	reason := auction_entity.AuditReasonCancelled
+	if to != auction_entity.Cancelled {
+		reason = auction_entity.AuditReasonStatusChanged
+	}
+
+	err := ar.TxRunner.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := ar.Collection.FindOneAndUpdate(ctx, filter, update).Err(); err != nil {
+			return err
+		}
+
+		return ar.recordStatusChanges(ctx, []auction_entity.AuctionAudit{
+			auction_entity.NewAuctionAudit(ctx, id, from, to, reason, ar.Clock.Now()),
+		})
+	})
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.ErrorContext(ctx, "Error trying to update auction status", err, zap.String("auction_id", id))
+		return internal_error.NewInternalServerError("Error trying to update auction status").Wrap(err)
+	}
+
+	var current AuctionEntityMongo
+	err = ar.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&current)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find auction by id", err, zap.String("auction_id", id))
+		return internal_error.NewInternalServerError("Error trying to update auction status").Wrap(err)
+	}
+
+	if current.Status != from {
+		return internal_error.NewConflictError("Auction status does not allow this transition")
+	}
+
+	return internal_error.ErrAuctionModified
+}
+
+var (
+	errAuctionNotEditable = errors.New("auction is no longer active")
+	errAuctionHasBids     = errors.New("auction already has bids")
+	errAuctionModified    = errors.New("auction version changed")
+)
+
+// UpdateAuction changes the product fields of an auction that is still Active, has no
+// bids and is still at version. The check and the update run in one transaction that
+// writes the auction document, the same document CreateBidIfAuctionActive writes when it
+// inserts a bid, so an update and a first bid conflict instead of both committing.
+func (ar *AuctionRepository) UpdateAuction(
+	ctx context.Context,
+	id string,
+	version int64,
+	update auction_entity.AuctionUpdate) (*auction_entity.Auction, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ar.operationTimeout)
+	defer cancel()
+
+	fields := bson.M{}
+	if update.ProductName != nil {
+		fields["product_name"] = *update.ProductName
+	}
+	if update.Category != nil {
+		fields["category"] = *update.Category
+	}
+	if update.Description != nil {
+		fields["description"] = *update.Description
+	}
+
+	var updated AuctionEntityMongo
+	err := ar.TxRunner.RunInTransaction(ctx, func(txCtx context.Context) error {
+		var current AuctionEntityMongo
+		if err := ar.Collection.FindOne(txCtx, bson.M{"_id": id}).Decode(&current); err != nil {
+			return err
+		}
+
+		if current.Status != auction_entity.Active || !current.EndTime.UTC().After(ar.Clock.Now()) {
+			return errAuctionNotEditable
+		}
+
+		if current.Version != version {
+			return errAuctionModified
+		}
+
+		bids, err := ar.Collection.Database().Collection(bidsCollection).CountDocuments(
+			txCtx, bson.M{"auction_id": id}, options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if bids > 0 {
+			return errAuctionHasBids
+		}
+
+		return ar.Collection.FindOneAndUpdate(
+			txCtx,
+			bson.M{"_id": id, "status": auction_entity.Active, "version": versionFilter(version)},
+			bson.M{"$set": fields, "$inc": versionIncrement},
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("Auction not found with this id = %s", id)).Wrap(err)
+		case errors.Is(err, errAuctionNotEditable):
+			return nil, internal_error.NewConflictError("Only active auctions can be updated").Wrap(err)
+		case errors.Is(err, errAuctionHasBids):
+			return nil, internal_error.NewConflictError("Auction can't be updated after the first bid").Wrap(err)
+		case errors.Is(err, errAuctionModified):
+			return nil, internal_error.ErrAuctionModified
+		}
+
+		logger.ErrorContext(ctx, "Error trying to update auction", err, zap.String("auction_id", id))
+		return nil, internal_error.NewInternalServerError("Error trying to update auction").Wrap(err)
+	}
+
+	auctionEntity := toAuctionEntity(updated)
+	return &auctionEntity, nil
+}
@@ -0,0 +1,38 @@
+package bid
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BackfillAmountCents stores the amount in cents of the bids placed before amounts moved
+// to cents, which only have a float amount, and drops the float. The winning bid is
+// found by sorting on amount_cents, which only ranks the bids holding it, so it has to
+// run before bids are read. It returns how many bids changed and is safe to run again,
+// also after it was cut short.
+func (bd *BidRepository) BackfillAmountCents(ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"amount_cents": bson.M{"$exists": false}, "amount": bson.M{"$type": "number"}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"amount_cents": bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$amount", 100}}, 0}}},
+		}}},
+		{{Key: "$unset", Value: "amount"}},
+	}
+
+	result, err := bd.Collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to backfill bid amounts in cents", err)
+		return 0, internal_error.NewInternalServerError("Error trying to backfill bid amounts in cents").Wrap(err)
+	}
+
+	return result.ModifiedCount, nil
+}
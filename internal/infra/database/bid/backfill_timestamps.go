@@ -0,0 +1,33 @@
+package bid
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BackfillTimestamps converts the timestamp of the bids and maximum bids stored in Unix
+// seconds, before they had milliseconds, into dates. It has to run before the bids are
+// paged or filtered by timestamp, which only matches dates. It returns how many
+// documents changed and is safe to run again, also after it was cut short.
+func (bd *BidRepository) BackfillTimestamps(ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	var backfilled int64
+	for _, collection := range []*mongo.Collection{bd.Collection, bd.MaxBidCollection} {
+		updated, err := dbtime.BackfillDates(ctx, collection, "timestamp")
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to backfill bid timestamps", err)
+			return backfilled, internal_error.NewInternalServerError("Error trying to backfill bid timestamps").Wrap(err)
+		}
+		backfilled += updated
+	}
+
+	return backfilled, nil
+}
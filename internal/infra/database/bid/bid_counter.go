@@ -0,0 +1,85 @@
+package bid
+
+import (
+	"context"
+	"errors"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// BidCounterMongo counts the bids of a user on an auction. Counters start with the first
+// bid stored after they were introduced, so earlier bids aren't counted.
+type BidCounterMongo struct {
+	Id        string `bson:"_id"`
+	AuctionId string `bson:"auction_id"`
+	UserId    string `bson:"user_id"`
+	Count     int64  `bson:"count"`
+}
+
+func bidCounterId(auctionId, userId string) string {
+	return auctionId + ":" + userId
+}
+
+// incrementBidCounters counts the bids just inserted, a bid and its counter bid, for
+// their bidders. It must run inside the bid transaction, so the counters never drift
+// from the bids.
+func (bd *BidRepository) incrementBidCounters(ctx context.Context, documents []interface{}) error {
+	counts := make(map[string]*BidCounterMongo)
+	for _, document := range documents {
+		bid := document.(*BidEntityMongo)
+
+		id := bidCounterId(bid.AuctionId, bid.UserId)
+		if counts[id] == nil {
+			counts[id] = &BidCounterMongo{Id: id, AuctionId: bid.AuctionId, UserId: bid.UserId}
+		}
+		counts[id].Count++
+	}
+
+	for id, counter := range counts {
+		_, err := bd.CounterCollection.UpdateOne(ctx,
+			bson.M{"_id": id},
+			bson.M{
+				"$inc":         bson.M{"count": counter.Count},
+				"$setOnInsert": bson.M{"auction_id": counter.AuctionId, "user_id": counter.UserId},
+			},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CountUserBids reads the counter of userId on auctionId, a single lookup by id. A user
+// without bids on the auction has no counter and counts zero.
+func (bd *BidRepository) CountUserBids(
+	ctx context.Context, auctionId, userId string) (int64, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.CountUserBids", attribute.String("auction_id", auctionId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	var counter BidCounterMongo
+	err := bd.CounterCollection.FindOne(ctx, bson.M{"_id": bidCounterId(auctionId, userId)}).Decode(&counter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+
+		logger.ErrorContext(ctx, "Error trying to count user bids", err,
+			zap.String("auction_id", auctionId), zap.String("user_id", userId))
+		return 0, internal_error.NewInternalServerError("Error trying to count user bids").Wrap(err)
+	}
+
+	return counter.Count, nil
+}
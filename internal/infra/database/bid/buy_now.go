@@ -0,0 +1,81 @@
+package bid
+
+import (
+	"context"
+	"errors"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// BuyNow inserts the bid buying the auction at its buy-now price and closes the auction
+// with it, inside a single transaction. The close is the conditional update of
+// auction.AuctionRepository.CloseBoughtNow, so of two concurrent buy-now bids only one
+// commits; the other, like any bid once the auction closed or its price was reached,
+// is rejected with ErrBuyNowUnavailable.
+//
+// With ReserveBalances, the hold moves from the outbid winning bid to the buyer, as it
+// does in CreateBidIfAuctionActive.
+func (bd *BidRepository) BuyNow(
+	ctx context.Context,
+	bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "BidRepository.BuyNow",
+		attribute.String("bid_id", bidEntity.Id), attribute.String("auction_id", bidEntity.AuctionId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	defer metrics.ObserveSince(metrics.BidInsertDuration, time.Now())
+
+	document := toBidEntityMongo(bidEntity)
+	err := bd.TxRunner.RunInTransaction(ctx, func(txCtx context.Context) error {
+		now := bd.AuctionRepository.Clock.Now()
+		if err := bd.AuctionRepository.CloseBoughtNow(txCtx, bidEntity, now); err != nil {
+			return err
+		}
+
+		document.Reserved = bd.ReserveBalances
+		if document.Reserved {
+			winningBid, err := bd.findWinningBid(txCtx, bidEntity.AuctionId)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return err
+			}
+
+			// winningBid is nil when the auction had no bid yet
+			if err := bd.transferHold(txCtx, winningBid, document); err != nil {
+				return err
+			}
+		}
+
+		if _, err := bd.Collection.InsertOne(txCtx, document); err != nil {
+			return err
+		}
+
+		return bd.incrementBidCounters(txCtx, []interface{}{document})
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return internal_error.ErrBuyNowUnavailable.Wrap(err)
+		}
+		if errors.Is(err, errInsufficientBalance) {
+			return internal_error.ErrInsufficientBalance
+		}
+
+		logger.ErrorContext(ctx, "Error trying to buy auction now", err,
+			zap.String("bid_id", bidEntity.Id), zap.String("auction_id", bidEntity.AuctionId))
+		return internal_error.NewInternalServerError("Error trying to buy auction now").Wrap(err)
+	}
+
+	metrics.BidsCreated.Inc()
+	metrics.AuctionsBoughtNow.Inc()
+	return nil
+}
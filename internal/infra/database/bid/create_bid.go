@@ -2,120 +2,350 @@ package bid
 
 import (
 	"context"
+	"errors"
+	"fullcycle-auction_go/configuration/database/mongodb"
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/entity/bid_entity"
 	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/infra/tracing"
 	"fullcycle-auction_go/internal/internal_error"
-	"os"
+	"fullcycle-auction_go/internal/money"
 	"sync"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 )
 
+// BidEntityMongo stores the amount in cents. Bids written before amounts moved to cents
+// only have the float amount instead; they are converted when read, see Cents. The
+// timestamp is a date, to the millisecond, so bids of the same second still rank by the
+// one placed first.
 type BidEntityMongo struct {
-	Id        string  `bson:"_id"`
-	UserId    string  `bson:"user_id"`
-	AuctionId string  `bson:"auction_id"`
-	Amount    float64 `bson:"amount"`
-	Timestamp int64   `bson:"timestamp"`
+	Id           string      `bson:"_id"`
+	UserId       string      `bson:"user_id"`
+	AuctionId    string      `bson:"auction_id"`
+	AmountCents  *int64      `bson:"amount_cents,omitempty"`
+	LegacyAmount float64     `bson:"amount,omitempty"`
+	Currency     string      `bson:"currency,omitempty"`
+	Timestamp    dbtime.Time `bson:"timestamp"`
+	Proxy        bool        `bson:"proxy,omitempty"`
+	Reserved     bool        `bson:"reserved,omitempty"`
+}
+
+// Cents returns the amount in cents, converting the legacy float amount if needed.
+func (b BidEntityMongo) Cents() int64 {
+	if b.AmountCents != nil {
+		return *b.AmountCents
+	}
+
+	return money.FromFloat(b.LegacyAmount)
+}
+
+func (b BidEntityMongo) toBidEntity() bid_entity.Bid {
+	currency := b.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	return bid_entity.Bid{
+		Id:        b.Id,
+		UserId:    b.UserId,
+		AuctionId: b.AuctionId,
+		Amount:    b.Cents(),
+		Currency:  currency,
+		Timestamp: b.Timestamp.UTC(),
+		Proxy:     b.Proxy,
+		Reserved:  b.Reserved,
+	}
+}
+
+func toBidEntityMongo(bidEntity *bid_entity.Bid) *BidEntityMongo {
+	amount := bidEntity.Amount
+
+	return &BidEntityMongo{
+		Id:          bidEntity.Id,
+		UserId:      bidEntity.UserId,
+		AuctionId:   bidEntity.AuctionId,
+		AmountCents: &amount,
+		Currency:    bidEntity.Currency,
+		Timestamp:   dbtime.From(bidEntity.Timestamp),
+		Proxy:       bidEntity.Proxy,
+		Reserved:    bidEntity.Reserved,
+	}
 }
 
 type BidRepository struct {
-	Collection            *mongo.Collection
-	AuctionRepository     *auction.AuctionRepository
-	auctionInterval       time.Duration
-	auctionStatusMap      map[string]auction_entity.AuctionStatus
-	auctionEndTimeMap     map[string]time.Time
-	auctionStatusMapMutex *sync.Mutex
-	auctionEndTimeMutex   *sync.Mutex
+	Collection        *mongo.Collection
+	MaxBidCollection  *mongo.Collection
+	UserCollection    *mongo.Collection
+	CounterCollection *mongo.Collection
+	AuctionRepository *auction.AuctionRepository
+
+	// ReserveBalances holds the amount of each leading bid from the bidder's balance,
+	// see CreateBidIfAuctionActive
+	ReserveBalances bool
+
+	// TxRunner runs the bid insert along with the auction counters and balance holds
+	TxRunner mongodb.TxRunner
+
+	// operationTimeout bounds each operation, see dbtimeout.WithTimeout
+	operationTimeout time.Duration
 }
 
-func NewBidRepository(database *mongo.Database, auctionRepository *auction.AuctionRepository) *BidRepository {
+func NewBidRepository(
+	database *mongo.Database,
+	auctionRepository *auction.AuctionRepository,
+	txRunner mongodb.TxRunner,
+	operationTimeout time.Duration) *BidRepository {
 	return &BidRepository{
-		auctionInterval:       getAuctionInterval(),
-		auctionStatusMap:      make(map[string]auction_entity.AuctionStatus),
-		auctionEndTimeMap:     make(map[string]time.Time),
-		auctionStatusMapMutex: &sync.Mutex{},
-		auctionEndTimeMutex:   &sync.Mutex{},
-		Collection:            database.Collection("bids"),
-		AuctionRepository:     auctionRepository,
+		Collection:        database.Collection("bids"),
+		MaxBidCollection:  database.Collection("max_bids"),
+		UserCollection:    database.Collection("users"),
+		CounterCollection: database.Collection("bid_counters"),
+		AuctionRepository: auctionRepository,
+		TxRunner:          txRunner,
+		operationTimeout:  operationTimeout,
+	}
+}
+
+// EnsureIndexes creates the indexes backing the bid queries: one per bidder, one for the
+// winner lookup, one for the per-auction listing by timestamp and id, which also finds
+// the first bid of an auction, and one for the export and the recent bid counts by
+// timestamp. CreateMany is a no-op for indexes that already exist, so it is safe to call
+// on every startup. The duplicate bid index is created on its own, so duplicates already
+// stored only keep that one from being built.
+func (bd *BidRepository) EnsureIndexes(ctx context.Context) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	_, err := bd.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "auction_id", Value: 1}, {Key: "amount_cents", Value: -1}, {Key: "timestamp", Value: 1}}},
+		{Keys: bson.D{{Key: "auction_id", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create bid indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create bid indexes").Wrap(err)
+	}
+
+	if _, err := bd.Collection.Indexes().CreateOne(ctx, duplicateBidIndex); err != nil {
+		logger.ErrorContext(ctx, "Error trying to create the duplicate bid index", err)
+		return internal_error.NewInternalServerError("Error trying to create bid indexes").Wrap(err)
+	}
+
+	_, err = bd.MaxBidCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "auction_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create max bid indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create bid indexes").Wrap(err)
 	}
+
+	return nil
 }
 
+// CreateBid inserts a batch of accepted bids and returns the result of each insert, see
+// bid_entity.BidEntityRepository. The bids of an auction are inserted one after the
+// other, in the order they were accepted, so a higher bid never races a lower one queued
+// before it and gets it rejected; different auctions are inserted concurrently. Bids
+// keep their id, so an insert retried by the transaction can't store the same bid twice.
+// Each bid is inserted on its own, so a duplicate or rejected bid never takes the rest
+// of the batch down with it.
 func (bd *BidRepository) CreateBid(
 	ctx context.Context,
-	bidEntities []bid_entity.Bid) *internal_error.InternalError {
+	bidEntities []bid_entity.Bid) []*internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "BidRepository.CreateBid", attribute.Int("bid_count", len(bidEntities)))
+	defer span.End()
+
+	// Each auction gets the indexes of its bids in the batch
+	bidsByAuction := make(map[string][]int)
+	for i := range bidEntities {
+		bidsByAuction[bidEntities[i].AuctionId] = append(bidsByAuction[bidEntities[i].AuctionId], i)
+	}
+
+	results := make([]*internal_error.InternalError, len(bidEntities))
 	var wg sync.WaitGroup
-	for _, bid := range bidEntities {
+	for _, indexes := range bidsByAuction {
 		wg.Add(1)
-		go func(bidValue bid_entity.Bid) {
+		go func(indexes []int) {
 			defer wg.Done()
 
-			bd.auctionStatusMapMutex.Lock()
-			auctionStatus, okStatus := bd.auctionStatusMap[bidValue.AuctionId]
-			bd.auctionStatusMapMutex.Unlock()
+			for _, i := range indexes {
+				if err := bd.CreateBidIfAuctionActive(ctx, &bidEntities[i]); err != nil {
+					logger.InfoContext(ctx, "Bid rejected",
+						zap.String("bid_id", bidEntities[i].Id),
+						zap.String("auction_id", bidEntities[i].AuctionId),
+						zap.String("reason", err.Error()))
+					results[i] = err
+				}
+			}
+		}(indexes)
+	}
+	wg.Wait()
+	return results
+}
 
-			bd.auctionEndTimeMutex.Lock()
-			auctionEndTime, okEndTime := bd.auctionEndTimeMap[bidValue.AuctionId]
-			bd.auctionEndTimeMutex.Unlock()
+var (
+	errBidNotHighest        = errors.New("bid amount is not higher than the current winning bid")
+	errCounterBidNotHighest = errors.New("counter bid amount is not higher than the bid it answers")
+	errInsufficientBalance  = errors.New("balance doesn't cover the bid amount")
+)
 
-			bidEntityMongo := &BidEntityMongo{
-				Id:        bidValue.Id,
-				UserId:    bidValue.UserId,
-				AuctionId: bidValue.AuctionId,
-				Amount:    bidValue.Amount,
-				Timestamp: bidValue.Timestamp.Unix(),
-			}
+// CreateBidIfAuctionActive checks the auction status and inserts the bid, followed by
+// its counter bid, inside a single transaction. The auction document is written as part
+// of the transaction, so a concurrent close conflicts with it instead of racing it:
+// either the bid commits before the auction is closed or it is rejected with
+// ErrAuctionNotActive.
+//
+// The same write serializes the bids of an auction, which makes the check that the
+// amount is strictly above the current winning bid deterministic for equal amounts. It
+// also keeps the bid_count and current_highest_amount of the auction, and the bid
+// counters of the bidders, in step with its bids.
+//
+// The bids of a Vickrey auction are sealed, so they are inserted whatever their amount;
+// only those above the winning bid take the lead.
+//
+// With ReserveBalances, the transaction also moves the hold from the outbid winning bid
+// to the new leading bid, the last of the chain: the outbid bidder gets the amount back
+// and the new leader's balance is debited, guarded so it never goes negative. A leader
+// whose balance doesn't cover the bid gets it rejected with ErrInsufficientBalance.
+//
+// A bid of the same user and amount already stored on the auction is a retry: the
+// duplicate key error rolls the transaction back and the bid is ignored as if stored.
+func (bd *BidRepository) CreateBidIfAuctionActive(
+	ctx context.Context,
+	bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "BidRepository.CreateBidIfAuctionActive",
+		attribute.String("bid_id", bidEntity.Id), attribute.String("auction_id", bidEntity.AuctionId))
+	defer span.End()
 
-			if okEndTime && okStatus {
-				now := time.Now()
-				if auctionStatus == auction_entity.Completed || now.After(auctionEndTime) {
-					return
-				}
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
 
-				if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-					logger.Error("Error trying to insert bid", err)
-					return
-				}
+	defer metrics.ObserveSince(metrics.BidInsertDuration, time.Now())
 
-				return
-			}
+	if bidEntity.CounterBid != nil && bidEntity.CounterBid.Amount <= bidEntity.Amount {
+		return internal_error.NewBadRequestError("Counter bid amount must be higher than the bid it answers").
+			Wrap(errCounterBidNotHighest)
+	}
 
-			auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, bidValue.AuctionId)
-			if err != nil {
-				logger.Error("Error trying to find auction by id", err)
-				return
-			}
-			if auctionEntity.Status == auction_entity.Completed {
-				return
-			}
+	var documents []interface{}
+	var leader *BidEntityMongo
+	highestAmount := bidEntity.Amount
+	for bid := bidEntity; bid != nil; bid = bid.CounterBid {
+		leader = toBidEntityMongo(bid)
+		documents = append(documents, leader)
+		if bid.Amount > highestAmount {
+			highestAmount = bid.Amount
+		}
+	}
+
+	err := bd.TxRunner.RunInTransaction(ctx, func(txCtx context.Context) error {
+		// The bid was accepted before the end of the auction when it was queued, which
+		// is what counts: the closer writes the queued bids before closing, see
+		// auction.AuctionRepository.FlushBids
+		now := bd.AuctionRepository.Clock.Now()
+		filter := bson.M{
+			"_id":        bidEntity.AuctionId,
+			"status":     auction_entity.Active,
+			"end_time":   bson.M{"$gt": bidEntity.Timestamp},
+			"deleted_at": nil,
+		}
+		// The counters are only committed along with the bids, so a rejected bid
+		// leaves them untouched. They change the listings, hence the revision
+		update := bson.M{
+			"$set": bson.M{"last_bid_at": dbtime.From(now)},
+			"$inc": bson.M{"bid_count": len(documents), "revision": 1},
+			"$max": bson.M{"current_highest_amount": highestAmount},
+		}
 
-			bd.auctionStatusMapMutex.Lock()
-			bd.auctionStatusMap[bidValue.AuctionId] = auctionEntity.Status
-			bd.auctionStatusMapMutex.Unlock()
+		var auctionDocument struct {
+			AuctionType auction_entity.AuctionType `bson:"auction_type"`
+		}
+		opts := options.FindOneAndUpdate().SetProjection(bson.M{"auction_type": 1})
+		err := bd.AuctionRepository.Collection.FindOneAndUpdate(txCtx, filter, update, opts).Decode(&auctionDocument)
+		if err != nil {
+			return err
+		}
 
-			bd.auctionEndTimeMutex.Lock()
-			bd.auctionEndTimeMap[bidValue.AuctionId] = auctionEntity.Timestamp.Add(bd.auctionInterval)
-			bd.auctionEndTimeMutex.Unlock()
+		winningBid, err := bd.findWinningBid(txCtx, bidEntity.AuctionId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		leads := err != nil || bidEntity.Amount > winningBid.Cents()
+		if !leads && auctionDocument.AuctionType != auction_entity.Vickrey {
+			return errBidNotHighest
+		}
 
-			if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-				logger.Error("Error trying to insert bid", err)
-				return
+		// winningBid is nil for the first bid of the auction
+		leader.Reserved = bd.ReserveBalances && leads
+		if leader.Reserved {
+			if err := bd.transferHold(txCtx, winningBid, leader); err != nil {
+				return err
 			}
-		}(bid)
+		}
+
+		if _, err := bd.Collection.InsertMany(txCtx, documents); err != nil {
+			return err
+		}
+
+		return bd.incrementBidCounters(txCtx, documents)
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return internal_error.ErrAuctionNotActive.Wrap(err)
+		}
+		if errors.Is(err, errBidNotHighest) {
+			return internal_error.NewBadRequestError("Bid amount must be higher than the current winning bid").Wrap(err)
+		}
+		if errors.Is(err, errInsufficientBalance) {
+			return internal_error.ErrInsufficientBalance
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			logger.InfoContext(ctx, "Duplicate bid ignored",
+				zap.String("bid_id", bidEntity.Id), zap.String("auction_id", bidEntity.AuctionId))
+			return nil
+		}
+
+		logger.ErrorContext(ctx, "Error trying to insert bid", err,
+			zap.String("bid_id", bidEntity.Id), zap.String("auction_id", bidEntity.AuctionId))
+		return internal_error.NewInternalServerError("Error trying to insert bid").Wrap(err)
 	}
-	wg.Wait()
+
+	metrics.BidsCreated.Add(float64(len(documents)))
 	return nil
 }
 
-func getAuctionInterval() time.Duration {
-	auctionInterval := os.Getenv("AUCTION_INTERVAL")
-	duration, err := time.ParseDuration(auctionInterval)
+// transferHold releases the amount held by the outbid bid, if it holds any, and debits
+// the amount of the new leader. It must run inside the bid transaction.
+func (bd *BidRepository) transferHold(ctx context.Context, outbid, leader *BidEntityMongo) error {
+	if outbid != nil && outbid.Reserved {
+		_, err := bd.UserCollection.UpdateOne(ctx,
+			bson.M{"_id": outbid.UserId}, bson.M{"$inc": bson.M{"balance": outbid.Cents()}})
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := bd.UserCollection.UpdateOne(ctx,
+		bson.M{"_id": leader.UserId, "balance": bson.M{"$gte": leader.Cents()}},
+		bson.M{"$inc": bson.M{"balance": -leader.Cents()}})
 	if err != nil {
-		return time.Minute * 5
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errInsufficientBalance
 	}
 
-	return duration
+	return nil
 }
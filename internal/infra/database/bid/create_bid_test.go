@@ -0,0 +1,856 @@
+package bid_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/bid"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testDBName = "bid_test_db"
+
+// mongoSkipReason caches the first connection failure so the remaining tests
+// skip right away instead of waiting for the server selection timeout again.
+var mongoSkipReason string
+
+func setupTestDB(t *testing.T) (*mongo.Database, func()) {
+	if mongoSkipReason != "" {
+		t.Skip(mongoSkipReason)
+	}
+
+	mongoURL := os.Getenv("MONGODB_URL")
+	if mongoURL == "" {
+		mongoURL = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		t.Skip(mongoSkipReason)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB ping failed: %v", err)
+		t.Skip(mongoSkipReason)
+	}
+
+	// Bids are inserted inside transactions, which need a replica set member
+	var hello bson.M
+	if err := client.Database("admin").RunCommand(ctx, bson.M{"hello": 1}).Decode(&hello); err != nil || hello["setName"] == nil {
+		client.Disconnect(ctx)
+		mongoSkipReason = "Skipping test: MongoDB is not running as a replica set"
+		t.Skip(mongoSkipReason)
+	}
+
+	database := client.Database(testDBName)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	}
+
+	return database, cleanup
+}
+
+// The test server is a replica set, so the repositories always run real transactions.
+func newAuctionRepository(database *mongo.Database) *auction.AuctionRepository {
+	return auction.NewAuctionRepository(database, clock.New(), mongodb.NewSessionTxRunner(database.Client()), 0, 0)
+}
+
+func newBidRepository(database *mongo.Database, auctionRepo *auction.AuctionRepository) *bid.BidRepository {
+	return bid.NewBidRepository(database, auctionRepo, mongodb.NewSessionTxRunner(database.Client()), 0)
+}
+
+func createActiveAuction(t *testing.T, repo *auction.AuctionRepository) *auction_entity.Auction {
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(),
+		"Test Product",
+		"Electronics",
+		"This is a test product description for testing",
+		auction_entity.New, 0,
+		auction_entity.DurationBounds{},
+		clock.New(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err)
+	}
+
+	if internalErr := repo.CreateAuction(context.Background(), auctionEntity); internalErr != nil {
+		t.Fatalf("Failed to create auction in repository: %v", internalErr.Error())
+	}
+
+	return auctionEntity
+}
+
+func newBid(t *testing.T, auctionId string, amount int64) *bid_entity.Bid {
+	bidEntity, err := bid_entity.CreateBid(uuid.New().String(), auctionId, amount, money.DefaultCurrency, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create bid entity: %v", err)
+	}
+
+	return bidEntity
+}
+
+func cents(amount int64) *int64 {
+	return &amount
+}
+
+// recordingTxRunner runs fn sequentially, counting the transactions it was asked for.
+type recordingTxRunner struct {
+	transactions int
+}
+
+func (r *recordingTxRunner) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.transactions++
+	return fn(ctx)
+}
+
+func TestCreateBidIfAuctionActiveRunsInOneTransaction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	runner := &recordingTxRunner{}
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := bid.NewBidRepository(database, auctionRepo, runner, 0)
+	ctx := context.Background()
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+
+	bidEntity := newBid(t, auctionEntity.Id, 10000)
+	bidEntity.CounterBid = newBid(t, auctionEntity.Id, 11000)
+	if internalErr := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); internalErr != nil {
+		t.Fatalf("Expected the bid to be accepted, got %v", internalErr.Error())
+	}
+
+	if runner.transactions != 1 {
+		t.Errorf("Expected the bid and its counter bid in a single transaction, got %d", runner.transactions)
+	}
+
+	count, err := bidRepo.Collection.CountDocuments(ctx, bson.M{"auction_id": auctionEntity.Id})
+	if err != nil {
+		t.Fatalf("Failed to count bids: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected both bids stored, got %d", count)
+	}
+}
+
+func TestCreateBidIfAuctionActiveRejectsClosedAuction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+
+	if internalErr := bidRepo.CreateBidIfAuctionActive(ctx, newBid(t, auctionEntity.Id, 10000)); internalErr != nil {
+		t.Fatalf("Expected bid on active auction to be accepted, got %v", internalErr.Error())
+	}
+
+	if _, err := auctionRepo.Collection.UpdateOne(ctx,
+		bson.M{"_id": auctionEntity.Id},
+		bson.M{"$set": bson.M{"status": auction_entity.Completed}}); err != nil {
+		t.Fatalf("Failed to close auction: %v", err)
+	}
+
+	internalErr := bidRepo.CreateBidIfAuctionActive(ctx, newBid(t, auctionEntity.Id, 20000))
+	if !errors.Is(internalErr, internal_error.ErrAuctionNotActive) {
+		t.Fatalf("Expected ErrAuctionNotActive for a closed auction, got %v", internalErr)
+	}
+
+	if found, internalErr := auctionRepo.FindAuctionById(ctx, auctionEntity.Id); internalErr != nil {
+		t.Errorf("Failed to find auction: %v", internalErr.Error())
+	} else if internalErr := found.ValidateAcceptsBids(time.Now()); internalErr != internal_error.ErrAuctionNotActive {
+		t.Errorf("Expected the auction to refuse bids up front with ErrAuctionNotActive, got %v", internalErr)
+	}
+
+	count, err := bidRepo.Collection.CountDocuments(ctx, bson.M{"auction_id": auctionEntity.Id})
+	if err != nil {
+		t.Fatalf("Failed to count bids: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected only the bid placed before closure to be stored, got %d", count)
+	}
+}
+
+func TestCreateBidIfAuctionActiveKeepsBidsAcceptedBeforeTheEnd(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+	acceptedBid := newBid(t, auctionEntity.Id, 10000)
+	lateBid := newBid(t, auctionEntity.Id, 20000)
+
+	// The auction ends after the first bid was accepted and before it is written
+	endTime := time.Now().Add(-time.Millisecond)
+	acceptedBid.Timestamp = endTime.Add(-time.Second)
+	if _, err := auctionRepo.Collection.UpdateOne(ctx,
+		bson.M{"_id": auctionEntity.Id}, bson.M{"$set": bson.M{"end_time": endTime}}); err != nil {
+		t.Fatalf("Failed to end auction: %v", err)
+	}
+
+	if internalErr := bidRepo.CreateBidIfAuctionActive(ctx, acceptedBid); internalErr != nil {
+		t.Fatalf("Expected the bid accepted before the end to be stored, got %v", internalErr.Error())
+	}
+	if internalErr := bidRepo.CreateBidIfAuctionActive(ctx, lateBid); !errors.Is(internalErr, internal_error.ErrAuctionNotActive) {
+		t.Errorf("Expected ErrAuctionNotActive for a bid placed after the end, got %v", internalErr)
+	}
+
+	closedIds, internalErr := auctionRepo.CloseExpiredAuctions(ctx)
+	if internalErr != nil || len(closedIds) != 1 {
+		t.Fatalf("Expected the auction to close, got %v, %v", closedIds, internalErr)
+	}
+	found, internalErr := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+	if found.WinningBid == nil || found.WinningBid.BidId != acceptedBid.Id {
+		t.Errorf("Expected the bid accepted before the end to win, got %+v", found.WinningBid)
+	}
+}
+
+func TestCreateBidRejectedOnCancelledAuction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+
+	if internalErr := auctionRepo.UpdateAuctionStatus(
+		ctx, auctionEntity.Id, 0, auction_entity.Active, auction_entity.Cancelled); internalErr != nil {
+		t.Fatalf("Failed to cancel auction: %v", internalErr.Error())
+	}
+
+	if found, internalErr := auctionRepo.FindAuctionById(ctx, auctionEntity.Id); internalErr != nil {
+		t.Errorf("Failed to find auction: %v", internalErr.Error())
+	} else if internalErr := found.ValidateAcceptsBids(time.Now()); internalErr != internal_error.ErrAuctionNotActive {
+		t.Errorf("Expected the auction to refuse bids up front with ErrAuctionNotActive, got %v", internalErr)
+	}
+
+	internalErr := bidRepo.CreateBidIfAuctionActive(ctx, newBid(t, auctionEntity.Id, 10000))
+	if !errors.Is(internalErr, internal_error.ErrAuctionNotActive) {
+		t.Errorf("Expected ErrAuctionNotActive for a cancelled auction, got %v", internalErr)
+	}
+}
+
+func TestWinningBidTieBreaksByEarliestTimestamp(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	if internalErr := bidRepo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create bid indexes: %v", internalErr.Error())
+	}
+
+	auctionId := uuid.New().String()
+	second := time.Now().Truncate(time.Second)
+
+	// The earlier bid predates amounts in cents, which must not change how it ranks. Both
+	// are placed within the same second, told apart by their milliseconds
+	earlierBid := bid.BidEntityMongo{
+		Id:           uuid.New().String(),
+		UserId:       uuid.New().String(),
+		AuctionId:    auctionId,
+		LegacyAmount: 5,
+		Timestamp:    dbtime.From(second.Add(100 * time.Millisecond)),
+	}
+	laterBid := bid.BidEntityMongo{
+		Id:          uuid.New().String(),
+		UserId:      uuid.New().String(),
+		AuctionId:   auctionId,
+		AmountCents: cents(500),
+		Timestamp:   dbtime.From(second.Add(600 * time.Millisecond)),
+	}
+
+	// The later bid goes in first so the natural order would favour it
+	if _, err := bidRepo.Collection.InsertMany(ctx, []interface{}{laterBid, earlierBid}); err != nil {
+		t.Fatalf("Failed to insert bids: %v", err)
+	}
+	if _, internalErr := bidRepo.BackfillAmountCents(ctx); internalErr != nil {
+		t.Fatalf("Failed to backfill amounts: %v", internalErr.Error())
+	}
+
+	for i := 0; i < 10; i++ {
+		winningBid, internalErr := bidRepo.FindWinningBidByAuctionId(ctx, auctionId)
+		if internalErr != nil {
+			t.Fatalf("Failed to find winning bid: %v", internalErr.Error())
+		}
+
+		if winningBid.Id != earlierBid.Id {
+			t.Fatalf("Run %d: expected the earlier bid %s to win, got %s", i, earlierBid.Id, winningBid.Id)
+		}
+		if winningBid.Amount != 500 || winningBid.Currency != money.DefaultCurrency {
+			t.Fatalf("Expected the legacy bid to read as 500 cents in %s, got %d %s",
+				money.DefaultCurrency, winningBid.Amount, winningBid.Currency)
+		}
+	}
+}
+
+func TestBidsStoredInUnixSecondsReadAndBackfill(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	bidRepo := newBidRepository(database, newAuctionRepository(database))
+	ctx := context.Background()
+
+	auctionId := uuid.New().String()
+	legacyAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	currentAt := time.Now().Truncate(time.Millisecond)
+
+	// A bid as written before timestamps were dates, next to a current one
+	legacyId, currentId := uuid.New().String(), uuid.New().String()
+	if _, err := bidRepo.Collection.InsertOne(ctx, bson.M{
+		"_id": legacyId, "user_id": uuid.New().String(), "auction_id": auctionId,
+		"amount_cents": int64(1000), "timestamp": legacyAt.Unix(),
+	}); err != nil {
+		t.Fatalf("Failed to insert the legacy bid: %v", err)
+	}
+	if _, err := bidRepo.Collection.InsertOne(ctx, bid.BidEntityMongo{
+		Id: currentId, UserId: uuid.New().String(), AuctionId: auctionId,
+		AmountCents: cents(2000), Timestamp: dbtime.From(currentAt),
+	}); err != nil {
+		t.Fatalf("Failed to insert the current bid: %v", err)
+	}
+
+	expectBids := func(stage string) {
+		t.Helper()
+
+		bids, internalErr := bidRepo.FindBidByAuctionId(ctx, auctionId, bid_entity.BidListFilter{Ascending: true})
+		if internalErr != nil {
+			t.Fatalf("%s: failed to find bids: %v", stage, internalErr.Error())
+		}
+		if len(bids) != 2 || bids[0].Id != legacyId || bids[1].Id != currentId {
+			t.Fatalf("%s: expected the legacy bid then the current one, got %+v", stage, bids)
+		}
+		if !bids[0].Timestamp.Equal(legacyAt) || !bids[1].Timestamp.Equal(currentAt) {
+			t.Errorf("%s: expected timestamps %v and %v, got %v and %v",
+				stage, legacyAt, currentAt, bids[0].Timestamp, bids[1].Timestamp)
+		}
+		if bids[0].Timestamp.Location() != time.UTC || bids[1].Timestamp.Location() != time.UTC {
+			t.Errorf("%s: expected timestamps in UTC, got %v and %v", stage, bids[0].Timestamp, bids[1].Timestamp)
+		}
+	}
+
+	expectBids("Before the backfill")
+
+	backfilled, internalErr := bidRepo.BackfillTimestamps(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to backfill timestamps: %v", internalErr.Error())
+	}
+	if backfilled != 1 {
+		t.Errorf("Expected only the legacy bid to be backfilled, got %d", backfilled)
+	}
+
+	var stored bson.M
+	if err := bidRepo.Collection.FindOne(ctx, bson.M{"_id": legacyId}).Decode(&stored); err != nil {
+		t.Fatalf("Failed to read the legacy bid: %v", err)
+	}
+	if _, ok := stored["timestamp"].(primitive.DateTime); !ok {
+		t.Errorf("Expected the backfill to store a date, got %T", stored["timestamp"])
+	}
+
+	expectBids("After the backfill")
+
+	if backfilled, _ := bidRepo.BackfillTimestamps(ctx); backfilled != 0 {
+		t.Errorf("Expected a second backfill to change nothing, got %d", backfilled)
+	}
+}
+
+func TestBackfillAmountCentsRanksLegacyBids(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	bidRepo := newBidRepository(database, newAuctionRepository(database))
+	ctx := context.Background()
+	auctionId := uuid.New().String()
+
+	legacyId, currentId := uuid.New().String(), uuid.New().String()
+	if _, err := bidRepo.Collection.InsertMany(ctx, []interface{}{
+		bid.BidEntityMongo{
+			Id: currentId, UserId: uuid.New().String(), AuctionId: auctionId,
+			AmountCents: cents(1000), Timestamp: dbtime.From(time.Now()),
+		},
+		bson.M{
+			"_id": legacyId, "user_id": uuid.New().String(), "auction_id": auctionId,
+			"amount": 19.99, "timestamp": time.Now().Add(-time.Minute),
+		},
+	}); err != nil {
+		t.Fatalf("Failed to insert bids: %v", err)
+	}
+
+	backfilled, internalErr := bidRepo.BackfillAmountCents(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to backfill amounts: %v", internalErr.Error())
+	}
+	if backfilled != 1 {
+		t.Errorf("Expected only the legacy bid to be backfilled, got %d", backfilled)
+	}
+
+	var stored bson.M
+	if err := bidRepo.Collection.FindOne(ctx, bson.M{"_id": legacyId}).Decode(&stored); err != nil {
+		t.Fatalf("Failed to read the legacy bid: %v", err)
+	}
+	if stored["amount_cents"] != int64(1999) {
+		t.Errorf("Expected 1999 cents stored, got %T %v", stored["amount_cents"], stored["amount_cents"])
+	}
+	if _, ok := stored["amount"]; ok {
+		t.Errorf("Expected the float amount to be removed, got %v", stored["amount"])
+	}
+
+	winningBid, internalErr := bidRepo.FindWinningBidByAuctionId(ctx, auctionId)
+	if internalErr != nil {
+		t.Fatalf("Failed to find the winning bid: %v", internalErr.Error())
+	}
+	if winningBid.Id != legacyId || winningBid.Amount != 1999 {
+		t.Errorf("Expected the legacy bid of 1999 cents to win, got %s with %d", winningBid.Id, winningBid.Amount)
+	}
+
+	if backfilled, _ := bidRepo.BackfillAmountCents(ctx); backfilled != 0 {
+		t.Errorf("Expected a second backfill to change nothing, got %d", backfilled)
+	}
+}
+
+func TestGetAuctionBidStats(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	bidRepo := newBidRepository(database, newAuctionRepository(database))
+	ctx := context.Background()
+	auctionId := uuid.New().String()
+
+	stats, internalErr := bidRepo.GetAuctionBidStats(ctx, auctionId)
+	if internalErr != nil {
+		t.Fatalf("Expected stats for an auction without bids, got %v", internalErr.Error())
+	}
+	if *stats != (bid_entity.BidStats{}) {
+		t.Errorf("Expected zeroed stats, got %+v", stats)
+	}
+
+	alice, bob := uuid.New().String(), uuid.New().String()
+	var bids []interface{}
+	for _, seed := range []struct {
+		userId string
+		amount int64
+		legacy bool
+	}{{alice, 1000, false}, {bob, 2000, true}, {alice, 3000, false}, {bob, 6000, true}} {
+		bidEntityMongo := bid.BidEntityMongo{
+			Id: uuid.New().String(), UserId: seed.userId, AuctionId: auctionId,
+			AmountCents: cents(seed.amount), Timestamp: dbtime.From(time.Now()),
+		}
+		if seed.legacy {
+			bidEntityMongo.AmountCents = nil
+			bidEntityMongo.LegacyAmount = float64(seed.amount) / 100
+		}
+		bids = append(bids, bidEntityMongo)
+	}
+	// A bid on another auction must not count
+	bids = append(bids, bid.BidEntityMongo{
+		Id: uuid.New().String(), UserId: alice, AuctionId: uuid.New().String(), AmountCents: cents(100000),
+	})
+	if _, err := bidRepo.Collection.InsertMany(ctx, bids); err != nil {
+		t.Fatalf("Failed to insert bids: %v", err)
+	}
+
+	stats, internalErr = bidRepo.GetAuctionBidStats(ctx, auctionId)
+	if internalErr != nil {
+		t.Fatalf("Failed to get bid stats: %v", internalErr.Error())
+	}
+
+	expected := bid_entity.BidStats{BidCount: 4, HighestBid: 6000, LowestBid: 1000, AverageBid: 3000, UniqueBidders: 2}
+	if *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *stats)
+	}
+}
+
+// The auction update lives in the auction repository, but its zero-bids check needs
+// bids and a replica set, which this package's setup provides
+func TestUpdateAuctionOnlyBeforeFirstBid(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+
+	description := "Fixed description of the test product"
+	updated, internalErr := auctionRepo.UpdateAuction(
+		ctx, auctionEntity.Id, 0, auction_entity.AuctionUpdate{Description: &description})
+	if internalErr != nil {
+		t.Fatalf("Expected the update before any bid to succeed, got %v", internalErr.Error())
+	}
+	if updated.Description != description || updated.ProductName != auctionEntity.ProductName {
+		t.Errorf("Expected only the description to change, got %+v", updated)
+	}
+
+	if internalErr := bidRepo.CreateBidIfAuctionActive(ctx, newBid(t, auctionEntity.Id, 10000)); internalErr != nil {
+		t.Fatalf("Failed to create bid: %v", internalErr.Error())
+	}
+
+	productName := "Renamed Product"
+	_, internalErr = auctionRepo.UpdateAuction(
+		ctx, auctionEntity.Id, updated.Version, auction_entity.AuctionUpdate{ProductName: &productName})
+	if internalErr == nil || internalErr.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict after the first bid, got %v", internalErr)
+	}
+
+	_, internalErr = auctionRepo.UpdateAuction(
+		ctx, uuid.New().String(), 0, auction_entity.AuctionUpdate{ProductName: &productName})
+	if internalErr == nil || internalErr.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found for an unknown auction, got %v", internalErr)
+	}
+}
+
+func TestCreateBidBatchKeepsAuctionOrder(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	first := createActiveAuction(t, auctionRepo)
+	second := createActiveAuction(t, auctionRepo)
+
+	// Every bid beats the previous one of its auction, so all of them must be stored
+	var batch []bid_entity.Bid
+	for i := 1; i <= 10; i++ {
+		batch = append(batch, *newBid(t, first.Id, int64(i*1000)), *newBid(t, second.Id, int64(i*1000)))
+	}
+
+	for i, internalErr := range bidRepo.CreateBid(ctx, batch) {
+		if internalErr != nil {
+			t.Fatalf("Expected bid %d of the batch to be stored, got %v", i, internalErr.Error())
+		}
+	}
+
+	for _, auctionId := range []string{first.Id, second.Id} {
+		count, err := bidRepo.Collection.CountDocuments(ctx, bson.M{"auction_id": auctionId})
+		if err != nil {
+			t.Fatalf("Failed to count bids: %v", err)
+		}
+		if count != 10 {
+			t.Errorf("Expected the 10 bids of auction %s to be stored, got %d", auctionId, count)
+		}
+	}
+}
+
+func TestCreateBidBatchSkipsRetriedBids(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	if internalErr := bidRepo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create bid indexes: %v", internalErr.Error())
+	}
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+	first := newBid(t, auctionEntity.Id, 1000)
+	retried := *first
+	retried.Id = uuid.New().String()
+
+	batch := []bid_entity.Bid{*first, *newBid(t, auctionEntity.Id, 2000), retried, *newBid(t, auctionEntity.Id, 3000)}
+	// The retry is answered as stored, like the bid it repeats
+	for i, internalErr := range bidRepo.CreateBid(ctx, batch) {
+		if internalErr != nil {
+			t.Fatalf("Expected bid %d of the batch to be stored, got %v", i, internalErr.Error())
+		}
+	}
+
+	count, err := bidRepo.Collection.CountDocuments(ctx, bson.M{"auction_id": auctionEntity.Id})
+	if err != nil {
+		t.Fatalf("Failed to count bids: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected every bid but the retry to be stored, got %d", count)
+	}
+
+	found, internalErr := bidRepo.FindBidByUserAndAmount(ctx, auctionEntity.Id, first.UserId, 1000)
+	if internalErr != nil {
+		t.Fatalf("Failed to find the retried bid: %v", internalErr.Error())
+	}
+	if found.Id != first.Id {
+		t.Errorf("Expected the first bid %s, got %s", first.Id, found.Id)
+	}
+
+	// The index catches the retries the transaction doesn't, e.g. on a standalone server
+	_, err = bidRepo.Collection.InsertOne(ctx, bson.M{
+		"_id": uuid.New().String(), "auction_id": auctionEntity.Id, "user_id": first.UserId,
+		"amount_cents": int64(1000), "timestamp": time.Now().Unix(),
+	})
+	if !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("Expected a duplicate key error storing the same amount twice, got %v", err)
+	}
+}
+
+func TestCountUserBidsCountsStoredBids(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	if internalErr := bidRepo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create bid indexes: %v", internalErr.Error())
+	}
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+	first := newBid(t, auctionEntity.Id, 1000)
+	bid := func(amount int64) bid_entity.Bid {
+		next := *newBid(t, auctionEntity.Id, amount)
+		next.UserId = first.UserId
+		return next
+	}
+
+	// The retry and the bid below the winning one are rejected, so they aren't counted
+	retried := bid(1000)
+	batch := []bid_entity.Bid{*first, bid(2000), retried, bid(1500), bid(3000)}
+	results := bidRepo.CreateBid(ctx, batch)
+	for i, internalErr := range results {
+		if i == 3 {
+			if internalErr == nil || internalErr.Err != internal_error.BadRequest {
+				t.Errorf("Expected the bid below the winning one to be rejected, got %v", internalErr)
+			}
+		} else if internalErr != nil {
+			t.Fatalf("Expected bid %d of the batch to be stored, got %v", i, internalErr.Error())
+		}
+	}
+
+	count, internalErr := bidRepo.CountUserBids(ctx, auctionEntity.Id, first.UserId)
+	if internalErr != nil {
+		t.Fatalf("Failed to count user bids: %v", internalErr.Error())
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 bids counted, got %d", count)
+	}
+
+	if count, _ := bidRepo.CountUserBids(ctx, auctionEntity.Id, uuid.New().String()); count != 0 {
+		t.Errorf("Expected a user without bids to count zero, got %d", count)
+	}
+}
+
+func TestAggregateUserStatsCountsWonAuctions(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	won, lost := createActiveAuction(t, auctionRepo), createActiveAuction(t, auctionRepo)
+	winning := newBid(t, won.Id, 1000)
+	userId := winning.UserId
+	outbid := newBid(t, lost.Id, 1000)
+	outbid.UserId = userId
+
+	batch := []bid_entity.Bid{*winning, *outbid, *newBid(t, lost.Id, 2000)}
+	for i, internalErr := range bidRepo.CreateBid(ctx, batch) {
+		if internalErr != nil {
+			t.Fatalf("Expected bid %d of the batch to be stored, got %v", i, internalErr.Error())
+		}
+	}
+	for _, auctionId := range []string{won.Id, lost.Id} {
+		if internalErr := auctionRepo.CloseAuctionNow(ctx, auctionId); internalErr != nil {
+			t.Fatalf("Failed to close auction: %v", internalErr.Error())
+		}
+	}
+
+	stats, internalErr := bidRepo.AggregateUserStats(ctx, userId)
+	if internalErr != nil {
+		t.Fatalf("Failed to aggregate user stats: %v", internalErr.Error())
+	}
+	expected := bid_entity.UserBidStats{AuctionsBidOn: 2, BidCount: 2, AuctionsWon: 1, TotalSpent: 1000}
+	if *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *stats)
+	}
+
+	if stats, _ := bidRepo.AggregateUserStats(ctx, uuid.New().String()); stats == nil || *stats != (bid_entity.UserBidStats{}) {
+		t.Errorf("Expected zeroed stats for a user without bids, got %+v", stats)
+	}
+}
+
+func TestBidRepositoryEnsureIndexes(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	bidRepo := newBidRepository(database, newAuctionRepository(database))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if internalErr := bidRepo.EnsureIndexes(ctx); internalErr != nil {
+			t.Fatalf("Failed to create bid indexes: %v", internalErr.Error())
+		}
+	}
+
+	cursor, err := bidRepo.Collection.Indexes().List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+
+	var indexes []struct {
+		Key bson.D `bson:"key"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatalf("Failed to decode indexes: %v", err)
+	}
+
+	hasUserIndex, hasAuctionAmountIndex := false, false
+	for _, index := range indexes {
+		if len(index.Key) == 1 && index.Key[0].Key == "user_id" {
+			hasUserIndex = true
+		}
+		if len(index.Key) >= 2 && index.Key[0].Key == "auction_id" && index.Key[1].Key == "amount_cents" {
+			hasAuctionAmountIndex = true
+		}
+	}
+
+	if !hasUserIndex {
+		t.Error("Expected an index on user_id")
+	}
+	if !hasAuctionAmountIndex {
+		t.Error("Expected an index on auction_id and amount_cents")
+	}
+}
+
+func TestNoBidsLandAfterConcurrentClose(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+
+	var closed atomic.Bool
+	var acceptedAfterClose atomic.Int64
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(bidder int) {
+			defer wg.Done()
+
+			for amount := 1; ; amount++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				startedAfterClose := closed.Load()
+				internalErr := bidRepo.CreateBidIfAuctionActive(
+					ctx, newBid(t, auctionEntity.Id, int64(bidder*1000+amount)))
+				if internalErr == nil && startedAfterClose {
+					acceptedAfterClose.Add(1)
+				}
+			}
+		}(i)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	if _, err := auctionRepo.Collection.UpdateOne(ctx,
+		bson.M{"_id": auctionEntity.Id, "status": auction_entity.Active},
+		bson.M{"$set": bson.M{"status": auction_entity.Completed}}); err != nil {
+		t.Fatalf("Failed to close auction: %v", err)
+	}
+	closed.Store(true)
+
+	time.Sleep(500 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if accepted := acceptedAfterClose.Load(); accepted != 0 {
+		t.Errorf("Expected zero bids to land after closure, got %d", accepted)
+	}
+}
+
+func TestConcurrentBidsKeepAuctionCounters(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auctionRepo := newAuctionRepository(database)
+	bidRepo := newBidRepository(database, auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity := createActiveAuction(t, auctionRepo)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(amount int64) {
+			defer wg.Done()
+
+			bidRepo.CreateBidIfAuctionActive(ctx, newBid(t, auctionEntity.Id, amount))
+		}(int64(i * 100))
+	}
+	wg.Wait()
+
+	found, internalErr := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find auction: %v", internalErr.Error())
+	}
+
+	storedBids, err := database.Collection("bids").CountDocuments(ctx, bson.M{"auction_id": auctionEntity.Id})
+	if err != nil {
+		t.Fatalf("Failed to count bids: %v", err)
+	}
+	if storedBids == 0 || found.BidCount != storedBids {
+		t.Errorf("Expected bid_count %d, got %d", storedBids, found.BidCount)
+	}
+	if found.Revision != storedBids {
+		t.Errorf("Expected a revision per stored bid, %d, got %d", storedBids, found.Revision)
+	}
+
+	winningBid, internalErr := bidRepo.FindWinningBidByAuctionId(ctx, auctionEntity.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find winning bid: %v", internalErr.Error())
+	}
+	if found.CurrentHighestAmount != winningBid.Amount {
+		t.Errorf("Expected current_highest_amount %d, got %d", winningBid.Amount, found.CurrentHighestAmount)
+	}
+}
@@ -0,0 +1,58 @@
+package bid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// duplicateBidIndex keeps a user from storing the same amount twice on an auction, so a
+// retried bid can't be inserted a second time. Legacy bids without amount_cents are left
+// out, since they would all collide on a null amount.
+var duplicateBidIndex = mongo.IndexModel{
+	Keys: bson.D{{Key: "auction_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "amount_cents", Value: 1}},
+	Options: options.Index().
+		SetName("auction_id_user_id_amount_cents_unique").
+		SetUnique(true).
+		SetPartialFilterExpression(bson.M{"amount_cents": bson.M{"$exists": true}}),
+}
+
+// FindBidByUserAndAmount returns the bid of userId on auctionId for exactly amount
+// cents, served by the duplicate bid index. It returns a not found error when there is
+// none.
+func (bd *BidRepository) FindBidByUserAndAmount(
+	ctx context.Context, auctionId, userId string, amount int64) (*bid_entity.Bid, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.FindBidByUserAndAmount", attribute.String("auction_id", auctionId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"auction_id": auctionId, "user_id": userId, "amount_cents": amount}
+
+	var bidEntityMongo BidEntityMongo
+	if err := bd.Collection.FindOne(ctx, filter).Decode(&bidEntityMongo); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("No bid of user %s found for this amount on auction %s", userId, auctionId))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find bid by user and amount", err,
+			zap.String("auction_id", auctionId), zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError("Error trying to find bid by user and amount").Wrap(err)
+	}
+
+	bidEntity := bidEntityMongo.toBidEntity()
+	return &bidEntity, nil
+}
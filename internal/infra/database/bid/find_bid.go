@@ -2,65 +2,237 @@ package bid
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
 	"fullcycle-auction_go/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"time"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 )
 
+// FindBidByAuctionId lists the bids of the auction by timestamp and then id, served by
+// the {auction_id, timestamp, _id} index. A cursor resumes the listing with a range on
+// that index instead of skipping the bids before it.
 func (bd *BidRepository) FindBidByAuctionId(
-	ctx context.Context, auctionId string) ([]bid_entity.Bid, *internal_error.InternalError) {
-	filter := bson.M{"auctionId": auctionId}
+	ctx context.Context,
+	auctionId string,
+	listFilter bid_entity.BidListFilter) ([]bid_entity.Bid, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.FindBidByAuctionId", attribute.String("auction_id", auctionId))
+	defer span.End()
 
-	cursor, err := bd.Collection.Find(ctx, filter)
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"auction_id": auctionId}
+
+	direction, past := -1, "$lt"
+	if listFilter.Ascending {
+		direction, past = 1, "$gt"
+	}
+
+	if after := listFilter.After; after != nil {
+		filter["$or"] = bson.A{
+			bson.M{"timestamp": bson.M{past: after.Timestamp}},
+			bson.M{"timestamp": after.Timestamp, "_id": bson.M{past: after.Id}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: direction}, {Key: "_id", Value: direction}})
+	if listFilter.Offset > 0 && listFilter.After == nil {
+		opts.SetSkip(int64(listFilter.Offset))
+	}
+	if listFilter.Limit > 0 {
+		opts.SetLimit(int64(listFilter.Limit))
+	}
+
+	cursor, err := bd.Collection.Find(ctx, filter, opts)
 	if err != nil {
-		logger.Error(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
+		logger.ErrorContext(ctx, "Error trying to find bids by auctionId", err, zap.String("auction_id", auctionId))
 		return nil, internal_error.NewInternalServerError(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).Wrap(err)
 	}
+	defer cursor.Close(ctx)
 
 	var bidEntitiesMongo []BidEntityMongo
 	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
-		logger.Error(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
+		logger.ErrorContext(ctx, "Error trying to find bids by auctionId", err, zap.String("auction_id", auctionId))
 		return nil, internal_error.NewInternalServerError(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).Wrap(err)
 	}
 
-	var bidEntities []bid_entity.Bid
+	bidEntities := make([]bid_entity.Bid, 0, len(bidEntitiesMongo))
 	for _, bidEntityMongo := range bidEntitiesMongo {
-		bidEntities = append(bidEntities, bid_entity.Bid{
-			Id:        bidEntityMongo.Id,
-			UserId:    bidEntityMongo.UserId,
-			AuctionId: bidEntityMongo.AuctionId,
-			Amount:    bidEntityMongo.Amount,
-			Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
-		})
+		bidEntities = append(bidEntities, bidEntityMongo.toBidEntity())
 	}
 
 	return bidEntities, nil
 }
 
+// findWinningBid picks the highest amount and, among equal amounts, the earliest bid.
+// It sorts on amount_cents, served by the {auction_id, amount_cents, timestamp} index,
+// which legacy float amounts only have once BackfillAmountCents ran.
+// It returns mongo.ErrNoDocuments when the auction has no bids.
+func (bd *BidRepository) findWinningBid(ctx context.Context, auctionId string) (*BidEntityMongo, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"auction_id": auctionId}}},
+		{{Key: "$sort", Value: bson.D{{Key: "amount_cents", Value: -1}, {Key: "timestamp", Value: 1}}}},
+		{{Key: "$limit", Value: 1}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var winningBids []BidEntityMongo
+	if err := cursor.All(ctx, &winningBids); err != nil {
+		return nil, err
+	}
+
+	if len(winningBids) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return &winningBids[0], nil
+}
+
 func (bd *BidRepository) FindWinningBidByAuctionId(
 	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
-	filter := bson.M{"auction_id": auctionId}
+	ctx, span := tracing.Start(ctx, "BidRepository.FindWinningBidByAuctionId", attribute.String("auction_id", auctionId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	bidEntityMongo, err := bd.findWinningBid(ctx, auctionId)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("No bids found for auctionId %s", auctionId)).Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find the auction winner", err, zap.String("auction_id", auctionId))
+		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner").Wrap(err)
+	}
+
+	bidEntity := bidEntityMongo.toBidEntity()
+	return &bidEntity, nil
+}
+
+func (bd *BidRepository) FindBidsByUserId(
+	ctx context.Context, userId string, onlyActiveAuctions bool) ([]bid_entity.Bid, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.FindBidsByUserId", attribute.String("user_id", userId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userId}}},
+	}
+
+	if onlyActiveAuctions {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from":         bd.AuctionRepository.Collection.Name(),
+				"localField":   "auction_id",
+				"foreignField": "_id",
+				"as":           "auction",
+			}}},
+			bson.D{{Key: "$match", Value: bson.M{"auction.status": auction_entity.Active}}},
+			bson.D{{Key: "$project", Value: bson.M{"auction": 0}}},
+		)
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find bids by userId", err, zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by userId %s", userId)).Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var bidEntitiesMongo []BidEntityMongo
+	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to find bids by userId", err, zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by userId %s", userId)).Wrap(err)
+	}
+
+	bidEntities := make([]bid_entity.Bid, 0, len(bidEntitiesMongo))
+	for _, bidEntityMongo := range bidEntitiesMongo {
+		bidEntities = append(bidEntities, bidEntityMongo.toBidEntity())
+	}
+
+	return bidEntities, nil
+}
+
+// GetAuctionBidStats computes the bid statistics of the auction in a single aggregation.
+func (bd *BidRepository) GetAuctionBidStats(
+	ctx context.Context, auctionId string) (*bid_entity.BidStats, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.GetAuctionBidStats", attribute.String("auction_id", auctionId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"auction_id": auctionId}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"count":   bson.M{"$sum": 1},
+			"highest": bson.M{"$max": auction.BidAmountCentsExpr},
+			"lowest":  bson.M{"$min": auction.BidAmountCentsExpr},
+			"average": bson.M{"$avg": auction.BidAmountCentsExpr},
+			"bidders": bson.M{"$addToSet": "$user_id"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"count":   1,
+			"highest": 1,
+			"lowest":  1,
+			"average": bson.M{"$toLong": bson.M{"$round": bson.A{"$average", 0}}},
+			"bidders": bson.M{"$size": "$bidders"},
+		}}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to compute bid stats", err, zap.String("auction_id", auctionId))
+		return nil, internal_error.NewInternalServerError("Error trying to compute bid stats").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Count   int64 `bson:"count"`
+		Highest int64 `bson:"highest"`
+		Lowest  int64 `bson:"lowest"`
+		Average int64 `bson:"average"`
+		Bidders int64 `bson:"bidders"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to compute bid stats", err, zap.String("auction_id", auctionId))
+		return nil, internal_error.NewInternalServerError("Error trying to compute bid stats").Wrap(err)
+	}
 
-	var bidEntityMongo BidEntityMongo
-	opts := options.FindOne().SetSort(bson.D{{"amount", -1}})
-	if err := bd.Collection.FindOne(ctx, filter, opts).Decode(&bidEntityMongo); err != nil {
-		logger.Error("Error trying to find the auction winner", err)
-		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner")
+	// No bids means no group at all
+	if len(results) == 0 {
+		return &bid_entity.BidStats{}, nil
 	}
 
-	return &bid_entity.Bid{
-		Id:        bidEntityMongo.Id,
-		UserId:    bidEntityMongo.UserId,
-		AuctionId: bidEntityMongo.AuctionId,
-		Amount:    bidEntityMongo.Amount,
-		Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
+	return &bid_entity.BidStats{
+		BidCount:      results[0].Count,
+		HighestBid:    results[0].Highest,
+		LowestBid:     results[0].Lowest,
+		AverageBid:    results[0].Average,
+		UniqueBidders: results[0].Bidders,
 	}, nil
 }
@@ -0,0 +1,88 @@
+package bid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// MaxBidEntityMongo is a user's maximum bid on an auction, unique per auction and user.
+type MaxBidEntityMongo struct {
+	AuctionId   string      `bson:"auction_id"`
+	UserId      string      `bson:"user_id"`
+	AmountCents int64       `bson:"amount_cents"`
+	Currency    string      `bson:"currency"`
+	Timestamp   dbtime.Time `bson:"timestamp"`
+}
+
+func (bd *BidRepository) SaveMaxBid(
+	ctx context.Context, maxBid *bid_entity.MaxBid) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "BidRepository.SaveMaxBid",
+		attribute.String("auction_id", maxBid.AuctionId), attribute.String("user_id", maxBid.UserId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"auction_id": maxBid.AuctionId, "user_id": maxBid.UserId}
+	maxBidEntityMongo := MaxBidEntityMongo{
+		AuctionId:   maxBid.AuctionId,
+		UserId:      maxBid.UserId,
+		AmountCents: maxBid.Amount,
+		Currency:    maxBid.Currency,
+		Timestamp:   dbtime.From(maxBid.Timestamp),
+	}
+
+	_, err := bd.MaxBidCollection.ReplaceOne(ctx, filter, maxBidEntityMongo, options.Replace().SetUpsert(true))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to save max bid", err,
+			zap.String("auction_id", maxBid.AuctionId), zap.String("user_id", maxBid.UserId))
+		return internal_error.NewInternalServerError("Error trying to save max bid").Wrap(err)
+	}
+
+	return nil
+}
+
+func (bd *BidRepository) FindMaxBid(
+	ctx context.Context, auctionId, userId string) (*bid_entity.MaxBid, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.FindMaxBid",
+		attribute.String("auction_id", auctionId), attribute.String("user_id", userId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	var maxBidEntityMongo MaxBidEntityMongo
+	err := bd.MaxBidCollection.FindOne(ctx, bson.M{"auction_id": auctionId, "user_id": userId}).
+		Decode(&maxBidEntityMongo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("No maximum bid found for user %s on auction %s", userId, auctionId)).Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find max bid", err,
+			zap.String("auction_id", auctionId), zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError("Error trying to find max bid").Wrap(err)
+	}
+
+	return &bid_entity.MaxBid{
+		AuctionId: maxBidEntityMongo.AuctionId,
+		UserId:    maxBidEntityMongo.UserId,
+		Amount:    maxBidEntityMongo.AmountCents,
+		Currency:  maxBidEntityMongo.Currency,
+		Timestamp: maxBidEntityMongo.Timestamp.UTC(),
+	}, nil
+}
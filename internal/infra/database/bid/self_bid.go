@@ -0,0 +1,70 @@
+package bid
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CountBidsByUserOnOwnAuctions joins every bid with its auction to find the ones placed
+// by the seller. It reads the whole collection, so it is meant for occasional reports.
+func (bd *BidRepository) CountBidsByUserOnOwnAuctions(
+	ctx context.Context) ([]bid_entity.SelfBidCount, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.CountBidsByUserOnOwnAuctions")
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         bd.AuctionRepository.Collection.Name(),
+			"localField":   "auction_id",
+			"foreignField": "_id",
+			"as":           "auction",
+		}}},
+		{{Key: "$unwind", Value: "$auction"}},
+		// Legacy auctions have no seller_id, which never equals a user_id
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$user_id", "$auction.seller_id"}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$user_id",
+			"bids":     bson.M{"$sum": 1},
+			"auctions": bson.M{"$addToSet": "$auction_id"},
+		}}},
+		{{Key: "$project", Value: bson.M{"bids": 1, "auctions": bson.M{"$size": "$auctions"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "bids", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count self bids", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count self bids").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		UserId   string `bson:"_id"`
+		Bids     int64  `bson:"bids"`
+		Auctions int64  `bson:"auctions"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to count self bids", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count self bids").Wrap(err)
+	}
+
+	counts := make([]bid_entity.SelfBidCount, 0, len(results))
+	for _, result := range results {
+		counts = append(counts, bid_entity.SelfBidCount{
+			UserId:       result.UserId,
+			AuctionCount: result.Auctions,
+			BidCount:     result.Bids,
+		})
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,116 @@
+package bid
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CountRecentBids counts the bids of the last day and, among them, those of the last
+// hour in one pass over the timestamp index.
+func (bd *BidRepository) CountRecentBids(
+	ctx context.Context, now time.Time) (*bid_entity.BidActivity, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.CountRecentBids")
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	hourAgo := now.Add(-time.Hour)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"timestamp": bson.M{"$gte": now.Add(-24 * time.Hour)}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      nil,
+			"last_day": bson.M{"$sum": 1},
+			"last_hour": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gte": bson.A{"$timestamp", hourAgo}}, 1, 0}}},
+		}}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to count recent bids", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count recent bids").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		LastHour int64 `bson:"last_hour"`
+		LastDay  int64 `bson:"last_day"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode recent bid counts", err)
+		return nil, internal_error.NewInternalServerError("Error trying to count recent bids").Wrap(err)
+	}
+
+	activity := &bid_entity.BidActivity{}
+	if len(results) > 0 {
+		activity.LastHour, activity.LastDay = results[0].LastHour, results[0].LastDay
+	}
+
+	return activity, nil
+}
+
+// AverageTimeToFirstBid starts from the auctions, found by the timestamp index, and
+// looks up only the earliest bid of each through the {auction_id, timestamp} index. An
+// auction starts at its start_time when it was scheduled and at its creation otherwise.
+func (bd *BidRepository) AverageTimeToFirstBid(
+	ctx context.Context, since time.Time) (time.Duration, bool, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.AverageTimeToFirstBid")
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"timestamp": bson.M{"$gte": since}, "deleted_at": nil}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": bd.Collection.Name(),
+			"let":  bson.M{"auction_id": "$_id"},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$auction_id", "$$auction_id"}}}}},
+				{{Key: "$sort", Value: bson.D{{Key: "auction_id", Value: 1}, {Key: "timestamp", Value: 1}}}},
+				{{Key: "$limit", Value: 1}},
+				{{Key: "$project", Value: bson.M{"_id": 0, "timestamp": 1}}},
+			},
+			"as": "first_bid",
+		}}},
+		{{Key: "$unwind", Value: "$first_bid"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"average_millis": bson.M{"$avg": bson.M{"$subtract": bson.A{
+				"$first_bid.timestamp",
+				bson.M{"$ifNull": bson.A{"$start_time", "$timestamp"}},
+			}}},
+		}}},
+	}
+
+	cursor, err := bd.AuctionRepository.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to average the time to first bid", err)
+		return 0, false, internal_error.NewInternalServerError("Error trying to average the time to first bid").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		AverageMillis float64 `bson:"average_millis"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode the time to first bid", err)
+		return 0, false, internal_error.NewInternalServerError("Error trying to average the time to first bid").Wrap(err)
+	}
+
+	if len(results) == 0 {
+		return 0, false, nil
+	}
+
+	return time.Duration(results[0].AverageMillis * float64(time.Millisecond)), true, nil
+}
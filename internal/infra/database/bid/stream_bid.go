@@ -0,0 +1,81 @@
+package bid
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// streamBatchSize is how many bids each round trip of the export cursor fetches
+const streamBatchSize = 1000
+
+// StreamBids reads the bids matching exportFilter from a cursor, oldest first and then by
+// id, decoding and handing them to fn one at a time. The cursor lives as long as the
+// export does, so it is bounded by ctx rather than DB_OPERATION_TIMEOUT_MS, and ctx is
+// checked again before each batch is fetched: a client that goes away closes it right
+// after the batch it is reading.
+func (bd *BidRepository) StreamBids(
+	ctx context.Context,
+	exportFilter bid_entity.BidExportFilter,
+	fn func(bid bid_entity.Bid) error) *internal_error.InternalError {
+	ctx, span := tracing.Start(ctx, "BidRepository.StreamBids")
+	defer span.End()
+
+	filter := bson.M{}
+	if exportFilter.AuctionId != "" {
+		filter["auction_id"] = exportFilter.AuctionId
+	}
+	timestamp := bson.M{}
+	if !exportFilter.From.IsZero() {
+		timestamp["$gte"] = exportFilter.From
+	}
+	if !exportFilter.To.IsZero() {
+		timestamp["$lte"] = exportFilter.To
+	}
+	if len(timestamp) > 0 {
+		filter["timestamp"] = timestamp
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+		SetBatchSize(streamBatchSize)
+	cursor, err := bd.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error finding bids", err)
+		return internal_error.NewInternalServerError("Error finding bids").Wrap(err)
+	}
+	defer cursor.Close(context.Background())
+
+	for {
+		// Next only looks at ctx when it has to fetch the next batch
+		if cursor.RemainingBatchLength() == 0 && ctx.Err() != nil {
+			return internal_error.NewInternalServerError("Error streaming bids").Wrap(ctx.Err())
+		}
+		if !cursor.Next(ctx) {
+			break
+		}
+
+		var bidEntityMongo BidEntityMongo
+		if err := cursor.Decode(&bidEntityMongo); err != nil {
+			logger.ErrorContext(ctx, "Error decoding bids", err)
+			return internal_error.NewInternalServerError("Error decoding bids").Wrap(err)
+		}
+
+		if err := fn(bidEntityMongo.toBidEntity()); err != nil {
+			return internal_error.NewInternalServerError("Error streaming bids").Wrap(err)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		logger.ErrorContext(ctx, "Error reading bids", err)
+		return internal_error.NewInternalServerError("Error reading bids").Wrap(err)
+	}
+
+	return nil
+}
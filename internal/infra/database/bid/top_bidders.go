@@ -0,0 +1,97 @@
+package bid
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// topBiddersOrder ranks the users by bid count, then total amount, then id and currency
+// to break the ties the same way every time.
+var topBiddersOrder = bson.D{
+	{Key: "bid_count", Value: -1}, {Key: "total_amount", Value: -1},
+	{Key: "_id.user_id", Value: 1}, {Key: "_id.currency", Value: 1},
+}
+
+// AggregateTopBidders groups the bids placed between from and to by user and currency,
+// joining the names of only the users that made the cut. Bids stored without a currency
+// are in money.DefaultCurrency.
+func (bd *BidRepository) AggregateTopBidders(
+	ctx context.Context, from, to time.Time, limit int) ([]bid_entity.TopBidder, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.AggregateTopBidders", attribute.Int("limit", limit))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"timestamp": bson.M{"$gte": from, "$lte": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"user_id":  "$user_id",
+				"currency": bson.M{"$ifNull": bson.A{"$currency", money.DefaultCurrency}},
+			},
+			"bid_count":    bson.M{"$sum": 1},
+			"total_amount": bson.M{"$sum": auction.BidAmountCentsExpr},
+		}}},
+		{{Key: "$sort", Value: topBiddersOrder}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         bd.UserCollection.Name(),
+			"localField":   "_id.user_id",
+			"foreignField": "_id",
+			"as":           "user",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"bid_count":    1,
+			"total_amount": 1,
+			"user_name":    bson.M{"$arrayElemAt": bson.A{"$user.name", 0}},
+		}}},
+		// $lookup doesn't keep the order, so the ranking is sorted again
+		{{Key: "$sort", Value: topBiddersOrder}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate top bidders", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate top bidders").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Id struct {
+			UserId   string `bson:"user_id"`
+			Currency string `bson:"currency"`
+		} `bson:"_id"`
+		UserName    string `bson:"user_name"`
+		BidCount    int64  `bson:"bid_count"`
+		TotalAmount int64  `bson:"total_amount"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate top bidders", err)
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate top bidders").Wrap(err)
+	}
+
+	topBidders := make([]bid_entity.TopBidder, 0, len(results))
+	for _, result := range results {
+		topBidders = append(topBidders, bid_entity.TopBidder{
+			UserId:      result.Id.UserId,
+			UserName:    result.UserName,
+			Currency:    result.Id.Currency,
+			BidCount:    result.BidCount,
+			TotalAmount: result.TotalAmount,
+		})
+	}
+
+	return topBidders, nil
+}
@@ -0,0 +1,87 @@
+package bid
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/infra/tracing"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// AggregateUserStats groups the bids of userId by auction, then joins each auction's
+// outcome and winner snapshot to tell the ones the user won.
+func (bd *BidRepository) AggregateUserStats(
+	ctx context.Context, userId string) (*bid_entity.UserBidStats, *internal_error.InternalError) {
+	ctx, span := tracing.Start(ctx, "BidRepository.AggregateUserStats", attribute.String("user_id", userId))
+	defer span.End()
+
+	ctx, cancel := dbtimeout.WithTimeout(ctx, bd.operationTimeout)
+	defer cancel()
+
+	won := bson.M{"$and": bson.A{
+		bson.M{"$in": bson.A{"$auction.outcome", auction_entity.SaleOutcomes}},
+		bson.M{"$in": bson.A{"$auction.winning_bid_id", "$bid_ids"}},
+	}}
+	// Winners pay the settlement amount, the winning amount for the snapshots recorded
+	// before it existed
+	paid := bson.M{"$ifNull": bson.A{"$auction.settlement_amount", "$auction.winning_amount"}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userId}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$auction_id",
+			"bid_count": bson.M{"$sum": 1},
+			"bid_ids":   bson.M{"$push": "$_id"},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         bd.AuctionRepository.Collection.Name(),
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "auction",
+		}}},
+		{{Key: "$set", Value: bson.M{"auction": bson.M{"$arrayElemAt": bson.A{"$auction", 0}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":             nil,
+			"auctions_bid_on": bson.M{"$sum": 1},
+			"bid_count":       bson.M{"$sum": "$bid_count"},
+			"auctions_won":    bson.M{"$sum": bson.M{"$cond": bson.A{won, 1, 0}}},
+			"total_spent":     bson.M{"$sum": bson.M{"$cond": bson.A{won, paid, 0}}},
+		}}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate user bid stats", err, zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate user bid stats").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		AuctionsBidOn int64 `bson:"auctions_bid_on"`
+		BidCount      int64 `bson:"bid_count"`
+		AuctionsWon   int64 `bson:"auctions_won"`
+		TotalSpent    int64 `bson:"total_spent"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		logger.ErrorContext(ctx, "Error trying to aggregate user bid stats", err, zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError("Error trying to aggregate user bid stats").Wrap(err)
+	}
+
+	// A user without bids has no group at all
+	if len(results) == 0 {
+		return &bid_entity.UserBidStats{}, nil
+	}
+
+	return &bid_entity.UserBidStats{
+		AuctionsBidOn: results[0].AuctionsBidOn,
+		BidCount:      results[0].BidCount,
+		AuctionsWon:   results[0].AuctionsWon,
+		TotalSpent:    results[0].TotalSpent,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package category
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type CategoryEntityMongo struct {
+	Id   string `bson:"_id"`
+	Name string `bson:"name"`
+
+	// Key is category_entity.Key of the name, unique so "Electronics" and "electronics"
+	// can't both be created
+	Key string `bson:"key"`
+
+	// Slug is unique too, while PreviousSlugs only back the lookup by an old slug
+	Slug          string   `bson:"slug"`
+	PreviousSlugs []string `bson:"previous_slugs,omitempty"`
+
+	DefaultDurationSeconds int64 `bson:"default_duration_seconds,omitempty"`
+}
+
+type CategoryRepository struct {
+	Collection *mongo.Collection
+
+	// operationTimeout bounds each operation, see dbtimeout.WithTimeout
+	operationTimeout time.Duration
+}
+
+func NewCategoryRepository(database *mongo.Database, operationTimeout time.Duration) *CategoryRepository {
+	return &CategoryRepository{
+		Collection:       database.Collection("categories"),
+		operationTimeout: operationTimeout,
+	}
+}
+
+// EnsureIndexes creates the unique key and slug indexes CreateCategory relies on to
+// reject duplicates that only differ in case or punctuation, and the index on the
+// previous slugs FindCategoryBySlug falls back to. Categories created before slugs must
+// have gone through BackfillSlugs first.
+func (cr *CategoryRepository) EnsureIndexes(ctx context.Context) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cr.operationTimeout)
+	defer cancel()
+
+	_, err := cr.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "previous_slugs", Value: 1}},
+		},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create category indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create category indexes").Wrap(err)
+	}
+
+	return nil
+}
+
+func (cr *CategoryRepository) CreateCategory(
+	ctx context.Context, categoryEntity *category_entity.Category) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cr.operationTimeout)
+	defer cancel()
+
+	if _, err := cr.Collection.InsertOne(ctx, toCategoryEntityMongo(categoryEntity)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return internal_error.NewConflictError("A category with this name already exists").Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to insert category", err, zap.String("category", categoryEntity.Name))
+		return internal_error.NewInternalServerError("Error trying to insert category").Wrap(err)
+	}
+
+	return nil
+}
+
+func toCategoryEntityMongo(categoryEntity *category_entity.Category) *CategoryEntityMongo {
+	return &CategoryEntityMongo{
+		Id:            categoryEntity.Id,
+		Name:          categoryEntity.Name,
+		Key:           category_entity.Key(categoryEntity.Name),
+		Slug:          categoryEntity.Slug,
+		PreviousSlugs: categoryEntity.PreviousSlugs,
+
+		DefaultDurationSeconds: categoryEntity.DefaultDurationSeconds,
+	}
+}
+
+func (cm *CategoryEntityMongo) toCategoryEntity() *category_entity.Category {
+	return &category_entity.Category{
+		Id:            cm.Id,
+		Name:          cm.Name,
+		Slug:          cm.Slug,
+		PreviousSlugs: cm.PreviousSlugs,
+
+		DefaultDurationSeconds: cm.DefaultDurationSeconds,
+	}
+}
@@ -0,0 +1,143 @@
+package category_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/infra/database/category"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testDBName = "category_test_db"
+
+// mongoSkipReason caches the first connection failure so the remaining tests
+// skip right away instead of waiting for the server selection timeout again.
+var mongoSkipReason string
+
+func setupTestDB(t *testing.T) (*mongo.Database, func()) {
+	if mongoSkipReason != "" {
+		t.Skip(mongoSkipReason)
+	}
+
+	mongoURL := os.Getenv("MONGODB_URL")
+	if mongoURL == "" {
+		mongoURL = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		t.Skip(mongoSkipReason)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB ping failed: %v", err)
+		t.Skip(mongoSkipReason)
+	}
+
+	database := client.Database(testDBName)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	}
+
+	return database, cleanup
+}
+
+func TestCreateAndFindCategories(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := category.NewCategoryRepository(database, 0)
+	ctx := context.Background()
+
+	if internalErr := repo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create category indexes: %v", internalErr.Error())
+	}
+
+	for _, name := range []string{"Electronics", "Books"} {
+		categoryEntity, internalErr := category_entity.CreateCategory(name, auction_entity.DurationBounds{})
+		if internalErr != nil {
+			t.Fatalf("Failed to create category entity: %v", internalErr.Error())
+		}
+		if internalErr := repo.CreateCategory(ctx, categoryEntity); internalErr != nil {
+			t.Fatalf("Failed to create category: %v", internalErr.Error())
+		}
+	}
+
+	duplicate, _ := category_entity.CreateCategory("ELECTRONICS", auction_entity.DurationBounds{})
+	if internalErr := repo.CreateCategory(ctx, duplicate); internalErr == nil || internalErr.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict for a duplicate category, got %v", internalErr)
+	}
+
+	categories, internalErr := repo.FindAllCategories(ctx)
+	if internalErr != nil {
+		t.Fatalf("Failed to find categories: %v", internalErr.Error())
+	}
+	if len(categories) != 2 || categories[0].Name != "Books" || categories[1].Name != "Electronics" {
+		t.Errorf("Expected Books and Electronics ordered by name, got %+v", categories)
+	}
+}
+
+func TestFindCategoryBySlugFollowsRenames(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := category.NewCategoryRepository(database, 0)
+	ctx := context.Background()
+
+	// A category stored before slugs existed gets one from the backfill
+	legacyId := "legacy-category"
+	if _, err := repo.Collection.InsertOne(ctx, bson.M{"_id": legacyId, "name": "Home & Garden", "key": "home & garden"}); err != nil {
+		t.Fatalf("Failed to insert legacy category: %v", err)
+	}
+	if backfilled, internalErr := repo.BackfillSlugs(ctx); internalErr != nil || backfilled != 1 {
+		t.Fatalf("Expected 1 category to be backfilled, got %d, %v", backfilled, internalErr)
+	}
+	if internalErr := repo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create category indexes: %v", internalErr.Error())
+	}
+
+	legacy, internalErr := repo.FindCategoryBySlug(ctx, "home-garden")
+	if internalErr != nil || legacy.Id != legacyId {
+		t.Fatalf("Expected to find the legacy category by its slug, got %+v, %v", legacy, internalErr)
+	}
+
+	if internalErr := legacy.Rename("Garden"); internalErr != nil {
+		t.Fatalf("Failed to rename the category: %v", internalErr.Error())
+	}
+	if internalErr := repo.UpdateCategory(ctx, legacy); internalErr != nil {
+		t.Fatalf("Failed to update the category: %v", internalErr.Error())
+	}
+
+	for _, slug := range []string{"garden", "home-garden"} {
+		found, internalErr := repo.FindCategoryBySlug(ctx, slug)
+		if internalErr != nil || found.Name != "Garden" {
+			t.Errorf("Expected %s to lead to the renamed category, got %+v, %v", slug, found, internalErr)
+		}
+	}
+
+	if _, internalErr := repo.FindCategoryBySlug(ctx, "unknown"); internalErr == nil || internalErr.Err != internal_error.CategoryNotFound {
+		t.Errorf("Expected category_not_found for an unknown slug, got %v", internalErr)
+	}
+
+	duplicate, _ := category_entity.CreateCategory("Garden!", auction_entity.DurationBounds{})
+	if internalErr := repo.CreateCategory(ctx, duplicate); internalErr == nil || internalErr.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict for a category with the same slug, got %v", internalErr)
+	}
+}
@@ -0,0 +1,94 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+func (cr *CategoryRepository) FindAllCategories(
+	ctx context.Context) ([]category_entity.Category, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cr.operationTimeout)
+	defer cancel()
+
+	cursor, err := cr.Collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find categories", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find categories").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var categoriesMongo []CategoryEntityMongo
+	if err := cursor.All(ctx, &categoriesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode categories", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find categories").Wrap(err)
+	}
+
+	categories := make([]category_entity.Category, 0, len(categoriesMongo))
+	for i := range categoriesMongo {
+		categories = append(categories, *categoriesMongo[i].toCategoryEntity())
+	}
+
+	return categories, nil
+}
+
+func (cr *CategoryRepository) FindCategoryById(
+	ctx context.Context, id string) (*category_entity.Category, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cr.operationTimeout)
+	defer cancel()
+
+	var categoryEntityMongo CategoryEntityMongo
+	if err := cr.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&categoryEntityMongo); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewNotFoundError(fmt.Sprintf("Category not found with this id = %s", id))
+		}
+
+		logger.ErrorContext(ctx, "Error trying to find category by id", err, zap.String("category_id", id))
+		return nil, internal_error.NewInternalServerError("Error trying to find category by id").Wrap(err)
+	}
+
+	return categoryEntityMongo.toCategoryEntity(), nil
+}
+
+// FindCategoryBySlug looks slug up among the current and previous slugs at once. A
+// previous slug can be taken again by a newer category, which then wins the lookup.
+func (cr *CategoryRepository) FindCategoryBySlug(
+	ctx context.Context, slug string) (*category_entity.Category, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cr.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{bson.M{"slug": slug}, bson.M{"previous_slugs": slug}}}
+	cursor, err := cr.Collection.Find(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find category by slug", err, zap.String("slug", slug))
+		return nil, internal_error.NewInternalServerError("Error trying to find category by slug").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var categoriesMongo []CategoryEntityMongo
+	if err := cursor.All(ctx, &categoriesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode categories", err, zap.String("slug", slug))
+		return nil, internal_error.NewInternalServerError("Error trying to find category by slug").Wrap(err)
+	}
+
+	if len(categoriesMongo) == 0 {
+		return nil, internal_error.ErrCategoryNotFound
+	}
+	for i := range categoriesMongo {
+		if categoriesMongo[i].Slug == slug {
+			return categoriesMongo[i].toCategoryEntity(), nil
+		}
+	}
+
+	return categoriesMongo[0].toCategoryEntity(), nil
+}
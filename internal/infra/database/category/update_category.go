@@ -0,0 +1,89 @@
+package category
+
+import (
+	"context"
+	"fmt"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func (cr *CategoryRepository) UpdateCategory(
+	ctx context.Context, categoryEntity *category_entity.Category) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cr.operationTimeout)
+	defer cancel()
+
+	categoryEntityMongo := toCategoryEntityMongo(categoryEntity)
+	update := bson.M{"$set": bson.M{
+		"name":           categoryEntityMongo.Name,
+		"key":            categoryEntityMongo.Key,
+		"slug":           categoryEntityMongo.Slug,
+		"previous_slugs": categoryEntityMongo.PreviousSlugs,
+	}}
+
+	result, err := cr.Collection.UpdateOne(ctx, bson.M{"_id": categoryEntity.Id}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return internal_error.NewConflictError("A category with this name already exists").Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to update category", err, zap.String("category_id", categoryEntity.Id))
+		return internal_error.NewInternalServerError("Error trying to update category").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Category not found with this id = %s", categoryEntity.Id))
+	}
+
+	return nil
+}
+
+// BackfillSlugs is a one-time backfill for categories created before they had a slug,
+// which has to run before EnsureIndexes makes slugs unique. A category whose name makes
+// the slug of another one, e.g. "C++" and "C", gets its id appended to it. It returns
+// how many categories changed and is safe to run again.
+func (cr *CategoryRepository) BackfillSlugs(ctx context.Context) (int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, cr.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"slug": bson.M{"$in": bson.A{nil, ""}}}
+	cursor, err := cr.Collection.Find(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to find categories without slug", err)
+		return 0, internal_error.NewInternalServerError("Error trying to backfill category slugs").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var categoriesMongo []CategoryEntityMongo
+	if err := cursor.All(ctx, &categoriesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error trying to decode categories", err)
+		return 0, internal_error.NewInternalServerError("Error trying to backfill category slugs").Wrap(err)
+	}
+
+	var backfilled int64
+	for _, category := range categoriesMongo {
+		slug := category_entity.Slugify(category.Name)
+		taken, err := cr.Collection.CountDocuments(ctx, bson.M{"slug": slug})
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to backfill category slugs", err, zap.String("category_id", category.Id))
+			return backfilled, internal_error.NewInternalServerError("Error trying to backfill category slugs").Wrap(err)
+		}
+		if taken > 0 || slug == "" {
+			slug = category_entity.Slugify(slug + " " + category.Id)
+		}
+
+		if _, err := cr.Collection.UpdateOne(ctx, bson.M{"_id": category.Id}, bson.M{"$set": bson.M{"slug": slug}}); err != nil {
+			logger.ErrorContext(ctx, "Error trying to backfill category slugs", err, zap.String("category_id", category.Id))
+			return backfilled, internal_error.NewInternalServerError("Error trying to backfill category slugs").Wrap(err)
+		}
+		backfilled++
+	}
+
+	return backfilled, nil
+}
@@ -0,0 +1,96 @@
+package dbtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Time is a timestamp stored as a BSON date, which keeps milliseconds. Documents written
+// before held whole Unix seconds as a number instead, which it reads too, so it can
+// decode a collection BackfillDates didn't convert yet.
+type Time struct {
+	t time.Time
+}
+
+// From truncates t to the milliseconds MongoDB keeps, so a value read back compares
+// equal to the one written.
+func From(t time.Time) Time {
+	return Time{t.Truncate(time.Millisecond)}
+}
+
+// UTC returns the time in UTC, the zero time when none was stored.
+func (t Time) UTC() time.Time {
+	if t.t.IsZero() {
+		return time.Time{}
+	}
+
+	return t.t.UTC()
+}
+
+// IsZero reports whether no time was stored.
+func (t Time) IsZero() bool {
+	return t.t.IsZero()
+}
+
+// MarshalBSONValue stores t as a BSON date, and the zero time as null rather than a date
+// in year 1, which reads back as the zero time.
+func (t Time) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if t.t.IsZero() {
+		return bsontype.Null, nil, nil
+	}
+
+	return bson.MarshalValue(primitive.NewDateTimeFromTime(t.t))
+}
+
+// UnmarshalBSONValue reads a BSON date or, from documents written before dates, a
+// number of Unix seconds. Null reads as the zero time.
+func (t *Time) UnmarshalBSONValue(bsonType bsontype.Type, data []byte) error {
+	value := bson.RawValue{Type: bsonType, Value: data}
+
+	switch bsonType {
+	case bsontype.DateTime:
+		t.t = value.Time()
+	case bsontype.Int64:
+		t.t = time.Unix(value.Int64(), 0)
+	case bsontype.Int32:
+		t.t = time.Unix(int64(value.Int32()), 0)
+	case bsontype.Double:
+		t.t = time.UnixMilli(int64(value.Double() * 1000))
+	case bsontype.Null, bsontype.Undefined:
+		t.t = time.Time{}
+	default:
+		return fmt.Errorf("cannot decode %v into a timestamp", bsonType)
+	}
+
+	return nil
+}
+
+// BackfillDates converts the field of the documents of collection still holding Unix
+// seconds into dates, in a single update run by the server. Range queries only match
+// values of the type they are given, so every document must be converted before they
+// look up the field by date. A zero, which stood for no time, becomes null, as Time
+// stores it. It returns how many documents changed and is safe to run again.
+func BackfillDates(ctx context.Context, collection *mongo.Collection, field string) (int64, error) {
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			field: bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$" + field, 0}},
+				nil,
+				bson.M{"$toDate": bson.M{"$multiply": bson.A{"$" + field, 1000}}},
+			}},
+		}}},
+	}
+
+	result, err := collection.UpdateMany(ctx, bson.M{field: bson.M{"$type": "number"}}, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,108 @@
+package dbtime_test
+
+import (
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type document struct {
+	Timestamp dbtime.Time  `bson:"timestamp"`
+	ClosedAt  *dbtime.Time `bson:"closed_at"`
+}
+
+func TestTimeIsStoredAsADateWithMilliseconds(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.FixedZone("BRT", -3*60*60))
+
+	data, err := bson.Marshal(document{Timestamp: dbtime.From(at)})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	var raw bson.M
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if stored, ok := raw["timestamp"].(primitive.DateTime); !ok || int64(stored) != at.UnixMilli() {
+		t.Errorf("Expected a date of %d ms, got %T %v", at.UnixMilli(), raw["timestamp"], raw["timestamp"])
+	}
+
+	var decoded document
+	if err := bson.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	want := at.Truncate(time.Millisecond).UTC()
+	if got := decoded.Timestamp.UTC(); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if decoded.ClosedAt != nil && !decoded.ClosedAt.IsZero() {
+		t.Errorf("Expected a null date to read as the zero time, got %v", decoded.ClosedAt.UTC())
+	}
+}
+
+func TestTimeReadsLegacyUnixSeconds(t *testing.T) {
+	seconds := time.Date(2023, 11, 5, 8, 0, 0, 0, time.UTC).Unix()
+
+	testCases := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "Int64", value: seconds},
+		{name: "Int32", value: int32(seconds)},
+		{name: "Double", value: float64(seconds)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := bson.Marshal(bson.M{"timestamp": tc.value, "closed_at": tc.value})
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+
+			var decoded document
+			if err := bson.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Failed to read a legacy timestamp: %v", err)
+			}
+			if got := decoded.Timestamp.UTC(); got.Unix() != seconds || got.Location() != time.UTC {
+				t.Errorf("Expected %d seconds in UTC, got %v", seconds, got)
+			}
+			if decoded.ClosedAt == nil || decoded.ClosedAt.UTC().Unix() != seconds {
+				t.Errorf("Expected the pointer to read %d seconds too, got %v", seconds, decoded.ClosedAt)
+			}
+		})
+	}
+}
+
+func TestTimeRejectsOtherTypes(t *testing.T) {
+	data, _ := bson.Marshal(bson.M{"timestamp": "yesterday"})
+
+	var decoded document
+	if err := bson.Unmarshal(data, &decoded); err == nil {
+		t.Errorf("Expected a string to fail, got %v", decoded.Timestamp.UTC())
+	}
+}
+
+func TestZeroTimeIsStoredAsNull(t *testing.T) {
+	data, err := bson.Marshal(document{})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	var raw bson.Raw = data
+	if value := raw.Lookup("timestamp"); value.Type != bsontype.Null {
+		t.Errorf("Expected the zero time to be stored as null, got %v %v", value.Type, value)
+	}
+
+	var decoded document
+	if err := bson.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if !decoded.Timestamp.IsZero() {
+		t.Errorf("Expected null to read back as the zero time, got %v", decoded.Timestamp.UTC())
+	}
+}
@@ -0,0 +1,20 @@
+package dbtimeout
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultOperationTimeout bounds the operations of a repository built without a timeout
+const DefaultOperationTimeout = 5 * time.Second
+
+// WithTimeout bounds a repository operation by timeout, DefaultOperationTimeout when it
+// is zero, so a slow or unreachable MongoDB fails the call instead of holding it
+// indefinitely. A deadline already set on ctx that is sooner than the timeout is kept.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultOperationTimeout
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,51 @@
+package dbtimeout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+)
+
+func TestWithTimeoutUsesConfiguredTimeout(t *testing.T) {
+	ctx, cancel := dbtimeout.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected the context to have a deadline")
+	}
+	if remaining := time.Until(deadline); remaining > 50*time.Millisecond {
+		t.Fatalf("expected a deadline within 50ms, got %s", remaining)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the context to time out")
+	}
+}
+
+func TestWithTimeoutKeepsSoonerDeadline(t *testing.T) {
+	parent, parentCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer parentCancel()
+
+	ctx, cancel := dbtimeout.WithTimeout(parent, time.Minute)
+	defer cancel()
+
+	parentDeadline, _ := parent.Deadline()
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(parentDeadline) {
+		t.Fatalf("expected the parent deadline %s, got %s", parentDeadline, deadline)
+	}
+}
+
+func TestWithTimeoutFallsBackToDefault(t *testing.T) {
+	ctx, cancel := dbtimeout.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	deadline, _ := ctx.Deadline()
+	if remaining := time.Until(deadline); remaining > 5*time.Second || remaining < 4*time.Second {
+		t.Fatalf("expected the 5s default, got %s", remaining)
+	}
+}
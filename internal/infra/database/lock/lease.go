@@ -0,0 +1,130 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// LeaseMongo is a lease held by Owner until ExpiresAt, unless renewed before.
+type LeaseMongo struct {
+	Name      string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	RenewedAt time.Time `bson:"renewed_at"`
+}
+
+// LeaseRepository hands out named leases from the locks collection, so a job only runs
+// on one instance at a time. Each repository is an owner of its own: two instances, or
+// two repositories in a test, compete for the same leases.
+//
+// The holder keeps a lease by renewing it before it expires. The expiry is compared
+// against the clock of the instance trying to take the lease over, so the clocks of
+// the instances must agree to well within the lease duration. Nothing else expires the
+// leases: a TTL index would judge them by the clock of the MongoDB server instead.
+type LeaseRepository struct {
+	Collection *mongo.Collection
+	Clock      clock.Clock
+
+	// Owner identifies this repository in the leases it holds
+	Owner string
+
+	// operationTimeout bounds each operation, see dbtimeout.WithTimeout
+	operationTimeout time.Duration
+}
+
+func NewLeaseRepository(database *mongo.Database, clk clock.Clock, operationTimeout time.Duration) *LeaseRepository {
+	owner := uuid.New().String()
+	if hostname, err := os.Hostname(); err == nil {
+		owner = hostname + "/" + owner
+	}
+
+	return &LeaseRepository{
+		Collection:       database.Collection("locks"),
+		Clock:            clk,
+		Owner:            owner,
+		operationTimeout: operationTimeout,
+	}
+}
+
+// expiresAtTTLIndex is the TTL index earlier versions created on expires_at. The server
+// compared it against its own clock, which isn't the one the leases are stamped with
+// when it is accelerated or faked, so it could delete a lease its holder still had.
+const expiresAtTTLIndex = "expires_at_1"
+
+// EnsureIndexes drops the TTL index earlier versions created on expires_at. A lease
+// left behind by a crashed instance stays in the collection until Acquire takes it
+// over, which it does once the lease expires by the repository clock.
+func (lr *LeaseRepository) EnsureIndexes(ctx context.Context) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, lr.operationTimeout)
+	defer cancel()
+
+	_, err := lr.Collection.Indexes().DropOne(ctx, expiresAtTTLIndex)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && (commandErr.Name == "IndexNotFound" || commandErr.Name == "NamespaceNotFound") {
+		return nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to drop the lease TTL index", err)
+		return internal_error.NewInternalServerError("Error trying to drop the lease TTL index").Wrap(err)
+	}
+
+	return nil
+}
+
+// Acquire takes the lease name for ttl, or renews it when this owner already holds it.
+// It returns false while another owner holds it and it hasn't expired yet.
+func (lr *LeaseRepository) Acquire(
+	ctx context.Context, name string, ttl time.Duration) (bool, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, lr.operationTimeout)
+	defer cancel()
+
+	now := lr.Clock.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": lr.Owner},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": lr.Owner, "expires_at": now.Add(ttl), "renewed_at": now}}
+
+	// A lease held by another owner doesn't match, so the upsert tries to insert it again
+	// and fails on its id
+	_, err := lr.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to acquire lease", err, zap.String("lease", name))
+		return false, internal_error.NewInternalServerError("Error trying to acquire lease").Wrap(err)
+	}
+
+	return true, nil
+}
+
+// Release gives the lease up if this owner holds it, so another owner can take it
+// without waiting for it to expire.
+func (lr *LeaseRepository) Release(ctx context.Context, name string) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, lr.operationTimeout)
+	defer cancel()
+
+	if _, err := lr.Collection.DeleteOne(ctx, bson.M{"_id": name, "owner": lr.Owner}); err != nil {
+		logger.ErrorContext(ctx, "Error trying to release lease", err, zap.String("lease", name))
+		return internal_error.NewInternalServerError("Error trying to release lease").Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package lock_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/infra/database/lock"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testDBName = "lock_test_db"
+
+// mongoSkipReason caches the first connection failure so the remaining tests
+// skip right away instead of waiting for the server selection timeout again.
+var mongoSkipReason string
+
+func setupTestDB(t *testing.T) (*mongo.Database, func()) {
+	if mongoSkipReason != "" {
+		t.Skip(mongoSkipReason)
+	}
+
+	mongoURL := os.Getenv("MONGODB_URL")
+	if mongoURL == "" {
+		mongoURL = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		t.Skip(mongoSkipReason)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB ping failed: %v", err)
+		t.Skip(mongoSkipReason)
+	}
+
+	database := client.Database(testDBName)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	}
+
+	return database, cleanup
+}
+
+func TestLeaseHeldByOneRepositoryAtATime(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ttl := 15 * time.Second
+
+	// Two instances sharing the same clock, so the expiry is decided by the test alone
+	clk := clock.NewFake(time.Now())
+	first := lock.NewLeaseRepository(database, clk, 0)
+	second := lock.NewLeaseRepository(database, clk, 0)
+
+	if err := first.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("Failed to create lease indexes: %v", err.Error())
+	}
+
+	acquire := func(repo *lock.LeaseRepository, expected bool, step string) {
+		t.Helper()
+
+		acquired, err := repo.Acquire(ctx, "closer", ttl)
+		if err != nil {
+			t.Fatalf("%s: failed to acquire the lease: %v", step, err.Error())
+		}
+		if acquired != expected {
+			t.Fatalf("%s: expected acquired to be %v, got %v", step, expected, acquired)
+		}
+	}
+
+	acquire(first, true, "first takes the free lease")
+	acquire(second, false, "second while first holds it")
+
+	// Renewals keep the lease past its first ttl
+	clk.Advance(10 * time.Second)
+	acquire(first, true, "first renews")
+	clk.Advance(10 * time.Second)
+	acquire(second, false, "second after the renewal")
+
+	// Without a renewal the lease expires and goes to whoever asks first
+	clk.Advance(ttl)
+	acquire(second, true, "second takes the expired lease")
+	acquire(first, false, "first after losing the lease")
+
+	var lease lock.LeaseMongo
+	if err := first.Collection.FindOne(ctx, bson.M{"_id": "closer"}).Decode(&lease); err != nil {
+		t.Fatalf("Failed to find lease: %v", err)
+	}
+	if lease.Owner != second.Owner || !lease.ExpiresAt.Equal(clk.Now().Add(ttl).Truncate(time.Millisecond)) {
+		t.Errorf("Expected the lease held by %s until %v, got %+v", second.Owner, clk.Now().Add(ttl), lease)
+	}
+
+	// Releasing someone else's lease leaves it alone
+	if err := first.Release(ctx, "closer"); err != nil {
+		t.Fatalf("Failed to release lease: %v", err.Error())
+	}
+	acquire(first, false, "first after releasing a lease it didn't hold")
+
+	if err := second.Release(ctx, "closer"); err != nil {
+		t.Fatalf("Failed to release lease: %v", err.Error())
+	}
+	acquire(first, true, "first after second released the lease")
+
+	// Other names are leased independently
+	acquired, err := second.Acquire(ctx, "archival", ttl)
+	if err != nil || !acquired {
+		t.Errorf("Expected second to take another lease, got %v (%v)", acquired, err)
+	}
+}
+
+func TestLeaseConcurrentAcquireHasOneWinner(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	clk := clock.NewFake(time.Now())
+
+	const contenders = 10
+	repos := make([]*lock.LeaseRepository, contenders)
+	for i := range repos {
+		repos[i] = lock.NewLeaseRepository(database, clk, 0)
+	}
+
+	for round := 0; round < 3; round++ {
+		var wg sync.WaitGroup
+		results := make([]bool, contenders)
+		for i, repo := range repos {
+			wg.Add(1)
+			go func(i int, repo *lock.LeaseRepository) {
+				defer wg.Done()
+
+				acquired, err := repo.Acquire(ctx, "closer", time.Second)
+				if err != nil {
+					t.Errorf("Failed to acquire the lease: %v", err.Error())
+				}
+				results[i] = acquired
+			}(i, repo)
+		}
+		wg.Wait()
+
+		winners := 0
+		for _, acquired := range results {
+			if acquired {
+				winners++
+			}
+		}
+		if winners != 1 {
+			t.Errorf("Round %d: expected exactly one repository to hold the lease, got %d", round, winners)
+		}
+
+		// Let the lease expire so the next round is contended again
+		clk.Advance(2 * time.Second)
+	}
+}
+
+func TestEnsureIndexesDropsTheTTLIndex(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo := lock.NewLeaseRepository(database, clock.NewFake(time.Now()), 0)
+
+	// Before the collection exists there is no index to drop
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("Failed to ensure lease indexes: %v", err.Error())
+	}
+
+	// The TTL index created by earlier versions
+	_, err := repo.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create the TTL index: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.EnsureIndexes(ctx); err != nil {
+			t.Fatalf("Failed to ensure lease indexes: %v", err.Error())
+		}
+	}
+
+	cursor, err := repo.Collection.Indexes().List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list lease indexes: %v", err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatalf("Failed to decode lease indexes: %v", err)
+	}
+	for _, index := range indexes {
+		if _, ok := index["expireAfterSeconds"]; ok {
+			t.Errorf("Expected no TTL index on the leases, got %v", index)
+		}
+	}
+}
@@ -0,0 +1,897 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	defaultAuctionDuration = 600 * time.Second
+)
+
+var _ auction_entity.AuctionRepositoryInterface = (*AuctionRepository)(nil)
+
+// AuctionRepository is a map backed auction_entity.AuctionRepositoryInterface for tests
+// that don't have a MongoDB at hand. It follows the MongoDB repository semantics,
+// including closing expired auctions through CloseExpiredAuctions, with time read from
+// the injected clock so tests can move it forward instantly.
+type AuctionRepository struct {
+	mu         sync.RWMutex
+	auctions   map[string]auction_entity.Auction
+	extensions map[string]time.Duration
+	audit      map[string][]auction_entity.AuctionAudit
+	clock      clock.Clock
+
+	// bids is the repository the close outcomes are decided from, set by NewBidRepository
+	bids *BidRepository
+
+	// flushBids writes the queued bids before auctions are closed, see SetFlushBids
+	flushBids func(ctx context.Context) error
+}
+
+// NewAuctionRepository builds an empty repository. A nil clk uses the real clock.
+func NewAuctionRepository(clk clock.Clock) *AuctionRepository {
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	return &AuctionRepository{
+		auctions:   make(map[string]auction_entity.Auction),
+		extensions: make(map[string]time.Duration),
+		audit:      make(map[string][]auction_entity.AuctionAudit),
+		clock:      clk,
+	}
+}
+
+func (ar *AuctionRepository) CreateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	if auctionEntity.Timestamp.IsZero() {
+		auctionEntity.Timestamp = ar.clock.Now()
+	}
+	auctionEntity.Timestamp = auctionEntity.Timestamp.Truncate(time.Millisecond).UTC()
+	if auctionEntity.StartTime.IsZero() {
+		auctionEntity.StartTime = auctionEntity.Timestamp
+	}
+	if auctionEntity.EndTime.IsZero() {
+		auctionEntity.EndTime = auctionEntity.StartTime.Add(defaultAuctionDuration)
+	}
+	if err := auctionEntity.ValidateNotEnded(ar.clock.Now()); err != nil {
+		return err
+	}
+
+	if _, exists := ar.auctions[auctionEntity.Id]; exists {
+		return internal_error.NewConflictError("Auction already exists")
+	}
+
+	// Stored the way MongoDB stores it: the timestamp, start and end times to the
+	// millisecond
+	stored := *auctionEntity
+	stored.EndTime = stored.EndTime.Truncate(time.Millisecond).UTC()
+	stored.StartTime = stored.StartTime.Truncate(time.Millisecond).UTC()
+	if stored.Currency == "" {
+		stored.Currency = money.DefaultCurrency
+	}
+	ar.auctions[stored.Id] = stored
+
+	return nil
+}
+
+func (ar *AuctionRepository) CreateAuctions(
+	ctx context.Context,
+	auctionEntities []*auction_entity.Auction) []*internal_error.InternalError {
+	errs := make([]*internal_error.InternalError, len(auctionEntities))
+	for i, auctionEntity := range auctionEntities {
+		errs[i] = ar.CreateAuction(ctx, auctionEntity)
+	}
+
+	return errs
+}
+
+func (ar *AuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	auctionEntity, ok := ar.auctions[id]
+	if !ok || !visible(ctx, auctionEntity) {
+		return nil, internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	return &auctionEntity, nil
+}
+
+// visible reports whether the auction is returned by finds under ctx, which leave
+// soft-deleted auctions out unless asked for them.
+func visible(ctx context.Context, auctionEntity auction_entity.Auction) bool {
+	return auctionEntity.DeletedAt == nil || auction_entity.IncludesDeleted(ctx)
+}
+
+func (ar *AuctionRepository) SoftDeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auctionEntity, ok := ar.auctions[id]
+	if !ok || auctionEntity.DeletedAt != nil {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	deletedAt := ar.clock.Now()
+	auctionEntity.DeletedAt = &deletedAt
+	auctionEntity.Revision++
+	ar.auctions[id] = auctionEntity
+
+	return nil
+}
+
+func (ar *AuctionRepository) ReplaceAuctionImages(
+	ctx context.Context,
+	id string,
+	version int64,
+	images []auction_entity.AuctionImage) (*auction_entity.Auction, *internal_error.InternalError) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auctionEntity, ok := ar.auctions[id]
+	if !ok || auctionEntity.DeletedAt != nil {
+		return nil, internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	if auctionEntity.Status != auction_entity.Active || !ar.clock.Now().Before(auctionEntity.EndTime) {
+		return nil, internal_error.ErrAuctionNotActive
+	}
+
+	if auctionEntity.Version != version {
+		return nil, internal_error.ErrAuctionModified
+	}
+
+	auctionEntity.Images = append(make([]auction_entity.AuctionImage, 0, len(images)), images...)
+	auctionEntity.Revision++
+	auctionEntity.Version++
+	ar.auctions[id] = auctionEntity
+
+	return &auctionEntity, nil
+}
+
+func (ar *AuctionRepository) FindAuctions(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter,
+	page, pageSize int) ([]auction_entity.Auction, int64, *internal_error.InternalError) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	matches := ar.findMatching(ctx, filter)
+
+	total := int64(len(matches))
+	start := (page - 1) * pageSize
+	if start >= len(matches) {
+		return []auction_entity.Auction{}, total, nil
+	}
+
+	end := start + pageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	return matches[start:end], total, nil
+}
+
+// StreamAuctions calls fn with each auction matching filter. The auctions are copied
+// out first, so fn runs without the lock held.
+func (ar *AuctionRepository) StreamAuctions(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter,
+	fn func(auction auction_entity.Auction) error) *internal_error.InternalError {
+	for _, auctionEntity := range ar.findMatching(ctx, filter) {
+		if err := fn(auctionEntity); err != nil {
+			return internal_error.NewInternalServerError("Error streaming auctions").Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// findMatching returns the auctions matching filter in its order.
+func (ar *AuctionRepository) findMatching(
+	ctx context.Context, filter auction_entity.AuctionFilter) []auction_entity.Auction {
+	ar.mu.RLock()
+	matches := make([]auction_entity.Auction, 0, len(ar.auctions))
+	for _, auctionEntity := range ar.auctions {
+		if visible(ctx, auctionEntity) && matchesFilter(auctionEntity, filter) {
+			matches = append(matches, auctionEntity)
+		}
+	}
+	ar.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		switch filter.Sort {
+		case auction_entity.SortOldest:
+			if !a.Timestamp.Equal(b.Timestamp) {
+				return a.Timestamp.Before(b.Timestamp)
+			}
+		case auction_entity.SortEndingSoon:
+			if !a.EndTime.Equal(b.EndTime) {
+				return a.EndTime.Before(b.EndTime)
+			}
+		case auction_entity.SortMostBids:
+			if a.BidCount != b.BidCount {
+				return a.BidCount > b.BidCount
+			}
+			fallthrough
+		default:
+			if !a.Timestamp.Equal(b.Timestamp) {
+				return a.Timestamp.After(b.Timestamp)
+			}
+		}
+		return a.Id < b.Id
+	})
+
+	return matches
+}
+
+func matchesFilter(auctionEntity auction_entity.Auction, filter auction_entity.AuctionFilter) bool {
+	if filter.SellerId != "" && auctionEntity.SellerId != filter.SellerId {
+		return false
+	}
+
+	if len(filter.Statuses) > 0 {
+		found := false
+		for _, status := range filter.Statuses {
+			if auctionEntity.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if filter.Category != "" && auctionEntity.Category != filter.Category {
+		return false
+	}
+
+	if filter.ProductName != "" &&
+		!strings.Contains(strings.ToLower(auctionEntity.ProductName), strings.ToLower(filter.ProductName)) {
+		return false
+	}
+
+	if !filter.CreatedAfter.IsZero() && auctionEntity.Timestamp.Before(filter.CreatedAfter) {
+		return false
+	}
+
+	if !filter.CreatedBefore.IsZero() && auctionEntity.Timestamp.After(filter.CreatedBefore) {
+		return false
+	}
+
+	return true
+}
+
+func (ar *AuctionRepository) UpdateAuctionStatus(
+	ctx context.Context,
+	id string,
+	version int64,
+	from, to auction_entity.AuctionStatus) *internal_error.InternalError {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auctionEntity, ok := ar.auctions[id]
+	if !ok {
+		return internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	if auctionEntity.Status != from {
+		return internal_error.NewConflictError("Auction status does not allow this transition")
+	}
+
+	if auctionEntity.Version != version {
+		return internal_error.ErrAuctionModified
+	}
+
+	reason := auction_entity.AuditReasonCancelled
+	if to != auction_entity.Cancelled {
+		reason = auction_entity.AuditReasonStatusChanged
+	}
+
+	auctionEntity.Status = to
+	auctionEntity.Revision++
+	auctionEntity.Version++
+	ar.auctions[id] = auctionEntity
+	ar.recordStatusChange(auction_entity.NewAuctionAudit(ctx, id, from, to, reason, ar.clock.Now()))
+
+	return nil
+}
+
+func (ar *AuctionRepository) ExtendAuctionEndTime(
+	ctx context.Context,
+	id string,
+	window, extension, maxExtension time.Duration) (time.Time, bool, *internal_error.InternalError) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auctionEntity, ok := ar.auctions[id]
+	now := ar.clock.Now()
+	if !ok ||
+		auctionEntity.Status != auction_entity.Active ||
+		!auctionEntity.EndTime.After(now) ||
+		auctionEntity.EndTime.Sub(now) > window ||
+		ar.extensions[id] >= maxExtension {
+		return time.Time{}, false, nil
+	}
+
+	step := extension
+	if remaining := maxExtension - ar.extensions[id]; remaining < step {
+		step = remaining
+	}
+
+	auctionEntity.EndTime = auctionEntity.EndTime.Add(step)
+	auctionEntity.Revision++
+	ar.auctions[id] = auctionEntity
+	ar.extensions[id] += step
+
+	return auctionEntity.EndTime, true, nil
+}
+
+// ActivateScheduledAuctions makes every Scheduled auction whose start time already
+// passed Active, mirroring the MongoDB sweep.
+func (ar *AuctionRepository) ActivateScheduledAuctions(ctx context.Context) ([]string, *internal_error.InternalError) {
+	now := ar.clock.Now()
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	var activatedIds []string
+	for id, auctionEntity := range ar.auctions {
+		if auctionEntity.Status == auction_entity.Scheduled && !auctionEntity.StartTime.After(now) {
+			auctionEntity.Status = auction_entity.Active
+			auctionEntity.Revision++
+			ar.auctions[id] = auctionEntity
+			ar.recordStatusChange(auction_entity.NewAuctionAudit(
+				ctx, id, auction_entity.Scheduled, auction_entity.Active, auction_entity.AuditReasonStarted, now))
+			activatedIds = append(activatedIds, id)
+		}
+	}
+
+	return activatedIds, nil
+}
+
+// CloseExpiredAuctions marks every Active auction whose end time already passed as
+// Completed, along with its outcome, mirroring the MongoDB sweep, so it can be driven by
+// auction.AuctionCloser.
+func (ar *AuctionRepository) CloseExpiredAuctions(ctx context.Context) ([]string, *internal_error.InternalError) {
+	now := ar.clock.Now()
+
+	ar.mu.RLock()
+	var expiredIds []string
+	for id, auctionEntity := range ar.auctions {
+		if auctionEntity.Status == auction_entity.Active && !auctionEntity.EndTime.After(now) {
+			expiredIds = append(expiredIds, id)
+		}
+	}
+	ar.mu.RUnlock()
+
+	return ar.closeAuctions(ctx, expiredIds, now, auction_entity.AuditReasonExpired), nil
+}
+
+// FindOverdueAuctions mirrors the MongoDB lookup, so it can be driven by
+// auction.CloseLagMonitor.
+func (ar *AuctionRepository) FindOverdueAuctions(
+	ctx context.Context, endedBefore time.Time) (time.Time, int64, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	var oldestEndTime time.Time
+	var overdue int64
+	for _, auctionEntity := range ar.auctions {
+		if auctionEntity.Status != auction_entity.Active {
+			continue
+		}
+
+		if oldestEndTime.IsZero() || auctionEntity.EndTime.Before(oldestEndTime) {
+			oldestEndTime = auctionEntity.EndTime
+		}
+		if auctionEntity.EndTime.Before(endedBefore) {
+			overdue++
+		}
+	}
+
+	return oldestEndTime, overdue, nil
+}
+
+// FindCloseFailures returns no failures: closing an auction in memory never fails.
+func (ar *AuctionRepository) FindCloseFailures(
+	ctx context.Context) ([]auction_entity.CloseFailure, *internal_error.InternalError) {
+	return []auction_entity.CloseFailure{}, nil
+}
+
+// RetryClose always returns a not found error, as no close failure is ever recorded.
+func (ar *AuctionRepository) RetryClose(ctx context.Context, id string) *internal_error.InternalError {
+	return internal_error.NewNotFoundError(
+		fmt.Sprintf("No close failure recorded for the auction with this id = %s", id))
+}
+
+// FindClosedAuctions returns the auctions with the given ids, in that order, like the
+// MongoDB repository does. There are no users to join here, so the winner names are
+// left empty.
+func (ar *AuctionRepository) FindClosedAuctions(
+	ctx context.Context, auctionIds []string) ([]auction_entity.ClosedAuction, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	closedAuctions := make([]auction_entity.ClosedAuction, 0, len(auctionIds))
+	for _, auctionId := range auctionIds {
+		if auctionEntity, ok := ar.auctions[auctionId]; ok {
+			closedAuctions = append(closedAuctions, auction_entity.ClosedAuction{Auction: auctionEntity})
+		}
+	}
+
+	return closedAuctions, nil
+}
+
+// CloseAuctionNow moves the end time of the Active auction id to now and closes it like
+// CloseExpiredAuctions, mirroring the MongoDB repository.
+func (ar *AuctionRepository) CloseAuctionNow(ctx context.Context, id string) *internal_error.InternalError {
+	now := ar.clock.Now()
+
+	ar.mu.Lock()
+	auctionEntity, ok := ar.auctions[id]
+	if !ok || auctionEntity.DeletedAt != nil {
+		ar.mu.Unlock()
+		return internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+	if auctionEntity.Status != auction_entity.Active {
+		ar.mu.Unlock()
+		return internal_error.NewConflictError("Only active auctions can be closed")
+	}
+
+	auctionEntity.EndTime = now.Truncate(time.Millisecond).UTC()
+	auctionEntity.Revision++
+	ar.auctions[id] = auctionEntity
+	ar.mu.Unlock()
+
+	ar.closeAuctions(ctx, []string{id}, now, auction_entity.AuditReasonClosedNow)
+	return nil
+}
+
+// SetFlushBids makes the closes write the bids still queued first, like the MongoDB
+// repository's FlushBids.
+func (ar *AuctionRepository) SetFlushBids(flush func(ctx context.Context) error) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	ar.flushBids = flush
+}
+
+// closeAuctions closes the expired auctions among expiredIds that are still Active,
+// recording reason in their audit log, and returns the ids it closed.
+func (ar *AuctionRepository) closeAuctions(
+	ctx context.Context, expiredIds []string, now time.Time, reason string) []string {
+	ar.mu.RLock()
+	bids, flushBids := ar.bids, ar.flushBids
+	ar.mu.RUnlock()
+
+	// The queued bids are stored through the bid repository, which reads the auctions,
+	// so they are flushed before taking the lock
+	if flushBids != nil && len(expiredIds) > 0 {
+		flushBids(ctx)
+	}
+
+	// The bid repository locks the auctions while storing a bid, so the winning bids are
+	// read without holding the lock here
+	winningBids := make(map[string]*bid_entity.Bid, len(expiredIds))
+	runnerUpAmounts := make(map[string]int64, len(expiredIds))
+	if bids != nil {
+		for _, id := range expiredIds {
+			winningBids[id] = bids.findWinningBid(id)
+			if winningBids[id] != nil {
+				runnerUpAmounts[id] = bids.runnerUpAmount(id, winningBids[id].UserId)
+			}
+		}
+	}
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	var closedIds []string
+	for _, id := range expiredIds {
+		auctionEntity := ar.auctions[id]
+		if auctionEntity.Status != auction_entity.Active {
+			continue
+		}
+
+		auctionEntity.Status = auction_entity.Completed
+		auctionEntity.ClosedAt = &now
+		auctionEntity.WinningBid = nil
+		if winningBid := winningBids[id]; winningBid != nil {
+			auctionEntity.Outcome = auctionEntity.OutcomeFor(winningBid.Amount, true)
+			auctionEntity.WinningBid = &auction_entity.WinningBid{
+				BidId:     winningBid.Id,
+				UserId:    winningBid.UserId,
+				Amount:    winningBid.Amount,
+				Currency:  winningBid.Currency,
+				Timestamp: winningBid.Timestamp,
+
+				SettlementAmount: auctionEntity.SettlementFor(winningBid.Amount, runnerUpAmounts[id]),
+			}
+		} else {
+			auctionEntity.Outcome = auctionEntity.OutcomeFor(0, false)
+		}
+
+		auctionEntity.Revision++
+		ar.auctions[id] = auctionEntity
+		ar.recordStatusChange(auction_entity.NewAuctionAudit(
+			ctx, id, auction_entity.Active, auction_entity.Completed, reason, now))
+		closedIds = append(closedIds, id)
+	}
+
+	return closedIds
+}
+
+// closeBoughtNow closes the auction bidEntity buys at its buy-now price, with the bid as
+// its winner, when the auction is still Active and before its end time and its buy-now
+// price is the bid amount and wasn't reached yet; it returns ErrBuyNowUnavailable
+// otherwise. hold runs before anything changes, and its error leaves the auction open.
+func (ar *AuctionRepository) closeBoughtNow(
+	ctx context.Context, bidEntity bid_entity.Bid, hold func() *internal_error.InternalError) *internal_error.InternalError {
+	now := ar.clock.Now()
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auctionEntity, ok := ar.auctions[bidEntity.AuctionId]
+	if !ok || auctionEntity.DeletedAt != nil ||
+		auctionEntity.Status != auction_entity.Active ||
+		!now.Before(auctionEntity.EndTime) ||
+		auctionEntity.BuyNowPrice != bidEntity.Amount ||
+		auctionEntity.CurrentHighestAmount >= bidEntity.Amount {
+		return internal_error.ErrBuyNowUnavailable
+	}
+
+	if err := hold(); err != nil {
+		return err
+	}
+
+	auctionEntity.Status = auction_entity.Completed
+	auctionEntity.ClosedAt = &now
+	auctionEntity.EndTime = now.Truncate(time.Millisecond).UTC()
+	auctionEntity.Outcome = auction_entity.BoughtNow
+	auctionEntity.WinningBid = &auction_entity.WinningBid{
+		BidId:     bidEntity.Id,
+		UserId:    bidEntity.UserId,
+		Amount:    bidEntity.Amount,
+		Currency:  bidEntity.Currency,
+		Timestamp: bidEntity.Timestamp,
+
+		SettlementAmount: bidEntity.Amount,
+	}
+	auctionEntity.BidCount++
+	auctionEntity.CurrentHighestAmount = bidEntity.Amount
+	auctionEntity.Revision++
+	ar.auctions[bidEntity.AuctionId] = auctionEntity
+	ar.recordStatusChange(auction_entity.NewAuctionAudit(
+		ctx, bidEntity.AuctionId, auction_entity.Active, auction_entity.Completed,
+		auction_entity.AuditReasonBoughtNow, now))
+
+	return nil
+}
+
+// AggregateTopSellers ranks the sellers like the MongoDB repository does. There are no
+// users to join here, so the names are left empty.
+func (ar *AuctionRepository) AggregateTopSellers(
+	ctx context.Context, from, to time.Time, limit int) ([]auction_entity.TopSeller, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	type sellerCurrency struct{ sellerId, currency string }
+	bySeller := make(map[sellerCurrency]*auction_entity.TopSeller)
+	for _, auctionEntity := range ar.auctions {
+		if auctionEntity.Status != auction_entity.Completed ||
+			!auctionEntity.Outcome.IsSale() ||
+			auctionEntity.WinningBid == nil ||
+			auctionEntity.SellerId == "" ||
+			auctionEntity.DeletedAt != nil ||
+			auctionEntity.ClosedAt == nil ||
+			auctionEntity.ClosedAt.Before(from) ||
+			auctionEntity.ClosedAt.After(to) {
+			continue
+		}
+
+		key := sellerCurrency{auctionEntity.SellerId, auctionEntity.WinningBid.Currency}
+		topSeller, ok := bySeller[key]
+		if !ok {
+			topSeller = &auction_entity.TopSeller{SellerId: key.sellerId, Currency: key.currency}
+			bySeller[key] = topSeller
+		}
+		topSeller.AuctionsSold++
+		topSeller.TotalSold += auctionEntity.WinningBid.SettlementAmount
+	}
+
+	topSellers := make([]auction_entity.TopSeller, 0, len(bySeller))
+	for _, topSeller := range bySeller {
+		topSellers = append(topSellers, *topSeller)
+	}
+	sort.Slice(topSellers, func(i, j int) bool {
+		if topSellers[i].TotalSold != topSellers[j].TotalSold {
+			return topSellers[i].TotalSold > topSellers[j].TotalSold
+		}
+		if topSellers[i].AuctionsSold != topSellers[j].AuctionsSold {
+			return topSellers[i].AuctionsSold > topSellers[j].AuctionsSold
+		}
+		if topSellers[i].SellerId != topSellers[j].SellerId {
+			return topSellers[i].SellerId < topSellers[j].SellerId
+		}
+		return topSellers[i].Currency < topSellers[j].Currency
+	})
+
+	if len(topSellers) > limit {
+		topSellers = topSellers[:limit]
+	}
+
+	return topSellers, nil
+}
+
+// GetFacets counts the auctions matching filter like the MongoDB repository does,
+// without its cache.
+func (ar *AuctionRepository) GetFacets(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter) (*auction_entity.AuctionFacets, *internal_error.InternalError) {
+	byCategory := make(map[string]int64)
+	byCondition := make(map[auction_entity.ProductCondition]int64)
+	for _, auctionEntity := range ar.findMatching(ctx, filter) {
+		byCategory[auctionEntity.Category]++
+		byCondition[auctionEntity.Condition]++
+	}
+
+	facets := &auction_entity.AuctionFacets{
+		Categories: make([]auction_entity.CategoryCount, 0, len(byCategory)),
+		Conditions: make([]auction_entity.ConditionCount, 0, len(byCondition)),
+	}
+	for category, count := range byCategory {
+		facets.Categories = append(facets.Categories, auction_entity.CategoryCount{Category: category, Count: count})
+	}
+	for condition, count := range byCondition {
+		facets.Conditions = append(facets.Conditions, auction_entity.ConditionCount{Condition: condition, Count: count})
+	}
+
+	sort.Slice(facets.Categories, func(i, j int) bool {
+		a, b := facets.Categories[i], facets.Categories[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Category < b.Category
+	})
+	sort.Slice(facets.Conditions, func(i, j int) bool {
+		a, b := facets.Conditions[i], facets.Conditions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Condition < b.Condition
+	})
+
+	return facets, nil
+}
+
+// recordStatusChange appends entry to the audit log of its auction. The caller holds
+// the write lock.
+func (ar *AuctionRepository) recordStatusChange(entry auction_entity.AuctionAudit) {
+	ar.audit[entry.AuctionId] = append(ar.audit[entry.AuctionId], entry)
+}
+
+func (ar *AuctionRepository) FindAuditByAuctionId(
+	ctx context.Context, auctionId string) ([]auction_entity.AuctionAudit, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	entries := make([]auction_entity.AuctionAudit, len(ar.audit[auctionId]))
+	copy(entries, ar.audit[auctionId])
+
+	return entries, nil
+}
+
+// UpdateAuction changes the product fields of an Active auction without bids. The bid
+// lock is taken before the auction lock, the same order bid inserts use, so no bid can be
+// stored between the check and the update.
+func (ar *AuctionRepository) UpdateAuction(
+	ctx context.Context,
+	id string,
+	version int64,
+	update auction_entity.AuctionUpdate) (*auction_entity.Auction, *internal_error.InternalError) {
+	ar.mu.RLock()
+	bids := ar.bids
+	ar.mu.RUnlock()
+
+	if bids != nil {
+		bids.mu.RLock()
+		defer bids.mu.RUnlock()
+	}
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auctionEntity, ok := ar.auctions[id]
+	if !ok {
+		return nil, internal_error.NewNotFoundError(fmt.Sprintf("Auction not found with this id = %s", id))
+	}
+
+	if auctionEntity.Status != auction_entity.Active || !ar.clock.Now().Before(auctionEntity.EndTime) {
+		return nil, internal_error.NewConflictError("Only active auctions can be updated")
+	}
+
+	if bids != nil && len(bids.bids[id]) > 0 {
+		return nil, internal_error.NewConflictError("Auction can't be updated after the first bid")
+	}
+
+	if auctionEntity.Version != version {
+		return nil, internal_error.ErrAuctionModified
+	}
+
+	if update.ProductName != nil {
+		auctionEntity.ProductName = *update.ProductName
+	}
+	if update.Category != nil {
+		auctionEntity.Category = *update.Category
+	}
+	if update.Description != nil {
+		auctionEntity.Description = *update.Description
+	}
+	auctionEntity.Revision++
+	auctionEntity.Version++
+	ar.auctions[id] = auctionEntity
+
+	return &auctionEntity, nil
+}
+
+// isActive reports whether the auction accepts bids at the repository's current time.
+// Soft-deleted auctions are reported as not found.
+func (ar *AuctionRepository) isActive(auctionId string, at time.Time) (bool, bool) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	auctionEntity, ok := ar.auctions[auctionId]
+	if !ok || auctionEntity.DeletedAt != nil {
+		return false, false
+	}
+
+	return auctionEntity.Status == auction_entity.Active && at.Before(auctionEntity.EndTime), true
+}
+
+// isSealed reports whether the bids on the auction don't have to beat each other, see
+// auction_entity.Vickrey.
+func (ar *AuctionRepository) isSealed(auctionId string) bool {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	return ar.auctions[auctionId].AuctionType == auction_entity.Vickrey
+}
+
+// isScheduled reports whether the auction is waiting for its start time.
+func (ar *AuctionRepository) isScheduled(auctionId string) bool {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	return ar.auctions[auctionId].Status == auction_entity.Scheduled
+}
+
+// sellerOf returns the seller of the auction, empty for legacy auctions.
+func (ar *AuctionRepository) sellerOf(auctionId string) string {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	return ar.auctions[auctionId].SellerId
+}
+
+// soldTo returns the id of the winning bid of the auction when it was sold, and the
+// amount the winner pays.
+func (ar *AuctionRepository) soldTo(auctionId string) (bidId string, amount int64, sold bool) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	auctionEntity := ar.auctions[auctionId]
+	if !auctionEntity.Outcome.IsSale() || auctionEntity.WinningBid == nil {
+		return "", 0, false
+	}
+
+	return auctionEntity.WinningBid.BidId, auctionEntity.WinningBid.SettlementAmount, true
+}
+
+// recordBids adds count bids to the counters of the auction, raising its current highest
+// amount to highestAmount if it is lower.
+func (ar *AuctionRepository) recordBids(auctionId string, count int, highestAmount int64) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	auctionEntity, ok := ar.auctions[auctionId]
+	if !ok {
+		return
+	}
+
+	auctionEntity.BidCount += int64(count)
+	if highestAmount > auctionEntity.CurrentHighestAmount {
+		auctionEntity.CurrentHighestAmount = highestAmount
+	}
+	auctionEntity.Revision++
+	ar.auctions[auctionId] = auctionEntity
+}
+
+func (ar *AuctionRepository) RenameCategory(
+	ctx context.Context, from, to string) (int64, *internal_error.InternalError) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	var renamed int64
+	for id, auctionEntity := range ar.auctions {
+		if auctionEntity.Category == from {
+			auctionEntity.Category = to
+			auctionEntity.Revision++
+			ar.auctions[id] = auctionEntity
+			renamed++
+		}
+	}
+
+	return renamed, nil
+}
+
+func (ar *AuctionRepository) CountAuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	counts := make(map[auction_entity.AuctionStatus]int64)
+	for _, auctionEntity := range ar.auctions {
+		if auctionEntity.DeletedAt == nil {
+			counts[auctionEntity.Status]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (ar *AuctionRepository) FindDuplicateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	since time.Time) (string, *internal_error.InternalError) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	var duplicate auction_entity.Auction
+	for _, existing := range ar.auctions {
+		if existing.Status != auction_entity.Active || existing.DeletedAt != nil ||
+			existing.SellerId != auctionEntity.SellerId ||
+			existing.ProductName != auctionEntity.ProductName ||
+			existing.Category != auctionEntity.Category ||
+			existing.Timestamp.Before(since) {
+			continue
+		}
+
+		if duplicate.Id == "" || existing.Timestamp.After(duplicate.Timestamp) {
+			duplicate = existing
+		}
+	}
+
+	return duplicate.Id, nil
+}
@@ -0,0 +1,621 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+var _ bid_entity.BidEntityRepository = (*BidRepository)(nil)
+
+// BidRepository is a map backed bid_entity.BidEntityRepository following the MongoDB
+// repository semantics: bids are only stored while their auction is active and only
+// when they beat the current winning bid.
+type BidRepository struct {
+	mu                sync.RWMutex
+	bids              map[string][]bid_entity.Bid
+	maxBids           map[maxBidKey]bid_entity.MaxBid
+	auctionRepository *AuctionRepository
+
+	// users holds the amount of each leading bid from the bidder's balance when set,
+	// see ReserveBalances
+	users *UserRepository
+}
+
+type maxBidKey struct {
+	auctionId string
+	userId    string
+}
+
+func NewBidRepository(auctionRepository *AuctionRepository) *BidRepository {
+	bidRepository := &BidRepository{
+		bids:              make(map[string][]bid_entity.Bid),
+		maxBids:           make(map[maxBidKey]bid_entity.MaxBid),
+		auctionRepository: auctionRepository,
+	}
+
+	auctionRepository.mu.Lock()
+	auctionRepository.bids = bidRepository
+	auctionRepository.mu.Unlock()
+
+	return bidRepository
+}
+
+// ReserveBalances makes every bid that takes the lead hold its amount from the bidder's
+// balance in users, released when it is outbid, like the MongoDB repository does with
+// BID_BALANCE_MODE=reserve. It must be called before the repository is used.
+func (br *BidRepository) ReserveBalances(users *UserRepository) {
+	br.users = users
+}
+
+func (br *BidRepository) CreateBid(
+	ctx context.Context,
+	bidEntities []bid_entity.Bid) []*internal_error.InternalError {
+	results := make([]*internal_error.InternalError, len(bidEntities))
+	for i := range bidEntities {
+		if err := br.CreateBidIfAuctionActive(ctx, &bidEntities[i]); err != nil {
+			logger.InfoContext(ctx, "Bid rejected",
+				zap.String("bid_id", bidEntities[i].Id),
+				zap.String("auction_id", bidEntities[i].AuctionId),
+				zap.String("reason", err.Error()))
+			results[i] = err
+		}
+	}
+
+	return results
+}
+
+// CreateBidIfAuctionActive stores the bid, followed by its counter bid, when its auction
+// is active and each amount is strictly above the winning bid before it. Either both
+// are stored or neither is. The bids of a Vickrey auction are stored whatever their
+// amount, and only hold the balance when they take the lead.
+func (br *BidRepository) CreateBidIfAuctionActive(
+	ctx context.Context, bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	// Like the MongoDB repository, the bid counts if it was accepted before the end
+	if active, _ := br.auctionRepository.isActive(bidEntity.AuctionId, bidEntity.Timestamp); !active {
+		return internal_error.ErrAuctionNotActive
+	}
+
+	winningBid := br.winningBid(bidEntity.AuctionId)
+	leads := winningBid == nil || bidEntity.Amount > winningBid.Amount
+	if !leads && !br.auctionRepository.isSealed(bidEntity.AuctionId) {
+		return internal_error.NewBadRequestError("Bid amount must be higher than the current winning bid")
+	}
+	if bidEntity.CounterBid != nil && bidEntity.CounterBid.Amount <= bidEntity.Amount {
+		return internal_error.NewBadRequestError("Counter bid amount must be higher than the bid it answers")
+	}
+
+	leader := bidEntity
+	for leader.CounterBid != nil {
+		leader = leader.CounterBid
+	}
+	if br.users != nil && leads {
+		if err := br.users.transferHold(winningBid, *leader); err != nil {
+			return err
+		}
+	}
+
+	count, highestAmount := 0, bidEntity.Amount
+	for bid := bidEntity; bid != nil; bid = bid.CounterBid {
+		stored := *bid
+		stored.Timestamp = stored.Timestamp.Truncate(time.Millisecond).UTC()
+		stored.CounterBid = nil
+		stored.Reserved = br.users != nil && bid == leader && leads
+		br.bids[stored.AuctionId] = append(br.bids[stored.AuctionId], stored)
+
+		count++
+		if stored.Amount > highestAmount {
+			highestAmount = stored.Amount
+		}
+	}
+	br.auctionRepository.recordBids(bidEntity.AuctionId, count, highestAmount)
+
+	return nil
+}
+
+// BuyNow stores the bid buying the auction at its buy-now price and closes the auction
+// with it, see AuctionRepository.closeBoughtNow. The bids are locked throughout, so a
+// concurrent buy-now or regular bid can't slip in between the check and the close.
+func (br *BidRepository) BuyNow(
+	ctx context.Context, bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	stored := *bidEntity
+	stored.Timestamp = stored.Timestamp.Truncate(time.Millisecond).UTC()
+	stored.CounterBid = nil
+	stored.Reserved = br.users != nil
+
+	hold := func() *internal_error.InternalError {
+		if br.users == nil {
+			return nil
+		}
+
+		return br.users.transferHold(br.winningBid(stored.AuctionId), stored)
+	}
+	if err := br.auctionRepository.closeBoughtNow(ctx, stored, hold); err != nil {
+		return err
+	}
+	br.bids[stored.AuctionId] = append(br.bids[stored.AuctionId], stored)
+
+	return nil
+}
+
+func (br *BidRepository) SaveMaxBid(
+	ctx context.Context, maxBid *bid_entity.MaxBid) *internal_error.InternalError {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	br.maxBids[maxBidKey{auctionId: maxBid.AuctionId, userId: maxBid.UserId}] = *maxBid
+	return nil
+}
+
+func (br *BidRepository) FindMaxBid(
+	ctx context.Context, auctionId, userId string) (*bid_entity.MaxBid, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	maxBid, ok := br.maxBids[maxBidKey{auctionId: auctionId, userId: userId}]
+	if !ok {
+		return nil, internal_error.NewNotFoundError(
+			fmt.Sprintf("No maximum bid found for user %s on auction %s", userId, auctionId))
+	}
+
+	return &maxBid, nil
+}
+
+// Insert stores the bid as is, skipping every check and leaving the auction counters
+// untouched, to seed tests with bids that the regular insert path would reject (e.g. ties).
+func (br *BidRepository) Insert(bidEntity bid_entity.Bid) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	br.bids[bidEntity.AuctionId] = append(br.bids[bidEntity.AuctionId], bidEntity)
+}
+
+// FindBidByAuctionId lists the bids of the auction by timestamp. Bids with the same
+// timestamp keep the order they were stored in, reversed when listing newest first, and
+// a cursor resumes the listing right after the bid with its id.
+func (br *BidRepository) FindBidByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	filter bid_entity.BidListFilter) ([]bid_entity.Bid, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	stored := br.bids[auctionId]
+	bids := make([]bid_entity.Bid, 0, len(stored))
+	for i := range stored {
+		if filter.Ascending {
+			bids = append(bids, stored[i])
+		} else {
+			bids = append(bids, stored[len(stored)-1-i])
+		}
+	}
+
+	sort.SliceStable(bids, func(i, j int) bool {
+		if filter.Ascending {
+			return bids[i].Timestamp.Before(bids[j].Timestamp)
+		}
+		return bids[i].Timestamp.After(bids[j].Timestamp)
+	})
+
+	offset := filter.Offset
+	if filter.After != nil {
+		offset = afterCursor(bids, filter.After, filter.Ascending)
+	}
+
+	if offset >= len(bids) {
+		return []bid_entity.Bid{}, nil
+	}
+	bids = bids[offset:]
+	if filter.Limit > 0 && filter.Limit < len(bids) {
+		bids = bids[:filter.Limit]
+	}
+
+	return bids, nil
+}
+
+// StreamBids calls fn with each bid matching filter, oldest first and then by id. The
+// bids are copied out first, so fn runs without the lock held.
+func (br *BidRepository) StreamBids(
+	ctx context.Context,
+	filter bid_entity.BidExportFilter,
+	fn func(bid bid_entity.Bid) error) *internal_error.InternalError {
+	br.mu.RLock()
+	var bids []bid_entity.Bid
+	for auctionId, stored := range br.bids {
+		if filter.AuctionId != "" && auctionId != filter.AuctionId {
+			continue
+		}
+		for _, bidEntity := range stored {
+			if (filter.From.IsZero() || !bidEntity.Timestamp.Before(filter.From)) &&
+				(filter.To.IsZero() || !bidEntity.Timestamp.After(filter.To)) {
+				bids = append(bids, bidEntity)
+			}
+		}
+	}
+	br.mu.RUnlock()
+
+	sort.Slice(bids, func(i, j int) bool {
+		if !bids[i].Timestamp.Equal(bids[j].Timestamp) {
+			return bids[i].Timestamp.Before(bids[j].Timestamp)
+		}
+		return bids[i].Id < bids[j].Id
+	})
+
+	for _, bidEntity := range bids {
+		if err := ctx.Err(); err != nil {
+			return internal_error.NewInternalServerError("Error streaming bids").Wrap(err)
+		}
+		if err := fn(bidEntity); err != nil {
+			return internal_error.NewInternalServerError("Error streaming bids").Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// afterCursor returns the position of the bid following the one the cursor points to.
+// Bids are never removed, so the cursor bid is only missing when it was forged; the
+// listing then resumes after its timestamp.
+func afterCursor(bids []bid_entity.Bid, cursor *bid_entity.BidCursor, ascending bool) int {
+	for i, bid := range bids {
+		if bid.Id == cursor.Id {
+			return i + 1
+		}
+	}
+
+	return sort.Search(len(bids), func(i int) bool {
+		if ascending {
+			return bids[i].Timestamp.After(cursor.Timestamp)
+		}
+		return bids[i].Timestamp.Before(cursor.Timestamp)
+	})
+}
+
+func (br *BidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	winningBid := br.winningBid(auctionId)
+	if winningBid == nil {
+		return nil, internal_error.NewNotFoundError(fmt.Sprintf("No bids found for auctionId %s", auctionId))
+	}
+
+	return winningBid, nil
+}
+
+func (br *BidRepository) FindBidByUserAndAmount(
+	ctx context.Context, auctionId, userId string, amount int64) (*bid_entity.Bid, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	for _, bid := range br.bids[auctionId] {
+		if bid.UserId == userId && bid.Amount == amount {
+			found := bid
+			return &found, nil
+		}
+	}
+
+	return nil, internal_error.NewNotFoundError(
+		fmt.Sprintf("No bid of user %s found for this amount on auction %s", userId, auctionId))
+}
+
+func (br *BidRepository) CountUserBids(
+	ctx context.Context, auctionId, userId string) (int64, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	var count int64
+	for _, bid := range br.bids[auctionId] {
+		if bid.UserId == userId {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// runnerUpAmount is the highest amount bid on the auction by anyone but winnerId, zero
+// when nobody else bid.
+func (br *BidRepository) runnerUpAmount(auctionId, winnerId string) int64 {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	var amount int64
+	for _, bid := range br.bids[auctionId] {
+		if bid.UserId != winnerId && bid.Amount > amount {
+			amount = bid.Amount
+		}
+	}
+
+	return amount
+}
+
+// findWinningBid is winningBid taking the lock itself.
+func (br *BidRepository) findWinningBid(auctionId string) *bid_entity.Bid {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	return br.winningBid(auctionId)
+}
+
+// winningBid picks the highest amount and, among equal amounts, the earliest bid. The
+// caller must hold the lock.
+func (br *BidRepository) winningBid(auctionId string) *bid_entity.Bid {
+	var winningBid *bid_entity.Bid
+	for i, bid := range br.bids[auctionId] {
+		if winningBid == nil ||
+			bid.Amount > winningBid.Amount ||
+			bid.Amount == winningBid.Amount && bid.Timestamp.Before(winningBid.Timestamp) {
+			winningBid = &br.bids[auctionId][i]
+		}
+	}
+
+	if winningBid == nil {
+		return nil
+	}
+
+	found := *winningBid
+	return &found
+}
+
+func (br *BidRepository) FindBidsByUserId(
+	ctx context.Context, userId string, onlyActiveAuctions bool) ([]bid_entity.Bid, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	bids := make([]bid_entity.Bid, 0)
+	for auctionId, auctionBids := range br.bids {
+		if onlyActiveAuctions {
+			auctionEntity, err := br.auctionRepository.FindAuctionById(ctx, auctionId)
+			if err != nil || auctionEntity.Status != auction_entity.Active {
+				continue
+			}
+		}
+
+		for _, bid := range auctionBids {
+			if bid.UserId == userId {
+				bids = append(bids, bid)
+			}
+		}
+	}
+
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Timestamp.Before(bids[j].Timestamp) })
+
+	return bids, nil
+}
+
+func (br *BidRepository) CountBidsByUserOnOwnAuctions(
+	ctx context.Context) ([]bid_entity.SelfBidCount, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	byUser := make(map[string]*bid_entity.SelfBidCount)
+	for auctionId, auctionBids := range br.bids {
+		sellerId := br.auctionRepository.sellerOf(auctionId)
+		if sellerId == "" {
+			continue
+		}
+
+		counted := false
+		for _, bid := range auctionBids {
+			if bid.UserId != sellerId {
+				continue
+			}
+
+			count, ok := byUser[sellerId]
+			if !ok {
+				count = &bid_entity.SelfBidCount{UserId: sellerId}
+				byUser[sellerId] = count
+			}
+			count.BidCount++
+			if !counted {
+				count.AuctionCount++
+				counted = true
+			}
+		}
+	}
+
+	counts := make([]bid_entity.SelfBidCount, 0, len(byUser))
+	for _, count := range byUser {
+		counts = append(counts, *count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].BidCount != counts[j].BidCount {
+			return counts[i].BidCount > counts[j].BidCount
+		}
+		return counts[i].UserId < counts[j].UserId
+	})
+
+	return counts, nil
+}
+
+// AggregateUserStats sums up the bids of userId like the MongoDB repository does.
+func (br *BidRepository) AggregateUserStats(
+	ctx context.Context, userId string) (*bid_entity.UserBidStats, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	stats := &bid_entity.UserBidStats{}
+	for auctionId, auctionBids := range br.bids {
+		winningBidId, paidAmount, sold := br.auctionRepository.soldTo(auctionId)
+
+		bidOn, won := false, false
+		for _, bid := range auctionBids {
+			if bid.UserId != userId {
+				continue
+			}
+
+			stats.BidCount++
+			bidOn = true
+			won = won || (sold && bid.Id == winningBidId)
+		}
+
+		if bidOn {
+			stats.AuctionsBidOn++
+		}
+		if won {
+			stats.AuctionsWon++
+			stats.TotalSpent += paidAmount
+		}
+	}
+
+	return stats, nil
+}
+
+// AggregateTopBidders ranks the users like the MongoDB repository does. There are no
+// users to join here, so the names are left empty.
+func (br *BidRepository) AggregateTopBidders(
+	ctx context.Context, from, to time.Time, limit int) ([]bid_entity.TopBidder, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	type userCurrency struct{ userId, currency string }
+	byUser := make(map[userCurrency]*bid_entity.TopBidder)
+	for _, auctionBids := range br.bids {
+		for _, bid := range auctionBids {
+			if bid.Timestamp.Before(from) || bid.Timestamp.After(to) {
+				continue
+			}
+
+			key := userCurrency{bid.UserId, bid.Currency}
+			topBidder, ok := byUser[key]
+			if !ok {
+				topBidder = &bid_entity.TopBidder{UserId: bid.UserId, Currency: bid.Currency}
+				byUser[key] = topBidder
+			}
+			topBidder.BidCount++
+			topBidder.TotalAmount += bid.Amount
+		}
+	}
+
+	topBidders := make([]bid_entity.TopBidder, 0, len(byUser))
+	for _, topBidder := range byUser {
+		topBidders = append(topBidders, *topBidder)
+	}
+	sort.Slice(topBidders, func(i, j int) bool {
+		if topBidders[i].BidCount != topBidders[j].BidCount {
+			return topBidders[i].BidCount > topBidders[j].BidCount
+		}
+		if topBidders[i].TotalAmount != topBidders[j].TotalAmount {
+			return topBidders[i].TotalAmount > topBidders[j].TotalAmount
+		}
+		if topBidders[i].UserId != topBidders[j].UserId {
+			return topBidders[i].UserId < topBidders[j].UserId
+		}
+		return topBidders[i].Currency < topBidders[j].Currency
+	})
+
+	if len(topBidders) > limit {
+		topBidders = topBidders[:limit]
+	}
+
+	return topBidders, nil
+}
+
+func (br *BidRepository) GetAuctionBidStats(
+	ctx context.Context, auctionId string) (*bid_entity.BidStats, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	bids := br.bids[auctionId]
+	if len(bids) == 0 {
+		return &bid_entity.BidStats{}, nil
+	}
+
+	stats := &bid_entity.BidStats{
+		BidCount:   int64(len(bids)),
+		HighestBid: bids[0].Amount,
+		LowestBid:  bids[0].Amount,
+	}
+	bidders := make(map[string]struct{})
+	var total int64
+	for _, bid := range bids {
+		if bid.Amount > stats.HighestBid {
+			stats.HighestBid = bid.Amount
+		}
+		if bid.Amount < stats.LowestBid {
+			stats.LowestBid = bid.Amount
+		}
+		total += bid.Amount
+		bidders[bid.UserId] = struct{}{}
+	}
+	stats.AverageBid = int64(math.Round(float64(total) / float64(len(bids))))
+	stats.UniqueBidders = int64(len(bidders))
+
+	return stats, nil
+}
+
+func (br *BidRepository) CountRecentBids(
+	ctx context.Context, now time.Time) (*bid_entity.BidActivity, *internal_error.InternalError) {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+
+	hourAgo, dayAgo := now.Add(-time.Hour), now.Add(-24*time.Hour)
+	activity := &bid_entity.BidActivity{}
+	for _, auctionBids := range br.bids {
+		for _, bid := range auctionBids {
+			if !bid.Timestamp.Before(dayAgo) {
+				activity.LastDay++
+			}
+			if !bid.Timestamp.Before(hourAgo) {
+				activity.LastHour++
+			}
+		}
+	}
+
+	return activity, nil
+}
+
+// AverageTimeToFirstBid counts in milliseconds, like the MongoDB repository.
+func (br *BidRepository) AverageTimeToFirstBid(
+	ctx context.Context, since time.Time) (time.Duration, bool, *internal_error.InternalError) {
+	br.mu.RLock()
+	firstBids := make(map[string]time.Time, len(br.bids))
+	for auctionId, auctionBids := range br.bids {
+		for _, bid := range auctionBids {
+			if first, ok := firstBids[auctionId]; !ok || bid.Timestamp.Before(first) {
+				firstBids[auctionId] = bid.Timestamp
+			}
+		}
+	}
+	br.mu.RUnlock()
+
+	var total time.Duration
+	var auctions int64
+	for auctionId, firstBid := range firstBids {
+		auctionEntity, err := br.auctionRepository.FindAuctionById(ctx, auctionId)
+		if err != nil || auctionEntity.Timestamp.Before(since) {
+			continue
+		}
+
+		start := auctionEntity.StartTime
+		if start.IsZero() {
+			start = auctionEntity.Timestamp
+		}
+		total += firstBid.Sub(start)
+		auctions++
+	}
+
+	if auctions == 0 {
+		return 0, false, nil
+	}
+
+	return total / time.Duration(auctions), true, nil
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+var _ category_entity.CategoryRepositoryInterface = (*CategoryRepository)(nil)
+
+// CategoryRepository is a map backed category_entity.CategoryRepositoryInterface keyed
+// by category_entity.Key, enforcing the same uniqueness as the MongoDB indexes.
+type CategoryRepository struct {
+	mu         sync.RWMutex
+	categories map[string]category_entity.Category
+}
+
+func NewCategoryRepository() *CategoryRepository {
+	return &CategoryRepository{
+		categories: make(map[string]category_entity.Category),
+	}
+}
+
+func (cr *CategoryRepository) CreateCategory(
+	ctx context.Context, categoryEntity *category_entity.Category) *internal_error.InternalError {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	key := category_entity.Key(categoryEntity.Name)
+	if cr.takenLocked(key, categoryEntity.Slug, categoryEntity.Id) {
+		return internal_error.NewConflictError("A category with this name already exists")
+	}
+
+	cr.categories[key] = *categoryEntity
+	return nil
+}
+
+// takenLocked reports whether a category other than id has key or slug.
+func (cr *CategoryRepository) takenLocked(key, slug, id string) bool {
+	for categoryKey, category := range cr.categories {
+		if category.Id != id && (categoryKey == key || (slug != "" && category.Slug == slug)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cr *CategoryRepository) FindAllCategories(
+	ctx context.Context) ([]category_entity.Category, *internal_error.InternalError) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	categories := make([]category_entity.Category, 0, len(cr.categories))
+	for _, category := range cr.categories {
+		categories = append(categories, category)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Name < categories[j].Name
+	})
+
+	return categories, nil
+}
+
+func (cr *CategoryRepository) FindCategoryById(
+	ctx context.Context, id string) (*category_entity.Category, *internal_error.InternalError) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	for _, category := range cr.categories {
+		if category.Id == id {
+			return &category, nil
+		}
+	}
+
+	return nil, internal_error.NewNotFoundError(fmt.Sprintf("Category not found with this id = %s", id))
+}
+
+// FindCategoryBySlug prefers the category whose current slug is slug over one that had it
+// before, like the MongoDB repository.
+func (cr *CategoryRepository) FindCategoryBySlug(
+	ctx context.Context, slug string) (*category_entity.Category, *internal_error.InternalError) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var previous *category_entity.Category
+	for _, category := range cr.categories {
+		category := category
+		if category.Slug == slug {
+			return &category, nil
+		}
+		if previous == nil && category.HasSlug(slug) {
+			previous = &category
+		}
+	}
+
+	if previous == nil {
+		return nil, internal_error.ErrCategoryNotFound
+	}
+	return previous, nil
+}
+
+func (cr *CategoryRepository) UpdateCategory(
+	ctx context.Context, categoryEntity *category_entity.Category) *internal_error.InternalError {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	key := category_entity.Key(categoryEntity.Name)
+	if cr.takenLocked(key, categoryEntity.Slug, categoryEntity.Id) {
+		return internal_error.NewConflictError("A category with this name already exists")
+	}
+
+	for categoryKey, category := range cr.categories {
+		if category.Id == categoryEntity.Id {
+			updated := category
+			updated.Name = categoryEntity.Name
+			updated.Slug = categoryEntity.Slug
+			updated.PreviousSlugs = append([]string(nil), categoryEntity.PreviousSlugs...)
+
+			delete(cr.categories, categoryKey)
+			cr.categories[key] = updated
+			return nil
+		}
+	}
+
+	return internal_error.NewNotFoundError(fmt.Sprintf("Category not found with this id = %s", categoryEntity.Id))
+}
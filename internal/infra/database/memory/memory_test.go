@@ -0,0 +1,2163 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/database/auction"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+	"fullcycle-auction_go/internal/usecase/category_usecase"
+	"fullcycle-auction_go/internal/usecase/rating_usecase"
+	"fullcycle-auction_go/internal/usecase/report_usecase"
+	"fullcycle-auction_go/internal/usecase/user_usecase"
+
+	"github.com/google/uuid"
+)
+
+// startAuctionCloser runs the real sweeper against the in-memory repository. Both run
+// on the fake clock, so advancing it past an end time triggers the sweep right away.
+func startAuctionCloser(t *testing.T, repo *memory.AuctionRepository, clk clock.Clock) {
+	closer := auction.NewAuctionCloser(repo, clk, time.Second, 0)
+	closer.Start(context.Background())
+	t.Cleanup(closer.Stop)
+}
+
+func createAuction(
+	t *testing.T, repo *memory.AuctionRepository, clk clock.Clock, duration time.Duration) *auction_entity.Auction {
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, duration,
+		auction_entity.DurationBounds{}, clk)
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+
+	if err := repo.CreateAuction(context.Background(), auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	return auctionEntity
+}
+
+// newCategoryRepository returns a category repository holding the given categories.
+func newCategoryRepository(t *testing.T, names ...string) *memory.CategoryRepository {
+	repo := memory.NewCategoryRepository()
+	for _, name := range names {
+		categoryEntity, err := category_entity.CreateCategory(name, auction_entity.DurationBounds{})
+		if err != nil {
+			t.Fatalf("Failed to create category entity: %v", err.Error())
+		}
+		if err := repo.CreateCategory(context.Background(), categoryEntity); err != nil {
+			t.Fatalf("Failed to create category: %v", err.Error())
+		}
+	}
+
+	return repo
+}
+
+func waitForStatus(t *testing.T, repo *memory.AuctionRepository, id string, status auction_entity.AuctionStatus) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		found, err := repo.FindAuctionById(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Failed to find auction: %v", err.Error())
+		}
+		if found.Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("Auction %s did not reach status %v", id, status)
+}
+
+func TestAuctionAutoClose(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	repo := memory.NewAuctionRepository(clk)
+	startAuctionCloser(t, repo, clk)
+
+	auctionEntity := createAuction(t, repo, clk, 10*time.Minute)
+
+	// Several sweeps run before the end time and must leave the auction alone
+	for i := 0; i < 5; i++ {
+		clk.Advance(time.Minute)
+	}
+	time.Sleep(20 * time.Millisecond)
+	found, err := repo.FindAuctionById(context.Background(), auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if found.Status != auction_entity.Active {
+		t.Fatalf("Expected the auction to be Active before its end time, got %v", found.Status)
+	}
+
+	clk.Advance(5*time.Minute + time.Second)
+	waitForStatus(t, repo, auctionEntity.Id, auction_entity.Completed)
+}
+
+// TestTimeAccelerationClosesAuctionsSooner runs the sweeper and the soft close on a clock
+// 60 times faster than the fake wall clock, so an auction of 600 seconds closes once the
+// wall clock moves 10 seconds, and a bid 30 simulated seconds before the end extends
+// another by a simulated minute, one wall second.
+func TestTimeAccelerationClosesAuctionsSooner(t *testing.T) {
+	wall := clock.NewFake(time.Now())
+	clk := clock.NewAccelerated(wall, 60)
+
+	repo := memory.NewAuctionRepository(clk)
+	startAuctionCloser(t, repo, clk)
+
+	config := bid_usecase.DefaultConfig()
+	config.SnipeWindow, config.SnipeExtension = time.Minute, time.Minute
+	bidUseCase := bid_usecase.NewBidUseCase(memory.NewBidRepository(repo), repo, nil, nil, nil, nil, clk, config)
+	t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+
+	unbid := createAuction(t, repo, clk, 600*time.Second)
+	auctionEntity := createAuction(t, repo, clk, 600*time.Second)
+	ctx := context.Background()
+	created, err := repo.FindAuctionById(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+
+	wall.Advance(9*time.Second + 500*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if found, err := repo.FindAuctionById(ctx, unbid.Id); err != nil || found.Status != auction_entity.Active {
+		t.Fatalf("Expected the auction to be Active 570 simulated seconds in, got %+v, %v", found, err)
+	}
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auctionEntity.Id, Amount: 1000,
+	}); err != nil {
+		t.Fatalf("Failed to place bid: %v", err.Error())
+	}
+
+	// Past the original end time, before the extended one
+	wall.Advance(time.Second)
+	waitForStatus(t, repo, unbid.Id, auction_entity.Completed)
+	found, err := repo.FindAuctionById(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if found.Status != auction_entity.Active || !found.EndTime.Equal(created.EndTime.Add(time.Minute)) {
+		t.Fatalf("Expected the auction to be Active and extended by a minute, got %v ending at %v",
+			found.Status, found.EndTime)
+	}
+
+	wall.Advance(600 * time.Millisecond)
+	waitForStatus(t, repo, auctionEntity.Id, auction_entity.Completed)
+
+	if elapsed := clk.Now().Sub(auctionEntity.Timestamp); elapsed < 660*time.Second || elapsed > 670*time.Second {
+		t.Errorf("Expected about 666 simulated seconds after 11.1 wall seconds, got %v", elapsed)
+	}
+}
+
+func TestMultipleAuctionsAutoClose(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	repo := memory.NewAuctionRepository(clk)
+	startAuctionCloser(t, repo, clk)
+
+	short := createAuction(t, repo, clk, time.Minute)
+	long := createAuction(t, repo, clk, time.Hour)
+
+	clk.Advance(2 * time.Minute)
+	waitForStatus(t, repo, short.Id, auction_entity.Completed)
+
+	found, err := repo.FindAuctionById(context.Background(), long.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if found.Status != auction_entity.Active {
+		t.Errorf("Expected the longer auction to stay Active, got %v", found.Status)
+	}
+}
+
+func TestCloseLagMonitorReportsAuctionsLeftActive(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	repo := memory.NewAuctionRepository(clk)
+	monitor := auction.NewCloseLagMonitor(repo, clk, time.Minute, time.Second)
+
+	// No closer runs, so the auction stays Active past its end time
+	createAuction(t, repo, clk, time.Minute)
+
+	monitor.Check(context.Background())
+	if lag := monitor.MaxLag(); lag != 0 {
+		t.Fatalf("Expected no lag before the end time, got %v", lag)
+	}
+
+	clk.Advance(3 * time.Minute)
+	monitor.Check(context.Background())
+	if lag := monitor.MaxLag(); lag <= monitor.Alarm() {
+		t.Fatalf("Expected a lag over the %v alarm, got %v", monitor.Alarm(), lag)
+	}
+}
+
+func TestScheduledAuctionStartsAndClosesOnTime(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidUseCase := bid_usecase.NewBidUseCase(
+		memory.NewBidRepository(auctionRepo), auctionRepo, nil, nil, nil, nil, clk, bid_usecase.DefaultConfig())
+	t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+	startAuctionCloser(t, auctionRepo, clk)
+	ctx := context.Background()
+
+	auctionEntity, internalErr := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, 10*time.Minute,
+		auction_entity.DurationBounds{}, clk)
+	if internalErr != nil {
+		t.Fatalf("Failed to create auction entity: %v", internalErr.Error())
+	}
+	startTime := auctionEntity.Timestamp.Add(time.Hour)
+	auctionEntity.ScheduleStart(startTime, auction_entity.DurationBounds{})
+	if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	if auctionEntity.Status != auction_entity.Scheduled {
+		t.Fatalf("Expected a future start to leave the auction Scheduled, got %v", auctionEntity.Status)
+	}
+	if want := startTime.Add(10 * time.Minute); !auctionEntity.EndTime.Equal(want) {
+		t.Fatalf("Expected the duration to count from the start time, ending at %v, got %v", want, auctionEntity.EndTime)
+	}
+
+	bidInput := bid_usecase.BidInputDTO{UserId: uuid.New().String(), AuctionId: auctionEntity.Id, Amount: 10000}
+	if _, err := bidUseCase.CreateBid(ctx, bidInput); err != internal_error.ErrAuctionNotStarted {
+		t.Fatalf("Expected ErrAuctionNotStarted before the start time, got %v", err)
+	}
+
+	clk.Advance(time.Hour + time.Second)
+	waitForStatus(t, auctionRepo, auctionEntity.Id, auction_entity.Active)
+
+	if _, err := bidUseCase.CreateBid(ctx, bidInput); err != nil {
+		t.Fatalf("Expected bids to be accepted once the auction started, got %v", err.Error())
+	}
+
+	clk.Advance(10 * time.Minute)
+	waitForStatus(t, auctionRepo, auctionEntity.Id, auction_entity.Completed)
+}
+
+func TestWinningBidTieBreaksByEarliestTimestamp(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	second := time.Now().Truncate(time.Second)
+
+	// Both bids are placed within the same second, told apart by their milliseconds
+	earlierBid := bid_entity.Bid{
+		Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionEntity.Id,
+		Amount: 500, Timestamp: second.Add(100 * time.Millisecond),
+	}
+	laterBid := bid_entity.Bid{
+		Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionEntity.Id,
+		Amount: 500, Timestamp: second.Add(600 * time.Millisecond),
+	}
+
+	// The later bid goes in first so insertion order would favour it
+	bidRepo.Insert(laterBid)
+	bidRepo.Insert(earlierBid)
+
+	winningBid, err := bidRepo.FindWinningBidByAuctionId(context.Background(), auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find winning bid: %v", err.Error())
+	}
+	if winningBid.Id != earlierBid.Id {
+		t.Errorf("Expected the earlier bid %s to win, got %s", earlierBid.Id, winningBid.Id)
+	}
+}
+
+// TestBidPlacedBeforeTheEndWinsTheClose places a bid a second before the end of the
+// auction and closes the auction right after its end: the bid must win it.
+func TestBidPlacedBeforeTheEndWinsTheClose(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	bidUseCase := bid_usecase.NewBidUseCase(
+		bidRepo, auctionRepo, nil, nil, nil, nil, clk, bid_usecase.DefaultConfig())
+	t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+	auctionRepo.SetFlushBids(bidUseCase.Flush)
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clk, time.Minute)
+	clk.Advance(time.Minute - time.Second)
+	bid, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auctionEntity.Id, Amount: 1000,
+	})
+	if err != nil {
+		t.Fatalf("Failed to place bid: %v", err.Error())
+	}
+
+	clk.Advance(2 * time.Second)
+	closedIds, err := auctionRepo.CloseExpiredAuctions(ctx)
+	if err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+	if len(closedIds) != 1 || closedIds[0] != auctionEntity.Id {
+		t.Fatalf("Expected the auction to close, got %v", closedIds)
+	}
+
+	found, err := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if found.WinningBid == nil || found.WinningBid.BidId != bid.Id || found.Outcome != auction_entity.Sold {
+		t.Errorf("Expected the bid %s to win, got %+v with outcome %d", bid.Id, found.WinningBid, found.Outcome)
+	}
+	if bids, err := bidRepo.FindBidByAuctionId(ctx, auctionEntity.Id, bid_entity.BidListFilter{}); err != nil || len(bids) != 1 {
+		t.Errorf("Expected the bid to be stored, got %+v, %v", bids, err)
+	}
+}
+
+// closingBidRepository closes the auction of the bids it is asked to write first, as
+// another instance closing it between the checks of CreateBid and the write would.
+type closingBidRepository struct {
+	*memory.BidRepository
+
+	auctionRepo *memory.AuctionRepository
+}
+
+func (r *closingBidRepository) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) []*internal_error.InternalError {
+	for _, bid := range bidEntities {
+		r.auctionRepo.CloseAuctionNow(ctx, bid.AuctionId)
+	}
+
+	return r.BidRepository.CreateBid(ctx, bidEntities)
+}
+
+func TestBidOnAuctionClosedBeforeTheWriteAnswersConflict(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := &closingBidRepository{BidRepository: memory.NewBidRepository(auctionRepo), auctionRepo: auctionRepo}
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	_, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auctionEntity.Id, Amount: 1000,
+	})
+	if err == nil || !errors.Is(err, internal_error.ErrAuctionNotActive) || err.Err != internal_error.Conflict {
+		t.Fatalf("Expected the write to reject the bid as a conflict, got %v", err)
+	}
+
+	if bids, err := bidRepo.FindBidByAuctionId(ctx, auctionEntity.Id, bid_entity.BidListFilter{}); err != nil || len(bids) != 0 {
+		t.Errorf("Expected no bid stored, got %+v, %v", bids, err)
+	}
+}
+
+func TestFindBidByAuctionIdOrdersAndPages(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	now := time.Now()
+
+	// Inserted out of order so the listing has to sort them
+	var ids []string
+	for _, minutes := range []int{2, 0, 1} {
+		bid := bid_entity.Bid{
+			Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionEntity.Id,
+			Amount: int64(100 * (minutes + 1)), Timestamp: now.Add(time.Duration(minutes) * time.Minute),
+		}
+		bidRepo.Insert(bid)
+		ids = append(ids, bid.Id)
+	}
+	oldest, middle, newest := ids[1], ids[2], ids[0]
+
+	testCases := []struct {
+		name     string
+		input    bid_usecase.BidListInputDTO
+		expected []string
+	}{
+		{name: "Newest first by default", expected: []string{newest, middle, oldest}},
+		{name: "Ascending", input: bid_usecase.BidListInputDTO{Order: "asc"}, expected: []string{oldest, middle, newest}},
+		{name: "Limit", input: bid_usecase.BidListInputDTO{Limit: 2}, expected: []string{newest, middle}},
+		{name: "Offset", input: bid_usecase.BidListInputDTO{Order: "asc", Offset: 1, Limit: 1}, expected: []string{middle}},
+		{name: "Past the end", input: bid_usecase.BidListInputDTO{Offset: 5}, expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := bidUseCase.FindBidByAuctionId(context.Background(), auctionEntity.Id, tc.input)
+			if err != nil {
+				t.Fatalf("Failed to find bids: %v", err.Error())
+			}
+			bids := page.Bids
+			if bids == nil || len(bids) != len(tc.expected) {
+				t.Fatalf("Expected %d bids, got %+v", len(tc.expected), bids)
+			}
+			for i, id := range tc.expected {
+				if bids[i].Id != id {
+					t.Errorf("Expected bid %d to be %s, got %s", i, id, bids[i].Id)
+				}
+			}
+		})
+	}
+
+	if _, err := bidUseCase.FindBidByAuctionId(
+		context.Background(), auctionEntity.Id, bid_usecase.BidListInputDTO{Order: "random"}); err == nil {
+		t.Errorf("Expected an invalid order to be rejected")
+	}
+}
+
+func TestFindBidByAuctionIdCursorSurvivesNewBids(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	now := time.Now()
+
+	insertBid := func(minutes int) string {
+		bid := bid_entity.Bid{
+			Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionEntity.Id,
+			Amount: int64(100 * (minutes + 1)), Timestamp: now.Add(time.Duration(minutes) * time.Minute),
+		}
+		bidRepo.Insert(bid)
+		return bid.Id
+	}
+
+	var expected []string
+	for minutes := 0; minutes < 5; minutes++ {
+		expected = append([]string{insertBid(minutes)}, expected...)
+	}
+
+	var listed []string
+	input := bid_usecase.BidListInputDTO{Limit: 2}
+	for page := 0; ; page++ {
+		result, err := bidUseCase.FindBidByAuctionId(context.Background(), auctionEntity.Id, input)
+		if err != nil {
+			t.Fatalf("Failed to find bids: %v", err.Error())
+		}
+		for _, bid := range result.Bids {
+			listed = append(listed, bid.Id)
+		}
+
+		// A bid arriving mid-listing goes on top and must not shift the next pages
+		if page == 0 {
+			insertBid(10)
+		}
+
+		if result.NextCursor == "" {
+			break
+		}
+		input.Cursor = result.NextCursor
+		input.Offset = 3 // ignored in favour of the cursor
+	}
+
+	if len(listed) != len(expected) {
+		t.Fatalf("Expected %d bids, got %v", len(expected), listed)
+	}
+	for i, id := range expected {
+		if listed[i] != id {
+			t.Errorf("Expected bid %d to be %s, got %s", i, id, listed[i])
+		}
+	}
+
+	if _, err := bidUseCase.FindBidByAuctionId(
+		context.Background(), auctionEntity.Id, bid_usecase.BidListInputDTO{Cursor: "not a cursor"}); err == nil {
+		t.Errorf("Expected an invalid cursor to be rejected")
+	}
+}
+
+func TestExportBidsFiltersAndOrdersAcrossAuctions(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	first := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	second := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	now := time.Now().Truncate(time.Second)
+
+	// Inserted out of order and across two auctions so the export has to merge them
+	var ids []string
+	for i, minutes := range []int{3, 1, 2, 0} {
+		auctionId := first.Id
+		if i%2 == 1 {
+			auctionId = second.Id
+		}
+		bid := bid_entity.Bid{
+			Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionId,
+			Amount: int64(100 * (i + 1)), Timestamp: now.Add(time.Duration(minutes) * time.Minute),
+		}
+		bidRepo.Insert(bid)
+		ids = append(ids, bid.Id)
+	}
+
+	testCases := []struct {
+		name     string
+		input    bid_usecase.BidExportInputDTO
+		expected []string
+	}{
+		{name: "Every bid oldest first", expected: []string{ids[3], ids[1], ids[2], ids[0]}},
+		{name: "One auction", input: bid_usecase.BidExportInputDTO{AuctionId: first.Id}, expected: []string{ids[2], ids[0]}},
+		{
+			name:     "Date range",
+			input:    bid_usecase.BidExportInputDTO{From: now.Add(time.Minute), To: now.Add(2 * time.Minute)},
+			expected: []string{ids[1], ids[2]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var exported []string
+			err := bidUseCase.ExportBids(context.Background(), tc.input, func(bid bid_usecase.BidOutputDTO) error {
+				exported = append(exported, bid.Id)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Failed to export bids: %v", err.Error())
+			}
+			if strings.Join(exported, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("Expected bids %v, got %v", tc.expected, exported)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := bidUseCase.ExportBids(ctx, bid_usecase.BidExportInputDTO{}, func(bid bid_usecase.BidOutputDTO) error {
+		t.Errorf("Expected no bid to be exported once the request is cancelled")
+		return nil
+	})
+	if err == nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the export to stop with the request, got %v", err)
+	}
+}
+
+func TestCreateBidRejectsClosedAuction(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	// The bids are timestamped by the default clock, so the late one is placed after the end
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clk, time.Minute)
+
+	firstBid, _ := bid_entity.CreateBid(uuid.New().String(), auctionEntity.Id, 10000, money.DefaultCurrency, clk)
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, firstBid); err != nil {
+		t.Fatalf("Expected the bid on an active auction to be accepted, got %v", err.Error())
+	}
+
+	lowerBid, _ := bid_entity.CreateBid(uuid.New().String(), auctionEntity.Id, 10000, money.DefaultCurrency, clk)
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, lowerBid); err == nil || err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad_request for a bid that doesn't beat the winner, got %v", err)
+	}
+
+	clk.Advance(2 * time.Minute)
+
+	lateBid, _ := bid_entity.CreateBid(uuid.New().String(), auctionEntity.Id, 20000, money.DefaultCurrency, clk)
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, lateBid); err != internal_error.ErrAuctionNotActive {
+		t.Errorf("Expected ErrAuctionNotActive after the end time, got %v", err)
+	}
+}
+
+func TestUpdateAuctionOnlyBeforeFirstBid(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, memory.NewUserRepository(), newCategoryRepository(t, "Collectibles"), nil, nil, nil, nil,
+		clk, auction_usecase.Config{})
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clk, time.Minute)
+
+	unknownCategory := "Colectibles"
+	if _, err := auctionUseCase.UpdateAuction(ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{
+		SellerId: auctionEntity.SellerId, Category: &unknownCategory}); err == nil || err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad_request for an unknown category, got %v", err)
+	}
+
+	category := "Collectibles"
+	updated, err := auctionUseCase.UpdateAuction(
+		ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{SellerId: auctionEntity.SellerId, Category: &category})
+	if err != nil {
+		t.Fatalf("Expected the update before any bid to succeed, got %v", err.Error())
+	}
+	if updated.Category != category || updated.ProductName != auctionEntity.ProductName {
+		t.Errorf("Expected only the category to change, got %+v", updated)
+	}
+
+	if _, err := auctionUseCase.UpdateAuction(ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{
+		SellerId: uuid.New().String(), Category: &category}); err == nil || err.Err != internal_error.Forbidden {
+		t.Errorf("Expected a forbidden error for another seller, got %v", err)
+	}
+
+	blank := " "
+	if _, err := auctionUseCase.UpdateAuction(
+		ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{SellerId: auctionEntity.SellerId, ProductName: &blank}); err == nil ||
+		err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad_request for a blank product name, got %v", err)
+	}
+	if _, err := auctionUseCase.UpdateAuction(
+		ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{SellerId: auctionEntity.SellerId}); err == nil ||
+		err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad_request for an empty update, got %v", err)
+	}
+
+	firstBid, _ := bid_entity.CreateBid(uuid.New().String(), auctionEntity.Id, 10000, money.DefaultCurrency, clk)
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, firstBid); err != nil {
+		t.Fatalf("Failed to create bid: %v", err.Error())
+	}
+
+	if _, err := auctionUseCase.UpdateAuction(
+		ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{SellerId: auctionEntity.SellerId, Category: &category}); err == nil ||
+		err.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict after the first bid, got %v", err)
+	}
+
+	closedAuction := createAuction(t, auctionRepo, clk, time.Minute)
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionUseCase.UpdateAuction(
+		ctx, closedAuction.Id, auction_usecase.AuctionUpdateInputDTO{SellerId: closedAuction.SellerId, Category: &category}); err == nil ||
+		err.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict once the auction ended, got %v", err)
+	}
+}
+
+func TestSellerChangesRejectStaleVersions(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, memory.NewUserRepository(), newCategoryRepository(t, "Collectibles"), nil, nil, nil, nil,
+		nil, auction_usecase.Config{})
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Minute)
+
+	description := "Fixed description of the test product"
+	var version int64
+	updated, err := auctionUseCase.UpdateAuction(ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{
+		SellerId: auctionEntity.SellerId, Version: &version, Description: &description})
+	if err != nil {
+		t.Fatalf("Expected the update at the current version to succeed, got %v", err.Error())
+	}
+	if updated.Version != 1 {
+		t.Errorf("Expected the update to bump the version to 1, got %d", updated.Version)
+	}
+
+	// A second client still holding version 0 must re-fetch before changing anything
+	productName := "Renamed Product"
+	if _, err := auctionUseCase.UpdateAuction(ctx, auctionEntity.Id, auction_usecase.AuctionUpdateInputDTO{
+		SellerId: auctionEntity.SellerId, Version: &version, ProductName: &productName}); err != internal_error.ErrAuctionModified {
+		t.Errorf("Expected ErrAuctionModified for a stale version, got %v", err)
+	}
+	if _, err := auctionUseCase.ReplaceAuctionImages(ctx, auctionEntity.Id, auction_usecase.AuctionImagesInputDTO{
+		SellerId: auctionEntity.SellerId, Version: &version, Images: []auction_usecase.AuctionImageInputDTO{},
+	}); err != internal_error.ErrAuctionModified {
+		t.Errorf("Expected ErrAuctionModified replacing images at a stale version, got %v", err)
+	}
+
+	// The repository checks the version too, for changes racing past the use case
+	if err := auctionRepo.UpdateAuctionStatus(
+		ctx, auctionEntity.Id, 0, auction_entity.Active, auction_entity.Cancelled); err != internal_error.ErrAuctionModified {
+		t.Errorf("Expected ErrAuctionModified cancelling at a stale version, got %v", err)
+	}
+
+	// Bids move the revision but not the version
+	firstBid, _ := bid_entity.CreateBid(
+		uuid.New().String(), auctionEntity.Id, 10000, money.DefaultCurrency, clock.New())
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, firstBid); err != nil {
+		t.Fatalf("Failed to create bid: %v", err.Error())
+	}
+	current, err := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if current.Version != 1 || current.Revision <= 1 {
+		t.Errorf("Expected the bid to leave the version at 1 and bump the revision, got %d and %d",
+			current.Version, current.Revision)
+	}
+
+	if err := auctionUseCase.CancelAuction(ctx, auctionEntity.Id, auctionEntity.SellerId); err != nil {
+		t.Fatalf("Expected the cancel at the current version to succeed, got %v", err.Error())
+	}
+}
+
+func TestSellerListingAndCancelOwnership(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	userRepo := memory.NewUserRepository()
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, memory.NewBidRepository(auctionRepo), userRepo, newCategoryRepository(t, "Electronics"), nil, nil, nil, nil,
+		nil, auction_usecase.Config{})
+	ctx := context.Background()
+
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	if err := userRepo.CreateUser(ctx, seller); err != nil {
+		t.Fatalf("Failed to create user: %v", err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := auctionUseCase.CreateAuction(ctx, auction_usecase.AuctionInputDTO{
+			SellerId:    seller.Id,
+			ProductName: "Seller Product",
+			Category:    "Electronics",
+			Description: "Auction listed by the seller",
+			Condition:   auction_usecase.ProductCondition(auction_entity.New),
+		}); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+	}
+	otherAuction := createAuction(t, auctionRepo, clock.New(), time.Hour)
+
+	auctions, total, err := auctionUseCase.FindAuctionsBySellerId(
+		ctx, seller.Id, auction_usecase.AuctionFilterInputDTO{}, 1, 10)
+	if err != nil {
+		t.Fatalf("Failed to list the seller's auctions: %v", err.Error())
+	}
+	if total != 2 || len(auctions) != 2 || auctions[0].SellerId != seller.Id {
+		t.Fatalf("Expected the seller's 2 auctions, got %d: %+v", total, auctions)
+	}
+
+	if _, _, err := auctionUseCase.FindAuctionsBySellerId(
+		ctx, uuid.New().String(), auction_usecase.AuctionFilterInputDTO{}, 1, 10); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found for an unknown seller, got %v", err)
+	}
+
+	if err := auctionUseCase.CancelAuction(ctx, otherAuction.Id, seller.Id); err == nil || err.Err != internal_error.Forbidden {
+		t.Errorf("Expected a forbidden error cancelling another seller's auction, got %v", err)
+	}
+	if err := auctionUseCase.CancelAuction(ctx, auctions[0].Id, seller.Id); err != nil {
+		t.Errorf("Expected the seller to cancel their auction, got %v", err.Error())
+	}
+}
+
+func TestUseCasesAgainstMemoryRepositories(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, memory.NewUserRepository(), nil, nil, nil, nil, nil, nil, auction_usecase.Config{})
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	winner := uuid.New().String()
+
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auctionEntity.Id, Amount: 10000,
+	}); err != nil {
+		t.Fatalf("Expected the first bid to be accepted, got %v", err.Error())
+	}
+
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: winner, AuctionId: auctionEntity.Id, Amount: 10050,
+	}); err == nil {
+		t.Error("Expected a bid under the minimum increment to be rejected")
+	}
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: winner, AuctionId: auctionEntity.Id, Amount: 15000,
+	}); err != nil {
+		t.Fatalf("Expected the higher bid to be accepted, got %v", err.Error())
+	}
+
+	winningInfo, err := auctionUseCase.FindWinningBidByAuctionId(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find winning bid: %v", err.Error())
+	}
+	if winningInfo.Bid == nil || winningInfo.Bid.UserId != winner || winningInfo.Bid.Amount != 15000 {
+		t.Errorf("Expected %s to win with 150.00, got %+v", winner, winningInfo.Bid)
+	}
+}
+
+func TestSoftDeletedAuctionsAreHidden(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, memory.NewUserRepository(), nil, nil, nil, nil, nil, nil, auction_usecase.Config{})
+	ctx := context.Background()
+
+	deleted := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	kept := createAuction(t, auctionRepo, clock.New(), time.Hour)
+
+	if err := auctionUseCase.DeleteAuction(ctx, deleted.Id); err != nil {
+		t.Fatalf("Failed to delete auction: %v", err.Error())
+	}
+	if err := auctionUseCase.DeleteAuction(ctx, deleted.Id); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found deleting the auction twice, got %v", err)
+	}
+
+	auctions, total, err := auctionUseCase.FindAuctions(ctx, auction_usecase.AuctionFilterInputDTO{}, 1, 10)
+	if err != nil {
+		t.Fatalf("Failed to find auctions: %v", err.Error())
+	}
+	if total != 1 || len(auctions) != 1 || auctions[0].Id != kept.Id {
+		t.Errorf("Expected only the kept auction to be listed, got %d: %+v", total, auctions)
+	}
+
+	if _, err := auctionUseCase.FindAuctionById(ctx, deleted.Id); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found for the deleted auction, got %v", err)
+	}
+	if _, err := auctionUseCase.GetAuctionSummary(ctx, deleted.Id); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected the summary of the deleted auction to be not_found, got %v", err)
+	}
+	if _, err := auctionUseCase.FindWinnerByAuctionId(ctx, deleted.Id); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected the winner of the deleted auction to be not_found, got %v", err)
+	}
+
+	adminCtx := auction_entity.WithDeleted(ctx)
+	found, err := auctionUseCase.FindAuctionById(adminCtx, deleted.Id)
+	if err != nil || found.DeletedAt == nil {
+		t.Errorf("Expected admins to find the deleted auction with deleted_at, got %+v, %v", found, err)
+	}
+	if _, total, _ := auctionUseCase.FindAuctions(adminCtx, auction_usecase.AuctionFilterInputDTO{}, 1, 10); total != 2 {
+		t.Errorf("Expected both auctions when including deleted ones, got %d", total)
+	}
+
+	bid, _ := bid_entity.CreateBid(uuid.New().String(), deleted.Id, 10000, money.DefaultCurrency, clock.New())
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+	_, bidErr := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{UserId: uuid.New().String(), AuctionId: deleted.Id, Amount: 10000})
+	if bidErr == nil || bidErr.Err != internal_error.AuctionNotFound {
+		t.Errorf("Expected bids on the deleted auction to be rejected up front, got %v", bidErr)
+	}
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, bid); err != internal_error.ErrAuctionNotActive {
+		t.Errorf("Expected the bid insert on the deleted auction to be rejected, got %v", err)
+	}
+}
+
+func TestCreateAuctionRejectsEndTimeInThePast(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	repo := memory.NewAuctionRepository(clk)
+	ctx := context.Background()
+
+	ended, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Minute,
+		auction_entity.DurationBounds{}, clk)
+	running, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clk)
+	completed, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Minute,
+		auction_entity.DurationBounds{}, clk)
+	completed.Status = auction_entity.Completed
+
+	// The auctions were built before the clock moved past the end of the shortest ones
+	clk.Advance(2 * time.Minute)
+
+	errs := repo.CreateAuctions(ctx, []*auction_entity.Auction{ended, running, completed})
+	if errs[0] == nil || errs[0].Err != internal_error.BadRequest || !errs[0].HasCause("end_time") {
+		t.Errorf("Expected an auction already ended to be rejected under end_time, got %v", errs[0])
+	}
+	if errs[1] != nil || errs[2] != nil {
+		t.Errorf("Expected the running and the completed auctions to be stored, got %v, %v", errs[1], errs[2])
+	}
+
+	if _, err := repo.FindAuctionById(ctx, ended.Id); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected the ended auction not to be stored, got %v", err)
+	}
+}
+
+func TestCreateCategoryRejectsDuplicateName(t *testing.T) {
+	repo := newCategoryRepository(t, "Home Appliances")
+
+	duplicate, _ := category_entity.CreateCategory("  home   APPLIANCES ", auction_entity.DurationBounds{})
+	if err := repo.CreateCategory(context.Background(), duplicate); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict for a name differing only in case and spacing, got %v", err)
+	}
+}
+
+func TestCategorySlugsSurviveRenames(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	categoryRepo := newCategoryRepository(t, "Electronics", "Eletrônicos & Games")
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, nil, memory.NewUserRepository(), categoryRepo, nil, nil, nil, nil, nil, auction_usecase.Config{})
+	categoryUseCase := category_usecase.NewCategoryUseCase(categoryRepo, auctionRepo, auction_entity.DurationBounds{})
+	ctx := context.Background()
+
+	createAuction(t, auctionRepo, clock.New(), time.Hour)
+	createAuction(t, auctionRepo, clock.New(), time.Hour)
+
+	if _, err := categoryRepo.FindCategoryBySlug(ctx, "eletronicos-games"); err != nil {
+		t.Errorf("Expected accents and punctuation to be dropped from the slug, got %v", err)
+	}
+
+	category, err := categoryRepo.FindCategoryBySlug(ctx, "electronics")
+	if err != nil {
+		t.Fatalf("Failed to find the category by slug: %v", err.Error())
+	}
+
+	renamed, err := categoryUseCase.RenameCategory(ctx, category.Id, category_usecase.CategoryRenameInputDTO{Name: "Consumer Electronics"})
+	if err != nil {
+		t.Fatalf("Failed to rename the category: %v", err.Error())
+	}
+	if renamed.Slug != "consumer-electronics" {
+		t.Errorf("Expected the slug to follow the name, got %s", renamed.Slug)
+	}
+
+	// The old slug still lists the auctions, which moved to the new name
+	for _, slug := range []string{"consumer-electronics", "electronics"} {
+		auctions, total, err := auctionUseCase.FindAuctionsByCategorySlug(ctx, slug, auction_usecase.AuctionFilterInputDTO{}, 1, 10)
+		if err != nil {
+			t.Fatalf("Failed to list the auctions of %s: %v", slug, err.Error())
+		}
+		if total != 2 || auctions[0].Category != "Consumer Electronics" {
+			t.Errorf("Expected the 2 renamed auctions under %s, got %d: %+v", slug, total, auctions)
+		}
+	}
+
+	// A new category can take the old slug back, and then wins the lookup
+	categoryUseCase.CreateCategory(ctx, category_usecase.CategoryInputDTO{Name: "Electronics"})
+	if found, err := categoryRepo.FindCategoryBySlug(ctx, "electronics"); err != nil || found.Id == category.Id {
+		t.Errorf("Expected the new Electronics category to own its slug, got %+v, %v", found, err)
+	}
+
+	if _, err := categoryUseCase.RenameCategory(
+		ctx, category.Id, category_usecase.CategoryRenameInputDTO{Name: "eletronicos games"}); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict renaming to another category's slug, got %v", err)
+	}
+
+	_, _, err = auctionUseCase.FindAuctionsByCategorySlug(ctx, "unknown", auction_usecase.AuctionFilterInputDTO{}, 1, 10)
+	if err == nil || err.Err != internal_error.CategoryNotFound {
+		t.Errorf("Expected category_not_found for an unknown slug, got %v", err)
+	}
+}
+
+func TestCreateUserRejectsDuplicateEmail(t *testing.T) {
+	repo := memory.NewUserRepository()
+	ctx := context.Background()
+
+	first, _ := user_entity.CreateUser("First", "same@example.com", "secret-password")
+	if err := repo.CreateUser(ctx, first); err != nil {
+		t.Fatalf("Expected the first user to be created, got %v", err.Error())
+	}
+
+	second, _ := user_entity.CreateUser("Second", "SAME@example.com", "secret-password")
+	if err := repo.CreateUser(ctx, second); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict for a duplicate email, got %v", err)
+	}
+}
+
+func TestAuthenticateRequiresThePassword(t *testing.T) {
+	userRepo := memory.NewUserRepository()
+	userUseCase := user_usecase.NewUserUseCase(userRepo, nil)
+	ctx := context.Background()
+
+	short := user_usecase.UserInputDTO{Name: "Short", Email: "short@example.com", Password: "short"}
+	if _, err := userUseCase.CreateUser(ctx, short); err == nil || err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad request for a short password, got %v", err)
+	}
+
+	user, err := userUseCase.CreateUser(
+		ctx, user_usecase.UserInputDTO{Name: "User", Email: "user@example.com", Password: "secret-password"})
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err.Error())
+	}
+
+	if found, err := userUseCase.Authenticate(ctx, user.Id, "secret-password"); err != nil || found.Id != user.Id {
+		t.Errorf("Expected the password to authenticate the user, got %+v, %v", found, err)
+	}
+
+	// A user created before passwords existed has no hash to check against
+	legacy := &user_entity.User{Id: uuid.New().String(), Name: "Legacy", Email: "legacy@example.com"}
+	if err := userRepo.CreateUser(ctx, legacy); err != nil {
+		t.Fatalf("Failed to create user: %v", err.Error())
+	}
+
+	rejected := map[string][2]string{
+		"wrong password":        {user.Id, "wrong-password"},
+		"empty password":        {user.Id, ""},
+		"unknown user":          {uuid.New().String(), "secret-password"},
+		"user with no password": {legacy.Id, ""},
+	}
+	for name, credentials := range rejected {
+		_, err := userUseCase.Authenticate(ctx, credentials[0], credentials[1])
+		if !errors.Is(err, user_usecase.ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", name, err)
+		}
+	}
+
+	if err := userUseCase.DeleteUser(ctx, user.Id); err != nil {
+		t.Fatalf("Failed to delete user: %v", err.Error())
+	}
+	_, err = userUseCase.Authenticate(ctx, user.Id, "secret-password")
+	if err != user_usecase.ErrInvalidCredentials {
+		t.Errorf("Expected ErrInvalidCredentials for a deleted user, got %v", err)
+	}
+}
+
+func TestSoftDeletedUserCantBidOrSell(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	userRepo := memory.NewUserRepository()
+	userUseCase := user_usecase.NewUserUseCase(userRepo, bidRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, userRepo, newCategoryRepository(t, "Electronics"), nil, nil, nil, nil, nil,
+		auction_usecase.Config{})
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, userRepo, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	ctx := context.Background()
+
+	user, _ := userUseCase.CreateUser(
+		ctx, user_usecase.UserInputDTO{Name: "User", Email: "user@example.com", Password: "secret-password"})
+	other, _ := userUseCase.CreateUser(
+		ctx, user_usecase.UserInputDTO{Name: "Other", Email: "other@example.com", Password: "secret-password"})
+
+	taken := "OTHER@example.com"
+	if _, err := userUseCase.UpdateUser(
+		ctx, user.Id, user_usecase.UserUpdateInputDTO{Email: &taken}); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected a conflict changing to another user's email, got %v", err)
+	}
+
+	name := "  Renamed "
+	updated, err := userUseCase.UpdateUser(ctx, user.Id, user_usecase.UserUpdateInputDTO{Name: &name})
+	if err != nil || updated.Name != "Renamed" || updated.Email != user.Email {
+		t.Fatalf("Expected only the name to change, got %+v, %v", updated, err)
+	}
+
+	if err := userUseCase.DeleteUser(ctx, user.Id); err != nil {
+		t.Fatalf("Failed to delete user: %v", err.Error())
+	}
+	if err := userUseCase.DeleteUser(ctx, user.Id); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found deleting the user twice, got %v", err)
+	}
+	if _, err := userUseCase.UpdateUser(ctx, user.Id, user_usecase.UserUpdateInputDTO{Name: &name}); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found updating a deleted user, got %v", err)
+	}
+
+	if err := auctionUseCase.CreateAuction(ctx, auction_usecase.AuctionInputDTO{
+		SellerId:    user.Id,
+		ProductName: "Deleted Seller Product",
+		Category:    "Electronics",
+		Description: "Auction from a deleted seller",
+		Condition:   auction_usecase.ProductCondition(auction_entity.New),
+	}); err != internal_error.ErrUserDeleted {
+		t.Errorf("Expected ErrUserDeleted creating an auction, got %v", err)
+	}
+
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: user.Id, AuctionId: auctionEntity.Id, Amount: 10000,
+	}); err != internal_error.ErrUserDeleted {
+		t.Errorf("Expected ErrUserDeleted placing a bid, got %v", err)
+	}
+
+	found, err := userUseCase.FindUserById(ctx, user.Id)
+	if err != nil || !found.Deleted || found.Name != "Renamed" {
+		t.Errorf("Expected the deleted user to still resolve flagged as deleted, got %+v, %v", found, err)
+	}
+	if found, err := userUseCase.FindUserById(ctx, other.Id); err != nil || found.Deleted {
+		t.Errorf("Expected the other user not to be flagged as deleted, got %+v, %v", found, err)
+	}
+}
+
+func TestSellerCantBidOnOwnAuction(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	sellerId := auctionEntity.SellerId
+
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: sellerId, AuctionId: auctionEntity.Id, Amount: 10000,
+	}); err != internal_error.ErrSelfBid {
+		t.Errorf("Expected ErrSelfBid placing a bid on the own auction, got %v", err)
+	}
+	if err := bidUseCase.CreateProxyBid(ctx, sellerId, auctionEntity.Id, 50000); err != internal_error.ErrSelfBid {
+		t.Errorf("Expected ErrSelfBid placing a maximum bid on the own auction, got %v", err)
+	}
+
+	// Auctions created before sellers were recorded can't tell, so the bid goes through
+	legacyAuction, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Legacy Product", "Electronics", "Legacy auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clock.New())
+	legacyAuction.SellerId = ""
+	if err := auctionRepo.CreateAuction(ctx, legacyAuction); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: sellerId, AuctionId: legacyAuction.Id, Amount: 10000,
+	}); err != nil {
+		t.Errorf("Expected the bid on a legacy auction to be accepted, got %v", err.Error())
+	}
+
+	// Self bids stored before the check existed show up in the report
+	for i := 0; i < 2; i++ {
+		bidRepo.Insert(bid_entity.Bid{
+			Id: uuid.New().String(), UserId: sellerId, AuctionId: auctionEntity.Id,
+			Amount: int64(10000 * (i + 1)), Timestamp: time.Now(),
+		})
+	}
+	bidUseCase.Flush(ctx)
+
+	counts, err := bidUseCase.CountSelfBids(ctx)
+	if err != nil {
+		t.Fatalf("Failed to count self bids: %v", err.Error())
+	}
+	if len(counts) != 1 || counts[0].UserId != sellerId || counts[0].AuctionCount != 1 || counts[0].BidCount != 2 {
+		t.Errorf("Expected 2 self bids on 1 auction, got %+v", counts)
+	}
+}
+
+func TestAuctionImagesKeepTheSubmittedOrder(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	userRepo := memory.NewUserRepository()
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, memory.NewBidRepository(auctionRepo), userRepo, newCategoryRepository(t, "Electronics"), nil, nil, nil, nil,
+		clk, auction_usecase.Config{})
+	ctx := context.Background()
+
+	seller, _ := user_entity.CreateUser("Seller", "images@example.com", "secret-password")
+	if err := userRepo.CreateUser(ctx, seller); err != nil {
+		t.Fatalf("Failed to create user: %v", err.Error())
+	}
+
+	input := auction_usecase.AuctionInputDTO{
+		SellerId:        seller.Id,
+		ProductName:     "Camera",
+		Category:        "Electronics",
+		Description:     "Camera with its original box",
+		Condition:       auction_usecase.ProductCondition(auction_entity.New),
+		DurationSeconds: 60,
+	}
+
+	input.Images = []auction_usecase.AuctionImageInputDTO{{URL: "ftp://example.com/camera.jpg"}}
+	if err := auctionUseCase.CreateAuction(ctx, input); err == nil || !err.HasCause("images[0].url") {
+		t.Errorf("Expected the non http URL to be rejected, got %v", err)
+	}
+
+	input.Images = make([]auction_usecase.AuctionImageInputDTO, auction_entity.MaxAuctionImages+1)
+	for i := range input.Images {
+		input.Images[i].URL = "https://example.com/camera.jpg"
+	}
+	if err := auctionUseCase.CreateAuction(ctx, input); err == nil || !err.HasCause("images") {
+		t.Errorf("Expected more than %d images to be rejected, got %v", auction_entity.MaxAuctionImages, err)
+	}
+
+	input.Images = []auction_usecase.AuctionImageInputDTO{
+		{URL: "https://example.com/front.jpg", AltText: "Front"},
+		{URL: "https://example.com/back.jpg", AltText: "Back"},
+		{URL: "https://example.com/box.jpg"},
+	}
+	if err := auctionUseCase.CreateAuction(ctx, input); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	auctions, _, err := auctionUseCase.FindAuctionsBySellerId(ctx, seller.Id, auction_usecase.AuctionFilterInputDTO{}, 1, 10)
+	if err != nil || len(auctions) != 1 {
+		t.Fatalf("Expected the seller's auction, got %+v, %v", auctions, err)
+	}
+	created := auctions[0]
+	if len(created.Images) != 3 || created.Images[0].AltText != "Front" ||
+		created.Images[2].URL != "https://example.com/box.jpg" || created.Images[2].Order != 3 {
+		t.Errorf("Expected the images in the submitted order, got %+v", created.Images)
+	}
+
+	reordered := auction_usecase.AuctionImagesInputDTO{
+		SellerId: seller.Id,
+		Images: []auction_usecase.AuctionImageInputDTO{
+			{URL: "https://example.com/box.jpg"},
+			{URL: "https://example.com/front.jpg", AltText: "Front"},
+		},
+	}
+	if _, err := auctionUseCase.ReplaceAuctionImages(ctx, created.Id, auction_usecase.AuctionImagesInputDTO{
+		SellerId: uuid.New().String(), Images: reordered.Images,
+	}); err == nil || err.Err != internal_error.Forbidden {
+		t.Errorf("Expected a forbidden error replacing another seller's images, got %v", err)
+	}
+
+	updated, err := auctionUseCase.ReplaceAuctionImages(ctx, created.Id, reordered)
+	if err != nil {
+		t.Fatalf("Failed to replace images: %v", err.Error())
+	}
+	if len(updated.Images) != 2 || updated.Images[0].URL != "https://example.com/box.jpg" ||
+		updated.Images[0].Order != 1 || updated.Images[1].AltText != "Front" {
+		t.Errorf("Expected the images in the new order, got %+v", updated.Images)
+	}
+
+	updated, err = auctionUseCase.ReplaceAuctionImages(ctx, created.Id, auction_usecase.AuctionImagesInputDTO{
+		SellerId: seller.Id, Images: []auction_usecase.AuctionImageInputDTO{},
+	})
+	if err != nil || updated.Images == nil || len(updated.Images) != 0 {
+		t.Fatalf("Expected an empty set of images to be accepted, got %+v, %v", updated, err)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionUseCase.ReplaceAuctionImages(ctx, created.Id, reordered); err != internal_error.ErrAuctionNotActive {
+		t.Errorf("Expected ErrAuctionNotActive once the auction ended, got %v", err)
+	}
+}
+
+func TestFindAuctionsByProductNameQuery(t *testing.T) {
+	repo := memory.NewAuctionRepository(nil)
+
+	for _, productName := range []string{"iPhone 13", "C++ book", "50% off blender"} {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), productName, "Electronics", "Test auction description", auction_entity.New, 0,
+			auction_entity.DurationBounds{}, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if err := repo.CreateAuction(context.Background(), auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+	}
+
+	for query, expected := range map[string]int64{"iphone": 1, "c++ BOOK": 1, "50% off": 1, ".*": 0} {
+		_, total, err := repo.FindAuctions(
+			context.Background(), auction_entity.AuctionFilter{ProductName: query}, 1, 20)
+		if err != nil {
+			t.Fatalf("Failed to find auctions: %v", err.Error())
+		}
+		if total != expected {
+			t.Errorf("Expected %d auctions for %q, got %d", expected, query, total)
+		}
+	}
+}
+
+func TestCloseDecidesOutcomeFromReservePrice(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	ctx := context.Background()
+
+	newAuction := func(startingPrice, reservePrice int64) *auction_entity.Auction {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Minute,
+			auction_entity.DurationBounds{}, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if err := auctionEntity.SetPrices(startingPrice, reservePrice); err != nil {
+			t.Fatalf("Failed to set prices: %v", err.Error())
+		}
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+		return auctionEntity
+	}
+	placeBid := func(auctionId string, amount int64) {
+		bidEntity, _ := bid_entity.CreateBid(uuid.New().String(), auctionId, amount, money.DefaultCurrency, clk)
+		if err := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); err != nil {
+			t.Fatalf("Failed to place bid: %v", err.Error())
+		}
+	}
+
+	reserveMet := newAuction(1000, 10000)
+	placeBid(reserveMet.Id, 10000)
+	reserveNotMet := newAuction(1000, 10000)
+	placeBid(reserveNotMet.Id, 9999)
+	noReserve := newAuction(1000, 0)
+	placeBid(noReserve.Id, 1000)
+	noBids := newAuction(1000, 10000)
+
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	expected := map[string]auction_entity.AuctionOutcome{
+		reserveMet.Id:    auction_entity.Sold,
+		reserveNotMet.Id: auction_entity.ReserveNotMet,
+		noReserve.Id:     auction_entity.Sold,
+		noBids.Id:        auction_entity.NoBids,
+	}
+	for id, outcome := range expected {
+		found, err := auctionRepo.FindAuctionById(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to find auction: %v", err.Error())
+		}
+		if found.Status != auction_entity.Completed || found.Outcome != outcome {
+			t.Errorf("Auction %s: expected Completed with outcome %d, got %d with outcome %d",
+				id, outcome, found.Status, found.Outcome)
+		}
+		if found.ClosedAt == nil || (found.WinningBid == nil) != (outcome == auction_entity.NoBids) {
+			t.Errorf("Auction %s: unexpected winner snapshot %+v closed at %v", id, found.WinningBid, found.ClosedAt)
+		}
+	}
+}
+
+func TestUserStatsCountOnlySoldAuctionsAsWon(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	userRepo := memory.NewUserRepository()
+	userUseCase := user_usecase.NewUserUseCase(userRepo, bidRepo)
+	ctx := context.Background()
+
+	bidder, _ := userUseCase.CreateUser(
+		ctx, user_usecase.UserInputDTO{Name: "Bidder", Email: "bidder@example.com", Password: "secret-password"})
+	idle, _ := userUseCase.CreateUser(
+		ctx, user_usecase.UserInputDTO{Name: "Idle", Email: "idle@example.com", Password: "secret-password"})
+	rival := uuid.New().String()
+
+	newAuction := func(reservePrice int64) string {
+		auctionEntity, _ := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Minute,
+			auction_entity.DurationBounds{}, clk)
+		auctionEntity.SetPrices(1000, reservePrice)
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+		return auctionEntity.Id
+	}
+	placeBid := func(userId, auctionId string, amount int64) {
+		clk.Advance(time.Second)
+		bidEntity, _ := bid_entity.CreateBid(userId, auctionId, amount, money.DefaultCurrency, clk)
+		if err := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); err != nil {
+			t.Fatalf("Failed to place bid: %v", err.Error())
+		}
+	}
+
+	won, lost, reserveNotMet := newAuction(0), newAuction(0), newAuction(10000)
+	placeBid(bidder.Id, won, 1000)
+	placeBid(rival, won, 1500)
+	placeBid(bidder.Id, won, 2000)
+	placeBid(bidder.Id, lost, 1000)
+	placeBid(rival, lost, 3000)
+	placeBid(bidder.Id, reserveNotMet, 5000)
+
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	stats, err := userUseCase.GetUserStats(ctx, bidder.Id)
+	if err != nil {
+		t.Fatalf("Failed to get user stats: %v", err.Error())
+	}
+	expected := user_usecase.UserStatsOutputDTO{
+		UserId: bidder.Id, AuctionsBidOn: 3, BidCount: 4, AuctionsWon: 1, TotalSpent: 2000,
+	}
+	if *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *stats)
+	}
+
+	stats, err = userUseCase.GetUserStats(ctx, idle.Id)
+	if err != nil || *stats != (user_usecase.UserStatsOutputDTO{UserId: idle.Id}) {
+		t.Errorf("Expected zeroed stats for a user without bids, got %+v, %v", stats, err)
+	}
+
+	if _, err := userUseCase.GetUserStats(ctx, uuid.New().String()); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found for an unknown user, got %v", err)
+	}
+}
+
+func TestOnlyTheWinnerRatesTheSellerOnce(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	// The ratings are timestamped by the default clock, which must move with clk for the
+	// second one to be the most recent
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	userRepo := memory.NewUserRepository()
+	userUseCase := user_usecase.NewUserUseCase(userRepo, bidRepo)
+	ratingUseCase := rating_usecase.NewRatingUseCase(memory.NewRatingRepository(userRepo), auctionRepo, userRepo, clk)
+	ctx := context.Background()
+
+	seller, _ := userUseCase.CreateUser(
+		ctx, user_usecase.UserInputDTO{Name: "Seller", Email: "seller@example.com", Password: "secret-password"})
+	winner, rival := uuid.New().String(), uuid.New().String()
+
+	newAuction := func(reservePrice int64, bids ...string) string {
+		auctionEntity, _ := auction_entity.CreateAuction(
+			seller.Id, "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Minute,
+			auction_entity.DurationBounds{}, clk)
+		auctionEntity.SetPrices(1000, reservePrice)
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+		for i, userId := range bids {
+			clk.Advance(time.Second)
+			bidEntity, _ := bid_entity.CreateBid(
+				userId, auctionEntity.Id, int64(1000*(i+1)), money.DefaultCurrency, clk)
+			if err := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); err != nil {
+				t.Fatalf("Failed to place bid: %v", err.Error())
+			}
+		}
+		return auctionEntity.Id
+	}
+
+	sold := newAuction(0, rival, winner)
+	soldAgain := newAuction(0, winner)
+	reserveNotMet := newAuction(10000, winner)
+	active := newAuction(0, winner)
+
+	rate := func(raterId, auctionId string, score int) (*rating_usecase.RatingOutputDTO, *internal_error.InternalError) {
+		return ratingUseCase.CreateRating(ctx, raterId, auctionId, rating_usecase.RatingInputDTO{Score: score})
+	}
+
+	if _, err := rate(winner, active, 5); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected rating an active auction to conflict, got %v", err)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	if _, err := rate(rival, sold, 1); err == nil || err.Err != internal_error.Forbidden {
+		t.Errorf("Expected an outbid bidder to be forbidden from rating, got %v", err)
+	}
+	if _, err := rate(winner, reserveNotMet, 1); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected rating an auction under its reserve price to conflict, got %v", err)
+	}
+	if _, err := rate(winner, sold, 6); err == nil || err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a score over 5 to be rejected, got %v", err)
+	}
+
+	rating, err := rate(winner, sold, 5)
+	if err != nil {
+		t.Fatalf("Expected the winner to rate the seller, got %v", err.Error())
+	}
+	if rating.SellerId != seller.Id || rating.RaterId != winner || rating.Score != 5 {
+		t.Errorf("Expected a 5 from %s to %s, got %+v", winner, seller.Id, rating)
+	}
+	if _, err := rate(winner, sold, 1); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected a second rating of the auction to conflict, got %v", err)
+	}
+
+	clk.Advance(time.Second)
+	if _, err := rate(winner, soldAgain, 2); err != nil {
+		t.Fatalf("Expected the winner to rate the seller again for another auction, got %v", err.Error())
+	}
+
+	ratings, total, err := ratingUseCase.FindRatingsBySellerId(ctx, seller.Id, 1, 1)
+	if err != nil {
+		t.Fatalf("Failed to find ratings: %v", err.Error())
+	}
+	if total != 2 || len(ratings.Ratings) != 1 || ratings.Ratings[0].AuctionId != soldAgain {
+		t.Errorf("Expected the most recent of 2 ratings first, got %d, %+v", total, ratings.Ratings)
+	}
+	if ratings.RatingCount != 2 || ratings.AverageRating == nil || *ratings.AverageRating != 3.5 {
+		t.Errorf("Expected an average of 3.5 over 2 ratings, got %+v", ratings)
+	}
+
+	user, err := userUseCase.FindUserById(ctx, seller.Id)
+	if err != nil || user.RatingCount != 2 || user.AverageRating == nil || *user.AverageRating != 3.5 {
+		t.Errorf("Expected the seller to carry the average, got %+v, %v", user, err)
+	}
+}
+
+func TestAuctionCloserRecordsLastRun(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	repo := memory.NewAuctionRepository(clk)
+
+	closer := auction.NewAuctionCloser(repo, clk, 0, 0)
+	if !closer.LastRun().IsZero() {
+		t.Fatalf("Expected no last run before the first sweep, got %v", closer.LastRun())
+	}
+
+	closer.Start(context.Background())
+	t.Cleanup(closer.Stop)
+
+	// The first sweep runs right away
+	deadline := time.Now().Add(time.Second)
+	for closer.LastRun().IsZero() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if !closer.LastRun().Equal(clk.Now()) {
+		t.Fatalf("Expected the last run at %v, got %v", clk.Now(), closer.LastRun())
+	}
+}
+
+// closeRecorder records the auction_closed events the closer notifies.
+type closeRecorder struct {
+	mu     sync.Mutex
+	closed []events.AuctionClosedEvent
+}
+
+func (r *closeRecorder) AuctionsClosed(closed []events.AuctionClosedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = append(r.closed, closed...)
+}
+
+func (r *closeRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.closed)
+}
+
+func TestCloseAuctionNowMatchesTheAutomaticClose(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	ctx := context.Background()
+
+	recorder := &closeRecorder{}
+	closer := auction.NewAuctionCloser(auctionRepo, clk, time.Second, 0)
+	closer.AddListener(recorder)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, nil, nil, nil, nil, closer, nil, clk, auction_usecase.Config{})
+
+	auctionEntity := createAuction(t, auctionRepo, clk, time.Hour)
+	winningBid := bid_entity.Bid{
+		Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionEntity.Id,
+		Amount: 2500, Currency: money.DefaultCurrency, Timestamp: clk.Now(),
+	}
+	bidRepo.Insert(winningBid)
+
+	adminId := uuid.New().String()
+	if err := auctionUseCase.CloseAuctionNow(ctx, auctionEntity.Id, adminId); err != nil {
+		t.Fatalf("Failed to close auction: %v", err.Error())
+	}
+
+	found, err := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if found.Status != auction_entity.Completed || found.Outcome != auction_entity.Sold || found.ClosedAt == nil {
+		t.Errorf("Expected a Completed and Sold auction, got %+v", found)
+	}
+	if found.WinningBid == nil || found.WinningBid.BidId != winningBid.Id {
+		t.Errorf("Expected the winner snapshot of bid %s, got %+v", winningBid.Id, found.WinningBid)
+	}
+	if recorder.count() != 1 {
+		t.Fatalf("Expected the listeners notified once, got %d", recorder.count())
+	}
+	event := recorder.closed[0]
+	if event.AuctionId != auctionEntity.Id || event.Outcome != auction_entity.Sold ||
+		event.ClosedAt == nil || !event.ClosedAt.Equal(*found.ClosedAt) {
+		t.Errorf("Expected the event of the sold auction, got %+v", event)
+	}
+	if event.WinningBid == nil || event.WinningBid.BidId != winningBid.Id ||
+		event.Winner == nil || event.Winner.UserId != winningBid.UserId {
+		t.Errorf("Expected the event to carry the winner snapshot of bid %s, got %+v", winningBid.Id, event)
+	}
+
+	if err := auctionUseCase.CloseAuctionNow(ctx, auctionEntity.Id, adminId); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected closing a Completed auction to conflict, got %v", err)
+	}
+	if err := auctionUseCase.CloseAuctionNow(ctx, uuid.New().String(), adminId); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected closing an unknown auction to be not found, got %v", err)
+	}
+
+	// The original end time passing doesn't close it a second time
+	closer.Start(ctx)
+	t.Cleanup(closer.Stop)
+	clk.Advance(2 * time.Hour)
+	deadline := time.Now().Add(time.Second)
+	for !closer.LastRun().Equal(clk.Now()) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if recorder.count() != 1 {
+		t.Errorf("Expected the sweep to skip the closed auction, got %d notifications", recorder.count())
+	}
+}
+
+func TestVickreyAuctionSealsBidsAndSettlesAtTheRunnerUp(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	closer := auction.NewAuctionCloser(auctionRepo, clk, time.Second, 0)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, memory.NewUserRepository(), nil, nil, nil, closer, nil, clk, auction_usecase.Config{})
+	bidUseCase := bid_usecase.NewBidUseCase(
+		bidRepo, auctionRepo, nil, nil, nil, nil, clk, bid_usecase.DefaultConfig())
+	ctx := context.Background()
+
+	auctionEntity, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clk)
+	auctionEntity.SetPrices(1000, 0)
+	if err := auctionEntity.SetAuctionType("vickrey"); err != nil {
+		t.Fatalf("Failed to set the auction type: %v", err.Error())
+	}
+	if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	winner, runnerUp := uuid.New().String(), uuid.New().String()
+	placeBid := func(userId string, amount int64) *bid_usecase.CreateBidOutputDTO {
+		clk.Advance(time.Second)
+		output, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auctionEntity.Id, Amount: money.Amount(amount),
+		})
+		if err != nil {
+			t.Fatalf("Expected the sealed bid of %d to be accepted, got %v", amount, err.Error())
+		}
+		bidUseCase.Flush(ctx)
+		return output
+	}
+
+	placeBid(winner, 5000)
+	// A lower bid after a higher one is still accepted, and the bidder isn't told it trails
+	output := placeBid(runnerUp, 3000)
+	if output.Amount != 3000 || output.IsWinning != nil || output.CurrentHighestAmount != 0 {
+		t.Errorf("Expected the bid response to hide the other amounts, got %+v", output)
+	}
+	placeBid(runnerUp, 4000)
+	placeBid(uuid.New().String(), 1000)
+
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auctionEntity.Id, Amount: 999,
+	}); err == nil {
+		t.Error("Expected a bid under the starting price to be rejected")
+	}
+	if err := bidUseCase.CreateProxyBid(ctx, runnerUp, auctionEntity.Id, 10000); err != bid_usecase.ErrProxyBidOnVickrey {
+		t.Errorf("Expected ErrProxyBidOnVickrey placing a maximum bid, got %v", err)
+	}
+
+	list, err := bidUseCase.FindBidByAuctionId(ctx, auctionEntity.Id, bid_usecase.BidListInputDTO{})
+	if err != nil {
+		t.Fatalf("Failed to list bids: %v", err.Error())
+	}
+	if len(list.Bids) != 0 || !list.Sealed || list.BidCount == nil || *list.BidCount != 4 {
+		t.Errorf("Expected a sealed list counting 4 bids, got %+v", list)
+	}
+	winningInfo, err := auctionUseCase.FindWinningBidByAuctionId(ctx, auctionEntity.Id)
+	if err != nil || winningInfo.Bid != nil || winningInfo.Auction.CurrentHighestAmount != 0 {
+		t.Errorf("Expected the leading bid hidden before the close, got %+v, %v", winningInfo, err)
+	}
+
+	if err := auctionUseCase.CloseAuctionNow(ctx, auctionEntity.Id, uuid.New().String()); err != nil {
+		t.Fatalf("Failed to close auction: %v", err.Error())
+	}
+
+	found, err := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if found.WinningBid == nil || found.WinningBid.UserId != winner ||
+		found.WinningBid.Amount != 5000 || found.WinningBid.SettlementAmount != 4000 {
+		t.Errorf("Expected %s to win with 50.00 and pay 40.00, got %+v", winner, found.WinningBid)
+	}
+
+	winnerOutput, err := auctionUseCase.FindWinnerByAuctionId(ctx, auctionEntity.Id)
+	if err != nil || winnerOutput.Amount != 5000 || winnerOutput.SettlementAmount != 4000 {
+		t.Errorf("Expected the winner to pay the runner-up bid of 40.00, got %+v, %v", winnerOutput, err)
+	}
+	list, err = bidUseCase.FindBidByAuctionId(ctx, auctionEntity.Id, bid_usecase.BidListInputDTO{})
+	if err != nil || len(list.Bids) != 4 || list.Sealed {
+		t.Errorf("Expected the 4 bids listed once closed, got %+v, %v", list, err)
+	}
+}
+
+func TestBuyNowClosesTheAuctionAtItsPrice(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	ctx := context.Background()
+
+	recorder := &closeRecorder{}
+	closer := auction.NewAuctionCloser(auctionRepo, clk, time.Second, 0)
+	closer.AddListener(recorder)
+	config := bid_usecase.DefaultConfig()
+	config.SnipeWindow, config.SnipeExtension = time.Minute, time.Minute
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, closer, clk, config)
+	t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+
+	auctionEntity, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clk)
+	auctionEntity.SetPrices(1000, 0)
+	if err := auctionEntity.SetBuyNowPrice(5000); err != nil {
+		t.Fatalf("Failed to set the buy-now price: %v", err.Error())
+	}
+	if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+	endTime := auctionEntity.EndTime
+
+	outbid := uuid.New().String()
+	output, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: outbid, AuctionId: auctionEntity.Id, Amount: 2000,
+	})
+	if err != nil || output.BoughtNow {
+		t.Fatalf("Expected a regular bid under the buy-now price, got %+v, %v", output, err)
+	}
+
+	// Inside the snipe window, where a regular bid would extend the auction
+	clk.Advance(time.Hour - 30*time.Second)
+	buyer := uuid.New().String()
+	output, err = bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: buyer, AuctionId: auctionEntity.Id, Amount: 6000,
+	})
+	if err != nil {
+		t.Fatalf("Expected the bid over the buy-now price to buy the auction, got %v", err.Error())
+	}
+	if !output.BoughtNow || output.Amount != 5000 || output.IsWinning == nil || !*output.IsWinning {
+		t.Errorf("Expected a winning bid of 50.00 buying the auction, got %+v", output)
+	}
+
+	found, err := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to find auction: %v", err.Error())
+	}
+	if found.Status != auction_entity.Completed || found.Outcome != auction_entity.BoughtNow ||
+		found.BidCount != 2 || found.EndTime.After(endTime) {
+		t.Errorf("Expected a Completed auction bought now, not extended, got %+v", found)
+	}
+	if found.WinningBid == nil || found.WinningBid.UserId != buyer || found.WinningBid.SettlementAmount != 5000 {
+		t.Errorf("Expected %s to win paying 50.00, got %+v", buyer, found.WinningBid)
+	}
+
+	if recorder.count() != 1 {
+		t.Fatalf("Expected the listeners notified once, got %d", recorder.count())
+	}
+	if event := recorder.closed[0]; event.Outcome != auction_entity.BoughtNow ||
+		event.Winner == nil || event.Winner.UserId != buyer {
+		t.Errorf("Expected the event of the auction bought by %s, got %+v", buyer, event)
+	}
+
+	if _, err := bidUseCase.BuyNow(ctx, outbid, auctionEntity.Id); err == nil || err.Err != internal_error.Conflict {
+		t.Errorf("Expected buying a closed auction to conflict, got %v", err)
+	}
+}
+
+func TestConcurrentBuyNowSellsOnce(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity, _ := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clk)
+	auctionEntity.SetBuyNowPrice(5000)
+	if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	const buyers = 8
+	errs := make(chan *internal_error.InternalError, buyers)
+	var wg sync.WaitGroup
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			bidEntity, _ := bid_entity.CreateBid(
+				uuid.New().String(), auctionEntity.Id, 5000, money.DefaultCurrency, clk)
+			errs <- bidRepo.BuyNow(ctx, bidEntity)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	bought := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			bought++
+		case err.Err != internal_error.BuyNowUnavailable:
+			t.Errorf("Expected the other buyers to get buy_now_unavailable, got %v", err)
+		}
+	}
+	if bought != 1 {
+		t.Errorf("Expected exactly one buyer to get the auction, got %d", bought)
+	}
+}
+
+func TestAuditLogRecordsEveryStatusChange(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	closer := auction.NewAuctionCloser(auctionRepo, clk, 0, 0)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, nil, nil, nil, nil, closer, nil, clk, auction_usecase.Config{})
+	ctx := context.Background()
+
+	scheduledAuction, internalErr := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, 10*time.Minute,
+		auction_entity.DurationBounds{}, clk)
+	if internalErr != nil {
+		t.Fatalf("Failed to create auction entity: %v", internalErr.Error())
+	}
+	scheduledAuction.ScheduleStart(scheduledAuction.Timestamp.Add(time.Hour), auction_entity.DurationBounds{})
+	if err := auctionRepo.CreateAuction(ctx, scheduledAuction); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+	cancelledAuction := createAuction(t, auctionRepo, clk, time.Hour)
+	closedAuction := createAuction(t, auctionRepo, clk, time.Hour)
+
+	if err := auctionUseCase.CancelAuction(ctx, cancelledAuction.Id, cancelledAuction.SellerId); err != nil {
+		t.Fatalf("Failed to cancel auction: %v", err.Error())
+	}
+	adminId := uuid.New().String()
+	if err := auctionUseCase.CloseAuctionNow(ctx, closedAuction.Id, adminId); err != nil {
+		t.Fatalf("Failed to close auction: %v", err.Error())
+	}
+
+	// Shutdown runs a single sweep, which the test drives by hand
+	clk.Advance(time.Hour + time.Second)
+	closer.Shutdown(ctx)
+	clk.Advance(10 * time.Minute)
+	closer.Shutdown(ctx)
+
+	testCases := []struct {
+		name      string
+		auctionId string
+		want      []auction_usecase.AuctionAuditOutputDTO
+	}{
+		{
+			name:      "cancelled by its seller",
+			auctionId: cancelledAuction.Id,
+			want: []auction_usecase.AuctionAuditOutputDTO{{
+				FromStatus: auction_usecase.AuctionStatus(auction_entity.Active),
+				ToStatus:   auction_usecase.AuctionStatus(auction_entity.Cancelled),
+				Actor:      cancelledAuction.SellerId,
+				Reason:     auction_entity.AuditReasonCancelled,
+			}},
+		},
+		{
+			name:      "closed early by an admin",
+			auctionId: closedAuction.Id,
+			want: []auction_usecase.AuctionAuditOutputDTO{{
+				FromStatus: auction_usecase.AuctionStatus(auction_entity.Active),
+				ToStatus:   auction_usecase.AuctionStatus(auction_entity.Completed),
+				Actor:      adminId,
+				Reason:     auction_entity.AuditReasonClosedNow,
+			}},
+		},
+		{
+			name:      "started and closed by the sweeper",
+			auctionId: scheduledAuction.Id,
+			want: []auction_usecase.AuctionAuditOutputDTO{
+				{
+					FromStatus: auction_usecase.AuctionStatus(auction_entity.Scheduled),
+					ToStatus:   auction_usecase.AuctionStatus(auction_entity.Active),
+					Actor:      auction_entity.ActorSweeper,
+					Reason:     auction_entity.AuditReasonStarted,
+				},
+				{
+					FromStatus: auction_usecase.AuctionStatus(auction_entity.Active),
+					ToStatus:   auction_usecase.AuctionStatus(auction_entity.Completed),
+					Actor:      auction_entity.ActorSweeper,
+					Reason:     auction_entity.AuditReasonExpired,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := auctionUseCase.FindAuditByAuctionId(ctx, tc.auctionId)
+			if err != nil {
+				t.Fatalf("Failed to find the audit log: %v", err.Error())
+			}
+			if len(entries) != len(tc.want) {
+				t.Fatalf("Expected %d audit entries, got %+v", len(tc.want), entries)
+			}
+
+			for i, want := range tc.want {
+				got := entries[i]
+				if got.AuctionId != tc.auctionId || got.FromStatus != want.FromStatus || got.ToStatus != want.ToStatus ||
+					got.Actor != want.Actor || got.Reason != want.Reason || got.Timestamp.IsZero() {
+					t.Errorf("Expected audit entry %d to be %+v, got %+v", i, want, got)
+				}
+			}
+		})
+	}
+
+	if _, err := auctionUseCase.FindAuditByAuctionId(ctx, uuid.New().String()); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected the audit log of an unknown auction to be not found, got %v", err)
+	}
+}
+
+func TestReportsRankCapAndCache(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	reportUseCase := report_usecase.NewReportUseCase(auctionRepo, bidRepo, clk, time.Minute, nil)
+	ctx := context.Background()
+
+	auctionEntity := createAuction(t, auctionRepo, clk, time.Hour)
+	firstBidder, secondBidder, thirdBidder := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	placeBid := func(userId string, amount int64) {
+		bidRepo.Insert(bid_entity.Bid{
+			Id: uuid.New().String(), UserId: userId, AuctionId: auctionEntity.Id,
+			Amount: amount, Currency: money.DefaultCurrency, Timestamp: clk.Now(),
+		})
+	}
+	placeBid(firstBidder, 100)
+	placeBid(firstBidder, 200)
+	placeBid(secondBidder, 500)
+	placeBid(thirdBidder, 300)
+	placeBid(thirdBidder, 400)
+
+	period := report_usecase.ReportInputDTO{From: clk.Now().Add(-time.Hour), To: clk.Now().Add(time.Hour), Limit: 2}
+	topBidders, err := reportUseCase.TopBidders(ctx, period)
+	if err != nil {
+		t.Fatalf("Failed to rank the bidders: %v", err.Error())
+	}
+	if len(topBidders) != 2 || topBidders[0].UserId != thirdBidder || topBidders[1].UserId != firstBidder {
+		t.Fatalf("Expected the two bidders with 2 bids, the higher total first, got %+v", topBidders)
+	}
+	if topBidders[0].BidCount != 2 || topBidders[0].TotalAmount != 700 {
+		t.Errorf("Expected 2 bids totalling 700 cents, got %+v", topBidders[0])
+	}
+
+	// The same report is served from the cache until its TTL passes
+	placeBid(secondBidder, 600)
+	placeBid(secondBidder, 700)
+	if cached, _ := reportUseCase.TopBidders(ctx, period); cached[0].UserId != thirdBidder {
+		t.Errorf("Expected the cached ranking, got %+v", cached)
+	}
+	clk.Advance(2 * time.Minute)
+	if refreshed, _ := reportUseCase.TopBidders(ctx, period); refreshed[0].UserId != secondBidder {
+		t.Errorf("Expected the ranking refreshed after the TTL, got %+v", refreshed)
+	}
+
+	emptyPeriod := report_usecase.ReportInputDTO{From: clk.Now().Add(-48 * time.Hour), To: clk.Now().Add(-24 * time.Hour)}
+	empty, err := reportUseCase.TopBidders(ctx, emptyPeriod)
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("Expected an empty ranking for a period without bids, got %+v and %v", empty, err)
+	}
+
+	if _, err := reportUseCase.TopBidders(ctx, report_usecase.ReportInputDTO{
+		From: clk.Now(), To: clk.Now().Add(-time.Hour)}); err == nil || err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad request for a period ending before it starts, got %v", err)
+	}
+
+	// The auction closes sold to the highest bid, 700 cents, and counts for its seller
+	clk.Advance(time.Hour)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	topSellers, err := reportUseCase.TopSellers(ctx, report_usecase.ReportInputDTO{})
+	if err != nil {
+		t.Fatalf("Failed to rank the sellers: %v", err.Error())
+	}
+	if len(topSellers) != 1 || topSellers[0].SellerId != auctionEntity.SellerId ||
+		topSellers[0].AuctionsSold != 1 || topSellers[0].TotalSold != 700 {
+		t.Errorf("Expected the seller with one auction sold for 700 cents, got %+v", topSellers)
+	}
+}
+
+func TestReportsKeepCurrenciesApart(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	reportUseCase := report_usecase.NewReportUseCase(auctionRepo, bidRepo, clk, 0, nil)
+	ctx := context.Background()
+
+	sellerId, bidderId := uuid.New().String(), uuid.New().String()
+	for currency, amount := range map[string]int64{"BRL": 5000, "USD": 1000} {
+		auctionEntity, _ := auction_entity.CreateAuction(
+			sellerId, "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+			auction_entity.DurationBounds{}, clk)
+		if err := auctionEntity.SetCurrency(strings.ToLower(currency)); err != nil || auctionEntity.Currency != currency {
+			t.Fatalf("Expected the auction to be priced in %s, got %q and %v", currency, auctionEntity.Currency, err)
+		}
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+
+		bidRepo.Insert(bid_entity.Bid{
+			Id: uuid.New().String(), UserId: bidderId, AuctionId: auctionEntity.Id,
+			Amount: amount, Currency: currency, Timestamp: clk.Now(),
+		})
+	}
+
+	topBidders, err := reportUseCase.TopBidders(ctx, report_usecase.ReportInputDTO{})
+	if err != nil {
+		t.Fatalf("Failed to rank the bidders: %v", err.Error())
+	}
+	if len(topBidders) != 2 || topBidders[0].Currency != "BRL" || topBidders[0].TotalAmount != 5000 ||
+		topBidders[1].Currency != "USD" || topBidders[1].TotalAmount != 1000 {
+		t.Errorf("Expected the bidder ranked once per currency, got %+v", topBidders)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	topSellers, err := reportUseCase.TopSellers(ctx, report_usecase.ReportInputDTO{})
+	if err != nil {
+		t.Fatalf("Failed to rank the sellers: %v", err.Error())
+	}
+	if len(topSellers) != 2 || topSellers[0].Currency != "BRL" || topSellers[0].TotalSold != 5000 ||
+		topSellers[1].Currency != "USD" || topSellers[1].TotalSold != 1000 {
+		t.Errorf("Expected the seller ranked once per currency, got %+v", topSellers)
+	}
+
+	invalid, _ := auction_entity.CreateAuction(
+		sellerId, "Test Product", "Electronics", "Test auction description", auction_entity.New, 0,
+		auction_entity.DurationBounds{}, clk)
+	if err := invalid.SetCurrency("JPY"); err == nil || !err.HasCause("currency") {
+		t.Errorf("Expected a currency outside the allowlist to be rejected, got %v", err)
+	}
+}
+
+// unavailableAuctionRepository fails the dashboard count, as a timed out query would.
+type unavailableAuctionRepository struct {
+	*memory.AuctionRepository
+}
+
+func (r unavailableAuctionRepository) CountAuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	return nil, internal_error.NewInternalServerError("Error trying to count auctions by status").Wrap(context.DeadlineExceeded)
+}
+
+func TestDashboardStatsLeaveOutFailedQueries(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	closeLag := func(q float64) (float64, bool) { return 0.4, true }
+	ctx := context.Background()
+
+	active := createAuction(t, auctionRepo, clk, time.Hour)
+	cancelled := createAuction(t, auctionRepo, clk, time.Hour)
+	createAuction(t, auctionRepo, clk, time.Hour)
+	if err := auctionRepo.UpdateAuctionStatus(
+		ctx, cancelled.Id, cancelled.Version, auction_entity.Active, auction_entity.Cancelled); err != nil {
+		t.Fatalf("Failed to cancel the auction: %v", err.Error())
+	}
+
+	placeBid := func(auctionEntity *auction_entity.Auction, at time.Time) {
+		bidRepo.Insert(bid_entity.Bid{
+			Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionEntity.Id,
+			Amount: 100, Currency: money.DefaultCurrency, Timestamp: at,
+		})
+	}
+	// The first bids come 30s and 10s after the start, kept to the millisecond, the later
+	// one doesn't count
+	placeBid(active, active.StartTime.Truncate(time.Millisecond).Add(30*time.Second))
+	placeBid(cancelled, cancelled.StartTime.Truncate(time.Millisecond).Add(10*time.Second))
+	clk.Advance(3 * time.Hour)
+	placeBid(active, clk.Now())
+
+	stats := report_usecase.NewReportUseCase(auctionRepo, bidRepo, clk, 0, closeLag).DashboardStats(ctx)
+	if stats.Auctions == nil || *stats.Auctions != (report_usecase.AuctionCountsOutputDTO{Active: 2, Cancelled: 1}) {
+		t.Errorf("Expected 2 active and 1 cancelled auctions, got %+v", stats.Auctions)
+	}
+	if stats.Bids == nil || *stats.Bids != (report_usecase.BidActivityOutputDTO{LastHour: 1, LastDay: 3}) {
+		t.Errorf("Expected 1 bid in the last hour and 3 in the last day, got %+v", stats.Bids)
+	}
+	if stats.AvgTimeToFirstBidSeconds == nil || *stats.AvgTimeToFirstBidSeconds != 20 {
+		t.Errorf("Expected 20s to the first bid on average, got %v", *stats.AvgTimeToFirstBidSeconds)
+	}
+	if stats.CloseLagP95Seconds == nil || *stats.CloseLagP95Seconds != 0.4 {
+		t.Errorf("Expected the close lag p95 to be read from the histogram, got %v", stats.CloseLagP95Seconds)
+	}
+
+	// A query that fails leaves its figures null and the others untouched
+	degraded := report_usecase.NewReportUseCase(
+		unavailableAuctionRepository{auctionRepo}, bidRepo, clk, 0, nil).DashboardStats(ctx)
+	if degraded.Auctions != nil || degraded.Bids == nil || degraded.CloseLagP95Seconds != nil {
+		t.Errorf("Expected only the auction counts and the close lag to be null, got %+v", degraded)
+	}
+}
+
+func TestWatchlistIsIdempotentAndNewestFirst(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	watchlistRepo := memory.NewWatchlistRepository()
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, nil, nil, watchlistRepo, nil, nil, nil, nil, auction_usecase.Config{})
+	ctx := context.Background()
+
+	alice, bob := uuid.New().String(), uuid.New().String()
+	first := createAuction(t, auctionRepo, clock.New(), time.Hour)
+	second := createAuction(t, auctionRepo, clock.New(), time.Hour)
+
+	for _, watch := range []struct{ userId, auctionId string }{
+		{alice, first.Id}, {alice, second.Id}, {alice, first.Id}, {bob, first.Id},
+	} {
+		if err := auctionUseCase.WatchAuction(ctx, watch.userId, watch.auctionId); err != nil {
+			t.Fatalf("Failed to watch auction: %v", err.Error())
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if err := auctionUseCase.WatchAuction(ctx, alice, uuid.New().String()); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected watching an unknown auction to be not found, got %v", err)
+	}
+
+	watched, total, err := auctionUseCase.FindWatchlist(ctx, alice, 1, 20)
+	if err != nil {
+		t.Fatalf("Failed to find watchlist: %v", err.Error())
+	}
+	// Watching the first auction again kept it where it was
+	if total != 2 || len(watched) != 2 || watched[0].Id != second.Id || watched[1].Id != first.Id {
+		t.Fatalf("Expected the second then the first auction, got %d: %+v", total, watched)
+	}
+	if !watched[1].WatchedAt.Before(watched[0].WatchedAt) || watched[0].WatchedAt.Location() != time.UTC {
+		t.Errorf("Expected UTC watch times, the most recent first, got %v and %v",
+			watched[0].WatchedAt, watched[1].WatchedAt)
+	}
+
+	summary, err := auctionUseCase.GetAuctionSummary(ctx, first.Id)
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err.Error())
+	}
+	if summary.WatchersCount != 2 {
+		t.Errorf("Expected 2 watchers, got %d", summary.WatchersCount)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := auctionUseCase.UnwatchAuction(ctx, alice, first.Id); err != nil {
+			t.Fatalf("Failed to unwatch auction: %v", err.Error())
+		}
+	}
+	if count, _ := watchlistRepo.CountByAuctionId(ctx, first.Id); count != 1 {
+		t.Errorf("Expected 1 watcher left, got %d", count)
+	}
+
+	// Deleted auctions drop out of the page
+	if err := auctionRepo.SoftDeleteAuction(ctx, second.Id); err != nil {
+		t.Fatalf("Failed to delete auction: %v", err.Error())
+	}
+	if watched, _, _ := auctionUseCase.FindWatchlist(ctx, alice, 1, 20); len(watched) != 0 {
+		t.Errorf("Expected an empty watchlist, got %+v", watched)
+	}
+}
+
+func TestWatchersAreNotifiedWhenTheAuctionCloses(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	watchlistRepo := memory.NewWatchlistRepository()
+	ctx := context.Background()
+
+	notifier := bid_usecase.NewChannelNotifier(10)
+	closer := auction.NewAuctionCloser(auctionRepo, clk, time.Second, 0)
+	closer.AddListener(auction_usecase.NewWatchersNotifier(watchlistRepo, notifier))
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, nil, nil, watchlistRepo, nil, closer, nil, clk, auction_usecase.Config{})
+
+	watchedAuction := createAuction(t, auctionRepo, clk, time.Hour)
+	otherAuction := createAuction(t, auctionRepo, clk, time.Hour)
+
+	watchers := map[string]bool{uuid.New().String(): true, uuid.New().String(): true}
+	for userId := range watchers {
+		if err := auctionUseCase.WatchAuction(ctx, userId, watchedAuction.Id); err != nil {
+			t.Fatalf("Failed to watch auction: %v", err.Error())
+		}
+	}
+	if err := auctionUseCase.WatchAuction(ctx, uuid.New().String(), otherAuction.Id); err != nil {
+		t.Fatalf("Failed to watch auction: %v", err.Error())
+	}
+
+	if err := auctionUseCase.CloseAuctionNow(ctx, watchedAuction.Id, uuid.New().String()); err != nil {
+		t.Fatalf("Failed to close auction: %v", err.Error())
+	}
+
+	for len(watchers) > 0 {
+		select {
+		case event := <-notifier.WatchedAuctionClosedEvents():
+			if !watchers[event.UserId] || event.Auction.AuctionId != watchedAuction.Id {
+				t.Fatalf("Unexpected notification: %+v", event)
+			}
+			delete(watchers, event.UserId)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a notification for each watcher, %d left", len(watchers))
+		}
+	}
+
+	select {
+	case event := <-notifier.WatchedAuctionClosedEvents():
+		t.Errorf("Expected no other notification, got %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
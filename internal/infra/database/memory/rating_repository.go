@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/rating_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+var _ rating_entity.RatingRepositoryInterface = (*RatingRepository)(nil)
+
+// RatingRepository is a map backed rating_entity.RatingRepositoryInterface keyed by
+// auction, like the unique MongoDB index. It adds each rating to the counters of the
+// seller in users.
+type RatingRepository struct {
+	users *UserRepository
+
+	mu      sync.RWMutex
+	ratings map[string]rating_entity.Rating
+}
+
+func NewRatingRepository(users *UserRepository) *RatingRepository {
+	return &RatingRepository{
+		users:   users,
+		ratings: make(map[string]rating_entity.Rating),
+	}
+}
+
+func (rr *RatingRepository) CreateRating(
+	ctx context.Context, rating *rating_entity.Rating) *internal_error.InternalError {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if _, ok := rr.ratings[rating.AuctionId]; ok {
+		return internal_error.ErrAuctionAlreadyRated
+	}
+
+	stored := *rating
+	stored.Timestamp = rating.Timestamp.Truncate(time.Millisecond).UTC()
+	rr.ratings[rating.AuctionId] = stored
+
+	rr.users.addRating(rating.SellerId, rating.Score)
+	return nil
+}
+
+func (rr *RatingRepository) FindRatingsBySellerId(
+	ctx context.Context,
+	sellerId string,
+	page, pageSize int) ([]rating_entity.Rating, int64, *internal_error.InternalError) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	rr.mu.RLock()
+	var matches []rating_entity.Rating
+	for _, rating := range rr.ratings {
+		if rating.SellerId == sellerId {
+			matches = append(matches, rating)
+		}
+	}
+	rr.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].Timestamp.Equal(matches[j].Timestamp) {
+			return matches[i].Timestamp.After(matches[j].Timestamp)
+		}
+		return matches[i].Id < matches[j].Id
+	})
+
+	total := int64(len(matches))
+	start := (page - 1) * pageSize
+	if start >= len(matches) {
+		return []rating_entity.Rating{}, total, nil
+	}
+
+	end := start + pageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	return matches[start:end], total, nil
+}
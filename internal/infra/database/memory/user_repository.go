@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+var _ user_entity.UserRepositoryInterface = (*UserRepository)(nil)
+
+// UserRepository is a map backed user_entity.UserRepositoryInterface that enforces the
+// same unique email rule as the MongoDB index.
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[string]user_entity.User
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users: make(map[string]user_entity.User),
+	}
+}
+
+func (ur *UserRepository) CreateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	for _, existing := range ur.users {
+		if userEntity.Email != "" && existing.Email == userEntity.Email {
+			return internal_error.NewConflictError("A user with this email already exists")
+		}
+	}
+
+	ur.users[userEntity.Id] = *userEntity
+	return nil
+}
+
+func (ur *UserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	userEntity, ok := ur.users[userId]
+	if !ok {
+		return nil, internal_error.NewNotFoundError(fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return &userEntity, nil
+}
+
+func (ur *UserRepository) UpdateUser(
+	ctx context.Context,
+	userId string,
+	update user_entity.UserUpdate) (*user_entity.User, *internal_error.InternalError) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	userEntity, ok := ur.users[userId]
+	if !ok || userEntity.IsDeleted() {
+		return nil, internal_error.NewNotFoundError(fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	if update.Email != nil {
+		for id, existing := range ur.users {
+			if id != userId && existing.Email == *update.Email {
+				return nil, internal_error.NewConflictError("A user with this email already exists")
+			}
+		}
+		userEntity.Email = *update.Email
+	}
+	if update.Name != nil {
+		userEntity.Name = *update.Name
+	}
+
+	ur.users[userId] = userEntity
+	return &userEntity, nil
+}
+
+func (ur *UserRepository) SoftDeleteUser(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	userEntity, ok := ur.users[userId]
+	if !ok || userEntity.IsDeleted() {
+		return internal_error.NewNotFoundError(fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	deletedAt := time.Now().UTC()
+	userEntity.DeletedAt = &deletedAt
+	ur.users[userId] = userEntity
+	return nil
+}
+
+func (ur *UserRepository) DebitBalance(
+	ctx context.Context, userId string, amount int64) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	return ur.debit(userId, amount)
+}
+
+func (ur *UserRepository) CreditBalance(
+	ctx context.Context, userId string, amount int64) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	return ur.credit(userId, amount)
+}
+
+// debit and credit change the balance of the user. The caller must hold the lock.
+func (ur *UserRepository) debit(userId string, amount int64) *internal_error.InternalError {
+	userEntity, ok := ur.users[userId]
+	if !ok {
+		return internal_error.NewNotFoundError(fmt.Sprintf("User not found with this id = %s", userId))
+	}
+	if userEntity.Balance < amount {
+		return internal_error.ErrInsufficientBalance
+	}
+
+	userEntity.Balance -= amount
+	ur.users[userId] = userEntity
+	return nil
+}
+
+func (ur *UserRepository) credit(userId string, amount int64) *internal_error.InternalError {
+	userEntity, ok := ur.users[userId]
+	if !ok {
+		return internal_error.NewNotFoundError(fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	userEntity.Balance += amount
+	ur.users[userId] = userEntity
+	return nil
+}
+
+// transferHold releases the amount held by the outbid bid, if it holds any, and holds
+// the amount of the new leading bid instead, all or nothing.
+func (ur *UserRepository) transferHold(outbid *bid_entity.Bid, leader bid_entity.Bid) *internal_error.InternalError {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	if outbid != nil && outbid.Reserved {
+		if err := ur.credit(outbid.UserId, outbid.Amount); err != nil {
+			return err
+		}
+	}
+
+	if err := ur.debit(leader.UserId, leader.Amount); err != nil {
+		if outbid != nil && outbid.Reserved {
+			ur.debit(outbid.UserId, outbid.Amount)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// addRating adds the score to the rating counters of the seller. Like the MongoDB $inc
+// on a user that doesn't exist, it does nothing then.
+func (ur *UserRepository) addRating(sellerId string, score int) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	userEntity, ok := ur.users[sellerId]
+	if !ok {
+		return
+	}
+
+	userEntity.RatingCount++
+	userEntity.RatingSum += int64(score)
+	ur.users[sellerId] = userEntity
+}
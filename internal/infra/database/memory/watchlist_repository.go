@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/watchlist_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+var _ watchlist_entity.WatchlistRepositoryInterface = (*WatchlistRepository)(nil)
+
+type watchKey struct {
+	userId    string
+	auctionId string
+}
+
+// WatchlistRepository is a map backed watchlist_entity.WatchlistRepositoryInterface
+// keyed by user and auction, like the unique MongoDB index.
+type WatchlistRepository struct {
+	mu      sync.RWMutex
+	watches map[watchKey]watchlist_entity.Watch
+}
+
+func NewWatchlistRepository() *WatchlistRepository {
+	return &WatchlistRepository{
+		watches: make(map[watchKey]watchlist_entity.Watch),
+	}
+}
+
+func (wr *WatchlistRepository) Add(
+	ctx context.Context, watch *watchlist_entity.Watch) *internal_error.InternalError {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	key := watchKey{userId: watch.UserId, auctionId: watch.AuctionId}
+	if _, ok := wr.watches[key]; ok {
+		return nil
+	}
+
+	stored := *watch
+	stored.Timestamp = watch.Timestamp.Truncate(time.Millisecond).UTC()
+	wr.watches[key] = stored
+
+	return nil
+}
+
+func (wr *WatchlistRepository) Remove(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	delete(wr.watches, watchKey{userId: userId, auctionId: auctionId})
+	return nil
+}
+
+func (wr *WatchlistRepository) FindByUserId(
+	ctx context.Context,
+	userId string,
+	page, pageSize int) ([]watchlist_entity.Watch, int64, *internal_error.InternalError) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	wr.mu.RLock()
+	var matches []watchlist_entity.Watch
+	for _, watch := range wr.watches {
+		if watch.UserId == userId {
+			matches = append(matches, watch)
+		}
+	}
+	wr.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].Timestamp.Equal(matches[j].Timestamp) {
+			return matches[i].Timestamp.After(matches[j].Timestamp)
+		}
+		return matches[i].AuctionId < matches[j].AuctionId
+	})
+
+	total := int64(len(matches))
+	start := (page - 1) * pageSize
+	if start >= len(matches) {
+		return []watchlist_entity.Watch{}, total, nil
+	}
+
+	end := start + pageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	return matches[start:end], total, nil
+}
+
+func (wr *WatchlistRepository) CountByAuctionId(
+	ctx context.Context, auctionId string) (int64, *internal_error.InternalError) {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+
+	var count int64
+	for key := range wr.watches {
+		if key.auctionId == auctionId {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (wr *WatchlistRepository) FindUserIdsByAuctionId(
+	ctx context.Context, auctionId string) ([]string, *internal_error.InternalError) {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+
+	userIds := []string{}
+	for key := range wr.watches {
+		if key.auctionId == auctionId {
+			userIds = append(userIds, key.userId)
+		}
+	}
+	sort.Strings(userIds)
+
+	return userIds, nil
+}
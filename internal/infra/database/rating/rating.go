@@ -0,0 +1,176 @@
+package rating
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/rating_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type RatingEntityMongo struct {
+	Id        string      `bson:"_id"`
+	AuctionId string      `bson:"auction_id"`
+	SellerId  string      `bson:"seller_id"`
+	RaterId   string      `bson:"rater_id"`
+	Score     int         `bson:"score"`
+	Comment   string      `bson:"comment,omitempty"`
+	Timestamp dbtime.Time `bson:"timestamp"`
+}
+
+type RatingRepository struct {
+	Collection     *mongo.Collection
+	UserCollection *mongo.Collection
+
+	// TxRunner runs the rating insert along with the rating counters of the seller
+	TxRunner mongodb.TxRunner
+
+	// operationTimeout bounds each operation, see dbtimeout.WithTimeout
+	operationTimeout time.Duration
+}
+
+func NewRatingRepository(
+	database *mongo.Database, txRunner mongodb.TxRunner, operationTimeout time.Duration) *RatingRepository {
+	return &RatingRepository{
+		Collection:       database.Collection("ratings"),
+		UserCollection:   database.Collection("users"),
+		TxRunner:         txRunner,
+		operationTimeout: operationTimeout,
+	}
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// EnsureIndexes creates the unique auction_id index CreateRating relies on to keep a
+// single rating per auction, and the one FindRatingsBySellerId pages through.
+func (rr *RatingRepository) EnsureIndexes(ctx context.Context) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, rr.operationTimeout)
+	defer cancel()
+
+	_, err := rr.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "auction_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "seller_id", Value: 1}, {Key: "timestamp", Value: -1}},
+		},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create rating indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create rating indexes").Wrap(err)
+	}
+
+	return nil
+}
+
+// CreateRating inserts the rating and increments the rating_count and rating_sum of the
+// seller in a single transaction, so the average read from the user always matches the
+// ratings. A second rating of the auction is rejected by the unique index, and the
+// counters are left as they were.
+func (rr *RatingRepository) CreateRating(
+	ctx context.Context, rating *rating_entity.Rating) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, rr.operationTimeout)
+	defer cancel()
+
+	document := RatingEntityMongo{
+		Id:        rating.Id,
+		AuctionId: rating.AuctionId,
+		SellerId:  rating.SellerId,
+		RaterId:   rating.RaterId,
+		Score:     rating.Score,
+		Comment:   rating.Comment,
+		Timestamp: dbtime.From(rating.Timestamp),
+	}
+
+	err := rr.TxRunner.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if _, err := rr.Collection.InsertOne(txCtx, document); err != nil {
+			return err
+		}
+
+		_, err := rr.UserCollection.UpdateOne(txCtx, bson.M{"_id": rating.SellerId},
+			bson.M{"$inc": bson.M{"rating_count": 1, "rating_sum": rating.Score}})
+		return err
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return internal_error.ErrAuctionAlreadyRated.Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to create rating", err,
+			zap.String("auction_id", rating.AuctionId), zap.String("seller_id", rating.SellerId))
+		return internal_error.NewInternalServerError("Error trying to create rating").Wrap(err)
+	}
+
+	return nil
+}
+
+func (rr *RatingRepository) FindRatingsBySellerId(
+	ctx context.Context,
+	sellerId string,
+	page, pageSize int) ([]rating_entity.Rating, int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, rr.operationTimeout)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	filter := bson.M{"seller_id": sellerId}
+
+	total, err := rr.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error counting ratings", err, zap.String("seller_id", sellerId))
+		return nil, 0, internal_error.NewInternalServerError("Error counting ratings").Wrap(err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := rr.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error finding ratings", err, zap.String("seller_id", sellerId))
+		return nil, 0, internal_error.NewInternalServerError("Error finding ratings").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var ratingsMongo []RatingEntityMongo
+	if err := cursor.All(ctx, &ratingsMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding ratings", err, zap.String("seller_id", sellerId))
+		return nil, 0, internal_error.NewInternalServerError("Error decoding ratings").Wrap(err)
+	}
+
+	ratings := make([]rating_entity.Rating, 0, len(ratingsMongo))
+	for _, rating := range ratingsMongo {
+		ratings = append(ratings, rating_entity.Rating{
+			Id:        rating.Id,
+			AuctionId: rating.AuctionId,
+			SellerId:  rating.SellerId,
+			RaterId:   rating.RaterId,
+			Score:     rating.Score,
+			Comment:   rating.Comment,
+			Timestamp: rating.Timestamp.UTC(),
+		})
+	}
+
+	return ratings, total, nil
+}
@@ -0,0 +1,137 @@
+package rating_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/database/mongodb"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/rating_entity"
+	"fullcycle-auction_go/internal/infra/database/rating"
+	"fullcycle-auction_go/internal/infra/database/user"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/google/uuid"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testDBName = "rating_test_db"
+
+// mongoSkipReason caches the first connection failure so the remaining tests
+// skip right away instead of waiting for the server selection timeout again.
+var mongoSkipReason string
+
+func setupTestDB(t *testing.T) (*mongo.Database, func()) {
+	if mongoSkipReason != "" {
+		t.Skip(mongoSkipReason)
+	}
+
+	mongoURL := os.Getenv("MONGODB_URL")
+	if mongoURL == "" {
+		mongoURL = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		t.Skip(mongoSkipReason)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB ping failed: %v", err)
+		t.Skip(mongoSkipReason)
+	}
+
+	database := client.Database(testDBName)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	}
+
+	return database, cleanup
+}
+
+func TestCreateRatingKeepsOneRatingPerAuction(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo := rating.NewRatingRepository(database, mongodb.NewTxRunner(ctx, database.Client()), 0)
+	userRepo := user.NewUserRepository(database, 0)
+
+	if internalErr := repo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create rating indexes: %v", internalErr.Error())
+	}
+
+	sellerId := uuid.New().String()
+	if _, err := userRepo.Collection.InsertOne(ctx, user.UserEntityMongo{Id: sellerId, Name: "Seller"}); err != nil {
+		t.Fatalf("Failed to insert seller: %v", err)
+	}
+
+	// Concurrent ratings of the same auction, only one of them kept
+	auctionId := uuid.New().String()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var created, conflicts int
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ratingEntity, _ := rating_entity.CreateRating(
+				auctionId, sellerId, uuid.New().String(), 4, "", clock.New())
+			internalErr := repo.CreateRating(ctx, ratingEntity)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case internalErr == nil:
+				created++
+			case internalErr.Err == internal_error.ErrAuctionAlreadyRated.Err:
+				conflicts++
+			default:
+				t.Errorf("Expected the rating to be created or refused, got %v", internalErr.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 || conflicts != 4 {
+		t.Fatalf("Expected 1 rating and 4 conflicts, got %d and %d", created, conflicts)
+	}
+
+	other, _ := rating_entity.CreateRating(
+		uuid.New().String(), sellerId, uuid.New().String(), 1, "Never shipped", clock.New())
+	other.Timestamp = time.Now().Add(time.Minute)
+	if internalErr := repo.CreateRating(ctx, other); internalErr != nil {
+		t.Fatalf("Failed to create rating: %v", internalErr.Error())
+	}
+
+	seller, internalErr := userRepo.FindUserById(ctx, sellerId)
+	if internalErr != nil {
+		t.Fatalf("Failed to find seller: %v", internalErr.Error())
+	}
+	if average, ok := seller.AverageRating(); !ok || seller.RatingCount != 2 || average != 2.5 {
+		t.Errorf("Expected an average of 2.5 over 2 ratings, got %v over %d", average, seller.RatingCount)
+	}
+
+	ratings, total, internalErr := repo.FindRatingsBySellerId(ctx, sellerId, 1, 1)
+	if internalErr != nil {
+		t.Fatalf("Failed to find ratings: %v", internalErr.Error())
+	}
+	if total != 2 || len(ratings) != 1 || ratings[0].Id != other.Id {
+		t.Errorf("Expected the most recent of 2 ratings first, got %d: %+v", total, ratings)
+	}
+}
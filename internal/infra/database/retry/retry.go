@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Policy bounds how an operation is retried: at most Attempts tries, waiting an
+// exponentially growing, jittered backoff between them, and never starting a new
+// attempt once MaxElapsed has passed since the first one.
+type Policy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxElapsed     time.Duration
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Attempts:       5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		MaxElapsed:     5 * time.Second,
+	}
+}
+
+// Do runs op until it succeeds, returns an error that is not transient, or the policy
+// or ctx runs out. The last error from op is returned.
+func Do(ctx context.Context, policy Policy, name string, op func(ctx context.Context) error) error {
+	start := time.Now()
+	backoff := policy.InitialBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(ctx); err == nil || !IsTransient(err) {
+			return err
+		}
+
+		if attempt >= policy.Attempts {
+			return err
+		}
+
+		wait := jitter(backoff)
+		if policy.MaxElapsed > 0 && time.Since(start)+wait > policy.MaxElapsed {
+			return err
+		}
+
+		logger.InfoContext(ctx, "Retrying database operation",
+			zap.String("operation", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", wait),
+			zap.NamedError("error", err))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// IsTransient reports whether err is worth retrying: network errors, timeouts and the
+// errors the server labels as retryable. A missing document or a cancelled context is
+// never transient.
+func IsTransient(err error) bool {
+	if err == nil ||
+		errors.Is(err, mongo.ErrNoDocuments) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var serverError mongo.ServerError
+	if errors.As(err, &serverError) {
+		return serverError.HasErrorLabel("RetryableWriteError") ||
+			serverError.HasErrorLabel("TransientTransactionError")
+	}
+
+	return false
+}
+
+// jitter picks a random wait in [backoff/2, backoff) so concurrent retries spread out.
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 1 {
+		return backoff
+	}
+
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)))
+}
@@ -0,0 +1,132 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/infra/database/retry"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// updateManyCollection is the slice of *mongo.Collection the close update relies on.
+type updateManyCollection interface {
+	UpdateMany(ctx context.Context, filter interface{}, update interface{}) (*mongo.UpdateResult, error)
+}
+
+// failingCollection fails the first failures calls with err and succeeds afterwards.
+type failingCollection struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (c *failingCollection) UpdateMany(
+	ctx context.Context, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	c.calls++
+	if c.calls <= c.failures {
+		return nil, c.err
+	}
+
+	return &mongo.UpdateResult{ModifiedCount: 1}, nil
+}
+
+var networkError = mongo.CommandError{Message: "connection reset", Labels: []string{"NetworkError"}}
+
+func testPolicy() retry.Policy {
+	return retry.Policy{
+		Attempts:       5,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		MaxElapsed:     time.Second,
+	}
+}
+
+func closeWith(ctx context.Context, policy retry.Policy, collection updateManyCollection) error {
+	return retry.Do(ctx, policy, "close_expired_auctions", func(ctx context.Context) error {
+		_, err := collection.UpdateMany(ctx, nil, nil)
+		return err
+	})
+}
+
+func TestDoRetriesTransientErrors(t *testing.T) {
+	collection := &failingCollection{failures: 2, err: networkError}
+
+	if err := closeWith(context.Background(), testPolicy(), collection); err != nil {
+		t.Fatalf("Expected the update to succeed after retrying, got %v", err)
+	}
+
+	if collection.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", collection.calls)
+	}
+}
+
+func TestDoGivesUpAfterAttempts(t *testing.T) {
+	collection := &failingCollection{failures: 100, err: networkError}
+
+	err := closeWith(context.Background(), testPolicy(), collection)
+	if !mongo.IsNetworkError(err) {
+		t.Fatalf("Expected the last network error, got %v", err)
+	}
+
+	if collection.calls != 5 {
+		t.Errorf("Expected 5 calls, got %d", collection.calls)
+	}
+}
+
+func TestDoDoesNotRetryPermanentErrors(t *testing.T) {
+	for _, permanentErr := range []error{mongo.ErrNoDocuments, errors.New("invalid filter"), context.Canceled} {
+		collection := &failingCollection{failures: 100, err: permanentErr}
+
+		err := closeWith(context.Background(), testPolicy(), collection)
+		if !errors.Is(err, permanentErr) {
+			t.Errorf("Expected %v to be returned as is, got %v", permanentErr, err)
+		}
+
+		if collection.calls != 1 {
+			t.Errorf("Expected %v not to be retried, got %d calls", permanentErr, collection.calls)
+		}
+	}
+}
+
+func TestDoRespectsMaxElapsed(t *testing.T) {
+	collection := &failingCollection{failures: 100, err: networkError}
+	policy := retry.Policy{
+		Attempts:       100,
+		InitialBackoff: 20 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		MaxElapsed:     100 * time.Millisecond,
+	}
+
+	start := time.Now()
+	if err := closeWith(context.Background(), policy, collection); err == nil {
+		t.Fatal("Expected the update to give up")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected to give up within the elapsed cap, took %v", elapsed)
+	}
+
+	if collection.calls >= 100 {
+		t.Errorf("Expected the elapsed cap to stop retries early, got %d calls", collection.calls)
+	}
+}
+
+func TestDoStopsWhenContextIsDone(t *testing.T) {
+	collection := &failingCollection{failures: 100, err: networkError}
+	policy := retry.Policy{Attempts: 100, InitialBackoff: time.Second, MaxBackoff: time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := closeWith(ctx, policy, collection); err == nil {
+		t.Fatal("Expected the update to give up")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected to stop with the context, took %v", elapsed)
+	}
+}
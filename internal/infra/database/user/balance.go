@@ -0,0 +1,56 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// DebitBalance takes amount cents from the user's balance with a single $inc guarded by
+// the balance itself, so concurrent debits can never take it below zero.
+func (ur *UserRepository) DebitBalance(
+	ctx context.Context, userId string, amount int64) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ur.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": userId, "balance": bson.M{"$gte": amount}}
+	result, err := ur.Collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"balance": -amount}})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to debit user balance", err, zap.String("user_id", userId))
+		return internal_error.NewInternalServerError("Error trying to debit user balance").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		// Either the user doesn't exist or the balance doesn't cover the amount
+		if _, err := ur.FindUserById(ctx, userId); err != nil {
+			return err
+		}
+		return internal_error.ErrInsufficientBalance
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) CreditBalance(
+	ctx context.Context, userId string, amount int64) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ur.operationTimeout)
+	defer cancel()
+
+	result, err := ur.Collection.UpdateOne(ctx, bson.M{"_id": userId}, bson.M{"$inc": bson.M{"balance": amount}})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to credit user balance", err, zap.String("user_id", userId))
+		return internal_error.NewInternalServerError("Error trying to credit user balance").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package user
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// EnsureIndexes creates the unique email index CreateUser relies on to reject duplicate
+// registrations. Users seeded before registration existed have no email, so the index
+// only covers documents that have one.
+func (ur *UserRepository) EnsureIndexes(ctx context.Context) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ur.operationTimeout)
+	defer cancel()
+
+	_, err := ur.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create user indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create user indexes").Wrap(err)
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) CreateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ur.operationTimeout)
+	defer cancel()
+
+	userEntityMongo := &UserEntityMongo{
+		Id:           userEntity.Id,
+		Name:         userEntity.Name,
+		Email:        userEntity.Email,
+		PasswordHash: userEntity.PasswordHash,
+	}
+
+	if _, err := ur.Collection.InsertOne(ctx, userEntityMongo); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return internal_error.NewConflictError("A user with this email already exists").Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to insert user", err, zap.String("user_id", userEntity.Id))
+		return internal_error.NewInternalServerError("Error trying to insert user").Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package user_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/user"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testDBName = "user_test_db"
+
+// mongoSkipReason caches the first connection failure so the remaining tests
+// skip right away instead of waiting for the server selection timeout again.
+var mongoSkipReason string
+
+func setupTestDB(t *testing.T) (*mongo.Database, func()) {
+	if mongoSkipReason != "" {
+		t.Skip(mongoSkipReason)
+	}
+
+	mongoURL := os.Getenv("MONGODB_URL")
+	if mongoURL == "" {
+		mongoURL = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		t.Skip(mongoSkipReason)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB ping failed: %v", err)
+		t.Skip(mongoSkipReason)
+	}
+
+	database := client.Database(testDBName)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	}
+
+	return database, cleanup
+}
+
+func TestCreateUserRejectsConcurrentDuplicateEmail(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := user.NewUserRepository(database, 0)
+	ctx := context.Background()
+
+	if internalErr := repo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create user indexes: %v", internalErr.Error())
+	}
+
+	const attempts = 10
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		created   int
+		conflicts int
+	)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Differently cased addresses are the same user
+			email := "racer@example.com"
+			if i%2 == 1 {
+				email = strings.ToUpper(email)
+			}
+
+			userEntity, err := user_entity.CreateUser(fmt.Sprintf("Racer %d", i), email, "secret-password")
+			if err != nil {
+				t.Errorf("Failed to create user entity: %v", err.Error())
+				return
+			}
+
+			internalErr := repo.CreateUser(ctx, userEntity)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case internalErr == nil:
+				created++
+			case internalErr.Err == "conflict":
+				conflicts++
+			default:
+				t.Errorf("Unexpected error: %v", internalErr.Error())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if created != 1 || conflicts != attempts-1 {
+		t.Errorf("Expected 1 user created and %d conflicts, got %d and %d", attempts-1, created, conflicts)
+	}
+
+	count, err := repo.Collection.CountDocuments(ctx, bson.M{"email": "racer@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to count users: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected exactly 1 stored user, got %d", count)
+	}
+}
+
+func TestCreateUserAllowsUsersWithoutEmail(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := user.NewUserRepository(database, 0)
+	ctx := context.Background()
+
+	if internalErr := repo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create user indexes: %v", internalErr.Error())
+	}
+
+	// Users seeded before registration existed have no email and must not collide
+	for _, id := range []string{"seeded-1", "seeded-2"} {
+		if _, err := repo.Collection.InsertOne(ctx, user.UserEntityMongo{Id: id, Name: id}); err != nil {
+			t.Fatalf("Failed to seed user without email: %v", err)
+		}
+	}
+}
+
+func TestDebitBalanceNeverGoesNegative(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := user.NewUserRepository(database, 0)
+	ctx := context.Background()
+
+	userEntity, _ := user_entity.CreateUser("Bidder", "bidder@example.com", "secret-password")
+	if internalErr := repo.CreateUser(ctx, userEntity); internalErr != nil {
+		t.Fatalf("Failed to create user: %v", internalErr.Error())
+	}
+	if internalErr := repo.CreditBalance(ctx, userEntity.Id, 1000); internalErr != nil {
+		t.Fatalf("Failed to credit balance: %v", internalErr.Error())
+	}
+
+	// 20 debits of 1.00 race for a balance of 10.00
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		debited  int
+		rejected int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			internalErr := repo.DebitBalance(ctx, userEntity.Id, 100)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case internalErr == nil:
+				debited++
+			case internalErr.Err == "bad_request":
+				rejected++
+			default:
+				t.Errorf("Unexpected error: %v", internalErr.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if debited != 10 || rejected != 10 {
+		t.Errorf("Expected 10 debits and 10 rejections, got %d and %d", debited, rejected)
+	}
+
+	found, internalErr := repo.FindUserById(ctx, userEntity.Id)
+	if internalErr != nil {
+		t.Fatalf("Failed to find user: %v", internalErr.Error())
+	}
+	if found.Balance != 0 {
+		t.Errorf("Expected an empty balance, got %d", found.Balance)
+	}
+}
@@ -6,47 +6,80 @@ import (
 	"fmt"
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
 	"fullcycle-auction_go/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
 )
 
 type UserEntityMongo struct {
-	Id   string `bson:"_id"`
-	Name string `bson:"name"`
+	Id    string `bson:"_id"`
+	Name  string `bson:"name"`
+	Email string `bson:"email,omitempty"`
+
+	// Balance is in cents. Users created before wallets existed have none
+	Balance int64 `bson:"balance,omitempty"`
+
+	// The rating counters are only set once the user is first rated as a seller
+	RatingCount int64 `bson:"rating_count,omitempty"`
+	RatingSum   int64 `bson:"rating_sum,omitempty"`
+
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
+
+	// PasswordHash is the bcrypt hash, missing for users created before passwords existed
+	PasswordHash []byte `bson:"password_hash,omitempty"`
 }
 
 type UserRepository struct {
 	Collection *mongo.Collection
+
+	// operationTimeout bounds each operation, see dbtimeout.WithTimeout
+	operationTimeout time.Duration
 }
 
-func NewUserRepository(database *mongo.Database) *UserRepository {
+func NewUserRepository(database *mongo.Database, operationTimeout time.Duration) *UserRepository {
 	return &UserRepository{
-		Collection: database.Collection("users"),
+		Collection:       database.Collection("users"),
+		operationTimeout: operationTimeout,
 	}
 }
 
 func (ur *UserRepository) FindUserById(
 	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ur.operationTimeout)
+	defer cancel()
+
 	filter := bson.M{"_id": userId}
 
 	var userEntityMongo UserEntityMongo
 	err := ur.Collection.FindOne(ctx, filter).Decode(&userEntityMongo)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			logger.Error(fmt.Sprintf("User not found with this id = %d", userId), err)
+			logger.ErrorContext(ctx, "User not found", err, zap.String("user_id", userId))
 			return nil, internal_error.NewNotFoundError(
-				fmt.Sprintf("User not found with this id = %d", userId))
+				fmt.Sprintf("User not found with this id = %s", userId)).Wrap(err)
 		}
 
-		logger.Error("Error trying to find user by userId", err)
-		return nil, internal_error.NewInternalServerError("Error trying to find user by userId")
+		logger.ErrorContext(ctx, "Error trying to find user by userId", err, zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").Wrap(err)
 	}
 
-	userEntity := &user_entity.User{
-		Id:   userEntityMongo.Id,
-		Name: userEntityMongo.Name,
-	}
+	return toUserEntity(userEntityMongo), nil
+}
+
+func toUserEntity(userEntityMongo UserEntityMongo) *user_entity.User {
+	return &user_entity.User{
+		Id:    userEntityMongo.Id,
+		Name:  userEntityMongo.Name,
+		Email: userEntityMongo.Email,
 
-	return userEntity, nil
+		Balance:     userEntityMongo.Balance,
+		RatingCount: userEntityMongo.RatingCount,
+		RatingSum:   userEntityMongo.RatingSum,
+		DeletedAt:   userEntityMongo.DeletedAt,
+
+		PasswordHash: userEntityMongo.PasswordHash,
+	}
 }
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// UpdateUser sets the changed profile fields of a user that isn't deleted. The unique
+// email index rejects an email already taken by another user, deleted ones included.
+func (ur *UserRepository) UpdateUser(
+	ctx context.Context,
+	userId string,
+	update user_entity.UserUpdate) (*user_entity.User, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ur.operationTimeout)
+	defer cancel()
+
+	fields := bson.M{}
+	if update.Name != nil {
+		fields["name"] = *update.Name
+	}
+	if update.Email != nil {
+		fields["email"] = *update.Email
+	}
+
+	var updated UserEntityMongo
+	err := ur.Collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": userId, "deleted_at": nil},
+		bson.M{"$set": fields},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, internal_error.NewNotFoundError(
+				fmt.Sprintf("User not found with this id = %s", userId)).Wrap(err)
+		case mongo.IsDuplicateKeyError(err):
+			return nil, internal_error.NewConflictError("A user with this email already exists").Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "Error trying to update user", err, zap.String("user_id", userId))
+		return nil, internal_error.NewInternalServerError("Error trying to update user").Wrap(err)
+	}
+
+	return toUserEntity(updated), nil
+}
+
+// SoftDeleteUser sets deleted_at on the user instead of removing it, so the bids and
+// auctions of the user keep pointing at an existing document.
+func (ur *UserRepository) SoftDeleteUser(
+	ctx context.Context, userId string) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, ur.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": userId, "deleted_at": nil}
+	update := bson.M{"$set": bson.M{"deleted_at": time.Now().UTC()}}
+
+	result, err := ur.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to delete user", err, zap.String("user_id", userId))
+		return internal_error.NewInternalServerError("Error trying to delete user").Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	logger.InfoContext(ctx, "User deleted", zap.String("user_id", userId))
+	return nil
+}
@@ -0,0 +1,201 @@
+package watchlist
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/watchlist_entity"
+	"fullcycle-auction_go/internal/infra/database/dbtime"
+	"fullcycle-auction_go/internal/infra/database/dbtimeout"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type WatchEntityMongo struct {
+	UserId    string      `bson:"user_id"`
+	AuctionId string      `bson:"auction_id"`
+	Timestamp dbtime.Time `bson:"timestamp"`
+}
+
+type WatchlistRepository struct {
+	Collection *mongo.Collection
+
+	// operationTimeout bounds each operation, see dbtimeout.WithTimeout
+	operationTimeout time.Duration
+}
+
+func NewWatchlistRepository(database *mongo.Database, operationTimeout time.Duration) *WatchlistRepository {
+	return &WatchlistRepository{
+		Collection:       database.Collection("watchlist"),
+		operationTimeout: operationTimeout,
+	}
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// EnsureIndexes creates the unique {user_id, auction_id} index Add relies on to keep a
+// single watch per user and auction, the index FindByUserId pages through and the one
+// the watchers of an auction are counted and looked up by.
+func (wr *WatchlistRepository) EnsureIndexes(ctx context.Context) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, wr.operationTimeout)
+	defer cancel()
+
+	_, err := wr.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "auction_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "auction_id", Value: 1}},
+		},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error trying to create watchlist indexes", err)
+		return internal_error.NewInternalServerError("Error trying to create watchlist indexes").Wrap(err)
+	}
+
+	return nil
+}
+
+// Add upserts the watch, only setting its fields when it is inserted so watching again
+// keeps the first timestamp. Two concurrent upserts can both try to insert, in which case
+// the unique index rejects one of them, and the watch stored by the other is kept.
+func (wr *WatchlistRepository) Add(
+	ctx context.Context, watch *watchlist_entity.Watch) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, wr.operationTimeout)
+	defer cancel()
+
+	filter := bson.M{"user_id": watch.UserId, "auction_id": watch.AuctionId}
+	update := bson.M{"$setOnInsert": bson.M{"timestamp": dbtime.From(watch.Timestamp)}}
+
+	_, err := wr.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		logger.ErrorContext(ctx, "Error trying to add auction to watchlist", err,
+			zap.String("user_id", watch.UserId), zap.String("auction_id", watch.AuctionId))
+		return internal_error.NewInternalServerError("Error trying to add auction to watchlist").Wrap(err)
+	}
+
+	return nil
+}
+
+func (wr *WatchlistRepository) Remove(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, wr.operationTimeout)
+	defer cancel()
+
+	if _, err := wr.Collection.DeleteOne(ctx, bson.M{"user_id": userId, "auction_id": auctionId}); err != nil {
+		logger.ErrorContext(ctx, "Error trying to remove auction from watchlist", err,
+			zap.String("user_id", userId), zap.String("auction_id", auctionId))
+		return internal_error.NewInternalServerError("Error trying to remove auction from watchlist").Wrap(err)
+	}
+
+	return nil
+}
+
+func (wr *WatchlistRepository) FindByUserId(
+	ctx context.Context,
+	userId string,
+	page, pageSize int) ([]watchlist_entity.Watch, int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, wr.operationTimeout)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	filter := bson.M{"user_id": userId}
+
+	total, err := wr.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error counting watchlist", err, zap.String("user_id", userId))
+		return nil, 0, internal_error.NewInternalServerError("Error counting watchlist").Wrap(err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "auction_id", Value: 1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := wr.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error finding watchlist", err, zap.String("user_id", userId))
+		return nil, 0, internal_error.NewInternalServerError("Error finding watchlist").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var watchesMongo []WatchEntityMongo
+	if err := cursor.All(ctx, &watchesMongo); err != nil {
+		logger.ErrorContext(ctx, "Error decoding watchlist", err, zap.String("user_id", userId))
+		return nil, 0, internal_error.NewInternalServerError("Error decoding watchlist").Wrap(err)
+	}
+
+	watches := make([]watchlist_entity.Watch, 0, len(watchesMongo))
+	for _, watch := range watchesMongo {
+		watches = append(watches, watchlist_entity.Watch{
+			UserId:    watch.UserId,
+			AuctionId: watch.AuctionId,
+			Timestamp: watch.Timestamp.UTC(),
+		})
+	}
+
+	return watches, total, nil
+}
+
+func (wr *WatchlistRepository) CountByAuctionId(
+	ctx context.Context, auctionId string) (int64, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, wr.operationTimeout)
+	defer cancel()
+
+	count, err := wr.Collection.CountDocuments(ctx, bson.M{"auction_id": auctionId})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error counting auction watchers", err, zap.String("auction_id", auctionId))
+		return 0, internal_error.NewInternalServerError("Error counting auction watchers").Wrap(err)
+	}
+
+	return count, nil
+}
+
+func (wr *WatchlistRepository) FindUserIdsByAuctionId(
+	ctx context.Context, auctionId string) ([]string, *internal_error.InternalError) {
+	ctx, cancel := dbtimeout.WithTimeout(ctx, wr.operationTimeout)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"user_id": 1, "_id": 0})
+	cursor, err := wr.Collection.Find(ctx, bson.M{"auction_id": auctionId}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error finding auction watchers", err, zap.String("auction_id", auctionId))
+		return nil, internal_error.NewInternalServerError("Error finding auction watchers").Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	var watchers []struct {
+		UserId string `bson:"user_id"`
+	}
+	if err := cursor.All(ctx, &watchers); err != nil {
+		logger.ErrorContext(ctx, "Error decoding auction watchers", err, zap.String("auction_id", auctionId))
+		return nil, internal_error.NewInternalServerError("Error decoding auction watchers").Wrap(err)
+	}
+
+	userIds := make([]string, 0, len(watchers))
+	for _, watcher := range watchers {
+		userIds = append(userIds, watcher.UserId)
+	}
+
+	return userIds, nil
+}
@@ -0,0 +1,123 @@
+package watchlist_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/watchlist_entity"
+	"fullcycle-auction_go/internal/infra/database/watchlist"
+
+	"github.com/google/uuid"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testDBName = "watchlist_test_db"
+
+// mongoSkipReason caches the first connection failure so the remaining tests
+// skip right away instead of waiting for the server selection timeout again.
+var mongoSkipReason string
+
+func setupTestDB(t *testing.T) (*mongo.Database, func()) {
+	if mongoSkipReason != "" {
+		t.Skip(mongoSkipReason)
+	}
+
+	mongoURL := os.Getenv("MONGODB_URL")
+	if mongoURL == "" {
+		mongoURL = "mongodb://localhost:27017"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB not available at %s: %v", mongoURL, err)
+		t.Skip(mongoSkipReason)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		mongoSkipReason = fmt.Sprintf("Skipping test: MongoDB ping failed: %v", err)
+		t.Skip(mongoSkipReason)
+	}
+
+	database := client.Database(testDBName)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	}
+
+	return database, cleanup
+}
+
+func TestAddIsIdempotentUnderConcurrentWatches(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := watchlist.NewWatchlistRepository(database, 0)
+	ctx := context.Background()
+
+	if internalErr := repo.EnsureIndexes(ctx); internalErr != nil {
+		t.Fatalf("Failed to create watchlist indexes: %v", internalErr.Error())
+	}
+
+	userId, auctionId := uuid.New().String(), uuid.New().String()
+	watchedAt := time.Now().Add(-time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			watch := &watchlist_entity.Watch{
+				UserId: userId, AuctionId: auctionId, Timestamp: watchedAt.Add(time.Duration(i) * time.Minute),
+			}
+			if internalErr := repo.Add(ctx, watch); internalErr != nil {
+				t.Errorf("Expected watching again to succeed, got %v", internalErr.Error())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if count, internalErr := repo.CountByAuctionId(ctx, auctionId); internalErr != nil || count != 1 {
+		t.Fatalf("Expected a single watch, got %d (%v)", count, internalErr)
+	}
+
+	other := watchlist_entity.CreateWatch(userId, uuid.New().String(), clock.New())
+	if internalErr := repo.Add(ctx, other); internalErr != nil {
+		t.Fatalf("Failed to watch auction: %v", internalErr.Error())
+	}
+
+	watches, total, internalErr := repo.FindByUserId(ctx, userId, 1, 1)
+	if internalErr != nil {
+		t.Fatalf("Failed to find watchlist: %v", internalErr.Error())
+	}
+	if total != 2 || len(watches) != 1 || watches[0].AuctionId != other.AuctionId {
+		t.Errorf("Expected the most recent of 2 watches first, got %d: %+v", total, watches)
+	}
+
+	userIds, internalErr := repo.FindUserIdsByAuctionId(ctx, auctionId)
+	if internalErr != nil || len(userIds) != 1 || userIds[0] != userId {
+		t.Errorf("Expected the watcher %s, got %v (%v)", userId, userIds, internalErr)
+	}
+
+	for i := 0; i < 2; i++ {
+		if internalErr := repo.Remove(ctx, userId, auctionId); internalErr != nil {
+			t.Fatalf("Expected removing twice to succeed, got %v", internalErr.Error())
+		}
+	}
+	if count, _ := repo.CountByAuctionId(ctx, auctionId); count != 0 {
+		t.Errorf("Expected no watcher left, got %d", count)
+	}
+}
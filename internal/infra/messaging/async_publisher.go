@@ -0,0 +1,147 @@
+// Package messaging publishes the auction lifecycle events to a message broker for the
+// services outside the process that consume them.
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/metrics"
+
+	"go.uber.org/zap"
+)
+
+// sendTimeout bounds each attempt to hand an event to the broker
+const sendTimeout = 5 * time.Second
+
+// Message is the JSON envelope every event is published in.
+type Message struct {
+	Type       string          `json:"type"`
+	AuctionId  string          `json:"auction_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Sender hands an encoded Message to the broker. It is only ever called by one
+// goroutine at a time.
+type Sender interface {
+	Send(ctx context.Context, message Message, body []byte) error
+	Close() error
+}
+
+// AsyncPublisher is an events.EventPublisher that queues events and sends them from a
+// single goroutine, so publishing never waits on the broker. An event that doesn't fit
+// in the queue is dropped, and one the sender keeps failing is dropped after the last
+// attempt; both are logged and counted in events_dropped_total.
+type AsyncPublisher struct {
+	sender   Sender
+	attempts int
+	backoff  time.Duration
+
+	// stopMu keeps Shutdown from closing the queue while an event is being enqueued
+	stopMu  sync.RWMutex
+	stopped bool
+	queue   chan Message
+	done    chan struct{}
+}
+
+// NewAsyncPublisher starts a publisher holding up to queueSize events, trying each one
+// up to attempts times with a backoff doubling from backoff between them.
+func NewAsyncPublisher(sender Sender, queueSize, attempts int, backoff time.Duration) *AsyncPublisher {
+	publisher := &AsyncPublisher{
+		sender:   sender,
+		attempts: attempts,
+		backoff:  backoff,
+		queue:    make(chan Message, queueSize),
+		done:     make(chan struct{}),
+	}
+
+	go publisher.run()
+	return publisher
+}
+
+func (p *AsyncPublisher) Publish(eventType, auctionId string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("Error trying to encode event", err, zap.String("event", eventType))
+		return
+	}
+
+	message := Message{Type: eventType, AuctionId: auctionId, OccurredAt: time.Now().UTC(), Data: payload}
+
+	p.stopMu.RLock()
+	defer p.stopMu.RUnlock()
+
+	if p.stopped {
+		return
+	}
+
+	select {
+	case p.queue <- message:
+	default:
+		metrics.EventsDropped.WithLabelValues("queue_full").Inc()
+		logger.Warn("Event queue is full, dropping event",
+			zap.String("event", eventType), zap.String("auction_id", auctionId))
+	}
+}
+
+// Shutdown stops accepting events and waits until the queued ones are sent or ctx is done.
+func (p *AsyncPublisher) Shutdown(ctx context.Context) error {
+	p.stopMu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+	}
+	p.stopMu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *AsyncPublisher) run() {
+	defer close(p.done)
+	defer p.sender.Close()
+
+	for message := range p.queue {
+		p.send(message)
+	}
+}
+
+func (p *AsyncPublisher) send(message Message) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		logger.Error("Error trying to encode event", err, zap.String("event", message.Type))
+		return
+	}
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = p.sender.Send(ctx, message, body)
+		cancel()
+
+		if err == nil {
+			metrics.EventsPublished.Inc()
+			return
+		}
+
+		if attempt >= p.attempts {
+			metrics.EventsDropped.WithLabelValues("send_failed").Inc()
+			logger.Error("Error trying to publish event, dropping it", err,
+				zap.String("event", message.Type),
+				zap.String("auction_id", message.AuctionId),
+				zap.Int("attempts", attempt))
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
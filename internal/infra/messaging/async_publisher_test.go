@@ -0,0 +1,127 @@
+package messaging_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/infra/messaging"
+)
+
+// fakeSender fails the first failures sends, then records the messages it gets. Sends
+// block until hold is closed when it is set, like a broker that stopped answering, and
+// report on entered that they started.
+type fakeSender struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	sent     []messaging.Message
+	bodies   [][]byte
+	hold     chan struct{}
+	entered  chan struct{}
+}
+
+func (s *fakeSender) Send(ctx context.Context, message messaging.Message, body []byte) error {
+	if s.hold != nil {
+		select {
+		case s.entered <- struct{}{}:
+		default:
+		}
+		<-s.hold
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("connection refused")
+	}
+
+	s.sent = append(s.sent, message)
+	s.bodies = append(s.bodies, body)
+	return nil
+}
+
+func (s *fakeSender) Close() error {
+	return nil
+}
+
+func shutdown(t *testing.T, publisher *messaging.AsyncPublisher) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := publisher.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to flush events: %v", err)
+	}
+}
+
+func TestAsyncPublisherRetriesUntilSent(t *testing.T) {
+	sender := &fakeSender{failures: 2}
+	publisher := messaging.NewAsyncPublisher(sender, 10, 3, time.Millisecond)
+
+	publisher.Publish("bid_placed", "auction-1", map[string]int{"amount": 100})
+	shutdown(t, publisher)
+
+	if len(sender.sent) != 1 || sender.calls != 3 {
+		t.Fatalf("Expected the event sent on the third attempt, got %d sent in %d calls", len(sender.sent), sender.calls)
+	}
+
+	var message messaging.Message
+	if err := json.Unmarshal(sender.bodies[0], &message); err != nil {
+		t.Fatalf("Expected a JSON envelope, got %q: %v", sender.bodies[0], err)
+	}
+	if message.Type != "bid_placed" || message.AuctionId != "auction-1" ||
+		string(message.Data) != `{"amount":100}` || message.OccurredAt.IsZero() {
+		t.Errorf("Unexpected envelope %+v", message)
+	}
+}
+
+func TestAsyncPublisherDropsAfterTheLastAttempt(t *testing.T) {
+	sender := &fakeSender{failures: 3}
+	publisher := messaging.NewAsyncPublisher(sender, 10, 3, time.Millisecond)
+
+	publisher.Publish("auction_created", "auction-1", nil)
+	publisher.Publish("auction_created", "auction-2", nil)
+	shutdown(t, publisher)
+
+	if len(sender.sent) != 1 || sender.sent[0].AuctionId != "auction-2" {
+		t.Errorf("Expected only the second event sent, got %+v", sender.sent)
+	}
+}
+
+func TestAsyncPublisherNeverBlocks(t *testing.T) {
+	sender := &fakeSender{hold: make(chan struct{}), entered: make(chan struct{}, 1)}
+	publisher := messaging.NewAsyncPublisher(sender, 2, 1, time.Millisecond)
+
+	publisher.Publish("bid_placed", "auction-1", 0)
+	<-sender.entered
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i < 10; i++ {
+			publisher.Publish("bid_placed", "auction-1", i)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Publish to return while the broker is stuck")
+	}
+
+	close(sender.hold)
+	shutdown(t, publisher)
+
+	// One event was taken by the stuck send and two waited in the queue
+	if len(sender.sent) != 3 {
+		t.Errorf("Expected the events beyond the queue to be dropped, got %d sent", len(sender.sent))
+	}
+
+	// Events published after the shutdown are dropped too
+	publisher.Publish("bid_placed", "auction-1", 10)
+}
@@ -0,0 +1,19 @@
+package messaging
+
+import "fullcycle-auction_go/internal/events"
+
+// ClosedAuctionPublisher listens to the auction closer and publishes its auction_closed
+// events, which carry the winner snapshot recorded by the close.
+type ClosedAuctionPublisher struct {
+	publisher events.EventPublisher
+}
+
+func NewClosedAuctionPublisher(publisher events.EventPublisher) *ClosedAuctionPublisher {
+	return &ClosedAuctionPublisher{publisher: publisher}
+}
+
+func (p *ClosedAuctionPublisher) AuctionsClosed(closed []events.AuctionClosedEvent) {
+	for _, event := range closed {
+		p.publisher.Publish(events.AuctionClosed, event.AuctionId, event)
+	}
+}
@@ -0,0 +1,129 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/events"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const defaultBackoff = 200 * time.Millisecond
+
+// Config is where the auction events are published.
+type Config struct {
+	// URL of the broker; empty disables the publishing
+	URL string
+
+	// Exchange is the topic exchange the events go to, with the routing key
+	// <RoutingKeyPrefix>.<event type>
+	Exchange         string
+	RoutingKeyPrefix string
+
+	// QueueSize is how many events may wait to be sent, and PublishAttempts how many
+	// times each is tried, see AsyncPublisher
+	QueueSize       int
+	PublishAttempts int
+}
+
+// DefaultConfig returns the configuration used for the settings left unset.
+func DefaultConfig() Config {
+	return Config{
+		Exchange:         "auction.events",
+		RoutingKeyPrefix: "auction",
+		QueueSize:        1024,
+		PublishAttempts:  5,
+	}
+}
+
+// NewEventPublisher returns the publisher of config along with its shutdown, or
+// events.NoopPublisher when config.URL is empty.
+func NewEventPublisher(config Config) (events.EventPublisher, func(ctx context.Context) error) {
+	if config.URL == "" {
+		return events.NoopPublisher{}, func(ctx context.Context) error { return nil }
+	}
+
+	sender := NewRabbitMQSender(config.URL, config.Exchange, config.RoutingKeyPrefix)
+	publisher := NewAsyncPublisher(sender, config.QueueSize, config.PublishAttempts, defaultBackoff)
+	logger.Info("Publishing auction events to RabbitMQ", zap.String("exchange", sender.exchange))
+
+	return publisher, publisher.Shutdown
+}
+
+// RabbitMQSender publishes each event to a durable topic exchange with the routing key
+// <prefix>.<event type>, e.g. auction.bid_placed. It connects on the first send and
+// reconnects on the send after a failure, so the broker may come up after the app.
+type RabbitMQSender struct {
+	url              string
+	exchange         string
+	routingKeyPrefix string
+
+	connection *amqp.Connection
+	channel    *amqp.Channel
+}
+
+func NewRabbitMQSender(url, exchange, routingKeyPrefix string) *RabbitMQSender {
+	return &RabbitMQSender{url: url, exchange: exchange, routingKeyPrefix: routingKeyPrefix}
+}
+
+func (s *RabbitMQSender) Send(ctx context.Context, message Message, body []byte) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+
+	err := s.channel.PublishWithContext(ctx, s.exchange, s.routingKeyPrefix+"."+message.Type, false, false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Type:         message.Type,
+			Timestamp:    message.OccurredAt,
+			Body:         body,
+		})
+	if err != nil {
+		s.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (s *RabbitMQSender) connect() error {
+	if s.channel != nil && !s.channel.IsClosed() {
+		return nil
+	}
+	s.Close()
+
+	connection, err := amqp.Dial(s.url)
+	if err != nil {
+		return err
+	}
+
+	channel, err := connection.Channel()
+	if err != nil {
+		connection.Close()
+		return err
+	}
+
+	if err := channel.ExchangeDeclare(s.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		connection.Close()
+		return err
+	}
+
+	s.connection = connection
+	s.channel = channel
+	return nil
+}
+
+func (s *RabbitMQSender) Close() error {
+	if s.connection == nil {
+		return nil
+	}
+
+	err := s.connection.Close()
+	s.connection = nil
+	s.channel = nil
+	return err
+}
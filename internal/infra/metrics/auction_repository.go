@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	RepositoryOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_operation_duration_seconds",
+		Help:    "Time taken by each repository operation, whether it succeeds or not.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"repository", "operation"})
+
+	RepositoryOperationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "repository_operation_errors_total",
+		Help: "Number of errors returned by each repository operation, by error code.",
+	}, []string{"repository", "operation", "code"})
+)
+
+// AuctionRepository decorates an auction repository with the duration and the errors of
+// each of its operations. Every method of the interface is measured, so a new one has to
+// be added here too.
+type AuctionRepository struct {
+	inner auction_entity.AuctionRepositoryInterface
+}
+
+var _ auction_entity.AuctionRepositoryInterface = (*AuctionRepository)(nil)
+
+// NewAuctionRepository measures the operations of inner.
+func NewAuctionRepository(inner auction_entity.AuctionRepositoryInterface) *AuctionRepository {
+	return &AuctionRepository{inner: inner}
+}
+
+// observe records an operation that started at start and failed with errs, if any.
+func (ar *AuctionRepository) observe(operation string, start time.Time, errs ...*internal_error.InternalError) {
+	RepositoryOperationDuration.WithLabelValues("auction", operation).Observe(time.Since(start).Seconds())
+	for _, err := range errs {
+		if err != nil {
+			RepositoryOperationErrors.WithLabelValues("auction", operation, string(err.Err)).Inc()
+		}
+	}
+}
+
+func (ar *AuctionRepository) CreateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	start := time.Now()
+	err := ar.inner.CreateAuction(ctx, auctionEntity)
+	ar.observe("CreateAuction", start, err)
+
+	return err
+}
+
+// CreateAuctions counts an error for each auction that failed to be stored.
+func (ar *AuctionRepository) CreateAuctions(
+	ctx context.Context, auctionEntities []*auction_entity.Auction) []*internal_error.InternalError {
+	start := time.Now()
+	errs := ar.inner.CreateAuctions(ctx, auctionEntities)
+	ar.observe("CreateAuctions", start, errs...)
+
+	return errs
+}
+
+func (ar *AuctionRepository) FindAuctions(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter,
+	page, pageSize int) ([]auction_entity.Auction, int64, *internal_error.InternalError) {
+	start := time.Now()
+	auctions, total, err := ar.inner.FindAuctions(ctx, filter, page, pageSize)
+	ar.observe("FindAuctions", start, err)
+
+	return auctions, total, err
+}
+
+func (ar *AuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	start := time.Now()
+	auction, err := ar.inner.FindAuctionById(ctx, id)
+	ar.observe("FindAuctionById", start, err)
+
+	return auction, err
+}
+
+// StreamAuctions also counts the time fn takes with each auction, as the stream is read
+// along with it.
+func (ar *AuctionRepository) StreamAuctions(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter,
+	fn func(auction auction_entity.Auction) error) *internal_error.InternalError {
+	start := time.Now()
+	err := ar.inner.StreamAuctions(ctx, filter, fn)
+	ar.observe("StreamAuctions", start, err)
+
+	return err
+}
+
+func (ar *AuctionRepository) UpdateAuctionStatus(
+	ctx context.Context, id string, version int64, from, to auction_entity.AuctionStatus) *internal_error.InternalError {
+	start := time.Now()
+	err := ar.inner.UpdateAuctionStatus(ctx, id, version, from, to)
+	ar.observe("UpdateAuctionStatus", start, err)
+
+	return err
+}
+
+func (ar *AuctionRepository) UpdateAuction(
+	ctx context.Context,
+	id string,
+	version int64,
+	update auction_entity.AuctionUpdate) (*auction_entity.Auction, *internal_error.InternalError) {
+	start := time.Now()
+	auction, err := ar.inner.UpdateAuction(ctx, id, version, update)
+	ar.observe("UpdateAuction", start, err)
+
+	return auction, err
+}
+
+func (ar *AuctionRepository) ExtendAuctionEndTime(
+	ctx context.Context,
+	id string,
+	window, extension, maxExtension time.Duration) (time.Time, bool, *internal_error.InternalError) {
+	start := time.Now()
+	endTime, extended, err := ar.inner.ExtendAuctionEndTime(ctx, id, window, extension, maxExtension)
+	ar.observe("ExtendAuctionEndTime", start, err)
+
+	return endTime, extended, err
+}
+
+func (ar *AuctionRepository) SoftDeleteAuction(ctx context.Context, id string) *internal_error.InternalError {
+	start := time.Now()
+	err := ar.inner.SoftDeleteAuction(ctx, id)
+	ar.observe("SoftDeleteAuction", start, err)
+
+	return err
+}
+
+func (ar *AuctionRepository) ReplaceAuctionImages(
+	ctx context.Context,
+	id string,
+	version int64,
+	images []auction_entity.AuctionImage) (*auction_entity.Auction, *internal_error.InternalError) {
+	start := time.Now()
+	auction, err := ar.inner.ReplaceAuctionImages(ctx, id, version, images)
+	ar.observe("ReplaceAuctionImages", start, err)
+
+	return auction, err
+}
+
+func (ar *AuctionRepository) FindAuditByAuctionId(
+	ctx context.Context, auctionId string) ([]auction_entity.AuctionAudit, *internal_error.InternalError) {
+	start := time.Now()
+	audit, err := ar.inner.FindAuditByAuctionId(ctx, auctionId)
+	ar.observe("FindAuditByAuctionId", start, err)
+
+	return audit, err
+}
+
+func (ar *AuctionRepository) AggregateTopSellers(
+	ctx context.Context, from, to time.Time, limit int) ([]auction_entity.TopSeller, *internal_error.InternalError) {
+	start := time.Now()
+	sellers, err := ar.inner.AggregateTopSellers(ctx, from, to, limit)
+	ar.observe("AggregateTopSellers", start, err)
+
+	return sellers, err
+}
+
+func (ar *AuctionRepository) GetFacets(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter) (*auction_entity.AuctionFacets, *internal_error.InternalError) {
+	start := time.Now()
+	facets, err := ar.inner.GetFacets(ctx, filter)
+	ar.observe("GetFacets", start, err)
+
+	return facets, err
+}
+
+func (ar *AuctionRepository) RenameCategory(
+	ctx context.Context, from, to string) (int64, *internal_error.InternalError) {
+	start := time.Now()
+	renamed, err := ar.inner.RenameCategory(ctx, from, to)
+	ar.observe("RenameCategory", start, err)
+
+	return renamed, err
+}
+
+func (ar *AuctionRepository) CountAuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	start := time.Now()
+	counts, err := ar.inner.CountAuctionsByStatus(ctx)
+	ar.observe("CountAuctionsByStatus", start, err)
+
+	return counts, err
+}
+
+func (ar *AuctionRepository) FindDuplicateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	since time.Time) (string, *internal_error.InternalError) {
+	start := time.Now()
+	id, err := ar.inner.FindDuplicateAuction(ctx, auctionEntity, since)
+	ar.observe("FindDuplicateAuction", start, err)
+
+	return id, err
+}
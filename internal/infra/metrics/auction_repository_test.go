@@ -0,0 +1,94 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/infra/metrics"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAuctionRepository answers the operations the test calls and counts the calls. The
+// other methods come from the nil embedded interface and panic if called.
+type fakeAuctionRepository struct {
+	auction_entity.AuctionRepositoryInterface
+
+	calls int
+}
+
+func (f *fakeAuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	f.calls++
+	if id == "missing" {
+		return nil, internal_error.NewNotFoundError("Auction not found")
+	}
+
+	return &auction_entity.Auction{Id: id}, nil
+}
+
+func (f *fakeAuctionRepository) CreateAuctions(
+	ctx context.Context, auctionEntities []*auction_entity.Auction) []*internal_error.InternalError {
+	f.calls++
+	return []*internal_error.InternalError{
+		nil, internal_error.NewConflictError("Auction already exists"), internal_error.NewConflictError("Auction already exists"),
+	}
+}
+
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/metrics", metrics.Handler())
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+
+	return string(body)
+}
+
+func TestAuctionRepositoryMeasuresEachOperation(t *testing.T) {
+	inner := &fakeAuctionRepository{}
+	repo := metrics.NewAuctionRepository(inner)
+	ctx := context.Background()
+
+	// The results of the inner repository are passed through untouched
+	found, err := repo.FindAuctionById(ctx, "auction-1")
+	if err != nil || found.Id != "auction-1" {
+		t.Fatalf("Expected to find auction-1, got %+v, %v", found, err)
+	}
+	if _, err := repo.FindAuctionById(ctx, "missing"); err == nil || err.Err != internal_error.NotFound {
+		t.Fatalf("Expected the not found error of the inner repository, got %v", err)
+	}
+
+	errs := repo.CreateAuctions(ctx, make([]*auction_entity.Auction, 3))
+	if len(errs) != 3 || errs[0] != nil || errs[1] == nil {
+		t.Fatalf("Expected the errors of the inner repository, got %v", errs)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls to reach the inner repository, got %d", inner.calls)
+	}
+
+	body := scrapeMetrics(t)
+	for _, line := range []string{
+		`repository_operation_duration_seconds_count{operation="FindAuctionById",repository="auction"} 2`,
+		`repository_operation_duration_seconds_count{operation="CreateAuctions",repository="auction"} 1`,
+		`repository_operation_errors_total{code="not_found",operation="FindAuctionById",repository="auction"} 1`,
+		`repository_operation_errors_total{code="conflict",operation="CreateAuctions",repository="auction"} 2`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected %q in the metrics output", line)
+		}
+	}
+}
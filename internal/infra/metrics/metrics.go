@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// The collectors are registered once, when the package is initialized, so building the
+// repositories several times (as the tests do) never registers them twice.
+var (
+	AuctionsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "auctions_created_total",
+		Help: "Number of auctions created.",
+	})
+
+	AuctionsClosed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "auctions_closed_total",
+		Help: "Number of expired auctions closed by the auction closer.",
+	})
+
+	AuctionsBoughtNow = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "auctions_bought_now_total",
+		Help: "Number of auctions closed early by a bid reaching their buy-now price.",
+	})
+
+	BidsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bids_created_total",
+		Help: "Number of bids persisted.",
+	})
+
+	BidInsertDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "bid_insert_duration_seconds",
+		Help:    "Time taken by the bid insert transaction, whether it succeeds or not.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	AuctionCloseLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "auction_close_lag_seconds",
+		Help:    "Time between an auction's end_time and the sweep that closed it.",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+	})
+
+	AuctionCloseFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "auction_close_failures_total",
+		Help: "Number of times an expired auction failed to close even after retrying.",
+	})
+
+	AuctionCloseOverdue = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "auction_close_overdue_total",
+		Help: "Number of Active auctions found past their end_time by more than the close lag alarm, once per check.",
+	})
+
+	HandlerPanics = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "http_handler_panics_total",
+		Help: "Number of requests whose handler panicked, recovered with a 500.",
+	})
+
+	EventsPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "events_published_total",
+		Help: "Number of events delivered to the message broker.",
+	})
+
+	EventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_dropped_total",
+		Help: "Number of events given up on, by reason: queue_full or send_failed.",
+	}, []string{"reason"})
+
+	BidQueueDepth = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "bid_queue_depth",
+		Help: "Number of accepted bids waiting to be written, including the batch being written.",
+	}, func() float64 { return float64(readBidQueue().Depth) })
+
+	BidsDropped = promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "bids_dropped_total",
+		Help: "Number of bids refused with service_busy because the bid queue was full.",
+	}, func() float64 { return float64(readBidQueue().Dropped) })
+)
+
+// bidQueue is read by BidQueueDepth and BidsDropped, see WatchBidQueue
+var (
+	bidQueueMu sync.RWMutex
+	bidQueue   func() bid_usecase.BidQueueOutputDTO
+)
+
+// WatchBidQueue makes the bid queue collectors read stats, the QueueStats of the bid use
+// case. Until it is called they report zero.
+func WatchBidQueue(stats func() bid_usecase.BidQueueOutputDTO) {
+	bidQueueMu.Lock()
+	defer bidQueueMu.Unlock()
+
+	bidQueue = stats
+}
+
+func readBidQueue() bid_usecase.BidQueueOutputDTO {
+	bidQueueMu.RLock()
+	defer bidQueueMu.RUnlock()
+
+	if bidQueue == nil {
+		return bid_usecase.BidQueueOutputDTO{}
+	}
+	return bidQueue()
+}
+
+// ObserveSince records the time elapsed since start in the histogram.
+func ObserveSince(histogram prometheus.Histogram, start time.Time) {
+	histogram.Observe(time.Since(start).Seconds())
+}
+
+// Quantile estimates the q quantile of histogram from its buckets, interpolating within
+// the bucket it falls in like PromQL's histogram_quantile, so it is only as precise as
+// the buckets are. Samples past the last bucket count as its upper bound. It returns
+// false while the histogram has no samples.
+func Quantile(histogram prometheus.Histogram, q float64) (float64, bool) {
+	var metric dto.Metric
+	if err := histogram.Write(&metric); err != nil || metric.GetHistogram().GetSampleCount() == 0 {
+		return 0, false
+	}
+
+	rank := q * float64(metric.GetHistogram().GetSampleCount())
+	var lowerBound float64
+	var lowerCount uint64
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		if float64(bucket.GetCumulativeCount()) >= rank {
+			inBucket := bucket.GetCumulativeCount() - lowerCount
+			if inBucket == 0 {
+				return bucket.GetUpperBound(), true
+			}
+			return lowerBound + (bucket.GetUpperBound()-lowerBound)*(rank-float64(lowerCount))/float64(inBucket), true
+		}
+		lowerBound, lowerCount = bucket.GetUpperBound(), bucket.GetCumulativeCount()
+	}
+
+	return lowerBound, true
+}
+
+// Handler serves the registered metrics in the Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
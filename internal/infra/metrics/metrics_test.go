@@ -0,0 +1,89 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/infra/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHandlerExposesAuctionAndBidMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	metrics.AuctionsCreated.Inc()
+	metrics.ObserveSince(metrics.BidInsertDuration, time.Now().Add(-time.Second))
+
+	router := gin.New()
+	router.GET("/metrics", metrics.Handler())
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+
+	for _, name := range []string{
+		"auctions_created_total 1",
+		"auctions_closed_total",
+		"bids_created_total",
+		"bid_insert_duration_seconds_count 1",
+		"auction_close_lag_seconds_count",
+	} {
+		if !strings.Contains(string(body), name) {
+			t.Errorf("Expected %q in the metrics output", name)
+		}
+	}
+}
+
+func TestQuantileInterpolatesWithinBuckets(t *testing.T) {
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_quantile_seconds",
+		Buckets: []float64{1, 2, 4},
+	})
+
+	if _, ok := metrics.Quantile(histogram, 0.95); ok {
+		t.Fatalf("Expected no quantile without samples")
+	}
+
+	// 10 samples: 5 up to 1s, 4 between 1s and 2s and 1 between 2s and 4s
+	for _, value := range []float64{0.5, 0.5, 0.5, 0.5, 0.5, 1.5, 1.5, 1.5, 1.5, 3} {
+		histogram.Observe(value)
+	}
+
+	testCases := []struct {
+		q        float64
+		expected float64
+	}{
+		{q: 0.5, expected: 1},
+		{q: 0.7, expected: 1.5},
+		{q: 0.95, expected: 3},
+	}
+	for _, tc := range testCases {
+		if got, ok := metrics.Quantile(histogram, tc.q); !ok || got != tc.expected {
+			t.Errorf("Expected the %v quantile to be %v, got %v", tc.q, tc.expected, got)
+		}
+	}
+
+	// Samples past the last bucket are reported as its upper bound
+	for i := 0; i < 90; i++ {
+		histogram.Observe(60)
+	}
+	if got, _ := metrics.Quantile(histogram, 0.95); got != 4 {
+		t.Errorf("Expected the quantile past the last bucket to be 4, got %v", got)
+	}
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in the request spans when OTEL_SERVICE_NAME is not set.
+const ServiceName = "auction"
+
+const tracerName = "fullcycle-auction_go"
+
+// propagator is the W3C trace context, used both for incoming requests and for the
+// traceparent stored along with auctions.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Setup installs the global tracer provider, exporting spans over OTLP/HTTP as
+// configured by the standard OTEL_EXPORTER_OTLP_* variables. Without an endpoint, or with
+// OTEL_SDK_DISABLED=true, tracing stays a no-op. The returned function flushes the spans
+// still buffered and must be called on shutdown.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	if !exporterConfigured() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The default resource reads OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func exporterConfigured() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Middleware starts a span per request, continuing the trace of the caller when the
+// request carries a traceparent header.
+func Middleware() gin.HandlerFunc {
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = ServiceName
+	}
+
+	return otelgin.Middleware(serviceName)
+}
+
+// Start starts a span named after the operation, such as "BidRepository.CreateBid", as
+// a child of the span in ctx.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartLinked is Start for work done on behalf of other traces, such as the requests
+// that created the auctions a sweep closes. The span links to each of them.
+func StartLinked(
+	ctx context.Context, name string, links []trace.Link, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithLinks(links...), trace.WithAttributes(attributes...))
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or "" without one. It is
+// stored with documents so later background work can link back to the request.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return carrier.Get("traceparent")
+}
+
+// LinkTo returns a link to the span a TraceParent value refers to. It reports false
+// for an empty or malformed traceparent.
+func LinkTo(traceParent string) (trace.Link, bool) {
+	if traceParent == "" {
+		return trace.Link{}, false
+	}
+
+	ctx := propagation.TraceContext{}.Extract(
+		context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return trace.Link{}, false
+	}
+
+	return trace.Link{SpanContext: spanContext}, true
+}
@@ -0,0 +1,94 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/infra/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider exporting to memory for the duration of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+func TestMiddlewareStartsRequestSpanParentOfRepositorySpans(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	exporter := recordSpans(t)
+
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/bid/:auctionId", func(c *gin.Context) {
+		ctx, span := tracing.Start(c.Request.Context(), "BidRepository.FindBidByAuctionId")
+		logger.ErrorContext(ctx, "Error trying to find bids by auctionId", errors.New("connection reset"))
+		span.End()
+
+		c.Status(http.StatusInternalServerError)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/bid/123", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected a request span and a repository span, got %d spans", len(spans))
+	}
+
+	repositorySpan, requestSpan := spans[0], spans[1]
+	if requestSpan.Name != "/bid/:auctionId" {
+		t.Errorf("Expected the request span to be named after the route, got %s", requestSpan.Name)
+	}
+	if repositorySpan.Parent.SpanID() != requestSpan.SpanContext.SpanID() {
+		t.Error("Expected the repository span to be a child of the request span")
+	}
+	if repositorySpan.Status.Code != codes.Error || len(repositorySpan.Events) == 0 {
+		t.Errorf("Expected the logged error to be recorded on the repository span, got %+v", repositorySpan.Status)
+	}
+}
+
+func TestLinkToFollowsTraceParent(t *testing.T) {
+	exporter := recordSpans(t)
+
+	ctx, span := tracing.Start(context.Background(), "AuctionRepository.CreateAuction")
+	traceParent := tracing.TraceParent(ctx)
+	span.End()
+
+	link, ok := tracing.LinkTo(traceParent)
+	if !ok {
+		t.Fatalf("Expected a link from traceparent %q", traceParent)
+	}
+	created := exporter.GetSpans()[0].SpanContext
+	if link.SpanContext.TraceID() != created.TraceID() || link.SpanContext.SpanID() != created.SpanID() {
+		t.Error("Expected the link to point at the span the traceparent was taken from")
+	}
+
+	for _, invalid := range []string{"", "not-a-traceparent"} {
+		if _, ok := tracing.LinkTo(invalid); ok {
+			t.Errorf("Expected no link from %q", invalid)
+		}
+	}
+
+	if traceParent := tracing.TraceParent(context.Background()); traceParent != "" {
+		t.Errorf("Expected no traceparent without a span, got %q", traceParent)
+	}
+}
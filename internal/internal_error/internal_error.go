@@ -1,31 +1,268 @@
 package internal_error
 
+import "fmt"
+
+// ErrorCode is the machine-readable kind of an InternalError. The rest_err layer maps
+// each code to an HTTP status.
+type ErrorCode string
+
+const (
+	NotFound   ErrorCode = "not_found"
+	BadRequest ErrorCode = "bad_request"
+	Conflict   ErrorCode = "conflict"
+	Forbidden  ErrorCode = "forbidden"
+	Internal   ErrorCode = "internal"
+
+	// Unauthorized is an error for credentials that don't identify a user
+	Unauthorized ErrorCode = "unauthorized"
+
+	// NoBids is a not found error for auctions that closed without any bid
+	NoBids ErrorCode = "no_bids"
+
+	// CategoryNotFound is a not found error for category slugs that match no category
+	CategoryNotFound ErrorCode = "category_not_found"
+
+	// AuctionNotFound and UserNotFound are not found errors for bids on an auction, or
+	// from a user, that doesn't exist
+	AuctionNotFound ErrorCode = "auction_not_found"
+	UserNotFound    ErrorCode = "user_not_found"
+
+	// NotStarted is a conflict error for bids on auctions that are still Scheduled
+	NotStarted ErrorCode = "auction_not_started"
+
+	// DuplicateAuction is a conflict error for an auction the seller just created already
+	DuplicateAuction ErrorCode = "duplicate_auction"
+
+	// CurrencyMismatch is a bad request error for bids in another currency than their
+	// auction
+	CurrencyMismatch ErrorCode = "currency_mismatch"
+
+	// SelfBidForbidden is a forbidden error for bids of a seller on their own auction
+	SelfBidForbidden ErrorCode = "self_bid_forbidden"
+
+	// BidLimitExceeded is a too many requests error for bids of a user past the cap of
+	// bids per auction
+	BidLimitExceeded ErrorCode = "bid_limit_exceeded"
+
+	// BuyNowUnavailable is a conflict error for buying an auction that has no buy-now
+	// price, or can't be bought at it anymore
+	BuyNowUnavailable ErrorCode = "buy_now_unavailable"
+
+	// ServiceBusy is a service unavailable error for requests refused to shed load, which
+	// the client may retry later
+	ServiceBusy ErrorCode = "service_busy"
+)
+
+// FieldError is one invalid input field, named as clients send it.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// InternalError is the error returned across the entity, usecase and repository layers.
+// Message is safe to show to clients; Cause keeps the underlying error, e.g. the Mongo
+// driver error, for logs and for errors.Is/errors.As. Bad requests that come from
+// validation list every failing field in Causes.
 type InternalError struct {
 	Message string
-	Err     string
+	Err     ErrorCode
+	Cause   error
+	Causes  []FieldError
+
+	// ExistingAuctionId is the auction a DuplicateAuction error is a duplicate of
+	ExistingAuctionId string
 }
 
 func (ie *InternalError) Error() string {
 	return ie.Message
 }
 
+func (ie *InternalError) Unwrap() error {
+	if ie == nil {
+		return nil
+	}
+
+	return ie.Cause
+}
+
+// Is matches errors of the same code and message, so errors.Is(err, ErrAuctionNotActive)
+// still holds once the shared error has been wrapped with a cause.
+func (ie *InternalError) Is(target error) bool {
+	other, ok := target.(*InternalError)
+	return ok && ie != nil && other != nil && other.Err == ie.Err && other.Message == ie.Message
+}
+
+// Wrap returns a copy of the error with cause attached, leaving the receiver untouched
+// so shared errors such as ErrAuctionNotActive can be wrapped safely.
+func (ie *InternalError) Wrap(cause error) *InternalError {
+	wrapped := *ie
+	wrapped.Cause = cause
+	return &wrapped
+}
+
 func NewNotFoundError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
-		Err:     "not_found",
+		Err:     NotFound,
+	}
+}
+
+func NewForbiddenError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     Forbidden,
+	}
+}
+
+func NewUnauthorizedError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     Unauthorized,
+	}
+}
+
+func NewNoBidsError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     NoBids,
 	}
 }
 
 func NewInternalServerError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
-		Err:     "internal_server_error",
+		Err:     Internal,
 	}
 }
 
 func NewBadRequestError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
-		Err:     "bad_request",
+		Err:     BadRequest,
 	}
 }
+
+// HasCause reports whether field is among the invalid fields. It is false for a nil error.
+func (ie *InternalError) HasCause(field string) bool {
+	if ie == nil {
+		return false
+	}
+
+	for _, cause := range ie.Causes {
+		if cause.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewValidationError is a bad request error listing the fields that failed validation.
+func NewValidationError(message string, causes ...FieldError) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     BadRequest,
+		Causes:  causes,
+	}
+}
+
+// JoinValidationErrors merges the causes of validation errors found in separate steps,
+// keeping the message of the first one, so clients see every failing field at once.
+// Nil errors are skipped and any error other than a bad request is returned as is.
+func JoinValidationErrors(errs ...*InternalError) *InternalError {
+	var joined *InternalError
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if err.Err != BadRequest {
+			return err
+		}
+
+		if joined == nil {
+			copied := *err
+			copied.Causes = append([]FieldError(nil), err.Causes...)
+			joined = &copied
+			continue
+		}
+		joined.Causes = append(joined.Causes, err.Causes...)
+	}
+
+	return joined
+}
+
+func NewConflictError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     Conflict,
+	}
+}
+
+var ErrAuctionNotActive = NewConflictError("Auction is not active")
+
+// NewDuplicateAuctionError rejects an auction identical to existingAuctionId, a live
+// auction the same seller created moments before.
+func NewDuplicateAuctionError(existingAuctionId string) *InternalError {
+	return &InternalError{
+		Message:           "An identical auction was just created",
+		Err:               DuplicateAuction,
+		ExistingAuctionId: existingAuctionId,
+	}
+}
+
+// ErrAuctionModified is returned when a change to an auction was based on a version that
+// another change replaced in the meantime. Fetching the auction again and retrying fixes it.
+var ErrAuctionModified = NewConflictError("Auction was changed by another request, fetch it again and retry")
+
+// ErrAuctionNotStarted is returned for bids on an auction whose start time wasn't reached yet.
+var ErrAuctionNotStarted = &InternalError{Message: "Auction has not started yet", Err: NotStarted}
+
+// ErrCategoryNotFound is returned when a category slug matches no category.
+var ErrCategoryNotFound = &InternalError{Message: "Category not found", Err: CategoryNotFound}
+
+// NewAuctionNotFoundError rejects a bid on auctionId, an auction that doesn't exist.
+func NewAuctionNotFoundError(auctionId string) *InternalError {
+	return &InternalError{
+		Message: fmt.Sprintf("Auction not found with this id = %s", auctionId),
+		Err:     AuctionNotFound,
+	}
+}
+
+// NewUserNotFoundError rejects a bid from userId, a user that doesn't exist.
+func NewUserNotFoundError(userId string) *InternalError {
+	return &InternalError{
+		Message: fmt.Sprintf("User not found with this id = %s", userId),
+		Err:     UserNotFound,
+	}
+}
+
+// NewCurrencyMismatchError rejects a bid that isn't in currency, the one of its auction.
+func NewCurrencyMismatchError(currency string) *InternalError {
+	return &InternalError{
+		Message: fmt.Sprintf("Bid currency must be %s, the currency of the auction", currency),
+		Err:     CurrencyMismatch,
+	}
+}
+
+// ErrInsufficientBalance is returned when a user's balance doesn't cover an amount.
+var ErrInsufficientBalance = NewBadRequestError("Insufficient balance")
+
+// ErrUserDeleted is returned when a soft-deleted user tries to bid or sell.
+var ErrUserDeleted = NewForbiddenError("User account was deleted")
+
+// ErrSelfBid is returned when a seller bids on their own auction.
+var ErrSelfBid = &InternalError{Message: "Sellers can't bid on their own auctions", Err: SelfBidForbidden}
+
+// ErrBidLimitExceeded is returned when a user already placed the most bids allowed on an auction.
+var ErrBidLimitExceeded = &InternalError{Message: "Too many bids on this auction", Err: BidLimitExceeded}
+
+// ErrBuyNowUnavailable is returned when an auction can't be bought at a buy-now price,
+// e.g. because another buyer got it first.
+var ErrBuyNowUnavailable = &InternalError{Message: "Auction can't be bought now", Err: BuyNowUnavailable}
+
+// ErrAuctionAlreadyRated is returned when the seller of an auction was already rated for it.
+var ErrAuctionAlreadyRated = NewConflictError("Auction was already rated")
+
+// ErrServiceBusy is returned when a bid is refused because the queue of bids waiting to
+// be written is full.
+var ErrServiceBusy = &InternalError{Message: "Too many bids waiting to be written, try again later", Err: ServiceBusy}
@@ -0,0 +1,91 @@
+package internal_error_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWrapKeepsCauseForErrorsIs(t *testing.T) {
+	internalErr := internal_error.NewNotFoundError("Auction not found").Wrap(mongo.ErrNoDocuments)
+
+	if !errors.Is(internalErr, mongo.ErrNoDocuments) {
+		t.Error("Expected the wrapped Mongo error to be reachable through errors.Is")
+	}
+
+	if internalErr.Error() != "Auction not found" {
+		t.Errorf("Expected the message to stay client safe, got %q", internalErr.Error())
+	}
+
+	var target *internal_error.InternalError
+	if !errors.As(error(internalErr), &target) || target.Err != internal_error.NotFound {
+		t.Errorf("Expected errors.As to find the not_found error, got %v", target)
+	}
+}
+
+func TestWrapLeavesSharedErrorsUntouched(t *testing.T) {
+	wrapped := internal_error.ErrAuctionNotActive.Wrap(mongo.ErrNoDocuments)
+
+	if internal_error.ErrAuctionNotActive.Cause != nil {
+		t.Error("Expected Wrap not to modify the shared error")
+	}
+
+	if !errors.Is(wrapped, internal_error.ErrAuctionNotActive) {
+		t.Error("Expected the wrapped error to still match ErrAuctionNotActive")
+	}
+
+	if errors.Is(internal_error.NewConflictError("Other conflict"), internal_error.ErrAuctionNotActive) {
+		t.Error("Expected a different conflict not to match ErrAuctionNotActive")
+	}
+}
+
+func TestStatusCodeMapping(t *testing.T) {
+	testCases := map[internal_error.ErrorCode]int{
+		internal_error.BadRequest:       http.StatusBadRequest,
+		internal_error.NotFound:         http.StatusNotFound,
+		internal_error.NoBids:           http.StatusNotFound,
+		internal_error.CategoryNotFound: http.StatusNotFound,
+		internal_error.Conflict:         http.StatusConflict,
+		internal_error.DuplicateAuction: http.StatusConflict,
+		internal_error.Forbidden:        http.StatusForbidden,
+		internal_error.Unauthorized:     http.StatusUnauthorized,
+		internal_error.Internal:         http.StatusInternalServerError,
+		"unknown":                       http.StatusInternalServerError,
+	}
+
+	for code, status := range testCases {
+		if got := rest_err.StatusCode(code); got != status {
+			t.Errorf("Expected %s to map to %d, got %d", code, status, got)
+		}
+
+		restErr := rest_err.ConvertError(&internal_error.InternalError{Message: "message", Err: code})
+		if restErr.Code != status {
+			t.Errorf("Expected ConvertError(%s) to use status %d, got %d", code, status, restErr.Code)
+		}
+	}
+
+	if restErr := rest_err.ConvertError(internal_error.NewNoBidsError("message")); restErr.Err != "no_bids" {
+		t.Errorf("Expected the no_bids code to reach the client, got %s", restErr.Err)
+	}
+	if restErr := rest_err.ConvertError(internal_error.ErrCategoryNotFound); restErr.Err != "category_not_found" {
+		t.Errorf("Expected the category_not_found code to reach the client, got %s", restErr.Err)
+	}
+	if restErr := rest_err.ConvertError(internal_error.NewNotFoundError("message")); restErr.Err != "not_found" {
+		t.Errorf("Expected plain not found errors to keep the not_found code, got %s", restErr.Err)
+	}
+
+	existingId := uuid.New().String()
+	restErr := rest_err.ConvertError(internal_error.NewDuplicateAuctionError(existingId))
+	if restErr.Err != "duplicate_auction" || restErr.ExistingAuctionId != existingId {
+		t.Errorf("Expected the duplicate_auction code and the existing auction to reach the client, got %+v", restErr)
+	}
+	if restErr := rest_err.ConvertError(internal_error.NewConflictError("message")); restErr.Err != "conflict" {
+		t.Errorf("Expected plain conflicts to keep the conflict code, got %s", restErr.Err)
+	}
+}
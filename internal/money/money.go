@@ -0,0 +1,152 @@
+// Package money keeps monetary amounts as int64 cents, so comparing and adding them is
+// exact, and converts them from and to their decimal representation.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of amounts that don't name one.
+const DefaultCurrency = "BRL"
+
+// SupportedCurrencies are the currencies auctions can be priced in, see
+// IsSupportedCurrency.
+var SupportedCurrencies = []string{"BRL", "USD", "EUR"}
+
+var (
+	ErrInvalidAmount   = errors.New("invalid amount: expected a decimal with at most two decimal places")
+	ErrInvalidCurrency = errors.New("invalid currency: expected a three letter ISO 4217 code")
+
+	amountPattern   = regexp.MustCompile(`^-?\d+(\.\d{1,2})?$`)
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// Parse converts a decimal amount such as "10.50" into cents. More than two decimal
+// places are rejected instead of rounded.
+func Parse(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if !amountPattern.MatchString(value) {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	units, fraction, _ := strings.Cut(value, ".")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+
+	if negative {
+		cents = -cents
+	}
+
+	return cents, nil
+}
+
+// Format renders cents as a decimal amount with two decimal places, e.g. 1050 as "10.50".
+func Format(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// FormatCurrency renders cents like Format, preceded by the currency, e.g. 1050 in USD
+// as "USD 10.50".
+func FormatCurrency(cents int64, currency string) string {
+	return currency + " " + Format(cents)
+}
+
+// FromFloat rounds a float amount, as stored before amounts moved to cents, to cents.
+func FromFloat(value float64) int64 {
+	return int64(math.Round(value * 100))
+}
+
+// ValidateCurrency accepts three letter uppercase currency codes, such as BRL.
+func ValidateCurrency(currency string) error {
+	if !currencyPattern.MatchString(currency) {
+		return ErrInvalidCurrency
+	}
+
+	return nil
+}
+
+// IsSupportedCurrency reports whether currency is one of SupportedCurrencies. Codes are
+// matched exactly, so callers uppercase what users send first.
+func IsSupportedCurrency(currency string) bool {
+	for _, supported := range SupportedCurrencies {
+		if currency == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Decimal is an amount as the client wrote it, a JSON number or a quoted decimal, kept
+// as text so it never goes through a float. Unlike Amount, malformed values decode
+// without error, for the amount binding of the requests to report them along with the
+// other invalid fields; Cents parses them.
+type Decimal string
+
+// Cents parses the decimal with Parse.
+func (d Decimal) Cents() (int64, error) {
+	return Parse(string(d))
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	*d = Decimal(bytes.Trim(data, `"`))
+	return nil
+}
+
+// Amount is an amount in cents that travels in JSON as a plain decimal number, e.g. 10.5
+// or 10.50 for 1050. Quoted decimals ("10.50") are accepted as well.
+type Amount int64
+
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+func (a Amount) String() string {
+	return Format(int64(a))
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(Format(int64(a))), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	cents, err := Parse(string(bytes.Trim(data, `"`)))
+	if err != nil {
+		// encoding/json names the offending field on type errors
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(a).Elem()}
+	}
+
+	*a = Amount(cents)
+	return nil
+}
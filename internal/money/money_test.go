@@ -0,0 +1,115 @@
+package money_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"fullcycle-auction_go/internal/money"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		value     string
+		cents     int64
+		expectErr bool
+	}{
+		{value: "10.50", cents: 1050},
+		{value: "10.5", cents: 1050},
+		{value: "10", cents: 1000},
+		{value: "0.01", cents: 1},
+		{value: "-3.20", cents: -320},
+		{value: " 7.25 ", cents: 725},
+		{value: "10.505", expectErr: true},
+		{value: "1e3", expectErr: true},
+		{value: "abc", expectErr: true},
+		{value: "", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			cents, err := money.Parse(tc.value)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got %d", tc.value, cents)
+				}
+				return
+			}
+
+			if err != nil || cents != tc.cents {
+				t.Errorf("Expected %d cents for %q, got %d (%v)", tc.cents, tc.value, cents, err)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for cents, expected := range map[int64]string{0: "0.00", 5: "0.05", 1050: "10.50", -320: "-3.20"} {
+		if got := money.Format(cents); got != expected {
+			t.Errorf("Expected %q for %d cents, got %q", expected, cents, got)
+		}
+	}
+
+	if got := money.FormatCurrency(1050, "USD"); got != "USD 10.50" {
+		t.Errorf("Expected the currency before the amount, got %q", got)
+	}
+}
+
+func TestIsSupportedCurrency(t *testing.T) {
+	for currency, expected := range map[string]bool{"BRL": true, "USD": true, "EUR": true, "usd": false, "JPY": false, "": false} {
+		if got := money.IsSupportedCurrency(currency); got != expected {
+			t.Errorf("Expected IsSupportedCurrency(%q) to be %v", currency, expected)
+		}
+	}
+}
+
+func TestFromFloatAvoidsRoundingSurprises(t *testing.T) {
+	// 0.1 + 0.2 is 0.30000000000000004 as a float
+	if cents := money.FromFloat(0.1) + money.FromFloat(0.2); cents != 30 {
+		t.Errorf("Expected 30 cents, got %d", cents)
+	}
+}
+
+func TestDecimalKeepsTheTextOfTheAmount(t *testing.T) {
+	var payload struct {
+		Amount money.Decimal `json:"amount"`
+	}
+
+	for body, cents := range map[string]int64{`{"amount":19.99}`: 1999, `{"amount":"0.30"}`: 30, `{"amount":4.35}`: 435} {
+		if err := json.Unmarshal([]byte(body), &payload); err != nil {
+			t.Fatalf("Expected %s to decode, got %v", body, err)
+		}
+		if parsed, err := payload.Amount.Cents(); err != nil || parsed != cents {
+			t.Errorf("Expected %d cents from %s, got %d (%v)", cents, body, parsed, err)
+		}
+	}
+
+	for _, body := range []string{`{"amount":10.555}`, `{"amount":"NaN"}`, `{"amount":true}`} {
+		if err := json.Unmarshal([]byte(body), &payload); err != nil {
+			t.Fatalf("Expected %s to decode, leaving it to the validation, got %v", body, err)
+		}
+		if _, err := payload.Amount.Cents(); err == nil {
+			t.Errorf("Expected %s not to parse", body)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var payload struct {
+		Amount money.Amount `json:"amount"`
+	}
+
+	for _, body := range []string{`{"amount":10.50}`, `{"amount":"10.50"}`, `{"amount":10.5}`} {
+		if err := json.Unmarshal([]byte(body), &payload); err != nil || payload.Amount != 1050 {
+			t.Errorf("Expected 1050 cents from %s, got %d (%v)", body, payload.Amount, err)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":10.501}`), &payload); err == nil {
+		t.Error("Expected an error for more than two decimal places")
+	}
+
+	encoded, _ := json.Marshal(payload)
+	if string(encoded) != `{"amount":10.50}` {
+		t.Errorf("Expected the amount encoded as a decimal number, got %s", encoded)
+	}
+}
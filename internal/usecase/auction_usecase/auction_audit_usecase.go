@@ -0,0 +1,47 @@
+package auction_usecase
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// AuctionAuditOutputDTO is one status change of an auction. Actor is the id of the user
+// who made it, or "system" and "sweeper" for the changes made by the application.
+type AuctionAuditOutputDTO struct {
+	AuctionId  string        `json:"auction_id"`
+	FromStatus AuctionStatus `json:"from_status"`
+	ToStatus   AuctionStatus `json:"to_status"`
+	Actor      string        `json:"actor"`
+	Reason     string        `json:"reason"`
+	Timestamp  time.Time     `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+}
+
+// FindAuditByAuctionId lists the status changes of the auction, oldest first. Unknown
+// auctions are rejected with a not found error rather than an empty log.
+func (au *AuctionUseCase) FindAuditByAuctionId(
+	ctx context.Context, auctionId string) ([]AuctionAuditOutputDTO, *internal_error.InternalError) {
+	if _, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId); err != nil {
+		return nil, err
+	}
+
+	entries, err := au.auctionRepositoryInterface.FindAuditByAuctionId(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	auditOutputs := make([]AuctionAuditOutputDTO, 0, len(entries))
+	for _, entry := range entries {
+		auditOutputs = append(auditOutputs, AuctionAuditOutputDTO{
+			AuctionId:  entry.AuctionId,
+			FromStatus: AuctionStatus(entry.From),
+			ToStatus:   AuctionStatus(entry.To),
+			Actor:      entry.Actor,
+			Reason:     entry.Reason,
+			Timestamp:  entry.Timestamp.UTC(),
+		})
+	}
+
+	return auditOutputs, nil
+}
@@ -0,0 +1,54 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// AuctionFacetsOutputDTO counts the auctions matching a listing filter by category and
+// by condition, most common first, for the filter chips of the listing.
+type AuctionFacetsOutputDTO struct {
+	Categories []CategoryFacetOutputDTO  `json:"categories"`
+	Conditions []ConditionFacetOutputDTO `json:"conditions"`
+}
+
+type CategoryFacetOutputDTO struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+type ConditionFacetOutputDTO struct {
+	Condition ProductCondition `json:"condition"`
+	Count     int64            `json:"count"`
+}
+
+// GetAuctionFacets counts the auctions matching filter, which is validated like the
+// listing's. Its sort doesn't change the counts.
+func (au *AuctionUseCase) GetAuctionFacets(
+	ctx context.Context, filter AuctionFilterInputDTO) (*AuctionFacetsOutputDTO, *internal_error.InternalError) {
+	auctionFilter, err := toAuctionFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := au.auctionRepositoryInterface.GetFacets(ctx, auctionFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	facetsOutput := &AuctionFacetsOutputDTO{
+		Categories: make([]CategoryFacetOutputDTO, 0, len(facets.Categories)),
+		Conditions: make([]ConditionFacetOutputDTO, 0, len(facets.Conditions)),
+	}
+	for _, category := range facets.Categories {
+		facetsOutput.Categories = append(facetsOutput.Categories,
+			CategoryFacetOutputDTO{Category: category.Category, Count: category.Count})
+	}
+	for _, condition := range facets.Conditions {
+		facetsOutput.Conditions = append(facetsOutput.Conditions,
+			ConditionFacetOutputDTO{Condition: ProductCondition(condition.Condition), Count: condition.Count})
+	}
+
+	return facetsOutput, nil
+}
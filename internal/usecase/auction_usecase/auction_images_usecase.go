@@ -0,0 +1,77 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// AuctionImageInputDTO points to a picture hosted elsewhere. Its position in the list it
+// is sent in decides its order.
+type AuctionImageInputDTO struct {
+	URL     string `json:"url"`
+	AltText string `json:"alt"`
+}
+
+type AuctionImageOutputDTO struct {
+	URL     string `json:"url"`
+	Order   int    `json:"order"`
+	AltText string `json:"alt,omitempty"`
+}
+
+// AuctionImagesInputDTO replaces every image of an auction. An empty list removes them.
+// Version works as in AuctionUpdateInputDTO.
+type AuctionImagesInputDTO struct {
+	SellerId string                 `json:"-"`
+	Version  *int64                 `json:"version"`
+	Images   []AuctionImageInputDTO `json:"images" binding:"required"`
+}
+
+// ReplaceAuctionImages sets the images of an Active auction to the ones given, in the
+// order given. Only the seller may change them.
+func (au *AuctionUseCase) ReplaceAuctionImages(
+	ctx context.Context,
+	id string,
+	imagesInput AuctionImagesInputDTO) (*AuctionOutputDTO, *internal_error.InternalError) {
+	images := auction_entity.NumberImages(toAuctionImages(imagesInput.Images))
+	if err := auction_entity.ValidateImages(images); err != nil {
+		return nil, err
+	}
+
+	auctionEntity, err := au.checkSeller(ctx, id, imagesInput.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := checkVersion(auctionEntity, imagesInput.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	auctionEntity, err = au.auctionRepositoryInterface.ReplaceAuctionImages(ctx, id, version, images)
+	if err != nil {
+		return nil, err
+	}
+
+	auctionOutput := au.toAuctionOutputDTO(auctionEntity)
+	return &auctionOutput, nil
+}
+
+func toAuctionImages(imagesInput []AuctionImageInputDTO) []auction_entity.AuctionImage {
+	images := make([]auction_entity.AuctionImage, len(imagesInput))
+	for i, image := range imagesInput {
+		images[i] = auction_entity.AuctionImage{URL: image.URL, AltText: image.AltText}
+	}
+
+	return images
+}
+
+// toAuctionImageOutputDTOs always returns a list, empty for auctions without images.
+func toAuctionImageOutputDTOs(images []auction_entity.AuctionImage) []AuctionImageOutputDTO {
+	output := make([]AuctionImageOutputDTO, len(images))
+	for i, image := range images {
+		output[i] = AuctionImageOutputDTO{URL: image.URL, Order: image.Order, AltText: image.AltText}
+	}
+
+	return output
+}
@@ -0,0 +1,57 @@
+package auction_usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+)
+
+// Conditions and statuses are sent by name in JSON, e.g. "new" or "active". They are
+// read by name in any case or, as the API first took them, by number.
+
+func (c ProductCondition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(auction_entity.ProductCondition(c).String())
+}
+
+// UnmarshalJSON reads anything but a known name or a number as 0, which the binding
+// reports as an invalid condition along with the other invalid fields.
+func (c *ProductCondition) UnmarshalJSON(data []byte) error {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		condition, _ := auction_entity.ParseProductCondition(name)
+		*c = ProductCondition(condition)
+		return nil
+	}
+
+	// number stays 0 when data isn't a whole number either
+	var number int64
+	json.Unmarshal(data, &number)
+
+	*c = ProductCondition(number)
+	return nil
+}
+
+func (s AuctionStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(auction_entity.AuctionStatus(s).String())
+}
+
+func (s *AuctionStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var number int64
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+
+		*s = AuctionStatus(number)
+		return nil
+	}
+
+	status, ok := auction_entity.ParseAuctionStatus(name)
+	if !ok {
+		return fmt.Errorf("unknown auction status %q", name)
+	}
+
+	*s = AuctionStatus(status)
+	return nil
+}
@@ -0,0 +1,56 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+)
+
+// AuctionSummaryOutputDTO is everything an auction card needs in one response: the
+// auction fields plus its bid statistics, all zero while it has no bids, and how many
+// users watch it. The amounts are null while a Vickrey auction hides them; the counts
+// are still given.
+type AuctionSummaryOutputDTO struct {
+	AuctionOutputDTO
+
+	BidCount      int64         `json:"bid_count"`
+	HighestBid    *money.Amount `json:"highest_bid"`
+	LowestBid     *money.Amount `json:"lowest_bid"`
+	AverageBid    *money.Amount `json:"average_bid"`
+	UniqueBidders int64         `json:"unique_bidders"`
+
+	WatchersCount int64 `json:"watchers_count"`
+}
+
+func (au *AuctionUseCase) GetAuctionSummary(
+	ctx context.Context, auctionId string) (*AuctionSummaryOutputDTO, *internal_error.InternalError) {
+	auctionEntity, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := au.bidRepositoryInterface.GetAuctionBidStats(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	watchersCount, err := au.countWatchers(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &AuctionSummaryOutputDTO{
+		AuctionOutputDTO: au.toAuctionOutputDTO(auctionEntity),
+		BidCount:         stats.BidCount,
+		UniqueBidders:    stats.UniqueBidders,
+		WatchersCount:    watchersCount,
+	}
+	if !auctionEntity.BidAmountsHidden() {
+		highestBid, lowestBid, averageBid :=
+			money.Amount(stats.HighestBid), money.Amount(stats.LowestBid), money.Amount(stats.AverageBid)
+		summary.HighestBid, summary.LowestBid, summary.AverageBid = &highestBid, &lowestBid, &averageBid
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,138 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestGetAuctionSummary(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, memory.NewUserRepository(), nil, nil, nil, nil, nil, nil, auction_usecase.Config{})
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	summary, err := auctionUseCase.GetAuctionSummary(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Expected a summary for an auction without bids, got %v", err.Error())
+	}
+	highest, lowest, average := summaryAmounts(t, summary)
+	if summary.Id != auctionEntity.Id || summary.BidCount != 0 || highest != 0 ||
+		lowest != 0 || average != 0 || summary.UniqueBidders != 0 {
+		t.Errorf("Expected zeroed stats, got %+v", summary)
+	}
+
+	alice, bob := uuid.New().String(), uuid.New().String()
+	for _, bid := range []struct {
+		userId string
+		amount int64
+	}{{alice, 1000}, {bob, 2000}, {alice, 3000}, {bob, 6000}} {
+		bidEntity, _ := bid_entity.CreateBid(
+			bid.userId, auctionEntity.Id, bid.amount, money.DefaultCurrency, clock.New())
+		if err := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); err != nil {
+			t.Fatalf("Failed to place bid: %v", err.Error())
+		}
+	}
+
+	summary, err = auctionUseCase.GetAuctionSummary(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err.Error())
+	}
+	highest, lowest, average = summaryAmounts(t, summary)
+	if summary.BidCount != 4 || highest != 6000 || lowest != 1000 ||
+		average != 3000 || summary.UniqueBidders != 2 {
+		t.Errorf("Unexpected stats: %+v", summary)
+	}
+
+	if _, err := auctionUseCase.GetAuctionSummary(ctx, uuid.New().String()); err == nil || err.Err != internal_error.NotFound {
+		t.Errorf("Expected not_found for an unknown auction, got %v", err)
+	}
+}
+
+func TestGetAuctionSummaryHidesVickreyAmountsUntilClosed(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, memory.NewUserRepository(), nil, nil, nil, nil, nil, nil, auction_usecase.Config{})
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if err := auctionEntity.SetAuctionType("vickrey"); err != nil {
+		t.Fatalf("Failed to set the auction type: %v", err.Error())
+	}
+	if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	// Sealed bids don't have to beat each other
+	for _, amount := range []int64{6000, 2000} {
+		bidEntity, _ := bid_entity.CreateBid(
+			uuid.New().String(), auctionEntity.Id, amount, money.DefaultCurrency, clock.New())
+		if err := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); err != nil {
+			t.Fatalf("Failed to place bid: %v", err.Error())
+		}
+	}
+
+	summary, err := auctionUseCase.GetAuctionSummary(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err.Error())
+	}
+	if summary.BidCount != 2 || summary.UniqueBidders != 2 {
+		t.Errorf("Expected the bid counts of an open vickrey auction, got %+v", summary)
+	}
+	if summary.HighestBid != nil || summary.LowestBid != nil || summary.AverageBid != nil ||
+		summary.CurrentHighestAmount != 0 {
+		t.Errorf("Expected no amounts while the vickrey auction is open, got %+v", summary)
+	}
+
+	if err := auctionRepo.CloseAuctionNow(ctx, auctionEntity.Id); err != nil {
+		t.Fatalf("Failed to close auction: %v", err.Error())
+	}
+
+	summary, err = auctionUseCase.GetAuctionSummary(ctx, auctionEntity.Id)
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err.Error())
+	}
+	if highest, lowest, _ := summaryAmounts(t, summary); highest != 6000 || lowest != 2000 {
+		t.Errorf("Expected the amounts once the vickrey auction closed, got %+v", summary)
+	}
+}
+
+// summaryAmounts returns the highest, lowest and average bids of summary, failing when
+// they are hidden.
+func summaryAmounts(
+	t *testing.T, summary *auction_usecase.AuctionSummaryOutputDTO) (money.Amount, money.Amount, money.Amount) {
+	t.Helper()
+
+	if summary.HighestBid == nil || summary.LowestBid == nil || summary.AverageBid == nil {
+		t.Fatalf("Expected the bid amounts, got %+v", summary)
+	}
+
+	return *summary.HighestBid, *summary.LowestBid, *summary.AverageBid
+}
@@ -0,0 +1,84 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+// CancelAuction ends a Scheduled or Active auction without a winner. Only its seller may
+// cancel it, and Completed and already cancelled auctions are rejected with a conflict
+// error. The amount held by the leading bid, if any, goes back to its bidder.
+func (au *AuctionUseCase) CancelAuction(
+	ctx context.Context, id, sellerId string) *internal_error.InternalError {
+	auctionEntity, err := au.checkSeller(ctx, id, sellerId)
+	if err != nil {
+		return err
+	}
+
+	// A Scheduled auction that starts in the meantime fails the status update as a
+	// conflict, and can be cancelled again as an Active one
+	from := auction_entity.Active
+	if auctionEntity.Status == auction_entity.Scheduled {
+		from = auction_entity.Scheduled
+	}
+
+	if err := au.auctionRepositoryInterface.UpdateAuctionStatus(
+		auction_entity.WithAuditActor(ctx, sellerId), id, auctionEntity.Version, from, auction_entity.Cancelled); err != nil {
+		return err
+	}
+
+	auctionEntity.Status = auction_entity.Cancelled
+	auctionEntity.Version++
+	au.eventPublisher.Publish(events.AuctionCancelled, id, au.toAuctionOutputDTO(auctionEntity))
+
+	// Only one cancel gets past the status update, so the hold is released once. The
+	// auction stopped taking bids, so the leading bid can't change anymore
+	leadingBid, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, id)
+	if err != nil || !leadingBid.Reserved || au.userRepositoryInterface == nil {
+		return nil
+	}
+
+	if err := au.userRepositoryInterface.CreditBalance(ctx, leadingBid.UserId, leadingBid.Amount); err != nil {
+		logger.ErrorContext(ctx, "Error trying to release the held bid amount", err,
+			zap.String("auction_id", id), zap.String("bid_id", leadingBid.Id))
+	}
+
+	return nil
+}
+
+// checkSeller returns the auction, failing with a forbidden error unless sellerId is its
+// seller. The seller never changes, so checking before the write is safe.
+func (au *AuctionUseCase) checkSeller(
+	ctx context.Context, id, sellerId string) (*auction_entity.Auction, *internal_error.InternalError) {
+	auctionEntity, err := au.auctionRepositoryInterface.FindAuctionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !auctionEntity.IsOwnedBy(sellerId) {
+		return nil, internal_error.NewForbiddenError("Only the seller can change this auction")
+	}
+
+	return auctionEntity, nil
+}
+
+// checkVersion returns the version a seller's change must apply at: the one the client
+// sent, so a change made on a stale copy fails with ErrAuctionModified, or else the one
+// just read, which still protects the change from racing with another one.
+func checkVersion(
+	auctionEntity *auction_entity.Auction, version *int64) (int64, *internal_error.InternalError) {
+	if version == nil {
+		return auctionEntity.Version, nil
+	}
+
+	if *version != auctionEntity.Version {
+		return 0, internal_error.ErrAuctionModified
+	}
+
+	return *version, nil
+}
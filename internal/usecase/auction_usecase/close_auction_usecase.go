@@ -0,0 +1,65 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"time"
+)
+
+// AuctionCloser closes an auction on demand through the same routine that closes the
+// expired ones, notifying the same listeners. It also keeps the auctions that failed
+// to close. auction.Closer implements it.
+type AuctionCloser interface {
+	CloseAuctionNow(ctx context.Context, id string) *internal_error.InternalError
+	FindCloseFailures(ctx context.Context) ([]auction_entity.CloseFailure, *internal_error.InternalError)
+	RetryClose(ctx context.Context, id string) *internal_error.InternalError
+}
+
+// CloseFailureOutputDTO is an expired auction the closer failed to close, see
+// auction_entity.CloseFailure.
+type CloseFailureOutputDTO struct {
+	AuctionId     string    `json:"auction_id"`
+	Error         string    `json:"error"`
+	Attempts      int64     `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at" time_format:"2006-01-02 15:04:05"`
+	LastFailedAt  time.Time `json:"last_failed_at" time_format:"2006-01-02 15:04:05"`
+	NextRetryAt   time.Time `json:"next_retry_at" time_format:"2006-01-02 15:04:05"`
+}
+
+// CloseAuctionNow ends an Active auction right away, e.g. when the item was sold
+// elsewhere, deciding its outcome and winner as if it had expired. Unknown auctions are
+// rejected with a not found error and those no longer Active with a conflict error. The
+// close is recorded in the audit log against adminId.
+func (au *AuctionUseCase) CloseAuctionNow(ctx context.Context, id, adminId string) *internal_error.InternalError {
+	return au.auctionCloser.CloseAuctionNow(auction_entity.WithAuditActor(ctx, adminId), id)
+}
+
+// FindCloseFailures lists the auctions that failed to close, the longest failing first.
+func (au *AuctionUseCase) FindCloseFailures(ctx context.Context) ([]CloseFailureOutputDTO, *internal_error.InternalError) {
+	failures, err := au.auctionCloser.FindCloseFailures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	failureOutputs := make([]CloseFailureOutputDTO, 0, len(failures))
+	for _, failure := range failures {
+		failureOutputs = append(failureOutputs, CloseFailureOutputDTO{
+			AuctionId:     failure.AuctionId,
+			Error:         failure.Error,
+			Attempts:      failure.Attempts,
+			FirstFailedAt: failure.FirstFailedAt.UTC(),
+			LastFailedAt:  failure.LastFailedAt.UTC(),
+			NextRetryAt:   failure.NextRetryAt.UTC(),
+		})
+	}
+
+	return failureOutputs, nil
+}
+
+// RetryClose closes an auction that failed to close right away, recording the close in
+// its audit log against adminId. Auctions without a recorded failure are rejected with
+// a not found error, and those that no longer need closing with a conflict error.
+func (au *AuctionUseCase) RetryClose(ctx context.Context, id, adminId string) *internal_error.InternalError {
+	return au.auctionCloser.RetryClose(auction_entity.WithAuditActor(ctx, adminId), id)
+}
@@ -0,0 +1,19 @@
+package auction_usecase
+
+import (
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+)
+
+// Config tunes the auction flow. It is loaded once at startup, see the config package;
+// the zero value turns every optional check off and keeps the default bounds.
+type Config struct {
+	// DuplicateWindow makes CreateAuction reject an auction when its seller created an
+	// identical one, with the same product name and category and still Active, less
+	// than DuplicateWindow before. Zero turns the check off
+	DuplicateWindow time.Duration
+
+	// DurationBounds limit the duration and the start time of new auctions
+	DurationBounds auction_entity.DurationBounds
+}
@@ -0,0 +1,70 @@
+package auction_usecase
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// MaxAuctionBatchSize caps how many auctions a single CreateAuctions call takes.
+const MaxAuctionBatchSize = 500
+
+// AuctionBatchResult is the result of one auction of a batch: the id of the auction
+// created or the error that kept it from being created.
+type AuctionBatchResult struct {
+	Id  string
+	Err *internal_error.InternalError
+}
+
+// CreateAuctions lists every auction of the batch for sellerId, validating each one on
+// its own. The results line up with auctionInputs and an auction failing doesn't keep
+// the others from being created; only an oversized batch or an unknown seller fail the
+// whole call.
+func (au *AuctionUseCase) CreateAuctions(
+	ctx context.Context,
+	sellerId string,
+	auctionInputs []AuctionInputDTO) ([]AuctionBatchResult, *internal_error.InternalError) {
+	if len(auctionInputs) > MaxAuctionBatchSize {
+		return nil, internal_error.NewBadRequestError(
+			fmt.Sprintf("A batch takes at most %d auctions", MaxAuctionBatchSize))
+	}
+
+	if err := au.checkSellerExists(ctx, sellerId); err != nil {
+		return nil, err
+	}
+
+	categories, err := au.categoryRepositoryInterface.FindAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]AuctionBatchResult, len(auctionInputs))
+	auctions := make([]*auction_entity.Auction, 0, len(auctionInputs))
+	indexes := make([]int, 0, len(auctionInputs))
+	for i, auctionInput := range auctionInputs {
+		auctionInput.SellerId = sellerId
+		auction, err := newAuction(auctionInput, categories, au.durationBounds, au.clock)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		auctions = append(auctions, auction)
+		indexes = append(indexes, i)
+	}
+
+	// The closer sweeps by end_time, which the repository persists for every auction, so
+	// the created auctions close like any other
+	for j, err := range au.auctionRepositoryInterface.CreateAuctions(ctx, auctions) {
+		if err != nil {
+			results[indexes[j]].Err = err
+			continue
+		}
+
+		results[indexes[j]].Id = auctions[j].Id
+		au.publishAuction(auctions[j])
+	}
+
+	return results, nil
+}
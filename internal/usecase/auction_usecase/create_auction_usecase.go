@@ -2,28 +2,116 @@ package auction_usecase
 
 import (
 	"context"
+	"fullcycle-auction_go/internal/clock"
 	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/entity/watchlist_entity"
+	"fullcycle-auction_go/internal/events"
 	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
+	"math"
+	"strings"
 	"time"
 )
 
 type AuctionInputDTO struct {
-	ProductName string           `json:"product_name" binding:"required,min=1"`
-	Category    string           `json:"category" binding:"required,min=2"`
+	// SellerId is the authenticated user, never read from the request body
+	SellerId    string           `json:"-"`
+	ProductName string           `json:"product_name" binding:"required,min=2"`
+	Category    string           `json:"category" binding:"required,min=3"`
 	Description string           `json:"description" binding:"required,min=10,max=200"`
-	Condition   ProductCondition `json:"condition" binding:"oneof=0 1 2"`
+	Condition   ProductCondition `json:"condition" binding:"product_condition"`
+
+	DurationSeconds int64 `json:"duration_seconds" binding:"omitempty,gt=0"`
+
+	// StartTime schedules the auction to go live later; the duration counts from it.
+	// Omitted, or not in the future, the auction starts right away. Start times further
+	// in the past than the allowed clock skew are rejected
+	StartTime time.Time `json:"start_time"`
+
+	// Currency is the ISO 4217 code of the prices and of every bid on the auction,
+	// money.DefaultCurrency when omitted
+	Currency string `json:"currency"`
+
+	// AuctionType is one of auction_entity.AuctionTypes, english when omitted
+	AuctionType string `json:"auction_type"`
+
+	// Prices are decimals such as 10.50 in JSON, kept in cents
+	StartingPrice money.Amount `json:"starting_price" binding:"omitempty,gte=0"`
+	ReservePrice  money.Amount `json:"reserve_price" binding:"omitempty,gte=0"`
+
+	// BuyNowPrice lets a bid reaching it buy the auction outright, none when omitted
+	BuyNowPrice money.Amount `json:"buy_now_price" binding:"omitempty,gte=0"`
+
+	// Images are numbered in the order they are listed in
+	Images []AuctionImageInputDTO `json:"images"`
 }
 
 type AuctionOutputDTO struct {
 	Id          string           `json:"id"`
+	SellerId    string           `json:"seller_id,omitempty"`
 	ProductName string           `json:"product_name"`
 	Category    string           `json:"category"`
 	Description string           `json:"description"`
 	Condition   ProductCondition `json:"condition"`
 	Status      AuctionStatus    `json:"status"`
 	Timestamp   time.Time        `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	EndTime     time.Time        `json:"end_time" time_format:"2006-01-02 15:04:05"`
+	StartTime   time.Time        `json:"start_time" time_format:"2006-01-02 15:04:05"`
+
+	RemainingSeconds int64 `json:"remaining_seconds"`
+
+	Currency      string         `json:"currency"`
+	AuctionType   string         `json:"auction_type"`
+	StartingPrice money.Amount   `json:"starting_price"`
+	ReservePrice  money.Amount   `json:"reserve_price,omitempty"`
+	BuyNowPrice   money.Amount   `json:"buy_now_price,omitempty"`
+	Outcome       AuctionOutcome `json:"outcome"`
+	ClosedAt      *time.Time     `json:"closed_at,omitempty"`
+
+	// CurrentHighestAmount is left out until the auction gets its first bid, and while a
+	// Vickrey auction hides its bid amounts
+	BidCount             int64        `json:"bid_count"`
+	CurrentHighestAmount money.Amount `json:"current_highest_amount,omitempty"`
+
+	// Revision tags the responses of the auction, see auction_entity.Auction
+	Revision int64 `json:"-"`
+
+	// Version is sent back with updates and image changes, see auction_entity.Auction
+	Version int64 `json:"version"`
+
+	// DeletedAt is only ever set in the admin views that include deleted auctions
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Images []AuctionImageOutputDTO `json:"images"`
+}
+
+// AuctionFilterInputDTO narrows FindAuctions; see auction_entity.AuctionFilter for the
+// meaning of empty fields.
+type AuctionFilterInputDTO struct {
+	SellerId      string
+	Statuses      []AuctionStatus
+	Category      string
+	ProductName   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// Sort is one of AuctionSortOptions; empty sorts newest first
+	Sort string
+}
+
+// AuctionSortOptions are the accepted values of AuctionFilterInputDTO.Sort.
+var AuctionSortOptions = []string{"newest", "oldest", "ending_soon", "most_bids"}
+
+var auctionSorts = map[string]auction_entity.AuctionSort{
+	"":            auction_entity.SortNewest,
+	"newest":      auction_entity.SortNewest,
+	"oldest":      auction_entity.SortOldest,
+	"ending_soon": auction_entity.SortEndingSoon,
+	"most_bids":   auction_entity.SortMostBids,
 }
 
 type WinningInfoOutputDTO struct {
@@ -31,12 +119,53 @@ type WinningInfoOutputDTO struct {
 	Bid     *bid_usecase.BidOutputDTO `json:"bid,omitempty"`
 }
 
+// AuctionPublisher is notified of every auction created by CreateAuction, e.g. to stream
+// it to clients following the auctions live.
+type AuctionPublisher interface {
+	PublishAuction(auction AuctionOutputDTO)
+}
+
+// AuctionPublisherFunc adapts a plain function to AuctionPublisher.
+type AuctionPublisherFunc func(auction AuctionOutputDTO)
+
+func (f AuctionPublisherFunc) PublishAuction(auction AuctionOutputDTO) {
+	f(auction)
+}
+
+// NewAuctionUseCase wires the auction flow. The auction publisher is optional, the
+// auction closer is only needed by CloseAuctionNow, the watchlist repository only by the
+// watchlist, a nil event publisher falls back to events.NoopPublisher and a nil clock
+// uses the real clock.
 func NewAuctionUseCase(
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface,
-	bidRepositoryInterface bid_entity.BidEntityRepository) AuctionUseCaseInterface {
+	bidRepositoryInterface bid_entity.BidEntityRepository,
+	userRepositoryInterface user_entity.UserRepositoryInterface,
+	categoryRepositoryInterface category_entity.CategoryRepositoryInterface,
+	watchlistRepositoryInterface watchlist_entity.WatchlistRepositoryInterface,
+	auctionPublisher AuctionPublisher,
+	auctionCloser AuctionCloser,
+	eventPublisher events.EventPublisher,
+	clk clock.Clock,
+	config Config) AuctionUseCaseInterface {
+	if eventPublisher == nil {
+		eventPublisher = events.NoopPublisher{}
+	}
+	if clk == nil {
+		clk = clock.New()
+	}
+
 	return &AuctionUseCase{
-		auctionRepositoryInterface: auctionRepositoryInterface,
-		bidRepositoryInterface:     bidRepositoryInterface,
+		auctionRepositoryInterface:   auctionRepositoryInterface,
+		bidRepositoryInterface:       bidRepositoryInterface,
+		userRepositoryInterface:      userRepositoryInterface,
+		categoryRepositoryInterface:  categoryRepositoryInterface,
+		watchlistRepositoryInterface: watchlistRepositoryInterface,
+		auctionPublisher:             auctionPublisher,
+		auctionCloser:                auctionCloser,
+		eventPublisher:               eventPublisher,
+		duplicateWindow:              config.DuplicateWindow,
+		durationBounds:               config.DurationBounds,
+		clock:                        clk,
 	}
 }
 
@@ -45,43 +174,295 @@ type AuctionUseCaseInterface interface {
 		ctx context.Context,
 		auctionInput AuctionInputDTO) *internal_error.InternalError
 
+	CreateAuctions(
+		ctx context.Context,
+		sellerId string,
+		auctionInputs []AuctionInputDTO) ([]AuctionBatchResult, *internal_error.InternalError)
+
 	FindAuctionById(
 		ctx context.Context, id string) (*AuctionOutputDTO, *internal_error.InternalError)
 
 	FindAuctions(
 		ctx context.Context,
-		status AuctionStatus,
-		category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError)
+		filter AuctionFilterInputDTO,
+		page, pageSize int) ([]AuctionOutputDTO, int64, *internal_error.InternalError)
+
+	FindAuctionsBySellerId(
+		ctx context.Context,
+		sellerId string,
+		filter AuctionFilterInputDTO,
+		page, pageSize int) ([]AuctionOutputDTO, int64, *internal_error.InternalError)
+
+	FindAuctionsByCategorySlug(
+		ctx context.Context,
+		slug string,
+		filter AuctionFilterInputDTO,
+		page, pageSize int) ([]AuctionOutputDTO, int64, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context,
 		auctionId string) (*WinningInfoOutputDTO, *internal_error.InternalError)
+
+	ExportAuctions(
+		ctx context.Context,
+		filter AuctionFilterInputDTO,
+		fn func(result AuctionResultOutputDTO) error) *internal_error.InternalError
+
+	FindWinnerByAuctionId(
+		ctx context.Context, auctionId string) (*WinnerOutputDTO, *internal_error.InternalError)
+
+	CancelAuction(
+		ctx context.Context, id, sellerId string) *internal_error.InternalError
+
+	CloseAuctionNow(ctx context.Context, id, adminId string) *internal_error.InternalError
+
+	FindCloseFailures(ctx context.Context) ([]CloseFailureOutputDTO, *internal_error.InternalError)
+
+	RetryClose(ctx context.Context, id, adminId string) *internal_error.InternalError
+
+	FindAuditByAuctionId(
+		ctx context.Context, auctionId string) ([]AuctionAuditOutputDTO, *internal_error.InternalError)
+
+	UpdateAuction(
+		ctx context.Context,
+		id string,
+		updateInput AuctionUpdateInputDTO) (*AuctionOutputDTO, *internal_error.InternalError)
+
+	GetAuctionSummary(
+		ctx context.Context, auctionId string) (*AuctionSummaryOutputDTO, *internal_error.InternalError)
+
+	GetAuctionFacets(
+		ctx context.Context, filter AuctionFilterInputDTO) (*AuctionFacetsOutputDTO, *internal_error.InternalError)
+
+	DeleteAuction(ctx context.Context, id string) *internal_error.InternalError
+
+	ReplaceAuctionImages(
+		ctx context.Context,
+		id string,
+		imagesInput AuctionImagesInputDTO) (*AuctionOutputDTO, *internal_error.InternalError)
+
+	WatchAuction(ctx context.Context, userId, auctionId string) *internal_error.InternalError
+
+	UnwatchAuction(ctx context.Context, userId, auctionId string) *internal_error.InternalError
+
+	FindWatchlist(
+		ctx context.Context,
+		userId string,
+		page, pageSize int) ([]WatchedAuctionOutputDTO, int64, *internal_error.InternalError)
 }
 
 type ProductCondition int64
 type AuctionStatus int64
+type AuctionOutcome int64
 
 type AuctionUseCase struct {
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface
 	bidRepositoryInterface     bid_entity.BidEntityRepository
+	userRepositoryInterface    user_entity.UserRepositoryInterface
+
+	categoryRepositoryInterface category_entity.CategoryRepositoryInterface
+	auctionPublisher            AuctionPublisher
+	auctionCloser               AuctionCloser
+
+	// watchlistRepositoryInterface is only needed by the watchlist; without it the
+	// summaries count no watchers
+	watchlistRepositoryInterface watchlist_entity.WatchlistRepositoryInterface
+
+	// eventPublisher sends auction_created and auction_cancelled to the services
+	// outside the process
+	eventPublisher events.EventPublisher
+
+	// duplicateWindow is Config.DuplicateWindow, see checkNotDuplicate
+	duplicateWindow time.Duration
+
+	// durationBounds is Config.DurationBounds, see newAuction
+	durationBounds auction_entity.DurationBounds
+
+	// clock stamps the auctions and watches created and measures the time left
+	clock clock.Clock
 }
 
 func (au *AuctionUseCase) CreateAuction(
 	ctx context.Context,
 	auctionInput AuctionInputDTO) *internal_error.InternalError {
+	categories, err := au.categoryRepositoryInterface.FindAllCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	auction, err := newAuction(auctionInput, categories, au.durationBounds, au.clock)
+	if err != nil {
+		return err
+	}
+
+	if err := au.checkSellerExists(ctx, auction.SellerId); err != nil {
+		return err
+	}
+
+	if err := au.checkNotDuplicate(ctx, auction); err != nil {
+		return err
+	}
+
+	if err := au.auctionRepositoryInterface.CreateAuction(
+		ctx, auction); err != nil {
+		return err
+	}
+
+	au.publishAuction(auction)
+	return nil
+}
+
+// newAuction builds the auction described by auctionInput, created at the time of clk,
+// with its category resolved among categories. The duration is the one of the request,
+// validated by the entity against bounds, else the default of the category; with
+// neither, EndTime stays zero and the repository applies AUCTION_DURATION_SECONDS, or
+// 600 seconds. Either way it ends up persisted as end_time, so changing the category
+// later doesn't move the end of its live auctions.
+func newAuction(
+	auctionInput AuctionInputDTO,
+	categories []category_entity.Category,
+	bounds auction_entity.DurationBounds,
+	clk clock.Clock) (*auction_entity.Auction, *internal_error.InternalError) {
 	auction, err := auction_entity.CreateAuction(
+		auctionInput.SellerId,
 		auctionInput.ProductName,
 		auctionInput.Category,
 		auctionInput.Description,
-		auction_entity.ProductCondition(auctionInput.Condition))
+		auction_entity.ProductCondition(auctionInput.Condition),
+		durationOf(auctionInput.DurationSeconds),
+		bounds,
+		clk)
+
+	// An unknown category is reported along with the other invalid fields, unless the
+	// name is already invalid by itself
+	var category *category_entity.Category
+	var categoryErr *internal_error.InternalError
+	if !err.HasCause("category") {
+		category, categoryErr = matchCategory(categories, auctionInput.Category)
+	}
+	if err != nil || categoryErr != nil {
+		return nil, internal_error.JoinValidationErrors(err, categoryErr)
+	}
+	auction.Category = category.Name
+
+	if auctionInput.DurationSeconds == 0 && category.DefaultDurationSeconds != 0 {
+		auction.EndTime = auction.StartTime.Add(category.DefaultDuration())
+	}
+	if err := auction.ScheduleStart(auctionInput.StartTime, bounds); err != nil {
+		return nil, err
+	}
+
+	if err := auction.SetCurrency(auctionInput.Currency); err != nil {
+		return nil, err
+	}
+
+	if err := auction.SetAuctionType(auctionInput.AuctionType); err != nil {
+		return nil, err
+	}
+
+	if err := auction.SetPrices(auctionInput.StartingPrice.Cents(), auctionInput.ReservePrice.Cents()); err != nil {
+		return nil, err
+	}
+
+	if err := auction.SetBuyNowPrice(auctionInput.BuyNowPrice.Cents()); err != nil {
+		return nil, err
+	}
+
+	if err := auction.SetImages(toAuctionImages(auctionInput.Images)); err != nil {
+		return nil, err
+	}
+
+	return auction, nil
+}
+
+// durationOf converts a duration in seconds, saturating instead of overflowing so the
+// entity rejects a huge one as too long.
+func durationOf(seconds int64) time.Duration {
+	if seconds > int64(math.MaxInt64/time.Second) {
+		return math.MaxInt64
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// checkNotDuplicate catches the double submits of a form, see Config.DuplicateWindow. Two
+// requests racing each other can still both get through, as the check and the insert
+// aren't atomic.
+func (au *AuctionUseCase) checkNotDuplicate(
+	ctx context.Context, auction *auction_entity.Auction) *internal_error.InternalError {
+	if au.duplicateWindow <= 0 {
+		return nil
+	}
+
+	existingId, err := au.auctionRepositoryInterface.FindDuplicateAuction(
+		ctx, auction, auction.Timestamp.Add(-au.duplicateWindow))
 	if err != nil {
 		return err
 	}
+	if existingId != "" {
+		return internal_error.NewDuplicateAuctionError(existingId)
+	}
+
+	return nil
+}
+
+func (au *AuctionUseCase) checkSellerExists(ctx context.Context, sellerId string) *internal_error.InternalError {
+	seller, err := au.userRepositoryInterface.FindUserById(ctx, sellerId)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return internal_error.NewBadRequestError("Seller not found").Wrap(err)
+		}
 
-	if err := au.auctionRepositoryInterface.CreateAuction(
-		ctx, auction); err != nil {
 		return err
 	}
 
+	if seller.IsDeleted() {
+		return internal_error.ErrUserDeleted
+	}
+
 	return nil
 }
+
+func (au *AuctionUseCase) publishAuction(auction *auction_entity.Auction) {
+	auctionOutput := au.toAuctionOutputDTO(auction)
+	if au.auctionPublisher != nil {
+		au.auctionPublisher.PublishAuction(auctionOutput)
+	}
+
+	au.eventPublisher.Publish(events.AuctionCreated, auction.Id, auctionOutput)
+}
+
+// resolveCategory returns the name of the managed category matching name, ignoring case
+// and whitespace, so every auction of a category is stored under the same spelling.
+// Unknown names are rejected, suggesting the categories they are likely a typo of.
+func (au *AuctionUseCase) resolveCategory(
+	ctx context.Context, name string) (string, *internal_error.InternalError) {
+	categories, err := au.categoryRepositoryInterface.FindAllCategories(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	category, err := matchCategory(categories, name)
+	if err != nil {
+		return "", err
+	}
+
+	return category.Name, nil
+}
+
+// matchCategory is resolveCategory among categories already loaded, returning the whole
+// category.
+func matchCategory(
+	categories []category_entity.Category, name string) (*category_entity.Category, *internal_error.InternalError) {
+	category, suggestions := category_entity.Match(categories, name)
+	if category != nil {
+		return category, nil
+	}
+
+	message := "Category " + strings.TrimSpace(name) + " does not exist"
+	if len(suggestions) > 0 {
+		message += ". Did you mean " + strings.Join(suggestions, ", ") + "?"
+	}
+
+	return nil, internal_error.NewValidationError(message, internal_error.FieldError{Field: "category", Message: message})
+}
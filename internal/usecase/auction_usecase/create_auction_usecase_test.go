@@ -0,0 +1,481 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/google/uuid"
+)
+
+func newCategoryRepository(names ...string) *memory.CategoryRepository {
+	repo := memory.NewCategoryRepository()
+	for _, name := range names {
+		categoryEntity, _ := category_entity.CreateCategory(name, auction_entity.DurationBounds{})
+		repo.CreateCategory(context.Background(), categoryEntity)
+	}
+
+	return repo
+}
+
+func TestCreateAuctionValidatesCategory(t *testing.T) {
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+
+	auctionRepo := memory.NewAuctionRepository(nil)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, nil, userRepo, newCategoryRepository("Electronics", "Books"), nil, nil, nil, nil, nil,
+		auction_usecase.Config{})
+
+	testCases := []struct {
+		name             string
+		category         string
+		expectedCategory string
+		suggestion       string
+	}{
+		{name: "Exact name", category: "Electronics", expectedCategory: "Electronics"},
+		{name: "Different case", category: " electronics ", expectedCategory: "Electronics"},
+		{name: "Typo", category: "eletronics", suggestion: "Electronics"},
+		{name: "Unknown", category: "Vehicles"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := auctionUseCase.CreateAuction(context.Background(), auction_usecase.AuctionInputDTO{
+				SellerId:    seller.Id,
+				ProductName: "Test Product",
+				Category:    tc.category,
+				Description: "Test auction description",
+				Condition:   auction_usecase.ProductCondition(auction_entity.New),
+			})
+
+			if tc.expectedCategory != "" {
+				if err != nil {
+					t.Fatalf("Expected the auction to be created, got %v", err.Error())
+				}
+				auctions, _, _ := auctionRepo.FindAuctions(context.Background(), auction_entity.AuctionFilter{}, 1, 100)
+				for _, auction := range auctions {
+					if auction.Category != tc.expectedCategory {
+						t.Errorf("Expected the category to be stored as %s, got %s", tc.expectedCategory, auction.Category)
+					}
+				}
+				return
+			}
+
+			if err == nil || err.Err != internal_error.BadRequest {
+				t.Fatalf("Expected a bad_request error, got %v", err)
+			}
+			if tc.suggestion != "" && !strings.Contains(err.Message, tc.suggestion) {
+				t.Errorf("Expected %s to be suggested, got %q", tc.suggestion, err.Message)
+			}
+			if tc.suggestion == "" && strings.Contains(err.Message, "Did you mean") {
+				t.Errorf("Expected no suggestion, got %q", err.Message)
+			}
+		})
+	}
+}
+
+func TestCreateAuctionReportsEveryInvalidField(t *testing.T) {
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		memory.NewAuctionRepository(nil), nil, userRepo, newCategoryRepository("Electronics"), nil, nil, nil, nil,
+		nil, auction_usecase.Config{})
+
+	testCases := []struct {
+		name          string
+		input         auction_usecase.AuctionInputDTO
+		invalidFields []string
+	}{
+		{
+			name: "Every product field",
+			input: auction_usecase.AuctionInputDTO{
+				ProductName: "A",
+				Category:    "Electronics",
+				Description: "Short",
+			},
+			invalidFields: []string{"product_name", "description", "condition"},
+		},
+		{
+			name: "Unknown category with other fields",
+			input: auction_usecase.AuctionInputDTO{
+				ProductName: "Test Product",
+				Category:    "Vehicles",
+				Description: "   Short   ",
+				Condition:   auction_usecase.ProductCondition(auction_entity.Used),
+			},
+			invalidFields: []string{"description", "category"},
+		},
+		{
+			name: "Category too short to look up",
+			input: auction_usecase.AuctionInputDTO{
+				ProductName: "Test Product",
+				Category:    "El",
+				Description: "Test auction description",
+				Condition:   auction_usecase.ProductCondition(auction_entity.Refurbished),
+			},
+			invalidFields: []string{"category"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.input.SellerId = seller.Id
+			err := auctionUseCase.CreateAuction(context.Background(), tc.input)
+			if err == nil || err.Err != internal_error.BadRequest {
+				t.Fatalf("Expected a bad_request error, got %v", err)
+			}
+
+			var fields []string
+			for _, cause := range err.Causes {
+				fields = append(fields, cause.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tc.invalidFields, ",") {
+				t.Errorf("Expected the invalid fields %v, got %v", tc.invalidFields, fields)
+			}
+		})
+	}
+}
+
+func TestCreateAuctionValidatesPrices(t *testing.T) {
+	testCases := []struct {
+		name          string
+		startingPrice money.Amount
+		reservePrice  money.Amount
+		expectErr     bool
+	}{
+		{name: "No prices", expectErr: false},
+		{name: "Starting price only", startingPrice: 1000, expectErr: false},
+		{name: "Reserve equal to the starting price", startingPrice: 1000, reservePrice: 1000, expectErr: false},
+		{name: "Reserve below the starting price", startingPrice: 1000, reservePrice: 999, expectErr: true},
+		{name: "Negative starting price", startingPrice: -100, expectErr: true},
+		{name: "Negative reserve price", reservePrice: -100, expectErr: true},
+	}
+
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		&auctionRepositoryStub{auctions: map[string]auction_entity.Auction{}}, nil,
+		userRepo, newCategoryRepository("Electronics"), nil, nil, nil, nil, nil, auction_usecase.Config{})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := auctionUseCase.CreateAuction(context.Background(), auction_usecase.AuctionInputDTO{
+				SellerId:      seller.Id,
+				ProductName:   "Test Product",
+				Category:      "Electronics",
+				Description:   "Test auction description",
+				Condition:     auction_usecase.ProductCondition(auction_entity.New),
+				StartingPrice: tc.startingPrice,
+				ReservePrice:  tc.reservePrice,
+			})
+
+			if tc.expectErr && (err == nil || err.Err != internal_error.BadRequest) {
+				t.Errorf("Expected a bad_request error, got %v", err)
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("Expected the auction to be created, got %v", err.Error())
+			}
+		})
+	}
+}
+
+func TestCreateAuctionRequiresExistingSeller(t *testing.T) {
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		&auctionRepositoryStub{auctions: map[string]auction_entity.Auction{}}, nil,
+		memory.NewUserRepository(), newCategoryRepository("Electronics"), nil, nil, nil, nil, nil,
+		auction_usecase.Config{})
+
+	err := auctionUseCase.CreateAuction(context.Background(), auction_usecase.AuctionInputDTO{
+		SellerId:    uuid.New().String(),
+		ProductName: "Test Product",
+		Category:    "Electronics",
+		Description: "Test auction description",
+		Condition:   auction_usecase.ProductCondition(auction_entity.New),
+	})
+	if err == nil || err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad_request error for an unknown seller, got %v", err)
+	}
+}
+
+func TestCreateAuctionsValidatesEachAuction(t *testing.T) {
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, nil, userRepo, newCategoryRepository("Electronics"), nil, nil, nil, nil, clk,
+		auction_usecase.Config{})
+	ctx := context.Background()
+
+	input := func(category string, startingPrice, reservePrice money.Amount) auction_usecase.AuctionInputDTO {
+		return auction_usecase.AuctionInputDTO{
+			ProductName:     "Test Product",
+			Category:        category,
+			Description:     "Test auction description",
+			Condition:       auction_usecase.ProductCondition(auction_entity.New),
+			DurationSeconds: 60,
+			StartingPrice:   startingPrice,
+			ReservePrice:    reservePrice,
+		}
+	}
+
+	results, err := auctionUseCase.CreateAuctions(ctx, seller.Id, []auction_usecase.AuctionInputDTO{
+		input("Electronics", 0, 0),
+		input("Vehicles", 0, 0),
+		input("electronics", 1000, 999),
+		input("electronics", 1000, 0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create auctions: %v", err.Error())
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("Expected one result per auction, got %d", len(results))
+	}
+	for i, expectCreated := range []bool{true, false, false, true} {
+		if expectCreated && (results[i].Err != nil || results[i].Id == "") {
+			t.Errorf("Expected auction %d to be created, got %+v", i, results[i])
+		}
+		if !expectCreated && (results[i].Err == nil || results[i].Err.Err != internal_error.BadRequest || results[i].Id != "") {
+			t.Errorf("Expected auction %d to fail with a bad_request error, got %+v", i, results[i])
+		}
+	}
+
+	// The created auctions are closed by the sweep like any other
+	clk.Advance(2 * time.Minute)
+	closedIds, err := auctionRepo.CloseExpiredAuctions(ctx)
+	if err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+	if len(closedIds) != 2 {
+		t.Errorf("Expected the 2 created auctions to close, got %d", len(closedIds))
+	}
+
+	if _, err := auctionUseCase.CreateAuctions(ctx, uuid.New().String(), []auction_usecase.AuctionInputDTO{
+		input("Electronics", 0, 0),
+	}); err == nil || err.Err != internal_error.BadRequest {
+		t.Errorf("Expected a bad_request error for an unknown seller, got %v", err)
+	}
+}
+
+func TestCreateAuctionResolvesDuration(t *testing.T) {
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+
+	categoryRepo := newCategoryRepository("Electronics")
+	flashSales, err := category_entity.CreateCategory("Flash Sales", auction_entity.DurationBounds{}, 120)
+	if err != nil {
+		t.Fatalf("Failed to create category entity: %v", err.Error())
+	}
+	categoryRepo.CreateCategory(context.Background(), flashSales)
+
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		memory.NewAuctionRepository(nil), nil, userRepo, categoryRepo, nil, nil, nil, nil, nil,
+		auction_usecase.Config{})
+	ctx := context.Background()
+
+	testCases := []struct {
+		name            string
+		category        string
+		durationSeconds int64
+		expected        time.Duration
+		invalid         string
+	}{
+		{name: "Request value", category: "Flash Sales", durationSeconds: 3600, expected: time.Hour},
+		{name: "Category default", category: "Flash Sales", expected: 2 * time.Minute},
+		{name: "Global default", category: "Electronics", expected: 10 * time.Minute},
+		{name: "Too short", category: "Electronics", durationSeconds: 29, invalid: "at least 30 seconds"},
+		{name: "Too long", category: "Electronics", durationSeconds: 30*24*3600 + 1, invalid: "at most 2592000 seconds"},
+		{name: "Overflowing", category: "Electronics", durationSeconds: math.MaxInt64, invalid: "at most 2592000 seconds"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			productName := "Product " + uuid.New().String()
+			err := auctionUseCase.CreateAuction(ctx, auction_usecase.AuctionInputDTO{
+				SellerId:        seller.Id,
+				ProductName:     productName,
+				Category:        tc.category,
+				Description:     "Test auction description",
+				Condition:       auction_usecase.ProductCondition(auction_entity.New),
+				DurationSeconds: tc.durationSeconds,
+			})
+
+			if tc.invalid != "" {
+				if err == nil || !err.HasCause("duration_seconds") || !strings.Contains(err.Causes[0].Message, tc.invalid) {
+					t.Fatalf("Expected duration_seconds to be rejected with %q, got %+v", tc.invalid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create auction: %v", err.Error())
+			}
+
+			auctions, _, err := auctionUseCase.FindAuctions(
+				ctx, auction_usecase.AuctionFilterInputDTO{ProductName: productName}, 1, 10)
+			if err != nil || len(auctions) != 1 {
+				t.Fatalf("Expected to find the auction, got %+v, %v", auctions, err)
+			}
+			if duration := auctions[0].EndTime.Sub(auctions[0].StartTime); duration != tc.expected {
+				t.Errorf("Expected a duration of %v, got %v", tc.expected, duration)
+			}
+		})
+	}
+
+	if _, err := category_entity.CreateCategory("Real Estate", auction_entity.DurationBounds{}, 10); err == nil || !err.HasCause("default_duration_seconds") {
+		t.Errorf("Expected a default duration below the minimum to be rejected, got %v", err)
+	}
+}
+
+func TestCreateAuctionValidatesStartTimeAndDurationBounds(t *testing.T) {
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+
+	// Bounds as read from the configuration, the default skew of a minute is kept
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		memory.NewAuctionRepository(nil), nil, userRepo, newCategoryRepository("Electronics"), nil, nil, nil, nil,
+		nil, auction_usecase.Config{DurationBounds: auction_entity.DurationBounds{Min: time.Minute, Max: time.Hour}})
+	ctx := context.Background()
+
+	now := time.Now()
+	testCases := []struct {
+		name            string
+		startTime       time.Time
+		durationSeconds int64
+		status          auction_usecase.AuctionStatus
+		field           string
+		invalid         string
+	}{
+		{name: "No start time", durationSeconds: 60, status: auction_usecase.AuctionStatus(auction_entity.Active)},
+		{name: "Within the skew", startTime: now.Add(-30 * time.Second), durationSeconds: 60,
+			status: auction_usecase.AuctionStatus(auction_entity.Active)},
+		{name: "Future start", startTime: now.Add(time.Hour), durationSeconds: 3600,
+			status: auction_usecase.AuctionStatus(auction_entity.Scheduled)},
+		{name: "Past start", startTime: now.Add(-10 * time.Minute), durationSeconds: 60,
+			field: "start_time", invalid: "more than 60 seconds in the past"},
+		{name: "Too short", durationSeconds: 59, field: "duration_seconds", invalid: "at least 60 seconds"},
+		{name: "Too long", durationSeconds: 3601, field: "duration_seconds", invalid: "at most 3600 seconds"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			productName := "Product " + uuid.New().String()
+			err := auctionUseCase.CreateAuction(ctx, auction_usecase.AuctionInputDTO{
+				SellerId:        seller.Id,
+				ProductName:     productName,
+				Category:        "Electronics",
+				Description:     "Test auction description",
+				Condition:       auction_usecase.ProductCondition(auction_entity.New),
+				DurationSeconds: tc.durationSeconds,
+				StartTime:       tc.startTime,
+			})
+
+			if tc.invalid != "" {
+				if err == nil || err.Err != internal_error.BadRequest || !err.HasCause(tc.field) ||
+					!strings.Contains(err.Causes[0].Message, tc.invalid) {
+					t.Fatalf("Expected %s to be rejected with %q, got %+v", tc.field, tc.invalid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create auction: %v", err.Error())
+			}
+
+			auctions, _, err := auctionUseCase.FindAuctions(
+				ctx, auction_usecase.AuctionFilterInputDTO{ProductName: productName}, 1, 10)
+			if err != nil || len(auctions) != 1 {
+				t.Fatalf("Expected to find the auction, got %+v, %v", auctions, err)
+			}
+			if auctions[0].Status != tc.status || !auctions[0].EndTime.After(auctions[0].StartTime) {
+				t.Errorf("Expected a %v auction ending after its start, got %+v", tc.status, auctions[0])
+			}
+		})
+	}
+}
+
+func TestCreateAuctionRejectsDuplicatesWithinTheWindow(t *testing.T) {
+	userRepo := memory.NewUserRepository()
+	seller, _ := user_entity.CreateUser("Seller", "seller@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), seller)
+	otherSeller, _ := user_entity.CreateUser("Other Seller", "other@example.com", "secret-password")
+	userRepo.CreateUser(context.Background(), otherSeller)
+
+	auctionRepo := memory.NewAuctionRepository(nil)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, nil, userRepo, newCategoryRepository("Electronics", "Books"), nil, nil, nil, nil, nil,
+		auction_usecase.Config{})
+	ctx := context.Background()
+
+	input := func(sellerId, productName, category string) auction_usecase.AuctionInputDTO {
+		return auction_usecase.AuctionInputDTO{
+			SellerId:    sellerId,
+			ProductName: productName,
+			Category:    category,
+			Description: "Test auction description",
+			Condition:   auction_usecase.ProductCondition(auction_entity.New),
+		}
+	}
+
+	// Off by default
+	for i := 0; i < 2; i++ {
+		if err := auctionUseCase.CreateAuction(ctx, input(seller.Id, "Camera", "Electronics")); err != nil {
+			t.Fatalf("Expected duplicates to be allowed without a window, got %v", err.Error())
+		}
+	}
+
+	auctionUseCase = auction_usecase.NewAuctionUseCase(
+		auctionRepo, nil, userRepo, newCategoryRepository("Electronics", "Books"), nil, nil, nil, nil, nil,
+		auction_usecase.Config{DuplicateWindow: time.Minute})
+
+	if err := auctionUseCase.CreateAuction(ctx, input(seller.Id, "Phone", "Electronics")); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+	auctions, _, _ := auctionRepo.FindAuctions(ctx, auction_entity.AuctionFilter{ProductName: "Phone"}, 1, 10)
+	if len(auctions) != 1 {
+		t.Fatalf("Expected to find the auction, got %+v", auctions)
+	}
+
+	err := auctionUseCase.CreateAuction(ctx, input(seller.Id, "Phone", " electronics "))
+	if err == nil || err.Err != internal_error.DuplicateAuction || err.ExistingAuctionId != auctions[0].Id {
+		t.Fatalf("Expected a duplicate of %s, got %+v", auctions[0].Id, err)
+	}
+
+	for _, other := range []auction_usecase.AuctionInputDTO{
+		input(seller.Id, "Phone case", "Electronics"),
+		input(seller.Id, "Phone", "Books"),
+		input(otherSeller.Id, "Phone", "Electronics"),
+	} {
+		if err := auctionUseCase.CreateAuction(ctx, other); err != nil {
+			t.Errorf("Expected %+v not to be a duplicate, got %v", other, err.Error())
+		}
+	}
+
+	if err := auctionRepo.UpdateAuctionStatus(
+		ctx, auctions[0].Id, auctions[0].Version, auction_entity.Active, auction_entity.Cancelled); err != nil {
+		t.Fatalf("Failed to cancel auction: %v", err.Error())
+	}
+	if err := auctionUseCase.CreateAuction(ctx, input(seller.Id, "Phone", "Electronics")); err != nil {
+		t.Errorf("Expected a cancelled auction not to count as a duplicate, got %v", err.Error())
+	}
+}
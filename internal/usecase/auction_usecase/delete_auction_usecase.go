@@ -0,0 +1,14 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// DeleteAuction hides the auction from every listing and stops it from taking bids. The
+// auction and its bids are kept, so it is a soft delete meant for admins taking down
+// fraudulent listings.
+func (au *AuctionUseCase) DeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	return au.auctionRepositoryInterface.SoftDeleteAuction(ctx, id)
+}
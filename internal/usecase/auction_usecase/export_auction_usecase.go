@@ -0,0 +1,62 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"time"
+)
+
+// AuctionResultOutputDTO is one auction of an export along with its winner. The winner
+// fields are empty for auctions that have none: open, cancelled or closed without a sale.
+type AuctionResultOutputDTO struct {
+	AuctionId     string
+	ProductName   string
+	Category      string
+	CreatedAt     time.Time
+	ClosedAt      *time.Time
+	WinningAmount *money.Amount
+	WinnerId      string
+
+	// Currency is the one of the auction, which its winning amount is in
+	Currency string
+}
+
+// ExportAuctions calls fn with the result of every auction matching filter, streamed
+// from the repository one auction at a time. Auctions closed before winner snapshots
+// were recorded have their winning bid looked up.
+func (au *AuctionUseCase) ExportAuctions(
+	ctx context.Context,
+	filter AuctionFilterInputDTO,
+	fn func(result AuctionResultOutputDTO) error) *internal_error.InternalError {
+	auctionFilter, err := toAuctionFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	return au.auctionRepositoryInterface.StreamAuctions(ctx, auctionFilter, func(auction auction_entity.Auction) error {
+		result := AuctionResultOutputDTO{
+			AuctionId:   auction.Id,
+			ProductName: auction.ProductName,
+			Category:    auction.Category,
+			CreatedAt:   auction.Timestamp,
+			ClosedAt:    auction.ClosedAt,
+			Currency:    auction.Currency,
+		}
+
+		if auction.Status == auction_entity.Completed && auction.Outcome != auction_entity.ReserveNotMet {
+			winningBid, err := au.findWinningBid(ctx, &auction)
+			if err != nil && err.Err != internal_error.NotFound {
+				return err
+			}
+			if winningBid != nil {
+				amount := money.Amount(winningBid.Amount)
+				result.WinningAmount = &amount
+				result.WinnerId = winningBid.UserId
+			}
+		}
+
+		return fn(result)
+	})
+}
@@ -2,10 +2,17 @@ package auction_usecase
 
 import (
 	"context"
+	"fmt"
 	"fullcycle-auction_go/configuration/logger"
 	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
 	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
 	"fullcycle-auction_go/internal/usecase/bid_usecase"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
 )
 
 func (au *AuctionUseCase) FindAuctionById(
@@ -15,41 +22,87 @@ func (au *AuctionUseCase) FindAuctionById(
 		return nil, err
 	}
 
-	return &AuctionOutputDTO{
-		Id:          auctionEntity.Id,
-		ProductName: auctionEntity.ProductName,
-		Category:    auctionEntity.Category,
-		Description: auctionEntity.Description,
-		Condition:   ProductCondition(auctionEntity.Condition),
-		Status:      AuctionStatus(auctionEntity.Status),
-		Timestamp:   auctionEntity.Timestamp,
-	}, nil
+	auctionOutputDTO := au.toAuctionOutputDTO(auctionEntity)
+	return &auctionOutputDTO, nil
 }
 
 func (au *AuctionUseCase) FindAuctions(
 	ctx context.Context,
-	status AuctionStatus,
-	category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError) {
-	auctionEntities, err := au.auctionRepositoryInterface.FindAuctions(
-		ctx, auction_entity.AuctionStatus(status), category, productName)
+	filter AuctionFilterInputDTO,
+	page, pageSize int) ([]AuctionOutputDTO, int64, *internal_error.InternalError) {
+	auctionFilter, err := toAuctionFilter(filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	auctionEntities, total, err := au.auctionRepositoryInterface.FindAuctions(ctx, auctionFilter, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	auctionOutputs := make([]AuctionOutputDTO, 0, len(auctionEntities))
+	for i := range auctionEntities {
+		auctionOutputs = append(auctionOutputs, au.toAuctionOutputDTO(&auctionEntities[i]))
+	}
+
+	return auctionOutputs, total, nil
+}
+
+// toAuctionFilter validates the sort option of filter and converts it to the repository filter.
+func toAuctionFilter(filter AuctionFilterInputDTO) (auction_entity.AuctionFilter, *internal_error.InternalError) {
+	auctionSort, ok := auctionSorts[filter.Sort]
+	if !ok {
+		message := "sort must be one of " + strings.Join(AuctionSortOptions, ", ")
+		return auction_entity.AuctionFilter{}, internal_error.NewValidationError("Invalid sort option",
+			internal_error.FieldError{Field: "sort", Message: message})
+	}
+
+	statuses := make([]auction_entity.AuctionStatus, 0, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, auction_entity.AuctionStatus(status))
+	}
+
+	return auction_entity.AuctionFilter{
+		SellerId:      filter.SellerId,
+		Statuses:      statuses,
+		Category:      filter.Category,
+		ProductName:   filter.ProductName,
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		Sort:          auctionSort,
+	}, nil
+}
+
+// FindAuctionsBySellerId lists the auctions of an existing seller, narrowed by filter
+// like FindAuctions.
+func (au *AuctionUseCase) FindAuctionsBySellerId(
+	ctx context.Context,
+	sellerId string,
+	filter AuctionFilterInputDTO,
+	page, pageSize int) ([]AuctionOutputDTO, int64, *internal_error.InternalError) {
+	if _, err := au.userRepositoryInterface.FindUserById(ctx, sellerId); err != nil {
+		return nil, 0, err
 	}
 
-	var auctionOutputs []AuctionOutputDTO
-	for _, value := range auctionEntities {
-		auctionOutputs = append(auctionOutputs, AuctionOutputDTO{
-			Id:          value.Id,
-			ProductName: value.ProductName,
-			Category:    value.Category,
-			Description: value.Description,
-			Condition:   ProductCondition(value.Condition),
-			Status:      AuctionStatus(value.Status),
-			Timestamp:   value.Timestamp,
-		})
+	filter.SellerId = sellerId
+	return au.FindAuctions(ctx, filter, page, pageSize)
+}
+
+// FindAuctionsByCategorySlug lists the auctions of the category with slug, current or
+// previous, narrowed by filter like FindAuctions. It returns
+// internal_error.ErrCategoryNotFound when no category has the slug.
+func (au *AuctionUseCase) FindAuctionsByCategorySlug(
+	ctx context.Context,
+	slug string,
+	filter AuctionFilterInputDTO,
+	page, pageSize int) ([]AuctionOutputDTO, int64, *internal_error.InternalError) {
+	category, err := au.categoryRepositoryInterface.FindCategoryBySlug(ctx, slug)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return auctionOutputs, nil
+	filter.Category = category.Name
+	return au.FindAuctions(ctx, filter, page, pageSize)
 }
 
 func (au *AuctionUseCase) FindWinningBidByAuctionId(
@@ -60,19 +113,16 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		return nil, err
 	}
 
-	auctionOutputDTO := AuctionOutputDTO{
-		Id:          auction.Id,
-		ProductName: auction.ProductName,
-		Category:    auction.Category,
-		Description: auction.Description,
-		Condition:   ProductCondition(auction.Condition),
-		Status:      AuctionStatus(auction.Status),
-		Timestamp:   auction.Timestamp,
+	auctionOutputDTO := au.toAuctionOutputDTO(auction)
+
+	// Telling who leads a Vickrey auction would give its amounts away
+	if auction.BidAmountsHidden() {
+		return &WinningInfoOutputDTO{Auction: auctionOutputDTO}, nil
 	}
 
-	bidWinning, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auction.Id)
+	bidWinning, err := au.findWinningBid(ctx, auction)
 	if err != nil {
-		logger.Error("", err)
+		logger.ErrorContext(ctx, "Error trying to find the auction winner", err, zap.String("auction_id", auction.Id))
 		return &WinningInfoOutputDTO{
 			Auction: auctionOutputDTO,
 			Bid:     nil,
@@ -83,8 +133,9 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		Id:        bidWinning.Id,
 		UserId:    bidWinning.UserId,
 		AuctionId: bidWinning.AuctionId,
-		Amount:    bidWinning.Amount,
-		Timestamp: bidWinning.Timestamp,
+		Amount:    money.Amount(bidWinning.Amount),
+		Currency:  bidWinning.Currency,
+		Timestamp: bidWinning.Timestamp.UTC(),
 	}
 
 	return &WinningInfoOutputDTO{
@@ -92,3 +143,90 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		Bid:     bidOutputDTO,
 	}, nil
 }
+
+// findWinningBid returns the winning bid of the auction. Closed auctions answer from the
+// snapshot taken when they closed, with a not found error if they closed without bids;
+// the others, and auctions closed before snapshots were recorded, ask the bid repository.
+func (au *AuctionUseCase) findWinningBid(
+	ctx context.Context, auction *auction_entity.Auction) (*bid_entity.Bid, *internal_error.InternalError) {
+	if auction.Status != auction_entity.Completed || auction.ClosedAt == nil {
+		return au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auction.Id)
+	}
+
+	if auction.WinningBid == nil {
+		return nil, internal_error.NewNotFoundError(fmt.Sprintf("No bids found for auctionId %s", auction.Id))
+	}
+
+	return &bid_entity.Bid{
+		Id:        auction.WinningBid.BidId,
+		UserId:    auction.WinningBid.UserId,
+		AuctionId: auction.Id,
+		Amount:    auction.WinningBid.Amount,
+		Currency:  auction.WinningBid.Currency,
+		Timestamp: auction.WinningBid.Timestamp,
+	}, nil
+}
+
+func (au *AuctionUseCase) toAuctionOutputDTO(auctionEntity *auction_entity.Auction) AuctionOutputDTO {
+	currentHighestAmount := auctionEntity.CurrentHighestAmount
+	if auctionEntity.BidAmountsHidden() {
+		currentHighestAmount = 0
+	}
+
+	return AuctionOutputDTO{
+		Id:          auctionEntity.Id,
+		SellerId:    auctionEntity.SellerId,
+		ProductName: auctionEntity.ProductName,
+		Category:    auctionEntity.Category,
+		Description: auctionEntity.Description,
+		Condition:   ProductCondition(auctionEntity.Condition),
+		Status:      AuctionStatus(auctionEntity.Status),
+		Timestamp:   auctionEntity.Timestamp.UTC(),
+		EndTime:     auctionEntity.EndTime.UTC(),
+		StartTime:   auctionEntity.StartTime.UTC(),
+
+		RemainingSeconds: remainingSeconds(auctionEntity, au.clock.Now()),
+
+		Currency:      auctionEntity.Currency,
+		AuctionType:   string(auctionEntity.AuctionType),
+		StartingPrice: money.Amount(auctionEntity.StartingPrice),
+		ReservePrice:  money.Amount(auctionEntity.ReservePrice),
+		BuyNowPrice:   money.Amount(auctionEntity.BuyNowPrice),
+		Outcome:       AuctionOutcome(auctionEntity.Outcome),
+		ClosedAt:      utcTime(auctionEntity.ClosedAt),
+		DeletedAt:     utcTime(auctionEntity.DeletedAt),
+
+		BidCount:             auctionEntity.BidCount,
+		CurrentHighestAmount: money.Amount(currentHighestAmount),
+		Revision:             auctionEntity.Revision,
+		Version:              auctionEntity.Version,
+
+		Images: toAuctionImageOutputDTOs(auctionEntity.Images),
+	}
+}
+
+// remainingSeconds counts down to the end time of Scheduled and Active auctions. It is
+// zero once the auction is no longer active or its end time has passed, even if the
+// closer has not swept it yet.
+func remainingSeconds(auctionEntity *auction_entity.Auction, now time.Time) int64 {
+	if auctionEntity.Status != auction_entity.Active && auctionEntity.Status != auction_entity.Scheduled {
+		return 0
+	}
+
+	remaining := int64(auctionEntity.EndTime.Sub(now) / time.Second)
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+// utcTime returns t in UTC, or nil when t is nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
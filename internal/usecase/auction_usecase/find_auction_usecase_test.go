@@ -0,0 +1,203 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+)
+
+// auctionRepositoryStub serves the auctions it was built with.
+type auctionRepositoryStub struct {
+	auctions map[string]auction_entity.Auction
+}
+
+func (s *auctionRepositoryStub) CreateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionRepositoryStub) CreateAuctions(
+	ctx context.Context, auctionEntities []*auction_entity.Auction) []*internal_error.InternalError {
+	return make([]*internal_error.InternalError, len(auctionEntities))
+}
+
+func (s *auctionRepositoryStub) FindAuctions(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter,
+	page, pageSize int) ([]auction_entity.Auction, int64, *internal_error.InternalError) {
+	auctions := make([]auction_entity.Auction, 0, len(s.auctions))
+	for _, auction := range s.auctions {
+		auctions = append(auctions, auction)
+	}
+	return auctions, int64(len(auctions)), nil
+}
+
+func (s *auctionRepositoryStub) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	auction, ok := s.auctions[id]
+	if !ok {
+		return nil, internal_error.NewNotFoundError("Auction not found")
+	}
+	return &auction, nil
+}
+
+func (s *auctionRepositoryStub) StreamAuctions(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter,
+	fn func(auction auction_entity.Auction) error) *internal_error.InternalError {
+	for _, auction := range s.auctions {
+		if err := fn(auction); err != nil {
+			return internal_error.NewInternalServerError("Error streaming auctions").Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (s *auctionRepositoryStub) UpdateAuctionStatus(
+	ctx context.Context, id string, version int64, from, to auction_entity.AuctionStatus) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionRepositoryStub) FindAuditByAuctionId(
+	ctx context.Context, auctionId string) ([]auction_entity.AuctionAudit, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionRepositoryStub) AggregateTopSellers(
+	ctx context.Context, from, to time.Time, limit int) ([]auction_entity.TopSeller, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionRepositoryStub) GetFacets(
+	ctx context.Context,
+	filter auction_entity.AuctionFilter) (*auction_entity.AuctionFacets, *internal_error.InternalError) {
+	return &auction_entity.AuctionFacets{}, nil
+}
+
+func (s *auctionRepositoryStub) RenameCategory(
+	ctx context.Context, from, to string) (int64, *internal_error.InternalError) {
+	return 0, nil
+}
+
+func (s *auctionRepositoryStub) CountAuctionsByStatus(
+	ctx context.Context) (map[auction_entity.AuctionStatus]int64, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *auctionRepositoryStub) FindDuplicateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	since time.Time) (string, *internal_error.InternalError) {
+	return "", nil
+}
+
+func (s *auctionRepositoryStub) UpdateAuction(
+	ctx context.Context,
+	id string,
+	version int64,
+	update auction_entity.AuctionUpdate) (*auction_entity.Auction, *internal_error.InternalError) {
+	auction, ok := s.auctions[id]
+	if !ok {
+		return nil, internal_error.NewNotFoundError("Auction not found")
+	}
+	return &auction, nil
+}
+
+func (s *auctionRepositoryStub) ExtendAuctionEndTime(
+	ctx context.Context,
+	id string,
+	window, extension, maxExtension time.Duration) (time.Time, bool, *internal_error.InternalError) {
+	return time.Time{}, false, nil
+}
+
+func (s *auctionRepositoryStub) SoftDeleteAuction(
+	ctx context.Context, id string) *internal_error.InternalError {
+	return nil
+}
+
+func (s *auctionRepositoryStub) ReplaceAuctionImages(
+	ctx context.Context, id string, version int64, images []auction_entity.AuctionImage) (*auction_entity.Auction, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func TestAuctionOutputRemainingSeconds(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		auction  auction_entity.Auction
+		minValue int64
+		maxValue int64
+	}{
+		{
+			name: "active auction counts down",
+			auction: auction_entity.Auction{
+				Id: "active", Status: auction_entity.Active,
+				Timestamp: now, EndTime: now.Add(90 * time.Second),
+			},
+			minValue: 85,
+			maxValue: 90,
+		},
+		{
+			name: "active auction past its end time is not negative",
+			auction: auction_entity.Auction{
+				Id: "expired", Status: auction_entity.Active,
+				Timestamp: now.Add(-2 * time.Minute), EndTime: now.Add(-time.Minute),
+			},
+		},
+		{
+			name: "completed auction has nothing left",
+			auction: auction_entity.Auction{
+				Id: "completed", Status: auction_entity.Completed,
+				Timestamp: now, EndTime: now.Add(time.Minute),
+			},
+		},
+		{
+			name: "cancelled auction has nothing left",
+			auction: auction_entity.Auction{
+				Id: "cancelled", Status: auction_entity.Cancelled,
+				Timestamp: now, EndTime: now.Add(time.Minute),
+			},
+		},
+	}
+
+	stub := &auctionRepositoryStub{auctions: map[string]auction_entity.Auction{}}
+	for _, tc := range testCases {
+		stub.auctions[tc.auction.Id] = tc.auction
+	}
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		stub, nil, nil, nil, nil, nil, nil, nil, nil, auction_usecase.Config{})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := auctionUseCase.FindAuctionById(context.Background(), tc.auction.Id)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err.Error())
+			}
+
+			if output.RemainingSeconds < tc.minValue || output.RemainingSeconds > tc.maxValue {
+				t.Errorf("Expected remaining_seconds in [%d, %d], got %d",
+					tc.minValue, tc.maxValue, output.RemainingSeconds)
+			}
+
+			if !output.EndTime.Equal(tc.auction.EndTime) {
+				t.Errorf("Expected end_time %v, got %v", tc.auction.EndTime, output.EndTime)
+			}
+		})
+	}
+
+	outputs, _, err := auctionUseCase.FindAuctions(
+		context.Background(), auction_usecase.AuctionFilterInputDTO{}, 1, 20)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err.Error())
+	}
+	for _, output := range outputs {
+		if output.RemainingSeconds < 0 || (output.Status != auction_usecase.AuctionStatus(auction_entity.Active) && output.RemainingSeconds != 0) {
+			t.Errorf("Unexpected remaining_seconds %d for auction %s", output.RemainingSeconds, output.Id)
+		}
+	}
+}
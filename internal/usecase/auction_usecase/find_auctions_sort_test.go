@@ -0,0 +1,93 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestFindAuctionsSorts(t *testing.T) {
+	auctionRepo := memory.NewAuctionRepository(nil)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, nil, nil, nil, nil, nil, nil, nil, auction_usecase.Config{})
+	ctx := context.Background()
+
+	// Created an hour apart, oldest first, with durations making the newest end first
+	// and the bid counts in neither order
+	now := time.Now()
+	bidCounts := []int{1, 3, 0}
+	ids := make([]string, len(bidCounts))
+	for i, bidCount := range bidCounts {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, 0,
+			auction_entity.DurationBounds{}, clock.New())
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		auctionEntity.Timestamp = now.Add(time.Duration(i-3) * time.Hour)
+		auctionEntity.EndTime = auctionEntity.Timestamp.Add(time.Duration(10-3*i) * time.Hour)
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+		ids[i] = auctionEntity.Id
+
+		for amount := 1; amount <= bidCount; amount++ {
+			bidEntity, _ := bid_entity.CreateBid(
+				uuid.New().String(), auctionEntity.Id, int64(amount*100), money.DefaultCurrency, clock.New())
+			if err := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); err != nil {
+				t.Fatalf("Failed to place bid: %v", err.Error())
+			}
+		}
+	}
+
+	testCases := []struct {
+		sort     string
+		expected []string
+	}{
+		{sort: "", expected: []string{ids[2], ids[1], ids[0]}},
+		{sort: "newest", expected: []string{ids[2], ids[1], ids[0]}},
+		{sort: "oldest", expected: []string{ids[0], ids[1], ids[2]}},
+		{sort: "ending_soon", expected: []string{ids[2], ids[1], ids[0]}},
+		{sort: "most_bids", expected: []string{ids[1], ids[0], ids[2]}},
+	}
+
+	for _, tc := range testCases {
+		t.Run("Sort "+tc.sort, func(t *testing.T) {
+			auctions, _, err := auctionUseCase.FindAuctions(
+				ctx, auction_usecase.AuctionFilterInputDTO{Sort: tc.sort}, 1, 10)
+			if err != nil {
+				t.Fatalf("Failed to find auctions: %v", err.Error())
+			}
+
+			var got []string
+			for _, auction := range auctions {
+				got = append(got, auction.Id)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	_, _, err := auctionUseCase.FindAuctions(ctx, auction_usecase.AuctionFilterInputDTO{Sort: "cheapest"}, 1, 10)
+	if err == nil || err.Err != internal_error.BadRequest || len(err.Causes) != 1 || err.Causes[0].Field != "sort" {
+		t.Fatalf("Expected a bad_request error on the sort field, got %v", err)
+	}
+	for _, option := range auction_usecase.AuctionSortOptions {
+		if !strings.Contains(err.Causes[0].Message, option) {
+			t.Errorf("Expected %s among the allowed options, got %q", option, err.Causes[0].Message)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+
+	"go.uber.org/zap"
+)
+
+// WinnerOutputDTO is the winning bid of a closed auction together with its bidder.
+// BidderName is null when the bidder's user no longer exists. SettlementAmount is what
+// the winner pays: the Amount, except in Vickrey auctions, see
+// auction_entity.Auction.SettlementFor.
+type WinnerOutputDTO struct {
+	AuctionId        string       `json:"auction_id"`
+	BidId            string       `json:"bid_id"`
+	Amount           money.Amount `json:"amount"`
+	SettlementAmount money.Amount `json:"settlement_amount"`
+	Currency         string       `json:"currency"`
+	BidderId         string       `json:"bidder_id"`
+	BidderName       *string      `json:"bidder_name"`
+
+	// BidderDeleted is set when the winner has since deleted their account
+	BidderDeleted bool `json:"bidder_deleted,omitempty"`
+}
+
+var (
+	ErrAuctionStillActive = internal_error.NewConflictError("Auction is still active, there is no winner yet")
+	ErrAuctionNotStarted  = internal_error.NewConflictError("Auction has not started yet, there is no winner")
+	ErrAuctionCancelled   = internal_error.NewConflictError("Auction was cancelled, there is no winner")
+	ErrReserveNotMet      = internal_error.NewConflictError("Auction closed below its reserve price, there is no winner")
+)
+
+// FindWinnerByAuctionId returns the winner of a closed auction. Auctions without a
+// winner fail with a conflict, or with a no_bids error when nobody bid at all.
+func (au *AuctionUseCase) FindWinnerByAuctionId(
+	ctx context.Context, auctionId string) (*WinnerOutputDTO, *internal_error.InternalError) {
+	auction, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case auction.Status == auction_entity.Scheduled:
+		return nil, ErrAuctionNotStarted
+	case auction.Status == auction_entity.Active:
+		return nil, ErrAuctionStillActive
+	case auction.Status == auction_entity.Cancelled:
+		return nil, ErrAuctionCancelled
+	case auction.Outcome == auction_entity.ReserveNotMet:
+		return nil, ErrReserveNotMet
+	}
+
+	winningBid, err := au.findWinningBid(ctx, auction)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return nil, internal_error.NewNoBidsError("Auction closed without bids").Wrap(err)
+		}
+
+		return nil, err
+	}
+
+	// Winners looked up from the bids, closed before the snapshots, paid their bid
+	settlementAmount := winningBid.Amount
+	if auction.WinningBid != nil && auction.WinningBid.SettlementAmount > 0 {
+		settlementAmount = auction.WinningBid.SettlementAmount
+	}
+
+	winner := &WinnerOutputDTO{
+		AuctionId:        auction.Id,
+		BidId:            winningBid.Id,
+		Amount:           money.Amount(winningBid.Amount),
+		SettlementAmount: money.Amount(settlementAmount),
+		Currency:         winningBid.Currency,
+		BidderId:         winningBid.UserId,
+	}
+
+	user, err := au.userRepositoryInterface.FindUserById(ctx, winningBid.UserId)
+	if err != nil {
+		if err.Err != internal_error.NotFound {
+			return nil, err
+		}
+
+		logger.InfoContext(ctx, "Auction winner user no longer exists",
+			zap.String("auction_id", auction.Id), zap.String("user_id", winningBid.UserId))
+		return winner, nil
+	}
+
+	winner.BidderName = &user.Name
+	winner.BidderDeleted = user.IsDeleted()
+	return winner, nil
+}
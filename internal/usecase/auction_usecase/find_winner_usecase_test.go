@@ -0,0 +1,162 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/auction_usecase"
+
+	"github.com/google/uuid"
+)
+
+func TestFindWinnerByAuctionId(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	userRepo := memory.NewUserRepository()
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, bidRepo, userRepo, nil, nil, nil, nil, nil, clk, auction_usecase.Config{})
+	ctx := context.Background()
+
+	newAuction := func() *auction_entity.Auction {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Minute,
+			auction_entity.DurationBounds{}, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+		return auctionEntity
+	}
+	placeBid := func(auctionId, userId string, amount int64) {
+		bidEntity, err := bid_entity.CreateBid(userId, auctionId, amount, money.DefaultCurrency, clk)
+		if err != nil {
+			t.Fatalf("Failed to create bid entity: %v", err.Error())
+		}
+		if err := bidRepo.CreateBidIfAuctionActive(ctx, bidEntity); err != nil {
+			t.Fatalf("Failed to place bid: %v", err.Error())
+		}
+	}
+
+	winner, err := user_entity.CreateUser("Winner", "winner@example.com", "secret-password")
+	if err != nil {
+		t.Fatalf("Failed to create user entity: %v", err.Error())
+	}
+	if err := userRepo.CreateUser(ctx, winner); err != nil {
+		t.Fatalf("Failed to create user: %v", err.Error())
+	}
+
+	withWinner := newAuction()
+	placeBid(withWinner.Id, winner.Id, 1000)
+	withDeletedWinner := newAuction()
+	deletedUserId := uuid.New().String()
+	placeBid(withDeletedWinner.Id, deletedUserId, 2000)
+	withoutBids := newAuction()
+
+	if _, err := auctionUseCase.FindWinnerByAuctionId(ctx, withWinner.Id); !errors.Is(err, auction_usecase.ErrAuctionStillActive) {
+		t.Errorf("Expected an active auction to have no winner yet, got %v", err)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	output, err := auctionUseCase.FindWinnerByAuctionId(ctx, withWinner.Id)
+	if err != nil {
+		t.Fatalf("Failed to find winner: %v", err.Error())
+	}
+	if output.BidderId != winner.Id || output.BidderName == nil || *output.BidderName != "Winner" || output.Amount != 1000 {
+		t.Errorf("Unexpected winner: %+v", output)
+	}
+
+	output, err = auctionUseCase.FindWinnerByAuctionId(ctx, withDeletedWinner.Id)
+	if err != nil {
+		t.Fatalf("Expected a partial winner when the user is missing, got %v", err.Error())
+	}
+	if output.BidderId != deletedUserId || output.BidderName != nil {
+		t.Errorf("Expected the bidder id without a name, got %+v", output)
+	}
+
+	if _, err := auctionUseCase.FindWinnerByAuctionId(ctx, withoutBids.Id); err == nil || err.Err != internal_error.NoBids {
+		t.Errorf("Expected a no_bids error, got %v", err)
+	}
+}
+
+// tamperedBidRepository answers FindWinningBidByAuctionId with a bid that never won,
+// standing in for bids changed after the auction closed.
+type tamperedBidRepository struct {
+	bid_entity.BidEntityRepository
+}
+
+func (tamperedBidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	return &bid_entity.Bid{
+		Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionId, Amount: 999999,
+	}, nil
+}
+
+func TestFindWinningBidReadsCloseSnapshot(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	auctionRepo := memory.NewAuctionRepository(clk)
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	auctionUseCase := auction_usecase.NewAuctionUseCase(
+		auctionRepo, tamperedBidRepository{}, memory.NewUserRepository(), nil, nil, nil, nil, nil, clk,
+		auction_usecase.Config{})
+	ctx := context.Background()
+
+	newAuction := func() *auction_entity.Auction {
+		auctionEntity, err := auction_entity.CreateAuction(
+			uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Minute,
+			auction_entity.DurationBounds{}, clk)
+		if err != nil {
+			t.Fatalf("Failed to create auction entity: %v", err.Error())
+		}
+		if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+			t.Fatalf("Failed to create auction: %v", err.Error())
+		}
+		return auctionEntity
+	}
+
+	withBids := newAuction()
+	winningBid, _ := bid_entity.CreateBid(uuid.New().String(), withBids.Id, 1500, money.DefaultCurrency, clk)
+	if err := bidRepo.CreateBidIfAuctionActive(ctx, winningBid); err != nil {
+		t.Fatalf("Failed to place bid: %v", err.Error())
+	}
+	withoutBids := newAuction()
+
+	clk.Advance(2 * time.Minute)
+	if _, err := auctionRepo.CloseExpiredAuctions(ctx); err != nil {
+		t.Fatalf("Failed to close auctions: %v", err.Error())
+	}
+
+	output, err := auctionUseCase.FindWinningBidByAuctionId(ctx, withBids.Id)
+	if err != nil {
+		t.Fatalf("Failed to find winning bid: %v", err.Error())
+	}
+	if output.Bid == nil || output.Bid.Id != winningBid.Id || output.Bid.UserId != winningBid.UserId || output.Bid.Amount != 1500 {
+		t.Errorf("Expected the winning bid recorded at close, got %+v", output.Bid)
+	}
+	if output.Auction.ClosedAt == nil {
+		t.Error("Expected closed_at to be set")
+	}
+
+	output, err = auctionUseCase.FindWinningBidByAuctionId(ctx, withoutBids.Id)
+	if err != nil {
+		t.Fatalf("Failed to find winning bid: %v", err.Error())
+	}
+	if output.Bid != nil || output.Auction.Outcome != auction_usecase.AuctionOutcome(auction_entity.NoBids) {
+		t.Errorf("Expected no winning bid and a no_bids outcome, got %+v", output)
+	}
+}
@@ -0,0 +1,61 @@
+package auction_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// AuctionUpdateInputDTO lists the fields a seller can fix before the first bid. Omitted
+// fields keep their current value. Version, when sent, is the version of the auction the
+// changes were made on; see checkVersion.
+type AuctionUpdateInputDTO struct {
+	SellerId    string  `json:"-"`
+	Version     *int64  `json:"version"`
+	ProductName *string `json:"product_name" binding:"omitempty,min=2,max=100"`
+	Category    *string `json:"category" binding:"omitempty,min=3,max=50"`
+	Description *string `json:"description" binding:"omitempty,min=10,max=200"`
+}
+
+// UpdateAuction changes the product fields of an Active auction that has no bids yet.
+// Otherwise it returns a conflict error. Only the seller may update the auction.
+func (au *AuctionUseCase) UpdateAuction(
+	ctx context.Context,
+	id string,
+	updateInput AuctionUpdateInputDTO) (*AuctionOutputDTO, *internal_error.InternalError) {
+	update := auction_entity.AuctionUpdate{
+		ProductName: updateInput.ProductName,
+		Category:    updateInput.Category,
+		Description: updateInput.Description,
+	}
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	auctionEntity, err := au.checkSeller(ctx, id, updateInput.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := checkVersion(auctionEntity, updateInput.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Category != nil {
+		category, err := au.resolveCategory(ctx, *update.Category)
+		if err != nil {
+			return nil, err
+		}
+		update.Category = &category
+	}
+
+	auctionEntity, err = au.auctionRepositoryInterface.UpdateAuction(ctx, id, version, update)
+	if err != nil {
+		return nil, err
+	}
+
+	auctionOutput := au.toAuctionOutputDTO(auctionEntity)
+	return &auctionOutput, nil
+}
@@ -0,0 +1,113 @@
+package auction_usecase
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/watchlist_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+)
+
+// WatchedAuctionOutputDTO is an auction of a watchlist along with when the user started
+// watching it.
+type WatchedAuctionOutputDTO struct {
+	AuctionOutputDTO
+
+	WatchedAt time.Time `json:"watched_at"`
+}
+
+// WatchAuction adds the auction to the watchlist of the user. Watching an auction already
+// watched keeps it where it was in the watchlist.
+func (au *AuctionUseCase) WatchAuction(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	if _, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId); err != nil {
+		return err
+	}
+
+	return au.watchlistRepositoryInterface.Add(ctx, watchlist_entity.CreateWatch(userId, auctionId, au.clock))
+}
+
+// UnwatchAuction removes the auction from the watchlist of the user, if it is there.
+func (au *AuctionUseCase) UnwatchAuction(
+	ctx context.Context, userId, auctionId string) *internal_error.InternalError {
+	return au.watchlistRepositoryInterface.Remove(ctx, userId, auctionId)
+}
+
+// FindWatchlist returns a page of the auctions the user watches, the most recently
+// watched first, and how many the user watches. Auctions deleted since they were watched
+// are left out of the page but still counted.
+func (au *AuctionUseCase) FindWatchlist(
+	ctx context.Context,
+	userId string,
+	page, pageSize int) ([]WatchedAuctionOutputDTO, int64, *internal_error.InternalError) {
+	watches, total, err := au.watchlistRepositoryInterface.FindByUserId(ctx, userId, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	watched := make([]WatchedAuctionOutputDTO, 0, len(watches))
+	for _, watch := range watches {
+		auctionEntity, err := au.auctionRepositoryInterface.FindAuctionById(ctx, watch.AuctionId)
+		if err != nil {
+			if err.Err == internal_error.NotFound {
+				continue
+			}
+
+			return nil, 0, err
+		}
+
+		watched = append(watched, WatchedAuctionOutputDTO{
+			AuctionOutputDTO: au.toAuctionOutputDTO(auctionEntity),
+			WatchedAt:        watch.Timestamp.UTC(),
+		})
+	}
+
+	return watched, total, nil
+}
+
+// countWatchers is how many users watch the auction, 0 without a watchlist.
+func (au *AuctionUseCase) countWatchers(
+	ctx context.Context, auctionId string) (int64, *internal_error.InternalError) {
+	if au.watchlistRepositoryInterface == nil {
+		return 0, nil
+	}
+
+	return au.watchlistRepositoryInterface.CountByAuctionId(ctx, auctionId)
+}
+
+// WatchersNotifier listens to the auction closer and tells the notifier about each user
+// watching an auction that closed.
+type WatchersNotifier struct {
+	watchlistRepositoryInterface watchlist_entity.WatchlistRepositoryInterface
+	notifier                     bid_usecase.Notifier
+}
+
+func NewWatchersNotifier(
+	watchlistRepositoryInterface watchlist_entity.WatchlistRepositoryInterface,
+	notifier bid_usecase.Notifier) *WatchersNotifier {
+	return &WatchersNotifier{
+		watchlistRepositoryInterface: watchlistRepositoryInterface,
+		notifier:                     notifier,
+	}
+}
+
+// AuctionsClosed looks the watchers up in its own goroutine, as the closer calls its
+// listeners in turn and waits for them.
+func (wn *WatchersNotifier) AuctionsClosed(closed []events.AuctionClosedEvent) {
+	go func() {
+		ctx := context.Background()
+
+		for _, auction := range closed {
+			userIds, err := wn.watchlistRepositoryInterface.FindUserIdsByAuctionId(ctx, auction.AuctionId)
+			if err != nil {
+				continue
+			}
+
+			for _, userId := range userIds {
+				wn.notifier.NotifyWatchedAuctionClosed(ctx, userId, auction)
+			}
+		}
+	}()
+}
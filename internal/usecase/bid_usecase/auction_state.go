@@ -0,0 +1,105 @@
+package bid_usecase
+
+import (
+	"time"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/money"
+)
+
+// CreateBidOutputDTO is the answer to CreateBid: the bid along with the state of its
+// auction right after the bid was accepted, so clients don't have to fetch it again.
+// The state is provisional, worked out from the auction as read before the bid rather
+// than in the transaction writing it: the bids written in the meantime by other
+// requests or instances aren't seen, see withAuctionState.
+type CreateBidOutputDTO struct {
+	BidOutputDTO
+
+	// IsWinning is false when the bid was outbid as soon as it was placed, by a proxy
+	// bid answering it or by a higher bid accepted before it. Both it and
+	// CurrentHighestAmount are left out for the sealed bids of Vickrey auctions
+	IsWinning            *bool        `json:"is_winning,omitempty"`
+	CurrentHighestAmount money.Amount `json:"current_highest_amount,omitempty"`
+	BidCount             int64        `json:"bid_count"`
+	AuctionEndsAt        time.Time    `json:"auction_ends_at"`
+
+	// BoughtNow is set when the bid reached the buy-now price and closed the auction
+	BoughtNow bool `json:"bought_now,omitempty"`
+}
+
+// withAuctionState adds the state of auctionEntity, as read before the bid, to bid. The
+// state adds placed, the bids the request just wrote, and the other bids still queued to
+// the ones stored when auctionEntity was read. A bid answering a retry places nothing,
+// it was already stored or queued.
+//
+// The caller moves the end of auctionEntity on a soft close extension, so AuctionEndsAt
+// includes it. The bid stays accepted when the auction couldn't be read; its state is
+// left empty. The state of an auction hiding its bid amounts only has the count and the
+// end.
+func (bu *BidUseCase) withAuctionState(
+	bid BidOutputDTO, auctionEntity *auction_entity.Auction, placed []bid_entity.Bid) *CreateBidOutputDTO {
+	output := &CreateBidOutputDTO{BidOutputDTO: bid}
+	amount := bid.Amount.Cents()
+
+	var storedHighest int64
+	hidden := false
+	if auctionEntity != nil {
+		output.BidCount = auctionEntity.BidCount
+		output.AuctionEndsAt = auctionEntity.EndTime.UTC()
+		storedHighest = auctionEntity.CurrentHighestAmount
+		hidden = auctionEntity.BidAmountsHidden()
+	}
+
+	highest := storedHighest
+	if amount > highest {
+		highest = amount
+	}
+
+	queued, outbid := false, false
+	addBid := func(pending *bid_entity.Bid) {
+		output.BidCount++
+		if pending.Amount > highest {
+			highest = pending.Amount
+		}
+
+		// Of two equal amounts, the repository keeps the one inserted first
+		if pending.Id == bid.Id {
+			queued = true
+		} else if pending.Amount > amount ||
+			(pending.Amount == amount && pending.Timestamp.Before(bid.Timestamp)) {
+			outbid = true
+		}
+	}
+
+	// The placed bids may have been written since, but not before the auction was read
+	for i := range placed {
+		addBid(&placed[i])
+	}
+
+	bu.pendingMu.Lock()
+	for _, pendingBid := range bu.pendingBids {
+		if pendingBid.bid.AuctionId != bid.AuctionId || (len(placed) > 0 && pendingBid.bid.Id == placed[0].Id) {
+			continue
+		}
+
+		for pending := &pendingBid.bid; pending != nil; pending = pending.CounterBid {
+			addBid(pending)
+		}
+	}
+	bu.pendingMu.Unlock()
+
+	if hidden {
+		return output
+	}
+
+	// A queued bid has to beat the stored highest, which a stored bid may be itself
+	isWinning := !outbid && amount >= storedHighest
+	if queued {
+		isWinning = !outbid && amount > storedHighest
+	}
+
+	output.IsWinning = &isWinning
+	output.CurrentHighestAmount = money.Amount(highest)
+	return output
+}
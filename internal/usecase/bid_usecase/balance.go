@@ -0,0 +1,59 @@
+package bid_usecase
+
+import (
+	"context"
+
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// Values of BID_BALANCE_MODE, which decides how bids are checked against the balance of
+// the bidder.
+const (
+	// BalanceVerify rejects bids above the balance without holding anything, so bids on
+	// different auctions may add up to more than the balance
+	BalanceVerify = "verify"
+
+	// BalanceReserve also holds the amount of each leading bid from the balance until it
+	// is outbid, see bid.BidRepository.ReserveBalances
+	BalanceReserve = "reserve"
+
+	// BalanceOff accepts bids whatever the balance
+	BalanceOff = "off"
+)
+
+// checkBidder rejects a bid of amount cents from a user that doesn't exist, with a
+// user_not_found error, from a deleted user or one the bidder can't cover. The amount held by their own leading bid counts as available, since raising it
+// releases the hold.
+func (bu *BidUseCase) checkBidder(
+	ctx context.Context, userId string, amount int64, leadingBid *bid_entity.Bid) *internal_error.InternalError {
+	if bu.userRepository == nil {
+		return nil
+	}
+
+	userEntity, err := bu.userRepository.FindUserById(ctx, userId)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return internal_error.NewUserNotFoundError(userId).Wrap(err)
+		}
+
+		return err
+	}
+
+	if userEntity.IsDeleted() {
+		return internal_error.ErrUserDeleted
+	}
+	if bu.balanceMode == BalanceOff {
+		return nil
+	}
+
+	available := userEntity.Balance
+	if leadingBid != nil && leadingBid.UserId == userId && leadingBid.Reserved {
+		available += leadingBid.Amount
+	}
+	if available < amount {
+		return internal_error.ErrInsufficientBalance
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/google/uuid"
+)
+
+// newWallet registers a user with balance cents in userRepo.
+func newWallet(t *testing.T, userRepo *memory.UserRepository, balance int64) string {
+	t.Helper()
+
+	userEntity, err := user_entity.CreateUser("Bidder", uuid.New().String()+"@example.com", "secret-password")
+	if err != nil {
+		t.Fatalf("Failed to create user entity: %v", err.Error())
+	}
+	if err := userRepo.CreateUser(context.Background(), userEntity); err != nil {
+		t.Fatalf("Failed to create user: %v", err.Error())
+	}
+	if err := userRepo.CreditBalance(context.Background(), userEntity.Id, balance); err != nil {
+		t.Fatalf("Failed to credit balance: %v", err.Error())
+	}
+
+	return userEntity.Id
+}
+
+func newActiveAuction(t *testing.T, auctionRepo *memory.AuctionRepository) string {
+	t.Helper()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if err := auctionRepo.CreateAuction(context.Background(), auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	return auctionEntity.Id
+}
+
+func balanceOf(t *testing.T, userRepo *memory.UserRepository, userId string) int64 {
+	t.Helper()
+
+	userEntity, err := userRepo.FindUserById(context.Background(), userId)
+	if err != nil {
+		t.Fatalf("Failed to find user: %v", err.Error())
+	}
+
+	return userEntity.Balance
+}
+
+func TestCreateBidChecksBalance(t *testing.T) {
+	config := bid_usecase.DefaultConfig()
+	config.BalanceMode = bid_usecase.BalanceVerify
+
+	auctionRepo := memory.NewAuctionRepository(clock.NewFake(time.Now()))
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	userRepo := memory.NewUserRepository()
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, userRepo, nil, nil, nil, nil, config)
+	defer bidUseCase.Shutdown(context.Background())
+
+	ctx := context.Background()
+	userId := newWallet(t, userRepo, 500)
+	auctionId := newActiveAuction(t, auctionRepo)
+
+	_, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{UserId: userId, AuctionId: auctionId, Amount: 600})
+	if err != internal_error.ErrInsufficientBalance {
+		t.Errorf("Expected a bid above the balance to be rejected, got %v", err)
+	}
+
+	if err := bidUseCase.CreateProxyBid(ctx, userId, auctionId, 600); err != internal_error.ErrInsufficientBalance {
+		t.Errorf("Expected a maximum above the balance to be rejected, got %v", err)
+	}
+
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{UserId: userId, AuctionId: auctionId, Amount: 500}); err != nil {
+		t.Fatalf("Expected a bid covered by the balance to be accepted, got %v", err.Error())
+	}
+	bidUseCase.Flush(ctx)
+
+	// Verifying holds nothing
+	if balance := balanceOf(t, userRepo, userId); balance != 500 {
+		t.Errorf("Expected the balance to stay at 500, got %d", balance)
+	}
+}
+
+func TestConcurrentBidsNeverOvercommitReservedBalance(t *testing.T) {
+	config := bid_usecase.DefaultConfig()
+	config.BalanceMode = bid_usecase.BalanceReserve
+	config.MinIncrement = 1
+
+	auctionRepo := memory.NewAuctionRepository(clock.NewFake(time.Now()))
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	userRepo := memory.NewUserRepository()
+	bidRepo.ReserveBalances(userRepo)
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, userRepo, nil, nil, nil, nil, config)
+	defer bidUseCase.Shutdown(context.Background())
+
+	ctx := context.Background()
+	userId := newWallet(t, userRepo, 1000)
+
+	// 20 bids of 1.00 on different auctions race for a balance of 10.00. Each one passes
+	// the up-front check alone, so only the holds taken when they are stored keep them
+	// from adding up to more than the balance
+	auctionIds := make([]string, 20)
+	for i := range auctionIds {
+		auctionIds[i] = newActiveAuction(t, auctionRepo)
+	}
+
+	var wg sync.WaitGroup
+	for _, auctionId := range auctionIds {
+		wg.Add(1)
+		go func(auctionId string) {
+			defer wg.Done()
+
+			bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{UserId: userId, AuctionId: auctionId, Amount: 100})
+		}(auctionId)
+	}
+	wg.Wait()
+	bidUseCase.Flush(ctx)
+
+	var leading []string
+	for _, auctionId := range auctionIds {
+		if bids, _ := bidRepo.FindBidByAuctionId(ctx, auctionId, bid_entity.BidListFilter{}); len(bids) > 0 {
+			leading = append(leading, auctionId)
+		}
+	}
+	if len(leading) != 10 {
+		t.Fatalf("Expected 10 bids stored, got %d", len(leading))
+	}
+	if balance := balanceOf(t, userRepo, userId); balance != 0 {
+		t.Fatalf("Expected the whole balance held, got %d", balance)
+	}
+
+	// Being outbid releases the hold
+	rivalId := newWallet(t, userRepo, 1000)
+	if _, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: rivalId, AuctionId: leading[0], Amount: 200}); err != nil {
+		t.Fatalf("Failed to outbid: %v", err.Error())
+	}
+	bidUseCase.Flush(ctx)
+
+	if balance := balanceOf(t, userRepo, userId); balance != 100 {
+		t.Errorf("Expected the outbid amount back, got %d", balance)
+	}
+	if balance := balanceOf(t, userRepo, rivalId); balance != 800 {
+		t.Errorf("Expected the rival's bid held, got %d", balance)
+	}
+}
@@ -0,0 +1,55 @@
+package bid_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+// checkBidLimit rejects the bid once its user placed maxBidsPerUser bids on the auction,
+// counting the stored ones and those still queued, to stop a runaway client. Bids of
+// the same user accepted concurrently may go past the limit by a few.
+func (bu *BidUseCase) checkBidLimit(
+	ctx context.Context, bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	if bu.maxBidsPerUser <= 0 {
+		return nil
+	}
+
+	stored, err := bu.BidRepository.CountUserBids(ctx, bidEntity.AuctionId, bidEntity.UserId)
+	if err != nil {
+		return err
+	}
+
+	if stored+bu.countPending(bidEntity.AuctionId, bidEntity.UserId) >= int64(bu.maxBidsPerUser) {
+		logger.WarnContext(ctx, "Bid rejected, user reached the bid limit",
+			zap.String("auction_id", bidEntity.AuctionId), zap.String("user_id", bidEntity.UserId))
+		return internal_error.ErrBidLimitExceeded
+	}
+
+	return nil
+}
+
+// countPending counts the bids of userId on auctionId queued but not written yet,
+// counter bids included. There are at most a few batches of them.
+func (bu *BidUseCase) countPending(auctionId, userId string) int64 {
+	bu.pendingMu.Lock()
+	defer bu.pendingMu.Unlock()
+
+	var count int64
+	for key, pending := range bu.pendingBids {
+		if key.auctionId != auctionId {
+			continue
+		}
+
+		for bid := &pending.bid; bid != nil; bid = bid.CounterBid {
+			if bid.UserId == userId {
+				count++
+			}
+		}
+	}
+
+	return count
+}
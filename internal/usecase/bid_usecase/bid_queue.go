@@ -0,0 +1,19 @@
+package bid_usecase
+
+// BidQueueOutputDTO is the state of the queue of bids waiting to be written. Depth
+// includes the batch being written, so it may go past Capacity by up to a batch.
+type BidQueueOutputDTO struct {
+	Depth    int64 `json:"depth"`
+	Capacity int   `json:"capacity"`
+	Dropped  int64 `json:"dropped"`
+}
+
+// QueueStats reports how many bids wait to be written, and how many were refused with
+// ErrServiceBusy since startup because the queue was full.
+func (bu *BidUseCase) QueueStats() BidQueueOutputDTO {
+	return BidQueueOutputDTO{
+		Depth:    bu.queueDepth.Load(),
+		Capacity: cap(bu.bidChannel),
+		Dropped:  bu.droppedBids.Load(),
+	}
+}
@@ -0,0 +1,140 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/google/uuid"
+)
+
+// slowBidRepository stands in for a database that stopped keeping up: each batch write
+// waits until release is closed, or sent a value.
+type slowBidRepository struct {
+	*bidRepositoryStub
+
+	release chan struct{}
+}
+
+func (r *slowBidRepository) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) []*internal_error.InternalError {
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+		results := make([]*internal_error.InternalError, len(bidEntities))
+		for i := range results {
+			results[i] = internal_error.NewInternalServerError("Write timed out").Wrap(ctx.Err())
+		}
+		return results
+	}
+
+	return r.bidRepositoryStub.CreateBid(ctx, bidEntities)
+}
+
+// waitForQueueDepth waits until at least depth bids are queued or being written.
+func waitForQueueDepth(t *testing.T, bidUseCase bid_usecase.BidUseCaseInterface, depth int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for bidUseCase.QueueStats().Depth < depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d bids queued, got %d", depth, bidUseCase.QueueStats().Depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBidQueueShedsLoadWhenWritesFallBehind(t *testing.T) {
+	config := bid_usecase.DefaultConfig()
+	config.MaxBatchSize = 10
+	config.QueueCapacity = 50
+
+	repository := &slowBidRepository{bidRepositoryStub: &bidRepositoryStub{}, release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(repository, nil, nil, nil, nil, nil, nil, config)
+
+	// A burst far past the capacity while no batch gets written
+	const bids = 500
+	var accepted, busy atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < bids; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 1000,
+			})
+			switch {
+			case err == nil:
+				accepted.Add(1)
+			case err.Err == internal_error.ServiceBusy:
+				busy.Add(1)
+			default:
+				t.Errorf("Expected the bid to be written or refused as busy, got %v", err.Error())
+			}
+		}()
+	}
+
+	// The bids past the queue are refused right away, while the others wait for the write
+	deadline := time.Now().Add(5 * time.Second)
+	for busy.Load()+bidUseCase.QueueStats().Depth != bids {
+		if time.Now().After(deadline) {
+			close(repository.release)
+			t.Fatal("Bids waited for the database instead of being refused")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The routine holds at most a batch on top of the full queue
+	stats := bidUseCase.QueueStats()
+	if limit := int64(config.QueueCapacity + config.MaxBatchSize); stats.Depth > limit {
+		t.Errorf("Expected at most %d bids held while the writes are stuck, got %d", limit, stats.Depth)
+	}
+	if busy.Load() == 0 || stats.Dropped != busy.Load() || stats.Capacity != config.QueueCapacity {
+		t.Errorf("Expected the bids past the queue of %d to be refused, got %d refused and %+v",
+			config.QueueCapacity, busy.Load(), stats)
+	}
+	if accepted.Load() != 0 {
+		t.Errorf("Expected no bid to succeed before it is written, got %d", accepted.Load())
+	}
+
+	// Once the database catches up every queued bid is written and the queue empties
+	close(repository.release)
+	wg.Wait()
+
+	repository.mu.Lock()
+	created := len(repository.created)
+	repository.mu.Unlock()
+	if int64(created) != accepted.Load() || accepted.Load() != stats.Depth {
+		t.Errorf("Expected the %d queued bids to be written and accepted, got %d written and %d accepted",
+			stats.Depth, created, accepted.Load())
+	}
+	if depth := bidUseCase.QueueStats().Depth; depth != 0 {
+		t.Errorf("Expected an empty queue after the writes, got %d", depth)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bidUseCase.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+}
+
+func TestServiceBusyAnswers503WithRetryAfter(t *testing.T) {
+	restErr := rest_err.ConvertError(internal_error.ErrServiceBusy)
+
+	if restErr.Code != http.StatusServiceUnavailable || restErr.Err != string(internal_error.ServiceBusy) {
+		t.Errorf("Expected a 503 service_busy error, got %d %s", restErr.Code, restErr.Err)
+	}
+	if restErr.RetryAfter <= 0 {
+		t.Error("Expected the client to be told when to retry")
+	}
+}
@@ -0,0 +1,124 @@
+package bid_usecase
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+// ClosedAuctionNotifier notifies the close listeners of auctions closed by a bid, as
+// those bought at their buy-now price. auction.Closer implements it.
+type ClosedAuctionNotifier interface {
+	NotifyClosed(ctx context.Context, auctionIds []string)
+}
+
+// BuyNow buys the auction at its buy-now price for the user, see buyNow. It fails with
+// ErrBuyNowUnavailable when the auction has no buy-now price, or no longer offers it.
+func (bu *BidUseCase) BuyNow(
+	ctx context.Context, userId, auctionId string) (*CreateBidOutputDTO, *internal_error.InternalError) {
+	auctionEntity, err := bu.findAuction(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bu.checkNotSeller(ctx, userId, auctionEntity); err != nil {
+		return nil, err
+	}
+
+	if auctionEntity == nil || !auctionEntity.BuyNowAvailable() {
+		return nil, internal_error.ErrBuyNowUnavailable
+	}
+
+	bidEntity, err := bid_entity.CreateBid(
+		userId, auctionId, auctionEntity.BuyNowPrice, auctionEntity.Currency, bu.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	return bu.buyNow(ctx, bidEntity, auctionEntity)
+}
+
+// buyNowAuction returns the auction when the bid reaches its buy-now price while the
+// auction still offers it, and nil otherwise.
+func buyNowAuction(bidEntity *bid_entity.Bid, auctionEntity *auction_entity.Auction) *auction_entity.Auction {
+	if auctionEntity == nil ||
+		!auctionEntity.BuyNowAvailable() || bidEntity.Amount < auctionEntity.BuyNowPrice {
+		return nil
+	}
+
+	return auctionEntity
+}
+
+// buyNow closes the auction with the bid as its winner, at the buy-now price whatever
+// the bid amount. The bids queued before it are written first, so it outbids them, and
+// the close is the conditional update of the repository: of concurrent buyers only one
+// gets the auction, the others fail with ErrBuyNowUnavailable. Unlike the bids placed
+// by placeBids, it never extends the auction, which it ends.
+func (bu *BidUseCase) buyNow(
+	ctx context.Context,
+	bidEntity *bid_entity.Bid,
+	auctionEntity *auction_entity.Auction) (*CreateBidOutputDTO, *internal_error.InternalError) {
+	bidEntity.Amount = auctionEntity.BuyNowPrice
+
+	bu.stopMu.RLock()
+	stopped := bu.stopped
+	bu.stopMu.RUnlock()
+	if stopped {
+		return nil, internal_error.NewInternalServerError("Bid service is shutting down")
+	}
+
+	if err := bu.Flush(ctx); err != nil {
+		return nil, errBidNotQueued.Wrap(err)
+	}
+
+	// Read after the flush, so the bid outbids the last of the queued bids
+	previousBid, err := bu.BidRepository.FindWinningBidByAuctionId(ctx, bidEntity.AuctionId)
+	if err != nil {
+		if err.Err != internal_error.NotFound {
+			return nil, err
+		}
+		previousBid = nil
+	}
+
+	if err := bu.checkBidder(ctx, bidEntity.UserId, bidEntity.Amount, previousBid); err != nil {
+		return nil, err
+	}
+
+	if err := bu.BidRepository.BuyNow(auction_entity.WithAuditActor(ctx, bidEntity.UserId), bidEntity); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Auction bought now",
+		zap.String("auction_id", bidEntity.AuctionId), zap.String("bid_id", bidEntity.Id))
+
+	if bu.closeNotifier != nil {
+		bu.closeNotifier.NotifyClosed(ctx, []string{bidEntity.AuctionId})
+	}
+
+	bid := toBidOutputDTO(bidEntity)
+	bu.stopMu.RLock()
+	if !bu.stopped && previousBid != nil && previousBid.UserId != bidEntity.UserId {
+		bu.enqueueOutbid(toBidOutputDTO(previousBid), bid)
+	}
+	bu.stopMu.RUnlock()
+
+	if bu.bidPublisher != nil {
+		bu.bidPublisher.PublishBid(bid)
+	}
+
+	// The close moved the end of the auction and counted the bid, so its state is read
+	// again; the bid stays bought when it can't be
+	closedAuction, err := bu.auctionRepository.FindAuctionById(ctx, bidEntity.AuctionId)
+	if err != nil {
+		closedAuction = nil
+	}
+
+	output := bu.withAuctionState(bid, closedAuction, nil)
+	output.BoughtNow = true
+	return output, nil
+}
@@ -0,0 +1,42 @@
+package bid_usecase
+
+import "time"
+
+// Config tunes the bid flow. It is loaded once at startup, see the config package;
+// DefaultConfig has the values used for the settings left unset.
+type Config struct {
+	// MaxBatchSize caps the bids written together. A batch is written as soon as the
+	// previous one is done, with the bids queued in the meantime
+	MaxBatchSize int
+
+	// QueueCapacity is how many accepted bids may wait for the batch routine; bids past it
+	// are refused with ErrServiceBusy until the writes catch up
+	QueueCapacity int
+
+	// MinIncrement is how much, in cents, a bid has to beat the leading bid by
+	MinIncrement int64
+
+	// Soft close: a bid accepted with less than SnipeWindow left extends the auction by
+	// SnipeExtension, up to SnipeMaxExtension in total. A zero window disables it.
+	SnipeWindow       time.Duration
+	SnipeExtension    time.Duration
+	SnipeMaxExtension time.Duration
+
+	// BalanceMode is one of the BID_BALANCE_MODE values, see checkBidder
+	BalanceMode string
+
+	// MaxBidsPerUser is how many bids a user may place on an auction, zero for no limit
+	MaxBidsPerUser int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxBatchSize:      5,
+		QueueCapacity:     1000,
+		MinIncrement:      100,
+		SnipeExtension:    30 * time.Second,
+		SnipeMaxExtension: 5 * time.Minute,
+		BalanceMode:       BalanceVerify,
+		MaxBidsPerUser:    50,
+	}
+}
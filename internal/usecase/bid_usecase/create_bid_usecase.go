@@ -2,134 +2,673 @@ package bid_usecase
 
 import (
 	"context"
+	"fmt"
 	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
 	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
 	"fullcycle-auction_go/internal/internal_error"
-	"os"
-	"strconv"
+	"fullcycle-auction_go/internal/money"
+	"math"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.uber.org/zap"
 )
 
+// BidInputDTO carries the amount in cents; in JSON it is a decimal such as 10.50. An
+// empty currency means money.DefaultCurrency.
 type BidInputDTO struct {
-	UserId    string  `json:"user_id"`
-	AuctionId string  `json:"auction_id"`
-	Amount    float64 `json:"amount"`
+	// UserId is the authenticated bidder, never read from the request body
+	UserId    string       `json:"-"`
+	AuctionId string       `json:"auction_id"`
+	Amount    money.Amount `json:"amount"`
+	Currency  string       `json:"currency"`
 }
 
 type BidOutputDTO struct {
-	Id        string    `json:"id"`
-	UserId    string    `json:"user_id"`
-	AuctionId string    `json:"auction_id"`
-	Amount    float64   `json:"amount"`
-	Timestamp time.Time `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	Id        string `json:"id"`
+	UserId    string `json:"user_id"`
+	AuctionId string `json:"auction_id"`
+
+	// Amount is left out of the bids on a Vickrey auction that didn't close yet, which
+	// are sent with Sealed set instead; placed bids are never zero
+	Amount    money.Amount `json:"amount,omitempty"`
+	Currency  string       `json:"currency"`
+	Timestamp time.Time    `json:"timestamp" time_format:"2006-01-02 15:04:05"`
+	Sealed    bool         `json:"sealed,omitempty"`
+
+	// Proxy is set on the bids placed automatically on behalf of a maximum bid
+	Proxy bool `json:"proxy,omitempty"`
+
+	// Duplicate is set by CreateBid when the bid repeats one the user already placed,
+	// which is returned in its place
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// BidPublisher is notified of every bid accepted by CreateBid, e.g. to push it to the
+// clients following the auction live.
+type BidPublisher interface {
+	PublishBid(bid BidOutputDTO)
+}
+
+// BidPublisherFunc adapts a plain function to BidPublisher.
+type BidPublisherFunc func(bid BidOutputDTO)
+
+func (f BidPublisherFunc) PublishBid(bid BidOutputDTO) {
+	f(bid)
+}
+
+// ExtensionPublisher can be implemented by a BidPublisher to learn when a last second
+// bid extends its auction, e.g. to resync the countdown of the clients following it.
+type ExtensionPublisher interface {
+	PublishExtension(auctionId string, endTime time.Time)
+}
+
+// BidPublishers publishes every bid to each of its publishers in order, and every
+// extension to those implementing ExtensionPublisher.
+type BidPublishers []BidPublisher
+
+func (p BidPublishers) PublishBid(bid BidOutputDTO) {
+	for _, publisher := range p {
+		publisher.PublishBid(bid)
+	}
+}
+
+func (p BidPublishers) PublishExtension(auctionId string, endTime time.Time) {
+	for _, publisher := range p {
+		if extensionPublisher, ok := publisher.(ExtensionPublisher); ok {
+			extensionPublisher.PublishExtension(auctionId, endTime)
+		}
+	}
+}
+
+// outbidQueueSize bounds the notifications waiting for the notifier; more are dropped.
+const outbidQueueSize = 256
+
+// batchWriteTimeout bounds each batch write. The batches are written apart from the
+// requests that queued their bids, so none of them can cut a write short.
+const batchWriteTimeout = 30 * time.Second
+
+type outbidNotification struct {
+	previousBid BidOutputDTO
+	newBid      BidOutputDTO
 }
 
 type BidUseCase struct {
-	BidRepository bid_entity.BidEntityRepository
+	BidRepository     bid_entity.BidEntityRepository
+	auctionRepository auction_entity.AuctionRepositoryInterface
+	userRepository    user_entity.UserRepositoryInterface
+	bidPublisher      BidPublisher
+
+	// balanceMode is one of the BID_BALANCE_MODE values, see checkBidder
+	balanceMode string
+
+	notifier     Notifier
+	outbidQueue  chan outbidNotification
+	notifierDone chan struct{}
+
+	// closeNotifier learns about the auctions bought now, see buyNow
+	closeNotifier ClosedAuctionNotifier
+
+	// Accepted bids are written in batches of up to maxBatchSize, see
+	// triggerCreateRoutine, and each request waits for the write of its bid
+	maxBatchSize  int
+	bidChannel    chan *pendingBid
+	flushRequests chan chan struct{}
+	minIncrement  int64
+
+	// queueDepth counts the bids queued and not written yet, the batch being written
+	// included, and droppedBids those refused because bidChannel was full
+	queueDepth  atomic.Int64
+	droppedBids atomic.Int64
 
-	timer               *time.Timer
-	maxBatchSize        int
-	batchInsertInterval time.Duration
-	bidChannel          chan bid_entity.Bid
+	// maxBidsPerUser caps the bids of a user on an auction, see checkBidLimit
+	maxBidsPerUser int
+
+	// pendingBids holds the bids queued but not written yet, so a retry arriving before
+	// the write is answered along with the queued bid, see findDuplicateBid
+	pendingMu   sync.Mutex
+	pendingBids map[pendingBidKey]*pendingBid
+
+	// Soft close: a bid accepted with less than snipeWindow left extends the auction by
+	// snipeExtension, up to snipeMaxExtension in total. A zero window disables it.
+	snipeWindow       time.Duration
+	snipeExtension    time.Duration
+	snipeMaxExtension time.Duration
+
+	// clock stamps the bids created and decides whether an auction still takes bids
+	clock clock.Clock
+
+	// stopMu keeps Shutdown from flushing while a bid is being enqueued, so every bid
+	// CreateBid reported as accepted is part of the final flush
+	stopMu  sync.RWMutex
+	stopped bool
+	stop    chan struct{}
+	done    chan struct{}
 }
 
-func NewBidUseCase(bidRepository bid_entity.BidEntityRepository) BidUseCaseInterface {
-	maxSizeInterval := getMaxBatchSizeInterval()
-	maxBatchSize := getMaxBatchSize()
+// NewBidUseCase wires the bid flow. A nil user repository skips the bidder checks, a
+// nil notifier falls back to LogNotifier, a nil closeNotifier tells no one about the
+// auctions bought now and a nil clock uses the real clock.
+func NewBidUseCase(
+	bidRepository bid_entity.BidEntityRepository,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	userRepository user_entity.UserRepositoryInterface,
+	bidPublisher BidPublisher,
+	notifier Notifier,
+	closeNotifier ClosedAuctionNotifier,
+	clk clock.Clock,
+	config Config) BidUseCaseInterface {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	queueCapacity := config.QueueCapacity
+	if queueCapacity < 1 {
+		queueCapacity = config.MaxBatchSize
+	}
 
 	bidUseCase := &BidUseCase{
-		BidRepository:       bidRepository,
-		maxBatchSize:        maxBatchSize,
-		batchInsertInterval: maxSizeInterval,
-		timer:               time.NewTimer(maxSizeInterval),
-		bidChannel:          make(chan bid_entity.Bid, maxBatchSize),
+		BidRepository:     bidRepository,
+		auctionRepository: auctionRepository,
+		userRepository:    userRepository,
+		bidPublisher:      bidPublisher,
+		balanceMode:       config.BalanceMode,
+		notifier:          notifier,
+		closeNotifier:     closeNotifier,
+		outbidQueue:       make(chan outbidNotification, outbidQueueSize),
+		notifierDone:      make(chan struct{}),
+		maxBatchSize:      config.MaxBatchSize,
+		bidChannel:        make(chan *pendingBid, queueCapacity),
+		flushRequests:     make(chan chan struct{}),
+		minIncrement:      config.MinIncrement,
+		maxBidsPerUser:    config.MaxBidsPerUser,
+		pendingBids:       make(map[pendingBidKey]*pendingBid),
+		snipeWindow:       config.SnipeWindow,
+		snipeExtension:    config.SnipeExtension,
+		snipeMaxExtension: config.SnipeMaxExtension,
+		clock:             clk,
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
 	}
 
 	bidUseCase.triggerCreateRoutine(context.Background())
+	bidUseCase.triggerNotifyRoutine(context.Background())
 
 	return bidUseCase
 }
 
-var bidBatch []bid_entity.Bid
-
 type BidUseCaseInterface interface {
 	CreateBid(
 		ctx context.Context,
-		bidInputDTO BidInputDTO) *internal_error.InternalError
+		bidInputDTO BidInputDTO) (*CreateBidOutputDTO, *internal_error.InternalError)
+
+	CreateProxyBid(
+		ctx context.Context, userId, auctionId string, maxAmount int64) *internal_error.InternalError
+
+	BuyNow(
+		ctx context.Context, userId, auctionId string) (*CreateBidOutputDTO, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context, auctionId string) (*BidOutputDTO, *internal_error.InternalError)
 
 	FindBidByAuctionId(
-		ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError)
+		ctx context.Context, auctionId string, listInput BidListInputDTO) (*BidListOutputDTO, *internal_error.InternalError)
+
+	FindBidsByUserId(
+		ctx context.Context, userId string, onlyActiveAuctions bool) ([]BidOutputDTO, *internal_error.InternalError)
+
+	CountSelfBids(ctx context.Context) ([]SelfBidCountOutputDTO, *internal_error.InternalError)
+
+	ExportBids(
+		ctx context.Context, input BidExportInputDTO, fn func(bid BidOutputDTO) error) *internal_error.InternalError
+
+	QueueStats() BidQueueOutputDTO
+
+	Flush(ctx context.Context) error
+
+	Shutdown(ctx context.Context) error
 }
 
+// triggerCreateRoutine writes the queued bids, as soon as it picks one up, along with
+// the others already queued up to maxBatchSize: the bids queued while a batch is being
+// written make up the next one. Each queued bid gets the result of its write.
 func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
 	go func() {
-		defer close(bu.bidChannel)
+		defer close(bu.done)
 
-		for {
-			select {
-			case bidEntity, ok := <-bu.bidChannel:
-				if !ok {
-					if len(bidBatch) > 0 {
-						if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
-							logger.Error("error trying to process bid batch list", err)
-						}
-					}
+		var bidBatch []*pendingBid
+
+		// fill takes the bids already queued, up to limit, without waiting for more
+		fill := func(limit int) {
+			for len(bidBatch) < limit {
+				select {
+				case queued := <-bu.bidChannel:
+					bidBatch = append(bidBatch, queued)
+				default:
 					return
 				}
+			}
+		}
 
-				bidBatch = append(bidBatch, bidEntity)
+		write := func() {
+			if len(bidBatch) == 0 {
+				return
+			}
 
-				if len(bidBatch) >= bu.maxBatchSize {
-					if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
-						logger.Error("error trying to process bid batch list", err)
-					}
+			bids := make([]bid_entity.Bid, len(bidBatch))
+			for i, pending := range bidBatch {
+				bids[i] = pending.bid
+			}
 
-					bidBatch = nil
-					bu.timer.Reset(bu.batchInsertInterval)
-				}
-			case <-bu.timer.C:
-				if err := bu.BidRepository.CreateBid(ctx, bidBatch); err != nil {
-					logger.Error("error trying to process bid batch list", err)
-				}
-				bidBatch = nil
-				bu.timer.Reset(bu.batchInsertInterval)
+			writeCtx, cancel := context.WithTimeout(ctx, batchWriteTimeout)
+			results := bu.BidRepository.CreateBid(writeCtx, bids)
+			cancel()
+			bu.queueDepth.Add(-int64(len(bids)))
+			bu.releasePending(bidBatch, results)
+
+			bidBatch = nil
+		}
+
+		for {
+			select {
+			case <-bu.stop:
+				fill(math.MaxInt)
+				write()
+				return
+			case reply := <-bu.flushRequests:
+				fill(math.MaxInt)
+				write()
+				close(reply)
+			case queued := <-bu.bidChannel:
+				bidBatch = append(bidBatch, queued)
+				fill(bu.maxBatchSize)
+				write()
 			}
 		}
 	}()
 }
 
+// triggerNotifyRoutine hands the queued outbid notifications to the notifier until the
+// queue is closed by Shutdown.
+func (bu *BidUseCase) triggerNotifyRoutine(ctx context.Context) {
+	go func() {
+		defer close(bu.notifierDone)
+
+		for notification := range bu.outbidQueue {
+			bu.notifier.NotifyOutbid(ctx, notification.previousBid, notification.newBid)
+		}
+	}()
+}
+
+// CreateBid places the bid and returns it along with the state of its auction, see
+// withAuctionState. A bid repeating the amount the user already bid on the auction is
+// taken for a retry and answered with the first one, flagged as Duplicate, instead of
+// being placed again.
+//
+// CreateBid only succeeds once the bid is written, and fails with the reason the
+// repository rejected it otherwise, see placeBids: an auction closed after the checks
+// below, by another request or instance, fails it with ErrAuctionNotActive. When ctx is done before the bid is
+// queued it isn't queued at all, and the error wraps ctx.Err(), e.g. context.Canceled
+// when the client went away; once queued, the bid is written whatever happens to ctx.
 func (bu *BidUseCase) CreateBid(
 	ctx context.Context,
-	bidInputDTO BidInputDTO) *internal_error.InternalError {
+	bidInputDTO BidInputDTO) (*CreateBidOutputDTO, *internal_error.InternalError) {
+
+	// The entity validation guards programmatic callers the same way the controller's
+	// request binding guards HTTP clients
+	bidEntity, err := bid_entity.CreateBid(
+		bidInputDTO.UserId, bidInputDTO.AuctionId, bidInputDTO.Amount.Cents(), bidInputDTO.Currency, bu.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	// The auction is read once, and each check below takes it from here
+	auctionEntity, err := bu.findAuction(ctx, bidEntity.AuctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bu.checkNotSeller(ctx, bidEntity.UserId, auctionEntity); err != nil {
+		return nil, err
+	}
+
+	if err := checkCurrency(bidEntity.Currency, auctionEntity); err != nil {
+		return nil, err
+	}
+
+	// Checked before the increment, which a stored duplicate would fail
+	duplicate, err := bu.findDuplicateBid(ctx, bidEntity)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate != nil {
+		return bu.withAuctionState(*duplicate, auctionEntity, nil), nil
+	}
+
+	// Checked after the duplicates, so a retry of an accepted bid still gets its answer
+	if err := bu.checkBidLimit(ctx, bidEntity); err != nil {
+		return nil, err
+	}
+
+	// A bid reaching the buy-now price buys the auction instead of being queued
+	if buyNowAuction := buyNowAuction(bidEntity, auctionEntity); buyNowAuction != nil {
+		return bu.buyNow(ctx, bidEntity, buyNowAuction)
+	}
+
+	sealed := isVickrey(auctionEntity)
+
+	var previousBid *bid_entity.Bid
+	if sealed {
+		previousBid, err = bu.validateSealedBid(ctx, bidEntity, auctionEntity)
+	} else {
+		previousBid, err = bu.validateMinimumIncrement(ctx, bidEntity, auctionEntity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bu.checkBidder(ctx, bidEntity.UserId, bidEntity.Amount, previousBid); err != nil {
+		return nil, err
+	}
+
+	// Sealed bids have no proxy bids to answer them, and outbid nobody anyone is told about
+	bids := []bid_entity.Bid{*bidEntity}
+	if sealed {
+		previousBid = nil
+	} else {
+		bids, err = bu.resolveProxyBids(ctx, *bidEntity, bidEntity.Amount, previousBid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bidOutput, err := bu.placeBids(ctx, auctionEntity, previousBid, bids, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	// A retry racing the first request is answered with the queued bid, placing nothing
+	placed := bids
+	if bidOutput.Id != bids[0].Id {
+		placed = nil
+	}
+
+	return bu.withAuctionState(*bidOutput, auctionEntity, placed), nil
+}
 
-	bidEntity, err := bid_entity.CreateBid(bidInputDTO.UserId, bidInputDTO.AuctionId, bidInputDTO.Amount)
+// findAuction reads the auction a bid is placed on and rejects the bid when the auction
+// isn't taking bids, telling an auction that doesn't exist apart with an
+// auction_not_found error. Without an auction repository there is none to read, and the
+// checks taking it let every bid through; the transaction writing the bid still rejects
+// those on an auction that isn't active.
+func (bu *BidUseCase) findAuction(
+	ctx context.Context, auctionId string) (*auction_entity.Auction, *internal_error.InternalError) {
+	if bu.auctionRepository == nil {
+		return nil, nil
+	}
+
+	auctionEntity, err := bu.auctionRepository.FindAuctionById(ctx, auctionId)
 	if err != nil {
-		return err
+		if err.Err == internal_error.NotFound {
+			return nil, internal_error.NewAuctionNotFoundError(auctionId).Wrap(err)
+		}
+		return nil, err
+	}
+
+	if err := auctionEntity.ValidateAcceptsBids(bu.clock.Now()); err != nil {
+		return nil, err
+	}
+
+	return auctionEntity, nil
+}
+
+// placeBids queues bids, a bid and the proxy bid answering it, to be inserted together,
+// and waits for the batch routine to write them. Once written, it notifies the users
+// each of them outbids and publishes them, without their amounts when sealed; a write
+// rejected by the repository, e.g. because the auction closed in the meantime, is
+// returned as is and places nothing. previousBid is the bid leading before them, if
+// any. The end time of auctionEntity is moved when the bids extend it. It returns the
+// first bid, or the queued bid it duplicates.
+func (bu *BidUseCase) placeBids(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	previousBid *bid_entity.Bid,
+	bids []bid_entity.Bid,
+	sealed bool) (*BidOutputDTO, *internal_error.InternalError) {
+	queued := bids[0]
+	if len(bids) > 1 {
+		queued.CounterBid = &bids[1]
+	}
+
+	// Bids are inserted by the batch routine, which only logs the bid id, so this entry
+	// ties the bid to the request that placed it
+	for _, bid := range bids {
+		logger.InfoContext(ctx, "Bid queued",
+			zap.String("bid_id", bid.Id), zap.String("auction_id", bid.AuctionId), zap.Bool("proxy", bid.Proxy))
+	}
+
+	bu.stopMu.RLock()
+	if bu.stopped {
+		bu.stopMu.RUnlock()
+		return nil, internal_error.NewInternalServerError("Bid service is shutting down")
+	}
+	if err := ctx.Err(); err != nil {
+		bu.stopMu.RUnlock()
+		return nil, errBidNotQueued.Wrap(err)
+	}
+	// A retry racing the first request past findDuplicateBid is caught here
+	pending, reserved := bu.reservePending(queued)
+	if !reserved {
+		bu.stopMu.RUnlock()
+		return pending.duplicateOf()
+	}
+	// A full queue means the writes fell behind: the bid is refused right away, instead of
+	// holding the request and the bid in memory until there is room, see QueueStats
+	bu.queueDepth.Add(1)
+	select {
+	case bu.bidChannel <- pending:
+	default:
+		bu.queueDepth.Add(-1)
+		bu.droppedBids.Add(1)
+		bu.stopMu.RUnlock()
+		bu.releasePending([]*pendingBid{pending}, []*internal_error.InternalError{internal_error.ErrServiceBusy})
+		return nil, internal_error.ErrServiceBusy
+	}
+	bu.stopMu.RUnlock()
+
+	// The routine writes every queued bid, on Shutdown too, within its own deadline, so
+	// ctx isn't waited on: the answer is the one of the write
+	if err := pending.wait(); err != nil {
+		return nil, err
+	}
+
+	bu.stopMu.RLock()
+	for i := range bids {
+		if !bu.stopped && previousBid != nil && previousBid.UserId != bids[i].UserId {
+			bu.enqueueOutbid(toBidOutputDTO(previousBid), toBidOutputDTO(&bids[i]))
+		}
+		previousBid = &bids[i]
+	}
+	bu.stopMu.RUnlock()
+
+	if endTime, extended := bu.extendIfSniped(ctx, queued.AuctionId); extended && auctionEntity != nil {
+		auctionEntity.EndTime = endTime
+	}
+
+	if bu.bidPublisher != nil {
+		for i := range bids {
+			bid := toBidOutputDTO(&bids[i])
+			if sealed {
+				bid = sealBid(bid)
+			}
+			bu.bidPublisher.PublishBid(bid)
+		}
+	}
+
+	bidOutput := toBidOutputDTO(&bids[0])
+	return &bidOutput, nil
+}
+
+// errBidNotQueued is returned, wrapping ctx.Err(), when the request placing a bid is done
+// before the bid is queued.
+var errBidNotQueued = internal_error.NewInternalServerError("The request ended before the bid was placed")
+
+// enqueueOutbid never blocks the bid: when the notifier falls behind and the queue is
+// full, the notification is dropped. The caller must hold stopMu.
+func (bu *BidUseCase) enqueueOutbid(previousBid, newBid BidOutputDTO) {
+	select {
+	case bu.outbidQueue <- outbidNotification{previousBid: previousBid, newBid: newBid}:
+	default:
+		logger.Info("Outbid notification dropped, queue is full",
+			zap.String("auction_id", newBid.AuctionId), zap.String("bid_id", newBid.Id))
+	}
+}
+
+func toBidOutputDTO(bidEntity *bid_entity.Bid) BidOutputDTO {
+	return BidOutputDTO{
+		Id:        bidEntity.Id,
+		UserId:    bidEntity.UserId,
+		AuctionId: bidEntity.AuctionId,
+		Amount:    money.Amount(bidEntity.Amount),
+		Currency:  bidEntity.Currency,
+		Timestamp: bidEntity.Timestamp.UTC(),
+		Proxy:     bidEntity.Proxy,
+	}
+}
+
+// Flush writes the bids queued so far, those waiting for the batch being written
+// included, and returns once the repository is done with them. It returns ctx.Err() if
+// ctx is done first; the bids then stay queued for the next write.
+func (bu *BidUseCase) Flush(ctx context.Context) error {
+	reply := make(chan struct{})
+
+	select {
+	case bu.flushRequests <- reply:
+	case <-bu.done:
+		// The create routine wrote everything on its way out
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	bu.bidChannel <- *bidEntity
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops accepting bids, flushes the ones still queued, including the tail of
+// the batch in progress, and waits for the pending outbid notifications to be handed
+// to the notifier. It returns ctx.Err() if ctx is done first; the create routine still
+// writes whatever is left before exiting.
+func (bu *BidUseCase) Shutdown(ctx context.Context) error {
+	bu.stopMu.Lock()
+	first := !bu.stopped
+	bu.stopped = true
+	bu.stopMu.Unlock()
+
+	// No bid can be queued anymore, so the flush leaves nothing behind
+	flushErr := bu.Flush(ctx)
+
+	if first {
+		close(bu.stop)
+		close(bu.outbidQueue)
+	}
+
+	if flushErr != nil {
+		return flushErr
+	}
+
+	for _, done := range []chan struct{}{bu.done, bu.notifierDone} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 
 	return nil
 }
 
-func getMaxBatchSizeInterval() time.Duration {
-	batchInsertInterval := os.Getenv("BATCH_INSERT_INTERVAL")
-	duration, err := time.ParseDuration(batchInsertInterval)
+// extendIfSniped applies the soft close rule to an accepted bid. The repository only
+// extends the auction when it is inside the window, so the bid itself is never rejected
+// because of it. It returns the new end time when the auction was extended.
+func (bu *BidUseCase) extendIfSniped(ctx context.Context, auctionId string) (time.Time, bool) {
+	if bu.auctionRepository == nil || bu.snipeWindow <= 0 || bu.snipeExtension <= 0 {
+		return time.Time{}, false
+	}
+
+	endTime, extended, err := bu.auctionRepository.ExtendAuctionEndTime(
+		ctx, auctionId, bu.snipeWindow, bu.snipeExtension, bu.snipeMaxExtension)
 	if err != nil {
-		return 3 * time.Minute
+		return time.Time{}, false
+	}
+
+	if !extended {
+		return time.Time{}, false
 	}
 
-	return duration
+	logger.InfoContext(ctx, "Auction extended by a last second bid",
+		zap.String("auction_id", auctionId), zap.Time("end_time", endTime))
+
+	if extensionPublisher, ok := bu.bidPublisher.(ExtensionPublisher); ok {
+		extensionPublisher.PublishExtension(auctionId, endTime)
+	}
+
+	return endTime, true
 }
 
-func getMaxBatchSize() int {
-	value, err := strconv.Atoi(os.Getenv("MAX_BATCH_SIZE"))
+// validateMinimumIncrement requires every bid to beat the current winning bid by at
+// least minIncrement. The first bid of an auction must meet its starting price instead.
+// It returns the winning bid the new one displaces, if any.
+func (bu *BidUseCase) validateMinimumIncrement(
+	ctx context.Context,
+	bidEntity *bid_entity.Bid,
+	auctionEntity *auction_entity.Auction) (*bid_entity.Bid, *internal_error.InternalError) {
+	winningBid, err := bu.BidRepository.FindWinningBidByAuctionId(ctx, bidEntity.AuctionId)
 	if err != nil {
-		return 5
+		if err.Err == internal_error.NotFound {
+			return nil, validateStartingPrice(bidEntity, auctionEntity)
+		}
+
+		return nil, err
 	}
 
-	return value
+	// Only bids of another auction currency could differ, which checkCurrency rejects
+	// unless there is no auction repository to read it from
+	if bidEntity.Currency != winningBid.Currency {
+		return nil, internal_error.NewCurrencyMismatchError(winningBid.Currency)
+	}
+
+	minimumAmount := winningBid.Amount + bu.minIncrement
+	if bidEntity.Amount < minimumAmount || bidEntity.Amount <= winningBid.Amount {
+		return nil, internal_error.NewBadRequestError(
+			fmt.Sprintf("Bid amount must be at least %s", money.FormatCurrency(minimumAmount, bidEntity.Currency)))
+	}
+
+	return winningBid, nil
+}
+
+func validateStartingPrice(
+	bidEntity *bid_entity.Bid, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	if auctionEntity == nil {
+		return nil
+	}
+
+	if bidEntity.Amount < auctionEntity.StartingPrice {
+		return internal_error.NewBadRequestError(
+			fmt.Sprintf("Bid amount must be at least the starting price of %s",
+				money.FormatCurrency(auctionEntity.StartingPrice, auctionEntity.Currency)))
+	}
+
+	return nil
 }
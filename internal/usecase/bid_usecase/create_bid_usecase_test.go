@@ -0,0 +1,1071 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/configuration/rest_err"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/events"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/google/uuid"
+)
+
+// bidRepositoryStub answers the winning bid lookup with a fixed bid, records the
+// batches it is asked to create and accepts everything else. When rejectWith is set,
+// the bids are rejected with it instead of being recorded.
+type bidRepositoryStub struct {
+	winningBid *bid_entity.Bid
+	rejectWith *internal_error.InternalError
+
+	mu      sync.Mutex
+	created []bid_entity.Bid
+}
+
+func (s *bidRepositoryStub) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) []*internal_error.InternalError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]*internal_error.InternalError, len(bidEntities))
+	if s.rejectWith != nil {
+		for i := range results {
+			results[i] = s.rejectWith
+		}
+		return results
+	}
+
+	s.created = append(s.created, bidEntities...)
+	return results
+}
+
+func (s *bidRepositoryStub) BuyNow(
+	ctx context.Context, bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	return nil
+}
+
+func (s *bidRepositoryStub) FindBidByAuctionId(
+	ctx context.Context,
+	auctionId string,
+	filter bid_entity.BidListFilter) ([]bid_entity.Bid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *bidRepositoryStub) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	if s.winningBid == nil {
+		return nil, internal_error.NewNotFoundError("No bids found")
+	}
+
+	return s.winningBid, nil
+}
+
+func (s *bidRepositoryStub) FindBidsByUserId(
+	ctx context.Context, userId string, onlyActiveAuctions bool) ([]bid_entity.Bid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *bidRepositoryStub) GetAuctionBidStats(
+	ctx context.Context, auctionId string) (*bid_entity.BidStats, *internal_error.InternalError) {
+	return &bid_entity.BidStats{}, nil
+}
+
+func (s *bidRepositoryStub) CountBidsByUserOnOwnAuctions(
+	ctx context.Context) ([]bid_entity.SelfBidCount, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *bidRepositoryStub) FindBidByUserAndAmount(
+	ctx context.Context, auctionId, userId string, amount int64) (*bid_entity.Bid, *internal_error.InternalError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, bid := range s.created {
+		if bid.AuctionId == auctionId && bid.UserId == userId && bid.Amount == amount {
+			return &bid, nil
+		}
+	}
+
+	return nil, internal_error.NewNotFoundError("No bid found")
+}
+
+func (s *bidRepositoryStub) AggregateUserStats(
+	ctx context.Context, userId string) (*bid_entity.UserBidStats, *internal_error.InternalError) {
+	return &bid_entity.UserBidStats{}, nil
+}
+
+func (s *bidRepositoryStub) CountUserBids(
+	ctx context.Context, auctionId, userId string) (int64, *internal_error.InternalError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, bid := range s.created {
+		if bid.AuctionId == auctionId && bid.UserId == userId {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *bidRepositoryStub) AggregateTopBidders(
+	ctx context.Context, from, to time.Time, limit int) ([]bid_entity.TopBidder, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (s *bidRepositoryStub) SaveMaxBid(
+	ctx context.Context, maxBid *bid_entity.MaxBid) *internal_error.InternalError {
+	return nil
+}
+
+func (s *bidRepositoryStub) FindMaxBid(
+	ctx context.Context, auctionId, userId string) (*bid_entity.MaxBid, *internal_error.InternalError) {
+	return nil, internal_error.NewNotFoundError("No maximum bid found")
+}
+
+func (s *bidRepositoryStub) StreamBids(
+	ctx context.Context,
+	filter bid_entity.BidExportFilter,
+	fn func(bid bid_entity.Bid) error) *internal_error.InternalError {
+	return nil
+}
+
+func (s *bidRepositoryStub) CountRecentBids(
+	ctx context.Context, now time.Time) (*bid_entity.BidActivity, *internal_error.InternalError) {
+	return &bid_entity.BidActivity{}, nil
+}
+
+func (s *bidRepositoryStub) AverageTimeToFirstBid(
+	ctx context.Context, since time.Time) (time.Duration, bool, *internal_error.InternalError) {
+	return 0, false, nil
+}
+
+// auctionRepositoryStub serves every id as an Active auction with startingPrice and
+// records the soft close extensions it is asked for.
+type auctionRepositoryStub struct {
+	auction_entity.AuctionRepositoryInterface
+
+	startingPrice int64
+	status        auction_entity.AuctionStatus
+
+	// currency is the one of the auction, money.DefaultCurrency when empty
+	currency string
+
+	mu         sync.Mutex
+	extensions []string
+	reads      int
+}
+
+func (s *auctionRepositoryStub) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+
+	currency := s.currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	return &auction_entity.Auction{
+		Id:            id,
+		Status:        s.status,
+		EndTime:       time.Now().Add(time.Hour),
+		StartingPrice: s.startingPrice,
+		Currency:      currency,
+	}, nil
+}
+
+func (s *auctionRepositoryStub) ExtendAuctionEndTime(
+	ctx context.Context,
+	id string,
+	window, extension, maxExtension time.Duration) (time.Time, bool, *internal_error.InternalError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.extensions = append(s.extensions, id)
+	return time.Now().Add(extension), true, nil
+}
+
+// winningBid is the current leader of an auction, bid in the default currency.
+func winningBid(cents int64) *bid_entity.Bid {
+	return &bid_entity.Bid{Id: uuid.New().String(), UserId: uuid.New().String(), Amount: cents, Currency: money.DefaultCurrency}
+}
+
+func TestCreateBidMinimumIncrement(t *testing.T) {
+	auctionId := uuid.New().String()
+
+	testCases := []struct {
+		name       string
+		winningBid *bid_entity.Bid
+		amount     money.Amount
+		expectErr  bool
+	}{
+		{name: "First bid only needs a positive amount", amount: 1},
+		{name: "Bid below the winning bid", winningBid: winningBid(10000), amount: 9000, expectErr: true},
+		{name: "Bid equal to the winning bid", winningBid: winningBid(10000), amount: 10000, expectErr: true},
+		{name: "Bid under the increment", winningBid: winningBid(10000), amount: 10050, expectErr: true},
+		{name: "Bid exactly at the increment", winningBid: winningBid(10000), amount: 10100},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bidUseCase := bid_usecase.NewBidUseCase(&bidRepositoryStub{winningBid: tc.winningBid}, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+			_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: auctionId,
+				Amount:    tc.amount,
+			})
+
+			if tc.expectErr {
+				if err == nil || err.Err != "bad_request" {
+					t.Errorf("Expected a bad_request error for amount %s, got %v", tc.amount, err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected amount %s to be accepted, got %v", tc.amount, err.Error())
+			}
+		})
+	}
+}
+
+func TestShutdownFlushesPendingBids(t *testing.T) {
+	// The first write is held, so the other bids are still queued when shutdown starts
+	repository := &slowBidRepository{bidRepositoryStub: &bidRepositoryStub{}, release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(repository, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	results := make(chan *internal_error.InternalError, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: uuid.New().String(),
+				Amount:    100,
+			})
+			results <- err
+		}()
+	}
+	waitForQueueDepth(t, bidUseCase, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	close(repository.release)
+	if err := bidUseCase.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("Expected the bid to be written, got %v", err.Error())
+		}
+	}
+
+	repository.mu.Lock()
+	created := len(repository.created)
+	repository.mu.Unlock()
+
+	if created != 3 {
+		t.Errorf("Expected 3 bids flushed on shutdown, got %d", created)
+	}
+
+	_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId:    uuid.New().String(),
+		AuctionId: uuid.New().String(),
+		Amount:    100,
+	})
+	if err == nil {
+		t.Error("Expected bids to be rejected after shutdown")
+	}
+}
+
+func TestRetriedBidReturnsTheFirstOne(t *testing.T) {
+	repository := &slowBidRepository{bidRepositoryStub: &bidRepositoryStub{}, release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(repository, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer bidUseCase.Shutdown(ctx)
+
+	bidInput := bid_usecase.BidInputDTO{
+		UserId:    uuid.New().String(),
+		AuctionId: uuid.New().String(),
+		Amount:    100,
+	}
+
+	type answer struct {
+		output *bid_usecase.CreateBidOutputDTO
+		err    *internal_error.InternalError
+	}
+	answers := make(chan answer, 2)
+	placeBid := func() {
+		output, err := bidUseCase.CreateBid(ctx, bidInput)
+		answers <- answer{output, err}
+	}
+
+	// The retry arrives while the first bid is being written, and waits for the write
+	go placeBid()
+	waitForQueueDepth(t, bidUseCase, 1)
+	go placeBid()
+	close(repository.release)
+
+	first, retried := <-answers, <-answers
+	if first.err != nil || retried.err != nil {
+		t.Fatalf("Expected the bid and its retry to succeed, got %v and %v", first.err, retried.err)
+	}
+	if first.output.Duplicate {
+		first, retried = retried, first
+	}
+	if first.output.Duplicate || !retried.output.Duplicate || retried.output.Id != first.output.Id {
+		t.Errorf("Expected the retry of a queued bid to return bid %s as a duplicate, got %+v",
+			first.output.Id, retried.output)
+	}
+
+	stored, err := bidUseCase.CreateBid(ctx, bidInput)
+	if err != nil {
+		t.Fatalf("Expected the retry of a stored bid to succeed, got %v", err.Error())
+	}
+	if !stored.Duplicate || stored.Id != first.output.Id {
+		t.Errorf("Expected the retry of a stored bid to return bid %s as a duplicate, got %+v",
+			first.output.Id, stored)
+	}
+
+	repository.mu.Lock()
+	created := len(repository.created)
+	repository.mu.Unlock()
+
+	if created != 1 {
+		t.Errorf("Expected the bid written once, got %d", created)
+	}
+}
+
+func TestCreateBidLimitsBidsPerUser(t *testing.T) {
+	config := bid_usecase.DefaultConfig()
+	config.MaxBidsPerUser = 3
+
+	repository := &slowBidRepository{bidRepositoryStub: &bidRepositoryStub{}, release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(repository, nil, nil, nil, nil, nil, nil, config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer bidUseCase.Shutdown(ctx)
+
+	userId, auctionId := uuid.New().String(), uuid.New().String()
+	bid := func(userId string, amount money.Amount) *internal_error.InternalError {
+		_, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{UserId: userId, AuctionId: auctionId, Amount: amount})
+		return err
+	}
+
+	// Two bids stored and one still being written reach the limit
+	for i, amount := range []money.Amount{100, 200} {
+		go func() { repository.release <- struct{}{} }()
+		if err := bid(userId, amount); err != nil {
+			t.Fatalf("Expected bid %d to be accepted, got %v", i+1, err.Error())
+		}
+	}
+	written := make(chan *internal_error.InternalError, 1)
+	go func() { written <- bid(userId, 300) }()
+	waitForQueueDepth(t, bidUseCase, 1)
+
+	if err := bid(userId, 400); err == nil || err.Err != internal_error.BidLimitExceeded {
+		t.Errorf("Expected a bid_limit_exceeded error past the limit, got %v", err)
+	}
+
+	close(repository.release)
+	if err := <-written; err != nil {
+		t.Fatalf("Expected bid 3 to be accepted, got %v", err.Error())
+	}
+	if err := bid(userId, 300); err != nil {
+		t.Errorf("Expected the retry of an accepted bid to be answered, got %v", err.Error())
+	}
+	if err := bid(uuid.New().String(), 500); err != nil {
+		t.Errorf("Expected another user to keep bidding, got %v", err.Error())
+	}
+}
+
+func TestFlushWritesQueuedBidsRightAway(t *testing.T) {
+	// The first write is held, so the other bids are still queued when the flush starts
+	repository := &slowBidRepository{bidRepositoryStub: &bidRepositoryStub{}, release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(repository, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer bidUseCase.Shutdown(ctx)
+
+	for i := 0; i < 3; i++ {
+		go bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId:    uuid.New().String(),
+			AuctionId: uuid.New().String(),
+			Amount:    100,
+		})
+	}
+	waitForQueueDepth(t, bidUseCase, 3)
+
+	close(repository.release)
+	if err := bidUseCase.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	repository.mu.Lock()
+	created := len(repository.created)
+	repository.mu.Unlock()
+
+	if created != 3 {
+		t.Errorf("Expected 3 bids written by the flush, got %d", created)
+	}
+}
+
+// writeContextRecorder records whether the context of each batch write was still live
+// and bounded by a deadline. Each write waits until release is closed.
+type writeContextRecorder struct {
+	*bidRepositoryStub
+
+	release       chan struct{}
+	liveWrites    int
+	bounded       bool
+	cancelledSeen bool
+}
+
+func (r *writeContextRecorder) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) []*internal_error.InternalError {
+	<-r.release
+
+	r.mu.Lock()
+	if ctx.Err() != nil {
+		r.cancelledSeen = true
+	} else {
+		r.liveWrites++
+	}
+	_, r.bounded = ctx.Deadline()
+	r.mu.Unlock()
+
+	return r.bidRepositoryStub.CreateBid(ctx, bidEntities)
+}
+
+func TestCreateBidCancelledBeforeQueueingIsNotPlaced(t *testing.T) {
+	stub := &bidRepositoryStub{}
+	bidUseCase := bid_usecase.NewBidUseCase(stub, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	defer bidUseCase.Shutdown(shutdownCtx)
+
+	// The client went away before the bid reached the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	input := bid_usecase.BidInputDTO{UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 100}
+	_, err := bidUseCase.CreateBid(ctx, input)
+	if err == nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the bid to be refused with context.Canceled, got %v", err)
+	}
+	if restErr := rest_err.ConvertError(err); restErr.Code != rest_err.StatusClientClosedRequest {
+		t.Errorf("Expected the refusal to answer 499, got %d", restErr.Code)
+	}
+
+	if err := bidUseCase.Flush(shutdownCtx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	stub.mu.Lock()
+	created := len(stub.created)
+	stub.mu.Unlock()
+	if created != 0 {
+		t.Fatalf("Expected the refused bid not to be written, got %d bids", created)
+	}
+
+	// Nothing was left reserved, so the client retrying places the bid for real
+	output, err := bidUseCase.CreateBid(context.Background(), input)
+	if err != nil || output.Duplicate {
+		t.Fatalf("Expected the retry to place the bid, got %+v, %v", output, err)
+	}
+}
+
+func TestCreateBidQueuedIsWrittenAfterRequestCancelled(t *testing.T) {
+	recorder := &writeContextRecorder{bidRepositoryStub: &bidRepositoryStub{}, release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(recorder, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	defer bidUseCase.Shutdown(shutdownCtx)
+
+	type answer struct {
+		output *bid_usecase.CreateBidOutputDTO
+		err    *internal_error.InternalError
+	}
+	answers := make(chan answer, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		output, err := bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 100,
+		})
+		answers <- answer{output, err}
+	}()
+
+	// The client goes away once the bid is queued, before the batch is written
+	waitForQueueDepth(t, bidUseCase, 1)
+	cancel()
+	close(recorder.release)
+
+	answered := <-answers
+	if answered.err != nil {
+		t.Fatalf("Expected the bid to be written, got %v", answered.err.Error())
+	}
+	output := answered.output
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.created) != 1 || recorder.created[0].Id != output.Id {
+		t.Fatalf("Expected the accepted bid to be written, got %+v", recorder.created)
+	}
+	if recorder.cancelledSeen || recorder.liveWrites != 1 || !recorder.bounded {
+		t.Errorf("Expected one write with a live context bounded by a deadline, got %+v", recorder)
+	}
+}
+
+func TestCreateBidReturnsTheRejectionOfItsWrite(t *testing.T) {
+	// The auction closed between the checks and the write
+	stub := &bidRepositoryStub{rejectWith: internal_error.ErrAuctionNotActive}
+	var published []bid_usecase.BidOutputDTO
+	publisher := bid_usecase.BidPublisherFunc(func(bid bid_usecase.BidOutputDTO) {
+		published = append(published, bid)
+	})
+	bidUseCase := bid_usecase.NewBidUseCase(stub, nil, nil, publisher, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer bidUseCase.Shutdown(ctx)
+
+	input := bid_usecase.BidInputDTO{UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 100}
+	for _, attempt := range []string{"bid", "retry"} {
+		_, err := bidUseCase.CreateBid(ctx, input)
+		if err == nil || !errors.Is(err, internal_error.ErrAuctionNotActive) {
+			t.Fatalf("Expected the %s to fail as the write did, got %v", attempt, err)
+		}
+		if restErr := rest_err.ConvertError(err); restErr.Code != http.StatusConflict {
+			t.Errorf("Expected the %s to answer 409, got %d", attempt, restErr.Code)
+		}
+	}
+
+	if len(published) != 0 {
+		t.Errorf("Expected the rejected bids not to be published, got %+v", published)
+	}
+}
+
+func TestBidsPersistedExactlyOnceWhenShutdownInterruptsBatch(t *testing.T) {
+	// Small batches keep the writes going while the bids come in, so shutdown lands in
+	// the middle of a batch
+	config := bid_usecase.DefaultConfig()
+	config.MaxBatchSize = 7
+
+	stub := &bidRepositoryStub{}
+	bidUseCase := bid_usecase.NewBidUseCase(stub, nil, nil, nil, nil, nil, nil, config)
+
+	const submitters = 8
+	const bidsPerSubmitter = 200
+
+	var acceptedMu sync.Mutex
+	accepted := make(map[string]bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < submitters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			userId := uuid.New().String()
+			for j := 0; j < bidsPerSubmitter; j++ {
+				auctionId := uuid.New().String()
+				_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+					UserId:    userId,
+					AuctionId: auctionId,
+					Amount:    100,
+				})
+				if err != nil {
+					return
+				}
+
+				// Each bid gets its own auction, so the auction id identifies the bid
+				acceptedMu.Lock()
+				accepted[auctionId] = true
+				acceptedMu.Unlock()
+			}
+		}()
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := bidUseCase.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	wg.Wait()
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	persisted := make(map[string]int)
+	for _, bid := range stub.created {
+		persisted[bid.AuctionId]++
+	}
+
+	if len(accepted) == 0 {
+		t.Fatal("Expected some bids to be accepted before shutdown")
+	}
+
+	for auctionId := range accepted {
+		if persisted[auctionId] != 1 {
+			t.Errorf("Expected accepted bid on auction %s to be persisted once, got %d", auctionId, persisted[auctionId])
+		}
+	}
+
+	for auctionId := range persisted {
+		if !accepted[auctionId] {
+			t.Errorf("Bid on auction %s was persisted but never reported as accepted", auctionId)
+		}
+	}
+}
+
+// extensionRecorder keeps the auctions whose extension was published.
+type extensionRecorder struct {
+	mu       sync.Mutex
+	extended []string
+}
+
+func (r *extensionRecorder) PublishBid(bid bid_usecase.BidOutputDTO) {}
+
+func (r *extensionRecorder) PublishExtension(auctionId string, endTime time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.extended = append(r.extended, auctionId)
+}
+
+func TestCreateBidExtendsAuctionOnlyInSoftCloseMode(t *testing.T) {
+	testCases := []struct {
+		name           string
+		window         time.Duration
+		expectedExtend int
+	}{
+		{name: "Soft close disabled", window: 0, expectedExtend: 0},
+		{name: "Soft close enabled", window: time.Minute, expectedExtend: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := bid_usecase.DefaultConfig()
+			config.SnipeWindow = tc.window
+
+			auctionStub := &auctionRepositoryStub{}
+			recorder := &extensionRecorder{}
+			bidUseCase := bid_usecase.NewBidUseCase(
+				&bidRepositoryStub{}, auctionStub, nil, bid_usecase.BidPublishers{recorder}, nil, nil, nil, config)
+
+			_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: uuid.New().String(),
+				Amount:    100,
+			})
+			if err != nil {
+				t.Fatalf("Expected the bid to be accepted, got %v", err.Error())
+			}
+
+			// Rejected bids never extend the auction
+			bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    "invalid",
+				AuctionId: uuid.New().String(),
+				Amount:    100,
+			})
+
+			auctionStub.mu.Lock()
+			defer auctionStub.mu.Unlock()
+			if len(auctionStub.extensions) != tc.expectedExtend {
+				t.Errorf("Expected %d extension requests, got %d", tc.expectedExtend, len(auctionStub.extensions))
+			}
+
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			if len(recorder.extended) != tc.expectedExtend {
+				t.Errorf("Expected %d published extensions, got %d", tc.expectedExtend, len(recorder.extended))
+			}
+		})
+	}
+}
+
+func TestCreateBidKeepsWinningBidCurrency(t *testing.T) {
+	bidUseCase := bid_usecase.NewBidUseCase(&bidRepositoryStub{winningBid: winningBid(10000)}, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId:    uuid.New().String(),
+		AuctionId: uuid.New().String(),
+		Amount:    50000,
+		Currency:  "USD",
+	})
+	if err == nil || err.Err != internal_error.CurrencyMismatch {
+		t.Errorf("Expected a currency_mismatch error for a bid in another currency, got %v", err)
+	}
+}
+
+func TestCreateBidReadsTheAuctionOnce(t *testing.T) {
+	config := bid_usecase.DefaultConfig()
+	config.SnipeWindow = time.Minute
+
+	auctionStub := &auctionRepositoryStub{startingPrice: 1000}
+	bidUseCase := bid_usecase.NewBidUseCase(&bidRepositoryStub{}, auctionStub, nil, nil, nil, nil, nil, config)
+
+	bid, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId:    uuid.New().String(),
+		AuctionId: uuid.New().String(),
+		Amount:    5000,
+	})
+	if err != nil {
+		t.Fatalf("Expected the bid to be accepted, got %v", err.Error())
+	}
+	if bid.BidCount != 1 || bid.IsWinning == nil || !*bid.IsWinning {
+		t.Errorf("Expected the state to count the winning bid, got %+v", bid)
+	}
+
+	auctionStub.mu.Lock()
+	defer auctionStub.mu.Unlock()
+	if auctionStub.reads != 1 {
+		t.Errorf("Expected the auction to be read once per bid, got %d reads", auctionStub.reads)
+	}
+}
+
+func TestCreateBidRejectsAuctionNotTakingBids(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      auction_entity.AuctionStatus
+		expectedErr *internal_error.InternalError
+	}{
+		{name: "Scheduled", status: auction_entity.Scheduled, expectedErr: internal_error.ErrAuctionNotStarted},
+		{name: "Completed", status: auction_entity.Completed, expectedErr: internal_error.ErrAuctionNotActive},
+		{name: "Cancelled", status: auction_entity.Cancelled, expectedErr: internal_error.ErrAuctionNotActive},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &bidRepositoryStub{}
+			auctionStub := &auctionRepositoryStub{status: tc.status}
+			bidUseCase := bid_usecase.NewBidUseCase(stub, auctionStub, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+			t.Cleanup(func() { bidUseCase.Shutdown(context.Background()) })
+
+			_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 5000,
+			})
+			if err != tc.expectedErr {
+				t.Errorf("Expected %v, got %v", tc.expectedErr, err)
+			}
+
+			// The status comes from the auction read for the other checks
+			if auctionStub.reads != 1 || len(stub.created) != 0 {
+				t.Errorf("Expected one read and no bid written, got %d reads and %+v", auctionStub.reads, stub.created)
+			}
+		})
+	}
+}
+
+func TestCreateBidRequiresAuctionCurrency(t *testing.T) {
+	auctionStub := &auctionRepositoryStub{currency: "USD", startingPrice: 1000}
+	bidUseCase := bid_usecase.NewBidUseCase(&bidRepositoryStub{}, auctionStub, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	testCases := []struct {
+		name     string
+		currency string
+		amount   money.Amount
+		code     internal_error.ErrorCode
+		message  string
+	}{
+		{name: "Another currency", currency: "EUR", amount: 5000, code: internal_error.CurrencyMismatch, message: "USD"},
+		{name: "No currency", amount: 5000, code: internal_error.CurrencyMismatch, message: "USD"},
+		{name: "Below the starting price", currency: "USD", amount: 500, code: internal_error.BadRequest,
+			message: "USD 10.00"},
+		{name: "Auction currency", currency: "USD", amount: 5000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bid, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: uuid.New().String(),
+				Amount:    tc.amount,
+				Currency:  tc.currency,
+			})
+
+			if tc.code == "" {
+				if err != nil || bid.Currency != "USD" {
+					t.Fatalf("Expected the bid to be accepted in USD, got %+v, %v", bid, err)
+				}
+				return
+			}
+			if err == nil || err.Err != tc.code || !strings.Contains(err.Message, tc.message) {
+				t.Errorf("Expected a %s error mentioning %q, got %v", tc.code, tc.message, err)
+			}
+		})
+	}
+}
+
+func TestCreateBidRequiresStartingPriceOnFirstBid(t *testing.T) {
+	auctionStub := &auctionRepositoryStub{startingPrice: 5000}
+
+	testCases := []struct {
+		name       string
+		winningBid *bid_entity.Bid
+		amount     money.Amount
+		expectErr  bool
+	}{
+		{name: "First bid below the starting price", amount: 4999, expectErr: true},
+		{name: "First bid at the starting price", amount: 5000, expectErr: false},
+		{name: "Later bids only need the increment", winningBid: winningBid(2000), amount: 2100, expectErr: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bidUseCase := bid_usecase.NewBidUseCase(&bidRepositoryStub{winningBid: tc.winningBid}, auctionStub, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+			_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: uuid.New().String(),
+				Amount:    tc.amount,
+			})
+
+			if tc.expectErr && (err == nil || err.Err != internal_error.BadRequest) {
+				t.Errorf("Expected a bad_request error, got %v", err)
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("Expected the bid to be accepted, got %v", err.Error())
+			}
+		})
+	}
+}
+
+func TestCreateBidRejectsInvalidInput(t *testing.T) {
+	bidRepo := &bidRepositoryStub{}
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, nil, nil, nil, nil, nil, nil, bid_usecase.DefaultConfig())
+
+	testCases := []struct {
+		name  string
+		input bid_usecase.BidInputDTO
+	}{
+		{name: "Zero amount", input: bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 0}},
+		{name: "Negative amount", input: bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: -1}},
+		{name: "Unknown currency", input: bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 10, Currency: "brl"}},
+		{name: "Missing auction id", input: bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), Amount: 10}},
+		{name: "Missing user id", input: bid_usecase.BidInputDTO{
+			AuctionId: uuid.New().String(), Amount: 10}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := bidUseCase.CreateBid(context.Background(), tc.input)
+			if err == nil || err.Err != internal_error.BadRequest {
+				t.Errorf("Expected a bad_request error, got %v", err)
+			}
+		})
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	bidUseCase.Shutdown(shutdownCtx)
+
+	if len(bidRepo.created) != 0 {
+		t.Errorf("Expected no bids to be stored, got %d", len(bidRepo.created))
+	}
+}
+
+// notifierStub records the outbid notifications, optionally blocking until release is
+// closed to simulate a slow notifier.
+type notifierStub struct {
+	release chan struct{}
+
+	mu            sync.Mutex
+	notifications []bid_usecase.OutbidEvent
+}
+
+func (s *notifierStub) NotifyOutbid(ctx context.Context, previousBid, newBid bid_usecase.BidOutputDTO) {
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications = append(s.notifications, bid_usecase.OutbidEvent{PreviousBid: previousBid, NewBid: newBid})
+}
+
+func (s *notifierStub) NotifyWatchedAuctionClosed(
+	ctx context.Context, userId string, auction events.AuctionClosedEvent) {
+}
+
+func TestCreateBidNotifiesOutbidLeader(t *testing.T) {
+	auctionId := uuid.New().String()
+	leader := &bid_entity.Bid{Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auctionId,
+		Amount: 10000, Currency: money.DefaultCurrency}
+
+	notifier := &notifierStub{}
+	bidUseCase := bid_usecase.NewBidUseCase(&bidRepositoryStub{winningBid: leader}, nil, nil, nil, notifier, nil, nil, bid_usecase.DefaultConfig())
+
+	// The leader raising their own bid isn't an outbid
+	if _, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: leader.UserId, AuctionId: auctionId, Amount: 11000,
+	}); err != nil {
+		t.Fatalf("Expected the bid to be accepted, got %v", err.Error())
+	}
+
+	challenger := uuid.New().String()
+	if _, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: challenger, AuctionId: auctionId, Amount: 12000,
+	}); err != nil {
+		t.Fatalf("Expected the bid to be accepted, got %v", err.Error())
+	}
+
+	// Rejected bids don't outbid anyone
+	bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auctionId, Amount: 10050,
+	})
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := bidUseCase.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("Expected 1 outbid notification, got %d", len(notifier.notifications))
+	}
+
+	notification := notifier.notifications[0]
+	if notification.PreviousBid.Id != leader.Id || notification.NewBid.UserId != challenger {
+		t.Errorf("Unexpected notification: %+v", notification)
+	}
+}
+
+func TestSlowNotifierNeverBlocksBids(t *testing.T) {
+	leader := &bid_entity.Bid{Id: uuid.New().String(), UserId: uuid.New().String(),
+		Amount: 100, Currency: money.DefaultCurrency}
+
+	notifier := &notifierStub{release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(&bidRepositoryStub{winningBid: leader}, nil, nil, nil, notifier, nil, nil, bid_usecase.DefaultConfig())
+
+	// Far more outbids than the queue holds, while the notifier is stuck
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for i := 0; i < 300; i++ {
+			bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: uuid.New().String(), Amount: 1000,
+			})
+		}
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("CreateBid blocked on the notifier")
+	}
+
+	close(notifier.release)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bidUseCase.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.notifications) == 0 || len(notifier.notifications) >= 300 {
+		t.Errorf("Expected the overflowing notifications to be dropped, got %d delivered", len(notifier.notifications))
+	}
+}
+
+func TestChannelNotifierDropsOnOverflow(t *testing.T) {
+	notifier := bid_usecase.NewChannelNotifier(1)
+
+	first := bid_usecase.BidOutputDTO{Id: "first"}
+	second := bid_usecase.BidOutputDTO{Id: "second"}
+	notifier.NotifyOutbid(context.Background(), bid_usecase.BidOutputDTO{}, first)
+	notifier.NotifyOutbid(context.Background(), bid_usecase.BidOutputDTO{}, second)
+
+	event := <-notifier.Events()
+	if event.NewBid.Id != "first" {
+		t.Errorf("Expected the first event to be kept, got %s", event.NewBid.Id)
+	}
+
+	select {
+	case event := <-notifier.Events():
+		t.Errorf("Expected the second event to be dropped, got %s", event.NewBid.Id)
+	default:
+	}
+}
+
+func TestConcurrentBidsKeepAuctionCounters(t *testing.T) {
+	config := bid_usecase.DefaultConfig()
+	config.MinIncrement = 1
+
+	auctionRepo := memory.NewAuctionRepository(clock.NewFake(time.Now()))
+	bidRepo := memory.NewBidRepository(auctionRepo)
+	ctx := context.Background()
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	if err := auctionRepo.CreateAuction(ctx, auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, nil, nil, nil, config)
+	defer bidUseCase.Shutdown(ctx)
+
+	// Bids racing each other are partly rejected, as not beating the winning bid,
+	// either up front or when inserted
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(amount money.Amount) {
+			defer wg.Done()
+
+			bidUseCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: auctionEntity.Id,
+				Amount:    amount,
+			})
+		}(money.Amount(i * 100))
+	}
+	wg.Wait()
+	bidUseCase.Flush(ctx)
+
+	found, _ := auctionRepo.FindAuctionById(ctx, auctionEntity.Id)
+	bids, _ := bidRepo.FindBidByAuctionId(ctx, auctionEntity.Id, bid_entity.BidListFilter{})
+	if len(bids) == 0 || found.BidCount != int64(len(bids)) {
+		t.Errorf("Expected bid_count %d, got %d", len(bids), found.BidCount)
+	}
+
+	winning, findErr := bidRepo.FindWinningBidByAuctionId(ctx, auctionEntity.Id)
+	if findErr != nil {
+		t.Fatalf("Failed to find winning bid: %v", findErr.Error())
+	}
+	if found.CurrentHighestAmount != winning.Amount {
+		t.Errorf("Expected current_highest_amount %d, got %d", winning.Amount, found.CurrentHighestAmount)
+	}
+}
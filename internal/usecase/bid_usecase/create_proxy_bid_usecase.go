@@ -0,0 +1,126 @@
+package bid_usecase
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+)
+
+// CreateProxyBid stores maxAmount, in cents, as the most userId pays for the auction and
+// bids on their behalf: the lowest amount that takes the lead now, and later, within the
+// transaction storing each competing bid, increment above it up to maxAmount. The
+// current leader only updates their maximum. Vickrey auctions take no proxy bids.
+func (bu *BidUseCase) CreateProxyBid(
+	ctx context.Context, userId, auctionId string, maxAmount int64) *internal_error.InternalError {
+	auctionEntity, err := bu.findAuction(ctx, auctionId)
+	if err != nil {
+		return err
+	}
+
+	if err := bu.checkNotSeller(ctx, userId, auctionEntity); err != nil {
+		return err
+	}
+
+	if isVickrey(auctionEntity) {
+		return ErrProxyBidOnVickrey
+	}
+
+	leadingBid, err := bu.BidRepository.FindWinningBidByAuctionId(ctx, auctionId)
+	if err != nil {
+		if err.Err != internal_error.NotFound {
+			return err
+		}
+		leadingBid = nil
+	}
+
+	// Proxy bids are placed in the currency of the auction
+	currency := auctionCurrency(auctionEntity)
+
+	maxBid, err := bid_entity.CreateMaxBid(userId, auctionId, maxAmount, currency, bu.clock)
+	if err != nil {
+		return err
+	}
+
+	// The whole maximum may end up bid, so the balance must cover it up front
+	if err := bu.checkBidder(ctx, userId, maxAmount, leadingBid); err != nil {
+		return err
+	}
+
+	if leadingBid != nil && leadingBid.UserId == userId {
+		if maxAmount < leadingBid.Amount {
+			return internal_error.NewBadRequestError(
+				fmt.Sprintf("Maximum bid can't be lower than your winning bid of %s",
+					money.FormatCurrency(leadingBid.Amount, leadingBid.Currency)))
+		}
+
+		return bu.BidRepository.SaveMaxBid(ctx, maxBid)
+	}
+
+	minimumAmount := bu.minimumBid(auctionEntity, leadingBid)
+	if maxAmount < minimumAmount {
+		return internal_error.NewBadRequestError(
+			fmt.Sprintf("Maximum bid must be at least %s", money.FormatCurrency(minimumAmount, currency)))
+	}
+
+	if err := bu.BidRepository.SaveMaxBid(ctx, maxBid); err != nil {
+		return err
+	}
+
+	bidEntity, err := bid_entity.CreateBid(userId, auctionId, minimumAmount, currency, bu.clock)
+	if err != nil {
+		return err
+	}
+	bidEntity.Proxy = true
+
+	bids, err := bu.resolveProxyBids(ctx, *bidEntity, maxAmount, leadingBid)
+	if err != nil {
+		return err
+	}
+
+	_, err = bu.placeBids(ctx, auctionEntity, leadingBid, bids, false)
+	return err
+}
+
+// resolveProxyBids answers bid with the maximum bid of the leader, if any, returning the
+// bids to place in order. bidMax is the most the bidder pays, see
+// bid_entity.ResolveProxyBids.
+func (bu *BidUseCase) resolveProxyBids(
+	ctx context.Context,
+	bidEntity bid_entity.Bid,
+	bidMax int64,
+	leadingBid *bid_entity.Bid) ([]bid_entity.Bid, *internal_error.InternalError) {
+	if leadingBid == nil || leadingBid.UserId == bidEntity.UserId {
+		return []bid_entity.Bid{bidEntity}, nil
+	}
+
+	leadingMax := leadingBid.Amount
+	maxBid, err := bu.BidRepository.FindMaxBid(ctx, bidEntity.AuctionId, leadingBid.UserId)
+	if err != nil && err.Err != internal_error.NotFound {
+		return nil, err
+	}
+	if err == nil && maxBid.Amount > leadingMax {
+		leadingMax = maxBid.Amount
+	}
+
+	return bid_entity.ResolveProxyBids(bidEntity, bidMax, *leadingBid, leadingMax, bu.minIncrement), nil
+}
+
+// minimumBid is the lowest amount a new bid may have: minIncrement above the leading
+// bid, or the starting price while the auction has no bids.
+func (bu *BidUseCase) minimumBid(auctionEntity *auction_entity.Auction, leadingBid *bid_entity.Bid) int64 {
+	if leadingBid != nil {
+		if bu.minIncrement < 1 {
+			return leadingBid.Amount + 1
+		}
+		return leadingBid.Amount + bu.minIncrement
+	}
+
+	if auctionEntity == nil || auctionEntity.StartingPrice < 1 {
+		return 1
+	}
+
+	return auctionEntity.StartingPrice
+}
@@ -0,0 +1,205 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/infra/database/memory"
+	"fullcycle-auction_go/internal/money"
+	"fullcycle-auction_go/internal/usecase/bid_usecase"
+
+	"github.com/google/uuid"
+)
+
+// proxyAuction is an auction with a 10.00 starting price bid on through the memory
+// repositories. Every bid is written before it is answered, so the next one sees it.
+type proxyAuction struct {
+	t          *testing.T
+	auctionId  string
+	bidRepo    *memory.BidRepository
+	bidUseCase bid_usecase.BidUseCaseInterface
+	notifier   *notifierStub
+}
+
+func newProxyAuction(t *testing.T) *proxyAuction {
+	auctionRepo := memory.NewAuctionRepository(clock.NewFake(time.Now()))
+	bidRepo := memory.NewBidRepository(auctionRepo)
+
+	auctionEntity, err := auction_entity.CreateAuction(
+		uuid.New().String(), "Test Product", "Electronics", "Test auction description", auction_entity.New, time.Hour,
+		auction_entity.DurationBounds{}, clock.New())
+	if err != nil {
+		t.Fatalf("Failed to create auction entity: %v", err.Error())
+	}
+	auctionEntity.SetPrices(1000, 0)
+	if err := auctionRepo.CreateAuction(context.Background(), auctionEntity); err != nil {
+		t.Fatalf("Failed to create auction: %v", err.Error())
+	}
+
+	notifier := &notifierStub{}
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepo, auctionRepo, nil, nil, notifier, nil, nil, bid_usecase.DefaultConfig())
+	t.Cleanup(func() {
+		bidUseCase.Shutdown(context.Background())
+	})
+
+	return &proxyAuction{t: t, auctionId: auctionEntity.Id, bidRepo: bidRepo, bidUseCase: bidUseCase, notifier: notifier}
+}
+
+func (a *proxyAuction) proxyBid(userId string, maxAmount int64) {
+	a.t.Helper()
+
+	if err := a.bidUseCase.CreateProxyBid(context.Background(), userId, a.auctionId, maxAmount); err != nil {
+		a.t.Fatalf("Failed to place proxy bid: %v", err.Error())
+	}
+}
+
+func (a *proxyAuction) bid(userId string, amount int64) {
+	a.t.Helper()
+
+	_, err := a.bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: userId, AuctionId: a.auctionId, Amount: money.Amount(amount),
+	})
+	if err != nil {
+		a.t.Fatalf("Failed to place bid: %v", err.Error())
+	}
+}
+
+func (a *proxyAuction) expectWinner(userId string, amount int64) {
+	a.t.Helper()
+
+	winningBid, err := a.bidUseCase.FindWinningBidByAuctionId(context.Background(), a.auctionId)
+	if err != nil {
+		a.t.Fatalf("Failed to find winning bid: %v", err.Error())
+	}
+	if winningBid.UserId != userId || winningBid.Amount.Cents() != amount {
+		a.t.Errorf("Expected %s winning with %d, got %s with %d", userId, amount, winningBid.UserId, winningBid.Amount.Cents())
+	}
+}
+
+func TestCompetingProxyBidsSettleAtSecondMaxPlusIncrement(t *testing.T) {
+	for _, name := range []string{"Higher maximum first", "Higher maximum last"} {
+		t.Run(name, func(t *testing.T) {
+			auction := newProxyAuction(t)
+			low, high := uuid.New().String(), uuid.New().String()
+
+			if name == "Higher maximum first" {
+				auction.proxyBid(high, 15000)
+				auction.expectWinner(high, 1000)
+				auction.proxyBid(low, 10000)
+			} else {
+				auction.proxyBid(low, 10000)
+				auction.expectWinner(low, 1000)
+				auction.proxyBid(high, 15000)
+			}
+
+			auction.expectWinner(high, 10100)
+
+			bids, _ := auction.bidRepo.FindBidByAuctionId(
+				context.Background(), auction.auctionId, bid_entity.BidListFilter{Ascending: true})
+			last := bids[len(bids)-2:]
+			if last[0].UserId != low || last[0].Amount != 10000 || !last[0].Proxy || !last[1].Proxy {
+				t.Errorf("Expected the lower maximum to be bid in full right before the winning proxy bid, got %+v", last)
+			}
+		})
+	}
+}
+
+func TestProxyBidAnswersPlainBids(t *testing.T) {
+	auction := newProxyAuction(t)
+	proxyUser, plainUser := uuid.New().String(), uuid.New().String()
+
+	auction.proxyBid(proxyUser, 5000)
+	auction.bid(plainUser, 3000)
+	auction.expectWinner(proxyUser, 3100)
+
+	// Beating the maximum takes the lead for good
+	auction.bid(plainUser, 5100)
+	auction.expectWinner(plainUser, 5100)
+
+	// The notifications are sent apart from the bids, and Shutdown waits for them
+	auction.bidUseCase.Shutdown(context.Background())
+	auction.notifier.mu.Lock()
+	defer auction.notifier.mu.Unlock()
+
+	var outbid []string
+	for _, notification := range auction.notifier.notifications {
+		outbid = append(outbid, notification.PreviousBid.UserId)
+	}
+	// The plain bid outbids the proxy user, the proxy bid outbids the plain one back and
+	// the last plain bid outbids the proxy user for good
+	if len(outbid) != 3 || outbid[0] != proxyUser || outbid[1] != plainUser || outbid[2] != proxyUser {
+		t.Errorf("Expected proxy bids to notify like plain bids, got outbid users %v", outbid)
+	}
+}
+
+func TestEqualMaximumsGoToTheEarlierProxy(t *testing.T) {
+	auction := newProxyAuction(t)
+	first, second := uuid.New().String(), uuid.New().String()
+
+	auction.proxyBid(first, 8000)
+	auction.proxyBid(second, 8000)
+
+	auction.expectWinner(first, 8000)
+}
+
+func TestProxyBidValidatesMaximum(t *testing.T) {
+	auction := newProxyAuction(t)
+	leader := uuid.New().String()
+	auction.proxyBid(leader, 5000)
+
+	if err := auction.bidUseCase.CreateProxyBid(
+		context.Background(), uuid.New().String(), auction.auctionId, 1050); err == nil {
+		t.Error("Expected a maximum under the minimum increment to be rejected")
+	}
+
+	// The leader only moves their own maximum, without bidding against themselves
+	auction.proxyBid(leader, 9000)
+	auction.expectWinner(leader, 1000)
+
+	if err := auction.bidUseCase.CreateProxyBid(
+		context.Background(), leader, auction.auctionId, 500); err == nil {
+		t.Error("Expected the leader's maximum under their winning bid to be rejected")
+	}
+}
+
+func TestCreateBidReturnsTheAuctionState(t *testing.T) {
+	auction := newProxyAuction(t)
+	leader, challenger := uuid.New().String(), uuid.New().String()
+	auction.proxyBid(leader, 5000)
+
+	// The proxy answers right away, so the bid is accepted but not winning. Both are
+	// still queued, and counted along with the leader's stored bid
+	outbid, err := auction.bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: challenger, AuctionId: auction.auctionId, Amount: 2000,
+	})
+	if err != nil {
+		t.Fatalf("Expected the outbid bid to be accepted, got %v", err.Error())
+	}
+	if outbid.IsWinning == nil {
+		t.Fatal("Expected is_winning to be set on a public bid")
+	}
+	if *outbid.IsWinning || outbid.CurrentHighestAmount != 2100 || outbid.BidCount != 3 {
+		t.Errorf("Expected a losing bid under 21.00 with 3 bids, got winning %v under %v with %d bids",
+			*outbid.IsWinning, outbid.CurrentHighestAmount, outbid.BidCount)
+	}
+	if outbid.AuctionEndsAt.IsZero() {
+		t.Error("Expected the end time of the auction")
+	}
+	auction.bidUseCase.Flush(context.Background())
+
+	winning, err := auction.bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: challenger, AuctionId: auction.auctionId, Amount: 6000,
+	})
+	if err != nil {
+		t.Fatalf("Expected the winning bid to be accepted, got %v", err.Error())
+	}
+	if winning.IsWinning == nil || !*winning.IsWinning ||
+		winning.CurrentHighestAmount != 6000 || winning.BidCount != 4 {
+		t.Errorf("Expected a winning bid of 60.00 with 4 bids, got winning %v under %v with %d bids",
+			winning.IsWinning != nil && *winning.IsWinning, winning.CurrentHighestAmount, winning.BidCount)
+	}
+}
@@ -0,0 +1,28 @@
+package bid_usecase
+
+import (
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+)
+
+// checkCurrency requires the bid to be in the currency of its auction; amounts are never
+// converted. A bid sent without a currency is in money.DefaultCurrency, so it only
+// matches the auctions priced in it.
+func checkCurrency(currency string, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	if auctionCurrency := auctionCurrency(auctionEntity); currency != auctionCurrency {
+		return internal_error.NewCurrencyMismatchError(auctionCurrency)
+	}
+
+	return nil
+}
+
+// auctionCurrency is the currency the auction is priced in. Without an auction
+// repository there is no auction, which is taken to be in money.DefaultCurrency.
+func auctionCurrency(auctionEntity *auction_entity.Auction) string {
+	if auctionEntity == nil {
+		return money.DefaultCurrency
+	}
+
+	return auctionEntity.Currency
+}
@@ -0,0 +1,113 @@
+package bid_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// pendingBidKey is what a retried bid repeats: its auction, bidder and amount. The
+// repository keeps a unique index on the same fields.
+type pendingBidKey struct {
+	auctionId string
+	userId    string
+	amount    int64
+}
+
+func pendingKeyOf(bidEntity *bid_entity.Bid) pendingBidKey {
+	return pendingBidKey{auctionId: bidEntity.AuctionId, userId: bidEntity.UserId, amount: bidEntity.Amount}
+}
+
+// pendingBid is a bid queued and not written yet. Once the batch routine is done with
+// it, err holds the result of the write and written is closed.
+type pendingBid struct {
+	bid     bid_entity.Bid
+	written chan struct{}
+	err     *internal_error.InternalError
+}
+
+// wait returns the result of the write of the bid, once it is done.
+func (p *pendingBid) wait() *internal_error.InternalError {
+	<-p.written
+	return p.err
+}
+
+// duplicateOf answers a retry of the pending bid with the result of its write, so the
+// retry and the first request get the same answer.
+func (p *pendingBid) duplicateOf() (*BidOutputDTO, *internal_error.InternalError) {
+	if err := p.wait(); err != nil {
+		return nil, err
+	}
+
+	return duplicateBidOutput(&p.bid), nil
+}
+
+// findDuplicateBid returns the bid that bidEntity repeats, already stored or still
+// queued, once written, flagged as Duplicate. It returns nil when the user hasn't bid
+// this amount on the auction yet.
+func (bu *BidUseCase) findDuplicateBid(
+	ctx context.Context, bidEntity *bid_entity.Bid) (*BidOutputDTO, *internal_error.InternalError) {
+	bu.pendingMu.Lock()
+	pending, queued := bu.pendingBids[pendingKeyOf(bidEntity)]
+	bu.pendingMu.Unlock()
+
+	if queued {
+		return pending.duplicateOf()
+	}
+
+	storedBid, err := bu.BidRepository.FindBidByUserAndAmount(
+		ctx, bidEntity.AuctionId, bidEntity.UserId, bidEntity.Amount)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return duplicateBidOutput(storedBid), nil
+}
+
+// reservePending marks bidEntity as queued. It returns false, along with the queued bid,
+// when one with the same key is already waiting for the write.
+func (bu *BidUseCase) reservePending(bidEntity bid_entity.Bid) (*pendingBid, bool) {
+	bu.pendingMu.Lock()
+	defer bu.pendingMu.Unlock()
+
+	key := pendingKeyOf(&bidEntity)
+	if pending, queued := bu.pendingBids[key]; queued {
+		return pending, false
+	}
+
+	pending := &pendingBid{bid: bidEntity, written: make(chan struct{})}
+	bu.pendingBids[key] = pending
+	return pending, true
+}
+
+// releasePending forgets the bids of a batch once the repository is done with them,
+// handing each its result in results. Those it stored are found there from then on.
+func (bu *BidUseCase) releasePending(bidBatch []*pendingBid, results []*internal_error.InternalError) {
+	bu.pendingMu.Lock()
+	defer bu.pendingMu.Unlock()
+
+	for i, pending := range bidBatch {
+		key := pendingKeyOf(&pending.bid)
+		if bu.pendingBids[key] == pending {
+			delete(bu.pendingBids, key)
+		}
+
+		if i < len(results) {
+			pending.err = results[i]
+		} else {
+			pending.err = internal_error.NewInternalServerError("Error trying to insert bid")
+		}
+		close(pending.written)
+	}
+}
+
+func duplicateBidOutput(bidEntity *bid_entity.Bid) *BidOutputDTO {
+	bidOutput := toBidOutputDTO(bidEntity)
+	bidOutput.Duplicate = true
+
+	return &bidOutput
+}
@@ -0,0 +1,37 @@
+package bid_usecase
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// BidExportInputDTO picks the bids of an export. Empty fields don't filter; From and To
+// bound the bid timestamp, both included.
+type BidExportInputDTO struct {
+	AuctionId string
+	From      time.Time
+	To        time.Time
+}
+
+// ExportBids calls fn with every bid matching input, oldest first, streamed from the
+// repository so the export never sits in memory as a whole. Bids still waiting for their
+// batch to be written aren't part of it.
+func (bu *BidUseCase) ExportBids(
+	ctx context.Context,
+	input BidExportInputDTO,
+	fn func(bid BidOutputDTO) error) *internal_error.InternalError {
+	if !input.From.IsZero() && !input.To.IsZero() && input.From.After(input.To) {
+		return internal_error.NewValidationError("Invalid export range", internal_error.FieldError{
+			Field:   "from",
+			Message: "from must not be after to",
+		})
+	}
+
+	filter := bid_entity.BidExportFilter{AuctionId: input.AuctionId, From: input.From, To: input.To}
+	return bu.BidRepository.StreamBids(ctx, filter, func(bidEntity bid_entity.Bid) error {
+		return fn(toBidOutputDTO(&bidEntity))
+	})
+}
@@ -2,25 +2,130 @@ package bid_usecase
 
 import (
 	"context"
+	"encoding/base64"
+	"fullcycle-auction_go/internal/entity/bid_entity"
 	"fullcycle-auction_go/internal/internal_error"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// BidListInputDTO orders and pages the bids of an auction. A zero Limit lists every bid
+// from Offset on.
+type BidListInputDTO struct {
+	// Order is one of BidOrderOptions; empty lists the newest bid first
+	Order  string
+	Limit  int
+	Offset int
+
+	// Cursor is the NextCursor of the previous page; it takes precedence over Offset.
+	// Offset pages shift while bids arrive and will be removed in a future release
+	Cursor string
+}
+
+// BidListOutputDTO is a page of bids. NextCursor resumes the listing after its last bid
+// and is only set when the page is full, so more bids may follow.
+//
+// The bids of an auction hiding their amounts aren't listed at all: the list is empty,
+// Sealed is set and BidCount tells how many bids the auction got.
+type BidListOutputDTO struct {
+	Bids       []BidOutputDTO `json:"bids"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Sealed     bool           `json:"sealed,omitempty"`
+	BidCount   *int64         `json:"bid_count,omitempty"`
+}
+
+// BidOrderOptions are the accepted values of BidListInputDTO.Order.
+var BidOrderOptions = []string{"asc", "desc"}
+
 func (bu *BidUseCase) FindBidByAuctionId(
-	ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError) {
-	bidEntities, err := bu.BidRepository.FindBidByAuctionId(ctx, auctionId)
+	ctx context.Context,
+	auctionId string,
+	listInput BidListInputDTO) (*BidListOutputDTO, *internal_error.InternalError) {
+	if listInput.Order != "" && listInput.Order != "asc" && listInput.Order != "desc" {
+		message := "order must be one of " + strings.Join(BidOrderOptions, ", ")
+		return nil, internal_error.NewValidationError("Invalid order option",
+			internal_error.FieldError{Field: "order", Message: message})
+	}
+
+	sealedAuction, err := bu.sealedAuction(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if sealedAuction != nil {
+		return &BidListOutputDTO{Bids: []BidOutputDTO{}, Sealed: true, BidCount: &sealedAuction.BidCount}, nil
+	}
+
+	listFilter := bid_entity.BidListFilter{
+		Ascending: listInput.Order == "asc",
+		Limit:     listInput.Limit,
+		Offset:    listInput.Offset,
+	}
+	if listInput.Cursor != "" {
+		cursor, err := decodeBidCursor(listInput.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		listFilter.After, listFilter.Offset = cursor, 0
+	}
+
+	bidEntities, err := bu.BidRepository.FindBidByAuctionId(ctx, auctionId, listFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	bidOutputDTOs := make([]BidOutputDTO, 0, len(bidEntities))
+	for _, bid := range bidEntities {
+		bidOutputDTOs = append(bidOutputDTOs, toBidOutputDTO(&bid))
+	}
+
+	output := &BidListOutputDTO{Bids: bidOutputDTOs}
+	if listInput.Limit > 0 && len(bidEntities) == listInput.Limit {
+		output.NextCursor = encodeBidCursor(bidEntities[len(bidEntities)-1])
+	}
+
+	return output, nil
+}
+
+// encodeBidCursor makes the opaque cursor pointing to bid: the base64 of its timestamp
+// in Unix nanoseconds and its id.
+func encodeBidCursor(bid bid_entity.Bid) string {
+	raw := strconv.FormatInt(bid.Timestamp.UnixNano(), 10) + ":" + bid.Id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeBidCursor(cursor string) (*bid_entity.BidCursor, *internal_error.InternalError) {
+	invalid := internal_error.NewValidationError("Invalid cursor",
+		internal_error.FieldError{Field: "cursor", Message: "Must be the next_cursor of a previous page"})
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+
+	timestamp, id, found := strings.Cut(string(raw), ":")
+	if !found || id == "" {
+		return nil, invalid
+	}
+
+	nanos, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+
+	return &bid_entity.BidCursor{Timestamp: time.Unix(0, nanos), Id: id}, nil
+}
+
+func (bu *BidUseCase) FindBidsByUserId(
+	ctx context.Context, userId string, onlyActiveAuctions bool) ([]BidOutputDTO, *internal_error.InternalError) {
+	bidEntities, err := bu.BidRepository.FindBidsByUserId(ctx, userId, onlyActiveAuctions)
 	if err != nil {
 		return nil, err
 	}
 
-	var bidOutputDTOs []BidOutputDTO
+	bidOutputDTOs := make([]BidOutputDTO, 0, len(bidEntities))
 	for _, bid := range bidEntities {
-		bidOutputDTOs = append(bidOutputDTOs, BidOutputDTO{
-			Id:        bid.Id,
-			UserId:    bid.UserId,
-			AuctionId: bid.AuctionId,
-			Amount:    bid.Amount,
-			Timestamp: bid.Timestamp,
-		})
+		bidOutputDTOs = append(bidOutputDTOs, toBidOutputDTO(&bid))
 	}
 
 	return bidOutputDTOs, nil
@@ -33,11 +138,15 @@ func (bu *BidUseCase) FindWinningBidByAuctionId(
 		return nil, err
 	}
 
-	return &BidOutputDTO{
-		Id:        bidEntity.Id,
-		UserId:    bidEntity.UserId,
-		AuctionId: bidEntity.AuctionId,
-		Amount:    bidEntity.Amount,
-		Timestamp: bidEntity.Timestamp,
-	}, nil
+	sealedAuction, err := bu.sealedAuction(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	bidOutputDTO := toBidOutputDTO(bidEntity)
+	if sealedAuction != nil {
+		bidOutputDTO = sealBid(bidOutputDTO)
+	}
+
+	return &bidOutputDTO, nil
 }
@@ -0,0 +1,95 @@
+package bid_usecase
+
+import (
+	"context"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/events"
+
+	"go.uber.org/zap"
+)
+
+// Notifier is told when an accepted bid takes the lead from another user's bid, so the
+// previous leader can be warned. BidUseCase calls it from its own goroutine, so a slow
+// implementation delays other notifications but never a bid.
+//
+// It is told too, once per watcher, when an auction a user watches closes.
+type Notifier interface {
+	NotifyOutbid(ctx context.Context, previousBid, newBid BidOutputDTO)
+	NotifyWatchedAuctionClosed(ctx context.Context, userId string, auction events.AuctionClosedEvent)
+}
+
+// LogNotifier only logs the outbid events. It is the default Notifier.
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyOutbid(ctx context.Context, previousBid, newBid BidOutputDTO) {
+	logger.InfoContext(ctx, "Bid outbid",
+		zap.String("auction_id", newBid.AuctionId),
+		zap.String("previous_user_id", previousBid.UserId),
+		zap.Stringer("previous_amount", previousBid.Amount),
+		zap.String("new_user_id", newBid.UserId),
+		zap.Stringer("new_amount", newBid.Amount))
+}
+
+func (LogNotifier) NotifyWatchedAuctionClosed(ctx context.Context, userId string, auction events.AuctionClosedEvent) {
+	logger.InfoContext(ctx, "Watched auction closed",
+		zap.String("auction_id", auction.AuctionId),
+		zap.String("user_id", userId),
+		zap.Int("outcome", int(auction.Outcome)))
+}
+
+type OutbidEvent struct {
+	PreviousBid BidOutputDTO `json:"previous_bid"`
+	NewBid      BidOutputDTO `json:"new_bid"`
+}
+
+// WatchedAuctionClosedEvent tells a user an auction on the user's watchlist closed.
+type WatchedAuctionClosedEvent struct {
+	UserId  string                    `json:"user_id"`
+	Auction events.AuctionClosedEvent `json:"auction"`
+}
+
+// ChannelNotifier hands outbid events to a consumer, such as the WebSocket hub or an
+// email sender, through a bounded channel, and the watched auction closes through
+// another one. Events that don't fit are dropped and logged.
+type ChannelNotifier struct {
+	events        chan OutbidEvent
+	watchedEvents chan WatchedAuctionClosedEvent
+}
+
+func NewChannelNotifier(size int) *ChannelNotifier {
+	return &ChannelNotifier{
+		events:        make(chan OutbidEvent, size),
+		watchedEvents: make(chan WatchedAuctionClosedEvent, size),
+	}
+}
+
+// Events is the channel the consumer reads from.
+func (n *ChannelNotifier) Events() <-chan OutbidEvent {
+	return n.events
+}
+
+// WatchedAuctionClosedEvents is the channel the consumer of the watched auction closes
+// reads from.
+func (n *ChannelNotifier) WatchedAuctionClosedEvents() <-chan WatchedAuctionClosedEvent {
+	return n.watchedEvents
+}
+
+func (n *ChannelNotifier) NotifyOutbid(ctx context.Context, previousBid, newBid BidOutputDTO) {
+	select {
+	case n.events <- OutbidEvent{PreviousBid: previousBid, NewBid: newBid}:
+	default:
+		logger.Info("Outbid event dropped, consumer is falling behind",
+			zap.String("auction_id", newBid.AuctionId), zap.String("bid_id", newBid.Id))
+	}
+}
+
+func (n *ChannelNotifier) NotifyWatchedAuctionClosed(
+	ctx context.Context, userId string, auction events.AuctionClosedEvent) {
+	select {
+	case n.watchedEvents <- WatchedAuctionClosedEvent{UserId: userId, Auction: auction}:
+	default:
+		logger.Info("Watched auction closed event dropped, consumer is falling behind",
+			zap.String("auction_id", auction.AuctionId), zap.String("user_id", userId))
+	}
+}
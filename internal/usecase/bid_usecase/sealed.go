@@ -0,0 +1,76 @@
+package bid_usecase
+
+import (
+	"context"
+
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// ErrProxyBidOnVickrey rejects proxy bids on Vickrey auctions: they raise the bid of
+// their user in answer to the others, which sealed bids never reveal.
+var ErrProxyBidOnVickrey = internal_error.NewConflictError("Proxy bids can't be placed on vickrey auctions")
+
+// isVickrey reports whether the auction is a Vickrey auction, whose bids are sealed.
+// Without an auction repository there is no auction, which is taken to be English.
+func isVickrey(auctionEntity *auction_entity.Auction) bool {
+	return auctionEntity != nil && auctionEntity.AuctionType == auction_entity.Vickrey
+}
+
+// sealedAuction returns the auction when the amounts of its bids are hidden, see
+// auction_entity.Auction.BidAmountsHidden, and nil otherwise. An auction that can't be
+// found isn't hidden; the caller answers for it as it did before.
+func (bu *BidUseCase) sealedAuction(
+	ctx context.Context, auctionId string) (*auction_entity.Auction, *internal_error.InternalError) {
+	if bu.auctionRepository == nil {
+		return nil, nil
+	}
+
+	auctionEntity, err := bu.auctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if !auctionEntity.BidAmountsHidden() {
+		return nil, nil
+	}
+
+	return auctionEntity, nil
+}
+
+// validateSealedBid only requires a bid on a Vickrey auction to meet the starting
+// price, since the bidder can't know the amounts to beat. It returns the leading bid,
+// if any, which the bid may or may not displace.
+func (bu *BidUseCase) validateSealedBid(
+	ctx context.Context,
+	bidEntity *bid_entity.Bid,
+	auctionEntity *auction_entity.Auction) (*bid_entity.Bid, *internal_error.InternalError) {
+	if err := validateStartingPrice(bidEntity, auctionEntity); err != nil {
+		return nil, err
+	}
+
+	leadingBid, err := bu.BidRepository.FindWinningBidByAuctionId(ctx, bidEntity.AuctionId)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return leadingBid, nil
+}
+
+// sealBid leaves the amount out of a bid on a sealed auction, for everyone but the
+// bidder.
+func sealBid(bid BidOutputDTO) BidOutputDTO {
+	bid.Amount = 0
+	bid.Sealed = true
+
+	return bid
+}
@@ -0,0 +1,59 @@
+package bid_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+// SelfBidCountOutputDTO is a seller who bid on their own auctions, in the admin report.
+type SelfBidCountOutputDTO struct {
+	UserId       string `json:"user_id"`
+	AuctionCount int64  `json:"auction_count"`
+	BidCount     int64  `json:"bid_count"`
+}
+
+// checkNotSeller rejects a bid of the seller on their own auction. Legacy auctions have no
+// seller to compare with, so their bids are allowed with a warning, as are all bids when
+// there is no auction, see findAuction.
+func (bu *BidUseCase) checkNotSeller(
+	ctx context.Context, userId string, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	if auctionEntity == nil {
+		return nil
+	}
+
+	if auctionEntity.SellerId == "" {
+		logger.WarnContext(ctx, "Auction has no seller, self bidding can't be checked",
+			zap.String("auction_id", auctionEntity.Id), zap.String("user_id", userId))
+		return nil
+	}
+	if auctionEntity.IsOwnedBy(userId) {
+		return internal_error.ErrSelfBid
+	}
+
+	return nil
+}
+
+// CountSelfBids reports the sellers who bid on their own auctions, e.g. before
+// self bidding was rejected.
+func (bu *BidUseCase) CountSelfBids(
+	ctx context.Context) ([]SelfBidCountOutputDTO, *internal_error.InternalError) {
+	counts, err := bu.BidRepository.CountBidsByUserOnOwnAuctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]SelfBidCountOutputDTO, 0, len(counts))
+	for _, count := range counts {
+		output = append(output, SelfBidCountOutputDTO{
+			UserId:       count.UserId,
+			AuctionCount: count.AuctionCount,
+			BidCount:     count.BidCount,
+		})
+	}
+
+	return output, nil
+}
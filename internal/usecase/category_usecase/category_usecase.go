@@ -0,0 +1,140 @@
+package category_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/category_entity"
+	"fullcycle-auction_go/internal/internal_error"
+
+	"go.uber.org/zap"
+)
+
+// NewCategoryUseCase checks the default durations of new categories against
+// durationBounds, like the auctions created with them.
+func NewCategoryUseCase(
+	categoryRepository category_entity.CategoryRepositoryInterface,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	durationBounds auction_entity.DurationBounds) CategoryUseCaseInterface {
+	return &CategoryUseCase{
+		CategoryRepository: categoryRepository,
+		AuctionRepository:  auctionRepository,
+		durationBounds:     durationBounds,
+	}
+}
+
+type CategoryUseCase struct {
+	CategoryRepository category_entity.CategoryRepositoryInterface
+
+	// AuctionRepository follows the renames, as auctions store the category name
+	AuctionRepository auction_entity.AuctionRepositoryInterface
+
+	durationBounds auction_entity.DurationBounds
+}
+
+type CategoryInputDTO struct {
+	Name string `json:"name" binding:"required,min=3,max=50"`
+
+	// DefaultDurationSeconds is the duration of the auctions of the category that don't
+	// set one; zero leaves them the global default
+	DefaultDurationSeconds int64 `json:"default_duration_seconds" binding:"omitempty,gt=0"`
+}
+
+// CategoryRenameInputDTO is the new name of a category.
+type CategoryRenameInputDTO struct {
+	Name string `json:"name" binding:"required,min=3,max=50"`
+}
+
+type CategoryOutputDTO struct {
+	Id                     string `json:"id"`
+	Name                   string `json:"name"`
+	Slug                   string `json:"slug"`
+	DefaultDurationSeconds int64  `json:"default_duration_seconds,omitempty"`
+}
+
+type CategoryUseCaseInterface interface {
+	CreateCategory(
+		ctx context.Context,
+		categoryInput CategoryInputDTO) (*CategoryOutputDTO, *internal_error.InternalError)
+
+	FindAllCategories(ctx context.Context) ([]CategoryOutputDTO, *internal_error.InternalError)
+
+	RenameCategory(
+		ctx context.Context,
+		id string,
+		renameInput CategoryRenameInputDTO) (*CategoryOutputDTO, *internal_error.InternalError)
+}
+
+func (u *CategoryUseCase) CreateCategory(
+	ctx context.Context, categoryInput CategoryInputDTO) (*CategoryOutputDTO, *internal_error.InternalError) {
+	categoryEntity, err := category_entity.CreateCategory(
+		categoryInput.Name, u.durationBounds, categoryInput.DefaultDurationSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.CategoryRepository.CreateCategory(ctx, categoryEntity); err != nil {
+		return nil, err
+	}
+
+	categoryOutput := toCategoryOutputDTO(*categoryEntity)
+	return &categoryOutput, nil
+}
+
+func (u *CategoryUseCase) FindAllCategories(
+	ctx context.Context) ([]CategoryOutputDTO, *internal_error.InternalError) {
+	categories, err := u.CategoryRepository.FindAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryOutputs := make([]CategoryOutputDTO, 0, len(categories))
+	for _, category := range categories {
+		categoryOutputs = append(categoryOutputs, toCategoryOutputDTO(category))
+	}
+
+	return categoryOutputs, nil
+}
+
+// RenameCategory renames the category and the category of its auctions. The slug of the
+// old name keeps leading to the category, see category_entity.Category.Rename.
+func (u *CategoryUseCase) RenameCategory(
+	ctx context.Context,
+	id string,
+	renameInput CategoryRenameInputDTO) (*CategoryOutputDTO, *internal_error.InternalError) {
+	categoryEntity, err := u.CategoryRepository.FindCategoryById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previousName := categoryEntity.Name
+	if err := categoryEntity.Rename(renameInput.Name); err != nil {
+		return nil, err
+	}
+
+	if err := u.CategoryRepository.UpdateCategory(ctx, categoryEntity); err != nil {
+		return nil, err
+	}
+
+	if categoryEntity.Name != previousName {
+		renamed, err := u.AuctionRepository.RenameCategory(ctx, previousName, categoryEntity.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.InfoContext(ctx, "Category renamed",
+			zap.String("category_id", id), zap.String("from", previousName), zap.Int64("auctions", renamed))
+	}
+
+	categoryOutput := toCategoryOutputDTO(*categoryEntity)
+	return &categoryOutput, nil
+}
+
+func toCategoryOutputDTO(category category_entity.Category) CategoryOutputDTO {
+	return CategoryOutputDTO{
+		Id:                     category.Id,
+		Name:                   category.Name,
+		Slug:                   category.Slug,
+		DefaultDurationSeconds: category.DefaultDurationSeconds,
+	}
+}
@@ -0,0 +1,165 @@
+package rating_usecase
+
+import (
+	"context"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/rating_entity"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// RatingInputDTO is the rating the winner gives the seller, a score from 1 to 5 and an
+// optional comment.
+type RatingInputDTO struct {
+	Score   int    `json:"score"`
+	Comment string `json:"comment"`
+}
+
+type RatingOutputDTO struct {
+	Id        string    `json:"id"`
+	AuctionId string    `json:"auction_id"`
+	SellerId  string    `json:"seller_id"`
+	RaterId   string    `json:"rater_id"`
+	Score     int       `json:"score"`
+	Comment   string    `json:"comment,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SellerRatingsOutputDTO is a page of the ratings of a seller along with the average of
+// all of them, null before the first one.
+type SellerRatingsOutputDTO struct {
+	SellerId      string            `json:"seller_id"`
+	AverageRating *float64          `json:"average_rating"`
+	RatingCount   int64             `json:"rating_count"`
+	Ratings       []RatingOutputDTO `json:"ratings"`
+}
+
+var (
+	ErrAuctionNotSold = internal_error.NewConflictError("Auction was not sold, there is no seller to rate")
+	ErrRaterNotWinner = internal_error.NewForbiddenError("Only the winner of the auction can rate its seller")
+)
+
+type RatingUseCase struct {
+	ratingRepository  rating_entity.RatingRepositoryInterface
+	auctionRepository auction_entity.AuctionRepositoryInterface
+	userRepository    user_entity.UserRepositoryInterface
+	clock             clock.Clock
+}
+
+// NewRatingUseCase stamps the ratings with the time of clk, the real clock when nil.
+func NewRatingUseCase(
+	ratingRepository rating_entity.RatingRepositoryInterface,
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	userRepository user_entity.UserRepositoryInterface,
+	clk clock.Clock) RatingUseCaseInterface {
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	return &RatingUseCase{
+		ratingRepository:  ratingRepository,
+		auctionRepository: auctionRepository,
+		userRepository:    userRepository,
+		clock:             clk,
+	}
+}
+
+type RatingUseCaseInterface interface {
+	CreateRating(
+		ctx context.Context,
+		raterId, auctionId string,
+		ratingInput RatingInputDTO) (*RatingOutputDTO, *internal_error.InternalError)
+
+	FindRatingsBySellerId(
+		ctx context.Context,
+		sellerId string,
+		page, pageSize int) (*SellerRatingsOutputDTO, int64, *internal_error.InternalError)
+}
+
+// CreateRating rates the seller of the auction on behalf of the rater, who must be its
+// winner as recorded in the winner snapshot when it closed. Auctions that closed without
+// a sale, or before the snapshots existed, fail with ErrAuctionNotSold, and a second
+// rating of the auction with ErrAuctionAlreadyRated.
+func (ru *RatingUseCase) CreateRating(
+	ctx context.Context,
+	raterId, auctionId string,
+	ratingInput RatingInputDTO) (*RatingOutputDTO, *internal_error.InternalError) {
+	auctionEntity, err := ru.auctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return nil, internal_error.NewAuctionNotFoundError(auctionId).Wrap(err)
+		}
+		return nil, err
+	}
+
+	if auctionEntity.Status != auction_entity.Completed || !auctionEntity.Outcome.IsSale() ||
+		auctionEntity.WinningBid == nil {
+		return nil, ErrAuctionNotSold
+	}
+	if auctionEntity.WinningBid.UserId != raterId {
+		return nil, ErrRaterNotWinner
+	}
+
+	ratingEntity, err := rating_entity.CreateRating(
+		auctionEntity.Id, auctionEntity.SellerId, raterId, ratingInput.Score, ratingInput.Comment, ru.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ru.ratingRepository.CreateRating(ctx, ratingEntity); err != nil {
+		return nil, err
+	}
+
+	output := toRatingOutputDTO(*ratingEntity)
+	return &output, nil
+}
+
+// FindRatingsBySellerId returns a page of the ratings of the seller, the most recent
+// first, and how many ratings the seller has. The average comes from the counters of the
+// seller, so it covers every rating, not only the page.
+func (ru *RatingUseCase) FindRatingsBySellerId(
+	ctx context.Context,
+	sellerId string,
+	page, pageSize int) (*SellerRatingsOutputDTO, int64, *internal_error.InternalError) {
+	seller, err := ru.userRepository.FindUserById(ctx, sellerId)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return nil, 0, internal_error.NewUserNotFoundError(sellerId).Wrap(err)
+		}
+		return nil, 0, err
+	}
+
+	ratings, total, err := ru.ratingRepository.FindRatingsBySellerId(ctx, sellerId, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	output := &SellerRatingsOutputDTO{
+		SellerId:    sellerId,
+		RatingCount: seller.RatingCount,
+		Ratings:     make([]RatingOutputDTO, 0, len(ratings)),
+	}
+	if average, ok := seller.AverageRating(); ok {
+		output.AverageRating = &average
+	}
+	for _, rating := range ratings {
+		output.Ratings = append(output.Ratings, toRatingOutputDTO(rating))
+	}
+
+	return output, total, nil
+}
+
+func toRatingOutputDTO(rating rating_entity.Rating) RatingOutputDTO {
+	return RatingOutputDTO{
+		Id:        rating.Id,
+		AuctionId: rating.AuctionId,
+		SellerId:  rating.SellerId,
+		RaterId:   rating.RaterId,
+		Score:     rating.Score,
+		Comment:   rating.Comment,
+		Timestamp: rating.Timestamp.UTC(),
+	}
+}
@@ -0,0 +1,113 @@
+package report_usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/configuration/logger"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+)
+
+const (
+	// statsQueryTimeout is how long each query of the dashboard may take before its
+	// figures are left out
+	statsQueryTimeout = 2 * time.Second
+
+	// timeToFirstBidPeriod is how far back the auctions averaged for the time to the
+	// first bid were created
+	timeToFirstBidPeriod = 7 * 24 * time.Hour
+)
+
+// DashboardStatsOutputDTO sums up the state of the platform for the operations dashboard.
+// A group of figures whose query failed or timed out is null, so the others still show.
+type DashboardStatsOutputDTO struct {
+	Auctions *AuctionCountsOutputDTO `json:"auctions"`
+	Bids     *BidActivityOutputDTO   `json:"bids"`
+
+	// AvgTimeToFirstBidSeconds averages, over the auctions created in the last 7 days
+	// that got bids, the time from their start to their first bid
+	AvgTimeToFirstBidSeconds *float64 `json:"avg_time_to_first_bid_seconds"`
+
+	// CloseLagP95Seconds is the 95th percentile of the time between the end of an
+	// auction and its close, as recorded by the closer of this instance since it started
+	CloseLagP95Seconds *float64 `json:"close_lag_p95_seconds"`
+
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type AuctionCountsOutputDTO struct {
+	Active    int64 `json:"active"`
+	Completed int64 `json:"completed"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+type BidActivityOutputDTO struct {
+	LastHour int64 `json:"last_hour"`
+	LastDay  int64 `json:"last_day"`
+}
+
+// DashboardStats runs the queries of the dashboard concurrently, each within
+// statsQueryTimeout, and returns whatever they found. The failures are logged.
+func (ru *ReportUseCase) DashboardStats(ctx context.Context) *DashboardStatsOutputDTO {
+	now := ru.clock.Now()
+	stats := &DashboardStatsOutputDTO{GeneratedAt: now.UTC()}
+
+	var wg sync.WaitGroup
+	run := func(query func(ctx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, statsQueryTimeout)
+			defer cancel()
+			query(ctx)
+		}()
+	}
+
+	run(func(ctx context.Context) {
+		counts, err := ru.auctionRepository.CountAuctionsByStatus(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to count auctions for the dashboard", err)
+			return
+		}
+
+		stats.Auctions = &AuctionCountsOutputDTO{
+			Active:    counts[auction_entity.Active],
+			Completed: counts[auction_entity.Completed],
+			Cancelled: counts[auction_entity.Cancelled],
+		}
+	})
+
+	run(func(ctx context.Context) {
+		activity, err := ru.bidRepository.CountRecentBids(ctx, now)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to count bids for the dashboard", err)
+			return
+		}
+
+		stats.Bids = &BidActivityOutputDTO{LastHour: activity.LastHour, LastDay: activity.LastDay}
+	})
+
+	run(func(ctx context.Context) {
+		average, ok, err := ru.bidRepository.AverageTimeToFirstBid(ctx, now.Add(-timeToFirstBidPeriod))
+		if err != nil {
+			logger.ErrorContext(ctx, "Error trying to average the time to first bid for the dashboard", err)
+			return
+		}
+
+		if ok {
+			seconds := average.Seconds()
+			stats.AvgTimeToFirstBidSeconds = &seconds
+		}
+	})
+
+	if ru.closeLag != nil {
+		if p95, ok := ru.closeLag(0.95); ok {
+			stats.CloseLagP95Seconds = &p95
+		}
+	}
+
+	wg.Wait()
+	return stats
+}
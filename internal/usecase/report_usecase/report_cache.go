@@ -0,0 +1,61 @@
+package report_usecase
+
+import (
+	"sync"
+	"time"
+
+	"fullcycle-auction_go/internal/clock"
+)
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// reportCache keeps each report for ttl after it was computed. Expired entries are
+// dropped whenever a new one is stored, so the cache only grows with the distinct
+// reports asked for within ttl.
+type reportCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	clock   clock.Clock
+	ttl     time.Duration
+}
+
+func newReportCache(clk clock.Clock, ttl time.Duration) *reportCache {
+	return &reportCache{
+		entries: make(map[string]cacheEntry),
+		clock:   clk,
+		ttl:     ttl,
+	}
+}
+
+func (rc *reportCache) get(key string) (interface{}, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok || !rc.clock.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (rc *reportCache) set(key string, value interface{}) {
+	if rc.ttl <= 0 {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := rc.clock.Now()
+	for existingKey, entry := range rc.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(rc.entries, existingKey)
+		}
+	}
+
+	rc.entries[key] = cacheEntry{value: value, expiresAt: now.Add(rc.ttl)}
+}
@@ -0,0 +1,194 @@
+package report_usecase
+
+import (
+	"context"
+	"fmt"
+	"fullcycle-auction_go/internal/clock"
+	"fullcycle-auction_go/internal/entity/auction_entity"
+	"fullcycle-auction_go/internal/entity/bid_entity"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+	"time"
+)
+
+const (
+	defaultReportLimit = 10
+	maxReportLimit     = 100
+
+	// defaultReportPeriod is how far back a report goes without a from, a week
+	defaultReportPeriod = 7 * 24 * time.Hour
+)
+
+// ReportInputDTO is the period a report covers and how many entries it ranks. A zero To
+// means now, a zero From a week before To and a zero Limit 10; Limit is capped at 100.
+type ReportInputDTO struct {
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// TopBidderOutputDTO is a user's bids in one currency; a user who bid in several
+// currencies is ranked once per currency, as totals are never added across them.
+type TopBidderOutputDTO struct {
+	UserId      string       `json:"user_id"`
+	UserName    string       `json:"user_name"`
+	Currency    string       `json:"currency"`
+	BidCount    int64        `json:"bid_count"`
+	TotalAmount money.Amount `json:"total_amount"`
+}
+
+// TopSellerOutputDTO is a seller's sales in one currency, see TopBidderOutputDTO.
+type TopSellerOutputDTO struct {
+	SellerId     string       `json:"seller_id"`
+	SellerName   string       `json:"seller_name"`
+	Currency     string       `json:"currency"`
+	AuctionsSold int64        `json:"auctions_sold"`
+	TotalSold    money.Amount `json:"total_sold"`
+}
+
+type ReportUseCaseInterface interface {
+	TopBidders(ctx context.Context, reportInput ReportInputDTO) ([]TopBidderOutputDTO, *internal_error.InternalError)
+
+	TopSellers(ctx context.Context, reportInput ReportInputDTO) ([]TopSellerOutputDTO, *internal_error.InternalError)
+
+	DashboardStats(ctx context.Context) *DashboardStatsOutputDTO
+}
+
+// QuantileFunc returns the q quantile of a distribution recorded elsewhere, e.g. in a
+// metrics histogram, and false while there is no sample yet.
+type QuantileFunc func(q float64) (float64, bool)
+
+type ReportUseCase struct {
+	auctionRepository auction_entity.AuctionRepositoryInterface
+	bidRepository     bid_entity.BidEntityRepository
+	clock             clock.Clock
+
+	// closeLag reads the delay of the closer, see DashboardStats
+	closeLag QuantileFunc
+
+	// The aggregations read whole collections, so their results are reused for a while
+	cache *reportCache
+}
+
+// NewReportUseCase builds the leaderboards, caching each of them for cacheTTL; zero
+// disables the cache. A nil clock uses the real clock, and the dashboard leaves out the
+// close lag without closeLag.
+func NewReportUseCase(
+	auctionRepository auction_entity.AuctionRepositoryInterface,
+	bidRepository bid_entity.BidEntityRepository,
+	clk clock.Clock,
+	cacheTTL time.Duration,
+	closeLag QuantileFunc) ReportUseCaseInterface {
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	return &ReportUseCase{
+		auctionRepository: auctionRepository,
+		bidRepository:     bidRepository,
+		clock:             clk,
+		closeLag:          closeLag,
+		cache:             newReportCache(clk, cacheTTL),
+	}
+}
+
+// TopBidders ranks the users by the bids they placed in the period, then by their total.
+func (ru *ReportUseCase) TopBidders(
+	ctx context.Context, reportInput ReportInputDTO) ([]TopBidderOutputDTO, *internal_error.InternalError) {
+	from, to, limit, err := ru.resolveInput(reportInput)
+	if err != nil {
+		return nil, err
+	}
+
+	key := cacheKey("top_bidders", from, to, limit)
+	if cached, ok := ru.cache.get(key); ok {
+		return cached.([]TopBidderOutputDTO), nil
+	}
+
+	topBidders, err := ru.bidRepository.AggregateTopBidders(ctx, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	topBidderOutputs := make([]TopBidderOutputDTO, 0, len(topBidders))
+	for _, topBidder := range topBidders {
+		topBidderOutputs = append(topBidderOutputs, TopBidderOutputDTO{
+			UserId:      topBidder.UserId,
+			UserName:    topBidder.UserName,
+			Currency:    topBidder.Currency,
+			BidCount:    topBidder.BidCount,
+			TotalAmount: money.Amount(topBidder.TotalAmount),
+		})
+	}
+
+	ru.cache.set(key, topBidderOutputs)
+	return topBidderOutputs, nil
+}
+
+// TopSellers ranks the sellers by the amount their auctions closed in the period sold
+// for, then by how many they sold.
+func (ru *ReportUseCase) TopSellers(
+	ctx context.Context, reportInput ReportInputDTO) ([]TopSellerOutputDTO, *internal_error.InternalError) {
+	from, to, limit, err := ru.resolveInput(reportInput)
+	if err != nil {
+		return nil, err
+	}
+
+	key := cacheKey("top_sellers", from, to, limit)
+	if cached, ok := ru.cache.get(key); ok {
+		return cached.([]TopSellerOutputDTO), nil
+	}
+
+	topSellers, err := ru.auctionRepository.AggregateTopSellers(ctx, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	topSellerOutputs := make([]TopSellerOutputDTO, 0, len(topSellers))
+	for _, topSeller := range topSellers {
+		topSellerOutputs = append(topSellerOutputs, TopSellerOutputDTO{
+			SellerId:     topSeller.SellerId,
+			SellerName:   topSeller.SellerName,
+			Currency:     topSeller.Currency,
+			AuctionsSold: topSeller.AuctionsSold,
+			TotalSold:    money.Amount(topSeller.TotalSold),
+		})
+	}
+
+	ru.cache.set(key, topSellerOutputs)
+	return topSellerOutputs, nil
+}
+
+// resolveInput fills in the defaults of reportInput. The default end is now rounded up to
+// the next minute, so requests without a to share a cache entry within that minute.
+func (ru *ReportUseCase) resolveInput(
+	reportInput ReportInputDTO) (time.Time, time.Time, int, *internal_error.InternalError) {
+	to := reportInput.To
+	if to.IsZero() {
+		to = ru.clock.Now().Truncate(time.Minute).Add(time.Minute)
+	}
+
+	from := reportInput.From
+	if from.IsZero() {
+		from = to.Add(-defaultReportPeriod)
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, 0, internal_error.NewValidationError("Invalid report period",
+			internal_error.FieldError{Field: "from", Message: "Must not be after to"})
+	}
+
+	limit := reportInput.Limit
+	if limit <= 0 {
+		limit = defaultReportLimit
+	}
+	if limit > maxReportLimit {
+		limit = maxReportLimit
+	}
+
+	return from, to, limit, nil
+}
+
+func cacheKey(report string, from, to time.Time, limit int) string {
+	return fmt.Sprintf("%s:%d:%d:%d", report, from.Unix(), to.Unix(), limit)
+}
@@ -0,0 +1,30 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// ErrInvalidCredentials is returned for every rejected login alike, so it doesn't tell
+// which users exist.
+var ErrInvalidCredentials = internal_error.NewUnauthorizedError("Invalid credentials")
+
+// Authenticate returns the user identified by userId when password is theirs. Unknown and
+// deleted users, and users without a password, fail with ErrInvalidCredentials.
+func (u *UserUseCase) Authenticate(
+	ctx context.Context, userId, password string) (*UserOutputDTO, *internal_error.InternalError) {
+	userEntity, err := u.UserRepository.FindUserById(ctx, userId)
+	if err != nil {
+		if err.Err == internal_error.NotFound {
+			return nil, ErrInvalidCredentials.Wrap(err)
+		}
+
+		return nil, err
+	}
+
+	if userEntity.IsDeleted() || !userEntity.CheckPassword(password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return toUserOutputDTO(userEntity), nil
+}
@@ -0,0 +1,29 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+type UserInputDTO struct {
+	Name  string `json:"name" binding:"required,min=1"`
+	Email string `json:"email" binding:"required,email"`
+
+	// Password is what the user logs in with, see user_entity.MinPasswordLength
+	Password string `json:"password" binding:"required"`
+}
+
+func (u *UserUseCase) CreateUser(
+	ctx context.Context, userInput UserInputDTO) (*UserOutputDTO, *internal_error.InternalError) {
+	userEntity, err := user_entity.CreateUser(userInput.Name, userInput.Email, userInput.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.UserRepository.CreateUser(ctx, userEntity); err != nil {
+		return nil, err
+	}
+
+	return toUserOutputDTO(userEntity), nil
+}
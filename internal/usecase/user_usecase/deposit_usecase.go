@@ -0,0 +1,40 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+)
+
+// DepositInputDTO tops up a wallet. The amount is a decimal such as 10.50 in JSON, kept
+// in cents.
+type DepositInputDTO struct {
+	Amount money.Amount `json:"amount" binding:"gt=0"`
+}
+
+type BalanceOutputDTO struct {
+	UserId  string       `json:"user_id"`
+	Balance money.Amount `json:"balance"`
+}
+
+// Deposit credits amount to the balance of the user and returns the new balance.
+func (u *UserUseCase) Deposit(
+	ctx context.Context, userId string, depositInput DepositInputDTO) (*BalanceOutputDTO, *internal_error.InternalError) {
+	if depositInput.Amount <= 0 {
+		return nil, internal_error.NewBadRequestError("Deposit amount must be greater than 0")
+	}
+
+	if err := u.UserRepository.CreditBalance(ctx, userId, depositInput.Amount.Cents()); err != nil {
+		return nil, err
+	}
+
+	userEntity, err := u.UserRepository.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BalanceOutputDTO{
+		UserId:  userEntity.Id,
+		Balance: money.Amount(userEntity.Balance),
+	}, nil
+}
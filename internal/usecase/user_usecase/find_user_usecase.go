@@ -2,29 +2,67 @@ package user_usecase
 
 import (
 	"context"
+	"fullcycle-auction_go/internal/entity/bid_entity"
 	"fullcycle-auction_go/internal/entity/user_entity"
 	"fullcycle-auction_go/internal/internal_error"
 )
 
-func NewUserUseCase(userRepository user_entity.UserRepositoryInterface) UserUseCaseInterface {
+func NewUserUseCase(
+	userRepository user_entity.UserRepositoryInterface, bidRepository bid_entity.BidEntityRepository) UserUseCaseInterface {
 	return &UserUseCase{
 		userRepository,
+		bidRepository,
 	}
 }
 
 type UserUseCase struct {
 	UserRepository user_entity.UserRepositoryInterface
+
+	// BidRepository is only read by GetUserStats
+	BidRepository bid_entity.BidEntityRepository
 }
 
 type UserOutputDTO struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+
+	// AverageRating is the average score the user got as a seller, null before the first
+	// of the RatingCount ratings
+	AverageRating *float64 `json:"average_rating"`
+	RatingCount   int64    `json:"rating_count"`
+
+	// Deleted is set for users who deleted their account, who are still returned so
+	// past winners and sellers can be shown
+	Deleted bool `json:"deleted,omitempty"`
 }
 
 type UserUseCaseInterface interface {
+	CreateUser(
+		ctx context.Context,
+		userInput UserInputDTO) (*UserOutputDTO, *internal_error.InternalError)
+
 	FindUserById(
 		ctx context.Context,
 		id string) (*UserOutputDTO, *internal_error.InternalError)
+
+	Authenticate(
+		ctx context.Context,
+		userId, password string) (*UserOutputDTO, *internal_error.InternalError)
+
+	Deposit(
+		ctx context.Context,
+		userId string,
+		depositInput DepositInputDTO) (*BalanceOutputDTO, *internal_error.InternalError)
+
+	UpdateUser(
+		ctx context.Context,
+		userId string,
+		updateInput UserUpdateInputDTO) (*UserOutputDTO, *internal_error.InternalError)
+
+	DeleteUser(ctx context.Context, userId string) *internal_error.InternalError
+
+	GetUserStats(ctx context.Context, userId string) (*UserStatsOutputDTO, *internal_error.InternalError)
 }
 
 func (u *UserUseCase) FindUserById(
@@ -34,8 +72,20 @@ func (u *UserUseCase) FindUserById(
 		return nil, err
 	}
 
-	return &UserOutputDTO{
-		Id:   userEntity.Id,
-		Name: userEntity.Name,
-	}, nil
+	return toUserOutputDTO(userEntity), nil
+}
+
+func toUserOutputDTO(userEntity *user_entity.User) *UserOutputDTO {
+	output := &UserOutputDTO{
+		Id:          userEntity.Id,
+		Name:        userEntity.Name,
+		Email:       userEntity.Email,
+		RatingCount: userEntity.RatingCount,
+		Deleted:     userEntity.IsDeleted(),
+	}
+	if average, ok := userEntity.AverageRating(); ok {
+		output.AverageRating = &average
+	}
+
+	return output
 }
@@ -0,0 +1,43 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/entity/user_entity"
+	"fullcycle-auction_go/internal/internal_error"
+)
+
+// UserUpdateInputDTO lists the profile fields a user can change. Omitted fields keep
+// their current value.
+type UserUpdateInputDTO struct {
+	Name  *string `json:"name" binding:"omitempty,min=1"`
+	Email *string `json:"email" binding:"omitempty,email"`
+}
+
+// UpdateUser changes the name or email of a user that isn't deleted. A new email is
+// checked for uniqueness again, returning a conflict error when it is already taken.
+func (u *UserUseCase) UpdateUser(
+	ctx context.Context,
+	userId string,
+	updateInput UserUpdateInputDTO) (*UserOutputDTO, *internal_error.InternalError) {
+	update := user_entity.UserUpdate{
+		Name:  updateInput.Name,
+		Email: updateInput.Email,
+	}.Normalize()
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	userEntity, err := u.UserRepository.UpdateUser(ctx, userId, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserOutputDTO(userEntity), nil
+}
+
+// DeleteUser soft deletes the user. Their auctions and bids are kept, but they can no
+// longer bid or create auctions.
+func (u *UserUseCase) DeleteUser(ctx context.Context, userId string) *internal_error.InternalError {
+	return u.UserRepository.SoftDeleteUser(ctx, userId)
+}
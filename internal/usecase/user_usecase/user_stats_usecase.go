@@ -0,0 +1,39 @@
+package user_usecase
+
+import (
+	"context"
+	"fullcycle-auction_go/internal/internal_error"
+	"fullcycle-auction_go/internal/money"
+)
+
+// UserStatsOutputDTO sums up the participation of a user in the auctions, see
+// bid_entity.UserBidStats. TotalSpent adds up the winning bids of the auctions won.
+type UserStatsOutputDTO struct {
+	UserId        string       `json:"user_id"`
+	AuctionsBidOn int64        `json:"auctions_bid_on"`
+	BidCount      int64        `json:"bid_count"`
+	AuctionsWon   int64        `json:"auctions_won"`
+	TotalSpent    money.Amount `json:"total_spent"`
+}
+
+// GetUserStats returns the bid statistics of the user, all zeros when they never bid.
+// Unknown users are rejected with a not found error.
+func (u *UserUseCase) GetUserStats(
+	ctx context.Context, userId string) (*UserStatsOutputDTO, *internal_error.InternalError) {
+	if _, err := u.UserRepository.FindUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	stats, err := u.BidRepository.AggregateUserStats(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserStatsOutputDTO{
+		UserId:        userId,
+		AuctionsBidOn: stats.AuctionsBidOn,
+		BidCount:      stats.BidCount,
+		AuctionsWon:   stats.AuctionsWon,
+		TotalSpent:    money.Amount(stats.TotalSpent),
+	}, nil
+}